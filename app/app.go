@@ -19,6 +19,8 @@ import (
 
 	"github.com/jeremyhahn/go-cropdroid/config"
 	gormstore "github.com/jeremyhahn/go-cropdroid/datastore/gorm"
+	pebbleds "github.com/jeremyhahn/go-cropdroid/datastore/pebble"
+	redisstore "github.com/jeremyhahn/go-cropdroid/datastore/redis"
 	"github.com/jeremyhahn/go-cropdroid/util"
 	"github.com/jeremyhahn/go-trusted-platform/pki/ca"
 	"github.com/jeremyhahn/go-trusted-platform/pki/tpm2"
@@ -48,6 +50,7 @@ type App struct {
 	DebugFlag               bool                        `yaml:"debug" json:"debug" mapstructure:"debug"`
 	DataDir                 string                      `yaml:"data-dir" json:"data_dir" mapstructure:"data-dir"`
 	DataStoreEngine         string                      `yaml:"datastore" json:"datastore" mapstructure:"datastore"`
+	DeviceDataStore         string                      `yaml:"data-store" json:"data_store" mapstructure:"data-store"`
 	DefaultRole             string                      `yaml:"default-role" json:"default_role" mapstructure:"default-role"`
 	DefaultPermission       string                      `yaml:"default-permission" json:"default_permission" mapstructure:"default-permission"`
 	DefaultConsistencyLevel int                         `yaml:"default-consistency-level" json:"default_consistency_level" mapstructure:"default-onsistency-level"`
@@ -67,12 +70,19 @@ type App struct {
 	ServerLicense           *config.ServerLicense       `yaml:"-" json:"-" mapstructure:"-"`
 	Location                *time.Location              `yaml:"-" json:"-" mapstructure:"-"`
 	LogDir                  string                      `yaml:"log-dir" json:"log_dir" mapstructure:"log-dir"`
+	LockoutDelay            int                         `yaml:"lockout-delay" json:"lockout_delay" mapstructure:"lockout-delay"`
 	LogFile                 string                      `yaml:"log-file" json:"log_file" mapstructure:"log-file"`
 	Logger                  *logging.Logger             `yaml:"-" json:"-" mapstructure:"-"`
+	MaxFailedLogins         int                         `yaml:"max-failed-logins" json:"max_failed_logins" mapstructure:"max-failed-logins"`
 	Mode                    string                      `yaml:"mode" json:"mode" mapstructure:"mode"`
 	Name                    string                      `yaml:"-" json:"-" mapstructure:"-"`
 	NodeID                  uint64                      `yaml:"node-id" json:"node_id" mapstructure:"node-id"`
+	OIDC                    *config.OIDC                `yaml:"oidc" json:"oidc" mapstructure:"oidc"`
+	PebbleInitParams        *pebbleds.PebbleInitParams  `yaml:"-" json:"-" mapstructure:"-"`
 	PasswordHasherParams    *util.PasswordHasherParams  `yaml:"argon2" json:"argon2" mapstructure:"argon2"`
+	PasswordPolicy          *util.PasswordPolicy        `yaml:"password-policy" json:"password_policy" mapstructure:"password-policy"`
+	RateLimit               bool                        `yaml:"rate-limit" json:"rate_limit" mapstructure:"rate-limit"`
+	RedisInitParams         *redisstore.RedisInitParams `yaml:"-" json:"-" mapstructure:"-"`
 	RedirectHttpToHttps     bool                        `yaml:"redirect-http-https" json:"redirect_http_https" mapstructure:"redirect-http-https"`
 	ShutdownChan            chan bool                   `yaml:"-" json:"-" mapstructure:"-"`
 	Smtp                    *config.SmtpStruct          `yaml:"smtp" json:"smtp" mapstructure:"smtp"`
@@ -176,6 +186,22 @@ func (app *App) initConfig() {
 		DBName:            app.Name,
 		Location:          app.Location}
 
+	app.DeviceDataStore = viper.GetString("data-store")
+	app.RedisInitParams = &redisstore.RedisInitParams{
+		Address:             viper.GetString("redis-address"),
+		Password:            viper.GetString("redis-password"),
+		Database:            viper.GetInt("redis-db"),
+		TLS:                 viper.GetBool("redis-tls"),
+		TLSSkipVerify:       viper.GetBool("redis-tls-skip-verify"),
+		CACert:              viper.GetString("redis-ca-cert"),
+		Retention:           time.Duration(viper.GetInt("redis-retention")) * 24 * time.Hour,
+		DownsampleRetention: time.Duration(viper.GetInt("redis-downsample-retention")) * 24 * time.Hour,
+		DownsampleBucket:    time.Duration(viper.GetInt("redis-downsample-bucket")) * time.Minute}
+	app.PebbleInitParams = &pebbleds.PebbleInitParams{
+		Dir:           fmt.Sprintf("%s/timeseries", viper.GetString("data-dir")),
+		BlockDuration: time.Duration(viper.GetInt("pebble-block-duration")) * time.Minute,
+		FlushInterval: time.Duration(viper.GetInt("pebble-flush-interval")) * time.Second}
+
 	configTypeSupported := false
 	for _, t := range supportedDatastores {
 		if app.DataStoreEngine == t {
@@ -196,6 +222,10 @@ func (app *App) initConfig() {
 			KeyLength:   32}
 	}
 
+	if viper.Get("password-policy") == nil {
+		app.PasswordPolicy = util.NewPasswordPolicy()
+	}
+
 	yamlConfig, err := yaml.Marshal(app)
 	if err != nil {
 		app.Logger.Fatalf("%s", yamlConfig)
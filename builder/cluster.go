@@ -15,6 +15,7 @@ import (
 	"github.com/jeremyhahn/go-cropdroid/datastore"
 
 	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	gormds "github.com/jeremyhahn/go-cropdroid/datastore/gorm"
 	"github.com/jeremyhahn/go-cropdroid/datastore/raft"
 	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
@@ -80,7 +81,8 @@ func (builder *ClusterConfigBuilder) Build() (
 
 	// Build and add event log service to registry
 	raftParams := builder.raftNode.GetParams()
-	systemEventLogService := service.NewEventLogService(builder.app, systemEventLogDAO, raftParams.RaftOptions.SystemClusterID)
+	systemEventLogService := service.NewEventLogService(builder.app, systemEventLogDAO,
+		builder.datastoreRegistry.GetEventLogArchiveDAO(), raftParams.RaftOptions.SystemClusterID)
 	builder.serviceRegistry.AddEventLogService(systemEventLogService)
 
 	var serverConfig = config.NewServer()
@@ -104,6 +106,10 @@ func (builder *ClusterConfigBuilder) Build() (
 			builder.app.Logger.Fatal(err)
 		}
 
+		if _, err := builder.serviceRegistry.GetLicenseService().Initialize(); err != nil {
+			builder.app.Logger.Errorf("Error issuing server license: %s", err)
+		}
+
 		serverConfig.SetID(builder.params.ClusterID)
 		if serverErr := serverDAO.Save(serverConfig); serverErr != nil {
 			builder.app.Logger.Fatal(serverErr)
@@ -135,7 +141,8 @@ func (builder *ClusterConfigBuilder) Build() (
 		builder.mapperRegistry.GetUserMapper(),
 		//		builder.params.GetFarmProvisionerChan(),
 		//		builder.params.GetFarmDeprovisionerChan(),
-		configInitializer)
+		configInitializer,
+		builder.serviceRegistry.GetLicenseService())
 	builder.serviceRegistry.SetFarmProvisioner(farmProvisioner)
 
 	// Load all farms that belong to an organization
@@ -205,7 +212,8 @@ func (builder *ClusterConfigBuilder) Build() (
 	organizationService := service.NewOrganizationService(
 		builder.app.Logger, builder.app.IdGenerator,
 		builder.datastoreRegistry.GetOrganizationDAO(),
-		builder.mapperRegistry.GetUserMapper())
+		builder.mapperRegistry.GetUserMapper(),
+		builder.serviceRegistry)
 	builder.serviceRegistry.SetOrganizationService(organizationService)
 
 	// Create the application RSA keypair
@@ -249,7 +257,8 @@ func (builder *ClusterConfigBuilder) createAndRunFarmCluster(farmFactory service
 		FarmErrorChan:         make(chan common.FarmError, common.BUFFERED_CHANNEL_SIZE),
 		FarmNotifyChan:        make(chan common.FarmNotification, common.BUFFERED_CHANNEL_SIZE),
 		DeviceStateChangeChan: make(chan common.DeviceStateChange, common.BUFFERED_CHANNEL_SIZE),
-		DeviceStateDeltaChan:  make(chan map[string]state.DeviceStateDeltaMap, common.BUFFERED_CHANNEL_SIZE)}
+		DeviceStateDeltaChan:  make(chan map[string]state.DeviceStateDeltaMap, common.BUFFERED_CHANNEL_SIZE),
+		AlarmChan:             make(chan *entity.Alarm, common.BUFFERED_CHANNEL_SIZE)}
 
 	farmStateStore := builder.createFarmStateStore(stateStoreType, farmID, farmChannels.FarmStateChangeChan)
 	farmConfigDAO := builder.createFarmConfigDAO(configStoreType)
@@ -275,7 +284,7 @@ func (builder *ClusterConfigBuilder) createAndRunFarmCluster(farmFactory service
 
 	farmEventLogService := builder.serviceRegistry.GetEventLogService(farmID)
 	startupEventLogMsg := fmt.Sprintf("Starting farm on node %d", builder.raftNode.GetParams().NodeID)
-	farmEventLogService.Create(0, common.CONTROLLER_TYPE_SERVER, "System", startupEventLogMsg)
+	farmEventLogService.Create(0, common.CONTROLLER_TYPE_SERVER, common.EVENT_TYPE_SYSTEM, startupEventLogMsg)
 }
 
 func (builder *ClusterConfigBuilder) createFarmStateStore(storeType int,
@@ -335,7 +344,11 @@ func (builder *ClusterConfigBuilder) createDeviceDataStore(storeType int) datast
 	case datastore.RAFT_STORE:
 		deviceDataStore = raft.NewRaftDeviceDataDAO(builder.app.Logger, builder.raftNode, 0)
 	case datastore.REDIS_TS:
-		deviceDataStore = redis.NewRedisDataStore(":6379", "")
+		redisDataStore, err := redis.NewRedisDataStore(builder.app.Logger, builder.app.RedisInitParams)
+		if err != nil {
+			builder.app.Logger.Fatal(err)
+		}
+		deviceDataStore = redisDataStore
 	}
 	return deviceDataStore
 }
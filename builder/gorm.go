@@ -8,10 +8,12 @@ import (
 	"github.com/jeremyhahn/go-cropdroid/config"
 	"github.com/jeremyhahn/go-cropdroid/datastore"
 	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
 
 	gormds "github.com/jeremyhahn/go-cropdroid/datastore/gorm"
+	"github.com/jeremyhahn/go-cropdroid/datastore/pebble"
 	"github.com/jeremyhahn/go-cropdroid/datastore/redis"
 	"github.com/jeremyhahn/go-cropdroid/mapper"
 	"github.com/jeremyhahn/go-cropdroid/provisioner"
@@ -48,8 +50,18 @@ func NewGormConfigBuilder(app *app.App) *GormConfigBuilder {
 		time.Duration(app.StateTick))
 
 	var deviceDatastore datastore.DeviceDataStore
-	if app.DataStoreEngine == "redis" {
-		deviceDatastore = redis.NewRedisDataStore(":6379", "")
+	if app.DeviceDataStore == "redis" {
+		redisDataStore, err := redis.NewRedisDataStore(app.Logger, app.RedisInitParams)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+		deviceDatastore = redisDataStore
+	} else if app.DeviceDataStore == "pebble" {
+		pebbleDataStore, err := pebble.NewPebbleDataStore(app.Logger, app.PebbleInitParams)
+		if err != nil {
+			app.Logger.Fatal(err)
+		}
+		deviceDatastore = pebbleDataStore
 	} else {
 		deviceDatastore = gormds.NewGormDeviceDataStore(app.Logger, db,
 			app.GORMInitParams.Engine, app.Location)
@@ -76,7 +88,8 @@ func (builder *GormConfigBuilder) Build() (mapper.MapperRegistry,
 		builder.datastoreRegistry, mapperRegistry)
 
 	eventLogDAO := gormds.NewEventLogDAO(builder.app.Logger, builder.db, 0)
-	eventLogService := service.NewEventLogService(builder.app, eventLogDAO, 0)
+	eventLogService := service.NewEventLogService(builder.app, eventLogDAO,
+		builder.datastoreRegistry.GetEventLogArchiveDAO(), 0)
 	builder.serviceRegistry.AddEventLogService(eventLogService)
 
 	// serverConfig := config.NewServer()
@@ -109,7 +122,8 @@ func (builder *GormConfigBuilder) Build() (mapper.MapperRegistry,
 	farmProvisioner := provisioner.NewGormFarmProvisioner(
 		builder.app.Logger, builder.db, builder.app.Location, builder.datastoreRegistry.NewFarmDAO(),
 		builder.datastoreRegistry.GetPermissionDAO(), farmProvisionerChan, farmDeprovisionerChan,
-		mapperRegistry.GetUserMapper(), configInitializer)
+		mapperRegistry.GetUserMapper(), configInitializer,
+		builder.serviceRegistry.GetLicenseService())
 	builder.serviceRegistry.SetFarmProvisioner(farmProvisioner)
 
 	// Initialize the database with a default farm
@@ -178,7 +192,8 @@ func (builder *GormConfigBuilder) Build() (mapper.MapperRegistry,
 	organizationService := service.NewOrganizationService(
 		builder.app.Logger, builder.idGenerator,
 		builder.datastoreRegistry.GetOrganizationDAO(),
-		mapperRegistry.GetUserMapper())
+		mapperRegistry.GetUserMapper(),
+		builder.serviceRegistry)
 	builder.serviceRegistry.SetOrganizationService(organizationService)
 
 	restServiceRegistry := rest.NewRestServiceRegistry(
@@ -190,6 +205,14 @@ func (builder *GormConfigBuilder) Build() (mapper.MapperRegistry,
 	return mapperRegistry, builder.serviceRegistry, restServiceRegistry, farmTickerProvisionerChan, err
 }
 
+// Closes the device data store, writing any buffered samples to disk
+func (builder *GormConfigBuilder) Close() error {
+	if store, ok := builder.deviceDataStore.(datastore.TimeSeriesDataStore); ok {
+		return store.Close()
+	}
+	return nil
+}
+
 func (builder *GormConfigBuilder) createAndRunFarm(farmDAO dao.FarmDAO,
 	farmFactory service.FarmFactory, farmConfig config.Farm) {
 
@@ -205,7 +228,8 @@ func (builder *GormConfigBuilder) createAndRunFarm(farmDAO dao.FarmDAO,
 		//MetricChangedChan:     make(chan common.MetricValueChanged, common.BUFFERED_CHANNEL_SIZE),
 		//SwitchChangedChan:     make(chan common.SwitchValueChanged, common.BUFFERED_CHANNEL_SIZE),
 		DeviceStateChangeChan: make(chan common.DeviceStateChange, common.BUFFERED_CHANNEL_SIZE),
-		DeviceStateDeltaChan:  make(chan map[string]state.DeviceStateDeltaMap, common.BUFFERED_CHANNEL_SIZE)}
+		DeviceStateDeltaChan:  make(chan map[string]state.DeviceStateDeltaMap, common.BUFFERED_CHANNEL_SIZE),
+		AlarmChan:             make(chan *entity.Alarm, common.BUFFERED_CHANNEL_SIZE)}
 
 	farmEventLogDAO := gormds.NewEventLogDAO(builder.app.Logger, builder.db, int(farmID))
 	farmService, err := farmFactory.BuildService(
@@ -227,7 +251,7 @@ func (builder *GormConfigBuilder) createAndRunFarm(farmDAO dao.FarmDAO,
 	builder.app.Logger.Debugf("Polling interval: %d", builder.app.Interval)
 
 	farmEventLogService := builder.serviceRegistry.GetEventLogService(farmID)
-	farmEventLogService.Create(farmID, common.CONTROLLER_TYPE_SERVER, "Startup", "Starting farm service")
+	farmEventLogService.Create(farmID, common.CONTROLLER_TYPE_SERVER, common.EVENT_TYPE_STARTUP, "Starting farm service")
 }
 
 func (builder *GormConfigBuilder) initDatabase() {
@@ -257,4 +281,8 @@ func (builder *GormConfigBuilder) initDatabase() {
 	if err != nil {
 		builder.app.Logger.Fatal(err)
 	}
+
+	if _, err := builder.serviceRegistry.GetLicenseService().Initialize(); err != nil {
+		builder.app.Logger.Errorf("Error issuing server license: %s", err)
+	}
 }
@@ -55,6 +55,7 @@ type RaftNode interface {
 	ReadLocal(clusterID uint64, query interface{}) (interface{}, error)
 	RequestLeaderTransfer(clusterID uint64, targetNodeID uint64) error
 	SyncPropose(clusterID uint64, cmd []byte) error
+	SyncProposeResult(clusterID uint64, cmd []byte) (sm.Result, error)
 	SyncRead(clusterID uint64, query interface{}) (interface{}, error)
 	WaitForClusterReady(clusterID uint64) bool
 }
@@ -573,23 +574,30 @@ func (r *Raft) GetNodeHost() *dragonboat.NodeHost {
 }
 
 func (r *Raft) SyncPropose(clusterID uint64, cmd []byte) error {
+	_, err := r.SyncProposeResult(clusterID, cmd)
+	return err
+}
+
+// Proposes the command and returns the result of applying it to the
+// cluster's state machine
+func (r *Raft) SyncProposeResult(clusterID uint64, cmd []byte) (sm.Result, error) {
 	r.sessionMutex.RLock()
 	session, ok := r.session[clusterID]
 	r.sessionMutex.RUnlock()
 	if !ok {
-		return common.ErrClusterNotFound
+		return sm.Result{}, common.ErrClusterNotFound
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
 	result, err := r.nodeHost.SyncPropose(ctx, session, cmd)
 	cancel()
 	if err != nil {
 		r.params.Logger.Errorf("[Raft.SyncPropose] Error: %s", err)
-		return err
+		return sm.Result{}, err
 	}
 	//session.ProposalCompleted()
 	r.params.Logger.Debugf("[Raft.SyncPropose] Raft confirmation: clusterID=%d, nodeID=%d, message=%s, result=%+v",
 		clusterID, r.config.NodeID, string(cmd), result)
-	return nil
+	return result, nil
 }
 
 func (r *Raft) SyncRead(clusterID uint64, query interface{}) (interface{}, error) {
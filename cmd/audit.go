@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	gormds "github.com/jeremyhahn/go-cropdroid/datastore/gorm"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/spf13/cobra"
+)
+
+var AuditExport string
+var AuditVerify bool
+
+// auditReplica is a read-only copy of the Raft audit log
+type auditReplica interface {
+	dao.AuditDAO
+	Close() error
+}
+
+func init() {
+
+	auditCmd.PersistentFlags().StringVarP(&AuditExport, "export", "e", "", "Export the audit log as JSON lines to the specified file (- for stdout)")
+	auditCmd.PersistentFlags().BoolVarP(&AuditVerify, "verify", "v", false, "Verify the audit log hash chain and signatures")
+
+	rootCmd.AddCommand(auditCmd)
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit log",
+	Long: `Exports and verifies the tamper-evident audit log of configuration and
+	       control actions. Each entry is chained to the previous entry's hash
+		   and signed with the Certificate Authority key. With the raft
+		   datastore, the audit log replica in the data directory of this
+		   node is read; stop the node first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var auditDAO dao.AuditDAO
+		if DataStoreEngine == "raft" {
+			replica, err := openRaftAuditReplica()
+			if err != nil {
+				App.Logger.Fatal(err)
+			}
+			defer replica.Close()
+			auditDAO = replica
+		} else {
+			gormdb := gormds.NewGormDB(App.Logger, App.GORMInitParams)
+			auditDAO = gormds.NewAuditDAO(App.Logger, gormdb.Connect(false))
+		}
+		auditService := service.NewAuditService(App.Logger, auditDAO, App.CA)
+
+		// --export file
+		if AuditExport != "" {
+			var writer io.Writer = os.Stdout
+			if AuditExport != "-" {
+				file, err := os.Create(AuditExport)
+				if err != nil {
+					App.Logger.Fatal(err)
+				}
+				defer file.Close()
+				writer = file
+			}
+			count, err := auditService.Export(writer)
+			if err != nil {
+				App.Logger.Fatal(err)
+			}
+			if AuditExport != "-" {
+				fmt.Printf("Exported %d audit entries to %s\n", count, AuditExport)
+			}
+		}
+
+		// --verify
+		if AuditVerify {
+			count, err := auditService.Verify()
+			if err != nil {
+				fmt.Printf("Audit log verification failed after %d entries: %s\n", count, err)
+				os.Exit(1)
+			}
+			fmt.Printf("Verified %d audit entries\n", count)
+		}
+	},
+}
@@ -0,0 +1,15 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package cmd
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft"
+	"github.com/jeremyhahn/go-cropdroid/util"
+)
+
+// Opens this node's replica of the Raft audit log
+func openRaftAuditReplica() (auditReplica, error) {
+	return raft.OpenRaftAuditReplica(App.Logger,
+		util.NewIdGenerator(DataStoreEngine), App.DataDir, ClusterID, App.NodeID)
+}
@@ -0,0 +1,13 @@
+//go:build !cluster || !pebble
+// +build !cluster !pebble
+
+package cmd
+
+import (
+	"errors"
+)
+
+// The Raft audit log is only available in cluster builds
+func openRaftAuditReplica() (auditReplica, error) {
+	return nil, errors.New("the raft audit log requires a cluster build")
+}
@@ -225,7 +225,7 @@ var clusterCmd = &cobra.Command{
 		go webserver.Run()
 		go webserver.RunClusterProvisionerConsumer()
 
-		serviceRegistry.GetEventLogService(ClusterID).Create(ClusterID, common.CONTROLLER_TYPE_SERVER, "System", "Startup")
+		serviceRegistry.GetEventLogService(ClusterID).Create(ClusterID, common.CONTROLLER_TYPE_SERVER, common.EVENT_TYPE_SYSTEM, "Startup")
 
 		signal.Notify(sigChan, syscall.SIGINT) // catch CTRL+C // syscall.SIGTERM, syscall.SIGHUP)
 
@@ -233,7 +233,7 @@ var clusterCmd = &cobra.Command{
 		close(App.ShutdownChan)
 		close(sigChan)
 
-		serviceRegistry.GetEventLogService(ClusterID).Create(ClusterID, common.CONTROLLER_TYPE_SERVER, "System", "Shutdown")
+		serviceRegistry.GetEventLogService(ClusterID).Create(ClusterID, common.CONTROLLER_TYPE_SERVER, common.EVENT_TYPE_SYSTEM, "Shutdown")
 
 		webserver.Shutdown()
 
@@ -111,15 +111,30 @@ func init() {
 	rootCmd.PersistentFlags().IntVarP(&App.StateTick, "state-tick", "", 3600, "How often to check farm store for expired entries")
 
 	// Data store options
-	rootCmd.PersistentFlags().StringVarP(&DeviceDataStore, "data-store", "", "gorm", "Where to store historical device data [ gorm | redis ]")
+	rootCmd.PersistentFlags().StringVarP(&DeviceDataStore, "data-store", "", "gorm", "Where to store historical device data [ gorm | redis | pebble ]")
+	rootCmd.PersistentFlags().String("redis-address", "localhost:6379", "Redis time series server address (host:port)")
+	rootCmd.PersistentFlags().String("redis-password", "", "Redis password")
+	rootCmd.PersistentFlags().Int("redis-db", 0, "Redis database number")
+	rootCmd.PersistentFlags().Bool("redis-tls", false, "Encrypt the Redis connection using TLS")
+	rootCmd.PersistentFlags().Bool("redis-tls-skip-verify", false, "Skip verification of the Redis server TLS certificate")
+	rootCmd.PersistentFlags().String("redis-ca-cert", "", "TLS Certificate Authority used to verify the Redis server")
+	rootCmd.PersistentFlags().Int("redis-retention", 30, "How long to keep raw device samples in Redis (days). 0 = keep forever")
+	rootCmd.PersistentFlags().Int("redis-downsample-retention", 365, "How long to keep downsampled min/max/avg samples in Redis (days). 0 = keep forever")
+	rootCmd.PersistentFlags().Int("redis-downsample-bucket", 60, "Time bucket used to downsample raw samples in Redis (minutes)")
+	rootCmd.PersistentFlags().Int("pebble-block-duration", 60, "Time window covered by each compressed block in the embedded time series store (minutes)")
+	rootCmd.PersistentFlags().Int("pebble-flush-interval", 300, "How long to buffer samples in memory before writing them to the embedded time series store (seconds)")
 
 	// Web service options
 	rootCmd.PersistentFlags().IntVarP(&App.WebService.Port, "web-port", "", 8080, "Web service port number")
 	rootCmd.PersistentFlags().IntVarP(&App.WebService.TLSPort, "web-tls-port", "", 8443, "Web service TLS port number")
-	rootCmd.PersistentFlags().IntVarP(&App.WebService.JWTExpiration, "jwt-expiration", "", 525600, "JWT expiration (minutes). Default 1 year")
+	rootCmd.PersistentFlags().IntVarP(&App.WebService.JWTExpiration, "jwt-expiration", "", 15, "JWT access token expiration (minutes)")
+	rootCmd.PersistentFlags().IntVarP(&App.WebService.JWTRefreshExpiration, "jwt-refresh-expiration", "", 43200, "Refresh token expiration (minutes). Default 30 days")
 	rootCmd.PersistentFlags().StringVarP(&App.CertDir, "cert-dir", "", fmt.Sprintf("%s/db/certs", wd), "Directory where key files are stored")
 	rootCmd.PersistentFlags().BoolVarP(&App.RedirectHttpToHttps, "redirect-http-https", "", false, "Redirect HTTP to HTTPS")
 	rootCmd.PersistentFlags().BoolVarP(&App.EnableRegistrations, "enable-registrations", "", false, "Allows user account registrations via API")
+	rootCmd.PersistentFlags().IntVarP(&App.MaxFailedLogins, "max-failed-logins", "", 5, "Failed logins allowed before the account is locked. 0 = never lock accounts")
+	rootCmd.PersistentFlags().IntVarP(&App.LockoutDelay, "lockout-delay", "", 30, "How long an account is locked after too many failed logins (seconds). Doubles with each additional failure")
+	rootCmd.PersistentFlags().BoolVarP(&App.RateLimit, "rate-limit", "", true, "Throttle API requests per client IP, user and API key")
 
 	// Database options
 	rootCmd.PersistentFlags().BoolVarP(&DatabaseInit, "init", "", false, "Initialize an empty database with a default user and optional farm")
@@ -30,8 +30,9 @@ var standaloneCmd = &cobra.Command{
 
 		sigChan := make(chan os.Signal, 1)
 
+		configBuilder := builder.NewGormConfigBuilder(App)
 		serviceMapper, serviceRegistry, restServiceRegistry,
-			farmTickerProvisionerChan, err := builder.NewGormConfigBuilder(App).Build()
+			farmTickerProvisionerChan, err := configBuilder.Build()
 		if err != nil {
 			App.Logger.Fatal(err)
 		}
@@ -50,14 +51,26 @@ var standaloneCmd = &cobra.Command{
 		go webserver.Run()
 		go webserver.RunProvisionerConsumer()
 
-		serviceRegistry.GetEventLogService(0).Create(0, common.CONTROLLER_TYPE_SERVER, "System", "Startup")
+		serviceRegistry.GetEventLogService(0).Create(0, common.CONTROLLER_TYPE_SERVER, common.EVENT_TYPE_SYSTEM, "Startup")
 
-		signal.Notify(sigChan, syscall.SIGINT) // catch CTRL+C // syscall.SIGTERM, syscall.SIGHUP)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM) // catch CTRL+C and service stop
 
-		<-App.ShutdownChan
+		select {
+		case <-App.ShutdownChan:
+		case sig := <-sigChan:
+			App.Logger.Infof("Received %s signal, shutting down", sig)
+		}
+		signal.Stop(sigChan)
 		close(App.ShutdownChan)
 		close(sigChan)
 
-		serviceRegistry.GetEventLogService(0).Create(0, common.CONTROLLER_TYPE_SERVER, "System", "Shutdown")
+		serviceRegistry.GetEventLogService(0).Create(0, common.CONTROLLER_TYPE_SERVER, common.EVENT_TYPE_SYSTEM, "Shutdown")
+
+		webserver.Shutdown()
+
+		// Flush buffered device samples before exiting
+		if err := configBuilder.Close(); err != nil {
+			App.Logger.Error(err)
+		}
 	},
 }
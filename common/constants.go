@@ -35,9 +35,90 @@ const (
 	DATATYPE_INT    = 1
 	DATATYPE_STRING = 2
 
-	NOTIFICATION_PRIORITY_LOW  = 0
-	NOTIFICATION_PRIORITY_MED  = 1
-	NOTIFICATION_PRIORITY_HIGH = 2
+	NOTIFICATION_PRIORITY_LOW      = 0
+	NOTIFICATION_PRIORITY_MED      = 1
+	NOTIFICATION_PRIORITY_HIGH     = 2
+	NOTIFICATION_PRIORITY_CRITICAL = 3
+
+	// Event log event types. The values of the types that predate the
+	// enum are preserved so existing event log entries match filters.
+	EVENT_TYPE_ALARM          = "ALARM"
+	EVENT_TYPE_ANOMALY        = "ANOMALY"
+	EVENT_TYPE_CALIBRATION    = "CALIBRATION"
+	EVENT_TYPE_POLL           = "Poll"
+	EVENT_TYPE_REPORT         = "REPORT"
+	EVENT_TYPE_STARTUP        = "Startup"
+	EVENT_TYPE_SWITCH         = "Switch"
+	EVENT_TYPE_SYSTEM         = "System"
+	EVENT_TYPE_TIMER_SWITCH   = "TimerSwitch"
+	EVENT_TYPE_WEBSERVER      = "WebServer"
+	EVENT_TYPE_WORKFLOW       = "WORKFLOW"
+	EVENT_TYPE_WORKFLOW_ERROR = "WORKFLOW_ERROR"
+
+	EVENT_SOURCE_MANUAL    = "manual"
+	EVENT_SOURCE_SCHEDULE  = "schedule"
+	EVENT_SOURCE_CONDITION = "condition"
+	EVENT_SOURCE_ALGORITHM = "algorithm"
+	EVENT_SOURCE_WORKFLOW  = "workflow"
+	EVENT_SOURCE_SYSTEM    = "system"
+
+	AUDIT_ACTION_DEVICE_CONFIG     = "device.config"
+	AUDIT_ACTION_DEVICE_SETTING    = "device.setting"
+	AUDIT_ACTION_SWITCH            = "device.switch"
+	AUDIT_ACTION_TIMER_SWITCH      = "device.timer_switch"
+	AUDIT_ACTION_USER_DELETE       = "user.delete"
+	AUDIT_ACTION_PERMISSION_SET    = "permission.set"
+	AUDIT_ACTION_PERMISSION_DELETE = "permission.delete"
+	AUDIT_ACTION_APIKEY_CREATE     = "apikey.create"
+	AUDIT_ACTION_APIKEY_REVOKE     = "apikey.revoke"
+	AUDIT_ACTION_SESSION_REVOKE    = "session.revoke"
+	AUDIT_ACTION_MFA_ENABLE        = "mfa.enable"
+	AUDIT_ACTION_MFA_DISABLE       = "mfa.disable"
+	AUDIT_ACTION_MFA_RESET         = "mfa.reset"
+	AUDIT_ACTION_MFA_POLICY        = "mfa.policy"
+	AUDIT_ACTION_USER_DISABLE      = "user.disable"
+	AUDIT_ACTION_USER_ENABLE       = "user.enable"
+	AUDIT_ACTION_PASSWORD_RESET    = "user.password_reset"
+	AUDIT_ACTION_EMAIL_CHANGE      = "user.email_change"
+	AUDIT_ACTION_INVITATION_CREATE = "invitation.create"
+	AUDIT_ACTION_INVITATION_ACCEPT = "invitation.accept"
+	AUDIT_ACTION_INVITATION_REVOKE = "invitation.revoke"
+	AUDIT_ACTION_LICENSE_ISSUE     = "license.issue"
+
+	ANOMALY_TYPE_ZSCORE         = "zscore"
+	ANOMALY_TYPE_RATE_OF_CHANGE = "rate"
+	ANOMALY_TYPE_FLATLINE       = "flatline"
+	DEFAULT_ANOMALY_BACKOFF     = 60 // minutes
+
+	CALIBRATION_REMINDER_INTERVAL = 24 // hours
+
+	REPORT_PERIOD_DAILY  = "daily"
+	REPORT_PERIOD_WEEKLY = "weekly"
+	DEFAULT_REPORT_TIME  = "07:00"
+
+	EVENT_LOG_ARCHIVE_DIR       = "archive"
+	EVENT_LOG_ARCHIVE_EXTENSION = ".jsonl.gz"
+	EVENT_LOG_ARCHIVE_INTERVAL  = 24 // hours
+
+	NOTIFIER_TYPE_WEBHOOK = "webhook"
+	NOTIFIER_TYPE_SLACK   = "slack"
+	NOTIFIER_TYPE_NTFY    = "ntfy"
+	NOTIFIER_TYPE_GOTIFY  = "gotify"
+	NOTIFIER_TYPE_SMS     = "sms"
+	NOTIFIER_TIMEOUT      = 10 // seconds
+
+	NOTIFICATION_CHANNEL_EMAIL    = "email"
+	DEFAULT_ESCALATION_TIMEOUT    = 15 // minutes
+	NOTIFICATION_PENDING_INTERVAL = 30 // seconds
+
+	ALARM_STATE_ACTIVE              = "ACTIVE"
+	ALARM_STATE_ACKNOWLEDGED        = "ACKNOWLEDGED"
+	ALARM_STATE_CLEARED             = "CLEARED"
+	ALARM_STATE_SHELVED             = "SHELVED"
+	ALARM_CONDITION_LOW             = "LOW"
+	ALARM_CONDITION_HIGH            = "HIGH"
+	DEFAULT_ALARM_RENOTIFY_INTERVAL = 60 // minutes
+	DEFAULT_ALARM_SHELVE_DURATION   = 60 // minutes
 
 	CONTROLLER_TYPE_ROOM      = "room"
 	CONTROLLER_TYPE_DOSER     = "doser"
@@ -88,8 +169,53 @@ const (
 	ROLE_CULTIVATOR = "cultivator"
 	ROLE_ANALYST    = "analyst"
 
+	PERMISSION_FARM_READ      = "farm:read"      // view farm configuration, state, history and logs
+	PERMISSION_FARM_MANAGE    = "farm:manage"    // provision, deprovision and maintain farms
+	PERMISSION_DEVICE_SWITCH  = "device:switch"  // switch device channels on and off
+	PERMISSION_CONFIG_WRITE   = "config:write"   // edit devices, channels, metrics, conditions, schedules and workflows
+	PERMISSION_WORKFLOW_RUN   = "workflow:run"   // run workflows
+	PERMISSION_ALARM_MANAGE   = "alarm:manage"   // acknowledge and shelve alarms
+	PERMISSION_REPORT_SEND    = "report:send"    // send reports and notifications
+	PERMISSION_USER_MANAGE    = "user:manage"    // manage users, roles and organizations
+	PERMISSION_BILLING_MANAGE = "billing:manage" // manage payment methods and purchases
+	PERMISSION_PROFILE_MANAGE = "profile:manage" // manage the user's own inbox, notifications and preferences
+	PERMISSION_APIKEY_MANAGE  = "apikey:manage"  // create, list and revoke service account API keys
+	PERMISSION_SYSTEM_ADMIN   = "system:admin"   // view system configuration and manage the cluster
+
+	ROLE_SERVICE_ACCOUNT            = "service-account"      // role assigned to sessions authenticated by an API key
+	SERVICE_ACCOUNT_DOMAIN          = "serviceaccount.local" // email domain of service account session users
+	API_KEY_PREFIX                  = "cdk"                  // API key prefix: cdk_<key id>_<secret>
+	API_KEY_SECRET_LENGTH           = 32                     // bytes of randomness in an API key secret
+	API_KEY_LAST_USED_INTERVAL      = 60                     // seconds between last-used updates of an API key
+	API_KEY_SERVICE_ACCOUNT_PATTERN = `^[a-z0-9][a-z0-9._-]{0,62}$`
+
+	REFRESH_TOKEN_SECRET_LENGTH = 32 // bytes of randomness in a refresh token secret
+
+	INVITATION_SECRET_LENGTH = 32     // bytes of randomness in an invitation token secret
+	INVITATION_EXPIRATION    = 604800 // seconds an invitation link is valid (7 days)
+
+	LICENSE_TYPE_SERVER       = "server"       // limits the number of organizations
+	LICENSE_TYPE_ORGANIZATION = "organization" // limits an organization's farms and users
+	LICENSE_TYPE_FARM         = "farm"         // limits a farm's devices and users
+
+	TOTP_PERIOD                 = 30  // seconds each TOTP code is valid
+	TOTP_DIGITS                 = 6   // digits in a TOTP code
+	TOTP_SKEW                   = 1   // periods of clock drift tolerated either side of the current period
+	TOTP_SECRET_LENGTH          = 20  // bytes of randomness in a TOTP secret
+	MFA_RECOVERY_CODES          = 10  // number of single use recovery codes issued at enrollment
+	MFA_CHALLENGE_SECRET_LENGTH = 32  // bytes of randomness in an MFA challenge token
+	MFA_CHALLENGE_EXPIRATION    = 300 // seconds a user has to complete an MFA challenge
+	MFA_CHALLENGE_MAX_ATTEMPTS  = 5   // invalid codes allowed since the last successful verification before an MFA challenge is discarded
+
+	OIDC_DISCOVERY_PATH   = "/.well-known/openid-configuration"
+	OIDC_STATE_LENGTH     = 32  // bytes of randomness in OIDC state, nonce and PKCE verifier values
+	OIDC_STATE_EXPIRATION = 600 // seconds a user has to complete an OIDC login at the provider
+	OIDC_HTTP_TIMEOUT     = 10  // seconds to wait for the OIDC provider to respond
+	OIDC_BINDING_COOKIE   = "cropdroid_oidc"
+
 	AUTH_TYPE_LOCAL  = 0
 	AUTH_TYPE_GOOGLE = 1
+	AUTH_TYPE_OIDC   = 2
 
 	WORKFLOW_STATE_READY     = 0
 	WORKFLOW_STATE_EXECUTING = 1
@@ -99,6 +225,29 @@ const (
 	EMAIL_ACTIVATION   = "activation_email.html"
 	EMAIL_REGISTRATION = "registration_email.html"
 
+	EMAIL_TEMPLATE_ALARM          = "alarm.html"
+	EMAIL_TEMPLATE_SUMMARY        = "summary.html"
+	EMAIL_TEMPLATE_WORKFLOW       = "workflow.html"
+	EMAIL_TEMPLATE_PASSWORD_RESET = "password_reset.html"
+	EMAIL_TEMPLATE_INVITATION     = "invitation.html"
+	EMAIL_TEMPLATE_EMAIL_CHANGE   = "email_change.html"
+	EMAIL_CATEGORY_ALARM          = "alarm"
+	EMAIL_CATEGORY_SUMMARY        = "summary"
+	EMAIL_CATEGORY_WORKFLOW       = "workflow"
+
+	PASSWORD_RESET_EXPIRATION = 3600  // seconds a password reset link is valid
+	EMAIL_CHANGE_EXPIRATION   = 86400 // seconds an email change confirmation link is valid
+	LOCKOUT_MAX_DELAY         = 86400 // longest an account is locked after repeated failed logins (seconds)
+	INBOX_RETENTION           = 500   // most recent notifications kept in each user's inbox
+	AUDIT_APPEND_ATTEMPTS     = 5     // times an audit entry is re-chained after losing a race to append
+
+	SMTP_ENCRYPTION_NONE     = "none"
+	SMTP_ENCRYPTION_STARTTLS = "starttls"
+	SMTP_ENCRYPTION_TLS      = "tls"
+	SMTP_TIMEOUT             = 30 // seconds
+	MAILER_MAX_ATTEMPTS      = 5
+	MAILER_RETRY_BACKOFF     = 5 // seconds, doubled after each failed attempt
+
 	// Initializer / provisioner
 	DEFAULT_GALLONS = "50"
 
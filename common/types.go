@@ -14,6 +14,36 @@ const (
 
 var (
 	ErrClusterNotFound = errors.New("cluster not found")
+
+	// The catalog of permissions that may be granted to a role
+	PermissionCatalog = []string{
+		PERMISSION_FARM_READ,
+		PERMISSION_FARM_MANAGE,
+		PERMISSION_DEVICE_SWITCH,
+		PERMISSION_CONFIG_WRITE,
+		PERMISSION_WORKFLOW_RUN,
+		PERMISSION_ALARM_MANAGE,
+		PERMISSION_REPORT_SEND,
+		PERMISSION_USER_MANAGE,
+		PERMISSION_BILLING_MANAGE,
+		PERMISSION_PROFILE_MANAGE,
+		PERMISSION_APIKEY_MANAGE,
+		PERMISSION_SYSTEM_ADMIN}
+
+	// The permission sets granted to the built-in roles
+	DefaultRolePermissions = map[string][]string{
+		ROLE_ADMIN: PermissionCatalog,
+		ROLE_CULTIVATOR: {
+			PERMISSION_FARM_READ,
+			PERMISSION_DEVICE_SWITCH,
+			PERMISSION_CONFIG_WRITE,
+			PERMISSION_WORKFLOW_RUN,
+			PERMISSION_ALARM_MANAGE,
+			PERMISSION_REPORT_SEND,
+			PERMISSION_PROFILE_MANAGE},
+		ROLE_ANALYST: {
+			PERMISSION_FARM_READ,
+			PERMISSION_PROFILE_MANAGE}}
 )
 
 // type UserAccount interface {
@@ -62,6 +92,8 @@ var (
 type Mailer interface {
 	SetRecipient(recipient string)
 	Send(subject, message string) error
+	SendTo(recipient, subject, message string) error
+	SendTemplate(recipients []string, subject, templateName string, data interface{}) error
 	SendHtml(template, subject string, data interface{}) (bool, error)
 }
 
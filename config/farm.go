@@ -48,12 +48,27 @@ type CommonFarm interface {
 	RemoveWorkflow(workflow *WorkflowStruct) error
 	SetWorkflows(workflows []*WorkflowStruct)
 	SetWorkflow(workflow *WorkflowStruct)
+	SetNotifiers(notifiers []*NotifierStruct)
+	GetNotifiers() []*NotifierStruct
+	SetNotificationRules(rules []*NotificationRuleStruct)
+	GetNotificationRules() []*NotificationRuleStruct
+	SetQuietHours(start, end string)
+	GetQuietHours() (string, string)
+	SetEscalationTimeout(minutes int)
+	GetEscalationTimeout() int
+	SetAlarmRenotify(minutes int)
+	GetAlarmRenotify() int
+	SetReportSchedule(schedule, timeOfDay string)
+	GetReportSchedule() (string, string)
+	SetEventLogRetention(days int)
+	GetEventLogRetention() int
 	KeyValueEntity
 }
 
 type Farm interface {
 	ParseSettings() error
 	HydrateSettings() error
+	Redact() Farm
 	CommonFarm
 }
 
@@ -75,7 +90,27 @@ type FarmStruct struct {
 	Devices        []*DeviceStruct   `gorm:"foreignKey:FarmID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" yaml:"devices" json:"devices"`
 	Users          []*UserStruct     `gorm:"many2many:user_farm" yaml:"users" json:"users"`
 	Workflows      []*WorkflowStruct `gorm:"name:workflow;foreignKey:FarmID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" yaml:"workflows" json:"workflows"`
-	Farm           `sql:"-" gorm:"-" yaml:"-" json:"-"`
+	Notifiers      []*NotifierStruct `gorm:"foreignKey:FarmID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" yaml:"notifiers" json:"notifiers"`
+
+	// Notification routing rules and the quiet hours (HH:MM in the farm timezone) during
+	// which low and medium priority notifications are held. High priority notifications
+	// that are not acknowledged within EscalationTimeout minutes escalate to the next
+	// on-call user. Alarms that remain active are re-notified every AlarmRenotify minutes.
+	NotificationRules []*NotificationRuleStruct `gorm:"foreignKey:FarmID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" yaml:"notification_rules" json:"notification_rules"`
+	QuietHoursStart   string                    `gorm:"quiet_hours_start" yaml:"quiet_hours_start" json:"quiet_hours_start"`
+	QuietHoursEnd     string                    `gorm:"quiet_hours_end" yaml:"quiet_hours_end" json:"quiet_hours_end"`
+	EscalationTimeout int                       `gorm:"escalation_timeout" yaml:"escalation_timeout" json:"escalation_timeout"`
+	AlarmRenotify     int                       `gorm:"alarm_renotify" yaml:"alarm_renotify" json:"alarm_renotify"`
+
+	// Summary reports are sent daily or weekly (Mondays) at ReportTime (HH:MM in the farm
+	// timezone). An empty ReportSchedule disables the scheduled reports.
+	ReportSchedule string `gorm:"report_schedule" yaml:"report_schedule" json:"report_schedule"`
+	ReportTime     string `gorm:"report_time" yaml:"report_time" json:"report_time"`
+
+	// Event log entries older than EventLogRetention days are archived to compressed
+	// JSONL files and removed from the event log. Zero keeps the event log forever.
+	EventLogRetention int `gorm:"event_log_retention" yaml:"event_log_retention" json:"event_log_retention"`
+	Farm              `sql:"-" gorm:"-" yaml:"-" json:"-"`
 }
 
 func NewFarm() *FarmStruct {
@@ -324,6 +359,77 @@ func (farm *FarmStruct) RemoveWorkflow(workflow *WorkflowStruct) error {
 	return ErrWorkflowNotFound
 }
 
+func (farm *FarmStruct) SetNotifiers(notifiers []*NotifierStruct) {
+	farm.Notifiers = notifiers
+}
+
+func (farm *FarmStruct) GetNotifiers() []*NotifierStruct {
+	return farm.Notifiers
+}
+
+// Returns a copy of the farm configuration safe to send to clients, with
+// the notifier credentials redacted
+func (farm *FarmStruct) Redact() Farm {
+	redacted := *farm
+	if farm.Notifiers != nil {
+		redacted.Notifiers = make([]*NotifierStruct, len(farm.Notifiers))
+		for i, notifier := range farm.Notifiers {
+			redacted.Notifiers[i] = notifier.Redact()
+		}
+	}
+	return &redacted
+}
+
+func (farm *FarmStruct) SetNotificationRules(rules []*NotificationRuleStruct) {
+	farm.NotificationRules = rules
+}
+
+func (farm *FarmStruct) GetNotificationRules() []*NotificationRuleStruct {
+	return farm.NotificationRules
+}
+
+func (farm *FarmStruct) SetQuietHours(start, end string) {
+	farm.QuietHoursStart = start
+	farm.QuietHoursEnd = end
+}
+
+func (farm *FarmStruct) GetQuietHours() (string, string) {
+	return farm.QuietHoursStart, farm.QuietHoursEnd
+}
+
+func (farm *FarmStruct) SetEscalationTimeout(minutes int) {
+	farm.EscalationTimeout = minutes
+}
+
+func (farm *FarmStruct) GetEscalationTimeout() int {
+	return farm.EscalationTimeout
+}
+
+func (farm *FarmStruct) SetAlarmRenotify(minutes int) {
+	farm.AlarmRenotify = minutes
+}
+
+func (farm *FarmStruct) GetAlarmRenotify() int {
+	return farm.AlarmRenotify
+}
+
+func (farm *FarmStruct) SetReportSchedule(schedule, timeOfDay string) {
+	farm.ReportSchedule = schedule
+	farm.ReportTime = timeOfDay
+}
+
+func (farm *FarmStruct) GetReportSchedule() (string, string) {
+	return farm.ReportSchedule, farm.ReportTime
+}
+
+func (farm *FarmStruct) SetEventLogRetention(days int) {
+	farm.EventLogRetention = days
+}
+
+func (farm *FarmStruct) GetEventLogRetention() int {
+	return farm.EventLogRetention
+}
+
 func (farm *FarmStruct) ParseSettings() error {
 	for i, device := range farm.GetDevices() {
 		if device.GetType() == "server" {
@@ -5,7 +5,7 @@ type ServerLicense interface {
 }
 
 type ServerLicenseStruct struct {
-	OrganizationQuota int
+	OrganizationQuota int `yaml:"organizationQuota" json:"organizationQuota"`
 }
 
 func (license *ServerLicenseStruct) GetOrganizationQuota() int {
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type Metric interface {
 	GetDeviceID() uint64
 	SetDeviceID(uint64)
@@ -19,21 +21,62 @@ type Metric interface {
 	SetAlarmLow(float64)
 	GetAlarmHigh() float64
 	SetAlarmHigh(float64)
+	GetAnomalyWindow() int
+	SetAnomalyWindow(int)
+	GetAnomalyZScore() float64
+	SetAnomalyZScore(float64)
+	GetRateOfChange() float64
+	SetRateOfChange(float64)
+	GetRateOfChangeWindow() int
+	SetRateOfChangeWindow(int)
+	GetFlatlineWindow() int
+	SetFlatlineWindow(int)
+	GetAnomalyBackoff() int
+	SetAnomalyBackoff(int)
+	IsAnomalyDetectionEnabled() bool
+	GetExpression() string
+	SetExpression(string)
+	IsComputed() bool
+	GetCalibrationOffset() float64
+	SetCalibrationOffset(float64)
+	GetCalibrationSlope() float64
+	SetCalibrationSlope(float64)
+	GetCalibratedAt() *time.Time
+	SetCalibratedAt(*time.Time)
+	GetCalibrationReferences() string
+	SetCalibrationReferences(string)
+	GetCalibrationInterval() int
+	SetCalibrationInterval(int)
+	IsCalibrated() bool
+	IsCalibrationOverdue(now time.Time) bool
+	Calibrate(raw float64) float64
 	KeyValueEntity
 }
 
 type MetricStruct struct {
-	ID        uint64  `gorm:"primaryKey" yaml:"id" json:"id"`
-	DeviceID  uint64  `yaml:"deviceID" json:"device_id"`
-	DataType  int     `gorm:"column:datatype" yaml:"datatype" json:"datatype"`
-	Name      string  `yaml:"name" json:"name"`
-	Key       string  `yaml:"key" json:"key"`
-	Enable    bool    `yaml:"enable" json:"enable"`
-	Notify    bool    `yaml:"notify" json:"notify"`
-	Unit      string  `yaml:"unit" json:"unit"`
-	AlarmLow  float64 `yaml:"alarmLow" json:"alarmLow"`
-	AlarmHigh float64 `yaml:"alarmHigh" json:"alarmHigh"`
-	Metric    `sql:"-" gorm:"-" yaml:"-" json:"-"`
+	ID                    uint64     `gorm:"primaryKey" yaml:"id" json:"id"`
+	DeviceID              uint64     `yaml:"deviceID" json:"device_id"`
+	DataType              int        `gorm:"column:datatype" yaml:"datatype" json:"datatype"`
+	Name                  string     `yaml:"name" json:"name"`
+	Key                   string     `yaml:"key" json:"key"`
+	Enable                bool       `yaml:"enable" json:"enable"`
+	Notify                bool       `yaml:"notify" json:"notify"`
+	Unit                  string     `yaml:"unit" json:"unit"`
+	AlarmLow              float64    `yaml:"alarmLow" json:"alarmLow"`
+	AlarmHigh             float64    `yaml:"alarmHigh" json:"alarmHigh"`
+	AnomalyWindow         int        `yaml:"anomalyWindow" json:"anomalyWindow"`
+	AnomalyZScore         float64    `gorm:"column:anomaly_zscore" yaml:"anomalyZScore" json:"anomalyZScore"`
+	RateOfChange          float64    `yaml:"rateOfChange" json:"rateOfChange"`
+	RateOfChangeWindow    int        `yaml:"rateOfChangeWindow" json:"rateOfChangeWindow"`
+	FlatlineWindow        int        `yaml:"flatlineWindow" json:"flatlineWindow"`
+	AnomalyBackoff        int        `yaml:"anomalyBackoff" json:"anomalyBackoff"`
+	Expression            string     `yaml:"expression" json:"expression"`
+	CalibrationOffset     float64    `yaml:"calibrationOffset" json:"calibrationOffset"`
+	CalibrationSlope      float64    `yaml:"calibrationSlope" json:"calibrationSlope"`
+	CalibratedAt          *time.Time `yaml:"calibratedAt" json:"calibratedAt"`
+	CalibrationReferences string     `yaml:"calibrationReferences" json:"calibrationReferences"`
+	CalibrationInterval   int        `yaml:"calibrationInterval" json:"calibrationInterval"`
+	Metric                `sql:"-" gorm:"-" yaml:"-" json:"-"`
 }
 
 func NewMetric() *MetricStruct {
@@ -133,3 +176,161 @@ func (metric *MetricStruct) SetAlarmHigh(alarm float64) {
 func (metric *MetricStruct) GetAlarmHigh() float64 {
 	return metric.AlarmHigh
 }
+
+// Sets the number of samples used to calculate the rolling
+// mean and standard deviation for z-score anomaly detection
+func (metric *MetricStruct) SetAnomalyWindow(samples int) {
+	metric.AnomalyWindow = samples
+}
+
+func (metric *MetricStruct) GetAnomalyWindow() int {
+	return metric.AnomalyWindow
+}
+
+// Sets the number of standard deviations from the rolling
+// mean a value must be to be considered an anomaly
+func (metric *MetricStruct) SetAnomalyZScore(zscore float64) {
+	metric.AnomalyZScore = zscore
+}
+
+func (metric *MetricStruct) GetAnomalyZScore() float64 {
+	return metric.AnomalyZScore
+}
+
+// Sets the maximum amount the metric value is allowed to rise
+// or fall within the rate of change window
+func (metric *MetricStruct) SetRateOfChange(delta float64) {
+	metric.RateOfChange = delta
+}
+
+func (metric *MetricStruct) GetRateOfChange() float64 {
+	return metric.RateOfChange
+}
+
+// Sets the rate of change window (minutes)
+func (metric *MetricStruct) SetRateOfChangeWindow(minutes int) {
+	metric.RateOfChangeWindow = minutes
+}
+
+func (metric *MetricStruct) GetRateOfChangeWindow() int {
+	return metric.RateOfChangeWindow
+}
+
+// Sets how long the metric value may remain unchanged before the
+// sensor is considered to have flatlined (minutes)
+func (metric *MetricStruct) SetFlatlineWindow(minutes int) {
+	metric.FlatlineWindow = minutes
+}
+
+func (metric *MetricStruct) GetFlatlineWindow() int {
+	return metric.FlatlineWindow
+}
+
+// Sets how long to suppress repeat notifications for an anomaly
+// that has not cleared (minutes)
+func (metric *MetricStruct) SetAnomalyBackoff(minutes int) {
+	metric.AnomalyBackoff = minutes
+}
+
+func (metric *MetricStruct) GetAnomalyBackoff() int {
+	return metric.AnomalyBackoff
+}
+
+// Returns true if any of the anomaly detectors are configured
+func (metric *MetricStruct) IsAnomalyDetectionEnabled() bool {
+	return (metric.AnomalyWindow > 1 && metric.AnomalyZScore > 0) ||
+		(metric.RateOfChange > 0 && metric.RateOfChangeWindow > 0) ||
+		metric.FlatlineWindow > 0
+}
+
+// Sets the expression used to compute the metric value from other
+// metrics in the farm, for example "vpd(tempC0, humidity0)" or
+// "avg(tempF0..tempF2)". Metrics on other devices are referenced
+// using their device type: "reservoir.resTemp".
+func (metric *MetricStruct) SetExpression(expression string) {
+	metric.Expression = expression
+}
+
+func (metric *MetricStruct) GetExpression() string {
+	return metric.Expression
+}
+
+// Returns true if the metric value is computed server-side
+// instead of being reported by the device firmware
+func (metric *MetricStruct) IsComputed() bool {
+	return metric.Expression != ""
+}
+
+// Sets the value added to the raw reading after the slope is applied
+func (metric *MetricStruct) SetCalibrationOffset(offset float64) {
+	metric.CalibrationOffset = offset
+}
+
+func (metric *MetricStruct) GetCalibrationOffset() float64 {
+	return metric.CalibrationOffset
+}
+
+// Sets the value the raw reading is multiplied by. A slope of zero
+// is treated as an uncalibrated slope of 1.
+func (metric *MetricStruct) SetCalibrationSlope(slope float64) {
+	metric.CalibrationSlope = slope
+}
+
+func (metric *MetricStruct) GetCalibrationSlope() float64 {
+	return metric.CalibrationSlope
+}
+
+func (metric *MetricStruct) SetCalibratedAt(calibratedAt *time.Time) {
+	metric.CalibratedAt = calibratedAt
+}
+
+func (metric *MetricStruct) GetCalibratedAt() *time.Time {
+	return metric.CalibratedAt
+}
+
+// Sets the comma separated reference solution values used during
+// the last calibration, for example "4.00,7.00" for a pH probe
+func (metric *MetricStruct) SetCalibrationReferences(references string) {
+	metric.CalibrationReferences = references
+}
+
+func (metric *MetricStruct) GetCalibrationReferences() string {
+	return metric.CalibrationReferences
+}
+
+// Sets how often the sensor needs to be recalibrated (days). Zero
+// disables calibration reminders.
+func (metric *MetricStruct) SetCalibrationInterval(days int) {
+	metric.CalibrationInterval = days
+}
+
+func (metric *MetricStruct) GetCalibrationInterval() int {
+	return metric.CalibrationInterval
+}
+
+// Returns true if a calibration has been applied to the metric
+func (metric *MetricStruct) IsCalibrated() bool {
+	return metric.CalibratedAt != nil
+}
+
+// Returns true if the metric has a calibration interval and has never
+// been calibrated or the interval has elapsed since the last calibration
+func (metric *MetricStruct) IsCalibrationOverdue(now time.Time) bool {
+	if metric.CalibrationInterval <= 0 {
+		return false
+	}
+	if metric.CalibratedAt == nil {
+		return true
+	}
+	interval := time.Duration(metric.CalibrationInterval) * 24 * time.Hour
+	return now.Sub(*metric.CalibratedAt) >= interval
+}
+
+// Applies the linear calibration to a raw sensor reading
+func (metric *MetricStruct) Calibrate(raw float64) float64 {
+	slope := metric.CalibrationSlope
+	if slope == 0 {
+		slope = 1
+	}
+	return raw*slope + metric.CalibrationOffset
+}
@@ -0,0 +1,134 @@
+package config
+
+import "strings"
+
+type NotificationRule interface {
+	GetFarmID() uint64
+	SetFarmID(id uint64)
+	GetUserID() uint64
+	SetUserID(id uint64)
+	GetEventTypes() string
+	SetEventTypes(eventTypes string)
+	GetMinPriority() int
+	SetMinPriority(priority int)
+	GetChannels() string
+	SetChannels(channels string)
+	GetOnCallOrder() int
+	SetOnCallOrder(order int)
+	Matches(eventType string, priority int) bool
+	GetChannelList() []string
+	KeyValueEntity
+}
+
+// NotificationRuleStruct routes farm notifications to a user. EventTypes and
+// Channels are comma separated lists; an empty EventTypes matches every event
+// and empty Channels delivers by email. Channels are either "email" or the name
+// of an organization or farm notifier. Users with an OnCallOrder greater than 0
+// form the escalation chain for high priority notifications, lowest order first.
+type NotificationRuleStruct struct {
+	ID               uint64 `gorm:"primaryKey" yaml:"id" json:"id"`
+	FarmID           uint64 `yaml:"farm_id" json:"farm_id"`
+	UserID           uint64 `yaml:"user_id" json:"user_id"`
+	EventTypes       string `yaml:"event_types" json:"event_types"`
+	MinPriority      int    `yaml:"min_priority" json:"min_priority"`
+	Channels         string `yaml:"channels" json:"channels"`
+	OnCallOrder      int    `yaml:"on_call_order" json:"on_call_order"`
+	NotificationRule `sql:"-" gorm:"-" yaml:"-" json:"-"`
+}
+
+func NewNotificationRule() *NotificationRuleStruct {
+	return new(NotificationRuleStruct)
+}
+
+func (rule *NotificationRuleStruct) TableName() string {
+	return "notification_rules"
+}
+
+func (rule *NotificationRuleStruct) SetID(id uint64) {
+	rule.ID = id
+}
+
+func (rule *NotificationRuleStruct) Identifier() uint64 {
+	return rule.ID
+}
+
+func (rule *NotificationRuleStruct) SetFarmID(id uint64) {
+	rule.FarmID = id
+}
+
+func (rule *NotificationRuleStruct) GetFarmID() uint64 {
+	return rule.FarmID
+}
+
+func (rule *NotificationRuleStruct) SetUserID(id uint64) {
+	rule.UserID = id
+}
+
+func (rule *NotificationRuleStruct) GetUserID() uint64 {
+	return rule.UserID
+}
+
+func (rule *NotificationRuleStruct) SetEventTypes(eventTypes string) {
+	rule.EventTypes = eventTypes
+}
+
+func (rule *NotificationRuleStruct) GetEventTypes() string {
+	return rule.EventTypes
+}
+
+func (rule *NotificationRuleStruct) SetMinPriority(priority int) {
+	rule.MinPriority = priority
+}
+
+func (rule *NotificationRuleStruct) GetMinPriority() int {
+	return rule.MinPriority
+}
+
+func (rule *NotificationRuleStruct) SetChannels(channels string) {
+	rule.Channels = channels
+}
+
+func (rule *NotificationRuleStruct) GetChannels() string {
+	return rule.Channels
+}
+
+func (rule *NotificationRuleStruct) SetOnCallOrder(order int) {
+	rule.OnCallOrder = order
+}
+
+func (rule *NotificationRuleStruct) GetOnCallOrder() int {
+	return rule.OnCallOrder
+}
+
+// Returns true if the rule routes notifications with the given
+// event type and priority
+func (rule *NotificationRuleStruct) Matches(eventType string, priority int) bool {
+	if priority < rule.MinPriority {
+		return false
+	}
+	eventTypes := splitList(rule.EventTypes)
+	if len(eventTypes) == 0 {
+		return true
+	}
+	for _, t := range eventTypes {
+		if strings.EqualFold(t, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the channels the notification is delivered through
+func (rule *NotificationRuleStruct) GetChannelList() []string {
+	return splitList(rule.Channels)
+}
+
+func splitList(list string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,150 @@
+package config
+
+type Notifier interface {
+	GetOrganizationID() uint64
+	SetOrganizationID(id uint64)
+	GetFarmID() uint64
+	SetFarmID(id uint64)
+	GetName() string
+	SetName(name string)
+	GetType() string
+	SetType(notifierType string)
+	IsEnabled() bool
+	SetEnable(enabled bool)
+	GetURL() string
+	SetURL(url string)
+	GetToken() string
+	SetToken(token string)
+	GetTopic() string
+	SetTopic(topic string)
+	GetRecipient() string
+	SetRecipient(recipient string)
+	GetMinPriority() int
+	SetMinPriority(priority int)
+	Redact() *NotifierStruct
+	KeyValueEntity
+}
+
+// NotifierStruct configures an external notification channel (webhook, Slack,
+// ntfy, Gotify, SMS gateway) for an organization or farm. Organization wide
+// notifiers have a FarmID of 0.
+type NotifierStruct struct {
+	ID             uint64 `gorm:"primaryKey" yaml:"id" json:"id"`
+	OrganizationID uint64 `yaml:"orgId" json:"orgId"`
+	FarmID         uint64 `yaml:"farmId" json:"farmId"`
+	Name           string `yaml:"name" json:"name"`
+	Type           string `yaml:"type" json:"type"`
+	Enable         bool   `yaml:"enable" json:"enable"`
+	URL            string `yaml:"url" json:"url"`
+	Token          string `yaml:"token" json:"token"`
+	Topic          string `yaml:"topic" json:"topic"`
+	Recipient      string `yaml:"recipient" json:"recipient"`
+	MinPriority    int    `yaml:"minPriority" json:"minPriority"`
+	Notifier       `sql:"-" gorm:"-" yaml:"-" json:"-"`
+}
+
+func NewNotifier() *NotifierStruct {
+	return &NotifierStruct{Enable: true}
+}
+
+func (notifier *NotifierStruct) TableName() string {
+	return "notifiers"
+}
+
+func (notifier *NotifierStruct) SetID(id uint64) {
+	notifier.ID = id
+}
+
+func (notifier *NotifierStruct) Identifier() uint64 {
+	return notifier.ID
+}
+
+func (notifier *NotifierStruct) SetOrganizationID(id uint64) {
+	notifier.OrganizationID = id
+}
+
+func (notifier *NotifierStruct) GetOrganizationID() uint64 {
+	return notifier.OrganizationID
+}
+
+func (notifier *NotifierStruct) SetFarmID(id uint64) {
+	notifier.FarmID = id
+}
+
+func (notifier *NotifierStruct) GetFarmID() uint64 {
+	return notifier.FarmID
+}
+
+func (notifier *NotifierStruct) SetName(name string) {
+	notifier.Name = name
+}
+
+func (notifier *NotifierStruct) GetName() string {
+	return notifier.Name
+}
+
+func (notifier *NotifierStruct) SetType(notifierType string) {
+	notifier.Type = notifierType
+}
+
+func (notifier *NotifierStruct) GetType() string {
+	return notifier.Type
+}
+
+func (notifier *NotifierStruct) SetEnable(enabled bool) {
+	notifier.Enable = enabled
+}
+
+func (notifier *NotifierStruct) IsEnabled() bool {
+	return notifier.Enable
+}
+
+func (notifier *NotifierStruct) SetURL(url string) {
+	notifier.URL = url
+}
+
+func (notifier *NotifierStruct) GetURL() string {
+	return notifier.URL
+}
+
+func (notifier *NotifierStruct) SetToken(token string) {
+	notifier.Token = token
+}
+
+func (notifier *NotifierStruct) GetToken() string {
+	return notifier.Token
+}
+
+func (notifier *NotifierStruct) SetTopic(topic string) {
+	notifier.Topic = topic
+}
+
+func (notifier *NotifierStruct) GetTopic() string {
+	return notifier.Topic
+}
+
+func (notifier *NotifierStruct) SetRecipient(recipient string) {
+	notifier.Recipient = recipient
+}
+
+func (notifier *NotifierStruct) GetRecipient() string {
+	return notifier.Recipient
+}
+
+func (notifier *NotifierStruct) SetMinPriority(priority int) {
+	notifier.MinPriority = priority
+}
+
+func (notifier *NotifierStruct) GetMinPriority() int {
+	return notifier.MinPriority
+}
+
+// Returns a copy of the notifier safe to send to clients. The URL and
+// token carry the channel credentials (webhook secrets, API tokens) and
+// are removed.
+func (notifier *NotifierStruct) Redact() *NotifierStruct {
+	redacted := *notifier
+	redacted.URL = ""
+	redacted.Token = ""
+	return &redacted
+}
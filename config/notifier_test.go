@@ -0,0 +1,40 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifierRedact(t *testing.T) {
+	notifier := NewNotifier()
+	notifier.SetName("ops")
+	notifier.SetType("slack")
+	notifier.SetURL("https://hooks.slack.com/services/secret")
+	notifier.SetToken("api-token")
+
+	farm := NewFarm()
+	farm.SetNotifiers([]*NotifierStruct{notifier})
+	org := &OrganizationStruct{
+		Farms:     []*FarmStruct{farm},
+		Notifiers: []*NotifierStruct{notifier}}
+
+	redactedFarm := farm.Redact()
+	assert.Equal(t, "ops", redactedFarm.GetNotifiers()[0].GetName())
+	assert.Empty(t, redactedFarm.GetNotifiers()[0].GetURL())
+	assert.Empty(t, redactedFarm.GetNotifiers()[0].GetToken())
+
+	redactedOrg := org.Redact()
+	assert.Empty(t, redactedOrg.GetNotifiers()[0].GetToken())
+	assert.Empty(t, redactedOrg.GetFarms()[0].GetNotifiers()[0].GetToken())
+
+	bytes, err := json.Marshal(redactedOrg)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(bytes), "secret")
+	assert.NotContains(t, string(bytes), "api-token")
+
+	// The stored configuration keeps the credentials
+	assert.Equal(t, "https://hooks.slack.com/services/secret", notifier.GetURL())
+	assert.Equal(t, "api-token", farm.GetNotifiers()[0].GetToken())
+}
@@ -0,0 +1,25 @@
+package config
+
+// OIDC configures a generic OpenID Connect login provider such as Keycloak
+// or Azure AD. The provider's endpoints are read from the issuer's discovery
+// document. Claim mappings translate the values of an ID token claim into
+// the role the user is assigned when their account is provisioned and the
+// organizations the user belongs to.
+type OIDC struct {
+	Issuer              string            `yaml:"issuer" json:"issuer" mapstructure:"issuer"`
+	ClientID            string            `yaml:"client-id" json:"client_id" mapstructure:"client-id"`
+	ClientSecret        string            `yaml:"client-secret" json:"-" mapstructure:"client-secret"`
+	RedirectURL         string            `yaml:"redirect-url" json:"redirect_url" mapstructure:"redirect-url"`
+	Scopes              []string          `yaml:"scopes" json:"scopes" mapstructure:"scopes"`
+	EmailClaim          string            `yaml:"email-claim" json:"email_claim" mapstructure:"email-claim"`
+	RoleClaim           string            `yaml:"role-claim" json:"role_claim" mapstructure:"role-claim"`
+	RoleMapping         map[string]string `yaml:"role-mapping" json:"role_mapping" mapstructure:"role-mapping"`
+	DefaultRole         string            `yaml:"default-role" json:"default_role" mapstructure:"default-role"`
+	OrganizationClaim   string            `yaml:"organization-claim" json:"organization_claim" mapstructure:"organization-claim"`
+	OrganizationMapping map[string]uint64 `yaml:"organization-mapping" json:"organization_mapping" mapstructure:"organization-mapping"`
+}
+
+// Returns true if an OIDC provider has been configured
+func (oidc *OIDC) IsEnabled() bool {
+	return oidc != nil && oidc.Issuer != "" && oidc.ClientID != ""
+}
@@ -21,6 +21,10 @@ type Organization interface {
 	RemoveUser(user *UserStruct)
 	GetLicense() *OrganizationLicenseStruct
 	SetLicense(*OrganizationLicenseStruct)
+	SetNotifiers(notifiers []*NotifierStruct)
+	GetNotifiers() []*NotifierStruct
+	SetMFARequired(required bool)
+	IsMFARequired() bool
 	CommonOrganization
 }
 
@@ -34,6 +38,10 @@ type OrganizationStruct struct {
 	Farms []*FarmStruct `gorm:"foreignKey:OrganizationID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" yaml:"farms" json:"farms"`
 	//Devices        []Device `yaml:"devices" json:"devices"`
 	Users []*UserStruct `gorm:"many2many:organization_user" yaml:"users" json:"users"`
+	// Organization wide notifiers; farm specific notifiers are stored with the farm
+	Notifiers []*NotifierStruct `gorm:"foreignKey:OrganizationID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" yaml:"notifiers" json:"notifiers"`
+	// Requires every member to log in with a second factor
+	MFARequired bool `gorm:"default:false" yaml:"mfa-required" json:"mfa_required"`
 	//Users              []User   `yaml:"users" json:"users"`
 	//License      *OrganizationLicenseStruct `yaml:"license" json:"license"`
 	Organization `sql:"-" gorm:"-" yaml:"-" json:"-"`
@@ -121,6 +129,24 @@ func (org *OrganizationStruct) GetUsers() []*UserStruct {
 	return org.Users
 }
 
+func (org *OrganizationStruct) SetNotifiers(notifiers []*NotifierStruct) {
+	org.Notifiers = notifiers
+}
+
+func (org *OrganizationStruct) GetNotifiers() []*NotifierStruct {
+	return org.Notifiers
+}
+
+// SetMFARequired sets whether members must log in with a second factor
+func (org *OrganizationStruct) SetMFARequired(required bool) {
+	org.MFARequired = required
+}
+
+// IsMFARequired returns true if members must log in with a second factor
+func (org *OrganizationStruct) IsMFARequired() bool {
+	return org.MFARequired
+}
+
 // func (org *OrganizationStruct) GetLicense() *OrganizationLicenseStruct {
 // 	return org.License
 // }
@@ -128,3 +154,22 @@ func (org *OrganizationStruct) GetUsers() []*UserStruct {
 // func (org *OrganizationStruct) SetLicense(license *OrganizationLicenseStruct) {
 // 	org.License = license
 // }
+
+// Returns a copy of the organization safe to send to clients, with the
+// organization and farm notifier credentials redacted
+func (org *OrganizationStruct) Redact() *OrganizationStruct {
+	redacted := *org
+	if org.Notifiers != nil {
+		redacted.Notifiers = make([]*NotifierStruct, len(org.Notifiers))
+		for i, notifier := range org.Notifiers {
+			redacted.Notifiers[i] = notifier.Redact()
+		}
+	}
+	if org.Farms != nil {
+		redacted.Farms = make([]*FarmStruct, len(org.Farms))
+		for i, farm := range org.Farms {
+			redacted.Farms[i] = farm.Redact().(*FarmStruct)
+		}
+	}
+	return &redacted
+}
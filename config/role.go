@@ -3,14 +3,21 @@ package config
 type Role interface {
 	GetName() string
 	SetName(name string)
+	GetPermissions() string
+	SetPermissions(permissions string)
+	GetPermissionList() []string
+	HasPermission(permission string) bool
 	KeyValueEntity
 }
 
+// RoleStruct is a named set of permissions. Permissions is a comma
+// separated list of permissions from the common.PermissionCatalog.
 type RoleStruct struct {
-	ID    uint64       `gorm:"primaryKey" yaml:"id" json:"id"`
-	Name  string       `yaml:"name" json:"name"`
-	Users []UserStruct `gorm:"many2many:user_role" yaml:"-" json:"-"`
-	Role  `sql:"-" gorm:"-" yaml:"-" json:"-"`
+	ID          uint64       `gorm:"primaryKey" yaml:"id" json:"id"`
+	Name        string       `yaml:"name" json:"name"`
+	Permissions string       `yaml:"permissions" json:"permissions"`
+	Users       []UserStruct `gorm:"many2many:user_role" yaml:"-" json:"-"`
+	Role        `sql:"-" gorm:"-" yaml:"-" json:"-"`
 }
 
 // gorm:"many2many:permissions"
@@ -38,3 +45,26 @@ func (role *RoleStruct) SetName(name string) {
 func (role *RoleStruct) GetName() string {
 	return role.Name
 }
+
+func (role *RoleStruct) SetPermissions(permissions string) {
+	role.Permissions = permissions
+}
+
+func (role *RoleStruct) GetPermissions() string {
+	return role.Permissions
+}
+
+// Returns the role's permissions
+func (role *RoleStruct) GetPermissionList() []string {
+	return splitList(role.Permissions)
+}
+
+// Returns true if the role grants the specified permission
+func (role *RoleStruct) HasPermission(permission string) bool {
+	for _, p := range role.GetPermissionList() {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -13,15 +13,18 @@ type Smtp interface {
 	SetPassword(password string)
 	GetRecipient() string
 	SetRecipient(recipient string)
+	GetEncryption() string
+	SetEncryption(encryption string)
 }
 
 type SmtpStruct struct {
-	Enable    bool   `yaml:"enable" json:"enable"`
-	Host      string `yaml:"host" json:"host"`
-	Port      int    `yaml:"port" json:"port"`
-	Username  string `yaml:"username" json:"username"`
-	Password  string `yaml:"password" json:"password"`
-	Recipient string `yaml:"recipient" json:"recipient"`
+	Enable     bool   `yaml:"enable" json:"enable"`
+	Host       string `yaml:"host" json:"host"`
+	Port       int    `yaml:"port" json:"port"`
+	Username   string `yaml:"username" json:"username"`
+	Password   string `yaml:"password" json:"password"`
+	Recipient  string `yaml:"recipient" json:"recipient"`
+	Encryption string `yaml:"encryption" json:"encryption"`
 	Smtp
 }
 
@@ -76,3 +79,13 @@ func (smtp *SmtpStruct) SetRecipient(recipient string) {
 func (smtp *SmtpStruct) GetRecipient() string {
 	return smtp.Recipient
 }
+
+func (smtp *SmtpStruct) SetEncryption(encryption string) {
+	smtp.Encryption = encryption
+}
+
+// Returns the connection encryption: none, starttls or tls (implicit TLS).
+// STARTTLS is used if the server supports it when no encryption is set.
+func (smtp *SmtpStruct) GetEncryption() string {
+	return smtp.Encryption
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type CommonUser interface {
 	GetEmail() string
 	SetEmail(string)
@@ -17,18 +19,37 @@ type User interface {
 	GetRoles() []*RoleStruct
 	SetRoles([]*RoleStruct)
 	AddRole(*RoleStruct)
+	GetNotificationEmail() string
+	SetNotificationEmail(string)
+	GetEmailPreferences() string
+	SetEmailPreferences(string)
+	IsEmailSubscribed(category string) bool
+	IsDisabled() bool
+	SetDisabled(disabled bool)
+	IsLocked(now time.Time) bool
 	CommonUser
 }
 
-// User represents a user account in the app
+// User represents a user account in the app. NotificationEmail is an optional
+// address for notification emails, used instead of the login email when set.
+// EmailPreferences is a comma separated list of the email categories the user
+// has opted in to (alarm, summary, workflow). Disabled accounts can't log in.
+// FailedLogins counts consecutive failed password logins; the account is
+// locked until LockedUntil once too many logins have failed.
 type UserStruct struct {
-	ID               uint64        `gorm:"primaryKey" yaml:"id" json:"id"`
-	Email            string        `gorm:"index" yaml:"email" json:"email"`
-	Password         string        `yaml:"password" json:"password"`
-	Roles            []*RoleStruct `gorm:"many2many:user_role" yaml:"roles" json:"roles"`
-	OrganizationRefs []uint64      `gorm:"-" yaml:"organizationRefs" json:"organizationRefs"`
-	FarmRefs         []uint64      `gorm:"-" yaml:"farmRefs" json:"farmRefs"`
-	User             `sql:"-" gorm:"-" yaml:"-" json:"-"`
+	ID                uint64        `gorm:"primaryKey" yaml:"id" json:"id"`
+	Email             string        `gorm:"index" yaml:"email" json:"email"`
+	Password          string        `yaml:"password" json:"password"`
+	NotificationEmail string        `yaml:"notificationEmail" json:"notificationEmail"`
+	EmailPreferences  string        `yaml:"emailPreferences" json:"emailPreferences"`
+	Disabled          bool          `gorm:"default:false" yaml:"disabled" json:"disabled"`
+	FailedLogins      int           `gorm:"default:0" yaml:"-" json:"failedLogins"`
+	LockedUntil       time.Time     `gorm:"type:timestamp" yaml:"-" json:"lockedUntil"`
+	OIDCSubject       string        `gorm:"index" yaml:"-" json:"oidcSubject,omitempty"`
+	Roles             []*RoleStruct `gorm:"many2many:user_role" yaml:"roles" json:"roles"`
+	OrganizationRefs  []uint64      `gorm:"-" yaml:"organizationRefs" json:"organizationRefs"`
+	FarmRefs          []uint64      `gorm:"-" yaml:"farmRefs" json:"farmRefs"`
+	User              `sql:"-" gorm:"-" yaml:"-" json:"-"`
 }
 
 func NewUser() *UserStruct {
@@ -68,6 +89,52 @@ func (user *UserStruct) GetPassword() string {
 	return user.Password
 }
 
+func (user *UserStruct) SetNotificationEmail(email string) {
+	user.NotificationEmail = email
+}
+
+// GetNotificationEmail gets the address notification emails are sent to,
+// falling back to the users login email
+func (user *UserStruct) GetNotificationEmail() string {
+	if user.NotificationEmail != "" {
+		return user.NotificationEmail
+	}
+	return user.Email
+}
+
+func (user *UserStruct) SetEmailPreferences(preferences string) {
+	user.EmailPreferences = preferences
+}
+
+func (user *UserStruct) GetEmailPreferences() string {
+	return user.EmailPreferences
+}
+
+// IsEmailSubscribed returns true if the user has opted in to
+// emails in the given category
+func (user *UserStruct) IsEmailSubscribed(category string) bool {
+	for _, preference := range splitList(user.EmailPreferences) {
+		if preference == category {
+			return true
+		}
+	}
+	return false
+}
+
+func (user *UserStruct) IsDisabled() bool {
+	return user.Disabled
+}
+
+func (user *UserStruct) SetDisabled(disabled bool) {
+	user.Disabled = disabled
+}
+
+// IsLocked returns true if too many logins have failed and
+// the lockout hasn't expired
+func (user *UserStruct) IsLocked(now time.Time) bool {
+	return now.Before(user.LockedUntil)
+}
+
 func (user *UserStruct) GetRoles() []*RoleStruct {
 	return user.Roles
 }
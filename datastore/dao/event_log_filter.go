@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+)
+
+// EventLogFilter restricts an event log query to the entries matching every
+// non-zero field. Start and End are inclusive. Search matches a case
+// insensitive substring of the event message.
+type EventLogFilter struct {
+	Start     time.Time
+	End       time.Time
+	DeviceID  uint64
+	ChannelID uint64
+	EventType string
+	ActorID   uint64
+	Source    string
+	Search    string
+}
+
+// Returns true if the event log entry matches the filter
+func (filter EventLogFilter) Matches(event *entity.EventLog) bool {
+	if !filter.Start.IsZero() && event.Timestamp.Before(filter.Start) {
+		return false
+	}
+	if !filter.End.IsZero() && event.Timestamp.After(filter.End) {
+		return false
+	}
+	if filter.DeviceID != 0 && event.DeviceID != filter.DeviceID {
+		return false
+	}
+	if filter.ChannelID != 0 && event.ChannelID != filter.ChannelID {
+		return false
+	}
+	if filter.EventType != "" && event.EventType != filter.EventType {
+		return false
+	}
+	if filter.ActorID != 0 && event.ActorID != filter.ActorID {
+		return false
+	}
+	if filter.Source != "" && event.Source != filter.Source {
+		return false
+	}
+	if filter.Search != "" &&
+		!strings.Contains(strings.ToLower(event.Message), strings.ToLower(filter.Search)) {
+		return false
+	}
+	return true
+}
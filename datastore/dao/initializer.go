@@ -3,6 +3,7 @@ package dao
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jeremyhahn/go-cropdroid/common"
@@ -65,16 +66,19 @@ func (initializer *ConfigInitializer) Initialize(includeFarm bool,
 	adminRole := config.NewRole()
 	adminRole.SetID(initializer.newID(common.DEFAULT_ROLE))
 	adminRole.SetName(common.DEFAULT_ROLE)
+	adminRole.SetPermissions(strings.Join(common.DefaultRolePermissions[common.DEFAULT_ROLE], ","))
 	initializer.roleDAO.Save(adminRole)
 
 	cultivatorRole := config.NewRole()
 	cultivatorRole.SetID(initializer.newID(common.ROLE_CULTIVATOR))
 	cultivatorRole.SetName(common.ROLE_CULTIVATOR)
+	cultivatorRole.SetPermissions(strings.Join(common.DefaultRolePermissions[common.ROLE_CULTIVATOR], ","))
 	initializer.roleDAO.Save(cultivatorRole)
 
 	analystRole := config.NewRole()
 	analystRole.SetID(initializer.newID(common.ROLE_ANALYST))
 	analystRole.SetName(common.ROLE_ANALYST)
+	analystRole.SetPermissions(strings.Join(common.DefaultRolePermissions[common.ROLE_ANALYST], ","))
 	initializer.roleDAO.Save(analystRole)
 
 	adminUser := config.NewUser()
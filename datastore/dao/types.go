@@ -1,6 +1,8 @@
 package dao
 
 import (
+	"time"
+
 	"github.com/jeremyhahn/go-cropdroid/config"
 	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
@@ -68,9 +70,70 @@ type DeviceDAO interface {
 }
 
 type EventLogDAO interface {
+	GetPageByFilter(filter EventLogFilter, pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (PageResult[*entity.EventLog], error)
 	GenericDAO[*entity.EventLog]
 }
 
+type UserEmailDAO interface {
+	GenericDAO[*entity.UserEmail]
+}
+
+type EventLogArchiveDAO interface {
+	GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.EventLogArchive, error)
+	GenericDAO[*entity.EventLogArchive]
+}
+
+type AlarmDAO interface {
+	GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.Alarm, error)
+	GenericDAO[*entity.Alarm]
+}
+
+// AuditDAO is append-only; audit entries are never updated or deleted.
+type AuditDAO interface {
+	Save(entry *entity.AuditEntry) error
+	Get(id uint64, CONSISTENCY_LEVEL int) (*entity.AuditEntry, error)
+	GetLast(CONSISTENCY_LEVEL int) (*entity.AuditEntry, error)
+	Count(CONSISTENCY_LEVEL int) (int64, error)
+	Pager[*entity.AuditEntry]
+}
+
+type APIKeyDAO interface {
+	GetByCreatedBy(userID uint64, CONSISTENCY_LEVEL int) ([]*entity.APIKey, error)
+	GenericDAO[*entity.APIKey]
+}
+
+type RefreshTokenDAO interface {
+	GetByUserID(userID uint64, CONSISTENCY_LEVEL int) ([]*entity.RefreshToken, error)
+	GetByFamily(family uint64, CONSISTENCY_LEVEL int) ([]*entity.RefreshToken, error)
+	GenericDAO[*entity.RefreshToken]
+}
+
+type TOTPDAO interface {
+	GenericDAO[*entity.TOTP]
+}
+
+type LicenseDAO interface {
+	GenericDAO[*entity.License]
+}
+
+type OIDCStateDAO interface {
+	DeleteExpired(now time.Time) error
+	GenericDAO[*entity.OIDCState]
+}
+
+type InvitationDAO interface {
+	GetByOrganizationID(orgID uint64, CONSISTENCY_LEVEL int) ([]*entity.Invitation, error)
+	GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.Invitation, error)
+	GenericDAO[*entity.Invitation]
+}
+
+type InboxDAO interface {
+	GetByUserID(userID uint64, pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (PageResult[*entity.InboxItem], error)
+	GetSince(userID, notificationID uint64, CONSISTENCY_LEVEL int) ([]*entity.InboxItem, error)
+	CountUnread(userID uint64, CONSISTENCY_LEVEL int) (int64, error)
+	GenericDAO[*entity.InboxItem]
+}
+
 type PermissionDAO interface {
 	Delete(permission *config.PermissionStruct) error
 	GetFarms(orgID uint64, CONSISTENCY_LEVEL int) ([]*config.FarmStruct, error)
@@ -167,4 +230,26 @@ type Registry interface {
 	SetWorkflowStepDAO(WorkflowStepDAO)
 	GetEventLogDAO() EventLogDAO
 	SetEventLogDAO(dao EventLogDAO)
+	GetEventLogArchiveDAO() EventLogArchiveDAO
+	SetEventLogArchiveDAO(dao EventLogArchiveDAO)
+	GetUserEmailDAO() UserEmailDAO
+	SetUserEmailDAO(dao UserEmailDAO)
+	GetAlarmDAO() AlarmDAO
+	SetAlarmDAO(dao AlarmDAO)
+	GetInboxDAO() InboxDAO
+	SetInboxDAO(dao InboxDAO)
+	GetAuditDAO() AuditDAO
+	SetAuditDAO(dao AuditDAO)
+	GetAPIKeyDAO() APIKeyDAO
+	SetAPIKeyDAO(dao APIKeyDAO)
+	GetRefreshTokenDAO() RefreshTokenDAO
+	SetRefreshTokenDAO(dao RefreshTokenDAO)
+	GetTOTPDAO() TOTPDAO
+	SetTOTPDAO(dao TOTPDAO)
+	GetInvitationDAO() InvitationDAO
+	SetInvitationDAO(dao InvitationDAO)
+	GetLicenseDAO() LicenseDAO
+	SetLicenseDAO(dao LicenseDAO)
+	GetOIDCStateDAO() OIDCStateDAO
+	SetOIDCStateDAO(dao OIDCStateDAO)
 }
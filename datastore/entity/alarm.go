@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type AlarmEntity interface {
+	GetFarmID() uint64
+	GetDeviceID() uint64
+	GetMetricKey() string
+	GetState() string
+	IsOpen() bool
+}
+
+// Alarm is raised when a metric leaves its configured alarm range and tracks
+// the alarm through its lifecycle until the value returns to the normal range.
+// Only one open (not cleared) alarm exists per farm, device and metric.
+type Alarm struct {
+	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
+	FarmID                uint64    `gorm:"index;not null" json:"farm_id"`
+	DeviceID              uint64    `gorm:"not null" json:"device_id"`
+	DeviceType            string    `gorm:"not null" json:"device"`
+	MetricKey             string    `gorm:"not null" json:"metric"`
+	MetricName            string    `json:"metric_name"`
+	Condition             string    `gorm:"not null" json:"condition"`
+	State                 string    `gorm:"index;not null" json:"state"`
+	Value                 float64   `json:"value"`
+	Threshold             float64   `json:"threshold"`
+	Message               string    `json:"message"`
+	NotifyCount           int       `json:"notify_count"`
+	RaisedAt              time.Time `gorm:"type:timestamp" json:"raised_at"`
+	LastNotifiedAt        time.Time `gorm:"type:timestamp" json:"last_notified_at"`
+	AcknowledgedBy        uint64    `json:"acknowledged_by"`
+	AcknowledgedAt        time.Time `gorm:"type:timestamp" json:"acknowledged_at"`
+	ShelvedUntil          time.Time `gorm:"type:timestamp" json:"shelved_until"`
+	ClearedAt             time.Time `gorm:"type:timestamp" json:"cleared_at"`
+	AlarmEntity           `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *Alarm) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *Alarm) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *Alarm) GetFarmID() uint64 {
+	return entity.FarmID
+}
+
+func (entity *Alarm) GetDeviceID() uint64 {
+	return entity.DeviceID
+}
+
+func (entity *Alarm) GetMetricKey() string {
+	return entity.MetricKey
+}
+
+func (entity *Alarm) GetState() string {
+	return entity.State
+}
+
+// Returns true if the alarm has not been cleared
+func (entity *Alarm) IsOpen() bool {
+	return entity.State != common.ALARM_STATE_CLEARED
+}
@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type APIKeyEntity interface {
+	GetServiceAccount() string
+	GetCreatedBy() uint64
+	GetFarmIDs() []uint64
+	GetPermissionList() []string
+	IsRevoked() bool
+	IsExpired(now time.Time) bool
+}
+
+// APIKey is a long-lived credential bound to a service account for use by
+// integrations. The key is scoped to a set of farms and permissions and only
+// a SHA-256 hash of the key secret is stored. Farms and permissions are
+// stored as comma separated lists.
+type APIKey struct {
+	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
+	Name                  string    `json:"name"`
+	ServiceAccount        string    `gorm:"index;not null" json:"service_account"`
+	CreatedBy             uint64    `gorm:"index;not null" json:"created_by"`
+	Hash                  string    `gorm:"not null" json:"hash,omitempty"`
+	Farms                 string    `json:"farms"`
+	Permissions           string    `json:"permissions"`
+	CreatedAt             time.Time `gorm:"type:timestamp" json:"created_at"`
+	ExpiresAt             time.Time `gorm:"type:timestamp" json:"expires_at"`
+	LastUsedAt            time.Time `gorm:"type:timestamp" json:"last_used_at"`
+	LastUsedAddress       string    `json:"last_used_address"`
+	RevokedAt             time.Time `gorm:"type:timestamp" json:"revoked_at"`
+	APIKeyEntity          `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *APIKey) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *APIKey) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *APIKey) GetServiceAccount() string {
+	return entity.ServiceAccount
+}
+
+func (entity *APIKey) GetCreatedBy() uint64 {
+	return entity.CreatedBy
+}
+
+// Returns the IDs of the farms the key is scoped to
+func (entity *APIKey) GetFarmIDs() []uint64 {
+	farmIDs := make([]uint64, 0)
+	for _, farm := range strings.Split(entity.Farms, ",") {
+		if farmID, err := strconv.ParseUint(strings.TrimSpace(farm), 10, 64); err == nil {
+			farmIDs = append(farmIDs, farmID)
+		}
+	}
+	return farmIDs
+}
+
+// Returns the permissions granted to the key
+func (entity *APIKey) GetPermissionList() []string {
+	permissions := make([]string, 0)
+	for _, permission := range strings.Split(entity.Permissions, ",") {
+		if permission = strings.TrimSpace(permission); permission != "" {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+func (entity *APIKey) IsRevoked() bool {
+	return !entity.RevokedAt.IsZero()
+}
+
+// Returns true if the key has an expiration date that has passed
+func (entity *APIKey) IsExpired(now time.Time) bool {
+	return !entity.ExpiresAt.IsZero() && now.After(entity.ExpiresAt)
+}
+
+// Returns a copy of the key without the secret hash
+func (entity *APIKey) Redact() *APIKey {
+	redacted := *entity
+	redacted.Hash = ""
+	return &redacted
+}
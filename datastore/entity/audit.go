@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+// AuditEntry records a configuration or control action. Entries form a hash
+// chain: each entry's hash covers its content and the previous entry's hash,
+// and is signed with the CA key so tampering with, inserting or removing an
+// entry is detectable.
+type AuditEntry struct {
+	ID                    uint64    `gorm:"primaryKey;autoIncrement:false" yaml:"id" json:"id"`
+	Timestamp             time.Time `gorm:"index;type:timestamp" json:"timestamp"`
+	ActorID               uint64    `gorm:"index" json:"actor_id"`
+	ActorEmail            string    `json:"actor_email"`
+	SessionID             string    `json:"session_id"`
+	OrganizationID        uint64    `json:"organization_id"`
+	FarmID                uint64    `gorm:"index" json:"farm_id"`
+	Action                string    `gorm:"index;not null" json:"action"`
+	ObjectType            string    `json:"object_type"`
+	ObjectID              uint64    `json:"object_id"`
+	Before                string    `json:"before"`
+	After                 string    `json:"after"`
+	Changes               string    `json:"changes"`
+	PrevHash              string    `json:"prev_hash"`
+	Hash                  string    `gorm:"not null" json:"hash"`
+	Signature             string    `json:"signature"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+// AuditChange is a single field that differs between the before and
+// after state of an audited object.
+type AuditChange struct {
+	Path   string `json:"path"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+func (entity *AuditEntry) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *AuditEntry) Identifier() uint64 {
+	return entity.ID
+}
@@ -12,18 +12,33 @@ type EventLogEntity interface {
 	GetDeviceName() string
 	GetEventType() string
 	GetMessage() string
+	GetChannelID() uint64
+	GetMetricKey() string
+	GetValue() float64
+	GetActorID() uint64
+	GetSource() string
 	GetTimestamp() string
 	GetTimestampAsObject() time.Time
 }
 
+// EventLog is a farm or system event. The structured attributes identify the
+// channel, metric and value involved, the user that performed the action and
+// whether it was triggered manually or by a schedule, condition, algorithm or
+// workflow. Entries are indexed by farm and timestamp, and by each filterable
+// attribute.
 type EventLog struct {
 	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
-	FarmID                uint64    `gorm:"not null" json:"farm_id"`
-	DeviceID              uint64    `gorm:"not null" json:"device_id"`
+	FarmID                uint64    `gorm:"not null;index:idx_event_logs_farm_timestamp,priority:1" json:"farm_id"`
+	DeviceID              uint64    `gorm:"not null;index" json:"device_id"`
 	DeviceName            string    `gorm:"not null" json:"device"`
-	EventType             string    `gorm:"not null" json:"type"`
+	EventType             string    `gorm:"not null;index" json:"type"`
 	Message               string    `gorm:"not null" json:"message"`
-	Timestamp             time.Time `gorm:"type:timestamp" json:"timestamp"`
+	ChannelID             uint64    `gorm:"index" json:"channel_id"`
+	MetricKey             string    `json:"metric_key"`
+	Value                 float64   `json:"value"`
+	ActorID               uint64    `gorm:"index" json:"actor_id"`
+	Source                string    `gorm:"index" json:"source"`
+	Timestamp             time.Time `gorm:"type:timestamp;index:idx_event_logs_farm_timestamp,priority:2" json:"timestamp"`
 	EventLogEntity        `gorm:"-" yaml:"-" json:"-"`
 	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
 }
@@ -56,6 +71,26 @@ func (entity *EventLog) GetMessage() string {
 	return entity.Message
 }
 
+func (entity *EventLog) GetChannelID() uint64 {
+	return entity.ChannelID
+}
+
+func (entity *EventLog) GetMetricKey() string {
+	return entity.MetricKey
+}
+
+func (entity *EventLog) GetValue() float64 {
+	return entity.Value
+}
+
+func (entity *EventLog) GetActorID() uint64 {
+	return entity.ActorID
+}
+
+func (entity *EventLog) GetSource() string {
+	return entity.Source
+}
+
 func (entity *EventLog) GetTimestamp() string {
 	return entity.Timestamp.Format(time.RFC3339)
 }
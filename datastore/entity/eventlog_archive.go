@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type EventLogArchiveEntity interface {
+	GetFarmID() uint64
+	GetName() string
+	IsRestored() bool
+}
+
+// EventLogArchive records a gzip compressed JSONL farm event log archive
+// written to the data directory of the node that created it. The entries
+// in an archive are restored to the event log at most once.
+type EventLogArchive struct {
+	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
+	FarmID                uint64    `gorm:"index;not null" json:"farm_id"`
+	Name                  string    `gorm:"not null" json:"name"`
+	Before                time.Time `gorm:"type:timestamp" json:"before"`
+	Entries               int       `json:"entries"`
+	CreatedAt             time.Time `gorm:"type:timestamp" json:"created_at"`
+	RestoredAt            time.Time `gorm:"type:timestamp" json:"restored_at"`
+	EventLogArchiveEntity `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *EventLogArchive) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *EventLogArchive) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *EventLogArchive) GetFarmID() uint64 {
+	return entity.FarmID
+}
+
+func (entity *EventLogArchive) GetName() string {
+	return entity.Name
+}
+
+func (entity *EventLogArchive) IsRestored() bool {
+	return !entity.RestoredAt.IsZero()
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type InboxItemEntity interface {
+	GetUserID() uint64
+	GetNotificationID() uint64
+	IsRead() bool
+}
+
+// InboxItem is a notification persisted for a single recipient so it
+// survives a full websocket buffer, a disconnected client or a restart.
+// Items are replayed to the notification websocket in the order the
+// datastore assigned their IDs, starting after the item for the
+// notification ID the client sends as its cursor.
+type InboxItem struct {
+	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
+	UserID                uint64    `gorm:"index;not null" json:"user_id"`
+	NotificationID        uint64    `gorm:"index;not null" json:"notification_id"`
+	OrganizationID        uint64    `json:"org_id"`
+	FarmID                uint64    `json:"farm_id"`
+	Device                string    `json:"device"`
+	Priority              int       `json:"priority"`
+	Type                  string    `json:"type"`
+	Title                 string    `json:"title"`
+	Message               string    `json:"message"`
+	Timestamp             time.Time `gorm:"type:timestamp" json:"timestamp"`
+	Read                  bool      `gorm:"index" json:"read"`
+	ReadAt                time.Time `gorm:"type:timestamp" json:"read_at"`
+	InboxItemEntity       `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *InboxItem) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *InboxItem) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *InboxItem) GetUserID() uint64 {
+	return entity.UserID
+}
+
+func (entity *InboxItem) GetNotificationID() uint64 {
+	return entity.NotificationID
+}
+
+func (entity *InboxItem) IsRead() bool {
+	return entity.Read
+}
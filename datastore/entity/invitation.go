@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type InvitationEntity interface {
+	GetEmail() string
+	GetOrganizationID() uint64
+	GetFarmID() uint64
+	IsAccepted() bool
+	IsRevoked() bool
+	IsExpired(now time.Time) bool
+	IsPending(now time.Time) bool
+}
+
+// Invitation grants an email address a role in an organization or farm once
+// the invitee accepts the emailed invitation link. Organization invitations
+// have a zero FarmID; farm invitations carry the farm's organization, if any.
+// Only a SHA-256 hash of the invitation token secret is stored.
+type Invitation struct {
+	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
+	Email                 string    `gorm:"index;not null" json:"email"`
+	OrganizationID        uint64    `gorm:"index" json:"org_id"`
+	FarmID                uint64    `gorm:"index" json:"farm_id"`
+	RoleID                uint64    `gorm:"not null" json:"role_id"`
+	Role                  string    `json:"role"`
+	InvitedBy             uint64    `gorm:"not null" json:"invited_by"`
+	Hash                  string    `gorm:"not null" json:"hash,omitempty"`
+	CreatedAt             time.Time `gorm:"type:timestamp" json:"created_at"`
+	ExpiresAt             time.Time `gorm:"type:timestamp" json:"expires_at"`
+	AcceptedAt            time.Time `gorm:"type:timestamp" json:"accepted_at"`
+	AcceptedBy            uint64    `json:"accepted_by"`
+	RevokedAt             time.Time `gorm:"type:timestamp" json:"revoked_at"`
+	InvitationEntity      `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *Invitation) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *Invitation) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *Invitation) GetEmail() string {
+	return entity.Email
+}
+
+func (entity *Invitation) GetOrganizationID() uint64 {
+	return entity.OrganizationID
+}
+
+func (entity *Invitation) GetFarmID() uint64 {
+	return entity.FarmID
+}
+
+func (entity *Invitation) IsAccepted() bool {
+	return !entity.AcceptedAt.IsZero()
+}
+
+func (entity *Invitation) IsRevoked() bool {
+	return !entity.RevokedAt.IsZero()
+}
+
+func (entity *Invitation) IsExpired(now time.Time) bool {
+	return now.After(entity.ExpiresAt)
+}
+
+// Returns true if the invitation can still be accepted
+func (entity *Invitation) IsPending(now time.Time) bool {
+	return !entity.IsAccepted() && !entity.IsRevoked() && !entity.IsExpired(now)
+}
+
+// Returns a copy of the invitation without the secret hash
+func (entity *Invitation) Redact() *Invitation {
+	redacted := *entity
+	redacted.Hash = ""
+	return &redacted
+}
@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type LicenseEntity interface {
+	GetType() string
+	GetSubjectID() uint64
+	GetSerial() uint64
+	SigningBytes() []byte
+	ServerLicense() *config.ServerLicenseStruct
+	OrganizationLicense() *config.OrganizationLicenseStruct
+	FarmLicense() *config.FarmLicenseStruct
+}
+
+// License sets the quotas of the server, an organization or a farm. The
+// SubjectID is the organization or farm ID, and zero for the server license.
+// Licenses are signed by the CA when they're issued so quotas edited in the
+// database are rejected. The serial increases every time the license is
+// reissued so an older signed license can't be replayed. A zero quota is
+// unlimited.
+type License struct {
+	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
+	Type                  string    `gorm:"index;not null" json:"type"`
+	SubjectID             uint64    `gorm:"index" json:"subject_id"`
+	Serial                uint64    `gorm:"not null;default:0" json:"serial"`
+	OrganizationQuota     int       `json:"organization_quota"`
+	FarmQuota             int       `json:"farm_quota"`
+	UserQuota             int       `json:"user_quota"`
+	DeviceQuota           int       `json:"device_quota"`
+	IssuedAt              time.Time `gorm:"type:timestamp" json:"issued_at"`
+	Signature             string    `json:"signature,omitempty"`
+	LicenseEntity         `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *License) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *License) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *License) GetType() string {
+	return entity.Type
+}
+
+func (entity *License) GetSubjectID() uint64 {
+	return entity.SubjectID
+}
+
+func (entity *License) GetSerial() uint64 {
+	return entity.Serial
+}
+
+// Returns the canonical representation of the license that's signed
+func (entity *License) SigningBytes() []byte {
+	return []byte(fmt.Sprintf("%d|%s|%d|%d|%d|%d|%d|%d|%d",
+		entity.ID, entity.Type, entity.SubjectID, entity.Serial, entity.OrganizationQuota,
+		entity.FarmQuota, entity.UserQuota, entity.DeviceQuota,
+		entity.IssuedAt.UTC().UnixNano()))
+}
+
+func (entity *License) ServerLicense() *config.ServerLicenseStruct {
+	return &config.ServerLicenseStruct{
+		OrganizationQuota: entity.OrganizationQuota}
+}
+
+func (entity *License) OrganizationLicense() *config.OrganizationLicenseStruct {
+	return &config.OrganizationLicenseStruct{
+		OrganizationID: entity.SubjectID,
+		UserQuota:      entity.UserQuota,
+		FarmQuota:      entity.FarmQuota}
+}
+
+func (entity *License) FarmLicense() *config.FarmLicenseStruct {
+	return &config.FarmLicenseStruct{
+		FarmID:      entity.SubjectID,
+		DeviceQuota: entity.DeviceQuota,
+		UserQuota:   entity.UserQuota}
+}
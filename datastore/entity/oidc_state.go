@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type OIDCStateEntity interface {
+	GetLinkUserID() uint64
+	IsExpired(now time.Time) bool
+}
+
+// OIDCState is an OpenID Connect login that's been started at the provider
+// but not yet completed. The ID is derived from the state parameter sent to
+// the provider. Only SHA-256 hashes of the state and of the browser binding
+// cookie are stored, so the login can only be completed by the browser that
+// started it. LinkUserID is set when a signed in user is linking their
+// account to their provider identity.
+type OIDCState struct {
+	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
+	StateHash             string    `gorm:"not null" json:"state_hash"`
+	BindingHash           string    `gorm:"not null" json:"binding_hash"`
+	Verifier              string    `json:"verifier"`
+	Nonce                 string    `json:"nonce"`
+	LinkUserID            uint64    `json:"link_user_id"`
+	ExpiresAt             time.Time `gorm:"type:timestamp;index" json:"expires_at"`
+	OIDCStateEntity       `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *OIDCState) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *OIDCState) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *OIDCState) GetLinkUserID() uint64 {
+	return entity.LinkUserID
+}
+
+func (entity *OIDCState) IsExpired(now time.Time) bool {
+	return now.After(entity.ExpiresAt)
+}
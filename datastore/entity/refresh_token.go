@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type RefreshTokenEntity interface {
+	GetUserID() uint64
+	GetFamily() uint64
+	IsRotated() bool
+	IsRevoked() bool
+	IsExpired(now time.Time) bool
+}
+
+// RefreshToken is an opaque, single use credential exchanged for a new access
+// token. Each exchange rotates the refresh token, replacing it with a new token
+// in the same family. Every token issued from a single login shares a family,
+// so presenting a rotated token revokes the entire family. Only a SHA-256 hash
+// of the token secret is stored.
+type RefreshToken struct {
+	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
+	UserID                uint64    `gorm:"index;not null" json:"user_id"`
+	Family                uint64    `gorm:"index;not null" json:"family"`
+	Hash                  string    `gorm:"not null" json:"hash"`
+	RemoteAddress         string    `json:"remote_address"`
+	CreatedAt             time.Time `gorm:"type:timestamp" json:"created_at"`
+	ExpiresAt             time.Time `gorm:"type:timestamp" json:"expires_at"`
+	RotatedAt             time.Time `gorm:"type:timestamp" json:"rotated_at"`
+	RevokedAt             time.Time `gorm:"type:timestamp" json:"revoked_at"`
+	RefreshTokenEntity    `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *RefreshToken) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *RefreshToken) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *RefreshToken) GetUserID() uint64 {
+	return entity.UserID
+}
+
+func (entity *RefreshToken) GetFamily() uint64 {
+	return entity.Family
+}
+
+// Returns true if the token has already been exchanged for a new token
+func (entity *RefreshToken) IsRotated() bool {
+	return !entity.RotatedAt.IsZero()
+}
+
+func (entity *RefreshToken) IsRevoked() bool {
+	return !entity.RevokedAt.IsZero()
+}
+
+func (entity *RefreshToken) IsExpired(now time.Time) bool {
+	return now.After(entity.ExpiresAt)
+}
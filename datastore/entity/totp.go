@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type TOTPEntity interface {
+	GetAccount() string
+	GetRecoveryCodeList() []string
+	HasChallenge() bool
+	IsChallengeExpired(now time.Time) bool
+}
+
+// TOTP is a user's time-based one-time password enrollment. The ID is the
+// user ID. The enrollment isn't enabled until the user confirms it with a
+// code from their authenticator app. Recovery codes and MFA login challenges
+// are stored as SHA-256 hashes; recovery codes are stored as a comma
+// separated list.
+type TOTP struct {
+	ID                    uint64    `gorm:"primaryKey" yaml:"id" json:"id"`
+	Account               string    `json:"account"`
+	Secret                string    `json:"secret"`
+	Enabled               bool      `json:"enabled"`
+	RecoveryCodes         string    `json:"recovery_codes"`
+	LastUsedStep          int64     `json:"last_used_step"`
+	ChallengeHash         string    `json:"challenge_hash"`
+	ChallengeExpiresAt    time.Time `gorm:"type:timestamp" json:"challenge_expires_at"`
+	ChallengeAttempts     int       `json:"challenge_attempts"`
+	CreatedAt             time.Time `gorm:"type:timestamp" json:"created_at"`
+	EnabledAt             time.Time `gorm:"type:timestamp" json:"enabled_at"`
+	TOTPEntity            `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *TOTP) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *TOTP) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *TOTP) GetAccount() string {
+	return entity.Account
+}
+
+// Returns the hashes of the unused recovery codes
+func (entity *TOTP) GetRecoveryCodeList() []string {
+	codes := make([]string, 0)
+	for _, code := range strings.Split(entity.RecoveryCodes, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// Returns true if a login is waiting on the second factor
+func (entity *TOTP) HasChallenge() bool {
+	return entity.ChallengeHash != ""
+}
+
+func (entity *TOTP) IsChallengeExpired(now time.Time) bool {
+	return now.After(entity.ChallengeExpiresAt)
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
+
+type UserEmailEntity interface {
+	GetEmail() string
+	GetUserID() uint64
+}
+
+// UserEmail indexes a verified email address a user changed to. User IDs
+// are derived from the email address the account was created with and
+// never change, so the index resolves the new address to the account.
+// The ID is derived from the email address.
+type UserEmail struct {
+	ID                    uint64 `gorm:"primaryKey;autoIncrement:false" yaml:"id" json:"id"`
+	Email                 string `gorm:"not null" json:"email"`
+	UserID                uint64 `gorm:"index;not null" json:"user_id"`
+	UserEmailEntity       `gorm:"-" yaml:"-" json:"-"`
+	config.KeyValueEntity `gorm:"-" yaml:"-" json:"-"`
+}
+
+func (entity *UserEmail) SetID(id uint64) {
+	entity.ID = id
+}
+
+func (entity *UserEmail) Identifier() uint64 {
+	return entity.ID
+}
+
+func (entity *UserEmail) GetEmail() string {
+	return entity.Email
+}
+
+func (entity *UserEmail) GetUserID() uint64 {
+	return entity.UserID
+}
@@ -0,0 +1,66 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormAlarmDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.Alarm]
+	dao.AlarmDAO
+}
+
+func NewAlarmDAO(logger *logging.Logger, db *gorm.DB) dao.AlarmDAO {
+	return &GormAlarmDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.Alarm](logger, db)}
+}
+
+func (dao *GormAlarmDAO) Save(alarm *entity.Alarm) error {
+	return dao.db.Save(alarm).Error
+}
+
+func (dao *GormAlarmDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.Alarm, error) {
+	return dao.GenericGormDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns all of the farm's alarms, most recently raised first
+func (dao *GormAlarmDAO) GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.Alarm, error) {
+	dao.logger.Debugf("Getting alarms for farm %d", farmID)
+	var alarms []*entity.Alarm
+	if err := dao.db.
+		Where("farm_id = ?", farmID).
+		Order("raised_at desc").
+		Find(&alarms).Error; err != nil {
+
+		dao.logger.Error(err)
+		return nil, err
+	}
+	return alarms, nil
+}
+
+func (dao *GormAlarmDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.Alarm], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormAlarmDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.Alarm], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormAlarmDAO) Delete(alarm *entity.Alarm) error {
+	return dao.GenericGormDAO.Delete(alarm)
+}
+
+func (dao *GormAlarmDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
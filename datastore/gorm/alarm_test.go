@@ -0,0 +1,73 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlarm_CRUD(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.Alarm{})
+
+	alarmDAO := NewAlarmDAO(currentTest.logger, currentTest.gorm)
+
+	now := time.Now()
+	alarm1 := &entity.Alarm{
+		FarmID:     1,
+		DeviceID:   2,
+		DeviceType: "room",
+		MetricKey:  "tempF0",
+		Condition:  common.ALARM_CONDITION_HIGH,
+		State:      common.ALARM_STATE_CLEARED,
+		RaisedAt:   now.Add(-time.Hour)}
+	assert.Nil(t, alarmDAO.Save(alarm1))
+	assert.NotZero(t, alarm1.ID)
+
+	alarm2 := &entity.Alarm{
+		FarmID:     1,
+		DeviceID:   2,
+		DeviceType: "room",
+		MetricKey:  "tempF0",
+		Condition:  common.ALARM_CONDITION_LOW,
+		State:      common.ALARM_STATE_ACTIVE,
+		RaisedAt:   now}
+	assert.Nil(t, alarmDAO.Save(alarm2))
+
+	otherFarm := &entity.Alarm{
+		FarmID:     3,
+		DeviceID:   4,
+		DeviceType: "reservoir",
+		MetricKey:  "ph",
+		Condition:  common.ALARM_CONDITION_LOW,
+		State:      common.ALARM_STATE_ACTIVE,
+		RaisedAt:   now}
+	assert.Nil(t, alarmDAO.Save(otherFarm))
+
+	alarms, err := alarmDAO.GetByFarmID(1, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(alarms))
+	assert.Equal(t, alarm2.ID, alarms[0].ID)
+	assert.Equal(t, alarm1.ID, alarms[1].ID)
+
+	alarm2.State = common.ALARM_STATE_ACKNOWLEDGED
+	alarm2.AcknowledgedBy = 5
+	assert.Nil(t, alarmDAO.Save(alarm2))
+
+	persisted, err := alarmDAO.Get(alarm2.ID, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, common.ALARM_STATE_ACKNOWLEDGED, persisted.State)
+	assert.Equal(t, uint64(5), persisted.AcknowledgedBy)
+	assert.True(t, persisted.IsOpen())
+
+	assert.Nil(t, alarmDAO.Delete(alarm1))
+	count, err := alarmDAO.Count(common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+}
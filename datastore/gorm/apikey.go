@@ -0,0 +1,66 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormAPIKeyDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.APIKey]
+	dao.APIKeyDAO
+}
+
+func NewAPIKeyDAO(logger *logging.Logger, db *gorm.DB) dao.APIKeyDAO {
+	return &GormAPIKeyDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.APIKey](logger, db)}
+}
+
+func (dao *GormAPIKeyDAO) Save(apiKey *entity.APIKey) error {
+	return dao.db.Save(apiKey).Error
+}
+
+func (dao *GormAPIKeyDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.APIKey, error) {
+	return dao.GenericGormDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns the API keys created by the user, newest first
+func (dao *GormAPIKeyDAO) GetByCreatedBy(userID uint64, CONSISTENCY_LEVEL int) ([]*entity.APIKey, error) {
+	dao.logger.Debugf("Getting API keys created by user %d", userID)
+	var apiKeys []*entity.APIKey
+	if err := dao.db.
+		Where("created_by = ?", userID).
+		Order("created_at desc").
+		Find(&apiKeys).Error; err != nil {
+
+		dao.logger.Error(err)
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (dao *GormAPIKeyDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.APIKey], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormAPIKeyDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.APIKey], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormAPIKeyDAO) Delete(apiKey *entity.APIKey) error {
+	return dao.GenericGormDAO.Delete(apiKey)
+}
+
+func (dao *GormAPIKeyDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
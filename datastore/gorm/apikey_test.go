@@ -0,0 +1,68 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey_CRUD(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.APIKey{})
+
+	apiKeyDAO := NewAPIKeyDAO(currentTest.logger, currentTest.gorm)
+
+	now := time.Now()
+	apiKey1 := &entity.APIKey{
+		ServiceAccount: "grafana",
+		CreatedBy:      5,
+		Hash:           "hash1",
+		Farms:          "1,2",
+		Permissions:    "farm:read",
+		CreatedAt:      now.Add(-time.Hour)}
+	assert.Nil(t, apiKeyDAO.Save(apiKey1))
+	assert.NotZero(t, apiKey1.ID)
+
+	apiKey2 := &entity.APIKey{
+		ServiceAccount: "exporter",
+		CreatedBy:      5,
+		Hash:           "hash2",
+		Permissions:    "farm:read,device:switch",
+		CreatedAt:      now}
+	assert.Nil(t, apiKeyDAO.Save(apiKey2))
+
+	otherUser := &entity.APIKey{
+		ServiceAccount: "ci",
+		CreatedBy:      6,
+		Hash:           "hash3",
+		CreatedAt:      now}
+	assert.Nil(t, apiKeyDAO.Save(otherUser))
+
+	apiKeys, err := apiKeyDAO.GetByCreatedBy(5, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(apiKeys))
+	assert.Equal(t, apiKey2.ID, apiKeys[0].ID)
+	assert.Equal(t, apiKey1.ID, apiKeys[1].ID)
+
+	persisted, err := apiKeyDAO.Get(apiKey1.ID, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "hash1", persisted.Hash)
+	assert.Equal(t, []uint64{1, 2}, persisted.GetFarmIDs())
+	assert.False(t, persisted.IsRevoked())
+
+	persisted.RevokedAt = now
+	assert.Nil(t, apiKeyDAO.Save(persisted))
+
+	persisted, err = apiKeyDAO.Get(apiKey1.ID, 0)
+	assert.Nil(t, err)
+	assert.True(t, persisted.IsRevoked())
+
+	count, err := apiKeyDAO.Count(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+}
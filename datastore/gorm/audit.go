@@ -0,0 +1,125 @@
+package gorm
+
+import (
+	"fmt"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormAuditDAO struct {
+	logger *logging.Logger
+	db     *gorm.DB
+	dao.AuditDAO
+}
+
+func NewAuditDAO(logger *logging.Logger, db *gorm.DB) dao.AuditDAO {
+	return &GormAuditDAO{logger: logger, db: db}
+}
+
+// Appends the entry to the audit log if it's the next entry in the chain,
+// comparing its sequence number and previous hash with the last entry in
+// the same transaction as the insert. Returns datastore.ErrAuditConflict
+// if another entry was appended first.
+func (auditDAO *GormAuditDAO) Save(entry *entity.AuditEntry) error {
+	return auditDAO.db.Transaction(func(tx *gorm.DB) error {
+		var last entity.AuditEntry
+		err := tx.Order("id desc").First(&last).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if err == gorm.ErrRecordNotFound {
+			if entry.ID != 1 || entry.PrevHash != "" {
+				return datastore.ErrAuditConflict
+			}
+		} else if entry.ID != last.ID+1 || entry.PrevHash != last.Hash {
+			return datastore.ErrAuditConflict
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (auditDAO *GormAuditDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.AuditEntry, error) {
+	var entry entity.AuditEntry
+	if err := auditDAO.db.First(&entry, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, datastore.ErrRecordNotFound
+		}
+		auditDAO.logger.Error(err)
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Returns the most recent entry in the audit log
+func (auditDAO *GormAuditDAO) GetLast(CONSISTENCY_LEVEL int) (*entity.AuditEntry, error) {
+	var entry entity.AuditEntry
+	if err := auditDAO.db.Order("id desc").First(&entry).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, datastore.ErrRecordNotFound
+		}
+		auditDAO.logger.Error(err)
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Returns a page of audit entries ordered by sequence number
+func (auditDAO *GormAuditDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.AuditEntry], error) {
+
+	pageResult := dao.PageResult[*entity.AuditEntry]{
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize}
+	sortOrder := "asc"
+	if pageQuery.SortOrder == query.SORT_DESCENDING {
+		sortOrder = "desc"
+	}
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	var entries []*entity.AuditEntry
+	if err := auditDAO.db.
+		Order(fmt.Sprintf("id %s", sortOrder)).
+		Offset(offset).
+		Limit(pageQuery.PageSize + 1). // peek one record to set HasMore flag
+		Find(&entries).Error; err != nil {
+		return pageResult, err
+	}
+	if len(entries) == pageQuery.PageSize+1 {
+		pageResult.HasMore = true
+		entries = entries[:len(entries)-1]
+	}
+	pageResult.Entities = entries
+	return pageResult, nil
+}
+
+func (auditDAO *GormAuditDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.AuditEntry], CONSISTENCY_LEVEL int) error {
+
+	pageResult, err := auditDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+	if err != nil {
+		return err
+	}
+	if err = pagerProcFunc(pageResult.Entities); err != nil {
+		return err
+	}
+	if pageResult.HasMore {
+		nextPageQuery := query.PageQuery{
+			Page:      pageQuery.Page + 1,
+			PageSize:  pageQuery.PageSize,
+			SortOrder: pageQuery.SortOrder}
+		return auditDAO.ForEachPage(nextPageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+	}
+	return nil
+}
+
+func (auditDAO *GormAuditDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	var count int64
+	if err := auditDAO.db.Model(&entity.AuditEntry{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,92 @@
+package gorm
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit_AppendOnly(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.AuditEntry{})
+
+	auditDAO := NewAuditDAO(currentTest.logger, currentTest.gorm)
+
+	_, err := auditDAO.GetLast(common.CONSISTENCY_LOCAL)
+	assert.Equal(t, datastore.ErrRecordNotFound, err)
+
+	// The first entry has to start the chain
+	assert.Equal(t, datastore.ErrAuditConflict, auditDAO.Save(&entity.AuditEntry{
+		ID:       2,
+		Action:   common.AUDIT_ACTION_DEVICE_CONFIG,
+		PrevHash: "hash0",
+		Hash:     "hash2"}))
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	prevHash := ""
+	for i := uint64(1); i <= 3; i++ {
+		hash := fmt.Sprintf("hash%d", i)
+		assert.Nil(t, auditDAO.Save(&entity.AuditEntry{
+			ID:        i,
+			Timestamp: now.Add(time.Duration(i) * time.Second),
+			ActorID:   5,
+			Action:    common.AUDIT_ACTION_DEVICE_CONFIG,
+			PrevHash:  prevHash,
+			Hash:      hash}))
+		prevHash = hash
+	}
+
+	// Existing entries can't be overwritten
+	assert.Equal(t, datastore.ErrAuditConflict, auditDAO.Save(&entity.AuditEntry{
+		ID:       2,
+		Action:   common.AUDIT_ACTION_USER_DELETE,
+		PrevHash: "hash1",
+		Hash:     "forged"}))
+
+	// and entries are only appended to the head of the chain
+	assert.Equal(t, datastore.ErrAuditConflict, auditDAO.Save(&entity.AuditEntry{
+		ID:       4,
+		Action:   common.AUDIT_ACTION_USER_DELETE,
+		PrevHash: "hash2",
+		Hash:     "forged"}))
+
+	entry, err := auditDAO.Get(2, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, common.AUDIT_ACTION_DEVICE_CONFIG, entry.Action)
+	assert.True(t, now.Add(2*time.Second).Equal(entry.Timestamp))
+
+	last, err := auditDAO.GetLast(common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), last.ID)
+
+	page, err := auditDAO.GetPage(query.PageQuery{Page: 1, PageSize: 2,
+		SortOrder: query.SORT_DESCENDING}, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(page.Entities))
+	assert.Equal(t, uint64(3), page.Entities[0].ID)
+	assert.True(t, page.HasMore)
+
+	ids := make([]uint64, 0)
+	err = auditDAO.ForEachPage(query.PageQuery{Page: 1, PageSize: 2},
+		func(entries []*entity.AuditEntry) error {
+			for _, entry := range entries {
+				ids = append(ids, entry.ID)
+			}
+			return nil
+		}, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, ids)
+
+	count, err := auditDAO.Count(common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+}
@@ -18,9 +18,17 @@ func NewChannelDAO(logger *logging.Logger, db *gorm.DB) dao.ChannelDAO {
 	return &GormChannelDAO{logger: logger, db: db}
 }
 
-// FarmID is accepted to support key/value database compatibility.
+// Saves a channel belonging to one of the farm's devices
 func (channelDAO *GormChannelDAO) Save(farmID uint64, channel *config.ChannelStruct) error {
 	channelDAO.logger.Debugf("Saving channel record")
+	if err := existsInScope(channelDAO.db, &config.DeviceStruct{},
+		channel.GetDeviceID(), farmScope(farmID)); err != nil {
+		return err
+	}
+	if err := savableInScope(channelDAO.db, &config.ChannelStruct{},
+		channel.ID, farmDeviceScope(farmID)); err != nil {
+		return err
+	}
 	//return channelDAO.db.Save(channel.(*entity.Channel)).Error
 	return channelDAO.db.Save(channel).Error
 }
@@ -49,14 +57,15 @@ func (channelDAO *GormChannelDAO) GetByDevice(orgID, farmID,
 	return channels, nil
 }
 
-// The orgID and farmID are accepted to support
-// key/value database compatibility.
+// Looks up a channel belonging to one of the farm's devices. The orgID
+// is accepted to support key/value database compatibility.
 func (channelDAO *GormChannelDAO) Get(orgID, farmID,
 	channelID uint64, CONSISTENCY_LEVEL int) (*config.ChannelStruct, error) {
 
 	channelDAO.logger.Debugf("Getting channel id %d", channelID)
 	var entity config.ChannelStruct
-	if err := channelDAO.db.First(&entity, channelID).Error; err != nil {
+	if err := channelDAO.db.Scopes(farmDeviceScope(farmID)).
+		First(&entity, channelID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			channelDAO.logger.Warning(err)
 			return nil, datastore.ErrRecordNotFound
@@ -27,6 +27,7 @@ func TestChannelCRUD(t *testing.T) {
 	assert.NotNil(t, channelDAO)
 
 	org := dstest.CreateTestOrganization(currentTest.idGenerator)
+	assert.Nil(t, currentTest.saveFarms(org.GetFarms()...))
 
 	dstest.TestChannelCRUD(t, channelDAO, org)
 }
@@ -18,22 +18,40 @@ func NewConditionDAO(logger *logging.Logger, db *gorm.DB) dao.ConditionDAO {
 	return &GormConditionDAO{logger: logger, db: db}
 }
 
-// farmID, deviceID accepted to support key/value database
+// Saves a condition belonging to one of the farm's channels. The deviceID
+// is accepted to support key/value database
 func (dao *GormConditionDAO) Save(farmID, deviceID uint64, condition *config.ConditionStruct) error {
+	if err := existsInScope(dao.db, &config.ChannelStruct{},
+		condition.GetChannelID(), farmDeviceScope(farmID)); err != nil {
+		return err
+	}
+	if err := savableInScope(dao.db, &config.ConditionStruct{},
+		condition.ID, farmChannelScope(farmID)); err != nil {
+		return err
+	}
 	return dao.db.Save(condition).Error
 }
 
-// farmID, deviceID accepted to support key/value database
+// Deletes a condition belonging to one of the farm's channels. The
+// deviceID is accepted to support key/value database
 func (dao *GormConditionDAO) Delete(farmID, deviceID uint64, condition *config.ConditionStruct) error {
-	return dao.db.Delete(condition).Error
+	result := dao.db.Scopes(farmChannelScope(farmID)).Delete(condition)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return datastore.ErrRecordNotFound
+	}
+	return nil
 }
 
-// farmID, deviceID, channelID accepted to support key/value database
+// deviceID, channelID accepted to support key/value database
 func (dao *GormConditionDAO) Get(farmID, deviceID, channelID, conditionID uint64,
 	CONSISTENCY_LEVEL int) (*config.ConditionStruct, error) {
 
-	var condition *config.ConditionStruct
-	if err := dao.db.First(condition, conditionID).Error; err != nil {
+	var condition config.ConditionStruct
+	if err := dao.db.Scopes(farmChannelScope(farmID)).
+		First(&condition, conditionID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			dao.logger.Warning(err)
 			return nil, datastore.ErrRecordNotFound
@@ -41,15 +59,16 @@ func (dao *GormConditionDAO) Get(farmID, deviceID, channelID, conditionID uint64
 		dao.logger.Error(err)
 		return nil, err
 	}
-	return condition, nil
+	return &condition, nil
 }
 
-// farmID, deviceID accepted to support key/value database
+// deviceID accepted to support key/value database
 func (dao *GormConditionDAO) GetByChannelID(farmID, deviceID,
 	channelID uint64, CONSISTENCY_LEVEL int) ([]*config.ConditionStruct, error) {
 
 	var entities []*config.ConditionStruct
-	if err := dao.db.Where("channel_id = ?", channelID).
+	if err := dao.db.Scopes(farmChannelScope(farmID)).
+		Where("channel_id = ?", channelID).
 		Find(&entities).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			dao.logger.Warning(err)
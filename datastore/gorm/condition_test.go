@@ -20,6 +20,7 @@ func TestConditionCRUD(t *testing.T) {
 	assert.NotNil(t, conditionDAO)
 
 	org := dstest.CreateTestOrganization(currentTest.idGenerator)
+	assert.Nil(t, currentTest.saveFarms(org.GetFarms()...))
 
 	dstest.TestConditionCRUD(t, conditionDAO, org)
 }
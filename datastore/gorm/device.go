@@ -18,12 +18,17 @@ func NewDeviceDAO(logger *logging.Logger, db *gorm.DB) dao.DeviceDAO {
 	return &GormDeviceDAO{logger: logger, db: db}
 }
 
+// Saves a device. Devices can't be moved to another farm.
 func (dao *GormDeviceDAO) Save(device *config.DeviceStruct) error {
 	dao.logger.Debugf("Creating device record")
+	if err := savableInScope(dao.db, &config.DeviceStruct{},
+		device.ID, farmScope(device.GetFarmID())); err != nil {
+		return err
+	}
 	return dao.db.Save(device).Error
 }
 
-// FarmID is used to maintain interface compatibility with Raft
+// Looks up a device belonging to the farm
 func (dao *GormDeviceDAO) Get(farmID, deviceID uint64,
 	CONSISTENCY_LEVEL int) (*config.DeviceStruct, error) {
 
@@ -35,6 +40,7 @@ func (dao *GormDeviceDAO) Get(farmID, deviceID uint64,
 		Preload("Channels").
 		Preload("Channels.Conditions").
 		Preload("Channels.Schedule").
+		Scopes(farmScope(farmID)).
 		First(&devices, deviceID).Error; err != nil {
 
 		if err == gorm.ErrRecordNotFound {
@@ -2,6 +2,7 @@ package gorm
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
 	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
@@ -25,6 +26,10 @@ func (dao *GormEventLogDAO) Save(log *entity.EventLog) error {
 	return dao.db.Save(log).Error
 }
 
+func (eventLogDAO *GormEventLogDAO) Delete(log *entity.EventLog) error {
+	return eventLogDAO.db.Delete(log).Error
+}
+
 func (eventLogDAO *GormEventLogDAO) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLog], error) {
 	pageResult := dao.PageResult[*entity.EventLog]{
 		Page:     pageQuery.Page,
@@ -54,6 +59,60 @@ func (eventLogDAO *GormEventLogDAO) GetPage(pageQuery query.PageQuery, CONSISTEN
 	return pageResult, nil
 }
 
+// Returns a page of the farm's event log entries matching the filter
+func (eventLogDAO *GormEventLogDAO) GetPageByFilter(filter dao.EventLogFilter, pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLog], error) {
+
+	pageResult := dao.PageResult[*entity.EventLog]{
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize}
+	var sortOrder string
+	if pageQuery.SortOrder == query.SORT_ASCENDING {
+		sortOrder = "asc"
+	} else {
+		sortOrder = "desc"
+	}
+	tx := eventLogDAO.db.Where("farm_id = ?", eventLogDAO.farmID)
+	if !filter.Start.IsZero() {
+		tx = tx.Where("timestamp >= ?", filter.Start)
+	}
+	if !filter.End.IsZero() {
+		tx = tx.Where("timestamp <= ?", filter.End)
+	}
+	if filter.DeviceID != 0 {
+		tx = tx.Where("device_id = ?", filter.DeviceID)
+	}
+	if filter.ChannelID != 0 {
+		tx = tx.Where("channel_id = ?", filter.ChannelID)
+	}
+	if filter.EventType != "" {
+		tx = tx.Where("event_type = ?", filter.EventType)
+	}
+	if filter.ActorID != 0 {
+		tx = tx.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Source != "" {
+		tx = tx.Where("source = ?", filter.Source)
+	}
+	if filter.Search != "" {
+		tx = tx.Where("LOWER(message) LIKE ?", "%"+strings.ToLower(filter.Search)+"%")
+	}
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	var logs []*entity.EventLog
+	if err := tx.Offset(offset).
+		Order(fmt.Sprintf("timestamp %s", sortOrder)).
+		Limit(pageQuery.PageSize + 1). // peek one record to set HasMore flag
+		Find(&logs).Error; err != nil {
+		return pageResult, err
+	}
+	if len(logs) == pageQuery.PageSize+1 {
+		pageResult.HasMore = true
+		logs = logs[:len(logs)-1]
+	}
+	pageResult.Entities = logs
+	return pageResult, nil
+}
+
 func (eventLogDAO *GormEventLogDAO) ForEachPage(pageQuery query.PageQuery,
 	pagerProcFunc query.PagerProcFunc[*entity.EventLog], CONSISTENCY_LEVEL int) error {
 
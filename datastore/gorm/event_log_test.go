@@ -0,0 +1,105 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLog_GetPageByFilter(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.EventLog{})
+
+	eventLogDAO := NewEventLogDAO(currentTest.logger, currentTest.gorm, 1)
+	otherFarmDAO := NewEventLogDAO(currentTest.logger, currentTest.gorm, 2)
+
+	now := time.Now().Truncate(time.Second)
+	events := []*entity.EventLog{
+		{DeviceID: 10, DeviceName: "room", EventType: common.EVENT_TYPE_SWITCH,
+			ChannelID: 100, ActorID: 5, Source: common.EVENT_SOURCE_MANUAL,
+			Message: "admin switching on room Light", Timestamp: now.Add(-3 * time.Hour)},
+		{DeviceID: 10, DeviceName: "room", EventType: common.EVENT_TYPE_SWITCH,
+			ChannelID: 100, Source: common.EVENT_SOURCE_SCHEDULE,
+			Message: "Switching OFF scheduled Light.", Timestamp: now.Add(-2 * time.Hour)},
+		{DeviceID: 11, DeviceName: "reservoir", EventType: common.EVENT_TYPE_TIMER_SWITCH,
+			ChannelID: 101, Source: common.EVENT_SOURCE_ALGORITHM, MetricKey: "ph0", Value: 6.9,
+			Message: "pH: 6.90, auto-dosing pH Down for 4 seconds", Timestamp: now.Add(-time.Hour)},
+		{DeviceID: 10, DeviceName: "room", EventType: common.EVENT_TYPE_POLL,
+			Source: common.EVENT_SOURCE_SYSTEM, Message: "timeout", Timestamp: now}}
+	for _, event := range events {
+		event.FarmID = 1
+		assert.Nil(t, eventLogDAO.Save(event))
+	}
+	assert.Nil(t, otherFarmDAO.Save(&entity.EventLog{FarmID: 2, DeviceID: 10,
+		EventType: common.EVENT_TYPE_SWITCH, Message: "other farm", Timestamp: now}))
+
+	pageQuery := query.PageQuery{Page: 1, PageSize: 10, SortOrder: query.SORT_DESCENDING}
+	messages := func(filter dao.EventLogFilter) []string {
+		page, err := eventLogDAO.GetPageByFilter(filter, pageQuery, common.CONSISTENCY_LOCAL)
+		assert.Nil(t, err)
+		messages := make([]string, len(page.Entities))
+		for i, event := range page.Entities {
+			messages[i] = event.GetMessage()
+		}
+		return messages
+	}
+
+	assert.Equal(t, 4, len(messages(dao.EventLogFilter{})))
+	assert.Equal(t, []string{"Switching OFF scheduled Light.", "admin switching on room Light"},
+		messages(dao.EventLogFilter{EventType: common.EVENT_TYPE_SWITCH}))
+	assert.Equal(t, []string{"admin switching on room Light"},
+		messages(dao.EventLogFilter{ActorID: 5}))
+	assert.Equal(t, []string{"pH: 6.90, auto-dosing pH Down for 4 seconds"},
+		messages(dao.EventLogFilter{ChannelID: 101, Source: common.EVENT_SOURCE_ALGORITHM}))
+	assert.Equal(t, []string{"timeout", "Switching OFF scheduled Light."},
+		messages(dao.EventLogFilter{DeviceID: 10, Start: now.Add(-150 * time.Minute)}))
+	assert.Equal(t, []string{"pH: 6.90, auto-dosing pH Down for 4 seconds", "Switching OFF scheduled Light."},
+		messages(dao.EventLogFilter{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}))
+	assert.Equal(t, []string{"Switching OFF scheduled Light.", "admin switching on room Light"},
+		messages(dao.EventLogFilter{Search: "LIGHT"}))
+
+	pageQuery.PageSize = 3
+	page, err := eventLogDAO.GetPageByFilter(dao.EventLogFilter{}, pageQuery, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(page.Entities))
+	assert.True(t, page.HasMore)
+
+	pageQuery.Page = 2
+	page, err = eventLogDAO.GetPageByFilter(dao.EventLogFilter{}, pageQuery, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.Entities))
+	assert.False(t, page.HasMore)
+	assert.Equal(t, "admin switching on room Light", page.Entities[0].GetMessage())
+}
+
+func TestEventLog_Delete(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.EventLog{})
+
+	eventLogDAO := NewEventLogDAO(currentTest.logger, currentTest.gorm, 1)
+
+	event := &entity.EventLog{FarmID: 1, DeviceID: 10, DeviceName: "room",
+		EventType: common.EVENT_TYPE_SWITCH, Message: "expired", Timestamp: time.Now()}
+	assert.Nil(t, eventLogDAO.Save(event))
+	assert.Nil(t, eventLogDAO.Save(&entity.EventLog{FarmID: 1, DeviceID: 10, DeviceName: "room",
+		EventType: common.EVENT_TYPE_SWITCH, Message: "current", Timestamp: time.Now()}))
+
+	assert.Nil(t, eventLogDAO.Delete(event))
+
+	page, err := eventLogDAO.GetPageByFilter(dao.EventLogFilter{}, query.NewPageQuery(),
+		common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.Entities))
+	assert.Equal(t, "current", page.Entities[0].GetMessage())
+}
@@ -0,0 +1,65 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormEventLogArchiveDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.EventLogArchive]
+	dao.EventLogArchiveDAO
+}
+
+func NewEventLogArchiveDAO(logger *logging.Logger, db *gorm.DB) dao.EventLogArchiveDAO {
+	return &GormEventLogArchiveDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.EventLogArchive](logger, db)}
+}
+
+func (dao *GormEventLogArchiveDAO) Save(archive *entity.EventLogArchive) error {
+	return dao.db.Save(archive).Error
+}
+
+func (dao *GormEventLogArchiveDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.EventLogArchive, error) {
+	return dao.GenericGormDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns the farm's event log archives, oldest first
+func (dao *GormEventLogArchiveDAO) GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.EventLogArchive, error) {
+	var archives []*entity.EventLogArchive
+	if err := dao.db.
+		Where("farm_id = ?", farmID).
+		Order("name asc").
+		Find(&archives).Error; err != nil {
+
+		dao.logger.Error(err)
+		return nil, err
+	}
+	return archives, nil
+}
+
+func (dao *GormEventLogArchiveDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLogArchive], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormEventLogArchiveDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.EventLogArchive], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormEventLogArchiveDAO) Delete(archive *entity.EventLogArchive) error {
+	return dao.GenericGormDAO.Delete(archive)
+}
+
+func (dao *GormEventLogArchiveDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
@@ -0,0 +1,64 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLogArchive_CRUD(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.EventLogArchive{})
+
+	archiveDAO := NewEventLogArchiveDAO(currentTest.logger, currentTest.gorm)
+
+	now := time.Now()
+	newer := &entity.EventLogArchive{
+		FarmID:    1,
+		Name:      "eventlog-1-20240301T000000Z.jsonl.gz",
+		Before:    now,
+		Entries:   10,
+		CreatedAt: now}
+	assert.Nil(t, archiveDAO.Save(newer))
+	assert.NotZero(t, newer.ID)
+
+	older := &entity.EventLogArchive{
+		FarmID:    1,
+		Name:      "eventlog-1-20240201T000000Z.jsonl.gz",
+		Before:    now.AddDate(0, -1, 0),
+		Entries:   20,
+		CreatedAt: now}
+	assert.Nil(t, archiveDAO.Save(older))
+
+	assert.Nil(t, archiveDAO.Save(&entity.EventLogArchive{
+		FarmID:    2,
+		Name:      "eventlog-2-20240301T000000Z.jsonl.gz",
+		CreatedAt: now}))
+
+	archives, err := archiveDAO.GetByFarmID(1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(archives))
+	assert.Equal(t, older.ID, archives[0].ID)
+	assert.Equal(t, 20, archives[0].Entries)
+	assert.False(t, archives[0].IsRestored())
+
+	older.RestoredAt = now
+	assert.Nil(t, archiveDAO.Save(older))
+	persisted, err := archiveDAO.Get(older.ID, 0)
+	assert.Nil(t, err)
+	assert.True(t, persisted.IsRestored())
+
+	count, err := archiveDAO.Count(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+
+	assert.Nil(t, archiveDAO.Delete(newer))
+	archives, err = archiveDAO.GetByFarmID(1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(archives))
+}
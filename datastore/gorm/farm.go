@@ -75,6 +75,8 @@ func (farmDAO *GormFarmDAO) Get(farmID uint64, CONSISTENCY_LEVEL int) (*config.F
 		Preload("Workflows.Conditions").
 		Preload("Workflows.Schedules").
 		Preload("Workflows.Steps").
+		Preload("Notifiers").
+		Preload("NotificationRules").
 		First(&farm, farmID).Error; err != nil {
 
 		if err == gorm.ErrRecordNotFound {
@@ -115,6 +117,8 @@ func (farmDAO *GormFarmDAO) GetByIds(farmIds []uint64, CONSISTENCY_LEVEL int) ([
 		Preload("Workflows.Conditions").
 		Preload("Workflows.Schedules").
 		Preload("Workflows.Steps").
+		Preload("Notifiers").
+		Preload("NotificationRules").
 		Where("id IN (?)", farmIds).
 		Find(&farms).Error; err != nil {
 
@@ -163,6 +167,8 @@ func (farmDAO *GormFarmDAO) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL
 		Preload("Workflows.Conditions").
 		Preload("Workflows.Schedules").
 		Preload("Workflows.Steps").
+		Preload("Notifiers").
+		Preload("NotificationRules").
 		Offset(offset).
 		Limit(pageQuery.PageSize + 1). // peek one record to set HasMore flag
 		Find(&farms).Error; err != nil {
@@ -232,6 +238,8 @@ func (farmDAO *GormFarmDAO) GetByUserID(userID uint64, CONSISTENCY_LEVEL int) ([
 		Preload("Workflows.Conditions").
 		Preload("Workflows.Schedules").
 		Preload("Workflows.Steps").
+		Preload("Notifiers").
+		Preload("NotificationRules").
 		Joins("JOIN permissions on permissions.farm_id = farms.id").
 		Where("permissions.user_id = ?", userID).
 		Find(&farms).Error; err != nil {
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/jeremyhahn/go-cropdroid/config"
+	dsentity "github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/datastore/gorm/entity"
 	"github.com/jeremyhahn/go-cropdroid/util"
 
@@ -131,6 +132,8 @@ func (database *GormDatabase) Migrate() error {
 	database.db.AutoMigrate(config.OrganizationLicenseStruct{})
 	database.db.AutoMigrate(config.FarmLicenseStruct{})
 	database.db.AutoMigrate(config.MetricStruct{})
+	database.db.AutoMigrate(config.NotificationRuleStruct{})
+	database.db.AutoMigrate(config.NotifierStruct{})
 	database.db.AutoMigrate(config.OrganizationStruct{})
 	database.db.AutoMigrate(config.PermissionStruct{})
 	database.db.AutoMigrate(config.RegistrationStruct{})
@@ -141,7 +144,18 @@ func (database *GormDatabase) Migrate() error {
 	database.db.AutoMigrate(config.WorkflowStepStruct{})
 	database.db.AutoMigrate(config.WorkflowStruct{})
 	// Entities
-	database.db.AutoMigrate(entity.EventLog{})
+	database.db.AutoMigrate(dsentity.Alarm{})
+	database.db.AutoMigrate(dsentity.AuditEntry{})
+	database.db.AutoMigrate(dsentity.APIKey{})
+	database.db.AutoMigrate(dsentity.RefreshToken{})
+	database.db.AutoMigrate(dsentity.TOTP{})
+	database.db.AutoMigrate(dsentity.Invitation{})
+	database.db.AutoMigrate(dsentity.License{})
+	database.db.AutoMigrate(dsentity.OIDCState{})
+	database.db.AutoMigrate(dsentity.EventLog{})
+	database.db.AutoMigrate(dsentity.EventLogArchive{})
+	database.db.AutoMigrate(dsentity.UserEmail{})
+	database.db.AutoMigrate(dsentity.InboxItem{})
 	database.db.AutoMigrate(entity.InventoryType{})
 	database.db.AutoMigrate(entity.Inventory{})
 
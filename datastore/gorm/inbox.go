@@ -0,0 +1,147 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormInboxDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.InboxItem]
+	dao.InboxDAO
+}
+
+func NewInboxDAO(logger *logging.Logger, db *gorm.DB) dao.InboxDAO {
+	return &GormInboxDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.InboxItem](logger, db)}
+}
+
+// Saves the item. New items beyond the user's INBOX_RETENTION
+// most recent items evict the user's oldest items.
+func (dao *GormInboxDAO) Save(item *entity.InboxItem) error {
+	isNew := item.ID == 0
+	if err := dao.db.Save(item).Error; err != nil {
+		dao.logger.Error(err)
+		return err
+	}
+	if !isNew {
+		return nil
+	}
+	var evicted []*entity.InboxItem
+	if err := dao.db.
+		Where("user_id = ?", item.UserID).
+		Order("id desc").
+		Offset(common.INBOX_RETENTION).
+		Limit(1).
+		Find(&evicted).Error; err != nil || len(evicted) == 0 {
+
+		return err
+	}
+	return dao.db.
+		Where("user_id = ? AND id <= ?", item.UserID, evicted[0].ID).
+		Delete(&entity.InboxItem{}).Error
+}
+
+func (dao *GormInboxDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.InboxItem, error) {
+	return dao.GenericGormDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns a page of the user's inbox, newest item first
+func (inboxDAO *GormInboxDAO) GetByUserID(userID uint64, pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.InboxItem], error) {
+
+	pageResult := dao.PageResult[*entity.InboxItem]{
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize}
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	var items []*entity.InboxItem
+	if err := inboxDAO.db.
+		Offset(offset).
+		Where("user_id = ?", userID).
+		Order("id desc").
+		Limit(pageQuery.PageSize + 1). // peek one record to set HasMore flag
+		Find(&items).Error; err != nil {
+
+		inboxDAO.logger.Error(err)
+		return pageResult, err
+	}
+	if len(items) == pageQuery.PageSize+1 {
+		pageResult.HasMore = true
+		items = items[:len(items)-1]
+	}
+	pageResult.Entities = items
+	return pageResult, nil
+}
+
+// Returns the user's inbox items added after the item for the given
+// notification, oldest first. All of the user's items are returned when
+// the notification ID is zero or its item is no longer in the inbox.
+func (dao *GormInboxDAO) GetSince(userID, notificationID uint64,
+	CONSISTENCY_LEVEL int) ([]*entity.InboxItem, error) {
+
+	var cursor []*entity.InboxItem
+	if notificationID > 0 {
+		if err := dao.db.
+			Where("user_id = ? AND notification_id = ?", userID, notificationID).
+			Limit(1).
+			Find(&cursor).Error; err != nil {
+
+			dao.logger.Error(err)
+			return nil, err
+		}
+	}
+	after := uint64(0)
+	if len(cursor) > 0 {
+		after = cursor[0].ID
+	}
+	var items []*entity.InboxItem
+	if err := dao.db.
+		Where("user_id = ? AND id > ?", userID, after).
+		Order("id asc").
+		Find(&items).Error; err != nil {
+
+		dao.logger.Error(err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// Returns the number of unread items in the user's inbox
+func (dao *GormInboxDAO) CountUnread(userID uint64, CONSISTENCY_LEVEL int) (int64, error) {
+	var count int64
+	if err := dao.db.Model(&entity.InboxItem{}).
+		Where(map[string]interface{}{"user_id": userID, "read": false}).
+		Count(&count).Error; err != nil {
+
+		dao.logger.Error(err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func (dao *GormInboxDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.InboxItem], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormInboxDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.InboxItem], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormInboxDAO) Delete(item *entity.InboxItem) error {
+	return dao.GenericGormDAO.Delete(item)
+}
+
+func (dao *GormInboxDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
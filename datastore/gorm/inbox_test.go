@@ -0,0 +1,116 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInbox_CRUD(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.InboxItem{})
+
+	inboxDAO := NewInboxDAO(currentTest.logger, currentTest.gorm)
+
+	now := time.Now()
+	for i := 1; i <= 3; i++ {
+		item := &entity.InboxItem{
+			UserID:         1,
+			NotificationID: uint64(100 + i),
+			FarmID:         2,
+			Type:           "Test",
+			Message:        "test notification",
+			Timestamp:      now}
+		assert.Nil(t, inboxDAO.Save(item))
+		assert.NotZero(t, item.ID)
+	}
+	otherUser := &entity.InboxItem{UserID: 5, NotificationID: 104, Timestamp: now}
+	assert.Nil(t, inboxDAO.Save(otherUser))
+
+	pageQuery := query.NewPageQuery()
+	pageQuery.PageSize = 2
+	page1, err := inboxDAO.GetByUserID(1, pageQuery, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.True(t, page1.HasMore)
+	assert.Equal(t, 2, len(page1.Entities))
+	assert.Equal(t, uint64(103), page1.Entities[0].NotificationID)
+	assert.Equal(t, uint64(102), page1.Entities[1].NotificationID)
+
+	pageQuery.Page = 2
+	page2, err := inboxDAO.GetByUserID(1, pageQuery, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.False(t, page2.HasMore)
+	assert.Equal(t, 1, len(page2.Entities))
+	assert.Equal(t, uint64(101), page2.Entities[0].NotificationID)
+
+	since, err := inboxDAO.GetSince(1, 101, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(since))
+	assert.Equal(t, uint64(102), since[0].NotificationID)
+	assert.Equal(t, uint64(103), since[1].NotificationID)
+
+	unread, err := inboxDAO.CountUnread(1, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), unread)
+
+	item := page1.Entities[0]
+	item.Read = true
+	item.ReadAt = now
+	assert.Nil(t, inboxDAO.Save(item))
+
+	persisted, err := inboxDAO.Get(item.ID, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.True(t, persisted.IsRead())
+
+	unread, err = inboxDAO.CountUnread(1, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), unread)
+
+	assert.Nil(t, inboxDAO.Delete(otherUser))
+	count, err := inboxDAO.Count(common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestInbox_RetentionAndCursor(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.InboxItem{})
+
+	inboxDAO := NewInboxDAO(currentTest.logger, currentTest.gorm)
+
+	now := time.Now()
+	for i := 1; i <= common.INBOX_RETENTION+2; i++ {
+		assert.Nil(t, inboxDAO.Save(&entity.InboxItem{
+			UserID:         1,
+			NotificationID: uint64(i),
+			Timestamp:      now}))
+	}
+	assert.Nil(t, inboxDAO.Save(&entity.InboxItem{UserID: 2, NotificationID: 1, Timestamp: now}))
+
+	// Only the most recent items are retained for each user
+	count, err := inboxDAO.Count(common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(common.INBOX_RETENTION+1), count)
+
+	// The cursor replays the items added after the cursor's item
+	since, err := inboxDAO.GetSince(1, uint64(common.INBOX_RETENTION), common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(since))
+	assert.Equal(t, uint64(common.INBOX_RETENTION+1), since[0].NotificationID)
+
+	// and everything still in the inbox once the cursor's item is evicted
+	since, err = inboxDAO.GetSince(1, 1, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, common.INBOX_RETENTION, len(since))
+	assert.Equal(t, uint64(3), since[0].NotificationID)
+}
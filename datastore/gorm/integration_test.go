@@ -8,9 +8,11 @@ import (
 	"time"
 
 	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
 	"github.com/jeremyhahn/go-cropdroid/util"
 	logging "github.com/op/go-logging"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var CurrentTest *DatastoreTest = &DatastoreTest{mutex: &sync.Mutex{}}
@@ -70,6 +72,34 @@ func (dt *DatastoreTest) Cleanup() {
 	}
 }
 
+// Saves the farms along with their devices, channels and workflows so
+// the farm child record DAOs can find the farm their records belong to.
+// The child records themselves are left for the DAO under test to save.
+func (dt *DatastoreTest) saveFarms(farms ...*config.FarmStruct) error {
+	db := dt.gorm.Omit(clause.Associations).Session(&gorm.Session{})
+	for _, farm := range farms {
+		if err := db.Save(farm).Error; err != nil {
+			return err
+		}
+		for _, device := range farm.GetDevices() {
+			if err := db.Save(device).Error; err != nil {
+				return err
+			}
+			for _, channel := range device.GetChannels() {
+				if err := db.Save(channel).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for _, workflow := range farm.GetWorkflows() {
+			if err := db.Save(workflow).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func createSqliteParams() *GormInitParams {
 	return &GormInitParams{
 		DebugFlag: true,
@@ -0,0 +1,79 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormInvitationDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.Invitation]
+	dao.InvitationDAO
+}
+
+func NewInvitationDAO(logger *logging.Logger, db *gorm.DB) dao.InvitationDAO {
+	return &GormInvitationDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.Invitation](logger, db)}
+}
+
+func (dao *GormInvitationDAO) Save(invitation *entity.Invitation) error {
+	return dao.db.Save(invitation).Error
+}
+
+func (dao *GormInvitationDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.Invitation, error) {
+	return dao.GenericGormDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns all of the invitations to the organization and its farms
+func (dao *GormInvitationDAO) GetByOrganizationID(orgID uint64, CONSISTENCY_LEVEL int) ([]*entity.Invitation, error) {
+	var invitations []*entity.Invitation
+	if err := dao.db.
+		Where("organization_id = ?", orgID).
+		Order("created_at desc").
+		Find(&invitations).Error; err != nil {
+
+		dao.logger.Error(err)
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// Returns all of the invitations to the farm
+func (dao *GormInvitationDAO) GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.Invitation, error) {
+	var invitations []*entity.Invitation
+	if err := dao.db.
+		Where("farm_id = ?", farmID).
+		Order("created_at desc").
+		Find(&invitations).Error; err != nil {
+
+		dao.logger.Error(err)
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (dao *GormInvitationDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.Invitation], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormInvitationDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.Invitation], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormInvitationDAO) Delete(invitation *entity.Invitation) error {
+	return dao.GenericGormDAO.Delete(invitation)
+}
+
+func (dao *GormInvitationDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
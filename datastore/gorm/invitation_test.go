@@ -0,0 +1,87 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitation_CRUD(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.Invitation{})
+
+	invitationDAO := NewInvitationDAO(currentTest.logger, currentTest.gorm)
+
+	now := time.Now()
+	orgInvitation := &entity.Invitation{
+		Email:          "grower@example.com",
+		OrganizationID: 10,
+		RoleID:         3,
+		Role:           "cultivator",
+		InvitedBy:      1,
+		Hash:           "hash1",
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(time.Hour)}
+	assert.Nil(t, invitationDAO.Save(orgInvitation))
+	assert.NotZero(t, orgInvitation.ID)
+
+	farmInvitation := &entity.Invitation{
+		Email:          "analyst@example.com",
+		OrganizationID: 10,
+		FarmID:         20,
+		RoleID:         4,
+		Role:           "analyst",
+		InvitedBy:      1,
+		Hash:           "hash2",
+		CreatedAt:      now.Add(time.Second),
+		ExpiresAt:      now.Add(time.Hour)}
+	assert.Nil(t, invitationDAO.Save(farmInvitation))
+
+	otherFarm := &entity.Invitation{
+		Email:     "grower@example.com",
+		FarmID:    30,
+		RoleID:    3,
+		InvitedBy: 1,
+		Hash:      "hash3",
+		CreatedAt: now,
+		ExpiresAt: now.Add(-time.Minute)}
+	assert.Nil(t, invitationDAO.Save(otherFarm))
+
+	invitations, err := invitationDAO.GetByOrganizationID(10, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(invitations))
+	assert.Equal(t, farmInvitation.ID, invitations[0].ID)
+
+	invitations, err = invitationDAO.GetByFarmID(20, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(invitations))
+	assert.Equal(t, "analyst@example.com", invitations[0].GetEmail())
+
+	persisted, err := invitationDAO.Get(otherFarm.ID, 0)
+	assert.Nil(t, err)
+	assert.True(t, persisted.IsExpired(now))
+	assert.False(t, persisted.IsPending(now))
+
+	persisted, err = invitationDAO.Get(orgInvitation.ID, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "hash1", persisted.Hash)
+	assert.True(t, persisted.IsPending(now))
+
+	persisted.AcceptedAt = now
+	persisted.AcceptedBy = 7
+	assert.Nil(t, invitationDAO.Save(persisted))
+	persisted, err = invitationDAO.Get(orgInvitation.ID, 0)
+	assert.Nil(t, err)
+	assert.True(t, persisted.IsAccepted())
+	assert.False(t, persisted.IsPending(now))
+
+	assert.Nil(t, invitationDAO.Delete(persisted))
+	count, err := invitationDAO.Count(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+}
@@ -0,0 +1,51 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormLicenseDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.License]
+	dao.LicenseDAO
+}
+
+func NewLicenseDAO(logger *logging.Logger, db *gorm.DB) dao.LicenseDAO {
+	return &GormLicenseDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.License](logger, db)}
+}
+
+func (dao *GormLicenseDAO) Save(license *entity.License) error {
+	return dao.db.Save(license).Error
+}
+
+func (dao *GormLicenseDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.License, error) {
+	return dao.GenericGormDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormLicenseDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.License], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormLicenseDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.License], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormLicenseDAO) Delete(license *entity.License) error {
+	return dao.GenericGormDAO.Delete(license)
+}
+
+func (dao *GormLicenseDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
@@ -20,17 +20,29 @@ func NewMetricDAO(logger *logging.Logger, db *gorm.DB) dao.MetricDAO {
 	return &GormMetricDAO{logger: logger, db: db}
 }
 
+// Saves a metric belonging to one of the farm's devices
 func (metricDAO *GormMetricDAO) Save(farmID uint64, metric *config.MetricStruct) error {
 	metricDAO.logger.Debugf(fmt.Sprintf("Saving metric record: %+v", metric))
+	if err := existsInScope(metricDAO.db, &config.DeviceStruct{},
+		metric.GetDeviceID(), farmScope(farmID)); err != nil {
+		return err
+	}
+	if err := savableInScope(metricDAO.db, &config.MetricStruct{},
+		metric.ID, farmDeviceScope(farmID)); err != nil {
+		return err
+	}
 	return metricDAO.db.Save(metric).Error
 }
 
+// Looks up a metric belonging to one of the farm's devices. The deviceID
+// is accepted to support key/value database compatibility.
 func (metricDAO *GormMetricDAO) Get(farmID, deviceID,
 	metricID uint64, CONSISTENCY_LEVEL int) (*config.MetricStruct, error) {
 
 	metricDAO.logger.Debugf("Getting metric id %d", metricID)
 	var entity config.MetricStruct
-	if err := metricDAO.db.First(&entity, metricID).Error; err != nil {
+	if err := metricDAO.db.Scopes(farmDeviceScope(farmID)).
+		First(&entity, metricID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			metricDAO.logger.Warning(err)
 			return nil, datastore.ErrRecordNotFound
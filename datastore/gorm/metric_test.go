@@ -31,6 +31,7 @@ func TestMetricCRUD(t *testing.T) {
 	assert.NotNil(t, metricDAO)
 
 	org := dstest.CreateTestOrganization(currentTest.idGenerator)
+	assert.Nil(t, currentTest.saveFarms(org.GetFarms()...))
 
 	dstest.TestMetricCRUD(t, metricDAO, org)
 }
@@ -0,0 +1,62 @@
+package gorm
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormOIDCStateDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.OIDCState]
+	dao.OIDCStateDAO
+}
+
+func NewOIDCStateDAO(logger *logging.Logger, db *gorm.DB) dao.OIDCStateDAO {
+	return &GormOIDCStateDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.OIDCState](logger, db)}
+}
+
+func (dao *GormOIDCStateDAO) Save(state *entity.OIDCState) error {
+	return dao.db.Save(state).Error
+}
+
+func (dao *GormOIDCStateDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.OIDCState, error) {
+	return dao.GenericGormDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Deletes the logins that expired before they were completed
+func (dao *GormOIDCStateDAO) DeleteExpired(now time.Time) error {
+	if err := dao.db.Where("expires_at < ?", now).Delete(&entity.OIDCState{}).Error; err != nil {
+		dao.logger.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (dao *GormOIDCStateDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.OIDCState], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormOIDCStateDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.OIDCState], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormOIDCStateDAO) Delete(state *entity.OIDCState) error {
+	return dao.GenericGormDAO.Delete(state)
+}
+
+func (dao *GormOIDCStateDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
@@ -62,6 +62,9 @@ func (dao *GormOrganizationDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.O
 		Preload("Farms.Workflows.Conditions").
 		Preload("Farms.Workflows.Schedules").
 		Preload("Farms.Workflows.Steps").
+		Preload("Farms.Notifiers").
+		Preload("Farms.NotificationRules").
+		Preload("Notifiers", "farm_id = ?", 0).
 		First(&org, id).Error; err != nil {
 
 		if err == gorm.ErrRecordNotFound {
@@ -102,6 +105,9 @@ func (organizationDAO *GormOrganizationDAO) GetPage(pageQuery query.PageQuery,
 		Preload("Farms.Workflows.Conditions").
 		Preload("Farms.Workflows.Schedules").
 		Preload("Farms.Workflows.Steps").
+		Preload("Farms.Notifiers").
+		Preload("Farms.NotificationRules").
+		Preload("Notifiers", "farm_id = ?", 0).
 		Offset(offset).
 		Limit(pageQuery.PageSize + 1). // peek one record to set HasMore flag
 		Find(&orgs).Error; err != nil {
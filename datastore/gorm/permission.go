@@ -33,7 +33,7 @@ func (permissionDAO *GormPermissionDAO) GetOrganizations(userID uint64,
 		Preload("Farms").
 		Preload("Users").
 		Preload("Users.Roles").
-		Select("organizations.id, organizations.name").
+		Select("organizations.id, organizations.name, organizations.mfa_required").
 		Joins("JOIN permissions on organizations.id = permissions.organization_id AND permissions.user_id = ?", userID).
 		Find(&orgs).Error; err != nil {
 
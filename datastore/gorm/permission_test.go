@@ -184,8 +184,9 @@ func TestGetOrganizations(t *testing.T) {
 	farmConfig2 := config.NewFarm()
 	farmConfig2.SetName(testFarmName2)
 	orgConfig2 := &config.OrganizationStruct{
-		Name:  testOrgName2,
-		Farms: []*config.FarmStruct{farmConfig2}}
+		Name:        testOrgName2,
+		Farms:       []*config.FarmStruct{farmConfig2},
+		MFARequired: true}
 
 	err = orgDAO.Save(orgConfig2)
 	assert.Nil(t, err)
@@ -215,4 +216,6 @@ func TestGetOrganizations(t *testing.T) {
 	assert.Equal(t, 2, len(persistedOrgs))
 	assert.Equal(t, persistedOrgs[0].ID, orgConfig.ID)
 	assert.Equal(t, persistedOrgs[1].ID, orgConfig2.ID)
+	assert.False(t, persistedOrgs[0].IsMFARequired())
+	assert.True(t, persistedOrgs[1].IsMFARequired())
 }
@@ -0,0 +1,77 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormRefreshTokenDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.RefreshToken]
+	dao.RefreshTokenDAO
+}
+
+func NewRefreshTokenDAO(logger *logging.Logger, db *gorm.DB) dao.RefreshTokenDAO {
+	return &GormRefreshTokenDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.RefreshToken](logger, db)}
+}
+
+func (dao *GormRefreshTokenDAO) Save(token *entity.RefreshToken) error {
+	return dao.db.Save(token).Error
+}
+
+func (dao *GormRefreshTokenDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.RefreshToken, error) {
+	return dao.GenericGormDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns all of the user's refresh tokens
+func (dao *GormRefreshTokenDAO) GetByUserID(userID uint64, CONSISTENCY_LEVEL int) ([]*entity.RefreshToken, error) {
+	var tokens []*entity.RefreshToken
+	if err := dao.db.
+		Where("user_id = ?", userID).
+		Find(&tokens).Error; err != nil {
+
+		dao.logger.Error(err)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Returns every refresh token issued from the same login
+func (dao *GormRefreshTokenDAO) GetByFamily(family uint64, CONSISTENCY_LEVEL int) ([]*entity.RefreshToken, error) {
+	var tokens []*entity.RefreshToken
+	if err := dao.db.
+		Where("family = ?", family).
+		Find(&tokens).Error; err != nil {
+
+		dao.logger.Error(err)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (dao *GormRefreshTokenDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.RefreshToken], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormRefreshTokenDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.RefreshToken], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormRefreshTokenDAO) Delete(token *entity.RefreshToken) error {
+	return dao.GenericGormDAO.Delete(token)
+}
+
+func (dao *GormRefreshTokenDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
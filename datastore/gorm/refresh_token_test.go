@@ -0,0 +1,85 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshToken_CRUD(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.RefreshToken{})
+
+	refreshTokenDAO := NewRefreshTokenDAO(currentTest.logger, currentTest.gorm)
+
+	now := time.Now()
+	token1 := &entity.RefreshToken{
+		UserID:    5,
+		Family:    100,
+		Hash:      "hash1",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour)}
+	assert.Nil(t, refreshTokenDAO.Save(token1))
+	assert.NotZero(t, token1.ID)
+
+	token2 := &entity.RefreshToken{
+		UserID:    5,
+		Family:    100,
+		Hash:      "hash2",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour)}
+	assert.Nil(t, refreshTokenDAO.Save(token2))
+
+	otherLogin := &entity.RefreshToken{
+		UserID:    5,
+		Family:    200,
+		Hash:      "hash3",
+		CreatedAt: now,
+		ExpiresAt: now.Add(-time.Minute)}
+	assert.Nil(t, refreshTokenDAO.Save(otherLogin))
+
+	otherUser := &entity.RefreshToken{
+		UserID:    6,
+		Family:    300,
+		Hash:      "hash4",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour)}
+	assert.Nil(t, refreshTokenDAO.Save(otherUser))
+
+	tokens, err := refreshTokenDAO.GetByUserID(5, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(tokens))
+
+	tokens, err = refreshTokenDAO.GetByFamily(100, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(tokens))
+
+	persisted, err := refreshTokenDAO.Get(otherLogin.ID, 0)
+	assert.Nil(t, err)
+	assert.True(t, persisted.IsExpired(now))
+	assert.False(t, persisted.IsRotated())
+
+	persisted, err = refreshTokenDAO.Get(token1.ID, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "hash1", persisted.Hash)
+	assert.False(t, persisted.IsRevoked())
+
+	persisted.RotatedAt = now
+	persisted.RevokedAt = now
+	assert.Nil(t, refreshTokenDAO.Save(persisted))
+
+	persisted, err = refreshTokenDAO.Get(token1.ID, 0)
+	assert.Nil(t, err)
+	assert.True(t, persisted.IsRotated())
+	assert.True(t, persisted.IsRevoked())
+
+	assert.Nil(t, refreshTokenDAO.Delete(otherLogin))
+	count, err := refreshTokenDAO.Count(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+}
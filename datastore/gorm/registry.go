@@ -24,6 +24,17 @@ type GormDaoRegistry struct {
 	conditionDAO    dao.ConditionDAO
 	algorithmDAO    dao.AlgorithmDAO
 	eventLogDAO     dao.EventLogDAO
+	archiveDAO      dao.EventLogArchiveDAO
+	userEmailDAO    dao.UserEmailDAO
+	alarmDAO        dao.AlarmDAO
+	inboxDAO        dao.InboxDAO
+	auditDAO        dao.AuditDAO
+	apiKeyDAO       dao.APIKeyDAO
+	refreshTokenDAO dao.RefreshTokenDAO
+	totpDAO         dao.TOTPDAO
+	invitationDAO   dao.InvitationDAO
+	licenseDAO      dao.LicenseDAO
+	oidcStateDAO    dao.OIDCStateDAO
 	userDAO         dao.UserDAO
 	roleDAO         dao.RoleDAO
 	customerDAO     dao.CustomerDAO
@@ -50,6 +61,17 @@ func NewGormRegistry(logger *logging.Logger, gormDB GormDB) dao.Registry {
 		conditionDAO:    NewConditionDAO(logger, gormDB.CloneConnection()),
 		algorithmDAO:    NewGenericGormDAO[*config.AlgorithmStruct](logger, gormDB.CloneConnection()),
 		eventLogDAO:     NewEventLogDAO(logger, gormDB.CloneConnection(), 0),
+		archiveDAO:      NewEventLogArchiveDAO(logger, gormDB.CloneConnection()),
+		userEmailDAO:    NewUserEmailDAO(logger, gormDB.CloneConnection()),
+		alarmDAO:        NewAlarmDAO(logger, gormDB.CloneConnection()),
+		inboxDAO:        NewInboxDAO(logger, gormDB.CloneConnection()),
+		auditDAO:        NewAuditDAO(logger, gormDB.CloneConnection()),
+		apiKeyDAO:       NewAPIKeyDAO(logger, gormDB.CloneConnection()),
+		refreshTokenDAO: NewRefreshTokenDAO(logger, gormDB.CloneConnection()),
+		totpDAO:         NewTOTPDAO(logger, gormDB.CloneConnection()),
+		invitationDAO:   NewInvitationDAO(logger, gormDB.CloneConnection()),
+		licenseDAO:      NewLicenseDAO(logger, gormDB.CloneConnection()),
+		oidcStateDAO:    NewOIDCStateDAO(logger, gormDB.CloneConnection()),
 		userDAO:         NewUserDAO(logger, gormDB.CloneConnection()),
 		roleDAO:         NewRoleDAO(logger, gormDB.CloneConnection()),
 		customerDAO:     NewCustomerDAO(logger, gormDB.CloneConnection()),
@@ -149,6 +171,94 @@ func (registry *GormDaoRegistry) SetEventLogDAO(dao dao.EventLogDAO) {
 	registry.eventLogDAO = dao
 }
 
+func (registry *GormDaoRegistry) GetEventLogArchiveDAO() dao.EventLogArchiveDAO {
+	return registry.archiveDAO
+}
+
+func (registry *GormDaoRegistry) SetEventLogArchiveDAO(dao dao.EventLogArchiveDAO) {
+	registry.archiveDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetUserEmailDAO() dao.UserEmailDAO {
+	return registry.userEmailDAO
+}
+
+func (registry *GormDaoRegistry) SetUserEmailDAO(dao dao.UserEmailDAO) {
+	registry.userEmailDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetAlarmDAO() dao.AlarmDAO {
+	return registry.alarmDAO
+}
+
+func (registry *GormDaoRegistry) SetAlarmDAO(dao dao.AlarmDAO) {
+	registry.alarmDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetInboxDAO() dao.InboxDAO {
+	return registry.inboxDAO
+}
+
+func (registry *GormDaoRegistry) SetInboxDAO(dao dao.InboxDAO) {
+	registry.inboxDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetAuditDAO() dao.AuditDAO {
+	return registry.auditDAO
+}
+
+func (registry *GormDaoRegistry) SetAuditDAO(dao dao.AuditDAO) {
+	registry.auditDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetAPIKeyDAO() dao.APIKeyDAO {
+	return registry.apiKeyDAO
+}
+
+func (registry *GormDaoRegistry) SetAPIKeyDAO(dao dao.APIKeyDAO) {
+	registry.apiKeyDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetRefreshTokenDAO() dao.RefreshTokenDAO {
+	return registry.refreshTokenDAO
+}
+
+func (registry *GormDaoRegistry) SetRefreshTokenDAO(dao dao.RefreshTokenDAO) {
+	registry.refreshTokenDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetTOTPDAO() dao.TOTPDAO {
+	return registry.totpDAO
+}
+
+func (registry *GormDaoRegistry) SetTOTPDAO(dao dao.TOTPDAO) {
+	registry.totpDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetInvitationDAO() dao.InvitationDAO {
+	return registry.invitationDAO
+}
+
+func (registry *GormDaoRegistry) SetInvitationDAO(dao dao.InvitationDAO) {
+	registry.invitationDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetLicenseDAO() dao.LicenseDAO {
+	return registry.licenseDAO
+}
+
+func (registry *GormDaoRegistry) SetLicenseDAO(dao dao.LicenseDAO) {
+	registry.licenseDAO = dao
+}
+
+func (registry *GormDaoRegistry) GetOIDCStateDAO() dao.OIDCStateDAO {
+	return registry.oidcStateDAO
+}
+
+func (registry *GormDaoRegistry) SetOIDCStateDAO(dao dao.OIDCStateDAO) {
+	registry.oidcStateDAO = dao
+}
+
 func (registry *GormDaoRegistry) GetUserDAO() dao.UserDAO {
 	return registry.userDAO
 }
@@ -18,19 +18,40 @@ func NewScheduleDAO(logger *logging.Logger, db *gorm.DB) dao.ScheduleDAO {
 	return &GormScheduleDAO{logger: logger, db: db}
 }
 
+// Saves a schedule belonging to one of the farm's channels. The deviceID
+// is accepted to support key/value database
 func (dao *GormScheduleDAO) Save(farmID, deviceID uint64, schedule *config.ScheduleStruct) error {
+	if err := existsInScope(dao.db, &config.ChannelStruct{},
+		schedule.GetChannelID(), farmDeviceScope(farmID)); err != nil {
+		return err
+	}
+	if err := savableInScope(dao.db, &config.ScheduleStruct{},
+		schedule.ID, farmChannelScope(farmID)); err != nil {
+		return err
+	}
 	return dao.db.Save(schedule).Error
 }
 
+// Deletes a schedule belonging to one of the farm's channels. The
+// deviceID is accepted to support key/value database
 func (dao *GormScheduleDAO) Delete(farmID, deviceID uint64, schedule *config.ScheduleStruct) error {
-	return dao.db.Delete(schedule).Error
+	result := dao.db.Scopes(farmChannelScope(farmID)).Delete(schedule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return datastore.ErrRecordNotFound
+	}
+	return nil
 }
 
 func (dao *GormScheduleDAO) GetByChannelID(farmID, deviceID,
 	channelID uint64, CONSISTENCY_LEVEL int) ([]*config.ScheduleStruct, error) {
 
 	var entities []*config.ScheduleStruct
-	if err := dao.db.Where("channel_id = ?", channelID).Find(&entities).Error; err != nil {
+	if err := dao.db.Scopes(farmChannelScope(farmID)).
+		Where("channel_id = ?", channelID).
+		Find(&entities).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			dao.logger.Warning(err)
 			return nil, datastore.ErrRecordNotFound
@@ -26,6 +26,7 @@ func TestScheduleCRUD(t *testing.T) {
 	assert.NotNil(t, scheduleDAO)
 
 	org := dstest.CreateTestOrganization(currentTest.idGenerator)
+	assert.Nil(t, currentTest.saveFarms(org.GetFarms()...))
 
 	dstest.TestScheduleCRUD(t, scheduleDAO, org)
 }
@@ -0,0 +1,104 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"gorm.io/gorm"
+)
+
+// Farm child records are stored in their own tables and referenced by
+// their primary key, which clients are free to send for any farm. These
+// scopes constrain queries to the records that belong to the requested
+// farm, the same way the key/value stores only look inside the farm's
+// own configuration.
+
+// Selects the IDs of the devices that belong to the farm
+func farmDeviceIDs(db *gorm.DB, farmID uint64) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&config.DeviceStruct{}).
+		Select("id").
+		Where("farm_id = ?", farmID)
+}
+
+// Selects the IDs of the channels that belong to the farm's devices
+func farmChannelIDs(db *gorm.DB, farmID uint64) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&config.ChannelStruct{}).
+		Select("id").
+		Where("device_id IN (?)", farmDeviceIDs(db, farmID))
+}
+
+// Selects the IDs of the workflows that belong to the farm
+func farmWorkflowIDs(db *gorm.DB, farmID uint64) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&config.WorkflowStruct{}).
+		Select("id").
+		Where("farm_id = ?", farmID)
+}
+
+// Scopes a query to the records that belong to one of the farm's devices
+func farmDeviceScope(farmID uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("device_id IN (?)", farmDeviceIDs(db, farmID))
+	}
+}
+
+// Scopes a query to the records that belong to one of the farm's channels
+func farmChannelScope(farmID uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("channel_id IN (?)", farmChannelIDs(db, farmID))
+	}
+}
+
+// Scopes a query to the records that belong to one of the farm's workflows
+func farmWorkflowScope(farmID uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("workflow_id IN (?)", farmWorkflowIDs(db, farmID))
+	}
+}
+
+// Scopes a query to the records that belong to the farm
+func farmScope(farmID uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("farm_id = ?", farmID)
+	}
+}
+
+// Returns datastore.ErrRecordNotFound unless a record with the given
+// primary key exists within the scope
+func existsInScope(db *gorm.DB, model interface{}, id uint64,
+	scope func(*gorm.DB) *gorm.DB) error {
+
+	var count int64
+	if err := db.Model(model).
+		Scopes(scope).
+		Where("id = ?", id).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return datastore.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Returns datastore.ErrRecordNotFound if a record with the given primary
+// key exists outside of the scope. Saving a record must never overwrite
+// a record that belongs to another farm.
+func savableInScope(db *gorm.DB, model interface{}, id uint64,
+	scope func(*gorm.DB) *gorm.DB) error {
+
+	if id == 0 {
+		return nil
+	}
+	var count int64
+	if err := db.Model(model).
+		Where("id = ?", id).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	return existsInScope(db, model, id, scope)
+}
@@ -0,0 +1,126 @@
+package gorm
+
+import (
+	"testing"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	dstest "github.com/jeremyhahn/go-cropdroid/test/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFarmChildRecordScope(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	org := dstest.CreateTestOrganization(currentTest.idGenerator)
+	farm1 := org.GetFarms()[0]
+	farm2 := org.GetFarms()[1]
+	assert.Nil(t, currentTest.saveFarms(farm1, farm2))
+
+	device2 := farm2.GetDevices()[1]
+	channel2 := device2.GetChannels()[0]
+	condition2 := channel2.GetConditions()[0]
+	schedule2 := &config.ScheduleStruct{ChannelID: channel2.ID}
+	metric2 := device2.GetMetrics()[0]
+
+	conditionDAO := NewConditionDAO(currentTest.logger, currentTest.gorm)
+	scheduleDAO := NewScheduleDAO(currentTest.logger, currentTest.gorm)
+	channelDAO := NewChannelDAO(currentTest.logger, currentTest.gorm)
+	metricDAO := NewMetricDAO(currentTest.logger, currentTest.gorm)
+	deviceDAO := NewDeviceDAO(currentTest.logger, currentTest.gorm)
+
+	assert.Nil(t, conditionDAO.Save(farm2.ID, device2.ID, condition2))
+	assert.Nil(t, scheduleDAO.Save(farm2.ID, device2.ID, schedule2))
+	assert.Nil(t, metricDAO.Save(farm2.ID, metric2))
+
+	notFound := datastore.ErrRecordNotFound.Error()
+
+	// Farm 2's records can't be read through farm 1
+	_, err := conditionDAO.Get(farm1.ID, 0, 0, condition2.ID, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, notFound, err.Error())
+	conditions, err := conditionDAO.GetByChannelID(farm1.ID, 0, channel2.ID, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(conditions))
+	_, err = channelDAO.Get(0, farm1.ID, channel2.ID, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, notFound, err.Error())
+	_, err = metricDAO.Get(farm1.ID, 0, metric2.ID, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, notFound, err.Error())
+	_, err = deviceDAO.Get(farm1.ID, device2.ID, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, notFound, err.Error())
+
+	// ...deleted through farm 1...
+	err = conditionDAO.Delete(farm1.ID, 0, &config.ConditionStruct{ID: condition2.ID})
+	assert.Equal(t, notFound, err.Error())
+	err = scheduleDAO.Delete(farm1.ID, 0, &config.ScheduleStruct{ID: schedule2.ID})
+	assert.Equal(t, notFound, err.Error())
+
+	// ...overwritten through farm 1...
+	farm1Channel := farm1.GetDevices()[1].GetChannels()[0]
+	err = conditionDAO.Save(farm1.ID, 0, &config.ConditionStruct{
+		ID: condition2.ID, ChannelID: farm1Channel.ID, Comparator: "="})
+	assert.Equal(t, notFound, err.Error())
+	err = deviceDAO.Save(&config.DeviceStruct{ID: device2.ID, FarmID: farm1.ID})
+	assert.Equal(t, notFound, err.Error())
+
+	// ...or attached to farm 1's records
+	err = conditionDAO.Save(farm1.ID, 0, &config.ConditionStruct{ChannelID: channel2.ID})
+	assert.Equal(t, notFound, err.Error())
+	err = metricDAO.Save(farm1.ID, &config.MetricStruct{DeviceID: device2.ID})
+	assert.Equal(t, notFound, err.Error())
+
+	// Farm 2 still has its records
+	persisted, err := conditionDAO.Get(farm2.ID, device2.ID, channel2.ID,
+		condition2.ID, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, condition2.GetComparator(), persisted.GetComparator())
+	schedules, err := scheduleDAO.GetByChannelID(farm2.ID, device2.ID,
+		channel2.ID, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(schedules))
+	device, err := deviceDAO.Get(farm2.ID, device2.ID, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, farm2.ID, device.GetFarmID())
+}
+
+func TestWorkflowFarmScope(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	org := dstest.CreateTestOrganization(currentTest.idGenerator)
+	farm1 := org.GetFarms()[0]
+	farm2 := org.GetFarms()[1]
+	assert.Nil(t, currentTest.saveFarms(farm1, farm2))
+
+	workflowDAO := NewWorkflowDAO(currentTest.logger, currentTest.gorm)
+	workflowStepDAO := NewWorkflowStepDAO(currentTest.logger, currentTest.gorm)
+
+	workflow1 := farm1.GetWorkflows()[0]
+	step := config.NewWorkflowStep()
+	step.SetWorkflowID(workflow1.ID)
+	assert.Nil(t, workflowStepDAO.Save(farm1.ID, step))
+
+	notFound := datastore.ErrRecordNotFound.Error()
+
+	_, err := workflowDAO.Get(farm2.ID, workflow1.ID, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, notFound, err.Error())
+	_, err = workflowStepDAO.Get(farm2.ID, workflow1.ID, step.ID, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, notFound, err.Error())
+
+	err = workflowDAO.Delete(&config.WorkflowStruct{ID: workflow1.ID, FarmID: farm2.ID})
+	assert.Equal(t, notFound, err.Error())
+	err = workflowDAO.Save(&config.WorkflowStruct{ID: workflow1.ID, FarmID: farm2.ID})
+	assert.Equal(t, notFound, err.Error())
+	err = workflowStepDAO.Delete(farm2.ID, &config.WorkflowStepStruct{ID: step.ID})
+	assert.Equal(t, notFound, err.Error())
+	err = workflowStepDAO.Save(farm2.ID, &config.WorkflowStepStruct{WorkflowID: workflow1.ID})
+	assert.Equal(t, notFound, err.Error())
+
+	workflow, err := workflowDAO.Get(farm1.ID, workflow1.ID, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(workflow.GetSteps()))
+}
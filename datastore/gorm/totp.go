@@ -0,0 +1,51 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormTOTPDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.TOTP]
+	dao.TOTPDAO
+}
+
+func NewTOTPDAO(logger *logging.Logger, db *gorm.DB) dao.TOTPDAO {
+	return &GormTOTPDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.TOTP](logger, db)}
+}
+
+func (dao *GormTOTPDAO) Save(totp *entity.TOTP) error {
+	return dao.db.Save(totp).Error
+}
+
+func (dao *GormTOTPDAO) Get(userID uint64, CONSISTENCY_LEVEL int) (*entity.TOTP, error) {
+	return dao.GenericGormDAO.Get(userID, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormTOTPDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.TOTP], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormTOTPDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.TOTP], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormTOTPDAO) Delete(totp *entity.TOTP) error {
+	return dao.GenericGormDAO.Delete(totp)
+}
+
+func (dao *GormTOTPDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
@@ -0,0 +1,55 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTP_CRUD(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.TOTP{})
+
+	totpDAO := NewTOTPDAO(currentTest.logger, currentTest.gorm)
+
+	_, err := totpDAO.Get(5, 0)
+	assert.Equal(t, datastore.ErrRecordNotFound, err)
+
+	now := time.Now()
+	totp := &entity.TOTP{
+		ID:            5,
+		Account:       "root@localhost",
+		Secret:        "JBSWY3DPEHPK3PXP",
+		RecoveryCodes: "hash1,hash2",
+		CreatedAt:     now}
+	assert.Nil(t, totpDAO.Save(totp))
+
+	persisted, err := totpDAO.Get(5, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", persisted.Secret)
+	assert.Equal(t, []string{"hash1", "hash2"}, persisted.GetRecoveryCodeList())
+	assert.False(t, persisted.Enabled)
+	assert.False(t, persisted.HasChallenge())
+
+	persisted.Enabled = true
+	persisted.ChallengeHash = "challenge"
+	persisted.ChallengeExpiresAt = now.Add(time.Minute)
+	assert.Nil(t, totpDAO.Save(persisted))
+
+	persisted, err = totpDAO.Get(5, 0)
+	assert.Nil(t, err)
+	assert.True(t, persisted.Enabled)
+	assert.True(t, persisted.HasChallenge())
+	assert.False(t, persisted.IsChallengeExpired(now))
+
+	assert.Nil(t, totpDAO.Delete(persisted))
+	count, err := totpDAO.Count(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
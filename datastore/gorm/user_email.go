@@ -0,0 +1,51 @@
+package gorm
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"gorm.io/gorm"
+)
+
+type GormUserEmailDAO struct {
+	logger         *logging.Logger
+	db             *gorm.DB
+	GenericGormDAO dao.GenericDAO[*entity.UserEmail]
+	dao.UserEmailDAO
+}
+
+func NewUserEmailDAO(logger *logging.Logger, db *gorm.DB) dao.UserEmailDAO {
+	return &GormUserEmailDAO{
+		logger:         logger,
+		db:             db,
+		GenericGormDAO: NewGenericGormDAO[*entity.UserEmail](logger, db)}
+}
+
+func (dao *GormUserEmailDAO) Save(userEmail *entity.UserEmail) error {
+	return dao.db.Save(userEmail).Error
+}
+
+func (dao *GormUserEmailDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.UserEmail, error) {
+	return dao.GenericGormDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormUserEmailDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.UserEmail], error) {
+
+	return dao.GenericGormDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormUserEmailDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.UserEmail], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericGormDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *GormUserEmailDAO) Delete(userEmail *entity.UserEmail) error {
+	return dao.GenericGormDAO.Delete(userEmail)
+}
+
+func (dao *GormUserEmailDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericGormDAO.Count(CONSISTENCY_LEVEL)
+}
@@ -0,0 +1,38 @@
+package gorm
+
+import (
+	"testing"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserEmail_CRUD(t *testing.T) {
+
+	currentTest := NewIntegrationTest()
+	defer currentTest.Cleanup()
+
+	currentTest.gorm.AutoMigrate(&entity.UserEmail{})
+
+	userEmailDAO := NewUserEmailDAO(currentTest.logger, currentTest.gorm)
+
+	userEmail := &entity.UserEmail{
+		ID:     currentTest.idGenerator.NewStringID("new@example.com"),
+		Email:  "new@example.com",
+		UserID: currentTest.idGenerator.NewStringID("old@example.com")}
+	assert.Nil(t, userEmailDAO.Save(userEmail))
+
+	persisted, err := userEmailDAO.Get(userEmail.ID, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "new@example.com", persisted.GetEmail())
+	assert.Equal(t, userEmail.UserID, persisted.GetUserID())
+
+	count, err := userEmailDAO.Count(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.Nil(t, userEmailDAO.Delete(userEmail))
+	_, err = userEmailDAO.Get(userEmail.ID, 0)
+	assert.Equal(t, datastore.ErrRecordNotFound, err)
+}
@@ -20,24 +20,38 @@ func NewWorkflowDAO(logger *logging.Logger, db *gorm.DB) dao.WorkflowDAO {
 	return &GormWorkflowDAO{logger: logger, db: db}
 }
 
+// Saves a workflow. Workflows can't be moved to another farm.
 func (dao *GormWorkflowDAO) Save(workflow *config.WorkflowStruct) error {
+	if err := savableInScope(dao.db, &config.WorkflowStruct{},
+		workflow.ID, farmScope(workflow.GetFarmID())); err != nil {
+		return err
+	}
 	return dao.db.Save(workflow).Error
 }
 
+// Deletes a workflow and its steps from the farm it belongs to
 func (dao *GormWorkflowDAO) Delete(workflow *config.WorkflowStruct) error {
+	if err := existsInScope(dao.db, &config.WorkflowStruct{},
+		workflow.ID, farmScope(workflow.GetFarmID())); err != nil {
+		return err
+	}
 	if err := dao.db.
 		Where("workflow_id = ?", workflow.ID).
 		Delete(&config.WorkflowStepStruct{}).
 		Error; err != nil {
 		return err
 	}
-	return dao.db.Delete(workflow).Error
+	return dao.db.Scopes(farmScope(workflow.GetFarmID())).Delete(workflow).Error
 }
 
+// Looks up a workflow belonging to the farm
 func (dao *GormWorkflowDAO) Get(farmID, workflowID uint64,
 	CONSISTENCY_LEVEL int) (*config.WorkflowStruct, error) {
-	var workflow *config.WorkflowStruct
-	if err := dao.db.First(workflow, workflowID).Error; err != nil {
+	var workflow config.WorkflowStruct
+	if err := dao.db.
+		Preload("Steps").
+		Scopes(farmScope(farmID)).
+		First(&workflow, workflowID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			dao.logger.Warning(err)
 			return nil, datastore.ErrRecordNotFound
@@ -49,7 +63,7 @@ func (dao *GormWorkflowDAO) Get(farmID, workflowID uint64,
 	sort.SliceStable(workflowSteps, func(i, j int) bool {
 		return workflowSteps[i].GetSortOrder() < workflowSteps[j].GetSortOrder()
 	})
-	return workflow, nil
+	return &workflow, nil
 }
 
 func (dao *GormWorkflowDAO) GetByFarmID(farmID uint64,
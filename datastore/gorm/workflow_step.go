@@ -18,29 +18,42 @@ func NewWorkflowStepDAO(logger *logging.Logger, db *gorm.DB) dao.WorkflowStepDAO
 	return &GormWorkflowStepDAO{logger: logger, db: db}
 }
 
+// Saves a step belonging to one of the farm's workflows
 func (dao *GormWorkflowStepDAO) Save(farmID uint64,
 	workflowStep *config.WorkflowStepStruct) error {
 
+	if err := existsInScope(dao.db, &config.WorkflowStruct{},
+		workflowStep.GetWorkflowID(), farmScope(farmID)); err != nil {
+		return err
+	}
+	if err := savableInScope(dao.db, &config.WorkflowStepStruct{},
+		workflowStep.ID, farmWorkflowScope(farmID)); err != nil {
+		return err
+	}
 	return dao.db.Save(workflowStep).Error
 }
 
+// Deletes a step belonging to one of the farm's workflows
 func (dao *GormWorkflowStepDAO) Delete(farmID uint64,
 	workflowStep *config.WorkflowStepStruct) error {
 
-	step := &config.WorkflowStepStruct{}
-	step.SetWorkflowID(workflowStep.GetWorkflowID())
-	// if err := dao.db.Model(step).Delete(step).Error; err != nil {
-	// 	return err
-	// }
-	// return dao.db.Delete(workflowStep).Error
-	return dao.db.Model(step).Delete(workflowStep).Error
+	result := dao.db.Scopes(farmWorkflowScope(farmID)).Delete(workflowStep)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return datastore.ErrRecordNotFound
+	}
+	return nil
 }
 
+// Looks up a step belonging to one of the farm's workflows
 func (dao *GormWorkflowStepDAO) Get(farmID, workflowID,
 	workflowStepID uint64, CONSISTENCY_LEVEL int) (*config.WorkflowStepStruct, error) {
 
-	var step *config.WorkflowStepStruct
-	if err := dao.db.First(step, workflowStepID).Error; err != nil {
+	var step config.WorkflowStepStruct
+	if err := dao.db.Scopes(farmWorkflowScope(farmID)).
+		First(&step, workflowStepID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			dao.logger.Warning(err)
 			return nil, datastore.ErrRecordNotFound
@@ -48,7 +61,7 @@ func (dao *GormWorkflowStepDAO) Get(farmID, workflowID,
 		dao.logger.Error(err)
 		return nil, err
 	}
-	return step, nil
+	return &step, nil
 }
 
 func (dao *GormWorkflowStepDAO) GetByWorkflowID(farmID,
@@ -56,6 +69,7 @@ func (dao *GormWorkflowStepDAO) GetByWorkflowID(farmID,
 
 	var steps []*config.WorkflowStepStruct
 	if err := dao.db.
+		Scopes(farmWorkflowScope(farmID)).
 		Where("workflow_id = ?", workflowID).
 		Find(&steps).Error; err != nil {
 
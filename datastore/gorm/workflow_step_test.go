@@ -22,6 +22,7 @@ func TestWorkflowStepCRUD(t *testing.T) {
 	assert.NotNil(t, workflowStepDAO)
 
 	org := dstest.CreateTestOrganization(currentTest.idGenerator)
+	assert.Nil(t, currentTest.saveFarms(org.GetFarms()...))
 
 	dstest.TestWorkflowStepCRUD(t, workflowStepDAO, org)
 }
@@ -0,0 +1,168 @@
+package pebble
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+const blockVersion = 1
+
+var (
+	ErrCorruptBlock = errors.New("corrupt time series block")
+)
+
+type sample struct {
+	timestamp int64
+	value     float64
+}
+
+// blockSummary is stored in the block header so aggregates over
+// whole blocks can be calculated without decoding the samples
+type blockSummary struct {
+	count int
+	min   float64
+	max   float64
+	sum   float64
+}
+
+// Encodes a time ordered run of samples into a compressed block. Timestamps
+// are stored as the delta from the previous sample and values as the XOR of
+// the previous value with leading and trailing zero bytes removed, so slowly
+// changing sensor readings sampled at a fixed interval only need a few bytes
+// per sample.
+//
+// Layout: version | count | min | max | sum | first timestamp | first value | samples...
+func encodeBlock(samples []sample) []byte {
+	summary := summarize(samples)
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+24+binary.MaxVarintLen64+8+len(samples)*4)
+	buf = append(buf, blockVersion)
+	buf = binary.AppendUvarint(buf, uint64(summary.count))
+	buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(summary.min))
+	buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(summary.max))
+	buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(summary.sum))
+	if len(samples) == 0 {
+		return buf
+	}
+	buf = binary.AppendVarint(buf, samples[0].timestamp)
+	buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(samples[0].value))
+	prevTimestamp := samples[0].timestamp
+	prevBits := math.Float64bits(samples[0].value)
+	for _, s := range samples[1:] {
+		buf = binary.AppendUvarint(buf, uint64(s.timestamp-prevTimestamp))
+		valueBits := math.Float64bits(s.value)
+		xor := valueBits ^ prevBits
+		if xor == 0 {
+			buf = append(buf, 8<<4)
+		} else {
+			leading := bits.LeadingZeros64(xor) / 8
+			trailing := bits.TrailingZeros64(xor) / 8
+			buf = append(buf, byte(leading<<4|trailing))
+			for i := 7 - leading; i >= trailing; i-- {
+				buf = append(buf, byte(xor>>(uint(i)*8)))
+			}
+		}
+		prevTimestamp = s.timestamp
+		prevBits = valueBits
+	}
+	return buf
+}
+
+// Decodes the block header without decoding the samples
+func decodeBlockSummary(data []byte) (blockSummary, []byte, error) {
+	var summary blockSummary
+	if len(data) < 1 || data[0] != blockVersion {
+		return summary, nil, ErrCorruptBlock
+	}
+	count, n := binary.Uvarint(data[1:])
+	if n <= 0 || len(data) < 1+n+24 {
+		return summary, nil, ErrCorruptBlock
+	}
+	offset := 1 + n
+	summary.count = int(count)
+	summary.min = math.Float64frombits(binary.BigEndian.Uint64(data[offset:]))
+	summary.max = math.Float64frombits(binary.BigEndian.Uint64(data[offset+8:]))
+	summary.sum = math.Float64frombits(binary.BigEndian.Uint64(data[offset+16:]))
+	return summary, data[offset+24:], nil
+}
+
+// Decodes all of the samples in a block
+func decodeBlock(data []byte) ([]sample, error) {
+	summary, data, err := decodeBlockSummary(data)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]sample, 0, summary.count)
+	if summary.count == 0 {
+		return samples, nil
+	}
+	timestamp, n := binary.Varint(data)
+	if n <= 0 || len(data) < n+8 {
+		return nil, ErrCorruptBlock
+	}
+	data = data[n:]
+	valueBits := binary.BigEndian.Uint64(data)
+	data = data[8:]
+	samples = append(samples, sample{timestamp: timestamp, value: math.Float64frombits(valueBits)})
+	for i := 1; i < summary.count; i++ {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 || len(data) < n+1 {
+			return nil, ErrCorruptBlock
+		}
+		data = data[n:]
+		timestamp += int64(delta)
+		header := data[0]
+		data = data[1:]
+		leading := int(header >> 4)
+		trailing := int(header & 0x0f)
+		if leading < 8 {
+			size := 8 - leading - trailing
+			if size <= 0 || len(data) < size {
+				return nil, ErrCorruptBlock
+			}
+			var xor uint64
+			for _, b := range data[:size] {
+				xor = xor<<8 | uint64(b)
+			}
+			valueBits ^= xor << (uint(trailing) * 8)
+			data = data[size:]
+		}
+		samples = append(samples, sample{timestamp: timestamp, value: math.Float64frombits(valueBits)})
+	}
+	return samples, nil
+}
+
+// Merges new samples into an existing run of samples, keeping them time
+// ordered. New samples replace existing samples with the same timestamp.
+func mergeSamples(existing, samples []sample) []sample {
+	merged := make([]sample, 0, len(existing)+len(samples))
+	merged = append(merged, existing...)
+	merged = append(merged, samples...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].timestamp < merged[j].timestamp
+	})
+	deduped := merged[:0]
+	for i, s := range merged {
+		if i+1 < len(merged) && merged[i+1].timestamp == s.timestamp {
+			continue
+		}
+		deduped = append(deduped, s)
+	}
+	return deduped
+}
+
+func summarize(samples []sample) blockSummary {
+	summary := blockSummary{count: len(samples)}
+	for i, s := range samples {
+		if i == 0 || s.value < summary.min {
+			summary.min = s.value
+		}
+		if i == 0 || s.value > summary.max {
+			summary.max = s.value
+		}
+		summary.sum += s.value
+	}
+	return summary
+}
@@ -0,0 +1,428 @@
+package pebble
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/state"
+	logging "github.com/op/go-logging"
+)
+
+const (
+	DEFAULT_BLOCK_DURATION = time.Hour
+	DEFAULT_FLUSH_INTERVAL = 5 * time.Minute
+
+	seriesKeyPrefix = 't'
+)
+
+type PebbleInitParams struct {
+	// Directory where the time series database is stored
+	Dir string
+	// The time window covered by each compressed block
+	BlockDuration time.Duration
+	// How long samples are buffered in memory before they are written
+	// to disk. Longer intervals mean fewer writes to the SD card at the
+	// cost of losing the buffered samples if the process crashes.
+	// 0 = write every sample immediately
+	FlushInterval time.Duration
+}
+
+type PebbleDataStore struct {
+	logger        *logging.Logger
+	params        *PebbleInitParams
+	db            *pebble.DB
+	blockDuration int64
+	head          map[string][]sample
+	lastFlush     time.Time
+	closed        bool
+	mutex         *sync.RWMutex
+	datastore.TimeSeriesDataStore
+}
+
+// Creates a new embedded time series device data store backed by a local
+// Pebble database. Samples are stored in compressed, fixed duration blocks
+// keyed by device, series and block start time so range scans are
+// sequential reads.
+func NewPebbleDataStore(logger *logging.Logger, params *PebbleInitParams) (datastore.TimeSeriesDataStore, error) {
+	if params.BlockDuration <= 0 {
+		params.BlockDuration = DEFAULT_BLOCK_DURATION
+	}
+	if err := os.MkdirAll(params.Dir, 0755); err != nil {
+		return nil, err
+	}
+	cache := pebble.NewCache(8 << 20)
+	defer cache.Unref()
+	db, err := pebble.Open(params.Dir, &pebble.Options{
+		Cache:               cache,
+		MemTableSize:        4 << 20,
+		MaxManifestFileSize: 1 << 20})
+	if err != nil {
+		return nil, err
+	}
+	return &PebbleDataStore{
+		logger:        logger,
+		params:        params,
+		db:            db,
+		blockDuration: params.BlockDuration.Milliseconds(),
+		head:          make(map[string][]sample, 0),
+		lastFlush:     time.Now(),
+		mutex:         &sync.RWMutex{}}, nil
+}
+
+// Buffers the device metrics and channels, writing them to disk
+// once the flush interval has elapsed
+func (store *PebbleDataStore) Save(deviceID uint64, deviceState state.DeviceStateMap) error {
+	timestamp := time.Now().UnixMilli()
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.closed {
+		return datastore.ErrDataStoreClosed
+	}
+	for k, v := range deviceState.GetMetrics() {
+		prefix := string(store.seriesPrefix(deviceID, k))
+		store.head[prefix] = append(store.head[prefix], sample{timestamp: timestamp, value: v})
+	}
+	for i, v := range deviceState.GetChannels() {
+		prefix := string(store.seriesPrefix(deviceID, fmt.Sprintf("c%d", i)))
+		store.head[prefix] = append(store.head[prefix], sample{timestamp: timestamp, value: float64(v)})
+	}
+	if time.Since(store.lastFlush) >= store.params.FlushInterval {
+		return store.flush()
+	}
+	return nil
+}
+
+// Writes all buffered samples to disk
+func (store *PebbleDataStore) Flush() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.closed {
+		return datastore.ErrDataStoreClosed
+	}
+	return store.flush()
+}
+
+// Flushes the buffered samples and closes the database. Samples
+// saved after the store is closed are rejected.
+func (store *PebbleDataStore) Close() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.closed {
+		return nil
+	}
+	if err := store.flush(); err != nil {
+		store.logger.Errorf("Error flushing time series samples: %s", err)
+	}
+	store.closed = true
+	return store.db.Close()
+}
+
+// Returns the raw values for the metric over the last month
+func (store *PebbleDataStore) GetLast30Days(deviceID uint64, metric string) ([]float64, error) {
+	now := time.Now()
+	datapoints, err := store.GetRange(deviceID, metric, now.AddDate(0, -1, 0), now)
+	if err != nil {
+		return nil, err
+	}
+	floats := make([]float64, len(datapoints))
+	for i, datapoint := range datapoints {
+		floats[i] = datapoint.Value
+	}
+	return floats, nil
+}
+
+// Returns the raw samples for the metric between start and end
+func (store *PebbleDataStore) GetRange(deviceID uint64, metric string,
+	start, end time.Time) ([]datastore.DataPoint, error) {
+
+	from, to := start.UnixMilli(), end.UnixMilli()
+	samples := make([]sample, 0)
+	err := store.scan(deviceID, metric, from, to, func(blockStart int64, data []byte) error {
+		blockSamples, err := decodeBlock(data)
+		if err != nil {
+			return err
+		}
+		for _, s := range blockSamples {
+			if s.timestamp >= from && s.timestamp <= to {
+				samples = append(samples, s)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range store.headSamples(deviceID, metric) {
+		if s.timestamp >= from && s.timestamp <= to {
+			samples = append(samples, s)
+		}
+	}
+	samples = mergeSamples(nil, samples)
+	datapoints := make([]datastore.DataPoint, len(samples))
+	for i, s := range samples {
+		datapoints[i] = datastore.DataPoint{
+			Timestamp: time.UnixMilli(s.timestamp),
+			Value:     s.value}
+	}
+	return datapoints, nil
+}
+
+// Returns the metric samples between start and end aggregated into time buckets
+// aligned to the epoch. Blocks that fall entirely within a bucket are aggregated
+// using their summary header without decoding the samples.
+func (store *PebbleDataStore) GetAggregate(deviceID uint64, metric string, start, end time.Time,
+	aggregation string, bucket time.Duration) ([]datastore.DataPoint, error) {
+
+	if _, ok := aggregators[aggregation]; !ok {
+		return nil, fmt.Errorf("%w: %s", datastore.ErrInvalidAggregation, aggregation)
+	}
+	bucketSize := bucket.Milliseconds()
+	if bucketSize <= 0 {
+		return nil, datastore.ErrInvalidTimeBucket
+	}
+	from, to := start.UnixMilli(), end.UnixMilli()
+	useSummary := aggregation != datastore.AGGREGATION_FIRST &&
+		aggregation != datastore.AGGREGATION_LAST &&
+		bucketSize%store.blockDuration == 0
+
+	buckets := make(map[int64]*accumulator, 0)
+	add := func(s sample) {
+		bucketStart := s.timestamp - (s.timestamp % bucketSize)
+		acc, ok := buckets[bucketStart]
+		if !ok {
+			acc = &accumulator{}
+			buckets[bucketStart] = acc
+		}
+		acc.add(s)
+	}
+
+	err := store.scan(deviceID, metric, from, to, func(blockStart int64, data []byte) error {
+		blockEnd := blockStart + store.blockDuration - 1
+		if useSummary && blockStart >= from && blockEnd <= to {
+			summary, _, err := decodeBlockSummary(data)
+			if err != nil {
+				return err
+			}
+			if summary.count == 0 {
+				return nil
+			}
+			bucketStart := blockStart - (blockStart % bucketSize)
+			acc, ok := buckets[bucketStart]
+			if !ok {
+				acc = &accumulator{}
+				buckets[bucketStart] = acc
+			}
+			acc.merge(summary)
+			return nil
+		}
+		blockSamples, err := decodeBlock(data)
+		if err != nil {
+			return err
+		}
+		for _, s := range blockSamples {
+			if s.timestamp >= from && s.timestamp <= to {
+				add(s)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range store.headSamples(deviceID, metric) {
+		if s.timestamp >= from && s.timestamp <= to {
+			add(s)
+		}
+	}
+
+	bucketStarts := make([]int64, 0, len(buckets))
+	for bucketStart := range buckets {
+		bucketStarts = append(bucketStarts, bucketStart)
+	}
+	sort.Slice(bucketStarts, func(i, j int) bool { return bucketStarts[i] < bucketStarts[j] })
+	datapoints := make([]datastore.DataPoint, len(bucketStarts))
+	for i, bucketStart := range bucketStarts {
+		datapoints[i] = datastore.DataPoint{
+			Timestamp: time.UnixMilli(bucketStart),
+			Value:     aggregators[aggregation](buckets[bucketStart])}
+	}
+	return datapoints, nil
+}
+
+// Returns the most recent sample for the metric
+func (store *PebbleDataStore) GetLatest(deviceID uint64, metric string) (*datastore.DataPoint, error) {
+	if head := store.headSamples(deviceID, metric); len(head) > 0 {
+		last := head[len(head)-1]
+		return &datastore.DataPoint{Timestamp: time.UnixMilli(last.timestamp), Value: last.value}, nil
+	}
+	prefix := store.seriesPrefix(deviceID, metric)
+	iter := store.db.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: store.blockKey(prefix, 1<<63-1)})
+	defer iter.Close()
+	if !iter.Last() {
+		return nil, datastore.ErrRecordNotFound
+	}
+	samples, err := decodeBlock(iter.Value())
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, datastore.ErrRecordNotFound
+	}
+	last := samples[len(samples)-1]
+	return &datastore.DataPoint{Timestamp: time.UnixMilli(last.timestamp), Value: last.value}, nil
+}
+
+// Writes the buffered samples to their blocks using a single synced batch.
+// The caller must hold the write lock.
+func (store *PebbleDataStore) flush() error {
+	store.lastFlush = time.Now()
+	if len(store.head) == 0 {
+		return nil
+	}
+	batch := store.db.NewBatch()
+	defer batch.Close()
+	for prefix, samples := range store.head {
+		blocks := make(map[int64][]sample, 0)
+		for _, s := range samples {
+			blockStart := s.timestamp - (s.timestamp % store.blockDuration)
+			blocks[blockStart] = append(blocks[blockStart], s)
+		}
+		for blockStart, blockSamples := range blocks {
+			key := store.blockKey([]byte(prefix), blockStart)
+			existing, err := store.readBlock(key)
+			if err != nil {
+				return err
+			}
+			merged := mergeSamples(existing, blockSamples)
+			if err := batch.Set(key, encodeBlock(merged), nil); err != nil {
+				return err
+			}
+		}
+	}
+	if err := batch.Commit(pebble.Sync); err != nil {
+		return err
+	}
+	store.logger.Debugf("Flushed %d time series to disk", len(store.head))
+	store.head = make(map[string][]sample, 0)
+	return nil
+}
+
+func (store *PebbleDataStore) readBlock(key []byte) ([]sample, error) {
+	data, closer, err := store.db.Get(key)
+	if err == pebble.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	return decodeBlock(data)
+}
+
+// Calls fn for each block in the series that overlaps the time range
+func (store *PebbleDataStore) scan(deviceID uint64, metric string, from, to int64,
+	fn func(blockStart int64, data []byte) error) error {
+
+	prefix := store.seriesPrefix(deviceID, metric)
+	firstBlock := from - (from % store.blockDuration)
+	if from < 0 {
+		firstBlock = 0
+	}
+	iter := store.db.NewIter(&pebble.IterOptions{
+		LowerBound: store.blockKey(prefix, firstBlock),
+		UpperBound: store.blockKey(prefix, to+1)})
+	defer iter.Close()
+	for iter.First(); iter.Valid(); iter.Next() {
+		key := iter.Key()
+		blockStart := int64(binary.BigEndian.Uint64(key[len(key)-8:]))
+		if err := fn(blockStart, iter.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *PebbleDataStore) headSamples(deviceID uint64, metric string) []sample {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	head := store.head[string(store.seriesPrefix(deviceID, metric))]
+	samples := make([]sample, len(head))
+	copy(samples, head)
+	return samples
+}
+
+// Returns the key prefix shared by all blocks in a series:
+// t | device id | series name | 0x00
+func (store *PebbleDataStore) seriesPrefix(deviceID uint64, name string) []byte {
+	prefix := make([]byte, 0, 1+8+len(name)+1)
+	prefix = append(prefix, seriesKeyPrefix)
+	prefix = binary.BigEndian.AppendUint64(prefix, deviceID)
+	prefix = append(prefix, name...)
+	return append(prefix, 0)
+}
+
+// Returns the key for the block starting at the specified unix millisecond
+func (store *PebbleDataStore) blockKey(prefix []byte, blockStart int64) []byte {
+	key := make([]byte, 0, len(prefix)+8)
+	key = append(key, prefix...)
+	return binary.BigEndian.AppendUint64(key, uint64(blockStart))
+}
+
+type accumulator struct {
+	count int
+	min   float64
+	max   float64
+	sum   float64
+	first sample
+	last  sample
+}
+
+func (acc *accumulator) add(s sample) {
+	if acc.count == 0 || s.value < acc.min {
+		acc.min = s.value
+	}
+	if acc.count == 0 || s.value > acc.max {
+		acc.max = s.value
+	}
+	if acc.count == 0 || s.timestamp < acc.first.timestamp {
+		acc.first = s
+	}
+	if acc.count == 0 || s.timestamp >= acc.last.timestamp {
+		acc.last = s
+	}
+	acc.sum += s.value
+	acc.count++
+}
+
+func (acc *accumulator) merge(summary blockSummary) {
+	if acc.count == 0 || summary.min < acc.min {
+		acc.min = summary.min
+	}
+	if acc.count == 0 || summary.max > acc.max {
+		acc.max = summary.max
+	}
+	acc.sum += summary.sum
+	acc.count += summary.count
+}
+
+var aggregators = map[string]func(acc *accumulator) float64{
+	datastore.AGGREGATION_MIN:   func(acc *accumulator) float64 { return acc.min },
+	datastore.AGGREGATION_MAX:   func(acc *accumulator) float64 { return acc.max },
+	datastore.AGGREGATION_SUM:   func(acc *accumulator) float64 { return acc.sum },
+	datastore.AGGREGATION_COUNT: func(acc *accumulator) float64 { return float64(acc.count) },
+	datastore.AGGREGATION_FIRST: func(acc *accumulator) float64 { return acc.first.value },
+	datastore.AGGREGATION_LAST:  func(acc *accumulator) float64 { return acc.last.value },
+	datastore.AGGREGATION_AVG: func(acc *accumulator) float64 {
+		if acc.count == 0 {
+			return 0
+		}
+		return acc.sum / float64(acc.count)
+	}}
@@ -0,0 +1,168 @@
+package pebble
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/state"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestDataStore(t *testing.T, flushInterval time.Duration) *PebbleDataStore {
+	store, err := NewPebbleDataStore(logging.MustGetLogger("pebble_test"), &PebbleInitParams{
+		Dir:           t.TempDir(),
+		BlockDuration: time.Hour,
+		FlushInterval: flushInterval})
+	assert.Nil(t, err)
+	return store.(*PebbleDataStore)
+}
+
+func TestBlockEncoding(t *testing.T) {
+	start := time.Now().UnixMilli()
+	samples := []sample{
+		{timestamp: start, value: 72.5},
+		{timestamp: start + 60000, value: 72.5},
+		{timestamp: start + 120000, value: 72.6},
+		{timestamp: start + 180000, value: -3.25},
+		{timestamp: start + 240000, value: 0}}
+
+	data := encodeBlock(samples)
+	decoded, err := decodeBlock(data)
+	assert.Nil(t, err)
+	assert.Equal(t, samples, decoded)
+
+	summary, _, err := decodeBlockSummary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, summary.count)
+	assert.Equal(t, -3.25, summary.min)
+	assert.Equal(t, 72.6, summary.max)
+
+	// Repeated values only need the timestamp delta and a header byte
+	flat := make([]sample, 60)
+	for i := range flat {
+		flat[i] = sample{timestamp: start + int64(i)*60000, value: 5.8}
+	}
+	assert.Less(t, len(encodeBlock(flat)), 60*5)
+
+	_, err = decodeBlock([]byte{0xff})
+	assert.Equal(t, ErrCorruptBlock, err)
+}
+
+func TestMergeSamples(t *testing.T) {
+	merged := mergeSamples(
+		[]sample{{timestamp: 1, value: 1}, {timestamp: 3, value: 3}},
+		[]sample{{timestamp: 2, value: 2}, {timestamp: 3, value: 4}})
+	assert.Equal(t, []sample{
+		{timestamp: 1, value: 1},
+		{timestamp: 2, value: 2},
+		{timestamp: 3, value: 4}}, merged)
+}
+
+func TestPebbleSaveAndQuery(t *testing.T) {
+	store := createTestDataStore(t, 0)
+	defer store.Close()
+
+	for _, value := range []float64{70, 72, 74} {
+		deviceState := state.CreateDeviceStateMap(map[string]float64{"tempF0": value}, []int{1})
+		assert.Nil(t, store.Save(1, deviceState))
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	now := time.Now()
+	datapoints, err := store.GetRange(1, "tempF0", now.Add(-time.Minute), now)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(datapoints))
+	assert.Equal(t, 70.0, datapoints[0].Value)
+	assert.Equal(t, 74.0, datapoints[2].Value)
+
+	values, err := store.GetLast30Days(1, "c0")
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{1, 1, 1}, values)
+
+	latest, err := store.GetLatest(1, "tempF0")
+	assert.Nil(t, err)
+	assert.Equal(t, 74.0, latest.Value)
+
+	_, err = store.GetLatest(2, "tempF0")
+	assert.Equal(t, datastore.ErrRecordNotFound, err)
+
+	for aggregation, expected := range map[string]float64{
+		datastore.AGGREGATION_AVG:   72,
+		datastore.AGGREGATION_MIN:   70,
+		datastore.AGGREGATION_MAX:   74,
+		datastore.AGGREGATION_SUM:   216,
+		datastore.AGGREGATION_COUNT: 3,
+		datastore.AGGREGATION_FIRST: 70,
+		datastore.AGGREGATION_LAST:  74} {
+
+		// The range covers the whole block so summaries are used where possible
+		aggregate, err := store.GetAggregate(1, "tempF0", time.UnixMilli(0), now.Add(time.Hour),
+			aggregation, 24*time.Hour)
+		assert.Nil(t, err, aggregation)
+		assert.Equal(t, 1, len(aggregate), aggregation)
+		assert.Equal(t, expected, aggregate[0].Value, aggregation)
+	}
+
+	_, err = store.GetAggregate(1, "tempF0", now.Add(-time.Minute), now, "median", time.Hour)
+	assert.True(t, errors.Is(err, datastore.ErrInvalidAggregation))
+
+	_, err = store.GetAggregate(1, "tempF0", now.Add(-time.Minute), now, datastore.AGGREGATION_AVG, 0)
+	assert.Equal(t, datastore.ErrInvalidTimeBucket, err)
+}
+
+func TestPebbleBufferedSamples(t *testing.T) {
+	dir := t.TempDir()
+	params := &PebbleInitParams{Dir: dir, FlushInterval: time.Hour}
+	store, err := NewPebbleDataStore(logging.MustGetLogger("pebble_test"), params)
+	assert.Nil(t, err)
+
+	deviceState := state.CreateDeviceStateMap(map[string]float64{"ph": 5.8}, []int{})
+	assert.Nil(t, store.Save(1, deviceState))
+
+	// Buffered samples are visible before they are flushed
+	values, err := store.GetLast30Days(1, "ph")
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{5.8}, values)
+
+	// and are written to disk when the store is closed
+	assert.Nil(t, store.Close())
+	store, err = NewPebbleDataStore(logging.MustGetLogger("pebble_test"), params)
+	assert.Nil(t, err)
+	defer store.Close()
+
+	values, err = store.GetLast30Days(1, "ph")
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{5.8}, values)
+}
+
+func TestPebbleFlushOnClose(t *testing.T) {
+	store := createTestDataStore(t, time.Hour)
+
+	deviceState := state.CreateDeviceStateMap(map[string]float64{"ph": 5.8}, []int{1})
+	assert.Nil(t, store.Save(1, deviceState))
+	assert.Equal(t, 2, len(store.head))
+
+	// Buffered samples are written to disk and the head cleared
+	assert.Nil(t, store.Close())
+	assert.Equal(t, 0, len(store.head))
+
+	// Closing twice is a no-op and late samples are rejected
+	assert.Nil(t, store.Close())
+	assert.Equal(t, datastore.ErrDataStoreClosed, store.Save(1, deviceState))
+	assert.Equal(t, datastore.ErrDataStoreClosed, store.Flush())
+
+	reopened, err := NewPebbleDataStore(logging.MustGetLogger("pebble_test"), store.params)
+	assert.Nil(t, err)
+	defer reopened.Close()
+
+	latest, err := reopened.GetLatest(1, "ph")
+	assert.Nil(t, err)
+	assert.Equal(t, 5.8, latest.Value)
+
+	latest, err = reopened.GetLatest(1, "c0")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, latest.Value)
+}
@@ -0,0 +1,109 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"sort"
+
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+type RaftAlarmDAO interface {
+	RaftDAO[*entity.Alarm]
+	dao.AlarmDAO
+	ClusterID() uint64
+}
+
+type RaftAlarm struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.AlarmDAO
+	GenericRaftDAO[*entity.Alarm]
+}
+
+func NewRaftAlarmDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftAlarmDAO {
+
+	alarmClusterID := raftNode.GetParams().
+		IdGenerator.CreateAlarmClusterID(clusterID)
+
+	return &RaftAlarm{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.Alarm]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: alarmClusterID,
+		}}
+}
+
+func (dao *RaftAlarm) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+func (dao *RaftAlarm) StartClusterNode(waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartClusterNode(waitForClusterReady)
+}
+
+func (dao *RaftAlarm) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartLocalCluster(localCluster, waitForClusterReady)
+}
+
+func (dao *RaftAlarm) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+func (dao *RaftAlarm) Save(alarm *entity.Alarm) error {
+	return dao.GenericRaftDAO.Save(alarm)
+}
+
+func (dao *RaftAlarm) Update(alarm *entity.Alarm) error {
+	return dao.GenericRaftDAO.Update(alarm)
+}
+
+func (dao *RaftAlarm) Delete(alarm *entity.Alarm) error {
+	return dao.GenericRaftDAO.Delete(alarm)
+}
+
+func (dao *RaftAlarm) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.Alarm, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns all of the farm's alarms, most recently raised first
+func (dao *RaftAlarm) GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.Alarm, error) {
+	alarms := make([]*entity.Alarm, 0)
+	err := dao.GenericRaftDAO.ForEachPage(query.NewPageQuery(),
+		func(entities []*entity.Alarm) error {
+			for _, alarm := range entities {
+				if alarm.GetFarmID() == farmID {
+					alarms = append(alarms, alarm)
+				}
+			}
+			return nil
+		}, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(alarms, func(i, j int) bool {
+		return alarms[i].RaisedAt.After(alarms[j].RaisedAt)
+	})
+	return alarms, nil
+}
+
+func (dao *RaftAlarm) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.Alarm], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftAlarm) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.Alarm], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftAlarm) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
@@ -0,0 +1,109 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"sort"
+
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+type RaftAPIKeyDAO interface {
+	RaftDAO[*entity.APIKey]
+	dao.APIKeyDAO
+	ClusterID() uint64
+}
+
+type RaftAPIKey struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.APIKeyDAO
+	GenericRaftDAO[*entity.APIKey]
+}
+
+func NewRaftAPIKeyDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftAPIKeyDAO {
+
+	apiKeyClusterID := raftNode.GetParams().
+		IdGenerator.CreateAPIKeyClusterID(clusterID)
+
+	return &RaftAPIKey{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.APIKey]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: apiKeyClusterID,
+		}}
+}
+
+func (dao *RaftAPIKey) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+func (dao *RaftAPIKey) StartClusterNode(waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartClusterNode(waitForClusterReady)
+}
+
+func (dao *RaftAPIKey) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartLocalCluster(localCluster, waitForClusterReady)
+}
+
+func (dao *RaftAPIKey) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+func (dao *RaftAPIKey) Save(apiKey *entity.APIKey) error {
+	return dao.GenericRaftDAO.Save(apiKey)
+}
+
+func (dao *RaftAPIKey) Update(apiKey *entity.APIKey) error {
+	return dao.GenericRaftDAO.Update(apiKey)
+}
+
+func (dao *RaftAPIKey) Delete(apiKey *entity.APIKey) error {
+	return dao.GenericRaftDAO.Delete(apiKey)
+}
+
+func (dao *RaftAPIKey) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.APIKey, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns the API keys created by the user, newest first
+func (dao *RaftAPIKey) GetByCreatedBy(userID uint64, CONSISTENCY_LEVEL int) ([]*entity.APIKey, error) {
+	apiKeys := make([]*entity.APIKey, 0)
+	err := dao.GenericRaftDAO.ForEachPage(query.NewPageQuery(),
+		func(entities []*entity.APIKey) error {
+			for _, apiKey := range entities {
+				if apiKey.GetCreatedBy() == userID {
+					apiKeys = append(apiKeys, apiKey)
+				}
+			}
+			return nil
+		}, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(apiKeys, func(i, j int) bool {
+		return apiKeys[i].CreatedAt.After(apiKeys[j].CreatedAt)
+	})
+	return apiKeys, nil
+}
+
+func (dao *RaftAPIKey) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.APIKey], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftAPIKey) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.APIKey], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftAPIKey) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
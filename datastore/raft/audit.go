@@ -0,0 +1,260 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"encoding/json"
+
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/statemachine"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	sm "github.com/lni/dragonboat/v3/statemachine"
+	logging "github.com/op/go-logging"
+)
+
+type RaftAuditDAO interface {
+	RaftDAO[*entity.AuditEntry]
+	dao.AuditDAO
+	ClusterID() uint64
+}
+
+type RaftAudit struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.AuditDAO
+	GenericRaftDAO[*entity.AuditEntry]
+}
+
+func NewRaftAuditDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftAuditDAO {
+
+	auditClusterID := raftNode.GetParams().
+		IdGenerator.CreateAuditClusterID(clusterID)
+
+	return &RaftAudit{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.AuditEntry]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: auditClusterID,
+		}}
+}
+
+func (dao *RaftAudit) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+// Starts the audit Raft cluster on the current node using the audit
+// state machine, which owns the hash chain's sequence
+func (dao *RaftAudit) StartClusterNode(waitForClusterReady bool) error {
+	params := dao.raft.GetParams()
+	clusterID := dao.GenericRaftDAO.clusterID
+	nodeID := params.GetNodeID()
+	dao.logger.Debugf("Starting audit raft cluster %d on node %d", clusterID, nodeID)
+	sm := statemachine.NewAuditOnDiskStateMachine(dao.logger, params.IdGenerator,
+		params.DataDir, clusterID, nodeID)
+	err := dao.raft.CreateOnDiskCluster(clusterID, params.Join, sm.CreateAuditOnDiskStateMachine)
+	if err != nil {
+		dao.logger.Errorf("StartClusterNode error starting audit raft cluster on node %d: %s", nodeID, err)
+		return err
+	}
+	if waitForClusterReady {
+		dao.raft.WaitForClusterReady(clusterID)
+	}
+	return nil
+}
+
+func (dao *RaftAudit) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	localCluster.app.Logger.Debugf("Creating local %d node audit raft cluster: %d",
+		localCluster.nodeCount, dao.GenericRaftDAO.clusterID)
+	for i := 0; i < localCluster.nodeCount; i++ {
+		raftNode := localCluster.GetRaftNode(i)
+		nodeDAO := &RaftAudit{
+			logger: dao.logger,
+			raft:   raftNode,
+			GenericRaftDAO: GenericRaftDAO[*entity.AuditEntry]{
+				logger:    dao.logger,
+				raft:      raftNode,
+				clusterID: dao.GenericRaftDAO.clusterID}}
+		if err := nodeDAO.StartClusterNode(false); err != nil {
+			dao.logger.Errorf("StartLocalCluster error starting audit raft cluster on node %d: %s", i, err)
+			return err
+		}
+	}
+	if waitForClusterReady {
+		dao.raft.WaitForClusterReady(dao.GenericRaftDAO.clusterID)
+	}
+	return nil
+}
+
+func (dao *RaftAudit) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+// Proposes the entry to the audit state machine, which only appends it if
+// it's the next entry in the chain. Returns datastore.ErrAuditConflict if
+// another entry was appended first.
+func (dao *RaftAudit) Save(entry *entity.AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		dao.logger.Errorf("Save json.Marshal error: %s", err)
+		return err
+	}
+	proposal, err := statemachine.CreateProposal(
+		statemachine.QUERY_TYPE_UPDATE, data).Serialize()
+	if err != nil {
+		dao.logger.Errorf("Save CreateProposal error: %s", err)
+		return err
+	}
+	result, err := dao.raft.SyncProposeResult(dao.GenericRaftDAO.clusterID, proposal)
+	if err != nil {
+		dao.logger.Errorf("Save SyncPropose error: %s", err)
+		return err
+	}
+	if result.Value != entry.ID {
+		return datastore.ErrAuditConflict
+	}
+	return nil
+}
+
+func (dao *RaftAudit) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.AuditEntry, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns the entry at the head of the audit chain
+func (dao *RaftAudit) GetLast(CONSISTENCY_LEVEL int) (*entity.AuditEntry, error) {
+	var result interface{}
+	var err error
+	if CONSISTENCY_LEVEL == common.CONSISTENCY_QUORUM {
+		result, err = dao.raft.SyncRead(dao.GenericRaftDAO.clusterID, statemachine.AUDIT_QUERY_LAST)
+	} else {
+		result, err = dao.raft.ReadLocal(dao.GenericRaftDAO.clusterID, statemachine.AUDIT_QUERY_LAST)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.(*entity.AuditEntry), nil
+}
+
+// Returns a page of audit entries ordered by sequence number
+func (dao *RaftAudit) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.AuditEntry], error) {
+
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+// Passes each page of audit entries to the pager function. Unlike the
+// generic pager, read errors are returned so a failed read can't pass
+// for a verified audit log.
+func (auditDAO *RaftAudit) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.AuditEntry], CONSISTENCY_LEVEL int) error {
+
+	pageResult, err := auditDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+	if err != nil {
+		return err
+	}
+	if err = pagerProcFunc(pageResult.Entities); err != nil {
+		return err
+	}
+	if pageResult.HasMore {
+		nextPageQuery := query.PageQuery{
+			Page:      pageQuery.Page + 1,
+			PageSize:  pageQuery.PageSize,
+			SortOrder: pageQuery.SortOrder}
+		return auditDAO.ForEachPage(nextPageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+	}
+	return nil
+}
+
+func (dao *RaftAudit) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
+
+// RaftAuditReplica reads this node's replica of the audit log without
+// joining the cluster, so the audit log can be exported and verified
+// while the node is stopped. The replica is read-only.
+type RaftAuditReplica struct {
+	replica sm.IOnDiskStateMachine
+	dao.AuditDAO
+}
+
+func OpenRaftAuditReplica(logger *logging.Logger, idGenerator util.IdGenerator,
+	dataDir string, clusterID, nodeID uint64) (*RaftAuditReplica, error) {
+
+	replica, err := statemachine.OpenAuditReplica(logger, dataDir,
+		idGenerator.CreateAuditClusterID(clusterID), nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return &RaftAuditReplica{replica: replica}, nil
+}
+
+func (auditDAO *RaftAuditReplica) Close() error {
+	return auditDAO.replica.Close()
+}
+
+func (auditDAO *RaftAuditReplica) Save(entry *entity.AuditEntry) error {
+	return statemachine.ErrReadOnlyReplica
+}
+
+func (auditDAO *RaftAuditReplica) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.AuditEntry, error) {
+	result, err := auditDAO.replica.Lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*entity.AuditEntry), nil
+}
+
+func (auditDAO *RaftAuditReplica) GetLast(CONSISTENCY_LEVEL int) (*entity.AuditEntry, error) {
+	result, err := auditDAO.replica.Lookup(statemachine.AUDIT_QUERY_LAST)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*entity.AuditEntry), nil
+}
+
+func (auditDAO *RaftAuditReplica) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.AuditEntry], error) {
+
+	jsonPageQuery, err := json.Marshal(pageQuery)
+	if err != nil {
+		return dao.PageResult[*entity.AuditEntry]{}, err
+	}
+	result, err := auditDAO.replica.Lookup(jsonPageQuery)
+	if err != nil {
+		return dao.PageResult[*entity.AuditEntry]{}, err
+	}
+	return result.(dao.PageResult[*entity.AuditEntry]), nil
+}
+
+func (auditDAO *RaftAuditReplica) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.AuditEntry], CONSISTENCY_LEVEL int) error {
+
+	for {
+		pageResult, err := auditDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+		if err != nil {
+			return err
+		}
+		if err = pagerProcFunc(pageResult.Entities); err != nil {
+			return err
+		}
+		if !pageResult.HasMore {
+			return nil
+		}
+		pageQuery.Page++
+	}
+}
+
+func (auditDAO *RaftAuditReplica) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	result, err := auditDAO.replica.Lookup(query.QUERY_TYPE_COUNT)
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
@@ -75,6 +75,68 @@ func (dao *RaftEventLog) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL in
 	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
 }
 
+// Returns a page of the event log entries matching the filter. Event log
+// entries are keyed by their microsecond creation time, so the scan stops
+// as soon as it passes the filter's time range.
+func (eventLogDAO *RaftEventLog) GetPageByFilter(filter dao.EventLogFilter, pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLog], error) {
+
+	pageResult := dao.PageResult[*entity.EventLog]{
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize}
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	matches := make([]*entity.EventLog, 0, pageQuery.PageSize+1)
+	scanQuery := query.PageQuery{
+		Page:      1,
+		PageSize:  query.NewPageQuery().PageSize,
+		SortOrder: pageQuery.SortOrder}
+	skipped := 0
+	for {
+		page, err := eventLogDAO.GenericRaftDAO.GetPage(scanQuery, CONSISTENCY_LEVEL)
+		if err != nil {
+			return pageResult, err
+		}
+		done := !page.HasMore
+		for _, event := range page.Entities {
+			if eventLogDAO.passed(filter, pageQuery.SortOrder, event) {
+				done = true
+				break
+			}
+			if !filter.Matches(event) {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			matches = append(matches, event)
+			if len(matches) == pageQuery.PageSize+1 {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+		scanQuery.Page++
+	}
+	// If the peek record was found, set the HasMore flag and remove the +1 record
+	if len(matches) == pageQuery.PageSize+1 {
+		pageResult.HasMore = true
+		matches = matches[:len(matches)-1]
+	}
+	pageResult.Entities = matches
+	return pageResult, nil
+}
+
+// Returns true if the scan has moved past the filter's time range
+func (eventLogDAO *RaftEventLog) passed(filter dao.EventLogFilter, sortOrder int, event *entity.EventLog) bool {
+	if sortOrder == query.SORT_ASCENDING {
+		return !filter.End.IsZero() && event.Timestamp.After(filter.End)
+	}
+	return !filter.Start.IsZero() && event.Timestamp.Before(filter.Start)
+}
+
 func (customerDAO *RaftEventLog) ForEachPage(pageQuery query.PageQuery,
 	pagerProcFunc query.PagerProcFunc[*entity.EventLog], CONSISTENCY_LEVEL int) error {
 
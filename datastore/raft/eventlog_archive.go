@@ -0,0 +1,109 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"sort"
+
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+type RaftEventLogArchiveDAO interface {
+	RaftDAO[*entity.EventLogArchive]
+	dao.EventLogArchiveDAO
+	ClusterID() uint64
+}
+
+type RaftEventLogArchive struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.EventLogArchiveDAO
+	GenericRaftDAO[*entity.EventLogArchive]
+}
+
+func NewRaftEventLogArchiveDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftEventLogArchiveDAO {
+
+	archiveClusterID := raftNode.GetParams().
+		IdGenerator.CreateEventLogArchiveClusterID(clusterID)
+
+	return &RaftEventLogArchive{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.EventLogArchive]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: archiveClusterID,
+		}}
+}
+
+func (dao *RaftEventLogArchive) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+func (dao *RaftEventLogArchive) StartClusterNode(waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartClusterNode(waitForClusterReady)
+}
+
+func (dao *RaftEventLogArchive) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartLocalCluster(localCluster, waitForClusterReady)
+}
+
+func (dao *RaftEventLogArchive) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+func (dao *RaftEventLogArchive) Save(archive *entity.EventLogArchive) error {
+	return dao.GenericRaftDAO.Save(archive)
+}
+
+func (dao *RaftEventLogArchive) Update(archive *entity.EventLogArchive) error {
+	return dao.GenericRaftDAO.Update(archive)
+}
+
+func (dao *RaftEventLogArchive) Delete(archive *entity.EventLogArchive) error {
+	return dao.GenericRaftDAO.Delete(archive)
+}
+
+func (dao *RaftEventLogArchive) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.EventLogArchive, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns the farm's event log archives, oldest first
+func (dao *RaftEventLogArchive) GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.EventLogArchive, error) {
+	archives := make([]*entity.EventLogArchive, 0)
+	err := dao.GenericRaftDAO.ForEachPage(query.NewPageQuery(),
+		func(entities []*entity.EventLogArchive) error {
+			for _, archive := range entities {
+				if archive.GetFarmID() == farmID {
+					archives = append(archives, archive)
+				}
+			}
+			return nil
+		}, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].Name < archives[j].Name
+	})
+	return archives, nil
+}
+
+func (dao *RaftEventLogArchive) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLogArchive], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftEventLogArchive) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.EventLogArchive], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftEventLogArchive) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
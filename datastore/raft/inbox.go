@@ -0,0 +1,192 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"encoding/json"
+
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/statemachine"
+	logging "github.com/op/go-logging"
+)
+
+type RaftInboxDAO interface {
+	RaftDAO[*entity.InboxItem]
+	dao.InboxDAO
+	ClusterID() uint64
+}
+
+type RaftInbox struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.InboxDAO
+	GenericRaftDAO[*entity.InboxItem]
+}
+
+func NewRaftInboxDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftInboxDAO {
+
+	inboxClusterID := raftNode.GetParams().
+		IdGenerator.CreateInboxClusterID(clusterID)
+
+	return &RaftInbox{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.InboxItem]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: inboxClusterID,
+		}}
+}
+
+func (dao *RaftInbox) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+// Starts the inbox Raft cluster on the current node using the inbox
+// state machine, which indexes the items by user
+func (dao *RaftInbox) StartClusterNode(waitForClusterReady bool) error {
+	params := dao.raft.GetParams()
+	clusterID := dao.GenericRaftDAO.clusterID
+	nodeID := params.GetNodeID()
+	dao.logger.Debugf("Starting inbox raft cluster %d on node %d", clusterID, nodeID)
+	sm := statemachine.NewInboxOnDiskStateMachine(dao.logger, params.IdGenerator,
+		params.DataDir, clusterID, nodeID, common.INBOX_RETENTION)
+	err := dao.raft.CreateOnDiskCluster(clusterID, params.Join, sm.CreateInboxOnDiskStateMachine)
+	if err != nil {
+		dao.logger.Errorf("StartClusterNode error starting inbox raft cluster on node %d: %s", nodeID, err)
+		return err
+	}
+	if waitForClusterReady {
+		dao.raft.WaitForClusterReady(clusterID)
+	}
+	return nil
+}
+
+func (dao *RaftInbox) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	localCluster.app.Logger.Debugf("Creating local %d node inbox raft cluster: %d",
+		localCluster.nodeCount, dao.GenericRaftDAO.clusterID)
+	for i := 0; i < localCluster.nodeCount; i++ {
+		raftNode := localCluster.GetRaftNode(i)
+		nodeDAO := &RaftInbox{
+			logger: dao.logger,
+			raft:   raftNode,
+			GenericRaftDAO: GenericRaftDAO[*entity.InboxItem]{
+				logger:    dao.logger,
+				raft:      raftNode,
+				clusterID: dao.GenericRaftDAO.clusterID}}
+		if err := nodeDAO.StartClusterNode(false); err != nil {
+			dao.logger.Errorf("StartLocalCluster error starting inbox raft cluster on node %d: %s", i, err)
+			return err
+		}
+	}
+	if waitForClusterReady {
+		dao.raft.WaitForClusterReady(dao.GenericRaftDAO.clusterID)
+	}
+	return nil
+}
+
+func (dao *RaftInbox) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+// Saves the item. New items are left without an ID so the state machine
+// assigns the next ID in its sequence when the item is applied.
+func (dao *RaftInbox) Save(item *entity.InboxItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		dao.logger.Errorf("Save json.Marshal error: %s", err)
+		return err
+	}
+	proposal, err := statemachine.CreateProposal(
+		statemachine.QUERY_TYPE_UPDATE, data).Serialize()
+	if err != nil {
+		dao.logger.Errorf("Save CreateProposal error: %s", err)
+		return err
+	}
+	if err := dao.raft.SyncPropose(dao.GenericRaftDAO.clusterID, proposal); err != nil {
+		dao.logger.Errorf("Save SyncPropose error: %s", err)
+		return err
+	}
+	return nil
+}
+
+func (dao *RaftInbox) Update(item *entity.InboxItem) error {
+	return dao.Save(item)
+}
+
+func (dao *RaftInbox) Delete(item *entity.InboxItem) error {
+	return dao.GenericRaftDAO.Delete(item)
+}
+
+func (dao *RaftInbox) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.InboxItem, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns a page of the user's inbox, newest item first
+func (inboxDAO *RaftInbox) GetByUserID(userID uint64, pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.InboxItem], error) {
+
+	pageResult := dao.PageResult[*entity.InboxItem]{
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize}
+	result, err := inboxDAO.read(statemachine.InboxQuery{
+		Type:      statemachine.INBOX_QUERY_PAGE,
+		UserID:    userID,
+		PageQuery: pageQuery}, CONSISTENCY_LEVEL)
+	if err != nil {
+		return pageResult, err
+	}
+	return result.(dao.PageResult[*entity.InboxItem]), nil
+}
+
+// Returns the user's inbox items added after the item for the given
+// notification, oldest first
+func (dao *RaftInbox) GetSince(userID, notificationID uint64,
+	CONSISTENCY_LEVEL int) ([]*entity.InboxItem, error) {
+
+	result, err := dao.read(statemachine.InboxQuery{
+		Type:           statemachine.INBOX_QUERY_SINCE,
+		UserID:         userID,
+		NotificationID: notificationID}, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	return result.([]*entity.InboxItem), nil
+}
+
+// Returns the number of unread items in the user's inbox
+func (dao *RaftInbox) CountUnread(userID uint64, CONSISTENCY_LEVEL int) (int64, error) {
+	result, err := dao.read(statemachine.InboxQuery{
+		Type:   statemachine.INBOX_QUERY_UNREAD,
+		UserID: userID}, CONSISTENCY_LEVEL)
+	if err != nil {
+		return 0, err
+	}
+	return result.(int64), nil
+}
+
+func (dao *RaftInbox) read(inboxQuery statemachine.InboxQuery, CONSISTENCY_LEVEL int) (interface{}, error) {
+	if CONSISTENCY_LEVEL == common.CONSISTENCY_QUORUM {
+		return dao.raft.SyncRead(dao.GenericRaftDAO.clusterID, inboxQuery)
+	}
+	return dao.raft.ReadLocal(dao.GenericRaftDAO.clusterID, inboxQuery)
+}
+
+func (dao *RaftInbox) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.InboxItem], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftInbox) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.InboxItem], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftInbox) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
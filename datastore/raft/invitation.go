@@ -0,0 +1,124 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"sort"
+
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+type RaftInvitationDAO interface {
+	RaftDAO[*entity.Invitation]
+	dao.InvitationDAO
+	ClusterID() uint64
+}
+
+type RaftInvitation struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.InvitationDAO
+	GenericRaftDAO[*entity.Invitation]
+}
+
+func NewRaftInvitationDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftInvitationDAO {
+
+	invitationClusterID := raftNode.GetParams().
+		IdGenerator.CreateInvitationClusterID(clusterID)
+
+	return &RaftInvitation{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.Invitation]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: invitationClusterID,
+		}}
+}
+
+func (dao *RaftInvitation) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+func (dao *RaftInvitation) StartClusterNode(waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartClusterNode(waitForClusterReady)
+}
+
+func (dao *RaftInvitation) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartLocalCluster(localCluster, waitForClusterReady)
+}
+
+func (dao *RaftInvitation) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+func (dao *RaftInvitation) Save(invitation *entity.Invitation) error {
+	return dao.GenericRaftDAO.Save(invitation)
+}
+
+func (dao *RaftInvitation) Update(invitation *entity.Invitation) error {
+	return dao.GenericRaftDAO.Update(invitation)
+}
+
+func (dao *RaftInvitation) Delete(invitation *entity.Invitation) error {
+	return dao.GenericRaftDAO.Delete(invitation)
+}
+
+func (dao *RaftInvitation) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.Invitation, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns all of the invitations to the organization and its farms
+func (dao *RaftInvitation) GetByOrganizationID(orgID uint64, CONSISTENCY_LEVEL int) ([]*entity.Invitation, error) {
+	return dao.filter(func(invitation *entity.Invitation) bool {
+		return invitation.GetOrganizationID() == orgID
+	}, CONSISTENCY_LEVEL)
+}
+
+// Returns all of the invitations to the farm
+func (dao *RaftInvitation) GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.Invitation, error) {
+	return dao.filter(func(invitation *entity.Invitation) bool {
+		return invitation.GetFarmID() == farmID
+	}, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftInvitation) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.Invitation], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftInvitation) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.Invitation], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftInvitation) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftInvitation) filter(matchFunc func(invitation *entity.Invitation) bool,
+	CONSISTENCY_LEVEL int) ([]*entity.Invitation, error) {
+
+	invitations := make([]*entity.Invitation, 0)
+	err := dao.GenericRaftDAO.ForEachPage(query.NewPageQuery(),
+		func(entities []*entity.Invitation) error {
+			for _, invitation := range entities {
+				if matchFunc(invitation) {
+					invitations = append(invitations, invitation)
+				}
+			}
+			return nil
+		}, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.After(invitations[j].CreatedAt)
+	})
+	return invitations, nil
+}
@@ -0,0 +1,86 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+type RaftLicenseDAO interface {
+	RaftDAO[*entity.License]
+	dao.LicenseDAO
+	ClusterID() uint64
+}
+
+type RaftLicense struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.LicenseDAO
+	GenericRaftDAO[*entity.License]
+}
+
+func NewRaftLicenseDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftLicenseDAO {
+
+	licenseClusterID := raftNode.GetParams().
+		IdGenerator.CreateLicenseClusterID(clusterID)
+
+	return &RaftLicense{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.License]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: licenseClusterID,
+		}}
+}
+
+func (dao *RaftLicense) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+func (dao *RaftLicense) StartClusterNode(waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartClusterNode(waitForClusterReady)
+}
+
+func (dao *RaftLicense) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartLocalCluster(localCluster, waitForClusterReady)
+}
+
+func (dao *RaftLicense) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+func (dao *RaftLicense) Save(license *entity.License) error {
+	return dao.GenericRaftDAO.Save(license)
+}
+
+func (dao *RaftLicense) Update(license *entity.License) error {
+	return dao.GenericRaftDAO.Update(license)
+}
+
+func (dao *RaftLicense) Delete(license *entity.License) error {
+	return dao.GenericRaftDAO.Delete(license)
+}
+
+func (dao *RaftLicense) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.License, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftLicense) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.License], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftLicense) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.License], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftLicense) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
@@ -0,0 +1,112 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+type RaftOIDCStateDAO interface {
+	RaftDAO[*entity.OIDCState]
+	dao.OIDCStateDAO
+	ClusterID() uint64
+}
+
+type RaftOIDCState struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.OIDCStateDAO
+	GenericRaftDAO[*entity.OIDCState]
+}
+
+func NewRaftOIDCStateDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftOIDCStateDAO {
+
+	oidcStateClusterID := raftNode.GetParams().
+		IdGenerator.CreateOIDCStateClusterID(clusterID)
+
+	return &RaftOIDCState{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.OIDCState]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: oidcStateClusterID,
+		}}
+}
+
+func (dao *RaftOIDCState) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+func (dao *RaftOIDCState) StartClusterNode(waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartClusterNode(waitForClusterReady)
+}
+
+func (dao *RaftOIDCState) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartLocalCluster(localCluster, waitForClusterReady)
+}
+
+func (dao *RaftOIDCState) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+func (dao *RaftOIDCState) Save(state *entity.OIDCState) error {
+	return dao.GenericRaftDAO.Save(state)
+}
+
+func (dao *RaftOIDCState) Update(state *entity.OIDCState) error {
+	return dao.GenericRaftDAO.Update(state)
+}
+
+func (dao *RaftOIDCState) Delete(state *entity.OIDCState) error {
+	return dao.GenericRaftDAO.Delete(state)
+}
+
+func (dao *RaftOIDCState) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.OIDCState, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Deletes the logins that expired before they were completed
+func (dao *RaftOIDCState) DeleteExpired(now time.Time) error {
+	expired := make([]*entity.OIDCState, 0)
+	err := dao.GenericRaftDAO.ForEachPage(query.NewPageQuery(),
+		func(entities []*entity.OIDCState) error {
+			for _, state := range entities {
+				if state.IsExpired(now) {
+					expired = append(expired, state)
+				}
+			}
+			return nil
+		}, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return err
+	}
+	for _, state := range expired {
+		if err := dao.GenericRaftDAO.Delete(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dao *RaftOIDCState) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.OIDCState], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftOIDCState) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.OIDCState], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftOIDCState) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
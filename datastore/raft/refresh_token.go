@@ -0,0 +1,119 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+type RaftRefreshTokenDAO interface {
+	RaftDAO[*entity.RefreshToken]
+	dao.RefreshTokenDAO
+	ClusterID() uint64
+}
+
+type RaftRefreshToken struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.RefreshTokenDAO
+	GenericRaftDAO[*entity.RefreshToken]
+}
+
+func NewRaftRefreshTokenDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftRefreshTokenDAO {
+
+	refreshTokenClusterID := raftNode.GetParams().
+		IdGenerator.CreateRefreshTokenClusterID(clusterID)
+
+	return &RaftRefreshToken{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.RefreshToken]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: refreshTokenClusterID,
+		}}
+}
+
+func (dao *RaftRefreshToken) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+func (dao *RaftRefreshToken) StartClusterNode(waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartClusterNode(waitForClusterReady)
+}
+
+func (dao *RaftRefreshToken) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartLocalCluster(localCluster, waitForClusterReady)
+}
+
+func (dao *RaftRefreshToken) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+func (dao *RaftRefreshToken) Save(token *entity.RefreshToken) error {
+	return dao.GenericRaftDAO.Save(token)
+}
+
+func (dao *RaftRefreshToken) Update(token *entity.RefreshToken) error {
+	return dao.GenericRaftDAO.Update(token)
+}
+
+func (dao *RaftRefreshToken) Delete(token *entity.RefreshToken) error {
+	return dao.GenericRaftDAO.Delete(token)
+}
+
+func (dao *RaftRefreshToken) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.RefreshToken, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+// Returns all of the user's refresh tokens
+func (dao *RaftRefreshToken) GetByUserID(userID uint64, CONSISTENCY_LEVEL int) ([]*entity.RefreshToken, error) {
+	return dao.filter(func(token *entity.RefreshToken) bool {
+		return token.GetUserID() == userID
+	}, CONSISTENCY_LEVEL)
+}
+
+// Returns every refresh token issued from the same login
+func (dao *RaftRefreshToken) GetByFamily(family uint64, CONSISTENCY_LEVEL int) ([]*entity.RefreshToken, error) {
+	return dao.filter(func(token *entity.RefreshToken) bool {
+		return token.GetFamily() == family
+	}, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftRefreshToken) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.RefreshToken], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftRefreshToken) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.RefreshToken], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftRefreshToken) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftRefreshToken) filter(matchFunc func(token *entity.RefreshToken) bool,
+	CONSISTENCY_LEVEL int) ([]*entity.RefreshToken, error) {
+
+	tokens := make([]*entity.RefreshToken, 0)
+	err := dao.GenericRaftDAO.ForEachPage(query.NewPageQuery(),
+		func(entities []*entity.RefreshToken) error {
+			for _, token := range entities {
+				if matchFunc(token) {
+					tokens = append(tokens, token)
+				}
+			}
+			return nil
+		}, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
@@ -28,6 +28,17 @@ type RaftDaoRegistry struct {
 	conditionDAO     dao.ConditionDAO
 	algorithmDAO     dao.AlgorithmDAO
 	eventLogDAO      dao.EventLogDAO
+	archiveDAO       dao.EventLogArchiveDAO
+	userEmailDAO     dao.UserEmailDAO
+	alarmDAO         dao.AlarmDAO
+	inboxDAO         dao.InboxDAO
+	auditDAO         dao.AuditDAO
+	apiKeyDAO        dao.APIKeyDAO
+	refreshTokenDAO  dao.RefreshTokenDAO
+	totpDAO          dao.TOTPDAO
+	invitationDAO    dao.InvitationDAO
+	licenseDAO       dao.LicenseDAO
+	oidcStateDAO     dao.OIDCStateDAO
 	userDAO          dao.UserDAO
 	roleDAO          dao.RoleDAO
 	customerDAO      dao.CustomerDAO
@@ -53,6 +64,50 @@ func NewRaftRegistry(logger *logging.Logger,
 		raftNode, raftOptions.SystemClusterID)
 	eventLogDAO.(RaftEventLogDAO).StartClusterNode(false)
 
+	archiveDAO := NewRaftEventLogArchiveDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	archiveDAO.StartClusterNode(false)
+
+	alarmDAO := NewRaftAlarmDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	alarmDAO.StartClusterNode(false)
+
+	inboxDAO := NewRaftInboxDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	inboxDAO.StartClusterNode(false)
+
+	auditDAO := NewRaftAuditDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	auditDAO.StartClusterNode(false)
+
+	apiKeyDAO := NewRaftAPIKeyDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	apiKeyDAO.StartClusterNode(false)
+
+	refreshTokenDAO := NewRaftRefreshTokenDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	refreshTokenDAO.StartClusterNode(false)
+
+	totpDAO := NewRaftTOTPDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	totpDAO.StartClusterNode(false)
+
+	invitationDAO := NewRaftInvitationDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	invitationDAO.StartClusterNode(false)
+
+	licenseDAO := NewRaftLicenseDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	licenseDAO.StartClusterNode(false)
+
+	oidcStateDAO := NewRaftOIDCStateDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	oidcStateDAO.StartClusterNode(false)
+
+	userEmailDAO := NewRaftUserEmailDAO(logger,
+		raftNode, raftOptions.SystemClusterID)
+	userEmailDAO.StartClusterNode(false)
+
 	orgDAO := NewRaftOrganizationDAO(logger,
 		raftNode, raftOptions.OrganizationClusterID, serverDAO)
 	orgDAO.(RaftOrganizationDAO).StartClusterNode(false)
@@ -111,6 +166,17 @@ func NewRaftRegistry(logger *logging.Logger,
 	eventLogClusterID := raftNode.GetParams().
 		IdGenerator.CreateEventLogClusterID(raftOptions.SystemClusterID)
 	raftNode.WaitForClusterReady(eventLogClusterID)
+	raftNode.WaitForClusterReady(archiveDAO.ClusterID())
+	raftNode.WaitForClusterReady(alarmDAO.ClusterID())
+	raftNode.WaitForClusterReady(inboxDAO.ClusterID())
+	raftNode.WaitForClusterReady(auditDAO.ClusterID())
+	raftNode.WaitForClusterReady(apiKeyDAO.ClusterID())
+	raftNode.WaitForClusterReady(refreshTokenDAO.ClusterID())
+	raftNode.WaitForClusterReady(totpDAO.ClusterID())
+	raftNode.WaitForClusterReady(invitationDAO.ClusterID())
+	raftNode.WaitForClusterReady(licenseDAO.ClusterID())
+	raftNode.WaitForClusterReady(oidcStateDAO.ClusterID())
+	raftNode.WaitForClusterReady(userEmailDAO.ClusterID())
 
 	raftNode.WaitForClusterReady(raftOptions.OrganizationClusterID)
 	raftNode.WaitForClusterReady(raftOptions.RoleClusterID)
@@ -132,6 +198,17 @@ func NewRaftRegistry(logger *logging.Logger,
 		conditionDAO:     conditionDAO,
 		algorithmDAO:     algorithmDAO,
 		eventLogDAO:      eventLogDAO,
+		archiveDAO:       archiveDAO,
+		userEmailDAO:     userEmailDAO,
+		alarmDAO:         alarmDAO,
+		inboxDAO:         inboxDAO,
+		auditDAO:         auditDAO,
+		apiKeyDAO:        apiKeyDAO,
+		refreshTokenDAO:  refreshTokenDAO,
+		totpDAO:          totpDAO,
+		invitationDAO:    invitationDAO,
+		licenseDAO:       licenseDAO,
+		oidcStateDAO:     oidcStateDAO,
 		userDAO:          userDAO,
 		roleDAO:          roleDAO,
 		customerDAO:      customerDAO,
@@ -237,6 +314,94 @@ func (registry *RaftDaoRegistry) SetEventLogDAO(dao dao.EventLogDAO) {
 	registry.eventLogDAO = dao
 }
 
+func (registry *RaftDaoRegistry) GetEventLogArchiveDAO() dao.EventLogArchiveDAO {
+	return registry.archiveDAO
+}
+
+func (registry *RaftDaoRegistry) SetEventLogArchiveDAO(dao dao.EventLogArchiveDAO) {
+	registry.archiveDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetUserEmailDAO() dao.UserEmailDAO {
+	return registry.userEmailDAO
+}
+
+func (registry *RaftDaoRegistry) SetUserEmailDAO(dao dao.UserEmailDAO) {
+	registry.userEmailDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetAlarmDAO() dao.AlarmDAO {
+	return registry.alarmDAO
+}
+
+func (registry *RaftDaoRegistry) SetAlarmDAO(dao dao.AlarmDAO) {
+	registry.alarmDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetInboxDAO() dao.InboxDAO {
+	return registry.inboxDAO
+}
+
+func (registry *RaftDaoRegistry) SetInboxDAO(dao dao.InboxDAO) {
+	registry.inboxDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetAuditDAO() dao.AuditDAO {
+	return registry.auditDAO
+}
+
+func (registry *RaftDaoRegistry) SetAuditDAO(dao dao.AuditDAO) {
+	registry.auditDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetAPIKeyDAO() dao.APIKeyDAO {
+	return registry.apiKeyDAO
+}
+
+func (registry *RaftDaoRegistry) SetAPIKeyDAO(dao dao.APIKeyDAO) {
+	registry.apiKeyDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetRefreshTokenDAO() dao.RefreshTokenDAO {
+	return registry.refreshTokenDAO
+}
+
+func (registry *RaftDaoRegistry) SetRefreshTokenDAO(dao dao.RefreshTokenDAO) {
+	registry.refreshTokenDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetTOTPDAO() dao.TOTPDAO {
+	return registry.totpDAO
+}
+
+func (registry *RaftDaoRegistry) SetTOTPDAO(dao dao.TOTPDAO) {
+	registry.totpDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetInvitationDAO() dao.InvitationDAO {
+	return registry.invitationDAO
+}
+
+func (registry *RaftDaoRegistry) SetInvitationDAO(dao dao.InvitationDAO) {
+	registry.invitationDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetLicenseDAO() dao.LicenseDAO {
+	return registry.licenseDAO
+}
+
+func (registry *RaftDaoRegistry) SetLicenseDAO(dao dao.LicenseDAO) {
+	registry.licenseDAO = dao
+}
+
+func (registry *RaftDaoRegistry) GetOIDCStateDAO() dao.OIDCStateDAO {
+	return registry.oidcStateDAO
+}
+
+func (registry *RaftDaoRegistry) SetOIDCStateDAO(dao dao.OIDCStateDAO) {
+	registry.oidcStateDAO = dao
+}
+
 func (registry *RaftDaoRegistry) GetUserDAO() dao.UserDAO {
 	return registry.userDAO
 }
@@ -0,0 +1,343 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package statemachine
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	sm "github.com/lni/dragonboat/v3/statemachine"
+	logging "github.com/op/go-logging"
+)
+
+const auditEntryPrefix = 'e'
+
+// AUDIT_QUERY_LAST returns the entry at the head of the audit chain
+const AUDIT_QUERY_LAST = "last"
+
+type AuditOnDiskStateMachine interface {
+	CreateAuditOnDiskStateMachine(clusterID, nodeID uint64) sm.IOnDiskStateMachine
+}
+
+// AuditDiskKV stores the audit log hash chain. The state machine owns the
+// chain's sequence: an entry is only appended if its ID is the next ID in
+// the sequence and its previous hash is the hash of the entry at the head
+// of the chain. Proposals that lost a race with another node's entry are
+// rejected with a zero result, leaving the proposer to re-chain and retry.
+// Entries are keyed by their big endian ID so the head of the chain and
+// each page are found with a seek rather than a scan of the log.
+//
+// Keys:
+//
+//	e | entry id -> entry
+type AuditDiskKV struct {
+	GenericDiskKV GenericDiskKV[*entity.AuditEntry]
+	head          *entity.AuditEntry
+	AuditOnDiskStateMachine
+}
+
+func NewAuditOnDiskStateMachine(logger *logging.Logger, idGenerator util.IdGenerator,
+	dbPath string, clusterID, nodeID uint64) AuditOnDiskStateMachine {
+
+	return &AuditDiskKV{
+		GenericDiskKV: GenericDiskKV[*entity.AuditEntry]{
+			logger:      logger,
+			idGenerator: idGenerator,
+			diskKV: DiskKV[*entity.AuditEntry]{
+				idGenerator: idGenerator,
+				dbPath:      dbPath,
+				clusterID:   clusterID,
+				nodeID:      nodeID}}}
+}
+
+// Opens this node's existing replica of the audit log, outside of Raft,
+// for offline export and verification. The node must be stopped.
+func OpenAuditReplica(logger *logging.Logger, dbPath string,
+	clusterID, nodeID uint64) (sm.IOnDiskStateMachine, error) {
+
+	dir := getNodeDBDirName(dbPath, clusterID, nodeID)
+	if isNewRun(dir) {
+		return nil, fmt.Errorf("%w: audit log replica %s", os.ErrNotExist, dir)
+	}
+	idGenerator := util.NewIdGenerator(common.DATASTORE_TYPE_64BIT)
+	replica := NewAuditOnDiskStateMachine(logger, idGenerator, dbPath, clusterID, nodeID).
+		CreateAuditOnDiskStateMachine(clusterID, nodeID)
+	if _, err := replica.Open(nil); err != nil {
+		return nil, err
+	}
+	return replica, nil
+}
+
+func (d *AuditDiskKV) CreateAuditOnDiskStateMachine(clusterID, nodeID uint64) sm.IOnDiskStateMachine {
+	d.GenericDiskKV.idGenerator = util.NewIdGenerator(common.DATASTORE_TYPE_64BIT)
+	d.GenericDiskKV.diskKV.clusterID = clusterID
+	d.GenericDiskKV.diskKV.nodeID = nodeID
+	return d
+}
+
+func (d *AuditDiskKV) Open(stopc <-chan struct{}) (uint64, error) {
+	appliedIndex, err := d.GenericDiskKV.Open(stopc)
+	if err != nil {
+		return 0, err
+	}
+	return appliedIndex, d.loadHead()
+}
+
+func (d *AuditDiskKV) Close() error {
+	return d.GenericDiskKV.Close()
+}
+
+func (d *AuditDiskKV) Sync() error {
+	return d.GenericDiskKV.Sync()
+}
+
+func (d *AuditDiskKV) PrepareSnapshot() (interface{}, error) {
+	return d.GenericDiskKV.PrepareSnapshot()
+}
+
+func (d *AuditDiskKV) SaveSnapshot(ctx interface{}, w io.Writer, done <-chan struct{}) error {
+	return d.GenericDiskKV.SaveSnapshot(ctx, w, done)
+}
+
+func (d *AuditDiskKV) RecoverFromSnapshot(r io.Reader, done <-chan struct{}) error {
+	if err := d.GenericDiskKV.RecoverFromSnapshot(r, done); err != nil {
+		return err
+	}
+	return d.loadHead()
+}
+
+// Appends the proposed entries that extend the head of the chain in a
+// single batch. The result of an appended entry is its ID; entries that
+// don't extend the chain, and delete proposals, have a zero result.
+func (d *AuditDiskKV) Update(ents []sm.Entry) ([]sm.Entry, error) {
+	diskKV := &d.GenericDiskKV.diskKV
+	if diskKV.aborted {
+		panic("update() called after abort set to true")
+	}
+	if diskKV.closed {
+		panic("update called after Close()")
+	}
+	db := (*pebbledb)(atomic.LoadPointer(&diskKV.db))
+	batch := db.db.NewBatch()
+	defer batch.Close()
+	head := d.head
+	for idx, e := range ents {
+		ents[idx].Result = sm.Result{}
+		var proposal Proposal
+		if err := json.Unmarshal(e.Cmd, &proposal); err != nil {
+			d.GenericDiskKV.logger.Errorf("[AuditDiskKV.Update] Error: %s", err)
+			return nil, err
+		}
+		if proposal.Query != QUERY_TYPE_UPDATE {
+			d.GenericDiskKV.logger.Warningf("[AuditDiskKV.Update] Rejected audit log proposal type: %d", proposal.Query)
+			continue
+		}
+		var entry entity.AuditEntry
+		if err := json.Unmarshal(proposal.Data, &entry); err != nil {
+			d.GenericDiskKV.logger.Errorf("[AuditDiskKV.Update] Error: %s", err)
+			return nil, err
+		}
+		if !extendsAuditChain(head, &entry) {
+			d.GenericDiskKV.logger.Warningf("[AuditDiskKV.Update] Rejected audit entry %d, not the next entry in the chain",
+				entry.ID)
+			continue
+		}
+		if err := batch.Set(auditEntryKey(entry.ID), proposal.Data, db.wo); err != nil {
+			return nil, err
+		}
+		head = &entry
+		ents[idx].Result = sm.Result{Value: entry.ID}
+	}
+	appliedIndex := make([]byte, 8)
+	binary.LittleEndian.PutUint64(appliedIndex, ents[len(ents)-1].Index)
+	if err := batch.Set([]byte(appliedIndexKey), appliedIndex, db.wo); err != nil {
+		return nil, err
+	}
+	if err := db.db.Apply(batch, db.syncwo); err != nil {
+		return nil, err
+	}
+	if diskKV.lastApplied >= ents[len(ents)-1].Index {
+		panic("lastApplied not moving forward")
+	}
+	diskKV.lastApplied = ents[len(ents)-1].Index
+	d.head = head
+	return ents, nil
+}
+
+func (d *AuditDiskKV) Lookup(key interface{}) (interface{}, error) {
+	db := (*pebbledb)(atomic.LoadPointer(&d.GenericDiskKV.diskKV.db))
+	if db == nil {
+		return nil, datastore.ErrDataStoreClosed
+	}
+	switch q := key.(type) {
+
+	case uint64: // get by id
+		entry, err := d.get(db.db, q)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return nil, datastore.ErrRecordNotFound
+		}
+		return entry, nil
+
+	case string:
+		if q == AUDIT_QUERY_LAST {
+			entry, err := d.last(db.db)
+			if err != nil {
+				return nil, err
+			}
+			if entry == nil {
+				return nil, datastore.ErrRecordNotFound
+			}
+			return entry, nil
+		}
+		d.GenericDiskKV.logger.Errorf("Unsupported audit query: %s", q)
+		return nil, ErrUnsupportedQuery
+
+	case []uint8: // json serialized page query
+		var pageQuery query.PageQuery
+		if err := json.Unmarshal(q, &pageQuery); err != nil {
+			d.GenericDiskKV.logger.Error(err)
+			return nil, err
+		}
+		return d.getPage(db.db, pageQuery)
+
+	case int:
+		if q == query.QUERY_TYPE_COUNT {
+			return d.Count(), nil
+		}
+		d.GenericDiskKV.logger.Errorf("Unsupported int type query: %d", q)
+		return nil, ErrUnsupportedQuery
+
+	default:
+		d.GenericDiskKV.logger.Error(fmt.Sprintf("Unsupported key type: %T", q))
+		return nil, ErrUnsupportedQuery
+	}
+}
+
+// Returns the number of entries in the audit log. Entry IDs are
+// contiguous, so the count is the ID of the entry at the head.
+func (d *AuditDiskKV) Count() int64 {
+	db := (*pebbledb)(atomic.LoadPointer(&d.GenericDiskKV.diskKV.db))
+	head, err := d.last(db.db)
+	if err != nil || head == nil {
+		return 0
+	}
+	return int64(head.ID)
+}
+
+// Returns a page of entries ordered by ID. Entry IDs are contiguous, so
+// the iterator seeks directly to the first entry in the page.
+func (d *AuditDiskKV) getPage(reader pebble.Reader,
+	pageQuery query.PageQuery) (dao.PageResult[*entity.AuditEntry], error) {
+
+	pageResult := dao.PageResult[*entity.AuditEntry]{
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize,
+		Entities: make([]*entity.AuditEntry, 0, pageQuery.PageSize)}
+	page := pageQuery.Page
+	if page < 1 {
+		page = 1
+	}
+	offset := uint64((page - 1) * pageQuery.PageSize)
+	prefix := []byte{auditEntryPrefix}
+	iter := reader.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixUpperBound(prefix)})
+	defer iter.Close()
+	var valid bool
+	next := iter.Next
+	if pageQuery.SortOrder == query.SORT_DESCENDING {
+		next = iter.Prev
+		if valid = iter.Last(); valid {
+			head := binary.BigEndian.Uint64(iter.Key()[1:])
+			if offset >= head {
+				return pageResult, nil
+			}
+			valid = iter.SeekLT(auditEntryKey(head - offset + 1))
+		}
+	} else {
+		valid = iter.SeekGE(auditEntryKey(offset + 1))
+	}
+	for ; valid; valid = next() {
+		if len(pageResult.Entities) == pageQuery.PageSize {
+			pageResult.HasMore = true
+			break
+		}
+		var entry entity.AuditEntry
+		if err := json.Unmarshal(iter.Value(), &entry); err != nil {
+			return pageResult, err
+		}
+		pageResult.Entities = append(pageResult.Entities, &entry)
+	}
+	return pageResult, nil
+}
+
+// Returns the entry at the head of the chain, or nil if the log is empty
+func (d *AuditDiskKV) last(reader pebble.Reader) (*entity.AuditEntry, error) {
+	prefix := []byte{auditEntryPrefix}
+	iter := reader.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixUpperBound(prefix)})
+	defer iter.Close()
+	if !iter.Last() {
+		return nil, nil
+	}
+	var entry entity.AuditEntry
+	if err := json.Unmarshal(iter.Value(), &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Returns the entry with the given ID, or nil if it doesn't exist
+func (d *AuditDiskKV) get(reader pebble.Reader, id uint64) (*entity.AuditEntry, error) {
+	data, closer, err := reader.Get(auditEntryKey(id))
+	if err == pebble.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	var entry entity.AuditEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (d *AuditDiskKV) loadHead() error {
+	db := (*pebbledb)(atomic.LoadPointer(&d.GenericDiskKV.diskKV.db))
+	head, err := d.last(db.db)
+	if err != nil {
+		return err
+	}
+	d.head = head
+	return nil
+}
+
+// Returns true if the entry is the next entry after the head of the chain
+func extendsAuditChain(head, entry *entity.AuditEntry) bool {
+	if head == nil {
+		return entry.ID == 1 && entry.PrevHash == ""
+	}
+	return entry.ID == head.ID+1 && entry.PrevHash == head.Hash
+}
+
+func auditEntryKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte{auditEntryPrefix}, id)
+}
@@ -0,0 +1,112 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package statemachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	"github.com/lni/dragonboat/v3/statemachine"
+	"github.com/stretchr/testify/assert"
+)
+
+func createAuditEntry(t *testing.T, index, id uint64, prevHash string) statemachine.Entry {
+	data, err := json.Marshal(&entity.AuditEntry{
+		ID:       id,
+		Action:   common.AUDIT_ACTION_SWITCH,
+		PrevHash: prevHash,
+		Hash:     fmt.Sprintf("hash%d", id)})
+	assert.Nil(t, err)
+	cmd, err := CreateProposal(QUERY_TYPE_UPDATE, data).Serialize()
+	assert.Nil(t, err)
+	return statemachine.Entry{Index: index, Cmd: cmd}
+}
+
+func TestAuditStateMachine(t *testing.T) {
+
+	dir := t.TempDir()
+	sm := NewAuditOnDiskStateMachine(createLogger(),
+		util.NewIdGenerator(common.DATASTORE_TYPE_64BIT), dir, 1, 1).
+		CreateAuditOnDiskStateMachine(1, 1)
+	_, err := sm.Open(nil)
+	assert.Nil(t, err)
+
+	_, err = sm.Lookup(AUDIT_QUERY_LAST)
+	assert.Equal(t, datastore.ErrRecordNotFound, err)
+
+	// Only entries that extend the head of the chain are appended
+	results, err := sm.Update([]statemachine.Entry{
+		createAuditEntry(t, 1, 2, "hash1"),
+		createAuditEntry(t, 2, 1, ""),
+		createAuditEntry(t, 3, 2, "hash1"),
+		createAuditEntry(t, 4, 2, "hash1"),
+		createAuditEntry(t, 5, 3, "forged")})
+	assert.Nil(t, err)
+	values := make([]uint64, 0, len(results))
+	for _, result := range results {
+		values = append(values, result.Result.Value)
+	}
+	assert.Equal(t, []uint64{0, 1, 2, 0, 0}, values)
+
+	entries := make([]statemachine.Entry, 0)
+	for id := uint64(3); id <= 12; id++ {
+		entries = append(entries, createAuditEntry(t, id+3, id, fmt.Sprintf("hash%d", id-1)))
+	}
+	_, err = sm.Update(entries)
+	assert.Nil(t, err)
+
+	result, err := sm.Lookup(AUDIT_QUERY_LAST)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(12), result.(*entity.AuditEntry).ID)
+	count, err := sm.Lookup(query.QUERY_TYPE_COUNT)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), count)
+
+	page := func(pageQuery query.PageQuery) ([]uint64, bool) {
+		data, err := json.Marshal(pageQuery)
+		assert.Nil(t, err)
+		result, err := sm.Lookup(data)
+		assert.Nil(t, err)
+		pageResult := result.(dao.PageResult[*entity.AuditEntry])
+		ids := make([]uint64, 0)
+		for _, entry := range pageResult.Entities {
+			ids = append(ids, entry.ID)
+		}
+		return ids, pageResult.HasMore
+	}
+	ids, hasMore := page(query.PageQuery{Page: 2, PageSize: 5})
+	assert.Equal(t, []uint64{6, 7, 8, 9, 10}, ids)
+	assert.True(t, hasMore)
+	ids, hasMore = page(query.PageQuery{Page: 3, PageSize: 5})
+	assert.Equal(t, []uint64{11, 12}, ids)
+	assert.False(t, hasMore)
+	ids, hasMore = page(query.PageQuery{Page: 2, PageSize: 5, SortOrder: query.SORT_DESCENDING})
+	assert.Equal(t, []uint64{7, 6, 5, 4, 3}, ids)
+	assert.True(t, hasMore)
+	ids, hasMore = page(query.PageQuery{Page: 4, PageSize: 5, SortOrder: query.SORT_DESCENDING})
+	assert.Empty(t, ids)
+	assert.False(t, hasMore)
+
+	// The head of the chain survives a restart
+	assert.Nil(t, sm.Close())
+	sm = NewAuditOnDiskStateMachine(createLogger(),
+		util.NewIdGenerator(common.DATASTORE_TYPE_64BIT), dir, 1, 1).
+		CreateAuditOnDiskStateMachine(1, 1)
+	_, err = sm.Open(nil)
+	assert.Nil(t, err)
+	defer sm.Close()
+	results, err = sm.Update([]statemachine.Entry{
+		createAuditEntry(t, 16, 12, "hash11"),
+		createAuditEntry(t, 17, 13, "hash12")})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), results[0].Result.Value)
+	assert.Equal(t, uint64(13), results[1].Result.Value)
+}
@@ -0,0 +1,503 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package statemachine
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	sm "github.com/lni/dragonboat/v3/statemachine"
+	logging "github.com/op/go-logging"
+)
+
+const (
+	INBOX_QUERY_PAGE = iota
+	INBOX_QUERY_SINCE
+	INBOX_QUERY_UNREAD
+
+	inboxItemPrefix   = 'i'
+	inboxUserPrefix   = 'u'
+	inboxCountPrefix  = 'c'
+	inboxUnreadPrefix = 'r'
+)
+
+var inboxSequenceKey = []byte("sequence")
+
+// InboxQuery queries a single user's inbox using the user index
+type InboxQuery struct {
+	Type           int
+	UserID         uint64
+	NotificationID uint64
+	PageQuery      query.PageQuery
+}
+
+type InboxOnDiskStateMachine interface {
+	CreateInboxOnDiskStateMachine(clusterID, nodeID uint64) sm.IOnDiskStateMachine
+}
+
+// InboxDiskKV stores the notification inbox of every user. New items are
+// assigned the next ID in the state machine's sequence as they are applied,
+// so every node orders a user's inbox the same way no matter which node
+// created the item. Items are indexed by user and only the most recent
+// retention items are kept for each user.
+//
+// Keys:
+//
+//	i | item id           -> item
+//	u | user id | item id -> nil
+//	c | user id           -> number of items
+//	r | user id           -> number of unread items
+//	sequence              -> last assigned item id
+type InboxDiskKV struct {
+	GenericDiskKV GenericDiskKV[*entity.InboxItem]
+	retention     int
+	sequence      uint64
+	InboxOnDiskStateMachine
+}
+
+func NewInboxOnDiskStateMachine(logger *logging.Logger, idGenerator util.IdGenerator,
+	dbPath string, clusterID, nodeID uint64, retention int) InboxOnDiskStateMachine {
+
+	return &InboxDiskKV{
+		GenericDiskKV: GenericDiskKV[*entity.InboxItem]{
+			logger:      logger,
+			idGenerator: idGenerator,
+			diskKV: DiskKV[*entity.InboxItem]{
+				idGenerator: idGenerator,
+				dbPath:      dbPath,
+				clusterID:   clusterID,
+				nodeID:      nodeID}},
+		retention: retention}
+}
+
+func (d *InboxDiskKV) CreateInboxOnDiskStateMachine(clusterID, nodeID uint64) sm.IOnDiskStateMachine {
+	d.GenericDiskKV.idGenerator = util.NewIdGenerator(common.DATASTORE_TYPE_64BIT)
+	d.GenericDiskKV.diskKV.clusterID = clusterID
+	d.GenericDiskKV.diskKV.nodeID = nodeID
+	return d
+}
+
+func (d *InboxDiskKV) Open(stopc <-chan struct{}) (uint64, error) {
+	appliedIndex, err := d.GenericDiskKV.Open(stopc)
+	if err != nil {
+		return 0, err
+	}
+	return appliedIndex, d.loadSequence()
+}
+
+func (d *InboxDiskKV) Close() error {
+	return d.GenericDiskKV.Close()
+}
+
+func (d *InboxDiskKV) Sync() error {
+	return d.GenericDiskKV.Sync()
+}
+
+func (d *InboxDiskKV) PrepareSnapshot() (interface{}, error) {
+	return d.GenericDiskKV.PrepareSnapshot()
+}
+
+func (d *InboxDiskKV) SaveSnapshot(ctx interface{}, w io.Writer, done <-chan struct{}) error {
+	return d.GenericDiskKV.SaveSnapshot(ctx, w, done)
+}
+
+func (d *InboxDiskKV) RecoverFromSnapshot(r io.Reader, done <-chan struct{}) error {
+	if err := d.GenericDiskKV.RecoverFromSnapshot(r, done); err != nil {
+		return err
+	}
+	return d.loadSequence()
+}
+
+// Applies the save and delete proposals in a single batch. Saved items
+// without an ID are assigned the next ID in the sequence, which is
+// returned as the entry result.
+func (d *InboxDiskKV) Update(ents []sm.Entry) ([]sm.Entry, error) {
+	diskKV := &d.GenericDiskKV.diskKV
+	if diskKV.aborted {
+		panic("update() called after abort set to true")
+	}
+	if diskKV.closed {
+		panic("update called after Close()")
+	}
+	db := (*pebbledb)(atomic.LoadPointer(&diskKV.db))
+	batch := db.db.NewIndexedBatch()
+	defer batch.Close()
+	for idx, e := range ents {
+		var proposal Proposal
+		if err := json.Unmarshal(e.Cmd, &proposal); err != nil {
+			d.GenericDiskKV.logger.Errorf("[InboxDiskKV.Update] Error: %s", err)
+			return nil, err
+		}
+		var item entity.InboxItem
+		if err := json.Unmarshal(proposal.Data, &item); err != nil {
+			d.GenericDiskKV.logger.Errorf("[InboxDiskKV.Update] Error: %s", err)
+			return nil, err
+		}
+		var err error
+		if proposal.Query == QUERY_TYPE_DELETE {
+			err = d.delete(batch, item.ID)
+		} else {
+			err = d.save(batch, &item)
+		}
+		if err != nil {
+			d.GenericDiskKV.logger.Errorf("[InboxDiskKV.Update] Error: %s", err)
+			return nil, err
+		}
+		ents[idx].Result = sm.Result{Value: item.ID}
+	}
+	if err := batch.Set(inboxSequenceKey, encodeUint64(d.sequence), db.wo); err != nil {
+		return nil, err
+	}
+	appliedIndex := make([]byte, 8)
+	binary.LittleEndian.PutUint64(appliedIndex, ents[len(ents)-1].Index)
+	if err := batch.Set([]byte(appliedIndexKey), appliedIndex, db.wo); err != nil {
+		return nil, err
+	}
+	if err := db.db.Apply(batch, db.syncwo); err != nil {
+		return nil, err
+	}
+	if diskKV.lastApplied >= ents[len(ents)-1].Index {
+		panic("lastApplied not moving forward")
+	}
+	diskKV.lastApplied = ents[len(ents)-1].Index
+	return ents, nil
+}
+
+func (d *InboxDiskKV) Lookup(key interface{}) (interface{}, error) {
+	db := (*pebbledb)(atomic.LoadPointer(&d.GenericDiskKV.diskKV.db))
+	if db == nil {
+		return nil, datastore.ErrDataStoreClosed
+	}
+	switch q := key.(type) {
+
+	case uint64: // get by id
+		item, err := d.get(db.db, q)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			return nil, datastore.ErrRecordNotFound
+		}
+		return item, nil
+
+	case InboxQuery:
+		switch q.Type {
+		case INBOX_QUERY_PAGE: // newest first
+			pageQuery := q.PageQuery
+			pageQuery.SortOrder = query.SORT_DESCENDING
+			return d.getPage(db.db, inboxUserPrefixKey(q.UserID), pageQuery)
+		case INBOX_QUERY_SINCE:
+			return d.getSince(db.db, q.UserID, q.NotificationID)
+		case INBOX_QUERY_UNREAD:
+			unread, err := readUint64(db.db, inboxUserCounterKey(inboxUnreadPrefix, q.UserID))
+			return int64(unread), err
+		}
+		d.GenericDiskKV.logger.Errorf("Unsupported inbox query type: %d", q.Type)
+		return nil, ErrUnsupportedQuery
+
+	case []uint8: // json serialized page query
+		var pageQuery query.PageQuery
+		if err := json.Unmarshal(q, &pageQuery); err != nil {
+			d.GenericDiskKV.logger.Error(err)
+			return nil, err
+		}
+		return d.getPage(db.db, []byte{inboxItemPrefix}, pageQuery)
+
+	case int:
+		if q == query.QUERY_TYPE_COUNT {
+			return d.Count(), nil
+		}
+		d.GenericDiskKV.logger.Errorf("Unsupported int type query: %d", q)
+		return nil, ErrUnsupportedQuery
+
+	default:
+		d.GenericDiskKV.logger.Error(fmt.Sprintf("Unsupported key type: %T", q))
+		return nil, ErrUnsupportedQuery
+	}
+}
+
+// Returns the number of items in every user's inbox
+func (d *InboxDiskKV) Count() int64 {
+	db := (*pebbledb)(atomic.LoadPointer(&d.GenericDiskKV.diskKV.db))
+	prefix := []byte{inboxItemPrefix}
+	iter := db.db.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixUpperBound(prefix)})
+	defer iter.Close()
+	count := int64(0)
+	for iter.First(); iter.Valid(); iter.Next() {
+		count++
+	}
+	return count
+}
+
+// Saves the item, replacing the stored copy if it already exists. New
+// items beyond the user's retention limit evict the user's oldest item.
+func (d *InboxDiskKV) save(batch *pebble.Batch, item *entity.InboxItem) error {
+	if item.ID == 0 {
+		d.sequence++
+		item.ID = d.sequence
+	} else if item.ID > d.sequence {
+		d.sequence = item.ID
+	}
+	existing, err := d.get(batch, item.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if err := d.delete(batch, existing.ID); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if err := batch.Set(inboxItemKey(item.ID), data, nil); err != nil {
+		return err
+	}
+	if err := batch.Set(inboxUserKey(item.UserID, item.ID), nil, nil); err != nil {
+		return err
+	}
+	count, err := addUint64(batch, inboxUserCounterKey(inboxCountPrefix, item.UserID), 1)
+	if err != nil {
+		return err
+	}
+	if !item.IsRead() {
+		if _, err := addUint64(batch, inboxUserCounterKey(inboxUnreadPrefix, item.UserID), 1); err != nil {
+			return err
+		}
+	}
+	if existing != nil || d.retention <= 0 || count <= uint64(d.retention) {
+		return nil
+	}
+	prefix := inboxUserPrefixKey(item.UserID)
+	iter := batch.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixUpperBound(prefix)})
+	if !iter.First() {
+		iter.Close()
+		return nil
+	}
+	oldest := binary.BigEndian.Uint64(iter.Key()[9:])
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	return d.delete(batch, oldest)
+}
+
+// Deletes the item and its user index entry
+func (d *InboxDiskKV) delete(batch *pebble.Batch, id uint64) error {
+	item, err := d.get(batch, id)
+	if err != nil || item == nil {
+		return err
+	}
+	if err := batch.Delete(inboxItemKey(id), nil); err != nil {
+		return err
+	}
+	if err := batch.Delete(inboxUserKey(item.UserID, id), nil); err != nil {
+		return err
+	}
+	if _, err := addUint64(batch, inboxUserCounterKey(inboxCountPrefix, item.UserID), -1); err != nil {
+		return err
+	}
+	if !item.IsRead() {
+		if _, err := addUint64(batch, inboxUserCounterKey(inboxUnreadPrefix, item.UserID), -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns a page of the items with the given key prefix
+func (d *InboxDiskKV) getPage(reader pebble.Reader, prefix []byte,
+	pageQuery query.PageQuery) (dao.PageResult[*entity.InboxItem], error) {
+
+	pageResult := dao.PageResult[*entity.InboxItem]{
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize}
+	ids, hasMore := d.scanIds(reader, prefix, pageQuery)
+	items, err := d.getAll(reader, ids)
+	if err != nil {
+		return pageResult, err
+	}
+	pageResult.Entities = items
+	pageResult.HasMore = hasMore
+	return pageResult, nil
+}
+
+// Returns the user's items that were applied after the item for the given
+// notification, oldest first. All of the user's items are returned when
+// the notification ID is zero or its item is no longer in the inbox.
+func (d *InboxDiskKV) getSince(reader pebble.Reader, userID,
+	notificationID uint64) ([]*entity.InboxItem, error) {
+
+	prefix := inboxUserPrefixKey(userID)
+	iter := reader.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixUpperBound(prefix)})
+	defer iter.Close()
+	items := make([]*entity.InboxItem, 0)
+	for iter.First(); iter.Valid(); iter.Next() {
+		item, err := d.get(reader, binary.BigEndian.Uint64(iter.Key()[9:]))
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			continue
+		}
+		if notificationID != 0 && item.NotificationID == notificationID {
+			items = items[:0]
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Returns the item IDs in the page, and whether more pages are available
+func (d *InboxDiskKV) scanIds(reader pebble.Reader, prefix []byte,
+	pageQuery query.PageQuery) ([]uint64, bool) {
+
+	page := pageQuery.Page
+	if page < 1 {
+		page = 1
+	}
+	offset := (page - 1) * pageQuery.PageSize
+	iter := reader.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixUpperBound(prefix)})
+	defer iter.Close()
+	first, next := iter.First, iter.Next
+	if pageQuery.SortOrder == query.SORT_DESCENDING {
+		first, next = iter.Last, iter.Prev
+	}
+	ids := make([]uint64, 0, pageQuery.PageSize)
+	i := 0
+	for valid := first(); valid; valid = next() {
+		if i < offset {
+			i++
+			continue
+		}
+		if len(ids) == pageQuery.PageSize {
+			return ids, true
+		}
+		key := iter.Key()
+		ids = append(ids, binary.BigEndian.Uint64(key[len(key)-8:]))
+	}
+	return ids, false
+}
+
+func (d *InboxDiskKV) getAll(reader pebble.Reader, ids []uint64) ([]*entity.InboxItem, error) {
+	items := make([]*entity.InboxItem, 0, len(ids))
+	for _, id := range ids {
+		item, err := d.get(reader, id)
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// Returns the item with the given ID, or nil if it doesn't exist
+func (d *InboxDiskKV) get(reader pebble.Reader, id uint64) (*entity.InboxItem, error) {
+	data, closer, err := reader.Get(inboxItemKey(id))
+	if err == pebble.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	var item entity.InboxItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (d *InboxDiskKV) loadSequence() error {
+	db := (*pebbledb)(atomic.LoadPointer(&d.GenericDiskKV.diskKV.db))
+	sequence, err := readUint64(db.db, inboxSequenceKey)
+	if err != nil {
+		return err
+	}
+	d.sequence = sequence
+	return nil
+}
+
+func inboxItemKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte{inboxItemPrefix}, id)
+}
+
+func inboxUserPrefixKey(userID uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte{inboxUserPrefix}, userID)
+}
+
+func inboxUserKey(userID, id uint64) []byte {
+	return binary.BigEndian.AppendUint64(inboxUserPrefixKey(userID), id)
+}
+
+func inboxUserCounterKey(prefix byte, userID uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte{prefix}, userID)
+}
+
+// Returns the smallest key greater than every key with the given prefix
+func prefixUpperBound(prefix []byte) []byte {
+	upperBound := make([]byte, len(prefix))
+	copy(upperBound, prefix)
+	for i := len(upperBound) - 1; i >= 0; i-- {
+		upperBound[i]++
+		if upperBound[i] != 0 {
+			return upperBound[:i+1]
+		}
+	}
+	return nil
+}
+
+func encodeUint64(value uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, value)
+}
+
+func readUint64(reader pebble.Reader, key []byte) (uint64, error) {
+	data, closer, err := reader.Get(key)
+	if err == pebble.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer closer.Close()
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// Adds delta to the counter stored at key and returns the new value
+func addUint64(batch *pebble.Batch, key []byte, delta int64) (uint64, error) {
+	value, err := readUint64(batch, key)
+	if err != nil {
+		return 0, err
+	}
+	if delta < 0 && uint64(-delta) > value {
+		value = 0
+	} else {
+		value = uint64(int64(value) + delta)
+	}
+	if value == 0 {
+		return 0, batch.Delete(key, nil)
+	}
+	return value, batch.Set(key, encodeUint64(value), nil)
+}
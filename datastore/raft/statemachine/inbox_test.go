@@ -0,0 +1,117 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package statemachine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	"github.com/lni/dragonboat/v3/statemachine"
+	"github.com/stretchr/testify/assert"
+)
+
+func createInboxEntry(t *testing.T, index uint64, queryType int, item *entity.InboxItem) statemachine.Entry {
+	data, err := json.Marshal(item)
+	assert.Nil(t, err)
+	cmd, err := CreateProposal(queryType, data).Serialize()
+	assert.Nil(t, err)
+	return statemachine.Entry{Index: index, Cmd: cmd}
+}
+
+func TestInboxStateMachine(t *testing.T) {
+
+	dir := t.TempDir()
+	sm := NewInboxOnDiskStateMachine(createLogger(),
+		util.NewIdGenerator(common.DATASTORE_TYPE_64BIT), dir, 1, 1, 3).
+		CreateInboxOnDiskStateMachine(1, 1)
+	_, err := sm.Open(nil)
+	assert.Nil(t, err)
+
+	// Items are assigned sequential IDs in the order they are applied,
+	// regardless of the notification ID assigned by the proposing node
+	notificationIDs := []uint64{500, 200, 300, 100}
+	entries := make([]statemachine.Entry, 0)
+	for i, notificationID := range notificationIDs {
+		entries = append(entries, createInboxEntry(t, uint64(i+1), QUERY_TYPE_UPDATE,
+			&entity.InboxItem{UserID: 1, NotificationID: notificationID}))
+	}
+	entries = append(entries, createInboxEntry(t, 5, QUERY_TYPE_UPDATE,
+		&entity.InboxItem{UserID: 2, NotificationID: 100}))
+	results, err := sm.Update(entries)
+	assert.Nil(t, err)
+	for i, result := range results {
+		assert.Equal(t, uint64(i+1), result.Result.Value)
+	}
+
+	// Only the 3 most recent items are retained for user 1
+	_, err = sm.Lookup(uint64(1))
+	assert.Equal(t, datastore.ErrRecordNotFound, err)
+	count, err := sm.Lookup(query.QUERY_TYPE_COUNT)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), count)
+
+	pageQuery := query.NewPageQuery()
+	pageQuery.PageSize = 2
+	result, err := sm.Lookup(InboxQuery{Type: INBOX_QUERY_PAGE, UserID: 1, PageQuery: pageQuery})
+	assert.Nil(t, err)
+	page := result.(dao.PageResult[*entity.InboxItem])
+	assert.True(t, page.HasMore)
+	assert.Equal(t, 2, len(page.Entities))
+	assert.Equal(t, uint64(100), page.Entities[0].NotificationID)
+	assert.Equal(t, uint64(300), page.Entities[1].NotificationID)
+
+	// The cursor replays the items applied after the cursor's item
+	result, err = sm.Lookup(InboxQuery{Type: INBOX_QUERY_SINCE, UserID: 1, NotificationID: 200})
+	assert.Nil(t, err)
+	since := result.([]*entity.InboxItem)
+	assert.Equal(t, 2, len(since))
+	assert.Equal(t, uint64(300), since[0].NotificationID)
+	assert.Equal(t, uint64(100), since[1].NotificationID)
+
+	// and everything still in the inbox once the cursor's item is evicted
+	result, err = sm.Lookup(InboxQuery{Type: INBOX_QUERY_SINCE, UserID: 1, NotificationID: 500})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(result.([]*entity.InboxItem)))
+
+	result, err = sm.Lookup(InboxQuery{Type: INBOX_QUERY_UNREAD, UserID: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), result)
+
+	// Updating an item keeps its ID and index entry
+	item := since[0]
+	item.Read = true
+	_, err = sm.Update([]statemachine.Entry{
+		createInboxEntry(t, 6, QUERY_TYPE_UPDATE, item),
+		createInboxEntry(t, 7, QUERY_TYPE_DELETE, &entity.InboxItem{ID: 5})})
+	assert.Nil(t, err)
+
+	result, err = sm.Lookup(InboxQuery{Type: INBOX_QUERY_UNREAD, UserID: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result)
+	result, err = sm.Lookup(item.ID)
+	assert.Nil(t, err)
+	assert.True(t, result.(*entity.InboxItem).IsRead())
+	result, err = sm.Lookup(InboxQuery{Type: INBOX_QUERY_PAGE, UserID: 2, PageQuery: pageQuery})
+	assert.Nil(t, err)
+	assert.Empty(t, result.(dao.PageResult[*entity.InboxItem]).Entities)
+
+	// The sequence survives a restart
+	assert.Nil(t, sm.Close())
+	sm = NewInboxOnDiskStateMachine(createLogger(),
+		util.NewIdGenerator(common.DATASTORE_TYPE_64BIT), dir, 1, 1, 3).
+		CreateInboxOnDiskStateMachine(1, 1)
+	_, err = sm.Open(nil)
+	assert.Nil(t, err)
+	defer sm.Close()
+	results, err = sm.Update([]statemachine.Entry{
+		createInboxEntry(t, 8, QUERY_TYPE_UPDATE, &entity.InboxItem{UserID: 2, NotificationID: 600})})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(6), results[0].Result.Value)
+}
@@ -17,4 +17,5 @@ const (
 var (
 	ErrUnsupportedQuery = errors.New("unsupported raft query")
 	ErrNullDataProposal = errors.New("null raft proposal data")
+	ErrReadOnlyReplica  = errors.New("read-only raft replica")
 )
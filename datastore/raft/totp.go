@@ -0,0 +1,86 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+type RaftTOTPDAO interface {
+	RaftDAO[*entity.TOTP]
+	dao.TOTPDAO
+	ClusterID() uint64
+}
+
+type RaftTOTP struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.TOTPDAO
+	GenericRaftDAO[*entity.TOTP]
+}
+
+func NewRaftTOTPDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftTOTPDAO {
+
+	totpClusterID := raftNode.GetParams().
+		IdGenerator.CreateTOTPClusterID(clusterID)
+
+	return &RaftTOTP{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.TOTP]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: totpClusterID,
+		}}
+}
+
+func (dao *RaftTOTP) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+func (dao *RaftTOTP) StartClusterNode(waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartClusterNode(waitForClusterReady)
+}
+
+func (dao *RaftTOTP) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartLocalCluster(localCluster, waitForClusterReady)
+}
+
+func (dao *RaftTOTP) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+func (dao *RaftTOTP) Save(totp *entity.TOTP) error {
+	return dao.GenericRaftDAO.Save(totp)
+}
+
+func (dao *RaftTOTP) Update(totp *entity.TOTP) error {
+	return dao.GenericRaftDAO.Update(totp)
+}
+
+func (dao *RaftTOTP) Delete(totp *entity.TOTP) error {
+	return dao.GenericRaftDAO.Delete(totp)
+}
+
+func (dao *RaftTOTP) Get(userID uint64, CONSISTENCY_LEVEL int) (*entity.TOTP, error) {
+	return dao.GenericRaftDAO.Get(userID, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftTOTP) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.TOTP], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftTOTP) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.TOTP], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftTOTP) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
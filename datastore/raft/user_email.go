@@ -0,0 +1,86 @@
+//go:build cluster && pebble
+// +build cluster,pebble
+
+package raft
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+type RaftUserEmailDAO interface {
+	RaftDAO[*entity.UserEmail]
+	dao.UserEmailDAO
+	ClusterID() uint64
+}
+
+type RaftUserEmail struct {
+	logger *logging.Logger
+	raft   cluster.RaftNode
+	dao.UserEmailDAO
+	GenericRaftDAO[*entity.UserEmail]
+}
+
+func NewRaftUserEmailDAO(logger *logging.Logger, raftNode cluster.RaftNode, clusterID uint64) RaftUserEmailDAO {
+
+	userEmailClusterID := raftNode.GetParams().
+		IdGenerator.CreateUserEmailClusterID(clusterID)
+
+	return &RaftUserEmail{
+		logger: logger,
+		raft:   raftNode,
+		GenericRaftDAO: GenericRaftDAO[*entity.UserEmail]{
+			logger:    logger,
+			raft:      raftNode,
+			clusterID: userEmailClusterID,
+		}}
+}
+
+func (dao *RaftUserEmail) ClusterID() uint64 {
+	return dao.GenericRaftDAO.clusterID
+}
+
+func (dao *RaftUserEmail) StartClusterNode(waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartClusterNode(waitForClusterReady)
+}
+
+func (dao *RaftUserEmail) StartLocalCluster(localCluster *LocalCluster, waitForClusterReady bool) error {
+	return dao.GenericRaftDAO.StartLocalCluster(localCluster, waitForClusterReady)
+}
+
+func (dao *RaftUserEmail) WaitForClusterReady() {
+	dao.GenericRaftDAO.WaitForClusterReady()
+}
+
+func (dao *RaftUserEmail) Save(userEmail *entity.UserEmail) error {
+	return dao.GenericRaftDAO.Save(userEmail)
+}
+
+func (dao *RaftUserEmail) Update(userEmail *entity.UserEmail) error {
+	return dao.GenericRaftDAO.Update(userEmail)
+}
+
+func (dao *RaftUserEmail) Delete(userEmail *entity.UserEmail) error {
+	return dao.GenericRaftDAO.Delete(userEmail)
+}
+
+func (dao *RaftUserEmail) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.UserEmail, error) {
+	return dao.GenericRaftDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftUserEmail) GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.UserEmail], error) {
+	return dao.GenericRaftDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftUserEmail) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.UserEmail], CONSISTENCY_LEVEL int) error {
+
+	return dao.GenericRaftDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+}
+
+func (dao *RaftUserEmail) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return dao.GenericRaftDAO.Count(CONSISTENCY_LEVEL)
+}
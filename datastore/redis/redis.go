@@ -1,71 +1,371 @@
 package redis
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/gomodule/redigo/redis"
 	"github.com/jeremyhahn/go-cropdroid/datastore"
 	"github.com/jeremyhahn/go-cropdroid/state"
+	logging "github.com/op/go-logging"
 
 	redistimeseries "github.com/RedisTimeSeries/redistimeseries-go"
 )
 
+var (
+	ErrInvalidCACert = errors.New("failed to parse redis CA certificate")
+)
+
+const (
+	LABEL_DEVICE = "device"
+	LABEL_KEY    = "key"
+	LABEL_TYPE   = "type"
+	LABEL_BUCKET = "bucket"
+
+	SERIES_TYPE_METRIC  = "metric"
+	SERIES_TYPE_CHANNEL = "channel"
+
+	// Metric keys start with the device ID, so channel and downsampled
+	// series keys are prefixed with a word to keep them out of the
+	// metric key space regardless of how metrics are named
+	KEY_PREFIX_CHANNEL    = "channel"
+	KEY_PREFIX_DOWNSAMPLE = "downsample"
+)
+
+// The aggregations that are continuously downsampled into
+// their own series using compaction rules
+var downsampleAggregations = map[string]redistimeseries.AggregationType{
+	datastore.AGGREGATION_MIN: redistimeseries.MinAggregation,
+	datastore.AGGREGATION_MAX: redistimeseries.MaxAggregation,
+	datastore.AGGREGATION_AVG: redistimeseries.AvgAggregation}
+
+var aggregations = map[string]redistimeseries.AggregationType{
+	datastore.AGGREGATION_MIN:   redistimeseries.MinAggregation,
+	datastore.AGGREGATION_MAX:   redistimeseries.MaxAggregation,
+	datastore.AGGREGATION_AVG:   redistimeseries.AvgAggregation,
+	datastore.AGGREGATION_SUM:   redistimeseries.SumAggregation,
+	datastore.AGGREGATION_COUNT: redistimeseries.CountAggregation,
+	datastore.AGGREGATION_FIRST: redistimeseries.FirstAggregation,
+	datastore.AGGREGATION_LAST:  redistimeseries.LastAggregation}
+
+type RedisInitParams struct {
+	Address       string
+	Password      string
+	Database      int
+	TLS           bool
+	TLSSkipVerify bool
+	CACert        string
+	// How long raw samples are kept. 0 = keep forever
+	Retention time.Duration
+	// How long downsampled min/max/avg samples are kept. 0 = keep forever
+	DownsampleRetention time.Duration
+	// The time bucket used to downsample raw samples
+	DownsampleBucket time.Duration
+}
+
 type RedisClient struct {
-	client *redistimeseries.Client
-	datastore.DeviceDataStore
+	logger  *logging.Logger
+	params  *RedisInitParams
+	pool    redistimeseries.ConnPool
+	client  *redistimeseries.Client
+	keys    map[string]bool
+	keysMux *sync.RWMutex
+	datastore.TimeSeriesDataStore
 }
 
-func NewRedisDataStore(address, password string) datastore.DeviceDataStore {
-	/*
-		pool := &redis.Pool{Dial: func() (redis.Conn, error) {
-			return redis.Dial("tcp", address, redis.DialPassword(password))
-		}}*/
+// Creates a new RedisTimeSeries device data store using the address,
+// password, database and TLS settings in the provided params.
+func NewRedisDataStore(logger *logging.Logger, params *RedisInitParams) (datastore.TimeSeriesDataStore, error) {
+	options := []redis.DialOption{
+		redis.DialDatabase(params.Database),
+		redis.DialConnectTimeout(10 * time.Second)}
+	if params.Password != "" {
+		options = append(options, redis.DialPassword(params.Password))
+	}
+	if params.TLS {
+		tlsConfig := &tls.Config{InsecureSkipVerify: params.TLSSkipVerify}
+		if params.CACert != "" {
+			pem, err := os.ReadFile(params.CACert)
+			if err != nil {
+				return nil, err
+			}
+			rootCAs := x509.NewCertPool()
+			if !rootCAs.AppendCertsFromPEM(pem) {
+				return nil, ErrInvalidCACert
+			}
+			tlsConfig.RootCAs = rootCAs
+		}
+		options = append(options,
+			redis.DialUseTLS(true),
+			redis.DialTLSConfig(tlsConfig))
+	}
+	pool := &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", params.Address, options...)
+		},
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			if time.Since(t) < time.Minute {
+				return nil
+			}
+			_, err := c.Do("PING")
+			return err
+		}}
+	return NewRedisDataStoreFromPool(logger, params, pool), nil
+}
+
+// Creates a new RedisTimeSeries device data store using an existing
+// connection pool
+func NewRedisDataStoreFromPool(logger *logging.Logger, params *RedisInitParams,
+	pool redistimeseries.ConnPool) datastore.TimeSeriesDataStore {
+
 	return &RedisClient{
-		client: redistimeseries.NewClient(":6379", "", nil)}
+		logger: logger,
+		params: params,
+		pool:   pool,
+		client: &redistimeseries.Client{
+			Pool: pool,
+			Name: "cropdroid"},
+		keys:    make(map[string]bool, 0),
+		keysMux: &sync.RWMutex{}}
 }
 
 func (r *RedisClient) Client() *redistimeseries.Client {
 	return r.client
 }
 
+// Closes the connection pool
+func (r *RedisClient) Close() error {
+	return r.pool.Close()
+}
+
+// Saves all of the device metrics and channels using a single TS.MADD
+// command, creating any missing series and their downsampling rules first.
 func (r *RedisClient) Save(deviceID uint64, deviceState state.DeviceStateMap) error {
-	for k, v := range deviceState.GetMetrics() {
-		_, err := r.client.AddAutoTs(fmt.Sprintf("%d_%s", deviceID, k), v)
-		if err != nil {
+	timestamp := time.Now().UnixMilli()
+	metrics := deviceState.GetMetrics()
+	channels := deviceState.GetChannels()
+	samples := make([]redistimeseries.Sample, 0, len(metrics)+len(channels))
+	for k, v := range metrics {
+		key := r.metricKey(deviceID, k)
+		if err := r.createTable(key, deviceID, k, SERIES_TYPE_METRIC); err != nil {
 			return err
 		}
-		println("Saved redis timestamp record")
+		samples = append(samples, redistimeseries.Sample{
+			Key:       key,
+			DataPoint: redistimeseries.DataPoint{Timestamp: timestamp, Value: v}})
 	}
-	for i, v := range deviceState.GetChannels() {
-		_, err := r.client.AddAutoTs(fmt.Sprintf("%d_c%d", deviceID, i), float64(v))
-		if err != nil {
+	for i, v := range channels {
+		key := r.channelKey(deviceID, i)
+		if err := r.createTable(key, deviceID, fmt.Sprintf("c%d", i), SERIES_TYPE_CHANNEL); err != nil {
 			return err
 		}
-		println("Saved redis timestamp record")
+		samples = append(samples, redistimeseries.Sample{
+			Key:       key,
+			DataPoint: redistimeseries.DataPoint{Timestamp: timestamp, Value: float64(v)}})
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	replies, err := r.client.MultiAdd(samples...)
+	if err != nil {
+		return err
+	}
+	// TS.MADD returns an error per sample instead of failing the command
+	for i, reply := range replies {
+		if err, ok := reply.(redis.Error); ok {
+			return fmt.Errorf("%s: %w", samples[i].Key, err)
+		}
 	}
-	//r.client.MultiAdd(key, data, CreateOptions{RetentionMSecs: 0})
-	//values, err = r.tsclient.MultiAdd(Sample{Key: "test:MultiAdd", DataPoint: DataPoint{Timestamp: currentTimestamp + 3, Value: 14}},
-	//		Sample{Key: "test:MultiAdd:notExit", DataPoint: DataPoint{Timestamp: currentTimestamp + 4, Value: 14}})
+	r.logger.Debugf("Saved %d redis samples for device %d", len(samples), deviceID)
 	return nil
 }
 
+// Returns the raw values for the metric over the last month
 func (r *RedisClient) GetLast30Days(deviceID uint64, metric string) ([]float64, error) {
-	oneMonthAgo := time.Now().AddDate(0, -1, 0).Unix()
-	now := time.Now().Unix()
-	datapoints, err := r.client.Range(fmt.Sprintf("%d_%s", deviceID, metric), oneMonthAgo, now)
+	now := time.Now()
+	datapoints, err := r.GetRange(deviceID, metric, now.AddDate(0, -1, 0), now)
+	if err != nil {
+		return nil, err
+	}
 	floats := make([]float64, len(datapoints))
 	for i, datapoint := range datapoints {
 		floats[i] = datapoint.Value
 	}
-	return floats, err
+	return floats, nil
+}
+
+// Returns the raw samples for the metric between start and end
+func (r *RedisClient) GetRange(deviceID uint64, metric string, start, end time.Time) ([]datastore.DataPoint, error) {
+	datapoints, err := r.client.RangeWithOptions(r.metricKey(deviceID, metric),
+		start.UnixMilli(), end.UnixMilli(), redistimeseries.DefaultRangeOptions)
+	if err != nil {
+		return nil, err
+	}
+	return r.parseDataPoints(datapoints), nil
+}
+
+// Returns the metric samples between start and end aggregated into time buckets. Min, max
+// and avg queries using the downsample bucket are served from the downsampled series so
+// they remain available after the raw samples expire.
+func (r *RedisClient) GetAggregate(deviceID uint64, metric string, start, end time.Time,
+	aggregation string, bucket time.Duration) ([]datastore.DataPoint, error) {
+
+	aggType, ok := aggregations[aggregation]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", datastore.ErrInvalidAggregation, aggregation)
+	}
+	if bucket < time.Millisecond {
+		return nil, datastore.ErrInvalidTimeBucket
+	}
+	key := r.metricKey(deviceID, metric)
+	if _, ok := downsampleAggregations[aggregation]; ok && bucket == r.params.DownsampleBucket {
+		datapoints, err := r.client.RangeWithOptions(r.downsampleKey(key, aggregation),
+			start.UnixMilli(), end.UnixMilli(), redistimeseries.DefaultRangeOptions)
+		if err != nil {
+			return nil, err
+		}
+		return r.parseDataPoints(datapoints), nil
+	}
+	options := redistimeseries.NewRangeOptions()
+	options.SetAggregation(aggType, int(bucket.Milliseconds()))
+	datapoints, err := r.client.RangeWithOptions(key, start.UnixMilli(), end.UnixMilli(), *options)
+	if err != nil {
+		return nil, err
+	}
+	return r.parseDataPoints(datapoints), nil
+}
+
+// Returns the most recent sample for the metric
+func (r *RedisClient) GetLatest(deviceID uint64, metric string) (*datastore.DataPoint, error) {
+	datapoint, err := r.client.Get(r.metricKey(deviceID, metric))
+	if err != nil {
+		return nil, err
+	}
+	if datapoint == nil {
+		return nil, datastore.ErrRecordNotFound
+	}
+	return &datastore.DataPoint{
+		Timestamp: time.UnixMilli(datapoint.Timestamp),
+		Value:     datapoint.Value}, nil
+}
+
+// Creates the series for a device metric or channel with its labels and
+// retention policy, along with the downsampled min, max and avg series
+// and the compaction rules that populate them. Each downsampled series and
+// rule is checked on its own so a partially created set of series is
+// completed on the next save. Keys that have already been created are
+// cached to avoid a round trip on every save.
+func (r *RedisClient) createTable(key string, deviceID uint64, name, seriesType string) error {
+	r.keysMux.RLock()
+	_, ok := r.keys[key]
+	r.keysMux.RUnlock()
+	if ok {
+		return nil
+	}
+
+	r.keysMux.Lock()
+	defer r.keysMux.Unlock()
+	if _, ok := r.keys[key]; ok {
+		return nil
+	}
+
+	labels := map[string]string{
+		LABEL_DEVICE: fmt.Sprintf("%d", deviceID),
+		LABEL_KEY:    name,
+		LABEL_TYPE:   seriesType}
+	exists, err := r.exists(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := r.client.CreateKeyWithOptions(key, redistimeseries.CreateOptions{
+			RetentionMSecs:  r.params.Retention,
+			Labels:          labels,
+			DuplicatePolicy: redistimeseries.LastDuplicatePolicy}); err != nil {
+			return err
+		}
+		r.logger.Debugf("Created redis time series %s", key)
+	}
+	if r.params.DownsampleBucket > 0 {
+		if err := r.createDownsampleRules(key, labels); err != nil {
+			return err
+		}
+	}
+	r.keys[key] = true
+	return nil
+}
+
+// Creates the downsampled min, max and avg series for the key and the
+// compaction rules that populate them, skipping any that already exist
+func (r *RedisClient) createDownsampleRules(key string, labels map[string]string) error {
+	info, err := r.client.Info(key)
+	if err != nil {
+		return err
+	}
+	rules := make(map[string]bool, len(info.Rules))
+	for _, rule := range info.Rules {
+		rules[rule.DestKey] = true
+	}
+	bucket := uint(r.params.DownsampleBucket.Milliseconds())
+	for aggregation, aggType := range downsampleAggregations {
+		downsampleKey := r.downsampleKey(key, aggregation)
+		exists, err := r.exists(downsampleKey)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			downsampleLabels := map[string]string{
+				LABEL_BUCKET: r.params.DownsampleBucket.String()}
+			for k, v := range labels {
+				downsampleLabels[k] = v
+			}
+			if err := r.client.CreateKeyWithOptions(downsampleKey, redistimeseries.CreateOptions{
+				RetentionMSecs: r.params.DownsampleRetention,
+				Labels:         downsampleLabels}); err != nil {
+				return err
+			}
+		}
+		if !rules[downsampleKey] {
+			if err := r.client.CreateRule(key, aggType, bucket, downsampleKey); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RedisClient) exists(key string) (bool, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+	return redis.Bool(conn.Do("EXISTS", key))
+}
+
+func (r *RedisClient) parseDataPoints(datapoints []redistimeseries.DataPoint) []datastore.DataPoint {
+	points := make([]datastore.DataPoint, len(datapoints))
+	for i, datapoint := range datapoints {
+		points[i] = datastore.DataPoint{
+			Timestamp: time.UnixMilli(datapoint.Timestamp),
+			Value:     datapoint.Value}
+	}
+	return points
+}
+
+func (r *RedisClient) metricKey(deviceID uint64, metric string) string {
+	return fmt.Sprintf("%d_%s", deviceID, metric)
+}
+
+func (r *RedisClient) channelKey(deviceID uint64, channelID int) string {
+	return fmt.Sprintf("%s:%d:%d", KEY_PREFIX_CHANNEL, deviceID, channelID)
 }
 
-func (r *RedisClient) createTable(key string, data map[string]float64) error {
-	r.client.CreateKeyWithOptions(key, redistimeseries.DefaultCreateOptions)
-	r.client.CreateKeyWithOptions(key+"_avg", redistimeseries.DefaultCreateOptions)
-	r.client.CreateRule(key, redistimeseries.MinAggregation, 60, key+"_min")
-	r.client.CreateRule(key, redistimeseries.MaxAggregation, 60, key+"_max")
-	r.client.CreateRule(key, redistimeseries.AvgAggregation, 60, key+"_avg")
-	//return r.client.CreateKeyWithOptions(key, redistimeseries.CreateOptions{RetentionMSecs: 0, Labels: data}) // keep forever
-	return r.client.CreateKeyWithOptions(key, redistimeseries.CreateOptions{})
+func (r *RedisClient) downsampleKey(key, aggregation string) string {
+	return fmt.Sprintf("%s:%s:%s", KEY_PREFIX_DOWNSAMPLE, strings.ToLower(aggregation), key)
 }
@@ -0,0 +1,307 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/state"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTimeSeries is a local, in-memory stand-in for a RedisTimeSeries
+// server that implements the subset of commands used by the data store
+type fakeTimeSeries struct {
+	mutex    sync.Mutex
+	series   map[string][][2]interface{}
+	labels   map[string]map[string]string
+	rules    map[string]string
+	commands map[string]int
+	lastKey  string
+}
+
+type fakeConn struct {
+	server *fakeTimeSeries
+}
+
+func newFakeTimeSeries() *fakeTimeSeries {
+	return &fakeTimeSeries{
+		series:   make(map[string][][2]interface{}, 0),
+		labels:   make(map[string]map[string]string, 0),
+		rules:    make(map[string]string, 0),
+		commands: make(map[string]int, 0)}
+}
+
+func (server *fakeTimeSeries) Get() redis.Conn {
+	return &fakeConn{server: server}
+}
+
+func (server *fakeTimeSeries) Close() error {
+	return nil
+}
+
+func (conn *fakeConn) Close() error                               { return nil }
+func (conn *fakeConn) Err() error                                 { return nil }
+func (conn *fakeConn) Send(cmd string, args ...interface{}) error { return nil }
+func (conn *fakeConn) Flush() error                               { return nil }
+func (conn *fakeConn) Receive() (interface{}, error)              { return nil, nil }
+
+func (conn *fakeConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	server := conn.server
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.commands[cmd]++
+	switch cmd {
+	case "EXISTS":
+		if _, ok := server.series[fmt.Sprint(args[0])]; ok {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case "TS.CREATE":
+		key := fmt.Sprint(args[0])
+		if _, ok := server.series[key]; ok {
+			return nil, redis.Error("ERR TSDB: key already exists")
+		}
+		server.series[key] = make([][2]interface{}, 0)
+		labels := make(map[string]string, 0)
+		for i := 1; i < len(args); i++ {
+			if fmt.Sprint(args[i]) == "LABELS" {
+				for j := i + 1; j+1 < len(args); j += 2 {
+					labels[fmt.Sprint(args[j])] = fmt.Sprint(args[j+1])
+				}
+				break
+			}
+		}
+		server.labels[key] = labels
+		return "OK", nil
+	case "TS.CREATERULE":
+		if _, ok := server.rules[fmt.Sprint(args[1])]; ok {
+			return nil, redis.Error("ERR TSDB: the destination key already has a src rule")
+		}
+		server.rules[fmt.Sprint(args[1])] = fmt.Sprint(args[0])
+		return "OK", nil
+	case "TS.INFO":
+		key := fmt.Sprint(args[0])
+		if _, ok := server.series[key]; !ok {
+			return nil, redis.Error("ERR TSDB: the key does not exist")
+		}
+		rules := make([]interface{}, 0)
+		for destKey, sourceKey := range server.rules {
+			if sourceKey == key {
+				rules = append(rules, []interface{}{destKey, int64(0), "AVG"})
+			}
+		}
+		return []interface{}{"rules", rules}, nil
+	case "TS.MADD":
+		replies := make([]interface{}, 0)
+		for i := 0; i+2 < len(args); i += 3 {
+			key := fmt.Sprint(args[i])
+			if _, ok := server.series[key]; !ok {
+				replies = append(replies, redis.Error("ERR TSDB: the key does not exist"))
+				continue
+			}
+			server.series[key] = append(server.series[key],
+				[2]interface{}{args[i+1].(int64), args[i+2].(float64)})
+			replies = append(replies, args[i+1])
+		}
+		return replies, nil
+	case "TS.GET":
+		samples := server.series[fmt.Sprint(args[0])]
+		if len(samples) == 0 {
+			return []interface{}{}, nil
+		}
+		last := samples[len(samples)-1]
+		return []interface{}{last[0], []byte(strconv.FormatFloat(last[1].(float64), 'f', -1, 64))}, nil
+	case "TS.RANGE":
+		key := fmt.Sprint(args[0])
+		server.lastKey = key
+		samples, ok := server.series[key]
+		if !ok {
+			return nil, redis.Error("ERR TSDB: the key does not exist")
+		}
+		from, _ := strconv.ParseInt(fmt.Sprint(args[1]), 10, 64)
+		to, _ := strconv.ParseInt(fmt.Sprint(args[2]), 10, 64)
+		aggregation := ""
+		var bucket int64
+		if len(args) > 5 && fmt.Sprint(args[3]) == "AGGREGATION" {
+			aggregation = fmt.Sprint(args[4])
+			bucket, _ = strconv.ParseInt(fmt.Sprint(args[5]), 10, 64)
+		}
+		replies := make([]interface{}, 0)
+		buckets := make(map[int64][]float64, 0)
+		order := make([]int64, 0)
+		for _, sample := range samples {
+			timestamp := sample[0].(int64)
+			if timestamp < from || timestamp > to {
+				continue
+			}
+			value := sample[1].(float64)
+			if aggregation == "" {
+				replies = append(replies, []interface{}{timestamp,
+					[]byte(strconv.FormatFloat(value, 'f', -1, 64))})
+				continue
+			}
+			start := timestamp - (timestamp % bucket)
+			if _, ok := buckets[start]; !ok {
+				order = append(order, start)
+			}
+			buckets[start] = append(buckets[start], value)
+		}
+		for _, start := range order {
+			values := buckets[start]
+			var result float64
+			switch aggregation {
+			case "AVG":
+				for _, v := range values {
+					result += v
+				}
+				result /= float64(len(values))
+			case "MAX":
+				result = values[0]
+				for _, v := range values {
+					if v > result {
+						result = v
+					}
+				}
+			default:
+				return nil, errors.New("unsupported aggregation")
+			}
+			replies = append(replies, []interface{}{start,
+				[]byte(strconv.FormatFloat(result, 'f', -1, 64))})
+		}
+		return replies, nil
+	}
+	return nil, fmt.Errorf("unsupported command: %s", cmd)
+}
+
+func createTestDataStore() (*fakeTimeSeries, datastore.TimeSeriesDataStore) {
+	server := newFakeTimeSeries()
+	params := &RedisInitParams{
+		Retention:           24 * time.Hour,
+		DownsampleRetention: 30 * 24 * time.Hour,
+		DownsampleBucket:    time.Hour}
+	return server, NewRedisDataStoreFromPool(logging.MustGetLogger("redis_test"), params, server)
+}
+
+func TestRedisSaveCreatesSeries(t *testing.T) {
+	server, store := createTestDataStore()
+
+	deviceState := state.CreateDeviceStateMap(
+		map[string]float64{"tempF0": 70, "humidity0": 50}, []int{1, 0})
+	assert.Nil(t, store.Save(1, deviceState))
+
+	// 4 raw series, each with min, max and avg downsampled series
+	assert.Equal(t, 16, server.commands["TS.CREATE"])
+	assert.Equal(t, 12, server.commands["TS.CREATERULE"])
+	assert.Equal(t, 1, server.commands["TS.MADD"])
+
+	assert.Equal(t, map[string]string{
+		LABEL_DEVICE: "1",
+		LABEL_KEY:    "tempF0",
+		LABEL_TYPE:   SERIES_TYPE_METRIC}, server.labels["1_tempF0"])
+	assert.Equal(t, SERIES_TYPE_CHANNEL, server.labels["channel:1:1"][LABEL_TYPE])
+	assert.Equal(t, "1h0m0s", server.labels["downsample:avg:1_tempF0"][LABEL_BUCKET])
+	assert.Equal(t, "1_tempF0", server.rules["downsample:max:1_tempF0"])
+
+	// Series are only created once
+	time.Sleep(2 * time.Millisecond)
+	assert.Nil(t, store.Save(1, deviceState))
+	assert.Equal(t, 16, server.commands["TS.CREATE"])
+	assert.Equal(t, 2, server.commands["TS.MADD"])
+	assert.Equal(t, 2, len(server.series["1_humidity0"]))
+}
+
+func TestRedisSeriesKeysDontCollide(t *testing.T) {
+	server, store := createTestDataStore()
+
+	// Metrics named like a channel or a downsampled series get their own series
+	deviceState := state.CreateDeviceStateMap(
+		map[string]float64{"c1": 5, "tempF0": 70, "tempF0_avg": 1}, []int{0, 1})
+	assert.Nil(t, store.Save(1, deviceState))
+
+	assert.Equal(t, 1, len(server.series["1_c1"]))
+	assert.Equal(t, SERIES_TYPE_METRIC, server.labels["1_c1"][LABEL_TYPE])
+	assert.Equal(t, 1, len(server.series["channel:1:1"]))
+	assert.Equal(t, SERIES_TYPE_CHANNEL, server.labels["channel:1:1"][LABEL_TYPE])
+	assert.Equal(t, 1, len(server.series["1_tempF0_avg"]))
+	assert.Equal(t, "1_tempF0", server.rules["downsample:avg:1_tempF0"])
+	assert.Equal(t, "1_tempF0_avg", server.rules["downsample:avg:1_tempF0_avg"])
+}
+
+func TestRedisCompletesDownsampling(t *testing.T) {
+	server, store := createTestDataStore()
+
+	// A previous save created the raw series and one of its downsampled
+	// series before failing
+	server.series["1_tempF0"] = make([][2]interface{}, 0)
+	server.series["downsample:min:1_tempF0"] = make([][2]interface{}, 0)
+	server.rules["downsample:min:1_tempF0"] = "1_tempF0"
+	server.series["downsample:max:1_tempF0"] = make([][2]interface{}, 0)
+
+	deviceState := state.CreateDeviceStateMap(map[string]float64{"tempF0": 70}, []int{})
+	assert.Nil(t, store.Save(1, deviceState))
+	assert.Equal(t, 1, server.commands["TS.CREATE"])
+	assert.Equal(t, 2, server.commands["TS.CREATERULE"])
+	for _, aggregation := range []string{"min", "max", "avg"} {
+		assert.Equal(t, "1_tempF0", server.rules["downsample:"+aggregation+":1_tempF0"])
+	}
+
+	// Saving from a new data store, such as after a restart, doesn't
+	// recreate any of the series or rules
+	_, restarted := createTestDataStore()
+	restarted.(*RedisClient).pool = server
+	restarted.(*RedisClient).client.Pool = server
+	assert.Nil(t, restarted.Save(1, deviceState))
+	assert.Equal(t, 1, server.commands["TS.CREATE"])
+	assert.Equal(t, 2, server.commands["TS.CREATERULE"])
+}
+
+func TestRedisHistoryQueries(t *testing.T) {
+	server, store := createTestDataStore()
+
+	for _, value := range []float64{70, 72, 74} {
+		deviceState := state.CreateDeviceStateMap(map[string]float64{"tempF0": value}, []int{})
+		assert.Nil(t, store.Save(1, deviceState))
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	now := time.Now()
+	datapoints, err := store.GetRange(1, "tempF0", now.Add(-time.Minute), now)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(datapoints))
+	assert.Equal(t, 70.0, datapoints[0].Value)
+
+	values, err := store.GetLast30Days(1, "tempF0")
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{70, 72, 74}, values)
+
+	latest, err := store.GetLatest(1, "tempF0")
+	assert.Nil(t, err)
+	assert.Equal(t, 74.0, latest.Value)
+
+	aggregate, err := store.GetAggregate(1, "tempF0", now.Add(-time.Minute), now,
+		datastore.AGGREGATION_MAX, 24*time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(aggregate))
+	assert.Equal(t, 74.0, aggregate[0].Value)
+	assert.Equal(t, "1_tempF0", server.lastKey)
+
+	// Downsample bucket queries are served from the compacted series
+	_, err = store.GetAggregate(1, "tempF0", now.Add(-time.Minute), now,
+		datastore.AGGREGATION_AVG, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "downsample:avg:1_tempF0", server.lastKey)
+
+	_, err = store.GetAggregate(1, "tempF0", now.Add(-time.Minute), now, "median", time.Hour)
+	assert.True(t, errors.Is(err, datastore.ErrInvalidAggregation))
+
+	_, err = store.GetAggregate(1, "tempF0", now.Add(-time.Minute), now,
+		datastore.AGGREGATION_AVG, 0)
+	assert.Equal(t, datastore.ErrInvalidTimeBucket, err)
+}
@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"errors"
+	"time"
 
 	"github.com/jeremyhahn/go-cropdroid/config"
 	"github.com/jeremyhahn/go-cropdroid/state"
@@ -13,11 +14,25 @@ const (
 	REDIS_TS
 )
 
+const (
+	AGGREGATION_MIN   = "min"
+	AGGREGATION_MAX   = "max"
+	AGGREGATION_AVG   = "avg"
+	AGGREGATION_SUM   = "sum"
+	AGGREGATION_COUNT = "count"
+	AGGREGATION_FIRST = "first"
+	AGGREGATION_LAST  = "last"
+)
+
 var (
-	ErrRecordNotFound    = errors.New("record not found")
-	ErrUnexpectedQuery   = errors.New("unexpected query")
-	ErrMetricKeyNotFound = errors.New("metric key not found")
-	ErrNullEntityId      = errors.New("null entity id")
+	ErrRecordNotFound     = errors.New("record not found")
+	ErrUnexpectedQuery    = errors.New("unexpected query")
+	ErrMetricKeyNotFound  = errors.New("metric key not found")
+	ErrNullEntityId       = errors.New("null entity id")
+	ErrInvalidAggregation = errors.New("invalid aggregation")
+	ErrInvalidTimeBucket  = errors.New("invalid time bucket")
+	ErrDataStoreClosed    = errors.New("datastore closed")
+	ErrAuditConflict      = errors.New("audit entry is not the next entry in the audit chain")
 	//ErrOrganizationNotFound = errors.New("organization not found")
 	//ErrOrganizationsNotFound = errors.New("organizations not found")
 )
@@ -31,3 +46,20 @@ type DeviceDataStore interface {
 	Save(deviceID uint64, deviceState state.DeviceStateMap) error
 	GetLast30Days(deviceID uint64, metric string) ([]float64, error)
 }
+
+// TimeSeriesDataStore is a DeviceDataStore that supports ranged
+// and aggregated queries over the device history
+type TimeSeriesDataStore interface {
+	GetRange(deviceID uint64, metric string, start, end time.Time) ([]DataPoint, error)
+	GetAggregate(deviceID uint64, metric string, start, end time.Time,
+		aggregation string, bucket time.Duration) ([]DataPoint, error)
+	GetLatest(deviceID uint64, metric string) (*DataPoint, error)
+	Close() error
+	DeviceDataStore
+}
+
+// DataPoint is a single value in a device metric or channel history
+type DataPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
@@ -19,28 +19,52 @@ func NewMetricMapper() MetricMapper {
 
 func (mapper *DefaultMetricMapper) MapConfigToModel(config *config.MetricStruct) model.Metric {
 	return &model.MetricStruct{
-		ID:        config.Identifier(),
-		DeviceID:  config.GetDeviceID(),
-		DataType:  config.GetDataType(),
-		Name:      config.GetName(),
-		Key:       config.GetKey(),
-		Enable:    config.IsEnabled(),
-		Notify:    config.IsNotify(),
-		Unit:      config.GetUnit(),
-		AlarmLow:  config.GetAlarmLow(),
-		AlarmHigh: config.GetAlarmHigh()}
+		ID:                    config.Identifier(),
+		DeviceID:              config.GetDeviceID(),
+		DataType:              config.GetDataType(),
+		Name:                  config.GetName(),
+		Key:                   config.GetKey(),
+		Enable:                config.IsEnabled(),
+		Notify:                config.IsNotify(),
+		Unit:                  config.GetUnit(),
+		AlarmLow:              config.GetAlarmLow(),
+		AlarmHigh:             config.GetAlarmHigh(),
+		AnomalyWindow:         config.GetAnomalyWindow(),
+		AnomalyZScore:         config.GetAnomalyZScore(),
+		RateOfChange:          config.GetRateOfChange(),
+		RateOfChangeWindow:    config.GetRateOfChangeWindow(),
+		FlatlineWindow:        config.GetFlatlineWindow(),
+		AnomalyBackoff:        config.GetAnomalyBackoff(),
+		Expression:            config.GetExpression(),
+		CalibrationOffset:     config.GetCalibrationOffset(),
+		CalibrationSlope:      config.GetCalibrationSlope(),
+		CalibratedAt:          config.GetCalibratedAt(),
+		CalibrationReferences: config.GetCalibrationReferences(),
+		CalibrationInterval:   config.GetCalibrationInterval()}
 }
 
 func (mapper *DefaultMetricMapper) MapModelToConfig(model model.Metric) *config.MetricStruct {
 	return &config.MetricStruct{
-		ID:        model.Identifier(),
-		DeviceID:  model.GetDeviceID(),
-		DataType:  model.GetDataType(),
-		Name:      model.GetName(),
-		Key:       model.GetKey(),
-		Enable:    model.IsEnabled(),
-		Notify:    model.IsNotify(),
-		Unit:      model.GetUnit(),
-		AlarmLow:  model.GetAlarmLow(),
-		AlarmHigh: model.GetAlarmHigh()}
+		ID:                    model.Identifier(),
+		DeviceID:              model.GetDeviceID(),
+		DataType:              model.GetDataType(),
+		Name:                  model.GetName(),
+		Key:                   model.GetKey(),
+		Enable:                model.IsEnabled(),
+		Notify:                model.IsNotify(),
+		Unit:                  model.GetUnit(),
+		AlarmLow:              model.GetAlarmLow(),
+		AlarmHigh:             model.GetAlarmHigh(),
+		AnomalyWindow:         model.GetAnomalyWindow(),
+		AnomalyZScore:         model.GetAnomalyZScore(),
+		RateOfChange:          model.GetRateOfChange(),
+		RateOfChangeWindow:    model.GetRateOfChangeWindow(),
+		FlatlineWindow:        model.GetFlatlineWindow(),
+		AnomalyBackoff:        model.GetAnomalyBackoff(),
+		Expression:            model.GetExpression(),
+		CalibrationOffset:     model.GetCalibrationOffset(),
+		CalibrationSlope:      model.GetCalibrationSlope(),
+		CalibratedAt:          model.GetCalibratedAt(),
+		CalibrationReferences: model.GetCalibrationReferences(),
+		CalibrationInterval:   model.GetCalibrationInterval()}
 }
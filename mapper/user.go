@@ -21,8 +21,9 @@ func (mapper *DefaultUserMapper) MapUserConfigToModel(config *config.UserStruct)
 	roles := make([]model.Role, len(config.GetRoles()))
 	for i, role := range config.GetRoles() {
 		roles[i] = &model.RoleStruct{
-			ID:   role.ID,
-			Name: role.GetName()}
+			ID:          role.ID,
+			Name:        role.GetName(),
+			Permissions: role.GetPermissionList()}
 	}
 	return &model.UserStruct{
 		ID:               config.Identifier(),
@@ -37,8 +38,9 @@ func (mapper *DefaultUserMapper) MapUserModelToConfig(user model.User) *config.U
 	roles := make([]*config.RoleStruct, len(user.GetRoles()))
 	for i, role := range user.GetRoles() {
 		roles[i] = &config.RoleStruct{
-			ID:   role.Identifier(),
-			Name: role.GetName()}
+			ID:          role.Identifier(),
+			Name:        role.GetName(),
+			Permissions: role.GetPermissions()}
 	}
 	return &config.UserStruct{
 		ID:               user.Identifier(),
@@ -15,19 +15,31 @@ type Metric interface {
 // The Metric model is a fully populated Metric that contains
 // the config, value, and the timestamp the value was last updated.
 type MetricStruct struct {
-	ID        uint64     `yaml:"id" json:"id"`
-	DeviceID  uint64     `yaml:"deviceID" json:"deviceId"`
-	DataType  int        `yaml:"datatype" json:"datatype"`
-	Name      string     `yaml:"name" json:"name"`
-	Key       string     `yaml:"key" json:"key"`
-	Enable    bool       `yaml:"enable" json:"enable"`
-	Notify    bool       `yaml:"notify" json:"notify"`
-	Unit      string     `yaml:"unit" json:"unit"`
-	AlarmLow  float64    `yaml:"alarmLow" json:"alarmLow"`
-	AlarmHigh float64    `yaml:"alarmHigh" json:"alarmHigh"`
-	Value     float64    `yaml:"value" json:"value"`
-	Timestamp *time.Time `yaml:"timestamp" json:"timestamp"`
-	Metric    `json:"-"`
+	ID                    uint64     `yaml:"id" json:"id"`
+	DeviceID              uint64     `yaml:"deviceID" json:"deviceId"`
+	DataType              int        `yaml:"datatype" json:"datatype"`
+	Name                  string     `yaml:"name" json:"name"`
+	Key                   string     `yaml:"key" json:"key"`
+	Enable                bool       `yaml:"enable" json:"enable"`
+	Notify                bool       `yaml:"notify" json:"notify"`
+	Unit                  string     `yaml:"unit" json:"unit"`
+	AlarmLow              float64    `yaml:"alarmLow" json:"alarmLow"`
+	AlarmHigh             float64    `yaml:"alarmHigh" json:"alarmHigh"`
+	AnomalyWindow         int        `yaml:"anomalyWindow" json:"anomalyWindow"`
+	AnomalyZScore         float64    `yaml:"anomalyZScore" json:"anomalyZScore"`
+	RateOfChange          float64    `yaml:"rateOfChange" json:"rateOfChange"`
+	RateOfChangeWindow    int        `yaml:"rateOfChangeWindow" json:"rateOfChangeWindow"`
+	FlatlineWindow        int        `yaml:"flatlineWindow" json:"flatlineWindow"`
+	AnomalyBackoff        int        `yaml:"anomalyBackoff" json:"anomalyBackoff"`
+	Expression            string     `yaml:"expression" json:"expression"`
+	CalibrationOffset     float64    `yaml:"calibrationOffset" json:"calibrationOffset"`
+	CalibrationSlope      float64    `yaml:"calibrationSlope" json:"calibrationSlope"`
+	CalibratedAt          *time.Time `yaml:"calibratedAt" json:"calibratedAt"`
+	CalibrationReferences string     `yaml:"calibrationReferences" json:"calibrationReferences"`
+	CalibrationInterval   int        `yaml:"calibrationInterval" json:"calibrationInterval"`
+	Value                 float64    `yaml:"value" json:"value"`
+	Timestamp             *time.Time `yaml:"timestamp" json:"timestamp"`
+	Metric                `json:"-"`
 }
 
 func NewMetric() Metric {
@@ -125,6 +137,72 @@ func (metric *MetricStruct) GetAlarmHigh() float64 {
 	return metric.AlarmHigh
 }
 
+func (metric *MetricStruct) SetAnomalyWindow(samples int) {
+	metric.AnomalyWindow = samples
+}
+
+func (metric *MetricStruct) GetAnomalyWindow() int {
+	return metric.AnomalyWindow
+}
+
+func (metric *MetricStruct) SetAnomalyZScore(zscore float64) {
+	metric.AnomalyZScore = zscore
+}
+
+func (metric *MetricStruct) GetAnomalyZScore() float64 {
+	return metric.AnomalyZScore
+}
+
+func (metric *MetricStruct) SetRateOfChange(delta float64) {
+	metric.RateOfChange = delta
+}
+
+func (metric *MetricStruct) GetRateOfChange() float64 {
+	return metric.RateOfChange
+}
+
+func (metric *MetricStruct) SetRateOfChangeWindow(minutes int) {
+	metric.RateOfChangeWindow = minutes
+}
+
+func (metric *MetricStruct) GetRateOfChangeWindow() int {
+	return metric.RateOfChangeWindow
+}
+
+func (metric *MetricStruct) SetFlatlineWindow(minutes int) {
+	metric.FlatlineWindow = minutes
+}
+
+func (metric *MetricStruct) GetFlatlineWindow() int {
+	return metric.FlatlineWindow
+}
+
+func (metric *MetricStruct) SetAnomalyBackoff(minutes int) {
+	metric.AnomalyBackoff = minutes
+}
+
+func (metric *MetricStruct) GetAnomalyBackoff() int {
+	return metric.AnomalyBackoff
+}
+
+func (metric *MetricStruct) IsAnomalyDetectionEnabled() bool {
+	return (metric.AnomalyWindow > 1 && metric.AnomalyZScore > 0) ||
+		(metric.RateOfChange > 0 && metric.RateOfChangeWindow > 0) ||
+		metric.FlatlineWindow > 0
+}
+
+func (metric *MetricStruct) SetExpression(expression string) {
+	metric.Expression = expression
+}
+
+func (metric *MetricStruct) GetExpression() string {
+	return metric.Expression
+}
+
+func (metric *MetricStruct) IsComputed() bool {
+	return metric.Expression != ""
+}
+
 func (metric *MetricStruct) SetValue(value float64) {
 	metric.Value = value
 }
@@ -140,3 +218,66 @@ func (metric *MetricStruct) SetTimestamp(timestamp *time.Time) {
 func (metric *MetricStruct) GetTimestamp() *time.Time {
 	return metric.Timestamp
 }
+
+func (metric *MetricStruct) SetCalibrationOffset(offset float64) {
+	metric.CalibrationOffset = offset
+}
+
+func (metric *MetricStruct) GetCalibrationOffset() float64 {
+	return metric.CalibrationOffset
+}
+
+func (metric *MetricStruct) SetCalibrationSlope(slope float64) {
+	metric.CalibrationSlope = slope
+}
+
+func (metric *MetricStruct) GetCalibrationSlope() float64 {
+	return metric.CalibrationSlope
+}
+
+func (metric *MetricStruct) SetCalibratedAt(calibratedAt *time.Time) {
+	metric.CalibratedAt = calibratedAt
+}
+
+func (metric *MetricStruct) GetCalibratedAt() *time.Time {
+	return metric.CalibratedAt
+}
+
+func (metric *MetricStruct) SetCalibrationReferences(references string) {
+	metric.CalibrationReferences = references
+}
+
+func (metric *MetricStruct) GetCalibrationReferences() string {
+	return metric.CalibrationReferences
+}
+
+func (metric *MetricStruct) SetCalibrationInterval(days int) {
+	metric.CalibrationInterval = days
+}
+
+func (metric *MetricStruct) GetCalibrationInterval() int {
+	return metric.CalibrationInterval
+}
+
+func (metric *MetricStruct) IsCalibrated() bool {
+	return metric.CalibratedAt != nil
+}
+
+func (metric *MetricStruct) IsCalibrationOverdue(now time.Time) bool {
+	if metric.CalibrationInterval <= 0 {
+		return false
+	}
+	if metric.CalibratedAt == nil {
+		return true
+	}
+	interval := time.Duration(metric.CalibrationInterval) * 24 * time.Hour
+	return now.Sub(*metric.CalibratedAt) >= interval
+}
+
+func (metric *MetricStruct) Calibrate(raw float64) float64 {
+	slope := metric.CalibrationSlope
+	if slope == 0 {
+		slope = 1
+	}
+	return raw*slope + metric.CalibrationOffset
+}
@@ -5,6 +5,10 @@ import (
 )
 
 type Notification interface {
+	GetID() uint64
+	SetID(id uint64)
+	GetOrganizationID() uint64
+	GetFarmID() uint64
 	GetDevice() string
 	GetPriority() int
 	GetType() string
@@ -15,13 +19,32 @@ type Notification interface {
 }
 
 type NotificationStruct struct {
-	Device       string    `json:"device"`
-	Priority     int       `json:"priority"`
-	Type         string    `json:"type"`
-	Title        string    `json:"title"`
-	Message      string    `json:"message"`
-	Timestamp    time.Time `json:"timestamp"`
-	Notification `json:"-"`
+	ID             uint64    `json:"id"`
+	OrganizationID uint64    `json:"orgId"`
+	FarmID         uint64    `json:"farmId"`
+	Device         string    `json:"device"`
+	Priority       int       `json:"priority"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Message        string    `json:"message"`
+	Timestamp      time.Time `json:"timestamp"`
+	Notification   `json:"-"`
+}
+
+func (model *NotificationStruct) GetID() uint64 {
+	return model.ID
+}
+
+func (model *NotificationStruct) SetID(id uint64) {
+	model.ID = id
+}
+
+func (model *NotificationStruct) GetOrganizationID() uint64 {
+	return model.OrganizationID
+}
+
+func (model *NotificationStruct) GetFarmID() uint64 {
+	return model.FarmID
 }
 
 func (model *NotificationStruct) GetDevice() string {
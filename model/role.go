@@ -1,15 +1,20 @@
 package model
 
-import "github.com/jeremyhahn/go-cropdroid/config"
+import (
+	"strings"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+)
 
 type Role interface {
 	config.Role
 }
 
 type RoleStruct struct {
-	ID   uint64 `json:"id"`
-	Name string `json:"name"`
-	Role `json:"-"`
+	ID          uint64   `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+	Role        `json:"-"`
 }
 
 func NewRole() Role {
@@ -31,3 +36,30 @@ func (role *RoleStruct) GetName() string {
 func (role *RoleStruct) SetName(name string) {
 	role.Name = name
 }
+
+func (role *RoleStruct) GetPermissions() string {
+	return strings.Join(role.Permissions, ",")
+}
+
+func (role *RoleStruct) SetPermissions(permissions string) {
+	role.Permissions = make([]string, 0)
+	for _, permission := range strings.Split(permissions, ",") {
+		if permission = strings.TrimSpace(permission); permission != "" {
+			role.Permissions = append(role.Permissions, permission)
+		}
+	}
+}
+
+func (role *RoleStruct) GetPermissionList() []string {
+	return role.Permissions
+}
+
+// Returns true if the role grants the specified permission
+func (role *RoleStruct) HasPermission(permission string) bool {
+	for _, p := range role.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
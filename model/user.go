@@ -6,6 +6,7 @@ type User interface {
 	GetRoles() []Role
 	SetRoles([]Role)
 	AddRole(Role)
+	HasPermission(permission string) bool
 	config.CommonUser
 }
 
@@ -69,6 +70,16 @@ func (user *UserStruct) HasRole(name string) bool {
 	return false
 }
 
+// Returns true if any of the user's roles grant the specified permission
+func (user *UserStruct) HasPermission(permission string) bool {
+	for _, role := range user.Roles {
+		if role.HasPermission(permission) {
+			return true
+		}
+	}
+	return false
+}
+
 func (user *UserStruct) SetOrganizationRefs(ids []uint64) {
 	user.OrganizationRefs = ids
 }
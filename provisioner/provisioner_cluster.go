@@ -26,13 +26,14 @@ type RaftFarmProvisioner struct {
 	userMapper          mapper.UserMapper
 	initializer         dao.Initializer
 	farmProvisionerChan chan config.Farm
+	quotaChecker        FarmQuotaChecker
 	FarmProvisioner
 }
 
 func NewRaftFarmProvisioner(app *app.App, gossip cluster.GossipNode,
 	location *time.Location, farmDAO dao.FarmDAO, userDAO dao.UserDAO,
 	permissionDAO dao.PermissionDAO, userMapper mapper.UserMapper,
-	initializer dao.Initializer) FarmProvisioner {
+	initializer dao.Initializer, quotaChecker FarmQuotaChecker) FarmProvisioner {
 
 	return &RaftFarmProvisioner{
 		app:           app,
@@ -42,12 +43,19 @@ func NewRaftFarmProvisioner(app *app.App, gossip cluster.GossipNode,
 		userDAO:       userDAO,
 		permissionDAO: permissionDAO,
 		userMapper:    userMapper,
-		initializer:   initializer}
+		initializer:   initializer,
+		quotaChecker:  quotaChecker}
 }
 
 func (provisioner *RaftFarmProvisioner) Provision(
 	userAccount model.User, params *common.ProvisionerParams) (*config.FarmStruct, error) {
 
+	if provisioner.quotaChecker != nil {
+		if err := provisioner.quotaChecker.CheckFarmQuota(params.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
 	userConfig := provisioner.userMapper.MapUserModelToConfig(userAccount)
 
 	// Send provisioning request to all nodes in the cluster
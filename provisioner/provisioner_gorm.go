@@ -22,6 +22,7 @@ type GormFarmProvisioner struct {
 	farmDeprovisionerChan chan config.Farm
 	userMapper            mapper.UserMapper
 	initializer           dao.Initializer
+	quotaChecker          FarmQuotaChecker
 	FarmProvisioner
 }
 
@@ -29,7 +30,7 @@ func NewGormFarmProvisioner(logger *logging.Logger, db *gorm.DB,
 	location *time.Location, farmDAO dao.FarmDAO,
 	permissionDAO dao.PermissionDAO, farmProvisionerChan chan config.Farm,
 	farmDeprovisionerChan chan config.Farm, userMapper mapper.UserMapper,
-	initializer dao.Initializer) FarmProvisioner {
+	initializer dao.Initializer, quotaChecker FarmQuotaChecker) FarmProvisioner {
 
 	return &GormFarmProvisioner{
 		logger:                logger,
@@ -40,12 +41,19 @@ func NewGormFarmProvisioner(logger *logging.Logger, db *gorm.DB,
 		farmProvisionerChan:   farmProvisionerChan,
 		farmDeprovisionerChan: farmDeprovisionerChan,
 		userMapper:            userMapper,
-		initializer:           initializer}
+		initializer:           initializer,
+		quotaChecker:          quotaChecker}
 }
 
 func (provisioner *GormFarmProvisioner) Provision(userAccount model.User,
 	params *common.ProvisionerParams) (*config.FarmStruct, error) {
 
+	if provisioner.quotaChecker != nil {
+		if err := provisioner.quotaChecker.CheckFarmQuota(params.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
 	userMappser := mapper.NewUserMapper()
 	user := userMappser.MapUserModelToConfig(userAccount)
 
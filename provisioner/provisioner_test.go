@@ -183,5 +183,5 @@ func createDefaultProvisioner() (dao.FarmDAO, dao.UserDAO, FarmProvisioner, dao.
 		"virtual")
 
 	return farmDAO, userDAO, NewGormFarmProvisioner(it.logger, it.gorm, it.location,
-		farmDAO, permissionDAO, nil, nil, userMapper, configInitializer), configInitializer
+		farmDAO, permissionDAO, nil, nil, userMapper, configInitializer, nil), configInitializer
 }
@@ -12,6 +12,12 @@ type FarmProvisioner interface {
 	Deprovision(user model.User, farmID uint64) error
 }
 
+// FarmQuotaChecker rejects new farms that would exceed the organization's
+// licensed farm quota
+type FarmQuotaChecker interface {
+	CheckFarmQuota(orgID uint64) error
+}
+
 // const (
 // 	DEFAULT_GALLONS = "50"
 
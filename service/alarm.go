@@ -0,0 +1,305 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrAlarmNotFound = errors.New("alarm not found")
+	ErrAlarmCleared  = errors.New("alarm already cleared")
+)
+
+type AlarmService interface {
+	Evaluate(farmID uint64, deviceConfig config.Device, metric config.Metric,
+		value float64, renotify int) (*entity.Alarm, bool, error)
+	GetAlarms(session Session, openOnly bool) ([]*entity.Alarm, error)
+	Acknowledge(session Session, alarmID uint64) (*entity.Alarm, error)
+	Shelve(session Session, alarmID uint64, minutes int) (*entity.Alarm, error)
+}
+
+type DefaultAlarmService struct {
+	logger          *logging.Logger
+	alarmDAO        dao.AlarmDAO
+	serviceRegistry ServiceRegistry
+	alarms          map[string]*entity.Alarm
+	loaded          map[uint64]bool
+	mutex           *sync.Mutex
+	clock           func() time.Time
+	AlarmService
+}
+
+// Creates a new alarm service that tracks metric alarms through their lifecycle.
+// Open alarms are kept in memory, keyed by farm, device and metric, so a metric
+// that stays out of range raises a single alarm that is re-notified at the farm's
+// re-notify interval instead of on every poll.
+func NewAlarmService(
+	logger *logging.Logger,
+	alarmDAO dao.AlarmDAO,
+	serviceRegistry ServiceRegistry) AlarmService {
+
+	return &DefaultAlarmService{
+		logger:          logger,
+		alarmDAO:        alarmDAO,
+		serviceRegistry: serviceRegistry,
+		alarms:          make(map[string]*entity.Alarm, 0),
+		loaded:          make(map[uint64]bool, 0),
+		mutex:           &sync.Mutex{},
+		clock:           time.Now}
+}
+
+// Evaluates the metric value against the metric's alarm range, raising, re-notifying
+// or clearing the metric's alarm as needed. Returns the alarm and true if a notification
+// should be sent, or nil if the alarm state did not change. Re-notify is the number of
+// minutes between notifications for an alarm that remains active.
+func (service *DefaultAlarmService) Evaluate(farmID uint64, deviceConfig config.Device,
+	metric config.Metric, value float64, renotify int) (*entity.Alarm, bool, error) {
+
+	if renotify <= 0 {
+		renotify = common.DEFAULT_ALARM_RENOTIFY_INTERVAL
+	}
+	now := service.clock()
+	deviceID := deviceConfig.Identifier()
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if err := service.load(farmID); err != nil {
+		return nil, false, err
+	}
+
+	var condition string
+	var threshold float64
+	if value <= metric.GetAlarmLow() {
+		condition, threshold = common.ALARM_CONDITION_LOW, metric.GetAlarmLow()
+	} else if value >= metric.GetAlarmHigh() {
+		condition, threshold = common.ALARM_CONDITION_HIGH, metric.GetAlarmHigh()
+	}
+
+	key := alarmKey(farmID, deviceID, metric.GetKey())
+	alarm, exists := service.alarms[key]
+
+	if condition == "" {
+		if !exists {
+			return nil, false, nil
+		}
+		// Value returned to the normal range
+		notify := alarm.State != common.ALARM_STATE_SHELVED
+		alarm.State = common.ALARM_STATE_CLEARED
+		alarm.Value = value
+		alarm.ClearedAt = now
+		alarm.Message = fmt.Sprintf("%s cleared: %.2f", metric.GetName(), value)
+		delete(service.alarms, key)
+		return service.save(alarm, notify)
+	}
+
+	if !exists {
+		alarm = &entity.Alarm{
+			FarmID:         farmID,
+			DeviceID:       deviceID,
+			DeviceType:     deviceConfig.GetType(),
+			MetricKey:      metric.GetKey(),
+			MetricName:     metric.GetName(),
+			Condition:      condition,
+			State:          common.ALARM_STATE_ACTIVE,
+			Value:          value,
+			Threshold:      threshold,
+			Message:        fmt.Sprintf("%s %s: %.2f", metric.GetName(), condition, value),
+			NotifyCount:    1,
+			RaisedAt:       now,
+			LastNotifiedAt: now}
+		service.alarms[key] = alarm
+		service.logger.Infof("Raised %s alarm for farm %d, device %d: %s",
+			alarm.Condition, farmID, deviceID, alarm.Message)
+		return service.save(alarm, true)
+	}
+
+	alarm.Value = value
+	changed, notify := false, false
+
+	if alarm.Condition != condition {
+		// Swinging across the range is a new condition the user
+		// has not acknowledged
+		alarm.Condition = condition
+		alarm.Threshold = threshold
+		if alarm.State == common.ALARM_STATE_ACKNOWLEDGED {
+			alarm.State = common.ALARM_STATE_ACTIVE
+		}
+		changed, notify = true, alarm.State == common.ALARM_STATE_ACTIVE
+	}
+	if alarm.State == common.ALARM_STATE_SHELVED && !now.Before(alarm.ShelvedUntil) {
+		alarm.State = common.ALARM_STATE_ACTIVE
+		alarm.ShelvedUntil = time.Time{}
+		changed, notify = true, true
+	}
+	if alarm.State == common.ALARM_STATE_ACTIVE &&
+		now.Sub(alarm.LastNotifiedAt) >= time.Duration(renotify)*time.Minute {
+		notify = true
+	}
+	if !changed && !notify {
+		return nil, false, nil
+	}
+	alarm.Message = fmt.Sprintf("%s %s: %.2f", metric.GetName(), condition, value)
+	if notify {
+		alarm.NotifyCount++
+		alarm.LastNotifiedAt = now
+	}
+	return service.save(alarm, notify)
+}
+
+// Returns the alarms for the requested farm, most recently raised first. Only
+// alarms that have not been cleared are returned when openOnly is true.
+func (service *DefaultAlarmService) GetAlarms(session Session, openOnly bool) ([]*entity.Alarm, error) {
+	farmID := session.GetRequestedFarmID()
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if err := service.load(farmID); err != nil {
+		return nil, err
+	}
+	alarms := make([]*entity.Alarm, 0)
+	if openOnly {
+		for _, alarm := range service.alarms {
+			if alarm.GetFarmID() == farmID {
+				alarms = append(alarms, service.copy(alarm))
+			}
+		}
+		sort.Slice(alarms, func(i, j int) bool {
+			return alarms[i].RaisedAt.After(alarms[j].RaisedAt)
+		})
+		return alarms, nil
+	}
+	persisted, err := service.alarmDAO.GetByFarmID(farmID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, err
+	}
+	for _, alarm := range persisted {
+		// Open alarms in memory have the most recent metric value
+		if open, ok := service.alarms[alarmKey(farmID, alarm.DeviceID, alarm.MetricKey)]; ok &&
+			open.Identifier() == alarm.Identifier() {
+			alarm = service.copy(open)
+		}
+		alarms = append(alarms, alarm)
+	}
+	return alarms, nil
+}
+
+// Acknowledges an open alarm. Acknowledged alarms are no longer re-notified
+// but remain open until the metric returns to the normal range.
+func (service *DefaultAlarmService) Acknowledge(session Session, alarmID uint64) (*entity.Alarm, error) {
+	return service.update(session, alarmID, func(alarm *entity.Alarm, now time.Time) {
+		alarm.State = common.ALARM_STATE_ACKNOWLEDGED
+		alarm.AcknowledgedBy = session.GetUser().Identifier()
+		alarm.AcknowledgedAt = now
+		alarm.ShelvedUntil = time.Time{}
+	})
+}
+
+// Shelves an open alarm for the requested number of minutes, suppressing
+// notifications until the shelve period expires.
+func (service *DefaultAlarmService) Shelve(session Session, alarmID uint64, minutes int) (*entity.Alarm, error) {
+	if minutes <= 0 {
+		minutes = common.DEFAULT_ALARM_SHELVE_DURATION
+	}
+	return service.update(session, alarmID, func(alarm *entity.Alarm, now time.Time) {
+		alarm.State = common.ALARM_STATE_SHELVED
+		alarm.ShelvedUntil = now.Add(time.Duration(minutes) * time.Minute)
+	})
+}
+
+// Applies the update to an open alarm in the requested farm and persists
+// and publishes the result
+func (service *DefaultAlarmService) update(session Session, alarmID uint64,
+	updateFunc func(alarm *entity.Alarm, now time.Time)) (*entity.Alarm, error) {
+
+	farmID := session.GetRequestedFarmID()
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if err := service.load(farmID); err != nil {
+		return nil, err
+	}
+	var alarm *entity.Alarm
+	for _, open := range service.alarms {
+		if open.Identifier() == alarmID && open.GetFarmID() == farmID {
+			alarm = open
+			break
+		}
+	}
+	if alarm == nil {
+		persisted, err := service.alarmDAO.Get(alarmID, common.CONSISTENCY_LOCAL)
+		if err != nil || persisted == nil || persisted.GetFarmID() != farmID {
+			return nil, ErrAlarmNotFound
+		}
+		return nil, ErrAlarmCleared
+	}
+	updateFunc(alarm, service.clock())
+	service.logger.Infof("Alarm %d is now %s (user=%d)", alarmID, alarm.State,
+		session.GetUser().Identifier())
+	updated, _, err := service.save(alarm, false)
+	return updated, err
+}
+
+// Persists the alarm, publishes the update to the farm's real-time clients
+// and emails the users subscribed to alarm emails when notify is true
+func (service *DefaultAlarmService) save(alarm *entity.Alarm, notify bool) (*entity.Alarm, bool, error) {
+	if err := service.alarmDAO.Save(alarm); err != nil {
+		service.logger.Errorf("Error saving alarm %d: %s", alarm.Identifier(), err)
+		return nil, false, err
+	}
+	published := service.copy(alarm)
+	if service.serviceRegistry != nil {
+		if farmService := service.serviceRegistry.GetFarmService(alarm.GetFarmID()); farmService != nil {
+			if err := farmService.PublishAlarm(published); err != nil {
+				service.logger.Warning(err)
+			}
+		}
+		if emailService := service.serviceRegistry.GetEmailService(); notify && emailService != nil {
+			if err := emailService.SendAlarm(service.copy(alarm)); err != nil {
+				service.logger.Warningf("Error emailing alarm %d: %s", alarm.Identifier(), err)
+			}
+		}
+	}
+	return published, notify, nil
+}
+
+// Loads the farm's open alarms from the database the first
+// time the farm is referenced. The mutex must be held.
+func (service *DefaultAlarmService) load(farmID uint64) error {
+	if service.loaded[farmID] {
+		return nil
+	}
+	alarms, err := service.alarmDAO.GetByFarmID(farmID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return err
+	}
+	for _, alarm := range alarms {
+		if alarm.IsOpen() {
+			service.alarms[alarmKey(farmID, alarm.DeviceID, alarm.MetricKey)] = alarm
+		}
+	}
+	service.loaded[farmID] = true
+	return nil
+}
+
+// Returns a copy of the alarm that is safe to hand to callers
+// outside of the mutex
+func (service *DefaultAlarmService) copy(alarm *entity.Alarm) *entity.Alarm {
+	c := *alarm
+	return &c
+}
+
+func alarmKey(farmID, deviceID uint64, metricKey string) string {
+	return fmt.Sprintf("%d-%d-%s", farmID, deviceID, metricKey)
+}
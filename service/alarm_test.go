@@ -0,0 +1,266 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	"github.com/jeremyhahn/go-cropdroid/state"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAlarmDAO struct {
+	alarms map[uint64]*entity.Alarm
+	nextID uint64
+	dao.AlarmDAO
+}
+
+func (alarmDAO *fakeAlarmDAO) Save(alarm *entity.Alarm) error {
+	if alarm.ID == 0 {
+		alarmDAO.nextID++
+		alarm.ID = alarmDAO.nextID
+	}
+	persisted := *alarm
+	alarmDAO.alarms[alarm.ID] = &persisted
+	return nil
+}
+
+func (alarmDAO *fakeAlarmDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.Alarm, error) {
+	if alarm, ok := alarmDAO.alarms[id]; ok {
+		persisted := *alarm
+		return &persisted, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (alarmDAO *fakeAlarmDAO) GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.Alarm, error) {
+	alarms := make([]*entity.Alarm, 0)
+	for _, alarm := range alarmDAO.alarms {
+		if alarm.FarmID == farmID {
+			persisted := *alarm
+			alarms = append(alarms, &persisted)
+		}
+	}
+	return alarms, nil
+}
+
+type fakeAlarmNotificationService struct {
+	notifications []model.Notification
+	NotificationServicer
+}
+
+func (notificationService *fakeAlarmNotificationService) Enqueue(notification model.Notification) error {
+	notificationService.notifications = append(notificationService.notifications, notification)
+	return nil
+}
+
+func createAlarmTestService(alarmDAO dao.AlarmDAO) (*DefaultAlarmService, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := NewAlarmService(logging.MustGetLogger("alarm_test"),
+		alarmDAO, nil).(*DefaultAlarmService)
+	service.clock = func() time.Time { return now }
+	return service, &now
+}
+
+func createAlarmTestMetric() (*config.DeviceStruct, *config.MetricStruct) {
+	device := &config.DeviceStruct{ID: 10, Type: common.CONTROLLER_TYPE_ROOM}
+	metric := &config.MetricStruct{
+		Key:       "humidity0",
+		Name:      "Humidity",
+		Enable:    true,
+		Notify:    true,
+		AlarmLow:  40,
+		AlarmHigh: 60}
+	return device, metric
+}
+
+func TestAlarmDeduplicationAndRenotify(t *testing.T) {
+	service, now := createAlarmTestService(&fakeAlarmDAO{alarms: make(map[uint64]*entity.Alarm)})
+	device, metric := createAlarmTestMetric()
+
+	alarm, notify, err := service.Evaluate(2, device, metric, 50, 30)
+	assert.Nil(t, err)
+	assert.Nil(t, alarm)
+	assert.False(t, notify)
+
+	alarm, notify, err = service.Evaluate(2, device, metric, 75, 30)
+	assert.Nil(t, err)
+	assert.True(t, notify)
+	assert.Equal(t, common.ALARM_STATE_ACTIVE, alarm.State)
+	assert.Equal(t, common.ALARM_CONDITION_HIGH, alarm.Condition)
+	assert.Equal(t, "Humidity HIGH: 75.00", alarm.Message)
+	alarmID := alarm.ID
+
+	// Still out of range, polled every minute
+	for i := 0; i < 29; i++ {
+		*now = now.Add(time.Minute)
+		alarm, notify, err = service.Evaluate(2, device, metric, 76, 30)
+		assert.Nil(t, err)
+		assert.Nil(t, alarm)
+		assert.False(t, notify)
+	}
+
+	*now = now.Add(time.Minute)
+	alarm, notify, err = service.Evaluate(2, device, metric, 77, 30)
+	assert.Nil(t, err)
+	assert.True(t, notify)
+	assert.Equal(t, alarmID, alarm.ID)
+	assert.Equal(t, 2, alarm.NotifyCount)
+
+	// Returning to the normal range clears the alarm
+	alarm, notify, err = service.Evaluate(2, device, metric, 55, 30)
+	assert.Nil(t, err)
+	assert.True(t, notify)
+	assert.Equal(t, common.ALARM_STATE_CLEARED, alarm.State)
+	assert.Equal(t, *now, alarm.ClearedAt)
+
+	// A new excursion raises a new alarm
+	alarm, notify, err = service.Evaluate(2, device, metric, 30, 30)
+	assert.Nil(t, err)
+	assert.True(t, notify)
+	assert.NotEqual(t, alarmID, alarm.ID)
+	assert.Equal(t, common.ALARM_CONDITION_LOW, alarm.Condition)
+}
+
+func TestAlarmAcknowledgeAndShelve(t *testing.T) {
+	service, now := createAlarmTestService(&fakeAlarmDAO{alarms: make(map[uint64]*entity.Alarm)})
+	device, metric := createAlarmTestMetric()
+	session := CreateSession(logging.MustGetLogger("alarm_test"), nil, nil, nil,
+		1, 2, common.CONSISTENCY_LOCAL, &model.UserStruct{ID: 5})
+
+	alarm, _, err := service.Evaluate(2, device, metric, 75, 30)
+	assert.Nil(t, err)
+	alarmID := alarm.ID
+
+	alarm, err = service.Acknowledge(session, alarmID)
+	assert.Nil(t, err)
+	assert.Equal(t, common.ALARM_STATE_ACKNOWLEDGED, alarm.State)
+	assert.Equal(t, uint64(5), alarm.AcknowledgedBy)
+
+	// Acknowledged alarms are not re-notified
+	*now = now.Add(2 * time.Hour)
+	alarm, notify, err := service.Evaluate(2, device, metric, 75, 30)
+	assert.Nil(t, err)
+	assert.Nil(t, alarm)
+	assert.False(t, notify)
+
+	// Swinging to the other side of the range re-activates the alarm
+	alarm, notify, err = service.Evaluate(2, device, metric, 35, 30)
+	assert.Nil(t, err)
+	assert.True(t, notify)
+	assert.Equal(t, common.ALARM_STATE_ACTIVE, alarm.State)
+	assert.Equal(t, common.ALARM_CONDITION_LOW, alarm.Condition)
+
+	alarm, err = service.Shelve(session, alarmID, 60)
+	assert.Nil(t, err)
+	assert.Equal(t, common.ALARM_STATE_SHELVED, alarm.State)
+
+	*now = now.Add(59 * time.Minute)
+	alarm, notify, err = service.Evaluate(2, device, metric, 35, 30)
+	assert.Nil(t, err)
+	assert.Nil(t, alarm)
+	assert.False(t, notify)
+
+	// Notifications resume when the shelve period expires
+	*now = now.Add(time.Minute)
+	alarm, notify, err = service.Evaluate(2, device, metric, 35, 30)
+	assert.Nil(t, err)
+	assert.True(t, notify)
+	assert.Equal(t, common.ALARM_STATE_ACTIVE, alarm.State)
+
+	open, err := service.GetAlarms(session, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(open))
+
+	// Cleared alarms can not be acknowledged
+	_, _, err = service.Evaluate(2, device, metric, 50, 30)
+	assert.Nil(t, err)
+	_, err = service.Acknowledge(session, alarmID)
+	assert.Equal(t, ErrAlarmCleared, err)
+
+	open, err = service.GetAlarms(session, true)
+	assert.Nil(t, err)
+	assert.Empty(t, open)
+	all, err := service.GetAlarms(session, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(all))
+
+	// Alarms can only be managed from their own farm
+	otherFarm := CreateSession(logging.MustGetLogger("alarm_test"), nil, nil, nil,
+		1, 3, common.CONSISTENCY_LOCAL, &model.UserStruct{ID: 5})
+	_, err = service.Shelve(otherFarm, alarmID, 0)
+	assert.Equal(t, ErrAlarmNotFound, err)
+}
+
+func TestAlarmsLoadedFromDatastore(t *testing.T) {
+	alarmDAO := &fakeAlarmDAO{alarms: make(map[uint64]*entity.Alarm)}
+	service, _ := createAlarmTestService(alarmDAO)
+	device, metric := createAlarmTestMetric()
+
+	alarm, _, err := service.Evaluate(2, device, metric, 75, 30)
+	assert.Nil(t, err)
+
+	// A restarted service picks up the open alarm instead of raising a new one
+	restarted, _ := createAlarmTestService(alarmDAO)
+	restarted.clock = service.clock
+	reloaded, notify, err := restarted.Evaluate(2, device, metric, 75, 30)
+	assert.Nil(t, err)
+	assert.Nil(t, reloaded)
+	assert.False(t, notify)
+	assert.Equal(t, 1, len(alarmDAO.alarms))
+
+	reloaded, _, err = restarted.Evaluate(2, device, metric, 50, 30)
+	assert.Nil(t, err)
+	assert.Equal(t, alarm.ID, reloaded.ID)
+	assert.Equal(t, common.ALARM_STATE_CLEARED, reloaded.State)
+}
+
+func TestManageMetricsTracksAlarmsWithoutNotify(t *testing.T) {
+	alarmDAO := &fakeAlarmDAO{alarms: make(map[uint64]*entity.Alarm)}
+	alarmService, _ := createAlarmTestService(alarmDAO)
+	notificationService := &fakeAlarmNotificationService{}
+	device, metric := createAlarmTestMetric()
+	metric.Notify = false
+	device.SetMetrics([]*config.MetricStruct{metric})
+	farmConfig := config.NewFarm()
+	farmConfig.ID = 2
+	farmService := &DefaultFarmService{
+		app:                 &app.App{Logger: logging.MustGetLogger("alarm_test")},
+		farmID:              2,
+		consistencyLevel:    common.CONSISTENCY_LOCAL,
+		farmDAO:             &fakeFarmDAO{farms: map[uint64]*config.FarmStruct{2: farmConfig}},
+		alarmService:        alarmService,
+		anomalyDetector:     NewAnomalyDetector(logging.MustGetLogger("alarm_test")),
+		notificationService: notificationService}
+
+	manage := func(value float64) {
+		farmState := state.NewFarmStateMap(2)
+		farmState.SetDevice(device.GetType(),
+			state.CreateDeviceStateMap(map[string]float64{metric.GetKey(): value}, []int{}))
+		assert.Empty(t, farmService.ManageMetrics(device, farmState))
+	}
+
+	// The alarm is raised and persisted without notifying anyone
+	manage(75)
+	assert.Equal(t, 1, len(alarmDAO.alarms))
+	assert.Equal(t, common.ALARM_STATE_ACTIVE, alarmDAO.alarms[1].State)
+	assert.Empty(t, notificationService.notifications)
+
+	// ...and cleared when the metric returns to the normal range
+	manage(50)
+	assert.Equal(t, common.ALARM_STATE_CLEARED, alarmDAO.alarms[1].State)
+	assert.Empty(t, notificationService.notifications)
+
+	metric.Notify = true
+	manage(30)
+	assert.Equal(t, 2, len(alarmDAO.alarms))
+	assert.Equal(t, 1, len(notificationService.notifications))
+	assert.Equal(t, common.EVENT_TYPE_ALARM, notificationService.notifications[0].GetType())
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	logging "github.com/op/go-logging"
+)
+
+type AnomalyDetector interface {
+	Detect(deviceID uint64, metric config.Metric, value float64, timestamp time.Time) []Anomaly
+	Reset(deviceID uint64, metricKey string)
+}
+
+// Anomaly describes a statistically unusual metric value detected
+// in the poll stream.
+type Anomaly struct {
+	DeviceID  uint64    `json:"device_id"`
+	MetricKey string    `json:"metric"`
+	Type      string    `json:"type"`
+	Value     float64   `json:"value"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type metricSample struct {
+	value     float64
+	timestamp time.Time
+}
+
+// metricStream holds the incremental statistics for a single device metric.
+// The z-score window is a fixed size ring buffer with a running sum and sum of
+// squares so the mean and standard deviation can be calculated in constant time.
+type metricStream struct {
+	window      []float64
+	windowIndex int
+	windowCount int
+	sum         float64
+	sumSquares  float64
+	history     []metricSample
+	lastValue   float64
+	lastChanged time.Time
+	initialized bool
+	notified    map[string]time.Time
+}
+
+type DefaultAnomalyDetector struct {
+	logger  *logging.Logger
+	streams map[uint64]map[string]*metricStream
+	mutex   *sync.Mutex
+	AnomalyDetector
+}
+
+// Creates a new anomaly detector that keeps rolling statistics for each
+// device metric in memory.
+func NewAnomalyDetector(logger *logging.Logger) AnomalyDetector {
+	return &DefaultAnomalyDetector{
+		logger:  logger,
+		streams: make(map[uint64]map[string]*metricStream, 0),
+		mutex:   &sync.Mutex{}}
+}
+
+// Adds the value to the metric's rolling statistics and returns any anomalies
+// that should be raised. Anomalies that have already been raised are suppressed
+// until the metric's anomaly backoff expires or the anomaly clears.
+func (detector *DefaultAnomalyDetector) Detect(deviceID uint64, metric config.Metric,
+	value float64, timestamp time.Time) []Anomaly {
+
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+
+	anomalies := make([]Anomaly, 0)
+	if !metric.IsAnomalyDetectionEnabled() {
+		return anomalies
+	}
+
+	stream := detector.stream(deviceID, metric)
+	key := metric.GetKey()
+	name := metric.GetName()
+
+	// z-score is calculated against the window before the new
+	// value is added so an outlier does not skew its own baseline
+	if metric.GetAnomalyWindow() > 1 && metric.GetAnomalyZScore() > 0 {
+		active := false
+		if stream.windowCount == len(stream.window) {
+			mean := stream.sum / float64(stream.windowCount)
+			variance := (stream.sumSquares / float64(stream.windowCount)) - (mean * mean)
+			stddev := math.Sqrt(math.Max(variance, 0))
+			if stddev > 0 {
+				zscore := (value - mean) / stddev
+				if math.Abs(zscore) >= metric.GetAnomalyZScore() {
+					active = true
+					message := fmt.Sprintf("%s value %.2f is %.1f standard deviations from the mean (%.2f)",
+						name, value, zscore, mean)
+					anomalies = detector.raise(anomalies, stream, metric, deviceID,
+						common.ANOMALY_TYPE_ZSCORE, value, message, timestamp)
+				}
+			}
+		}
+		if !active {
+			delete(stream.notified, common.ANOMALY_TYPE_ZSCORE)
+		}
+		stream.push(value)
+	}
+
+	if metric.GetRateOfChange() > 0 && metric.GetRateOfChangeWindow() > 0 {
+		active := false
+		window := time.Duration(metric.GetRateOfChangeWindow()) * time.Minute
+		stream.prune(timestamp.Add(-window))
+		if len(stream.history) > 0 {
+			oldest := stream.history[0]
+			delta := value - oldest.value
+			if math.Abs(delta) >= metric.GetRateOfChange() {
+				active = true
+				direction := "rose"
+				if delta < 0 {
+					direction = "fell"
+				}
+				minutes := timestamp.Sub(oldest.timestamp).Minutes()
+				message := fmt.Sprintf("%s %s %.2f in %.0f minutes", name, direction, math.Abs(delta), minutes)
+				anomalies = detector.raise(anomalies, stream, metric, deviceID,
+					common.ANOMALY_TYPE_RATE_OF_CHANGE, value, message, timestamp)
+			}
+		}
+		if !active {
+			delete(stream.notified, common.ANOMALY_TYPE_RATE_OF_CHANGE)
+		}
+		stream.history = append(stream.history, metricSample{value: value, timestamp: timestamp})
+	}
+
+	if !stream.initialized || value != stream.lastValue {
+		stream.lastValue = value
+		stream.lastChanged = timestamp
+		stream.initialized = true
+		delete(stream.notified, common.ANOMALY_TYPE_FLATLINE)
+	} else if metric.GetFlatlineWindow() > 0 {
+		unchanged := timestamp.Sub(stream.lastChanged)
+		if unchanged >= time.Duration(metric.GetFlatlineWindow())*time.Minute {
+			message := fmt.Sprintf("%s sensor flatlined at %.2f for %s", name, value,
+				unchanged.Round(time.Minute).String())
+			anomalies = detector.raise(anomalies, stream, metric, deviceID,
+				common.ANOMALY_TYPE_FLATLINE, value, message, timestamp)
+		}
+	}
+
+	detector.logger.Debugf("deviceID=%d, metric=%s, value=%.2f, anomalies=%d",
+		deviceID, key, value, len(anomalies))
+
+	return anomalies
+}
+
+// Discards the rolling statistics for the specified device metric
+func (detector *DefaultAnomalyDetector) Reset(deviceID uint64, metricKey string) {
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+	if metrics, ok := detector.streams[deviceID]; ok {
+		delete(metrics, metricKey)
+	}
+}
+
+// Returns the stream for the requested metric, creating a new one if this is the
+// first value seen or the z-score window size has been reconfigured.
+func (detector *DefaultAnomalyDetector) stream(deviceID uint64, metric config.Metric) *metricStream {
+	metrics, ok := detector.streams[deviceID]
+	if !ok {
+		metrics = make(map[string]*metricStream, 0)
+		detector.streams[deviceID] = metrics
+	}
+	windowSize := metric.GetAnomalyWindow()
+	if windowSize < 0 {
+		windowSize = 0
+	}
+	stream, ok := metrics[metric.GetKey()]
+	if !ok || len(stream.window) != windowSize {
+		stream = &metricStream{
+			window:   make([]float64, windowSize),
+			history:  make([]metricSample, 0),
+			notified: make(map[string]time.Time, 0)}
+		metrics[metric.GetKey()] = stream
+	}
+	return stream
+}
+
+// Appends the anomaly to the list unless a notification for the same
+// anomaly type was raised within the metric's backoff period.
+func (detector *DefaultAnomalyDetector) raise(anomalies []Anomaly, stream *metricStream,
+	metric config.Metric, deviceID uint64, anomalyType string, value float64,
+	message string, timestamp time.Time) []Anomaly {
+
+	backoff := metric.GetAnomalyBackoff()
+	if backoff <= 0 {
+		backoff = common.DEFAULT_ANOMALY_BACKOFF
+	}
+	if last, ok := stream.notified[anomalyType]; ok {
+		if timestamp.Sub(last) < time.Duration(backoff)*time.Minute {
+			detector.logger.Debugf("Suppressing repeat %s anomaly for %s", anomalyType, metric.GetKey())
+			return anomalies
+		}
+	}
+	stream.notified[anomalyType] = timestamp
+	return append(anomalies, Anomaly{
+		DeviceID:  deviceID,
+		MetricKey: metric.GetKey(),
+		Type:      anomalyType,
+		Value:     value,
+		Message:   message,
+		Timestamp: timestamp})
+}
+
+// Adds a value to the z-score ring buffer, updating the running sums
+func (stream *metricStream) push(value float64) {
+	if len(stream.window) == 0 {
+		return
+	}
+	if stream.windowCount == len(stream.window) {
+		evicted := stream.window[stream.windowIndex]
+		stream.sum -= evicted
+		stream.sumSquares -= evicted * evicted
+	} else {
+		stream.windowCount++
+	}
+	stream.window[stream.windowIndex] = value
+	stream.sum += value
+	stream.sumSquares += value * value
+	stream.windowIndex = (stream.windowIndex + 1) % len(stream.window)
+}
+
+// Removes rate of change samples older than the specified time
+func (stream *metricStream) prune(since time.Time) {
+	i := 0
+	for i < len(stream.history) && stream.history[i].timestamp.Before(since) {
+		i++
+	}
+	stream.history = stream.history[i:]
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnomalyDetectorZScore(t *testing.T) {
+
+	detector := NewAnomalyDetector(logging.MustGetLogger("anomaly_test"))
+
+	metric := &config.MetricStruct{
+		Key:           "humidity0",
+		Name:          "Humidity",
+		AnomalyWindow: 5,
+		AnomalyZScore: 3}
+
+	now := time.Now()
+	for i, value := range []float64{50, 51, 49, 50, 51} {
+		anomalies := detector.Detect(1, metric, value, now.Add(time.Duration(i)*time.Minute))
+		assert.Equal(t, 0, len(anomalies))
+	}
+
+	anomalies := detector.Detect(1, metric, 80, now.Add(6*time.Minute))
+	assert.Equal(t, 1, len(anomalies))
+	assert.Equal(t, common.ANOMALY_TYPE_ZSCORE, anomalies[0].Type)
+	assert.Equal(t, "humidity0", anomalies[0].MetricKey)
+
+	// Repeat is suppressed until the backoff expires
+	anomalies = detector.Detect(1, metric, 95, now.Add(7*time.Minute))
+	assert.Equal(t, 0, len(anomalies))
+}
+
+func TestAnomalyDetectorRateOfChange(t *testing.T) {
+
+	detector := NewAnomalyDetector(logging.MustGetLogger("anomaly_test"))
+
+	metric := &config.MetricStruct{
+		Key:                "resTemp",
+		Name:               "Reservoir Temperature",
+		RateOfChange:       5,
+		RateOfChangeWindow: 10,
+		AnomalyBackoff:     30}
+
+	now := time.Now()
+	assert.Equal(t, 0, len(detector.Detect(1, metric, 65, now)))
+	assert.Equal(t, 0, len(detector.Detect(1, metric, 67, now.Add(5*time.Minute))))
+
+	anomalies := detector.Detect(1, metric, 70.5, now.Add(9*time.Minute))
+	assert.Equal(t, 1, len(anomalies))
+	assert.Equal(t, common.ANOMALY_TYPE_RATE_OF_CHANGE, anomalies[0].Type)
+	assert.Equal(t, "Reservoir Temperature rose 5.50 in 9 minutes", anomalies[0].Message)
+
+	// Samples outside the window are ignored
+	assert.Equal(t, 0, len(detector.Detect(1, metric, 71, now.Add(30*time.Minute))))
+}
+
+func TestAnomalyDetectorFlatline(t *testing.T) {
+
+	detector := NewAnomalyDetector(logging.MustGetLogger("anomaly_test"))
+
+	metric := &config.MetricStruct{
+		Key:            "ph",
+		Name:           "pH",
+		FlatlineWindow: 120,
+		AnomalyBackoff: 60}
+
+	now := time.Now()
+	assert.Equal(t, 0, len(detector.Detect(1, metric, 5.8, now)))
+	assert.Equal(t, 0, len(detector.Detect(1, metric, 5.8, now.Add(time.Hour))))
+
+	anomalies := detector.Detect(1, metric, 5.8, now.Add(2*time.Hour))
+	assert.Equal(t, 1, len(anomalies))
+	assert.Equal(t, common.ANOMALY_TYPE_FLATLINE, anomalies[0].Type)
+
+	assert.Equal(t, 0, len(detector.Detect(1, metric, 5.8, now.Add(150*time.Minute))))
+	assert.Equal(t, 1, len(detector.Detect(1, metric, 5.8, now.Add(3*time.Hour))))
+
+	// A changed value clears the flatline
+	assert.Equal(t, 0, len(detector.Detect(1, metric, 5.9, now.Add(4*time.Hour))))
+}
+
+func TestAnomalyDetectorDisabled(t *testing.T) {
+	detector := NewAnomalyDetector(logging.MustGetLogger("anomaly_test"))
+	metric := &config.MetricStruct{Key: "tempF0", Name: "Temperature"}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, 0, len(detector.Detect(1, metric, float64(i*100), time.Now())))
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrAPIKeyNotFound             = errors.New("api key not found")
+	ErrInvalidAPIKey              = errors.New("invalid api key")
+	ErrAPIKeyRevoked              = errors.New("api key revoked")
+	ErrAPIKeyExpired              = errors.New("api key expired")
+	ErrInvalidServiceAccount      = errors.New("invalid service account name")
+	ErrAPIKeyPermissionsRequired  = errors.New("api key requires at least one permission")
+	ErrUnknownPermission          = errors.New("unknown permission")
+	ErrAPIKeyFarmAccessDenied     = errors.New("api key can't be scoped to a farm the user doesn't belong to")
+	ErrAPIKeyPermissionEscalation = errors.New("api key can't be granted a permission the user doesn't have")
+	ErrInvalidAPIKeyExpiration    = errors.New("api key expiration must not be negative")
+	ErrAPIKeyCreatorDisabled      = errors.New("api key creator is disabled")
+	ErrAPIKeyCreatorNotFarmMember = errors.New("api key creator is no longer a member of the farm")
+
+	serviceAccountPattern = regexp.MustCompile(common.API_KEY_SERVICE_ACCOUNT_PATTERN)
+)
+
+// APIKeyRequest describes a new API key. Farms and permissions limit what the
+// key can access; a key can't be granted access the creating user doesn't
+// have. The key never expires if ExpiresInDays is zero.
+type APIKeyRequest struct {
+	Name           string   `json:"name"`
+	ServiceAccount string   `json:"service_account"`
+	Farms          []uint64 `json:"farms"`
+	Permissions    []string `json:"permissions"`
+	ExpiresInDays  int      `json:"expires_in_days"`
+}
+
+type APIKeyService interface {
+	Create(session Session, request APIKeyRequest) (*entity.APIKey, string, error)
+	List(session Session) ([]*entity.APIKey, error)
+	Revoke(session Session, id uint64) (*entity.APIKey, error)
+	RevokeUser(session Session, userID uint64) (int, error)
+	Authenticate(token, remoteAddress string) (*entity.APIKey, error)
+}
+
+type DefaultAPIKeyService struct {
+	logger          *logging.Logger
+	apiKeyDAO       dao.APIKeyDAO
+	userDAO         dao.UserDAO
+	farmDAO         dao.FarmDAO
+	serviceRegistry ServiceRegistry
+	clock           func() time.Time
+	APIKeyService
+}
+
+// Creates a new API key service that issues, lists, revokes and authenticates
+// service account API keys. Only a hash of each key secret is stored; the key
+// itself is returned once, when it's created.
+func NewAPIKeyService(
+	logger *logging.Logger,
+	apiKeyDAO dao.APIKeyDAO,
+	userDAO dao.UserDAO,
+	farmDAO dao.FarmDAO,
+	serviceRegistry ServiceRegistry) APIKeyService {
+
+	return &DefaultAPIKeyService{
+		logger:          logger,
+		apiKeyDAO:       apiKeyDAO,
+		userDAO:         userDAO,
+		farmDAO:         farmDAO,
+		serviceRegistry: serviceRegistry,
+		clock:           time.Now}
+}
+
+// Returns true if the token is formatted as an API key rather than a JWT
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, common.API_KEY_PREFIX+"_")
+}
+
+// Creates a new API key for a service account, scoped to the requested farms
+// and permissions. Each permission must be held by the session user and, for
+// farm scoped keys, granted to the user by their role in every requested farm.
+// Returns the stored key and the API key token, which is only available at
+// creation.
+func (service *DefaultAPIKeyService) Create(session Session,
+	request APIKeyRequest) (*entity.APIKey, string, error) {
+
+	if !serviceAccountPattern.MatchString(request.ServiceAccount) {
+		return nil, "", ErrInvalidServiceAccount
+	}
+	if len(request.Permissions) == 0 {
+		return nil, "", ErrAPIKeyPermissionsRequired
+	}
+	if request.ExpiresInDays < 0 {
+		return nil, "", ErrInvalidAPIKeyExpiration
+	}
+	for _, permission := range request.Permissions {
+		if !service.isCatalogPermission(permission) {
+			return nil, "", fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+		}
+		if !session.HasPermission(permission) {
+			return nil, "", fmt.Errorf("%w: %s", ErrAPIKeyPermissionEscalation, permission)
+		}
+	}
+	farms := make([]string, len(request.Farms))
+	for i, farmID := range request.Farms {
+		if err := service.verifyFarmAccess(session, farmID, request.Permissions); err != nil {
+			return nil, "", err
+		}
+		farms[i] = strconv.FormatUint(farmID, 10)
+	}
+
+	secret := make([]byte, common.API_KEY_SECRET_LENGTH)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	encodedSecret := hex.EncodeToString(secret)
+
+	now := service.clock()
+	apiKey := &entity.APIKey{
+		Name:           request.Name,
+		ServiceAccount: request.ServiceAccount,
+		CreatedBy:      session.GetUser().Identifier(),
+		Hash:           hashSecret(encodedSecret),
+		Farms:          strings.Join(farms, ","),
+		Permissions:    strings.Join(request.Permissions, ","),
+		CreatedAt:      now}
+	if request.ExpiresInDays > 0 {
+		apiKey.ExpiresAt = now.AddDate(0, 0, request.ExpiresInDays)
+	}
+	if err := service.apiKeyDAO.Save(apiKey); err != nil {
+		return nil, "", err
+	}
+	token := fmt.Sprintf("%s_%d_%s", common.API_KEY_PREFIX, apiKey.ID, encodedSecret)
+
+	service.logger.Infof("API key %d created for service account %s by %s",
+		apiKey.ID, apiKey.ServiceAccount, session.GetUser().GetEmail())
+	recordAudit(service.logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_APIKEY_CREATE, "apikey", apiKey.ID, nil, apiKey.Redact())
+
+	return apiKey.Redact(), token, nil
+}
+
+// Returns the API keys created by the session user, newest first
+func (service *DefaultAPIKeyService) List(session Session) ([]*entity.APIKey, error) {
+	apiKeys, err := service.apiKeyDAO.GetByCreatedBy(
+		session.GetUser().Identifier(), common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(apiKeys, func(i, j int) bool {
+		return apiKeys[i].CreatedAt.After(apiKeys[j].CreatedAt)
+	})
+	redacted := make([]*entity.APIKey, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		redacted[i] = apiKey.Redact()
+	}
+	return redacted, nil
+}
+
+// Revokes an API key created by the session user
+func (service *DefaultAPIKeyService) Revoke(session Session, id uint64) (*entity.APIKey, error) {
+	apiKey, err := service.apiKeyDAO.Get(id, common.CONSISTENCY_LOCAL)
+	if err != nil || apiKey == nil || apiKey.ID == 0 {
+		return nil, ErrAPIKeyNotFound
+	}
+	if apiKey.GetCreatedBy() != session.GetUser().Identifier() {
+		return nil, ErrPermissionDenied
+	}
+	if apiKey.IsRevoked() {
+		return apiKey.Redact(), nil
+	}
+	before := apiKey.Redact()
+	apiKey.RevokedAt = service.clock()
+	if err := service.apiKeyDAO.Save(apiKey); err != nil {
+		return nil, err
+	}
+	service.logger.Infof("API key %d for service account %s revoked by %s",
+		apiKey.ID, apiKey.ServiceAccount, session.GetUser().GetEmail())
+	recordAudit(service.logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_APIKEY_REVOKE, "apikey", apiKey.ID, before, apiKey.Redact())
+	return apiKey.Redact(), nil
+}
+
+// Revokes all of the API keys created by the user. Used when the user is
+// disabled or deleted. Returns the number of keys revoked.
+func (service *DefaultAPIKeyService) RevokeUser(session Session, userID uint64) (int, error) {
+	apiKeys, err := service.apiKeyDAO.GetByCreatedBy(userID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, apiKey := range apiKeys {
+		if apiKey.IsRevoked() {
+			continue
+		}
+		before := apiKey.Redact()
+		apiKey.RevokedAt = service.clock()
+		if err := service.apiKeyDAO.Save(apiKey); err != nil {
+			return revoked, err
+		}
+		revoked++
+		recordAudit(service.logger, service.serviceRegistry, session,
+			common.AUDIT_ACTION_APIKEY_REVOKE, "apikey", apiKey.ID, before, apiKey.Redact())
+	}
+	service.logger.Infof("Revoked %d API keys for user %d", revoked, userID)
+	return revoked, nil
+}
+
+// Authenticates an API key token, returning the key if the secret matches, the
+// key is neither revoked nor expired, and the user that created it is still
+// enabled and a member of every farm the key is scoped to. The key's last-used
+// time and address are updated at most once per API_KEY_LAST_USED_INTERVAL.
+func (service *DefaultAPIKeyService) Authenticate(token, remoteAddress string) (*entity.APIKey, error) {
+	parts := strings.SplitN(token, "_", 3)
+	if len(parts) != 3 || parts[0] != common.API_KEY_PREFIX {
+		return nil, ErrInvalidAPIKey
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	apiKey, err := service.apiKeyDAO.Get(id, common.CONSISTENCY_LOCAL)
+	if err != nil || apiKey == nil || apiKey.ID == 0 {
+		return nil, ErrInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(parts[2])), []byte(apiKey.Hash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	now := service.clock()
+	if apiKey.IsRevoked() {
+		return nil, ErrAPIKeyRevoked
+	}
+	if apiKey.IsExpired(now) {
+		return nil, ErrAPIKeyExpired
+	}
+	if err := service.verifyCreator(apiKey); err != nil {
+		return nil, err
+	}
+	if now.Sub(apiKey.LastUsedAt) >= common.API_KEY_LAST_USED_INTERVAL*time.Second {
+		apiKey.LastUsedAt = now
+		apiKey.LastUsedAddress = remoteAddress
+		if err := service.apiKeyDAO.Save(apiKey); err != nil {
+			service.logger.Errorf("Error updating API key %d last used time: %s", apiKey.ID, err)
+		}
+	}
+	return apiKey, nil
+}
+
+// Returns an error unless the session user is a member of the farm and their
+// roles in the farm grant every requested permission
+func (service *DefaultAPIKeyService) verifyFarmAccess(session Session,
+	farmID uint64, permissions []string) error {
+
+	farm, err := service.farmDAO.Get(farmID, common.CONSISTENCY_LOCAL)
+	if err != nil || farm == nil {
+		return fmt.Errorf("%w: %d", ErrAPIKeyFarmAccessDenied, farmID)
+	}
+	user := session.GetUser()
+	farmUser := service.farmUser(farm, user.Identifier(), user.GetEmail())
+	if farmUser == nil {
+		return fmt.Errorf("%w: %d", ErrAPIKeyFarmAccessDenied, farmID)
+	}
+	granted := make(map[string]bool)
+	for _, role := range farmUser.GetRoles() {
+		for _, permission := range RolePermissions(role) {
+			if permission != common.PERMISSION_SYSTEM_ADMIN {
+				granted[permission] = true
+			}
+		}
+	}
+	for _, permission := range permissions {
+		if !granted[permission] {
+			return fmt.Errorf("%w: %s in farm %d", ErrAPIKeyPermissionEscalation, permission, farmID)
+		}
+	}
+	return nil
+}
+
+// Returns an error if the user that created the API key has been disabled,
+// deleted, or removed from one of the farms the key is scoped to
+func (service *DefaultAPIKeyService) verifyCreator(apiKey *entity.APIKey) error {
+	creator, err := service.userDAO.Get(apiKey.GetCreatedBy(), common.CONSISTENCY_LOCAL)
+	if err != nil || creator == nil || creator.IsDisabled() {
+		return ErrAPIKeyCreatorDisabled
+	}
+	for _, farmID := range apiKey.GetFarmIDs() {
+		farm, err := service.farmDAO.Get(farmID, common.CONSISTENCY_LOCAL)
+		if err != nil || farm == nil ||
+			service.farmUser(farm, creator.Identifier(), creator.GetEmail()) == nil {
+			return fmt.Errorf("%w: %d", ErrAPIKeyCreatorNotFarmMember, farmID)
+		}
+	}
+	return nil
+}
+
+// Returns the farm's user with the given ID or email, or nil if the user
+// isn't a member of the farm
+func (service *DefaultAPIKeyService) farmUser(farm *config.FarmStruct,
+	userID uint64, email string) *config.UserStruct {
+
+	for _, user := range farm.GetUsers() {
+		if user.Identifier() == userID || user.GetEmail() == email {
+			return user
+		}
+	}
+	return nil
+}
+
+func (service *DefaultAPIKeyService) isCatalogPermission(permission string) bool {
+	for _, p := range common.PermissionCatalog {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
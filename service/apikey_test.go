@@ -0,0 +1,269 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAPIKeyDAO struct {
+	apiKeys map[uint64]*entity.APIKey
+	nextID  uint64
+	dao.APIKeyDAO
+}
+
+func (apiKeyDAO *fakeAPIKeyDAO) Save(apiKey *entity.APIKey) error {
+	if apiKey.ID == 0 {
+		apiKeyDAO.nextID++
+		apiKey.ID = apiKeyDAO.nextID
+	}
+	stored := *apiKey
+	apiKeyDAO.apiKeys[apiKey.ID] = &stored
+	return nil
+}
+
+func (apiKeyDAO *fakeAPIKeyDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.APIKey, error) {
+	apiKey, ok := apiKeyDAO.apiKeys[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	persisted := *apiKey
+	return &persisted, nil
+}
+
+func (apiKeyDAO *fakeAPIKeyDAO) GetByCreatedBy(userID uint64, CONSISTENCY_LEVEL int) ([]*entity.APIKey, error) {
+	apiKeys := make([]*entity.APIKey, 0)
+	for _, apiKey := range apiKeyDAO.apiKeys {
+		if apiKey.CreatedBy == userID {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+	return apiKeys, nil
+}
+
+// Farm 1 has user 5 as an analyst and user 7 as a cultivator
+type fakeAPIKeyFarmDAO struct {
+	dao.FarmDAO
+}
+
+func (farmDAO *fakeAPIKeyFarmDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.FarmStruct, error) {
+	if id != 1 {
+		return nil, errors.New("record not found")
+	}
+	farm := config.NewFarm()
+	farm.ID = id
+	farm.SetUsers([]*config.UserStruct{
+		{ID: 5, Email: "user5@localhost",
+			Roles: []*config.RoleStruct{{Name: common.ROLE_ANALYST}}},
+		{ID: 7, Email: "user7@localhost",
+			Roles: []*config.RoleStruct{{Name: common.ROLE_CULTIVATOR}}}})
+	return farm, nil
+}
+
+func (farmDAO *fakeAPIKeyFarmDAO) GetByUserID(userID uint64, CONSISTENCY_LEVEL int) ([]*config.FarmStruct, error) {
+	return []*config.FarmStruct{}, nil
+}
+
+func newAPIKeyTestUserDAO() *fakeOIDCUserDAO {
+	return &fakeOIDCUserDAO{users: map[uint64]*config.UserStruct{
+		5: {ID: 5, Email: "user5@localhost"},
+		6: {ID: 6, Email: "user6@localhost"},
+		7: {ID: 7, Email: "user7@localhost"}}}
+}
+
+func apiKeyTestSession(userID uint64, permissions ...string) Session {
+	return CreateSession(logging.MustGetLogger("apikey_test"), nil,
+		[]FarmClaim{{ID: 1}}, nil, 0, 0, common.CONSISTENCY_LOCAL,
+		&model.UserStruct{
+			ID:    userID,
+			Email: fmt.Sprintf("user%d@localhost", userID),
+			Roles: []model.Role{&model.RoleStruct{
+				Name:        common.ROLE_CULTIVATOR,
+				Permissions: permissions}}})
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	apiKeyDAO := &fakeAPIKeyDAO{apiKeys: make(map[uint64]*entity.APIKey)}
+	apiKeyService := NewAPIKeyService(logging.MustGetLogger("apikey_test"),
+		apiKeyDAO, newAPIKeyTestUserDAO(), &fakeAPIKeyFarmDAO{}, nil)
+	apiKeyService.(*DefaultAPIKeyService).clock = func() time.Time { return now }
+
+	session := apiKeyTestSession(5, common.PERMISSION_FARM_READ, common.PERMISSION_APIKEY_MANAGE)
+	request := APIKeyRequest{
+		Name:           "grafana",
+		ServiceAccount: "grafana",
+		Farms:          []uint64{1},
+		Permissions:    []string{common.PERMISSION_FARM_READ},
+		ExpiresInDays:  30}
+
+	invalid := request
+	invalid.ServiceAccount = "Grafana Dashboards"
+	_, _, err := apiKeyService.Create(session, invalid)
+	assert.ErrorIs(t, err, ErrInvalidServiceAccount)
+
+	invalid = request
+	invalid.Permissions = []string{"farm:destroy"}
+	_, _, err = apiKeyService.Create(session, invalid)
+	assert.ErrorIs(t, err, ErrUnknownPermission)
+
+	// A key can't be granted more than the user that creates it
+	invalid = request
+	invalid.Permissions = []string{common.PERMISSION_DEVICE_SWITCH}
+	_, _, err = apiKeyService.Create(session, invalid)
+	assert.ErrorIs(t, err, ErrAPIKeyPermissionEscalation)
+
+	invalid = request
+	invalid.Farms = []uint64{2}
+	_, _, err = apiKeyService.Create(session, invalid)
+	assert.ErrorIs(t, err, ErrAPIKeyFarmAccessDenied)
+
+	// Permissions granted outside the farm don't carry into a farm scoped key
+	switcher := apiKeyTestSession(5, common.PERMISSION_FARM_READ, common.PERMISSION_DEVICE_SWITCH)
+	invalid = request
+	invalid.Permissions = []string{common.PERMISSION_DEVICE_SWITCH}
+	_, _, err = apiKeyService.Create(switcher, invalid)
+	assert.ErrorIs(t, err, ErrAPIKeyPermissionEscalation)
+	_, _, err = apiKeyService.Create(apiKeyTestSession(7, common.PERMISSION_DEVICE_SWITCH), invalid)
+	assert.Nil(t, err)
+	delete(apiKeyDAO.apiKeys, apiKeyDAO.nextID)
+
+	// System administrators can't scope keys to farms they don't belong to
+	_, _, err = apiKeyService.Create(apiKeyTestSession(6, common.PERMISSION_SYSTEM_ADMIN,
+		common.PERMISSION_FARM_READ), request)
+	assert.ErrorIs(t, err, ErrAPIKeyFarmAccessDenied)
+
+	apiKey, token, err := apiKeyService.Create(session, request)
+	assert.Nil(t, err)
+	assert.True(t, IsAPIKey(token))
+	assert.True(t, strings.HasPrefix(token, fmt.Sprintf("cdk_%d_", apiKey.ID)))
+	assert.Empty(t, apiKey.Hash)
+	assert.Equal(t, []uint64{1}, apiKey.GetFarmIDs())
+	assert.Equal(t, now.AddDate(0, 0, 30), apiKey.ExpiresAt)
+
+	// Only a hash of the secret is stored
+	stored := apiKeyDAO.apiKeys[apiKey.ID]
+	assert.NotEmpty(t, stored.Hash)
+	assert.False(t, strings.Contains(token, stored.Hash))
+
+	authenticated, err := apiKeyService.Authenticate(token, "10.0.0.1:5000")
+	assert.Nil(t, err)
+	assert.Equal(t, "grafana", authenticated.GetServiceAccount())
+	assert.Equal(t, []string{common.PERMISSION_FARM_READ}, authenticated.GetPermissionList())
+	assert.Equal(t, now, apiKeyDAO.apiKeys[apiKey.ID].LastUsedAt)
+	assert.Equal(t, "10.0.0.1:5000", apiKeyDAO.apiKeys[apiKey.ID].LastUsedAddress)
+
+	tampered := token[:len(token)-1] + "0"
+	if tampered == token {
+		tampered = token[:len(token)-1] + "1"
+	}
+	_, err = apiKeyService.Authenticate(tampered, "10.0.0.1:5000")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = apiKeyService.Authenticate("cdk_x_secret", "10.0.0.1:5000")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = apiKeyService.Authenticate("cdk_99_secret", "10.0.0.1:5000")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	apiKeys, err := apiKeyService.List(session)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(apiKeys))
+	assert.Empty(t, apiKeys[0].Hash)
+
+	otherUser := apiKeyTestSession(6, common.PERMISSION_APIKEY_MANAGE)
+	apiKeys, err = apiKeyService.List(otherUser)
+	assert.Nil(t, err)
+	assert.Empty(t, apiKeys)
+
+	_, err = apiKeyService.Revoke(otherUser, apiKey.ID)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	// Keys are only visible to and revocable by the user that created them
+	systemAdmin := apiKeyTestSession(6, common.PERMISSION_SYSTEM_ADMIN)
+	apiKeys, err = apiKeyService.List(systemAdmin)
+	assert.Nil(t, err)
+	assert.Empty(t, apiKeys)
+	_, err = apiKeyService.Revoke(systemAdmin, apiKey.ID)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = apiKeyService.Revoke(session, 99)
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	revoked, err := apiKeyService.Revoke(session, apiKey.ID)
+	assert.Nil(t, err)
+	assert.True(t, revoked.IsRevoked())
+
+	_, err = apiKeyService.Authenticate(token, "10.0.0.1:5000")
+	assert.ErrorIs(t, err, ErrAPIKeyRevoked)
+}
+
+func TestAPIKeyExpiration(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	apiKeyDAO := &fakeAPIKeyDAO{apiKeys: make(map[uint64]*entity.APIKey)}
+	apiKeyService := NewAPIKeyService(logging.MustGetLogger("apikey_test"),
+		apiKeyDAO, newAPIKeyTestUserDAO(), &fakeAPIKeyFarmDAO{}, nil)
+	apiKeyService.(*DefaultAPIKeyService).clock = func() time.Time { return now }
+
+	session := apiKeyTestSession(5, common.PERMISSION_FARM_READ)
+	_, token, err := apiKeyService.Create(session, APIKeyRequest{
+		ServiceAccount: "exporter",
+		Permissions:    []string{common.PERMISSION_FARM_READ},
+		ExpiresInDays:  1})
+	assert.Nil(t, err)
+
+	_, err = apiKeyService.Authenticate(token, "10.0.0.1:5000")
+	assert.Nil(t, err)
+
+	now = now.Add(25 * time.Hour)
+	_, err = apiKeyService.Authenticate(token, "10.0.0.1:5000")
+	assert.ErrorIs(t, err, ErrAPIKeyExpired)
+}
+
+func TestAPIKeyCreatorAccess(t *testing.T) {
+	apiKeyDAO := &fakeAPIKeyDAO{apiKeys: make(map[uint64]*entity.APIKey)}
+	userDAO := newAPIKeyTestUserDAO()
+	farm := config.NewFarm()
+	farm.ID = 1
+	farm.SetUsers([]*config.UserStruct{{ID: 5, Email: "user5@localhost",
+		Roles: []*config.RoleStruct{{Name: common.ROLE_ANALYST}}}})
+	farmDAO := &fakeFarmDAO{farms: map[uint64]*config.FarmStruct{1: farm}}
+	apiKeyService := NewAPIKeyService(logging.MustGetLogger("apikey_test"),
+		apiKeyDAO, userDAO, farmDAO, nil)
+
+	session := apiKeyTestSession(5, common.PERMISSION_FARM_READ)
+	_, token, err := apiKeyService.Create(session, APIKeyRequest{
+		ServiceAccount: "grafana",
+		Farms:          []uint64{1},
+		Permissions:    []string{common.PERMISSION_FARM_READ}})
+	assert.Nil(t, err)
+	_, err = apiKeyService.Authenticate(token, "10.0.0.1:5000")
+	assert.Nil(t, err)
+
+	// Keys stop working when their creator is disabled...
+	userDAO.users[5].SetDisabled(true)
+	_, err = apiKeyService.Authenticate(token, "10.0.0.1:5000")
+	assert.ErrorIs(t, err, ErrAPIKeyCreatorDisabled)
+	userDAO.users[5].SetDisabled(false)
+	_, err = apiKeyService.Authenticate(token, "10.0.0.1:5000")
+	assert.Nil(t, err)
+
+	// ...removed from the key's farm...
+	farm.SetUsers([]*config.UserStruct{})
+	_, err = apiKeyService.Authenticate(token, "10.0.0.1:5000")
+	assert.ErrorIs(t, err, ErrAPIKeyCreatorNotFarmMember)
+	farm.SetUsers([]*config.UserStruct{{ID: 5, Email: "user5@localhost"}})
+
+	// ...or deleted
+	delete(userDAO.users, 5)
+	_, err = apiKeyService.Authenticate(token, "10.0.0.1:5000")
+	assert.ErrorIs(t, err, ErrAPIKeyCreatorDisabled)
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrAuditChainBroken      = errors.New("audit chain broken")
+	ErrAuditSignatureInvalid = errors.New("invalid audit entry signature")
+)
+
+// AuditSigner signs and verifies audit entry hashes. The application
+// certificate authority satisfies this interface.
+type AuditSigner interface {
+	Sign(data []byte) ([]byte, error)
+	VerifySignature(data []byte, signature []byte) error
+}
+
+type AuditService interface {
+	Record(session Session, action, objectType string, objectID uint64, before, after any) error
+	GetPage(pageQuery query.PageQuery) (dao.PageResult[*entity.AuditEntry], error)
+	Export(writer io.Writer) (int, error)
+	Verify() (int, error)
+}
+
+type DefaultAuditService struct {
+	logger *logging.Logger
+	dao    dao.AuditDAO
+	signer AuditSigner
+	mutex  *sync.Mutex
+	AuditService
+}
+
+// The fields of an audit entry covered by its hash, in a fixed order
+type auditHashContent struct {
+	ID             uint64 `json:"id"`
+	Timestamp      string `json:"timestamp"`
+	ActorID        uint64 `json:"actor_id"`
+	ActorEmail     string `json:"actor_email"`
+	SessionID      string `json:"session_id"`
+	OrganizationID uint64 `json:"organization_id"`
+	FarmID         uint64 `json:"farm_id"`
+	Action         string `json:"action"`
+	ObjectType     string `json:"object_type"`
+	ObjectID       uint64 `json:"object_id"`
+	Before         string `json:"before"`
+	After          string `json:"after"`
+	Changes        string `json:"changes"`
+	PrevHash       string `json:"prev_hash"`
+}
+
+// Records the action in the audit log if the registry provides an audit
+// service. Errors are logged rather than returned so a failure to audit
+// doesn't fail the audited operation.
+func recordAudit(logger *logging.Logger, serviceRegistry ServiceRegistry, session Session,
+	action, objectType string, objectID uint64, before, after any) {
+
+	if serviceRegistry == nil {
+		return
+	}
+	auditService := serviceRegistry.GetAuditService()
+	if auditService == nil {
+		return
+	}
+	if err := auditService.Record(session, action, objectType, objectID, before, after); err != nil {
+		logger.Errorf("Error recording %s audit entry: %s", action, err)
+	}
+}
+
+func NewAuditService(logger *logging.Logger, auditDAO dao.AuditDAO,
+	signer AuditSigner) AuditService {
+
+	return &DefaultAuditService{
+		logger: logger,
+		dao:    auditDAO,
+		signer: signer,
+		mutex:  &sync.Mutex{}}
+}
+
+// Appends a new entry to the audit log describing the action the session's
+// user performed on the object. The before and after states are stored as
+// JSON along with a field level diff. The entry is chained to the previous
+// entry's hash and signed.
+func (service *DefaultAuditService) Record(session Session, action, objectType string,
+	objectID uint64, before, after any) error {
+
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+	changes, err := json.Marshal(diffAuditJSON(beforeJSON, afterJSON))
+	if err != nil {
+		return err
+	}
+	entry := &entity.AuditEntry{
+		Timestamp:  time.Now().UTC().Truncate(time.Microsecond),
+		Action:     action,
+		ObjectType: objectType,
+		ObjectID:   objectID,
+		Before:     string(beforeJSON),
+		After:      string(afterJSON),
+		Changes:    string(changes)}
+	if session != nil {
+		entry.SessionID = session.GetSessionID()
+		entry.OrganizationID = session.GetRequestedOrganizationID()
+		entry.FarmID = session.GetRequestedFarmID()
+		if user := session.GetUser(); user != nil {
+			entry.ActorID = user.Identifier()
+			entry.ActorEmail = user.GetEmail()
+		}
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	// The datastore only appends the entry if it's still the next entry in
+	// the chain, so an entry recorded by another node in the meantime
+	// means the entry has to be chained to the new head and signed again
+	for attempt := 1; ; attempt++ {
+		err = service.append(entry)
+		if err != datastore.ErrAuditConflict || attempt == common.AUDIT_APPEND_ATTEMPTS {
+			break
+		}
+		service.logger.Warningf("Audit entry %d conflicts with the audit chain, retrying", entry.ID)
+	}
+	if err != nil {
+		service.logger.Errorf("Error saving audit entry: %s", err)
+		return err
+	}
+	service.logger.Debugf("Audit entry %d: action=%s, actor=%s, object=%s/%d",
+		entry.ID, action, entry.ActorEmail, objectType, objectID)
+	return nil
+}
+
+// Chains the entry to the entry at the head of the audit log, signs it
+// and appends it to the log
+func (service *DefaultAuditService) append(entry *entity.AuditEntry) error {
+	last, err := service.dao.GetLast(common.CONSISTENCY_QUORUM)
+	if err != nil && err != datastore.ErrRecordNotFound {
+		return err
+	}
+	entry.ID, entry.PrevHash = 1, ""
+	if last != nil {
+		entry.ID = last.ID + 1
+		entry.PrevHash = last.Hash
+	}
+	entry.Hash, err = auditHash(entry)
+	if err != nil {
+		return err
+	}
+	entry.Signature = ""
+	if service.signer != nil {
+		signature, err := service.signer.Sign([]byte(entry.Hash))
+		if err != nil {
+			return err
+		}
+		entry.Signature = base64.StdEncoding.EncodeToString(signature)
+	} else {
+		service.logger.Warningf("Audit signer not configured, entry %d is unsigned", entry.ID)
+	}
+	return service.dao.Save(entry)
+}
+
+// Returns a page of audit entries
+func (service *DefaultAuditService) GetPage(pageQuery query.PageQuery) (dao.PageResult[*entity.AuditEntry], error) {
+	return service.dao.GetPage(pageQuery, common.CONSISTENCY_LOCAL)
+}
+
+// Writes the audit log to the writer as JSON lines, oldest entry first,
+// and returns the number of entries written.
+func (service *DefaultAuditService) Export(writer io.Writer) (int, error) {
+	count := 0
+	encoder := json.NewEncoder(writer)
+	err := service.dao.ForEachPage(query.NewPageQuery(),
+		func(entries []*entity.AuditEntry) error {
+			for _, entry := range entries {
+				if err := encoder.Encode(entry); err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		}, common.CONSISTENCY_LOCAL)
+	return count, err
+}
+
+// Walks the audit log from the first entry and verifies the sequence
+// numbers, hash chain and signatures. Returns the number of verified
+// entries and an error describing the first entry that failed.
+func (service *DefaultAuditService) Verify() (int, error) {
+	verified := 0
+	var previous *entity.AuditEntry
+	err := service.dao.ForEachPage(query.NewPageQuery(),
+		func(entries []*entity.AuditEntry) error {
+			for _, entry := range entries {
+				if err := service.verifyEntry(previous, entry); err != nil {
+					return err
+				}
+				previous = entry
+				verified++
+			}
+			return nil
+		}, common.CONSISTENCY_LOCAL)
+	return verified, err
+}
+
+func (service *DefaultAuditService) verifyEntry(previous, entry *entity.AuditEntry) error {
+	expectedID, prevHash := uint64(1), ""
+	if previous != nil {
+		expectedID, prevHash = previous.ID+1, previous.Hash
+	}
+	if entry.ID != expectedID {
+		return fmt.Errorf("%w: expected entry %d, found %d", ErrAuditChainBroken, expectedID, entry.ID)
+	}
+	if entry.PrevHash != prevHash {
+		return fmt.Errorf("%w: entry %d previous hash mismatch", ErrAuditChainBroken, entry.ID)
+	}
+	hash, err := auditHash(entry)
+	if err != nil {
+		return err
+	}
+	if entry.Hash != hash {
+		return fmt.Errorf("%w: entry %d hash mismatch", ErrAuditChainBroken, entry.ID)
+	}
+	if service.signer == nil {
+		return nil
+	}
+	signature, err := base64.StdEncoding.DecodeString(entry.Signature)
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("%w: entry %d", ErrAuditSignatureInvalid, entry.ID)
+	}
+	if err := service.signer.VerifySignature([]byte(entry.Hash), signature); err != nil {
+		return fmt.Errorf("%w: entry %d", ErrAuditSignatureInvalid, entry.ID)
+	}
+	return nil
+}
+
+// Returns the hex encoded SHA-256 hash of the entry content and the
+// previous entry's hash
+func auditHash(entry *entity.AuditEntry) (string, error) {
+	content, err := json.Marshal(auditHashContent{
+		ID:             entry.ID,
+		Timestamp:      entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		ActorID:        entry.ActorID,
+		ActorEmail:     entry.ActorEmail,
+		SessionID:      entry.SessionID,
+		OrganizationID: entry.OrganizationID,
+		FarmID:         entry.FarmID,
+		Action:         entry.Action,
+		ObjectType:     entry.ObjectType,
+		ObjectID:       entry.ObjectID,
+		Before:         entry.Before,
+		After:          entry.After,
+		Changes:        entry.Changes,
+		PrevHash:       entry.PrevHash})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Returns the JSON encoding of the object, or nil if there isn't one.
+// Objects that are already JSON encoded are returned as-is.
+func auditJSON(object any) ([]byte, error) {
+	switch value := object.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return value, nil
+	case json.RawMessage:
+		return value, nil
+	}
+	if reflect.ValueOf(object).Kind() == reflect.Ptr && reflect.ValueOf(object).IsNil() {
+		return nil, nil
+	}
+	return json.Marshal(object)
+}
+
+// Returns the fields that differ between the before and after JSON
+// documents, sorted by path. Nested fields are separated by a dot and
+// array elements are addressed by their index.
+func diffAuditJSON(before, after []byte) []entity.AuditChange {
+	var beforeValue, afterValue any
+	if len(before) > 0 {
+		json.Unmarshal(before, &beforeValue)
+	}
+	if len(after) > 0 {
+		json.Unmarshal(after, &afterValue)
+	}
+	changes := make([]entity.AuditChange, 0)
+	diffAuditValue("", beforeValue, afterValue, &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diffAuditValue(path string, before, after any, changes *[]entity.AuditChange) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	beforeMap, beforeIsMap := before.(map[string]any)
+	afterMap, afterIsMap := after.(map[string]any)
+	if beforeIsMap && afterIsMap {
+		for key, value := range beforeMap {
+			diffAuditValue(join(key), value, afterMap[key], changes)
+		}
+		for key, value := range afterMap {
+			if _, ok := beforeMap[key]; !ok {
+				diffAuditValue(join(key), nil, value, changes)
+			}
+		}
+		return
+	}
+	beforeSlice, beforeIsSlice := before.([]any)
+	afterSlice, afterIsSlice := after.([]any)
+	if beforeIsSlice && afterIsSlice {
+		for i := 0; i < len(beforeSlice) || i < len(afterSlice); i++ {
+			var b, a any
+			if i < len(beforeSlice) {
+				b = beforeSlice[i]
+			}
+			if i < len(afterSlice) {
+				a = afterSlice[i]
+			}
+			diffAuditValue(join(strconv.Itoa(i)), b, a, changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, entity.AuditChange{
+			Path:   path,
+			Before: before,
+			After:  after})
+	}
+}
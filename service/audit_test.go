@@ -0,0 +1,261 @@
+package service
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuditDAO struct {
+	entries map[uint64]*entity.AuditEntry
+	// entries appended by other nodes before the next save
+	concurrent []*entity.AuditEntry
+	dao.AuditDAO
+}
+
+func (auditDAO *fakeAuditDAO) Save(entry *entity.AuditEntry) error {
+	for _, concurrent := range auditDAO.concurrent {
+		concurrent.ID, concurrent.PrevHash = 1, ""
+		if last, _ := auditDAO.GetLast(common.CONSISTENCY_LOCAL); last != nil {
+			concurrent.ID, concurrent.PrevHash = last.ID+1, last.Hash
+		}
+		concurrent.Hash, _ = auditHash(concurrent)
+		auditDAO.entries[concurrent.ID] = concurrent
+	}
+	auditDAO.concurrent = nil
+	last, _ := auditDAO.GetLast(common.CONSISTENCY_LOCAL)
+	if (last == nil && (entry.ID != 1 || entry.PrevHash != "")) ||
+		(last != nil && (entry.ID != last.ID+1 || entry.PrevHash != last.Hash)) {
+		return datastore.ErrAuditConflict
+	}
+	copy := *entry
+	auditDAO.entries[entry.ID] = &copy
+	return nil
+}
+
+func (auditDAO *fakeAuditDAO) GetLast(CONSISTENCY_LEVEL int) (*entity.AuditEntry, error) {
+	var last *entity.AuditEntry
+	for _, entry := range auditDAO.entries {
+		if last == nil || entry.ID > last.ID {
+			last = entry
+		}
+	}
+	if last == nil {
+		return nil, datastore.ErrRecordNotFound
+	}
+	return last, nil
+}
+
+func (auditDAO *fakeAuditDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*entity.AuditEntry], CONSISTENCY_LEVEL int) error {
+
+	entries := make([]*entity.AuditEntry, 0, len(auditDAO.entries))
+	for _, entry := range auditDAO.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return pagerProcFunc(entries)
+}
+
+type fakeAuditSigner struct {
+	key *rsa.PrivateKey
+}
+
+func (signer *fakeAuditSigner) Sign(data []byte) ([]byte, error) {
+	hashed := sha256.Sum256(data)
+	return rsa.SignPKCS1v15(rand.Reader, signer.key, crypto.SHA256, hashed[:])
+}
+
+func (signer *fakeAuditSigner) VerifySignature(data []byte, signature []byte) error {
+	hashed := sha256.Sum256(data)
+	return rsa.VerifyPKCS1v15(&signer.key.PublicKey, crypto.SHA256, hashed[:], signature)
+}
+
+type fakeAuditDeviceService struct {
+	DeviceServicer
+}
+
+func (deviceService *fakeAuditDeviceService) SetConfig(deviceConfig config.Device) error {
+	return nil
+}
+
+type fakeAuditRegistry struct {
+	auditService AuditService
+	ServiceRegistry
+}
+
+func (registry *fakeAuditRegistry) GetDeviceService(farmID uint64,
+	deviceType string) (DeviceServicer, error) {
+	return &fakeAuditDeviceService{}, nil
+}
+
+func (registry *fakeAuditRegistry) GetAuditService() AuditService {
+	return registry.auditService
+}
+
+func createAuditTestService(t *testing.T) (AuditService, *fakeAuditDAO, Session) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	auditDAO := &fakeAuditDAO{entries: make(map[uint64]*entity.AuditEntry)}
+	auditService := NewAuditService(logging.MustGetLogger("audit_test"),
+		auditDAO, &fakeAuditSigner{key: key})
+	session := CreateSession(logging.MustGetLogger("audit_test"), nil, nil, nil,
+		0, 1, common.CONSISTENCY_LOCAL, &model.UserStruct{ID: 5, Email: "grower@localhost"})
+	session.SetSessionID("abc123")
+	return auditService, auditDAO, session
+}
+
+func recordAuditTestEntries(t *testing.T, auditService AuditService, session Session) {
+	before := &config.DeviceStruct{ID: 10, Type: "room", Enable: true,
+		Channels: []*config.ChannelStruct{{ID: 1, Name: "Light", Duration: 0}}}
+	after := &config.DeviceStruct{ID: 10, Type: "room", Enable: true,
+		Channels: []*config.ChannelStruct{{ID: 1, Name: "Light", Duration: 60}}}
+	assert.Nil(t, auditService.Record(session, common.AUDIT_ACTION_DEVICE_CONFIG,
+		"device", 10, before, after))
+	assert.Nil(t, auditService.Record(session, common.AUDIT_ACTION_SWITCH, "channel", 1,
+		map[string]any{"position": common.SWITCH_OFF}, map[string]any{"position": common.SWITCH_ON}))
+	assert.Nil(t, auditService.Record(session, common.AUDIT_ACTION_USER_DELETE, "user", 7,
+		map[string]any{"email": "former@localhost"}, nil))
+}
+
+func TestAuditRecord(t *testing.T) {
+	auditService, auditDAO, session := createAuditTestService(t)
+	recordAuditTestEntries(t, auditService, session)
+
+	assert.Equal(t, 3, len(auditDAO.entries))
+	first := auditDAO.entries[1]
+	assert.Equal(t, uint64(5), first.ActorID)
+	assert.Equal(t, "grower@localhost", first.ActorEmail)
+	assert.Equal(t, "abc123", first.SessionID)
+	assert.Equal(t, uint64(1), first.FarmID)
+	assert.Equal(t, "", first.PrevHash)
+	assert.NotEmpty(t, first.Signature)
+
+	var changes []entity.AuditChange
+	assert.Nil(t, json.Unmarshal([]byte(first.Changes), &changes))
+	assert.Equal(t, []entity.AuditChange{
+		{Path: "channels.0.duration", Before: float64(0), After: float64(60)}}, changes)
+
+	assert.Equal(t, first.Hash, auditDAO.entries[2].PrevHash)
+	assert.Equal(t, auditDAO.entries[2].Hash, auditDAO.entries[3].PrevHash)
+	assert.Equal(t, "", auditDAO.entries[3].After)
+
+	count, err := auditService.Verify()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+
+	var export bytes.Buffer
+	count, err = auditService.Export(&export)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+	lines := strings.Split(strings.TrimSpace(export.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	var exported entity.AuditEntry
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &exported))
+	assert.Equal(t, common.AUDIT_ACTION_SWITCH, exported.Action)
+}
+
+func TestAuditVerifyDetectsTampering(t *testing.T) {
+
+	// Modified content
+	auditService, auditDAO, session := createAuditTestService(t)
+	recordAuditTestEntries(t, auditService, session)
+	auditDAO.entries[2].After = `{"position":0}`
+	count, err := auditService.Verify()
+	assert.ErrorIs(t, err, ErrAuditChainBroken)
+	assert.Equal(t, 1, count)
+
+	// Removed entry
+	auditService, auditDAO, session = createAuditTestService(t)
+	recordAuditTestEntries(t, auditService, session)
+	delete(auditDAO.entries, 2)
+	count, err = auditService.Verify()
+	assert.ErrorIs(t, err, ErrAuditChainBroken)
+	assert.Equal(t, 1, count)
+
+	// Rewritten entry with a recomputed hash but without the signing key
+	auditService, auditDAO, session = createAuditTestService(t)
+	recordAuditTestEntries(t, auditService, session)
+	entry := auditDAO.entries[3]
+	entry.ActorEmail = "someone@localhost"
+	entry.Hash, err = auditHash(entry)
+	assert.Nil(t, err)
+	count, err = auditService.Verify()
+	assert.ErrorIs(t, err, ErrAuditSignatureInvalid)
+	assert.Equal(t, 2, count)
+}
+
+func TestAuditRecordRechainsConflictingEntry(t *testing.T) {
+	auditService, auditDAO, session := createAuditTestService(t)
+	assert.Nil(t, auditService.Record(session, common.AUDIT_ACTION_SWITCH, "channel", 1, nil, nil))
+
+	// Another node appends an entry between reading the head and saving
+	signer := auditService.(*DefaultAuditService).signer
+	other := &entity.AuditEntry{Action: common.AUDIT_ACTION_USER_DELETE, ObjectType: "user", ObjectID: 7}
+	auditDAO.concurrent = []*entity.AuditEntry{other}
+	assert.Nil(t, auditService.Record(session, common.AUDIT_ACTION_SWITCH, "channel", 2, nil, nil))
+
+	assert.Equal(t, 3, len(auditDAO.entries))
+	assert.Equal(t, other.Hash, auditDAO.entries[3].PrevHash)
+	assert.Equal(t, uint64(2), auditDAO.entries[3].ObjectID)
+
+	signature, err := signer.Sign([]byte(other.Hash))
+	assert.Nil(t, err)
+	other.Signature = base64.StdEncoding.EncodeToString(signature)
+	count, err := auditService.Verify()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestAuditSetDeviceConfigUsesStoredConfig(t *testing.T) {
+	auditService, auditDAO, session := createAuditTestService(t)
+
+	// Stored by another node after this farm service started
+	farm := config.NewFarm()
+	farm.ID = 1
+	farm.SetDevices([]*config.DeviceStruct{{ID: 10, FarmID: 1, Type: "room",
+		Channels: []*config.ChannelStruct{{ID: 1, Name: "Light", Duration: 30}}}})
+	farmDAO := &fakeFarmDAO{farms: map[uint64]*config.FarmStruct{1: farm}}
+	farmService := &DefaultFarmService{
+		app:              &app.App{Logger: logging.MustGetLogger("audit_test")},
+		farmID:           1,
+		consistencyLevel: common.CONSISTENCY_LOCAL,
+		channels:         &FarmChannels{FarmConfigChan: make(chan config.Farm, 2)},
+		farmDAO:          farmDAO,
+		serviceRegistry:  &fakeAuditRegistry{auditService: auditService}}
+
+	assert.Nil(t, farmService.SetDeviceConfig(session, &config.DeviceStruct{ID: 10,
+		FarmID: 1, Type: "room",
+		Channels: []*config.ChannelStruct{{ID: 1, Name: "Light", Duration: 60}}}))
+
+	var changes []entity.AuditChange
+	assert.Nil(t, json.Unmarshal([]byte(auditDAO.entries[1].Changes), &changes))
+	assert.Equal(t, []entity.AuditChange{
+		{Path: "channels.0.duration", Before: float64(30), After: float64(60)}}, changes)
+
+	stored, err := farmDAO.Get(1, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	device, err := stored.GetDeviceById(10)
+	assert.Nil(t, err)
+	assert.Equal(t, 60, device.GetChannels()[0].GetDuration())
+}
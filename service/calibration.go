@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrCalibrationNotStarted      = errors.New("calibration not started")
+	ErrCalibrationInProgress      = errors.New("calibration already in progress")
+	ErrCalibrationPointsRequired  = errors.New("at least one calibration point is required")
+	ErrCalibrationDuplicatePoints = errors.New("calibration points must use different raw readings")
+	ErrCalibrationComputedMetric  = errors.New("computed metrics can not be calibrated")
+)
+
+type CalibrationService interface {
+	Start(session Session, deviceID, metricID uint64) (*Calibration, error)
+	Capture(session Session, deviceID, metricID uint64, reference float64) (*Calibration, error)
+	Complete(session Session, deviceID, metricID uint64) (model.Metric, error)
+	Cancel(session Session, deviceID, metricID uint64) error
+	GetOverdue(session Session) ([]model.Metric, error)
+}
+
+// CalibrationPoint is a raw sensor reading captured while the
+// probe was placed in a reference solution.
+type CalibrationPoint struct {
+	Reference float64   `json:"reference"`
+	Raw       float64   `json:"raw"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Calibration is a guided calibration in progress
+type Calibration struct {
+	FarmID    uint64             `json:"farmId"`
+	DeviceID  uint64             `json:"deviceId"`
+	MetricID  uint64             `json:"metricId"`
+	MetricKey string             `json:"metricKey"`
+	Points    []CalibrationPoint `json:"points"`
+	StartedAt time.Time          `json:"startedAt"`
+}
+
+type DefaultCalibrationService struct {
+	logger          *logging.Logger
+	metricService   MetricService
+	serviceRegistry ServiceRegistry
+	calibrations    map[string]*Calibration
+	mutex           *sync.Mutex
+	CalibrationService
+}
+
+// Creates a new calibration service that walks technicians through capturing raw
+// sensor readings against reference solutions and calculates the slope and
+// offset that are applied to the metric when the device is polled.
+func NewCalibrationService(
+	logger *logging.Logger,
+	metricService MetricService,
+	serviceRegistry ServiceRegistry) CalibrationService {
+
+	return &DefaultCalibrationService{
+		logger:          logger,
+		metricService:   metricService,
+		serviceRegistry: serviceRegistry,
+		calibrations:    make(map[string]*Calibration, 0),
+		mutex:           &sync.Mutex{}}
+}
+
+// Starts a new guided calibration for the requested metric
+func (service *DefaultCalibrationService) Start(session Session,
+	deviceID, metricID uint64) (*Calibration, error) {
+
+	metric, err := service.metricService.Get(session, deviceID, metricID)
+	if err != nil {
+		return nil, err
+	}
+	if metric.IsComputed() {
+		return nil, ErrCalibrationComputedMetric
+	}
+	farmID := session.GetRequestedFarmID()
+	key := service.key(farmID, deviceID, metricID)
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if _, ok := service.calibrations[key]; ok {
+		return nil, ErrCalibrationInProgress
+	}
+	calibration := &Calibration{
+		FarmID:    farmID,
+		DeviceID:  deviceID,
+		MetricID:  metricID,
+		MetricKey: metric.GetKey(),
+		Points:    make([]CalibrationPoint, 0),
+		StartedAt: time.Now()}
+	service.calibrations[key] = calibration
+	service.logger.Infof("Started %s calibration for device %d", metric.GetKey(), deviceID)
+	return calibration, nil
+}
+
+// Reads the current raw sensor value from the device and records it
+// against the reference value of the solution the probe is placed in
+func (service *DefaultCalibrationService) Capture(session Session,
+	deviceID, metricID uint64, reference float64) (*Calibration, error) {
+
+	farmID := session.GetRequestedFarmID()
+	key := service.key(farmID, deviceID, metricID)
+
+	service.mutex.Lock()
+	calibration, ok := service.calibrations[key]
+	service.mutex.Unlock()
+	if !ok {
+		return nil, ErrCalibrationNotStarted
+	}
+
+	deviceService, err := service.serviceRegistry.GetDeviceServiceByID(farmID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := deviceService.ReadRawMetric(calibration.MetricKey)
+	if err != nil {
+		return nil, err
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	calibration.Points = append(calibration.Points, CalibrationPoint{
+		Reference: reference,
+		Raw:       raw,
+		Timestamp: time.Now()})
+	service.logger.Infof("Captured %s calibration point: reference=%.4f, raw=%.4f",
+		calibration.MetricKey, reference, raw)
+	return calibration, nil
+}
+
+// Calculates the calibration from the captured points and saves it to the
+// metric. A single point calculates an offset, two or more points calculate
+// the slope and offset using a least squares fit.
+func (service *DefaultCalibrationService) Complete(session Session,
+	deviceID, metricID uint64) (model.Metric, error) {
+
+	farmID := session.GetRequestedFarmID()
+	key := service.key(farmID, deviceID, metricID)
+
+	service.mutex.Lock()
+	calibration, ok := service.calibrations[key]
+	service.mutex.Unlock()
+	if !ok {
+		return nil, ErrCalibrationNotStarted
+	}
+
+	slope, offset, err := CalculateCalibration(calibration.Points)
+	if err != nil {
+		return nil, err
+	}
+
+	metric, err := service.metricService.Get(session, deviceID, metricID)
+	if err != nil {
+		return nil, err
+	}
+	references := make([]string, len(calibration.Points))
+	for i, point := range calibration.Points {
+		references[i] = strconv.FormatFloat(point.Reference, 'f', -1, 64)
+	}
+	now := time.Now()
+	metric.SetCalibrationSlope(slope)
+	metric.SetCalibrationOffset(offset)
+	metric.SetCalibrationReferences(strings.Join(references, ","))
+	metric.SetCalibratedAt(&now)
+	if err := service.metricService.Update(session, metric); err != nil {
+		return nil, err
+	}
+
+	service.mutex.Lock()
+	delete(service.calibrations, key)
+	service.mutex.Unlock()
+
+	service.logger.Infof("Completed %s calibration for device %d: slope=%.6f, offset=%.6f",
+		calibration.MetricKey, deviceID, slope, offset)
+	return metric, nil
+}
+
+// Discards a calibration in progress without changing the metric
+func (service *DefaultCalibrationService) Cancel(session Session, deviceID, metricID uint64) error {
+	key := service.key(session.GetRequestedFarmID(), deviceID, metricID)
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if _, ok := service.calibrations[key]; !ok {
+		return ErrCalibrationNotStarted
+	}
+	delete(service.calibrations, key)
+	return nil
+}
+
+// Returns all of the farm metrics that are overdue for calibration
+func (service *DefaultCalibrationService) GetOverdue(session Session) ([]model.Metric, error) {
+	now := time.Now()
+	metrics := make([]model.Metric, 0)
+	for _, device := range session.GetFarmService().GetConfig().GetDevices() {
+		deviceMetrics, err := service.metricService.GetAll(session, device.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, metric := range deviceMetrics {
+			if metric.IsEnabled() && metric.IsCalibrationOverdue(now) {
+				metrics = append(metrics, metric)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+func (service *DefaultCalibrationService) key(farmID, deviceID, metricID uint64) string {
+	return fmt.Sprintf("%d-%d-%d", farmID, deviceID, metricID)
+}
+
+// Calculates the linear calibration slope and offset that maps the raw
+// readings to their reference values. A single point only corrects the
+// offset; two or more points are fit using least squares.
+func CalculateCalibration(points []CalibrationPoint) (float64, float64, error) {
+	if len(points) == 0 {
+		return 0, 0, ErrCalibrationPointsRequired
+	}
+	if len(points) == 1 {
+		return 1, points[0].Reference - points[0].Raw, nil
+	}
+	n := float64(len(points))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, point := range points {
+		sumX += point.Raw
+		sumY += point.Reference
+		sumXY += point.Raw * point.Reference
+		sumXX += point.Raw * point.Raw
+	}
+	denominator := n*sumXX - sumX*sumX
+	if math.Abs(denominator) < 1e-12 {
+		return 0, 0, ErrCalibrationDuplicatePoints
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	offset := (sumY - slope*sumX) / n
+	return slope, offset, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCalibrationDeviceDAO struct {
+	device *config.DeviceStruct
+	dao.DeviceDAO
+}
+
+func (deviceDAO *fakeCalibrationDeviceDAO) Get(farmID, deviceID uint64,
+	CONSISTENCY_LEVEL int) (*config.DeviceStruct, error) {
+	return deviceDAO.device, nil
+}
+
+type fakeNotificationService struct {
+	notifications []model.Notification
+	NotificationServicer
+}
+
+func (ns *fakeNotificationService) Enqueue(notification model.Notification) error {
+	ns.notifications = append(ns.notifications, notification)
+	return nil
+}
+
+func TestCalculateCalibrationSinglePoint(t *testing.T) {
+	slope, offset, err := CalculateCalibration([]CalibrationPoint{
+		{Reference: 7.0, Raw: 7.2}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, slope)
+	assert.InDelta(t, -0.2, offset, 0.0001)
+}
+
+func TestCalculateCalibrationTwoPoint(t *testing.T) {
+	slope, offset, err := CalculateCalibration([]CalibrationPoint{
+		{Reference: 4.0, Raw: 4.3},
+		{Reference: 7.0, Raw: 7.5}})
+	assert.Nil(t, err)
+
+	metric := &config.MetricStruct{
+		CalibrationSlope:  slope,
+		CalibrationOffset: offset}
+	assert.InDelta(t, 4.0, metric.Calibrate(4.3), 0.0001)
+	assert.InDelta(t, 7.0, metric.Calibrate(7.5), 0.0001)
+}
+
+func TestCalculateCalibrationErrors(t *testing.T) {
+	_, _, err := CalculateCalibration([]CalibrationPoint{})
+	assert.Equal(t, ErrCalibrationPointsRequired, err)
+
+	_, _, err = CalculateCalibration([]CalibrationPoint{
+		{Reference: 4.0, Raw: 5.0},
+		{Reference: 7.0, Raw: 5.0}})
+	assert.Equal(t, ErrCalibrationDuplicatePoints, err)
+}
+
+func TestMetricCalibrationOverdue(t *testing.T) {
+	now := time.Now()
+	metric := &config.MetricStruct{Key: "ph"}
+
+	// Uncalibrated metrics pass through unchanged
+	assert.Equal(t, 5.8, metric.Calibrate(5.8))
+	assert.False(t, metric.IsCalibrationOverdue(now))
+
+	metric.SetCalibrationInterval(7)
+	assert.True(t, metric.IsCalibrationOverdue(now))
+
+	calibratedAt := now.Add(-6 * 24 * time.Hour)
+	metric.SetCalibratedAt(&calibratedAt)
+	assert.False(t, metric.IsCalibrationOverdue(now))
+	assert.True(t, metric.IsCalibrationOverdue(now.Add(24*time.Hour)))
+}
+
+func TestCalibrationReminder(t *testing.T) {
+	calibratedAt := time.Now().Add(-8 * 24 * time.Hour)
+	ph := &config.MetricStruct{Key: "ph", Name: "pH", Enable: true}
+	ph.SetCalibrationInterval(7)
+	ph.SetCalibratedAt(&calibratedAt)
+	ec := &config.MetricStruct{Key: "ec", Name: "EC", Enable: true}
+	ec.SetCalibrationInterval(30)
+	ec.SetCalibratedAt(&calibratedAt)
+
+	deviceConfig := &config.DeviceStruct{ID: 3, Type: "reservoir", Notify: true}
+	deviceConfig.SetMetrics([]*config.MetricStruct{ph, ec})
+
+	notificationService := &fakeNotificationService{}
+	deviceService := &IOSwitchDeviceService{
+		app:                 &app.App{Logger: logging.MustGetLogger("calibration_test")},
+		organizationID:      1,
+		farmID:              2,
+		deviceID:            3,
+		farmName:            "Room",
+		deviceDAO:           &fakeCalibrationDeviceDAO{device: deviceConfig},
+		notificationService: notificationService,
+		reminders:           make(map[string]time.Time, 0)}
+
+	// Only the overdue metric produces a reminder
+	deviceService.remindCalibrations(deviceConfig)
+	assert.Equal(t, 1, len(notificationService.notifications))
+	notification := notificationService.notifications[0]
+	assert.Equal(t, uint64(1), notification.GetOrganizationID())
+	assert.Equal(t, uint64(2), notification.GetFarmID())
+	assert.Equal(t, common.EVENT_TYPE_CALIBRATION, notification.GetType())
+	assert.Equal(t, common.NOTIFICATION_PRIORITY_MED, notification.GetPriority())
+	assert.Contains(t, notification.GetMessage(), "pH sensor calibration is overdue")
+
+	// Reminders aren't repeated within the reminder interval
+	deviceService.remindCalibrations(deviceConfig)
+	assert.Equal(t, 1, len(notificationService.notifications))
+
+	// Devices with notifications disabled don't send reminders
+	deviceConfig.Notify = false
+	deviceService.reminders = make(map[string]time.Time, 0)
+	deviceService.remindCalibrations(deviceConfig)
+	assert.Equal(t, 1, len(notificationService.notifications))
+}
@@ -83,7 +83,7 @@ func (service *ChannelService) Update(session Session, channel model.Channel) er
 				}
 			}
 			device.SetChannel(channelStruct)
-			return farmService.SetDeviceConfig(device)
+			return farmService.SetDeviceConfig(session, device)
 		}
 	}
 	return ErrChannelNotFound
@@ -74,7 +74,7 @@ func (service *DefaultConditionService) Create(session Session, condition config
 			if channel.ID == condition.GetChannelID() {
 				channel.AddCondition(condition.(*config.ConditionStruct))
 				device.SetChannel(channel)
-				return condition, farmService.SetDeviceConfig(device)
+				return condition, farmService.SetDeviceConfig(session, device)
 			}
 		}
 	}
@@ -88,10 +88,15 @@ func (service *DefaultConditionService) Update(session Session, condition config
 	farmConfig := farmService.GetConfig()
 	for _, device := range farmConfig.GetDevices() {
 		for _, channel := range device.GetChannels() {
-			if channel.ID == condition.GetChannelID() {
-				channel.SetCondition(condition.(*config.ConditionStruct))
-				device.SetChannel(channel)
-				return farmService.SetDeviceConfig(device)
+			if channel.ID != condition.GetChannelID() {
+				continue
+			}
+			for _, _condition := range channel.GetConditions() {
+				if _condition.ID == condition.Identifier() {
+					channel.SetCondition(condition.(*config.ConditionStruct))
+					device.SetChannel(channel)
+					return farmService.SetDeviceConfig(session, device)
+				}
 			}
 		}
 	}
@@ -102,18 +107,19 @@ func (service *DefaultConditionService) Update(session Session, condition config
 func (service *DefaultConditionService) Delete(session Session, condition config.Condition) error {
 	farmID := session.GetRequestedFarmID()
 	service.logger.Debugf("Deleting condition config: %+v", condition)
-	if err := service.dao.Delete(farmID, condition.GetChannelID(), condition.(*config.ConditionStruct)); err != nil {
-		return err
-	}
 	farmService := session.GetFarmService()
 	farmConfig := farmService.GetConfig()
 	for _, device := range farmConfig.GetDevices() {
 		for _, channel := range device.GetChannels() {
 			for i, _condition := range channel.GetConditions() {
 				if _condition.ID == condition.Identifier() {
+					// Delete the farm's own condition, not the one sent by the client
+					if err := service.dao.Delete(farmID, device.ID, _condition); err != nil {
+						return err
+					}
 					channel.Conditions = append(channel.Conditions[:i], channel.Conditions[i+1:]...)
 					device.SetChannel(channel)
-					return farmService.SetDeviceConfig(device)
+					return farmService.SetDeviceConfig(session, device)
 				}
 			}
 		}
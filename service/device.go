@@ -11,6 +11,7 @@ import (
 	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/config"
 	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/model"
 
 	"github.com/jeremyhahn/go-cropdroid/datastore"
@@ -36,39 +37,43 @@ type DeviceServicer interface {
 	SetMode(mode string, device device.IOSwitcher)
 	SetState(deviceStateMap state.DeviceStateMap) error
 	Stop()
-	Switch(channelID, position int, logMessage string) (*common.Switch, error)
-	TimerSwitch(channelID, duration int, logMessage string) (common.TimerEvent, error)
+	Switch(channelID, position int, attributes EventAttributes) (*common.Switch, error)
+	TimerSwitch(channelID, duration int, attributes EventAttributes) (common.TimerEvent, error)
 	ManageMetrics(config config.Device, farmState state.FarmStateMap) []error
 	ManageChannels(deviceConfig config.Device, farmState state.FarmStateMap, channels []model.Channel) []error
 	ChannelConfig(channelID int) (config.Channel, error)
+	ReadRawMetric(key string) (float64, error)
 	RefreshSystemInfo() error
 }
 
 type IOSwitchDeviceService struct {
-	app             *app.App
-	farmID          uint64
-	deviceID        uint64
-	farmName        string
-	consistency     int
-	stateStore      state.DeviceStateStorer
-	deviceDAO       dao.DeviceDAO
-	eventLogDAO     dao.EventLogDAO
-	deviceStore     datastore.DeviceDataStore
-	device          device.IOSwitcher
-	deviceMutex     *sync.RWMutex
-	mapper          mapper.DeviceMapper
-	eventLogService EventLogServicer
-	farmChannels    *FarmChannels
+	app                 *app.App
+	organizationID      uint64
+	farmID              uint64
+	deviceID            uint64
+	farmName            string
+	consistency         int
+	stateStore          state.DeviceStateStorer
+	deviceDAO           dao.DeviceDAO
+	deviceStore         datastore.DeviceDataStore
+	device              device.IOSwitcher
+	deviceMutex         *sync.RWMutex
+	mapper              mapper.DeviceMapper
+	eventLogService     EventLogServicer
+	notificationService NotificationServicer
+	farmChannels        *FarmChannels
+	reminders           map[string]time.Time
 	DeviceServicer
 }
 
 func NewDeviceService(
 	app *app.App,
-	farmID, deviceID uint64,
+	organizationID, farmID, deviceID uint64,
 	farmName string,
 	stateStore state.DeviceStateStorer,
 	deviceDAO dao.DeviceDAO,
-	eventLogDAO dao.EventLogDAO,
+	eventLogService EventLogServicer,
+	notificationService NotificationServicer,
 	deviceDatastore datastore.DeviceDataStore,
 	deviceMapper mapper.DeviceMapper,
 	device device.IOSwitcher,
@@ -94,20 +99,22 @@ func NewDeviceService(
 	app.Logger.Errorf("Creating IOSwitchDeviceService for %s", device.GetType())
 
 	return &IOSwitchDeviceService{
-		app:             app,
-		deviceID:        deviceID,
-		farmID:          farmID,
-		farmName:        farmName,
-		stateStore:      stateStore,
-		deviceDAO:       deviceDAO,
-		eventLogDAO:     eventLogDAO,
-		deviceStore:     deviceDatastore,
-		mapper:          deviceMapper,
-		device:          device,
-		deviceMutex:     &sync.RWMutex{},
-		eventLogService: NewEventLogService(app, eventLogDAO, farmID),
-		farmChannels:    farmChannels,
-		consistency:     consistency}, nil
+		app:                 app,
+		organizationID:      organizationID,
+		deviceID:            deviceID,
+		farmID:              farmID,
+		farmName:            farmName,
+		stateStore:          stateStore,
+		deviceDAO:           deviceDAO,
+		deviceStore:         deviceDatastore,
+		mapper:              deviceMapper,
+		device:              device,
+		deviceMutex:         &sync.RWMutex{},
+		eventLogService:     eventLogService,
+		notificationService: notificationService,
+		farmChannels:        farmChannels,
+		reminders:           make(map[string]time.Time, 0),
+		consistency:         consistency}, nil
 }
 
 // Retrives the latest hardware and firmware versions from the device
@@ -226,7 +233,7 @@ func (service *IOSwitchDeviceService) Device() (model.Device, error) {
 func (service *IOSwitchDeviceService) Poll() error {
 	deviceID := service.deviceID
 	deviceType := service.device.GetType()
-	eventType := "Poll"
+	eventType := common.EVENT_TYPE_POLL
 	deviceConfig, err := service.deviceDAO.Get(service.farmID,
 		service.deviceID, service.consistency)
 	if err != nil {
@@ -245,6 +252,8 @@ func (service *IOSwitchDeviceService) Poll() error {
 	}
 	state.SetID(deviceID)
 	state.SetFarmID(service.farmID)
+	service.calibrate(deviceConfig, state)
+	service.remindCalibrations(deviceConfig)
 	service.stateStore.Put(deviceID, state)
 	service.farmChannels.DeviceStateChangeChan <- common.DeviceStateChange{
 		DeviceID:    deviceID,
@@ -256,8 +265,8 @@ func (service *IOSwitchDeviceService) Poll() error {
 
 // Toggles a switch to the requested permission, updates the current device state
 // and broadcasts the new state to connected websocket clients.
-func (service *IOSwitchDeviceService) Switch(channelID, position int, logMessage string) (*common.Switch, error) {
-	eventType := "Switch"
+func (service *IOSwitchDeviceService) Switch(channelID, position int, attributes EventAttributes) (*common.Switch, error) {
+	eventType := common.EVENT_TYPE_SWITCH
 	deviceType := service.device.GetType()
 	switchPosition := util.NewSwitchPosition(position)
 	channelConfig, err := service.ChannelConfig(channelID)
@@ -266,11 +275,11 @@ func (service *IOSwitchDeviceService) Switch(channelID, position int, logMessage
 		return nil, err
 	}
 	channelName := channelConfig.GetName()
-	if logMessage == "" {
-		logMessage = fmt.Sprintf("Switching %s %s", strings.ToLower(channelName),
+	if attributes.Message == "" {
+		attributes.Message = fmt.Sprintf("Switching %s %s", strings.ToLower(channelName),
 			switchPosition.ToString())
 	}
-	service.notify(eventType, logMessage)
+	service.notify(eventType, attributes.Message)
 	service.app.Logger.Debug(fmt.Sprintf("Switching %s (channel=%d), %s", channelName, channelID,
 		switchPosition.ToString()))
 	_switch, err := service.device.Switch(channelConfig.GetBoardID(), position)
@@ -294,8 +303,7 @@ func (service *IOSwitchDeviceService) Switch(channelID, position int, logMessage
 	// 	ChannelID:  1,
 	// 	Value:      common,
 	// }
-	service.eventLogService.Create(service.deviceID, deviceType,
-		eventType, logMessage)
+	service.logSwitchEvent(eventType, channelConfig, float64(position), attributes)
 	return _switch, nil
 }
 
@@ -304,10 +312,9 @@ func (service *IOSwitchDeviceService) Switch(channelID, position int, logMessage
 // the duration has lapsed. Devices should be designed to turn their switches off after
 // the specified duration when the TimerSwitch function is called. The 2nd call to turn the
 // switch off is only a safety mechanism an should never be relied on to turn a Timerswtich off.
-func (service *IOSwitchDeviceService) TimerSwitch(channelID, duration int, logMessage string) (common.TimerEvent, error) {
-	eventType := "TimerSwitch"
+func (service *IOSwitchDeviceService) TimerSwitch(channelID, duration int, attributes EventAttributes) (common.TimerEvent, error) {
+	eventType := common.EVENT_TYPE_TIMER_SWITCH
 	deviceID := service.deviceID
-	deviceType := service.device.GetType()
 	channelConfig, err := service.ChannelConfig(channelID)
 	if err != nil {
 		service.error(eventType, eventType, err)
@@ -351,16 +358,39 @@ func (service *IOSwitchDeviceService) TimerSwitch(channelID, duration int, logMe
 	}()
 
 	service.app.Logger.Debugf("DeviceService timed switch event: %+v", event)
-	if logMessage == "" {
-		logMessage = fmt.Sprintf("Starting %s timer for %d seconds",
+	if attributes.Message == "" {
+		attributes.Message = fmt.Sprintf("Starting %s timer for %d seconds",
 			channelConfig.GetName(), duration)
 	}
-	service.notify(eventType, logMessage)
-	service.eventLogService.Create(deviceID, deviceType, eventType, logMessage)
-	service.app.Logger.Debug(logMessage)
+	service.notify(eventType, attributes.Message)
+	service.logSwitchEvent(eventType, channelConfig, float64(duration), attributes)
+	service.app.Logger.Debug(attributes.Message)
 	return event, nil
 }
 
+// Records a switch event in the farm event log. The value defaults to the
+// switch position or timer duration unless the caller provided a metric value.
+func (service *IOSwitchDeviceService) logSwitchEvent(eventType string,
+	channelConfig config.Channel, value float64, attributes EventAttributes) {
+
+	if attributes.Source == "" {
+		attributes.Source = common.EVENT_SOURCE_SYSTEM
+	}
+	if attributes.MetricKey != "" {
+		value = attributes.Value
+	}
+	service.eventLogService.Log(&entity.EventLog{
+		DeviceID:   service.deviceID,
+		DeviceName: service.device.GetType(),
+		EventType:  eventType,
+		Message:    attributes.Message,
+		ChannelID:  channelConfig.Identifier(),
+		MetricKey:  attributes.MetricKey,
+		Value:      value,
+		ActorID:    attributes.ActorID,
+		Source:     attributes.Source})
+}
+
 // Updates the device state with a new metric value.
 func (service *IOSwitchDeviceService) SetMetricValue(key string, value float64) error {
 	deviceState, err := service.stateStore.Get(service.deviceID)
@@ -391,6 +421,58 @@ func (service *IOSwitchDeviceService) SetMetricValue(key string, value float64)
 	return nil
 }
 
+// Reads the current, uncalibrated value of a metric directly from the device
+func (service *IOSwitchDeviceService) ReadRawMetric(key string) (float64, error) {
+	deviceState, err := service.device.State()
+	if err != nil {
+		return 0, err
+	}
+	value, ok := deviceState.GetMetrics()[key]
+	if !ok {
+		return 0, ErrMetricNotFound
+	}
+	return value, nil
+}
+
+// Applies the metric calibrations to the raw readings reported by the device
+// so conditions, history and connected clients only see calibrated values.
+func (service *IOSwitchDeviceService) calibrate(deviceConfig config.Device, deviceState state.DeviceStateMap) {
+	metrics := deviceState.GetMetrics()
+	for _, metric := range deviceConfig.GetMetrics() {
+		if !metric.IsCalibrated() || metric.IsComputed() {
+			continue
+		}
+		if raw, ok := metrics[metric.GetKey()]; ok {
+			metrics[metric.GetKey()] = metric.Calibrate(raw)
+		}
+	}
+	deviceState.SetMetrics(metrics)
+}
+
+// Sends a reminder for each metric that is overdue for calibration. Reminders
+// are repeated every CALIBRATION_REMINDER_INTERVAL hours until the sensor
+// is recalibrated.
+func (service *IOSwitchDeviceService) remindCalibrations(deviceConfig config.Device) {
+	now := time.Now()
+	interval := time.Duration(common.CALIBRATION_REMINDER_INTERVAL) * time.Hour
+	for _, metric := range deviceConfig.GetMetrics() {
+		if !metric.IsEnabled() || !metric.IsCalibrationOverdue(now) {
+			delete(service.reminders, metric.GetKey())
+			continue
+		}
+		if last, ok := service.reminders[metric.GetKey()]; ok && now.Sub(last) < interval {
+			continue
+		}
+		service.reminders[metric.GetKey()] = now
+		message := fmt.Sprintf("%s sensor has never been calibrated", metric.GetName())
+		if calibratedAt := metric.GetCalibratedAt(); calibratedAt != nil {
+			message = fmt.Sprintf("%s sensor calibration is overdue, last calibrated %s",
+				metric.GetName(), calibratedAt.Format(common.TIME_DISPLAY_FORMAT))
+		}
+		service.notify(common.EVENT_TYPE_CALIBRATION, message)
+	}
+}
+
 // Returns a device channel configuration
 func (service *IOSwitchDeviceService) ChannelConfig(channelID int) (config.Channel, error) {
 	deviceConfig, err := service.deviceDAO.Get(service.farmID,
@@ -409,26 +491,33 @@ func (service *IOSwitchDeviceService) ChannelConfig(channelID int) (config.Chann
 	return nil, fmt.Errorf("channel ID not found: %d", channelID)
 }
 
-// Broadcast a real-time farm push notification message to connected clients
+// Sends a farm notification through the notification service, which routes it
+// to the farm's subscribers, notifiers and connected clients
 func (service *IOSwitchDeviceService) notify(eventType, message string) {
-	config, err := service.Config()
+	deviceConfig, err := service.Config()
 	if err != nil {
 		service.error("notify", eventType, err)
 		return
 	}
-	if !config.IsNotify() {
-		deviceConfig, err := service.deviceDAO.Get(service.farmID,
-			service.deviceID, service.consistency)
-		if err != nil {
-			service.app.Logger.Errorf("Error: ", err)
-			return
-		}
+	if !deviceConfig.IsNotify() {
 		service.app.Logger.Warningf("%s notifications disabled!", deviceConfig.GetType())
 		return
 	}
-	service.farmChannels.FarmNotifyChan <- common.FarmNotification{
-		EventType: eventType,
-		Message:   message}
+	if service.notificationService == nil {
+		return
+	}
+	err = service.notificationService.Enqueue(&model.NotificationStruct{
+		OrganizationID: service.organizationID,
+		FarmID:         service.farmID,
+		Device:         service.farmName,
+		Priority:       notificationPriority(eventType),
+		Title:          deviceConfig.GetType(),
+		Type:           eventType,
+		Message:        message,
+		Timestamp:      time.Now()})
+	if err != nil {
+		service.app.Logger.Errorf("Error sending %s notification: %s", eventType, err)
+	}
 }
 
 // Broadcast a real-time farm error via push notification to connected clients
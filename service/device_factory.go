@@ -27,10 +27,11 @@ type DeviceFactory interface {
 
 type DefaultDeviceFactory struct {
 	app               *app.App
+	organizationID    uint64
 	farmID            uint64
 	farmName          string
 	datastoreRegistry dao.Registry
-	eventLogDAO       dao.EventLogDAO
+	eventLogService   EventLogServicer
 	stateStore        state.DeviceStateStorer
 	consistency       int
 	deviceMapper      mapper.DeviceMapper
@@ -41,10 +42,10 @@ type DefaultDeviceFactory struct {
 
 func NewDeviceFactory(
 	app *app.App,
-	farmID uint64,
+	organizationID, farmID uint64,
 	farmName string,
 	datastoreRegistry dao.Registry,
-	eventLogDAO dao.EventLogDAO,
+	eventLogService EventLogServicer,
 	configStoreType, consistency int,
 	stateStore state.DeviceStateStorer,
 	deviceMapper mapper.DeviceMapper,
@@ -53,10 +54,11 @@ func NewDeviceFactory(
 
 	return &DefaultDeviceFactory{
 		app:               app,
+		organizationID:    organizationID,
 		farmID:            farmID,
 		farmName:          farmName,
 		datastoreRegistry: datastoreRegistry,
-		eventLogDAO:       eventLogDAO,
+		eventLogService:   eventLogService,
 		stateStore:        stateStore,
 		consistency:       consistency,
 		deviceMapper:      deviceMapper,
@@ -64,17 +66,26 @@ func NewDeviceFactory(
 		farmChannels:      farmChannels}
 }
 
-// Builds all device services for a given farm
+// Builds all device services for a given farm. Devices beyond the farm's
+// licensed device quota aren't started.
 func (factory *DefaultDeviceFactory) BuildServices(
 	deviceConfigs []*config.DeviceStruct,
 	datastore datastore.DeviceDataStore,
 	mode string) ([]DeviceServicer, error) {
 
+	licenseService := factory.serviceRegistry.GetLicenseService()
 	services := make([]DeviceServicer, 0, len(deviceConfigs))
 	for _, deviceConfig := range deviceConfigs {
 		if deviceConfig.GetType() == common.CONTROLLER_TYPE_SERVER {
 			continue
 		}
+		if licenseService != nil {
+			if err := licenseService.CheckDeviceQuota(factory.farmID, len(services)+1); err != nil {
+				factory.app.Logger.Errorf("Not starting %s device %d: %s",
+					deviceConfig.GetType(), deviceConfig.ID, err)
+				continue
+			}
+		}
 		service, err := factory.BuildService(datastore, deviceConfig, mode)
 		if err != nil {
 			return nil, err
@@ -108,9 +119,9 @@ func (factory *DefaultDeviceFactory) BuildService(
 		_device = device.NewSmartSwitch(factory.app, deviceConfig.GetURI(), deviceType)
 	}
 
-	service, err := NewDeviceService(factory.app, factory.farmID, deviceID,
-		factory.farmName, factory.stateStore, factory.datastoreRegistry.NewDeviceDAO(),
-		factory.eventLogDAO, datastore, factory.deviceMapper, _device,
+	service, err := NewDeviceService(factory.app, factory.organizationID, factory.farmID,
+		deviceID, factory.farmName, factory.stateStore, factory.datastoreRegistry.NewDeviceDAO(),
+		factory.eventLogService, factory.serviceRegistry.GetNotificationService(), datastore, factory.deviceMapper, _device,
 		factory.farmChannels, factory.consistency)
 
 	if err != nil {
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrInvalidEmailCategory = errors.New("invalid email category")
+)
+
+type EmailService interface {
+	SendAlarm(alarm *entity.Alarm) error
+	SendWorkflowResult(farmConfig config.Farm, workflow config.Workflow, workflowErr error) error
+	SendSummary(farmConfig config.Farm, summary *EmailSummary) error
+	GetPreferences(session Session) (*EmailPreferences, error)
+	SetPreferences(session Session, preferences *EmailPreferences) error
+}
+
+// EmailPreferences are the session user's notification email address and
+// the email categories the user has opted in to
+type EmailPreferences struct {
+	NotificationEmail string   `json:"notificationEmail"`
+	Categories        []string `json:"categories"`
+}
+
+// EmailSummary is a farm summary rendered as a list of tables
+type EmailSummary struct {
+	Title    string
+	Start    time.Time
+	End      time.Time
+	Sections []EmailSummarySection
+}
+
+type EmailSummarySection struct {
+	Heading string
+	Columns []string
+	Rows    [][]string
+}
+
+type alarmEmail struct {
+	AppName  string
+	FarmName string
+	Alarm    *entity.Alarm
+}
+
+type workflowEmail struct {
+	AppName   string
+	FarmName  string
+	Workflow  string
+	Error     string
+	Steps     []workflowEmailStep
+	Timestamp time.Time
+}
+
+type workflowEmailStep struct {
+	Number   int
+	Channel  string
+	Duration int
+	State    string
+}
+
+type summaryEmail struct {
+	AppName  string
+	FarmName string
+	Summary  *EmailSummary
+}
+
+type DefaultEmailService struct {
+	logger  *logging.Logger
+	appName string
+	mailer  common.Mailer
+	farmDAO dao.FarmDAO
+	userDAO dao.UserDAO
+	EmailService
+}
+
+// Creates a new email service that renders the embedded email templates
+// and sends them to each of the farm's users that opted in to the category
+func NewEmailService(
+	logger *logging.Logger,
+	appName string,
+	mailer common.Mailer,
+	farmDAO dao.FarmDAO,
+	userDAO dao.UserDAO) EmailService {
+
+	return &DefaultEmailService{
+		logger:  logger,
+		appName: appName,
+		mailer:  mailer,
+		farmDAO: farmDAO,
+		userDAO: userDAO}
+}
+
+// Emails the alarm to the farm's users subscribed to alarm emails
+func (service *DefaultEmailService) SendAlarm(alarm *entity.Alarm) error {
+	farmConfig, err := service.farmDAO.Get(alarm.GetFarmID(), common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return err
+	}
+	recipients := service.recipients(farmConfig, common.EMAIL_CATEGORY_ALARM)
+	if len(recipients) == 0 {
+		return nil
+	}
+	subject := fmt.Sprintf("%s alarm: %s", farmConfig.GetName(), alarm.Message)
+	return service.mailer.SendTemplate(recipients, subject, common.EMAIL_TEMPLATE_ALARM,
+		alarmEmail{
+			AppName:  service.appName,
+			FarmName: farmConfig.GetName(),
+			Alarm:    alarm})
+}
+
+// Emails the outcome of a workflow run to the farm's users subscribed to workflow emails
+func (service *DefaultEmailService) SendWorkflowResult(farmConfig config.Farm,
+	workflow config.Workflow, workflowErr error) error {
+
+	recipients := service.recipients(farmConfig, common.EMAIL_CATEGORY_WORKFLOW)
+	if len(recipients) == 0 {
+		return nil
+	}
+	channels := make(map[uint64]string, 0)
+	for _, device := range farmConfig.GetDevices() {
+		for _, channel := range device.GetChannels() {
+			channels[channel.ID] = channel.GetName()
+		}
+	}
+	data := workflowEmail{
+		AppName:   service.appName,
+		FarmName:  farmConfig.GetName(),
+		Workflow:  workflow.GetName(),
+		Steps:     make([]workflowEmailStep, len(workflow.GetSteps())),
+		Timestamp: time.Now()}
+	for i, step := range workflow.GetSteps() {
+		data.Steps[i] = workflowEmailStep{
+			Number:   i + 1,
+			Channel:  channels[step.GetChannelID()],
+			Duration: step.GetDuration(),
+			State:    workflowStateName(step.GetState())}
+	}
+	status := "completed"
+	if workflowErr != nil {
+		data.Error = workflowErr.Error()
+		status = "failed"
+	}
+	subject := fmt.Sprintf("%s workflow %s %s", farmConfig.GetName(), workflow.GetName(), status)
+	return service.mailer.SendTemplate(recipients, subject, common.EMAIL_TEMPLATE_WORKFLOW, data)
+}
+
+// Emails the summary to the farm's users subscribed to summary emails
+func (service *DefaultEmailService) SendSummary(farmConfig config.Farm, summary *EmailSummary) error {
+	recipients := service.recipients(farmConfig, common.EMAIL_CATEGORY_SUMMARY)
+	if len(recipients) == 0 {
+		return nil
+	}
+	subject := fmt.Sprintf("%s %s", farmConfig.GetName(), summary.Title)
+	return service.mailer.SendTemplate(recipients, subject, common.EMAIL_TEMPLATE_SUMMARY,
+		summaryEmail{
+			AppName:  service.appName,
+			FarmName: farmConfig.GetName(),
+			Summary:  summary})
+}
+
+// Returns the session user's email preferences
+func (service *DefaultEmailService) GetPreferences(session Session) (*EmailPreferences, error) {
+	user, err := service.userDAO.Get(session.GetUser().Identifier(), session.GetConsistencyLevel())
+	if err != nil {
+		return nil, err
+	}
+	return &EmailPreferences{
+		NotificationEmail: user.NotificationEmail,
+		Categories:        splitEmailCategories(user.GetEmailPreferences())}, nil
+}
+
+// Updates the session user's notification email address and email categories
+func (service *DefaultEmailService) SetPreferences(session Session, preferences *EmailPreferences) error {
+	for _, category := range preferences.Categories {
+		switch category {
+		case common.EMAIL_CATEGORY_ALARM, common.EMAIL_CATEGORY_SUMMARY, common.EMAIL_CATEGORY_WORKFLOW:
+		default:
+			return fmt.Errorf("%w: %s", ErrInvalidEmailCategory, category)
+		}
+	}
+	user, err := service.userDAO.Get(session.GetUser().Identifier(), session.GetConsistencyLevel())
+	if err != nil {
+		return err
+	}
+	user.SetNotificationEmail(preferences.NotificationEmail)
+	user.SetEmailPreferences(strings.Join(preferences.Categories, ","))
+	return service.userDAO.Save(user)
+}
+
+// Returns the notification email addresses of the farm's users
+// that opted in to the email category
+func (service *DefaultEmailService) recipients(farmConfig config.Farm, category string) []string {
+	recipients := make([]string, 0)
+	for _, farmUser := range farmConfig.GetUsers() {
+		user, err := service.userDAO.Get(farmUser.ID, common.CONSISTENCY_LOCAL)
+		if err != nil {
+			service.logger.Warningf("Farm %d user %d: %s", farmConfig.Identifier(), farmUser.ID, err)
+			continue
+		}
+		if user.IsEmailSubscribed(category) && user.GetNotificationEmail() != "" {
+			recipients = append(recipients, user.GetNotificationEmail())
+		}
+	}
+	return recipients
+}
+
+func splitEmailCategories(preferences string) []string {
+	categories := make([]string, 0)
+	for _, category := range strings.Split(preferences, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+func workflowStateName(state int) string {
+	switch state {
+	case common.WORKFLOW_STATE_EXECUTING:
+		return "executing"
+	case common.WORKFLOW_STATE_COMPLETED:
+		return "completed"
+	case common.WORKFLOW_STATE_ERROR:
+		return "error"
+	}
+	return "ready"
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/util"
+)
+
+const emailChangeAudience = "email-change"
+
+var (
+	ErrInvalidEmailChangeToken = errors.New("invalid or expired email change token")
+	ErrEmailChangeUnavailable  = errors.New("email change unavailable")
+)
+
+type EmailChangeService interface {
+	RequestChange(session Session, email, password string) error
+	Confirm(token string) error
+}
+
+type DefaultEmailChangeService struct {
+	app             *app.App
+	userDAO         dao.UserDAO
+	emails          *userEmailResolver
+	mailer          common.Mailer
+	serviceRegistry ServiceRegistry
+	expiration      time.Duration
+	privateKey      func() (*rsa.PrivateKey, error)
+	clock           func() time.Time
+	mutex           *sync.Mutex
+	EmailChangeService
+}
+
+// The claims in an email change token. The fingerprint is derived from the
+// user's current email address and password hash, so the token stops
+// working once the email address or password changes.
+type emailChangeClaims struct {
+	UserID      uint64 `json:"uid"`
+	Email       string `json:"email"`
+	Fingerprint string `json:"fp"`
+	jwt.StandardClaims
+}
+
+// Creates a new email change service. The new email address isn't applied
+// until it's confirmed using a signed, single use link emailed to the new
+// address. Tokens are signed with the web server's private key.
+func NewEmailChangeService(
+	app *app.App,
+	userDAO dao.UserDAO,
+	userEmailDAO dao.UserEmailDAO,
+	mailer common.Mailer,
+	serviceRegistry ServiceRegistry) EmailChangeService {
+
+	return &DefaultEmailChangeService{
+		app:             app,
+		userDAO:         userDAO,
+		emails:          newUserEmailResolver(util.NewIdGenerator(app.DataStoreEngine), userDAO, userEmailDAO),
+		mailer:          mailer,
+		serviceRegistry: serviceRegistry,
+		expiration:      common.EMAIL_CHANGE_EXPIRATION * time.Second,
+		privateKey: func() (*rsa.PrivateKey, error) {
+			if app.CA == nil {
+				return nil, ErrEmailChangeUnavailable
+			}
+			return app.CA.CertStore().PrivKey(app.Domain)
+		},
+		clock: time.Now,
+		mutex: &sync.Mutex{}}
+}
+
+// Emails a confirmation link to the new email address of the session
+// user. The user's password is required to request the change.
+func (service *DefaultEmailChangeService) RequestChange(session Session, email, password string) error {
+	if !emailPattern.MatchString(email) {
+		return ErrInvalidEmailAddress
+	}
+	user, err := service.userDAO.Get(session.GetUser().Identifier(), common.CONSISTENCY_LOCAL)
+	if err != nil || user == nil {
+		return ErrInvalidCredentials
+	}
+	if user.IsDisabled() {
+		return ErrAccountDisabled
+	}
+	match, err := util.CreatePasswordHasher(service.app.PasswordHasherParams).Compare(password, user.GetPassword())
+	if err != nil || !match {
+		service.app.Logger.Warningf("[UNAUTHORIZED] Email change requested with an invalid password: %s", user.Email)
+		return ErrInvalidCredentials
+	}
+	if email == user.GetEmail() {
+		return ErrUserAlreadyExists
+	}
+	inUse, err := service.emails.inUse(email, user.ID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrUserAlreadyExists
+	}
+	now := service.clock()
+	expiresAt := now.Add(service.expiration)
+	token, err := service.sign(user, email, now, expiresAt)
+	if err != nil {
+		return err
+	}
+	templateData := struct {
+		AppName    string
+		Email      string
+		ConfirmURL string
+		ExpiresAt  time.Time
+	}{
+		AppName:    service.app.Name,
+		Email:      email,
+		ConfirmURL: webURL(service.app, "/confirm-email", token),
+		ExpiresAt:  expiresAt}
+	subject := fmt.Sprintf("Confirm your %s email address", service.app.Name)
+	if err := service.mailer.SendTemplate([]string{email}, subject,
+		common.EMAIL_TEMPLATE_EMAIL_CHANGE, templateData); err != nil {
+		service.app.Logger.Errorf("Error sending email change confirmation to %s: %s", email, err)
+		return err
+	}
+	return nil
+}
+
+// Changes the user's email address to the address the token was sent to.
+// Changing the email address signs the user out of every device.
+func (service *DefaultEmailChangeService) Confirm(token string) error {
+	claims, err := service.verify(token)
+	if err != nil {
+		return err
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	user, err := service.userDAO.Get(claims.UserID, common.CONSISTENCY_LOCAL)
+	if err != nil || user == nil {
+		return ErrInvalidEmailChangeToken
+	}
+	if subtle.ConstantTimeCompare([]byte(service.fingerprint(user)), []byte(claims.Fingerprint)) != 1 {
+		return ErrInvalidEmailChangeToken
+	}
+	if user.IsDisabled() {
+		return ErrAccountDisabled
+	}
+	// The address may have been taken since the link was sent
+	inUse, err := service.emails.inUse(claims.Email, user.ID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrUserAlreadyExists
+	}
+	oldEmail := user.GetEmail()
+	if err := service.emails.changeEmail(user, claims.Email); err != nil {
+		return err
+	}
+	service.app.Logger.Infof("Email address changed from %s to %s for user %d", oldEmail, claims.Email, user.ID)
+
+	if service.serviceRegistry == nil {
+		return nil
+	}
+	if refreshTokenService := service.serviceRegistry.GetRefreshTokenService(); refreshTokenService != nil {
+		if _, err := refreshTokenService.RevokeUser(nil, user.ID); err != nil {
+			service.app.Logger.Errorf("Error revoking sessions for user %d: %s", user.ID, err)
+		}
+	}
+	recordAudit(service.app.Logger, service.serviceRegistry, nil,
+		common.AUDIT_ACTION_EMAIL_CHANGE, "user", user.ID,
+		map[string]any{"email": oldEmail}, map[string]any{"email": claims.Email})
+	return nil
+}
+
+func (service *DefaultEmailChangeService) sign(user *config.UserStruct, email string,
+	issuedAt, expiresAt time.Time) (string, error) {
+
+	privateKey, err := service.privateKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, emailChangeClaims{
+		UserID:      user.ID,
+		Email:       email,
+		Fingerprint: service.fingerprint(user),
+		StandardClaims: jwt.StandardClaims{
+			Audience:  emailChangeAudience,
+			Issuer:    common.APPNAME,
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: expiresAt.Unix()}})
+	return token.SignedString(privateKey)
+}
+
+// Returns the token claims if the token was signed by this server
+// for an email change and hasn't expired
+func (service *DefaultEmailChangeService) verify(token string) (*emailChangeClaims, error) {
+	privateKey, err := service.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	claims := &emailChangeClaims{}
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	_, err = parser.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, ErrInvalidEmailChangeToken
+		}
+		return &privateKey.PublicKey, nil
+	})
+	if err != nil {
+		return nil, ErrInvalidEmailChangeToken
+	}
+	if claims.Audience != emailChangeAudience ||
+		service.clock().Unix() > claims.ExpiresAt {
+		return nil, ErrInvalidEmailChangeToken
+	}
+	return claims, nil
+}
+
+func (service *DefaultEmailChangeService) fingerprint(user *config.UserStruct) string {
+	digest := sha256.Sum256([]byte(user.Email + "\x00" + user.Password))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/mapper"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUserEmailDAO struct {
+	userEmails map[uint64]*entity.UserEmail
+	dao.UserEmailDAO
+}
+
+func (userEmailDAO *fakeUserEmailDAO) Save(userEmail *entity.UserEmail) error {
+	userEmailDAO.userEmails[userEmail.ID] = userEmail
+	return nil
+}
+
+func (userEmailDAO *fakeUserEmailDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.UserEmail, error) {
+	if userEmail, ok := userEmailDAO.userEmails[id]; ok {
+		return userEmail, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (userEmailDAO *fakeUserEmailDAO) Delete(userEmail *entity.UserEmail) error {
+	if _, ok := userEmailDAO.userEmails[userEmail.ID]; !ok {
+		return datastore.ErrRecordNotFound
+	}
+	delete(userEmailDAO.userEmails, userEmail.ID)
+	return nil
+}
+
+// Returns the token from the confirmation link in the last email sent
+func confirmationToken(t *testing.T, mailer *fakeTemplateMailer) string {
+	confirmURL := mailer.data.(struct {
+		AppName    string
+		Email      string
+		ConfirmURL string
+		ExpiresAt  time.Time
+	}).ConfirmURL
+	assert.True(t, strings.HasPrefix(confirmURL, "https://cropdroid.local:8443/confirm-email?token="))
+	parsed, err := url.Parse(confirmURL)
+	assert.Nil(t, err)
+	return parsed.Query().Get("token")
+}
+
+func createEmailChangeTestService(t *testing.T, now *time.Time) (
+	*DefaultEmailChangeService, *LocalAuthService, *fakeOIDCUserDAO, *fakeTemplateMailer, Session) {
+
+	_app := &app.App{
+		Logger:              logging.MustGetLogger("email_change_test"),
+		Name:                "cropdroid",
+		Domain:              "cropdroid.local",
+		EnableRegistrations: true,
+		WebService:          config.WebService{TLSPort: 8443},
+		PasswordPolicy:      util.NewPasswordPolicy(),
+		PasswordHasherParams: &util.PasswordHasherParams{
+			Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	password, err := util.CreatePasswordHasher(_app.PasswordHasherParams).Encrypt("password")
+	assert.Nil(t, err)
+	idGenerator := util.NewIdGenerator("")
+	grower := &config.UserStruct{
+		ID:       idGenerator.NewStringID("grower@example.com"),
+		Email:    "grower@example.com",
+		Password: password}
+	analyst := &config.UserStruct{
+		ID:       idGenerator.NewStringID("analyst@example.com"),
+		Email:    "analyst@example.com",
+		Password: password}
+	userDAO := &fakeOIDCUserDAO{users: map[uint64]*config.UserStruct{
+		grower.ID: grower, analyst.ID: analyst}}
+	userEmailDAO := &fakeUserEmailDAO{userEmails: make(map[uint64]*entity.UserEmail)}
+	mailer := &fakeTemplateMailer{}
+
+	emailChangeService := NewEmailChangeService(_app, userDAO, userEmailDAO,
+		mailer, nil).(*DefaultEmailChangeService)
+	emailChangeService.privateKey = func() (*rsa.PrivateKey, error) { return privateKey, nil }
+	emailChangeService.clock = func() time.Time { return *now }
+
+	authService := NewLocalAuthService(_app, &fakeOIDCPermissionDAO{}, nil, nil,
+		&fakeAPIKeyFarmDAO{}, userDAO, userEmailDAO, &fakeOIDCRoleDAO{},
+		mapper.NewUserMapper(), nil).(*LocalAuthService)
+	authService.clock = func() time.Time { return *now }
+
+	session := CreateSession(logging.MustGetLogger("email_change_test"), nil, nil, nil,
+		0, 0, common.CONSISTENCY_LOCAL, &model.UserStruct{ID: grower.ID, Email: grower.Email})
+	return emailChangeService, authService, userDAO, mailer, session
+}
+
+func TestEmailChange(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	emailChangeService, authService, userDAO, mailer, session := createEmailChangeTestService(t, &now)
+	user := userDAO.users[session.GetUser().Identifier()]
+
+	assert.Nil(t, emailChangeService.RequestChange(session, "new@example.com", "password"))
+	assert.Equal(t, []string{"new@example.com"}, mailer.recipients)
+	assert.Equal(t, common.EMAIL_TEMPLATE_EMAIL_CHANGE, mailer.template)
+	token := confirmationToken(t, mailer)
+
+	// Nothing changes until the new address is confirmed
+	assert.Equal(t, "grower@example.com", user.Email)
+
+	assert.Nil(t, emailChangeService.Confirm(token))
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Equal(t, util.NewIdGenerator("").NewStringID("grower@example.com"), user.ID)
+
+	// Tokens are single use
+	assert.Equal(t, ErrInvalidEmailChangeToken, emailChangeService.Confirm(token))
+
+	// The original address can't be registered by another account
+	_, err := authService.Register(&UserCredentials{Email: "grower@example.com", Password: "password"}, "")
+	assert.Equal(t, ErrUserAlreadyExists, err)
+
+	_, _, _, err = authService.Login(&UserCredentials{Email: "grower@example.com", Password: "password"})
+	assert.Equal(t, ErrRecordNotFound.Error(), err.Error())
+	_, _, _, err = authService.Login(&UserCredentials{Email: "new@example.com", Password: "password"})
+	assert.Nil(t, err)
+}
+
+func TestEmailChangeRevert(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	emailChangeService, authService, userDAO, mailer, session := createEmailChangeTestService(t, &now)
+	user := userDAO.users[session.GetUser().Identifier()]
+
+	assert.Nil(t, emailChangeService.RequestChange(session, "new@example.com", "password"))
+	assert.Nil(t, emailChangeService.Confirm(confirmationToken(t, mailer)))
+
+	// The user can change back to the address the account was created with
+	assert.Nil(t, emailChangeService.RequestChange(session, "grower@example.com", "password"))
+	assert.Nil(t, emailChangeService.Confirm(confirmationToken(t, mailer)))
+	assert.Equal(t, "grower@example.com", user.Email)
+
+	_, _, _, err := authService.Login(&UserCredentials{Email: "new@example.com", Password: "password"})
+	assert.Equal(t, ErrRecordNotFound.Error(), err.Error())
+	_, _, _, err = authService.Login(&UserCredentials{Email: "grower@example.com", Password: "password"})
+	assert.Nil(t, err)
+}
+
+func TestEmailChangeRejected(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	emailChangeService, _, userDAO, mailer, session := createEmailChangeTestService(t, &now)
+	user := userDAO.users[session.GetUser().Identifier()]
+
+	assert.Equal(t, ErrInvalidCredentials,
+		emailChangeService.RequestChange(session, "new@example.com", "wrong"))
+	assert.Equal(t, ErrInvalidEmailAddress,
+		emailChangeService.RequestChange(session, "not-an-email", "password"))
+	assert.Equal(t, ErrUserAlreadyExists,
+		emailChangeService.RequestChange(session, "analyst@example.com", "password"))
+	assert.Nil(t, mailer.recipients)
+
+	assert.Nil(t, emailChangeService.RequestChange(session, "new@example.com", "password"))
+	token := confirmationToken(t, mailer)
+
+	assert.Equal(t, ErrInvalidEmailChangeToken, emailChangeService.Confirm("garbage"))
+	assert.Equal(t, ErrInvalidEmailChangeToken, emailChangeService.Confirm(token[:len(token)-2]))
+
+	// Links expire
+	now = now.Add(common.EMAIL_CHANGE_EXPIRATION*time.Second + time.Second)
+	assert.Equal(t, ErrInvalidEmailChangeToken, emailChangeService.Confirm(token))
+	assert.Equal(t, "grower@example.com", user.Email)
+}
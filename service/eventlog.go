@@ -1,36 +1,99 @@
 package service
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
 	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
 )
 
+var (
+	ErrInvalidEventType   = errors.New("invalid event type")
+	ErrInvalidEventSource = errors.New("invalid event source")
+	ErrInvalidArchiveName = errors.New("invalid event log archive name")
+	ErrArchiveNotFound    = errors.New("event log archive not found")
+	ErrArchiveRestored    = errors.New("event log archive already restored")
+
+	eventTypes = map[string]bool{
+		common.EVENT_TYPE_ALARM:          true,
+		common.EVENT_TYPE_ANOMALY:        true,
+		common.EVENT_TYPE_CALIBRATION:    true,
+		common.EVENT_TYPE_POLL:           true,
+		common.EVENT_TYPE_REPORT:         true,
+		common.EVENT_TYPE_STARTUP:        true,
+		common.EVENT_TYPE_SWITCH:         true,
+		common.EVENT_TYPE_SYSTEM:         true,
+		common.EVENT_TYPE_TIMER_SWITCH:   true,
+		common.EVENT_TYPE_WEBSERVER:      true,
+		common.EVENT_TYPE_WORKFLOW:       true,
+		common.EVENT_TYPE_WORKFLOW_ERROR: true}
+
+	eventSources = map[string]bool{
+		common.EVENT_SOURCE_MANUAL:    true,
+		common.EVENT_SOURCE_SCHEDULE:  true,
+		common.EVENT_SOURCE_CONDITION: true,
+		common.EVENT_SOURCE_ALGORITHM: true,
+		common.EVENT_SOURCE_WORKFLOW:  true,
+		common.EVENT_SOURCE_SYSTEM:    true}
+)
+
 type EventLogServicer interface {
 	GetFarmID() uint64
 	Create(deviceID uint64, deviceName, eventType, message string)
+	Log(event *entity.EventLog)
 	GetPage(pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLog], error)
+	GetPageByFilter(filter dao.EventLogFilter, pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLog], error)
+	Archive(before time.Time) (*entity.EventLogArchive, error)
+	Archives() ([]*entity.EventLogArchive, error)
+	Restore(name string) (int, error)
+	Watch() <-chan *entity.EventLog
+}
+
+// EventAttributes describe what caused a device event. They are
+// recorded with the event in the farm event log.
+type EventAttributes struct {
+	Source    string
+	ActorID   uint64
+	MetricKey string
+	Value     float64
+	Message   string
 }
 
 type EventLog struct {
-	app    *app.App
-	dao    dao.EventLogDAO
-	farmID uint64
+	app        *app.App
+	dao        dao.EventLogDAO
+	archiveDAO dao.EventLogArchiveDAO
+	farmID     uint64
+	events     chan *entity.EventLog
+	mutex      *sync.Mutex
 	EventLogServicer
 }
 
 func NewEventLogService(
 	app *app.App,
 	dao dao.EventLogDAO,
+	archiveDAO dao.EventLogArchiveDAO,
 	farmID uint64) EventLogServicer {
 
 	return &EventLog{
-		app:    app,
-		dao:    dao,
-		farmID: farmID}
+		app:        app,
+		dao:        dao,
+		archiveDAO: archiveDAO,
+		farmID:     farmID,
+		events:     make(chan *entity.EventLog, common.BUFFERED_CHANNEL_SIZE),
+		mutex:      &sync.Mutex{}}
 }
 
 func (eventLog *EventLog) GetFarmID() uint64 {
@@ -38,23 +101,44 @@ func (eventLog *EventLog) GetFarmID() uint64 {
 }
 
 func (eventLog *EventLog) Create(deviceID uint64, deviceName, eventType, message string) {
-
-	eventLogEntry := &entity.EventLog{
-		FarmID:     eventLog.farmID,
+	eventLog.Log(&entity.EventLog{
 		DeviceID:   deviceID,
 		DeviceName: deviceName,
 		EventType:  eventType,
 		Message:    message,
-		Timestamp:  time.Now()}
+		Source:     common.EVENT_SOURCE_SYSTEM})
+}
+
+// Saves the event to the farm event log, setting the farm
+// ID and the timestamp if it hasn't been set, and publishes
+// the saved event to real-time clients
+func (eventLog *EventLog) Log(event *entity.EventLog) {
+	event.FarmID = eventLog.farmID
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
 
-	eventLog.app.Logger.Debugf("Event log entry: %+v", eventLogEntry)
+	eventLog.app.Logger.Debugf("Event log entry: %+v", event)
 
-	err := eventLog.dao.Save(eventLogEntry)
+	err := eventLog.dao.Save(event)
 	if err != nil {
-		eventLog.app.Logger.Errorf("[Create] Error: %s", err)
+		eventLog.app.Logger.Errorf("[Log] Error: %s", err)
+		return
+	}
+
+	select {
+	case eventLog.events <- event:
+	default:
+		// No real-time clients are draining the channel
+		eventLog.app.Logger.Debugf("[Log] Event channel buffer full, discarding real-time update: %+v", event)
 	}
 }
 
+// Returns the channel new event log entries are published on
+func (eventLog *EventLog) Watch() <-chan *entity.EventLog {
+	return eventLog.events
+}
+
 func (eventLog *EventLog) GetPage(pageQuery query.PageQuery,
 	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLog], error) {
 
@@ -65,3 +149,195 @@ func (eventLog *EventLog) GetPage(pageQuery query.PageQuery,
 	}
 	return page, nil
 }
+
+// Returns a page of the event log entries matching the filter
+func (eventLog *EventLog) GetPageByFilter(filter dao.EventLogFilter, pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLog], error) {
+
+	if filter.EventType != "" && !eventTypes[filter.EventType] {
+		return dao.PageResult[*entity.EventLog]{},
+			fmt.Errorf("%w: %s", ErrInvalidEventType, filter.EventType)
+	}
+	if filter.Source != "" && !eventSources[filter.Source] {
+		return dao.PageResult[*entity.EventLog]{},
+			fmt.Errorf("%w: %s", ErrInvalidEventSource, filter.Source)
+	}
+	eventLog.app.Logger.Debugf("[GetPageByFilter]: %+v, %+v", filter, pageQuery)
+	return eventLog.dao.GetPageByFilter(filter, pageQuery, CONSISTENCY_LEVEL)
+}
+
+// Moves the event log entries older than the specified time to a gzip
+// compressed JSONL archive in the data directory, deleting them from the
+// event log only after the archive and its metadata have been saved.
+// Entries are streamed to the archive a page at a time. Returns nil when
+// there are no expired entries.
+func (eventLog *EventLog) Archive(before time.Time) (*entity.EventLogArchive, error) {
+	filter := dao.EventLogFilter{End: before}
+	pageQuery := query.NewPageQuery()
+	pageQuery.PageSize = 1000
+	page, err := eventLog.dao.GetPageByFilter(filter, pageQuery, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Entities) == 0 {
+		return nil, nil
+	}
+
+	dir := eventLog.archiveDir()
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s%s%s", eventLog.archivePrefix(),
+		before.UTC().Format("20060102T150405Z"), common.EVENT_LOG_ARCHIVE_EXTENSION)
+	filename := filepath.Join(dir, name)
+	tmpfile := filename + ".tmp"
+	ids, err := eventLog.writeArchive(tmpfile, filter, pageQuery, page)
+	if err != nil {
+		os.Remove(tmpfile)
+		return nil, err
+	}
+	if err := os.Rename(tmpfile, filename); err != nil {
+		os.Remove(tmpfile)
+		return nil, err
+	}
+
+	archive := &entity.EventLogArchive{
+		FarmID:    eventLog.farmID,
+		Name:      name,
+		Before:    before,
+		Entries:   len(ids),
+		CreatedAt: time.Now()}
+	if err := eventLog.archiveDAO.Save(archive); err != nil {
+		os.Remove(filename)
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := eventLog.dao.Delete(&entity.EventLog{ID: id, FarmID: eventLog.farmID}); err != nil {
+			return archive, err
+		}
+	}
+	eventLog.app.Logger.Infof("Archived %d event log entries for farm %d to %s",
+		len(ids), eventLog.farmID, filename)
+	return archive, nil
+}
+
+// Writes the entries matching the filter to a gzip compressed JSONL file,
+// starting with the first page, and returns the IDs of the written entries
+func (eventLog *EventLog) writeArchive(filename string, filter dao.EventLogFilter,
+	pageQuery query.PageQuery, page dao.PageResult[*entity.EventLog]) ([]uint64, error) {
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	writer := gzip.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	ids := make([]uint64, 0, len(page.Entities))
+	for {
+		for _, event := range page.Entities {
+			if err := encoder.Encode(event); err != nil {
+				return nil, err
+			}
+			ids = append(ids, event.ID)
+		}
+		if !page.HasMore {
+			break
+		}
+		pageQuery.Page++
+		page, err = eventLog.dao.GetPageByFilter(filter, pageQuery, common.CONSISTENCY_LOCAL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return ids, file.Sync()
+}
+
+// Returns the farm's event log archives, oldest first
+func (eventLog *EventLog) Archives() ([]*entity.EventLogArchive, error) {
+	return eventLog.archiveDAO.GetByFarmID(eventLog.farmID, common.CONSISTENCY_LOCAL)
+}
+
+// Streams the entries in a farm event log archive back into the event log.
+// Entries keep their original IDs and an archive can only be restored once;
+// restoring it again returns ErrArchiveRestored.
+func (eventLog *EventLog) Restore(name string) (int, error) {
+	if name != filepath.Base(name) ||
+		!strings.HasPrefix(name, eventLog.archivePrefix()) ||
+		!strings.HasSuffix(name, common.EVENT_LOG_ARCHIVE_EXTENSION) {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidArchiveName, name)
+	}
+
+	eventLog.mutex.Lock()
+	defer eventLog.mutex.Unlock()
+
+	archive, err := eventLog.findArchive(name)
+	if err != nil {
+		return 0, err
+	}
+	if archive.IsRestored() {
+		return 0, fmt.Errorf("%w: %s", ErrArchiveRestored, name)
+	}
+
+	file, err := os.Open(filepath.Join(eventLog.archiveDir(), name))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	restored := 0
+	decoder := json.NewDecoder(reader)
+	for {
+		var event entity.EventLog
+		if err := decoder.Decode(&event); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return restored, err
+		}
+		event.FarmID = eventLog.farmID
+		if err := eventLog.dao.Save(&event); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+
+	archive.RestoredAt = time.Now()
+	if err := eventLog.archiveDAO.Save(archive); err != nil {
+		return restored, err
+	}
+	eventLog.app.Logger.Infof("Restored %d event log entries for farm %d from %s",
+		restored, eventLog.farmID, name)
+	return restored, nil
+}
+
+// Returns the farm's event log archive with the specified name
+func (eventLog *EventLog) findArchive(name string) (*entity.EventLogArchive, error) {
+	archives, err := eventLog.archiveDAO.GetByFarmID(eventLog.farmID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, err
+	}
+	for _, archive := range archives {
+		if archive.GetName() == name {
+			return archive, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrArchiveNotFound, name)
+}
+
+func (eventLog *EventLog) archiveDir() string {
+	return filepath.Join(eventLog.app.DataDir, common.EVENT_LOG_ARCHIVE_DIR)
+}
+
+func (eventLog *EventLog) archivePrefix() string {
+	return fmt.Sprintf("eventlog-%d-", eventLog.farmID)
+}
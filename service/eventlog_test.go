@@ -0,0 +1,195 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEventLogDAO struct {
+	events map[uint64]*entity.EventLog
+	dao.EventLogDAO
+}
+
+func (eventLogDAO *fakeEventLogDAO) Save(event *entity.EventLog) error {
+	eventLogDAO.events[event.ID] = event
+	return nil
+}
+
+func (eventLogDAO *fakeEventLogDAO) Delete(event *entity.EventLog) error {
+	delete(eventLogDAO.events, event.ID)
+	return nil
+}
+
+func (eventLogDAO *fakeEventLogDAO) GetPageByFilter(filter dao.EventLogFilter,
+	pageQuery query.PageQuery, CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLog], error) {
+
+	matches := make([]*entity.EventLog, 0)
+	for _, event := range eventLogDAO.events {
+		if filter.Matches(event) {
+			matches = append(matches, event)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ID < matches[j].ID
+	})
+	start := (pageQuery.Page - 1) * pageQuery.PageSize
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := start + pageQuery.PageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+	return dao.PageResult[*entity.EventLog]{
+		Entities: matches[start:end],
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize,
+		HasMore:  end < len(matches)}, nil
+}
+
+type fakeEventLogArchiveDAO struct {
+	archives map[uint64]*entity.EventLogArchive
+	dao.EventLogArchiveDAO
+}
+
+func (archiveDAO *fakeEventLogArchiveDAO) Save(archive *entity.EventLogArchive) error {
+	if archive.ID == 0 {
+		archive.ID = uint64(len(archiveDAO.archives) + 1)
+	}
+	archiveDAO.archives[archive.ID] = archive
+	return nil
+}
+
+func (archiveDAO *fakeEventLogArchiveDAO) GetByFarmID(farmID uint64,
+	CONSISTENCY_LEVEL int) ([]*entity.EventLogArchive, error) {
+
+	archives := make([]*entity.EventLogArchive, 0)
+	for _, archive := range archiveDAO.archives {
+		if archive.FarmID == farmID {
+			archives = append(archives, archive)
+		}
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].Name < archives[j].Name
+	})
+	return archives, nil
+}
+
+func TestEventLogArchiveAndRestore(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	eventLogDAO := &fakeEventLogDAO{events: make(map[uint64]*entity.EventLog)}
+	for i := 1; i <= 1500; i++ {
+		eventLogDAO.events[uint64(i)] = &entity.EventLog{
+			ID:        uint64(i),
+			FarmID:    1,
+			EventType: common.EVENT_TYPE_SWITCH,
+			Source:    common.EVENT_SOURCE_SCHEDULE,
+			Message:   "switched",
+			Timestamp: now.Add(time.Duration(i-1500) * time.Hour)}
+	}
+
+	archiveDAO := &fakeEventLogArchiveDAO{archives: make(map[uint64]*entity.EventLogArchive)}
+	dataDir := t.TempDir()
+	eventLog := NewEventLogService(&app.App{
+		Logger:  logging.MustGetLogger("eventlog_test"),
+		DataDir: dataDir}, eventLogDAO, archiveDAO, 1)
+
+	archives, err := eventLog.Archives()
+	assert.Nil(t, err)
+	assert.Empty(t, archives)
+
+	// Archive everything older than 30 days
+	before := now.AddDate(0, 0, -30)
+	archive, err := eventLog.Archive(before)
+	assert.Nil(t, err)
+	assert.Equal(t, "eventlog-1-20240131T000000Z.jsonl.gz", archive.GetName())
+	assert.Equal(t, 1500-30*24, archive.Entries)
+	assert.Equal(t, uint64(1), archive.GetFarmID())
+	assert.False(t, archive.IsRestored())
+	assert.Equal(t, 30*24, len(eventLogDAO.events))
+	for _, event := range eventLogDAO.events {
+		assert.True(t, event.Timestamp.After(before))
+	}
+
+	// Nothing left to archive
+	archive, err = eventLog.Archive(before)
+	assert.Nil(t, err)
+	assert.Nil(t, archive)
+
+	archives, err = eventLog.Archives()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(archives))
+	assert.Equal(t, "eventlog-1-20240131T000000Z.jsonl.gz", archives[0].GetName())
+
+	// Another farm's event log can't see or restore this farm's archives
+	otherFarm := NewEventLogService(&app.App{
+		Logger:  logging.MustGetLogger("eventlog_test"),
+		DataDir: dataDir}, eventLogDAO, archiveDAO, 2)
+	archives, err = otherFarm.Archives()
+	assert.Nil(t, err)
+	assert.Empty(t, archives)
+	_, err = otherFarm.Restore("eventlog-1-20240131T000000Z.jsonl.gz")
+	assert.ErrorIs(t, err, ErrInvalidArchiveName)
+
+	_, err = eventLog.Restore("../eventlog-1-20240131T000000Z.jsonl.gz")
+	assert.ErrorIs(t, err, ErrInvalidArchiveName)
+	_, err = eventLog.Restore("eventlog-1-20240101T000000Z.jsonl.gz")
+	assert.ErrorIs(t, err, ErrArchiveNotFound)
+
+	restored, err := eventLog.Restore("eventlog-1-20240131T000000Z.jsonl.gz")
+	assert.Nil(t, err)
+	assert.Equal(t, 1500-30*24, restored)
+	assert.Equal(t, 1500, len(eventLogDAO.events))
+	assert.Equal(t, "switched", eventLogDAO.events[1].Message)
+	assert.True(t, now.Add(-1499*time.Hour).Equal(eventLogDAO.events[1].Timestamp))
+
+	archives, err = eventLog.Archives()
+	assert.Nil(t, err)
+	assert.True(t, archives[0].IsRestored())
+
+	// An archive can only be restored once
+	restored, err = eventLog.Restore("eventlog-1-20240131T000000Z.jsonl.gz")
+	assert.ErrorIs(t, err, ErrArchiveRestored)
+	assert.Equal(t, 0, restored)
+	assert.Equal(t, 1500, len(eventLogDAO.events))
+
+	_, err = os.Stat(filepath.Join(dataDir, common.EVENT_LOG_ARCHIVE_DIR,
+		"eventlog-1-20240131T000000Z.jsonl.gz.tmp"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestEventLogWatch(t *testing.T) {
+	eventLogDAO := &fakeEventLogDAO{events: make(map[uint64]*entity.EventLog)}
+	eventLog := NewEventLogService(&app.App{
+		Logger: logging.MustGetLogger("eventlog_test")}, eventLogDAO, nil, 1)
+
+	eventLog.Log(&entity.EventLog{ID: 1, DeviceID: 10, EventType: common.EVENT_TYPE_SWITCH,
+		Source: common.EVENT_SOURCE_MANUAL, Message: "switching on"})
+	eventLog.Create(11, "reservoir", common.EVENT_TYPE_POLL, "timeout")
+
+	event := <-eventLog.Watch()
+	assert.Equal(t, uint64(1), event.FarmID)
+	assert.Equal(t, "switching on", event.Message)
+	assert.False(t, event.Timestamp.IsZero())
+
+	event = <-eventLog.Watch()
+	assert.Equal(t, uint64(11), event.DeviceID)
+	assert.Equal(t, common.EVENT_SOURCE_SYSTEM, event.Source)
+
+	// Publishing never blocks when no clients are draining the channel
+	for i := 0; i <= common.BUFFERED_CHANNEL_SIZE; i++ {
+		eventLog.Create(10, "room", common.EVENT_TYPE_POLL, "timeout")
+	}
+	assert.Equal(t, common.BUFFERED_CHANNEL_SIZE, len(eventLog.Watch()))
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/jeremyhahn/go-cropdroid/config"
 	"github.com/jeremyhahn/go-cropdroid/datastore"
 	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/device"
 	"github.com/jeremyhahn/go-cropdroid/mapper"
 	"github.com/jeremyhahn/go-cropdroid/model"
@@ -24,6 +26,7 @@ type FarmServicer interface {
 	GetChannels() *FarmChannels
 	GetConfig() config.Farm
 	GetConsistencyLevel() int
+	GetDeviceDataStore() datastore.DeviceDataStore
 	GetPublicKey() string
 	GetState() state.FarmStateMap
 	GetStateID() uint64
@@ -34,13 +37,14 @@ type FarmServicer interface {
 	PublishState(farmState state.FarmStateMap) error
 	PublishDeviceState(deviceState map[string]state.DeviceStateMap) error
 	PublishDeviceDelta(deviceState map[string]state.DeviceStateDeltaMap) error
+	PublishAlarm(alarm *entity.Alarm) error
 	RefreshHardwareVersions() error
 	Run()
 	RunCluster()
 	RunWorkflow(workflow config.Workflow)
 	SaveConfig(farmConfig config.Farm) error
 	SetConfig(farmConfig config.Farm) error
-	SetDeviceConfig(deviceConfig config.Device) error
+	SetDeviceConfig(session Session, deviceConfig config.Device) error
 	SetDeviceState(deviceType string, deviceState state.DeviceStateMap)
 	SetConfigValue(session Session, farmID, deviceID uint64, key, value string) error
 	SetMetricValue(deviceType string, key string, value float64) error
@@ -50,6 +54,7 @@ type FarmServicer interface {
 	WatchState() <-chan state.FarmStateMap
 	WatchDeviceState() <-chan map[string]state.DeviceStateMap
 	WatchDeviceDeltas() <-chan map[string]state.DeviceStateDeltaMap
+	WatchAlarms() <-chan *entity.Alarm
 	WatchFarmStateChange()
 }
 
@@ -73,10 +78,14 @@ type DefaultFarmService struct {
 	conditionService    ConditionServicer
 	scheduleService     ScheduleService
 	notificationService NotificationServicer
+	alarmService        AlarmService
+	anomalyDetector     AnomalyDetector
 	farmStateQuitChan   chan int
 	farmConfigQuitChan  chan int
 	deviceStateQuitChan chan int
 	pollTickerQuitChan  chan int
+	nextReport          time.Time
+	nextArchive         time.Time
 	FarmServicer
 }
 
@@ -111,6 +120,8 @@ func CreateFarmService(
 		conditionService:    serviceRegistry.GetConditionService(),
 		scheduleService:     serviceRegistry.GetScheduleService(),
 		notificationService: serviceRegistry.GetNotificationService(),
+		alarmService:        serviceRegistry.GetAlarmService(),
+		anomalyDetector:     NewAnomalyDetector(app.Logger),
 		channels:            farmChannels,
 		running:             false,
 		farmStateQuitChan:   make(chan int),
@@ -248,6 +259,11 @@ func (farm *DefaultFarmService) GetChannels() *FarmChannels {
 }
 
 // Returns the farm RSA public key
+// Returns the data store used to persist the farm's device history
+func (farm *DefaultFarmService) GetDeviceDataStore() datastore.DeviceDataStore {
+	return farm.deviceDataStore
+}
+
 func (farm *DefaultFarmService) GetPublicKey() string {
 	// Try to load a certificate issued to the farm
 	cert, err := farm.app.CA.PEM(fmt.Sprintf("%d", farm.farmID))
@@ -322,27 +338,43 @@ func (farm *DefaultFarmService) SetDeviceState(deviceType string, deviceState st
 	farm.farmStateStore.Put(farm.farmStateID, farmState)
 }
 
-// Stores the specified device config in the farm and device config stores and publishes
-// the whole farm configuration to connected websocket clients.
-func (farm *DefaultFarmService) SetDeviceConfig(deviceConfig config.Device) error {
+// Stores the specified device config in the farm and device config stores, records the
+// change in the audit log and publishes the whole farm configuration to connected
+// websocket clients.
+func (farm *DefaultFarmService) SetDeviceConfig(session Session, deviceConfig config.Device) error {
 	farm.app.Logger.Debugf("config: %+v", deviceConfig)
-	deviceService, err := farm.serviceRegistry.GetDeviceService(farm.farmID, deviceConfig.GetType())
+	after, err := json.Marshal(deviceConfig)
+	if err != nil {
+		return err
+	}
+	farmConfig, err := farm.farmDAO.Get(deviceConfig.GetFarmID(), farm.consistencyLevel)
 	if err != nil {
 		farm.app.Logger.Errorf("Error: %s", err)
 		return err
 	}
-	err = deviceService.SetConfig(deviceConfig)
+	// Audit against the device config as it's currently stored, not as
+	// this service last saw it; it may have been changed by another node
+	var before []byte
+	if device, err := farmConfig.GetDeviceById(deviceConfig.Identifier()); err == nil {
+		if before, err = json.Marshal(device); err != nil {
+			return err
+		}
+	}
+	deviceService, err := farm.serviceRegistry.GetDeviceService(farm.farmID, deviceConfig.GetType())
 	if err != nil {
 		farm.app.Logger.Errorf("Error: %s", err)
 		return err
 	}
-	farmConfig, err := farm.farmDAO.Get(deviceConfig.GetFarmID(), farm.consistencyLevel)
+	err = deviceService.SetConfig(deviceConfig)
 	if err != nil {
 		farm.app.Logger.Errorf("Error: %s", err)
 		return err
 	}
 	farmConfig.SetDevice(deviceConfig.(*config.DeviceStruct))
 	farm.farmDAO.Save(farmConfig)
+	recordAudit(farm.app.Logger, farm.serviceRegistry, session,
+		common.AUDIT_ACTION_DEVICE_CONFIG, "device",
+		deviceConfig.Identifier(), json.RawMessage(before), json.RawMessage(after))
 	farm.PublishConfig(farmConfig)
 	return nil
 }
@@ -358,9 +390,13 @@ func (farm *DefaultFarmService) SetConfigValue(session Session, farmID, deviceID
 	if err != nil {
 		return err
 	}
+	before := *configItem
 	configItem.SetValue(value)
 	farm.deviceSettingDAO.Save(farm.farmID, configItem)
 	farm.app.Logger.Debugf("Saved configuration item: %+v", configItem)
+	recordAudit(farm.app.Logger, farm.serviceRegistry, session,
+		common.AUDIT_ACTION_DEVICE_SETTING, "device_setting",
+		configItem.ID, &before, configItem)
 
 	farmConfig, err := farm.farmDAO.Get(farm.farmID, common.CONSISTENCY_LOCAL)
 	if err != nil {
@@ -454,6 +490,41 @@ func (farm *DefaultFarmService) PublishDeviceDelta(deviceState map[string]state.
 	return nil
 }
 
+// Publishes an alarm that was raised, changed state or cleared
+func (farm *DefaultFarmService) PublishAlarm(alarm *entity.Alarm) error {
+	select {
+	case farm.channels.AlarmChan <- alarm:
+		farm.app.Logger.Debugf("PublishAlarm fired! alarm.id=%d, state=%s", alarm.ID, alarm.State)
+	default:
+		errmsg := "alarm channel buffer full, discarding update!"
+		farm.app.Logger.Error(errmsg)
+		return errors.New(errmsg)
+	}
+	return nil
+}
+
+// Returns the channel farm configuration updates are published on
+func (farm *DefaultFarmService) WatchConfig() <-chan config.Farm {
+	return farm.channels.FarmConfigChan
+}
+
+// Returns the channel full farm state updates are published on. Farm
+// state changes are published to real-time clients as device deltas,
+// so the returned channel never receives.
+func (farm *DefaultFarmService) WatchState() <-chan state.FarmStateMap {
+	return nil
+}
+
+// Returns the channel device state deltas are published on
+func (farm *DefaultFarmService) WatchDeviceDeltas() <-chan map[string]state.DeviceStateDeltaMap {
+	return farm.channels.DeviceStateDeltaChan
+}
+
+// Returns the channel alarm updates are published on
+func (farm *DefaultFarmService) WatchAlarms() <-chan *entity.Alarm {
+	return farm.channels.AlarmChan
+}
+
 // WatchFarmStateChange runs within a goroutine and listens to the farmStateChangeChan
 // for incoming farm state change messages. When received, the new state is compared
 // against the previous state to produce a delta that contains only the values that have
@@ -522,6 +593,17 @@ func (farm *DefaultFarmService) WatchFarmConfigChange() {
 		case newConfig := <-farm.channels.FarmConfigChangeChan:
 			farm.app.Logger.Debugf("New config change for farm %d", farm.GetFarmID())
 
+			if err := farm.notificationService.SetFarmNotifiers(farm.farmID,
+				newConfig.GetNotifiers()); err != nil {
+				farm.app.Logger.Error(err)
+			}
+			routing, err := NewNotificationRouting(newConfig, CreateMailer(farm.app, newConfig.GetSmtp()))
+			if err != nil {
+				farm.app.Logger.Error(err)
+			} else {
+				farm.notificationService.SetFarmRouting(farm.farmID, routing)
+			}
+
 			// Calling farm.SetConfig here results in an infinite loop
 			// since SetConfig calls configStore.Put which in turn
 			// sends a farmConfigChangeChan message with the newly
@@ -585,6 +667,28 @@ func (farm *DefaultFarmService) WatchDeviceStateChange() {
 			deviceType := newDeviceState.DeviceType
 			stateMap := newDeviceState.StateMap
 
+			var deviceConfig config.Device
+			if newDeviceState.IsPollEvent {
+				deviceService, err := farm.serviceRegistry.GetDeviceService(farm.farmID, deviceType)
+				if err != nil {
+					farm.app.Logger.Errorf("Error getting device service: %s", err)
+					continue
+				}
+				deviceConfig, err = deviceService.Config()
+				if err != nil {
+					farm.app.Logger.Errorf("Error getting device config: %s", err)
+					continue
+				}
+				// Computed metrics are evaluated before the new state is published
+				// and stored so they are treated like any other device metric
+				for _, err := range farm.ComputeMetrics(deviceConfig, stateMap) {
+					farm.app.Logger.Errorf("Error computing %s metric: %s", deviceType, err)
+				}
+				if err := deviceService.SetState(stateMap); err != nil {
+					farm.app.Logger.Errorf("Error storing device state: %s", err)
+				}
+			}
+
 			_, err := farm.OnDeviceStateChange(deviceType, stateMap)
 			if err != nil {
 				farm.error("WatchDeviceStateChange", "WatchDeviceStateChange", err)
@@ -600,16 +704,6 @@ func (farm *DefaultFarmService) WatchDeviceStateChange() {
 			}
 
 			if newDeviceState.IsPollEvent {
-				deviceService, err := farm.serviceRegistry.GetDeviceService(farm.farmID, deviceType)
-				if err != nil {
-					farm.app.Logger.Errorf("Error getting device service: %s", err)
-					continue
-				}
-				deviceConfig, err := deviceService.Config()
-				if err != nil {
-					farm.app.Logger.Errorf("Error getting device config: %s", err)
-					continue
-				}
 				farm.Manage(deviceConfig, farm.GetState())
 			}
 		case <-farm.deviceStateQuitChan:
@@ -619,6 +713,63 @@ func (farm *DefaultFarmService) WatchDeviceStateChange() {
 	}
 }
 
+// Evaluates the device's computed metrics using the newly polled device state and
+// the current state of the other devices in the farm. Metrics on the polled device
+// may be referenced by key (tempC0) or qualified with the device type (room.tempC0),
+// while metrics on other devices must always be qualified. Computed metrics are
+// evaluated in configuration order so they may reference computed metrics that
+// precede them.
+func (farm *DefaultFarmService) ComputeMetrics(deviceConfig config.Device,
+	deviceState state.DeviceStateMap) []error {
+
+	var errors []error
+	deviceType := deviceConfig.GetType()
+	variables := make(map[string]float64, 0)
+
+	farmConfig := farm.GetConfig()
+	farmState := farm.GetState()
+	if farmConfig != nil && farmState != nil {
+		for _, device := range farmConfig.GetDevices() {
+			otherState, err := farmState.GetDevice(device.GetType())
+			if err != nil {
+				continue
+			}
+			for key, value := range otherState.GetMetrics() {
+				variables[fmt.Sprintf("%s.%s", device.GetType(), key)] = value
+			}
+		}
+	}
+
+	metrics := deviceState.GetMetrics()
+	for key, value := range metrics {
+		variables[key] = value
+		variables[fmt.Sprintf("%s.%s", deviceType, key)] = value
+	}
+
+	for _, metric := range deviceConfig.GetMetrics() {
+		if !metric.IsEnabled() || !metric.IsComputed() {
+			continue
+		}
+		expression, err := util.ParseExpression(metric.GetExpression(), len(deviceConfig.GetChannels()))
+		if err != nil {
+			errors = append(errors, fmt.Errorf("%s: %w", metric.GetKey(), err))
+			continue
+		}
+		value, err := expression.Evaluate(variables)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("%s: %w", metric.GetKey(), err))
+			continue
+		}
+		farm.app.Logger.Debugf("Computed metric %s.%s = %.2f (%s)",
+			deviceType, metric.GetKey(), value, expression)
+		metrics[metric.GetKey()] = value
+		variables[metric.GetKey()] = value
+		variables[fmt.Sprintf("%s.%s", deviceType, metric.GetKey())] = value
+	}
+	deviceState.SetMetrics(metrics)
+	return errors
+}
+
 func (farm *DefaultFarmService) OnDeviceStateChange(deviceType string,
 	newDeviceState state.DeviceStateMap) (state.DeviceStateDeltaMap, error) {
 
@@ -740,6 +891,77 @@ func (farm *DefaultFarmService) poll() {
 	for _, device := range deviceServices {
 		device.Poll()
 	}
+	now := time.Now()
+	farm.sendScheduledReport(now)
+	farm.archiveEventLog(now)
+}
+
+// Archives the event log entries that have exceeded the farm's event
+// log retention period. Runs at most once every archive interval.
+func (farm *DefaultFarmService) archiveEventLog(now time.Time) {
+	farmConfig := farm.GetConfig()
+	if farmConfig == nil || farmConfig.GetEventLogRetention() <= 0 {
+		return
+	}
+	if now.Before(farm.nextArchive) {
+		return
+	}
+	eventLogService := farm.serviceRegistry.GetEventLogService(farm.farmID)
+	if eventLogService == nil {
+		return
+	}
+	farm.nextArchive = now.Add(common.EVENT_LOG_ARCHIVE_INTERVAL * time.Hour)
+	before := now.AddDate(0, 0, -farmConfig.GetEventLogRetention())
+	go func() {
+		if _, err := eventLogService.Archive(before); err != nil {
+			farm.app.Logger.Errorf("Error archiving farm %d event log: %s", farm.farmID, err)
+		}
+	}()
+}
+
+// Generates and delivers the farm's summary report once the next
+// scheduled report time has passed. The first report is scheduled
+// for the first report time after the farm starts polling.
+func (farm *DefaultFarmService) sendScheduledReport(now time.Time) {
+	farmConfig := farm.GetConfig()
+	reportService := farm.serviceRegistry.GetReportService()
+	if farmConfig == nil || reportService == nil {
+		return
+	}
+	schedule, _ := farmConfig.GetReportSchedule()
+	if schedule == "" {
+		farm.nextReport = time.Time{}
+		return
+	}
+	if !farm.nextReport.IsZero() && now.Before(farm.nextReport) {
+		return
+	}
+	sendReport := !farm.nextReport.IsZero()
+	location := farm.app.Location
+	if timezone := farmConfig.GetTimezone(); timezone != "" {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			location = loc
+		}
+	}
+	nextReport, err := NextReportTime(farmConfig, location, now)
+	if err != nil {
+		farm.app.Logger.Errorf("Farm %d report schedule: %s", farm.farmID, err)
+		return
+	}
+	farm.nextReport = nextReport
+	if !sendReport {
+		return
+	}
+	go func() {
+		report, err := reportService.Generate(farm.farmID, schedule, now)
+		if err != nil {
+			farm.app.Logger.Errorf("Error generating farm %d %s report: %s", farm.farmID, schedule, err)
+			return
+		}
+		if err := reportService.Deliver(report); err != nil {
+			farm.app.Logger.Errorf("Error delivering farm %d %s report: %s", farm.farmID, schedule, err)
+		}
+	}()
 }
 
 func (farm *DefaultFarmService) Manage(deviceConfig config.Device, farmState state.FarmStateMap) {
@@ -781,8 +1003,13 @@ func (farm *DefaultFarmService) Manage(deviceConfig config.Device, farmState sta
 
 func (farm *DefaultFarmService) ManageMetrics(config config.Device, farmState state.FarmStateMap) []error {
 	var errors []error
-	eventType := "ALARM"
 	deviceType := config.GetType()
+	now := time.Now()
+
+	renotify := common.DEFAULT_ALARM_RENOTIFY_INTERVAL
+	if farmConfig := farm.GetConfig(); farmConfig != nil && farmConfig.GetAlarmRenotify() > 0 {
+		renotify = farmConfig.GetAlarmRenotify()
+	}
 
 	farm.app.Logger.Debugf("Managing configured %s metrics...", deviceType)
 
@@ -803,14 +1030,24 @@ func (farm *DefaultFarmService) ManageMetrics(config config.Device, farmState st
 		farm.app.Logger.Debugf("notify=%t, metric=%s, value=%.2f, alarmLow=%.2f, alarmHigh=%.2f",
 			metric.IsNotify(), metric.GetKey(), metricValue, metric.GetAlarmLow(), metric.GetAlarmHigh())
 
-		if metric.IsNotify() && metricValue <= metric.GetAlarmLow() {
-			message := fmt.Sprintf("%s LOW: %.2f", metric.GetName(), metricValue)
-			farm.notify(deviceType, eventType, message)
+		// Alarms are always evaluated so their state is tracked for every metric,
+		// but only notified when metric notifications are enabled. Alarms are
+		// deduplicated by the alarm service so a metric that stays out of range
+		// is only re-notified at the farm's re-notify interval.
+		alarm, notify, err := farm.alarmService.Evaluate(farm.farmID, config, metric, metricValue, renotify)
+		if err != nil {
+			errors = append(errors, err)
+		} else if notify && metric.IsNotify() {
+			farm.notify(deviceType, common.EVENT_TYPE_ALARM, alarm.Message)
 		}
 
-		if metric.IsNotify() && metricValue >= metric.GetAlarmHigh() {
-			message := fmt.Sprintf("%s HIGH: %.2f", metric.GetName(), metricValue)
-			farm.notify(deviceType, eventType, message)
+		// Anomalies are always detected to keep the rolling statistics
+		// current, but only raised when metric notifications are enabled
+		anomalies := farm.anomalyDetector.Detect(config.Identifier(), metric, metricValue, now)
+		if metric.IsNotify() {
+			for _, anomaly := range anomalies {
+				farm.notify(deviceType, common.EVENT_TYPE_ANOMALY, anomaly.Message)
+			}
 		}
 	}
 	return errors
@@ -929,14 +1166,17 @@ func (farm *DefaultFarmService) RunWorkflow(workflow config.Workflow) {
 					boardID := channel.GetBoardID()
 					_, err := deviceService.TimerSwitch(boardID,
 						duration,
-						fmt.Sprintf("%s workflow step #%d switching on %s for %d seconds",
-							workflow.GetName(), i+1, channel.GetName(), duration))
+						EventAttributes{
+							Source: common.EVENT_SOURCE_WORKFLOW,
+							Message: fmt.Sprintf("%s workflow step #%d switching on %s for %d seconds",
+								workflow.GetName(), i+1, channel.GetName(), duration)})
 					if err != nil {
 						farm.app.Logger.Error(err)
 						step.SetState(common.WORKFLOW_STATE_ERROR)
 						workflow.SetStep(step)
 						farmConfig.SetWorkflow(workflow.(*config.WorkflowStruct))
 						farm.SetConfig(farmConfig)
+						farm.workflowResult(farmConfig, workflow, err)
 						return
 					}
 
@@ -956,6 +1196,7 @@ func (farm *DefaultFarmService) RunWorkflow(workflow config.Workflow) {
 						workflow.SetStep(step)
 						farmConfig.SetWorkflow(workflow.(*config.WorkflowStruct))
 						farm.SetConfig(farmConfig)
+						farm.workflowResult(farmConfig, workflow, err)
 						return
 					}
 					for state != common.SWITCH_OFF {
@@ -977,6 +1218,7 @@ func (farm *DefaultFarmService) RunWorkflow(workflow config.Workflow) {
 		nowHr, nowMin, nowSec := now.Clock()
 		nowDateTime := time.Date(now.Year(), now.Month(), now.Day(), nowHr, nowMin, nowSec, 0, farm.app.Location)
 		workflow.SetLastCompleted(&nowDateTime)
+		farm.workflowResult(farmConfig, workflow, nil)
 		for _, step := range workflow.GetSteps() {
 			step.SetState(common.WORKFLOW_STATE_READY)
 			workflow.SetStep(step)
@@ -986,6 +1228,44 @@ func (farm *DefaultFarmService) RunWorkflow(workflow config.Workflow) {
 	}()
 }
 
+// Records the workflow result in the farm event log and emails it
+// to the farm's users subscribed to workflow emails
+func (farm *DefaultFarmService) workflowResult(farmConfig config.Farm,
+	workflow config.Workflow, workflowErr error) {
+
+	if eventLogService := farm.serviceRegistry.GetEventLogService(farm.farmID); eventLogService != nil {
+		if workflowErr != nil {
+			eventLogService.Create(0, workflow.GetName(), common.EVENT_TYPE_WORKFLOW_ERROR,
+				fmt.Sprintf("%s workflow failed: %s", workflow.GetName(), workflowErr))
+		} else {
+			eventLogService.Create(0, workflow.GetName(), common.EVENT_TYPE_WORKFLOW,
+				fmt.Sprintf("%s workflow completed", workflow.GetName()))
+		}
+	}
+	emailService := farm.serviceRegistry.GetEmailService()
+	if emailService == nil {
+		return
+	}
+	if err := emailService.SendWorkflowResult(farmConfig, workflow, workflowErr); err != nil {
+		farm.app.Logger.Warningf("Error emailing %s workflow result: %s", workflow.GetName(), err)
+	}
+}
+
+// Returns the notification priority for a farm event. Alarms are critical
+// and anomalies high priority so both bypass quiet hours and escalate to the
+// on-call users until acknowledged.
+func notificationPriority(eventType string) int {
+	switch eventType {
+	case common.EVENT_TYPE_ALARM:
+		return common.NOTIFICATION_PRIORITY_CRITICAL
+	case common.EVENT_TYPE_ANOMALY:
+		return common.NOTIFICATION_PRIORITY_HIGH
+	case common.EVENT_TYPE_CALIBRATION:
+		return common.NOTIFICATION_PRIORITY_MED
+	}
+	return common.NOTIFICATION_PRIORITY_LOW
+}
+
 // TODO: replace device service notify with this
 func (farm *DefaultFarmService) notify(deviceType, eventType, message string) error {
 	farmConfig, err := farm.farmDAO.Get(farm.farmID, farm.consistencyLevel)
@@ -994,12 +1274,14 @@ func (farm *DefaultFarmService) notify(deviceType, eventType, message string) er
 		return err
 	}
 	return farm.notificationService.Enqueue(&model.NotificationStruct{
-		Device:    farmConfig.GetName(),
-		Priority:  common.NOTIFICATION_PRIORITY_LOW,
-		Title:     deviceType,
-		Type:      eventType,
-		Message:   message,
-		Timestamp: time.Now()})
+		OrganizationID: farmConfig.GetOrganizationID(),
+		FarmID:         farm.farmID,
+		Device:         farmConfig.GetName(),
+		Priority:       notificationPriority(eventType),
+		Title:          deviceType,
+		Type:           eventType,
+		Message:        message,
+		Timestamp:      time.Now()})
 }
 
 func (farm *DefaultFarmService) error(method, eventType string, err error) {
@@ -1010,10 +1292,12 @@ func (farm *DefaultFarmService) error(method, eventType string, err error) {
 		return
 	}
 	farm.notificationService.Enqueue(&model.NotificationStruct{
-		Device:    farmConfig.GetName(),
-		Priority:  common.NOTIFICATION_PRIORITY_HIGH,
-		Title:     farmConfig.GetName(),
-		Type:      eventType,
-		Message:   err.Error(),
-		Timestamp: time.Now()})
+		OrganizationID: farmConfig.GetOrganizationID(),
+		FarmID:         farm.farmID,
+		Device:         farmConfig.GetName(),
+		Priority:       common.NOTIFICATION_PRIORITY_HIGH,
+		Title:          farmConfig.GetName(),
+		Type:           eventType,
+		Message:        err.Error(),
+		Timestamp:      time.Now()})
 }
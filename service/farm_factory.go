@@ -75,9 +75,15 @@ func (ff *DefaultFarmFactory) BuildService(
 	farmName := farmConfig.GetName()
 	deviceConfigs := farmConfig.GetDevices()
 
+	// Build the farm event log service shared by the farm, its devices and
+	// the real-time event log clients
+	eventLogService := NewEventLogService(ff.app, eventLogDAO,
+		ff.datastoreRegistry.GetEventLogArchiveDAO(), farmConfig.Identifier())
+
 	// Build device services
-	deviceFactory := NewDeviceFactory(ff.app, farmConfig.Identifier(), farmName,
-		ff.datastoreRegistry, eventLogDAO, farmConfig.GetConfigStore(), consistencyLevel,
+	deviceFactory := NewDeviceFactory(ff.app, farmConfig.GetOrganizationID(),
+		farmConfig.Identifier(), farmName,
+		ff.datastoreRegistry, eventLogService, farmConfig.GetConfigStore(), consistencyLevel,
 		deviceStateStore, ff.deviceMapper, ff.serviceRegistry, farmChannels)
 
 	deviceServices, err := deviceFactory.BuildServices(deviceConfigs,
@@ -94,8 +100,8 @@ func (ff *DefaultFarmFactory) BuildService(
 		return nil, err
 	}
 
-	// Build event log service
-	eventLogService := NewEventLogService(ff.app, eventLogDAO, farmConfig.Identifier())
+	// Register the notification channels and routing policy
+	ff.registerNotifiers(farmConfig, consistencyLevel)
 
 	// deviceIndexMap := make(map[uint64]config.Device, 0)
 	// channelIndexMap := make(map[int]config.Channel, 0)
@@ -127,6 +133,36 @@ func (ff *DefaultFarmFactory) BuildService(
 	return farmService, nil
 }
 
+// Registers the farm and organization notification channels and the farm's
+// notification routing policy with the notification service. Invalid
+// channels are logged and skipped.
+func (ff *DefaultFarmFactory) registerNotifiers(farmConfig config.Farm, consistencyLevel int) {
+	notificationService := ff.serviceRegistry.GetNotificationService()
+	if err := notificationService.SetFarmNotifiers(farmConfig.Identifier(),
+		farmConfig.GetNotifiers()); err != nil {
+		ff.app.Logger.Error(err)
+	}
+	routing, err := NewNotificationRouting(farmConfig, CreateMailer(ff.app, farmConfig.GetSmtp()))
+	if err != nil {
+		ff.app.Logger.Errorf("Invalid notification routing for farm %d: %s", farmConfig.Identifier(), err)
+	} else {
+		notificationService.SetFarmRouting(farmConfig.Identifier(), routing)
+	}
+	orgID := farmConfig.GetOrganizationID()
+	if orgID == 0 || ff.datastoreRegistry == nil {
+		return
+	}
+	org, err := ff.datastoreRegistry.GetOrganizationDAO().Get(orgID, consistencyLevel)
+	if err != nil {
+		ff.app.Logger.Errorf("Error loading organization %d notifiers: %s", orgID, err)
+		return
+	}
+	if err := notificationService.SetOrganizationNotifiers(orgID,
+		org.GetNotifiers()); err != nil {
+		ff.app.Logger.Error(err)
+	}
+}
+
 func (ff *DefaultFarmFactory) GetFarmProvisionerChan() chan config.Farm {
 	return ff.farmProvisionerChan
 }
@@ -1,3 +1,4 @@
+//go:build broken
 // +build broken
 
 package service
@@ -21,6 +21,7 @@ type GoogleAuthService struct {
 	idGenerator   util.IdGenerator
 	permissionDAO dao.PermissionDAO
 	userDAO       dao.UserDAO
+	emails        *userEmailResolver
 	roleDAO       dao.RoleDAO
 	farmDAO       dao.FarmDAO
 	mapper        mapper.UserMapper
@@ -31,6 +32,7 @@ func NewGoogleAuthService(
 	app *app.App,
 	permissionDAO dao.PermissionDAO,
 	userDAO dao.UserDAO,
+	userEmailDAO dao.UserEmailDAO,
 	roleDAO dao.RoleDAO,
 	farmDAO dao.FarmDAO,
 	userMapper mapper.UserMapper) AuthServicer {
@@ -40,6 +42,7 @@ func NewGoogleAuthService(
 		idGenerator:   util.NewIdGenerator(app.DataStoreEngine),
 		permissionDAO: permissionDAO,
 		userDAO:       userDAO,
+		emails:        newUserEmailResolver(util.NewIdGenerator(app.DataStoreEngine), userDAO, userEmailDAO),
 		roleDAO:       roleDAO,
 		farmDAO:       farmDAO,
 		mapper:        userMapper}
@@ -60,6 +63,13 @@ func (service *GoogleAuthService) ResetPassword(userCredentials *UserCredentials
 	return ErrResetPasswordUnsupported
 }
 
+// Google accounts use Google's own second factor
+func (service *GoogleAuthService) VerifyMFA(token, code string) (model.User,
+	[]config.Organization, []config.Farm, error) {
+
+	return nil, nil, nil, ErrMFAUnsupported
+}
+
 func (service *GoogleAuthService) Login(userCredentials *UserCredentials) (model.User,
 	[]config.Organization, []config.Farm, error) {
 
@@ -79,8 +89,7 @@ func (service *GoogleAuthService) Login(userCredentials *UserCredentials) (model
 	}
 	service.app.Logger.Debugf("tokenInfo: %+v", tokenInfo)
 
-	userID := service.idGenerator.NewStringID(tokenInfo.Email)
-	userEntity, err := service.userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	userEntity, err := service.emails.getUser(tokenInfo.Email)
 
 	// Create a new trial account if this is a new user
 	if err != nil && err.Error() == ErrRecordNotFound.Error() {
@@ -162,6 +171,13 @@ func (service *GoogleAuthService) Login(userCredentials *UserCredentials) (model
 			organizations = append(organizations, &org)
 		}*/
 
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if userEntity.IsDisabled() {
+		return nil, nil, nil, ErrAccountDisabled
+	}
+
 	organizations, err := service.permissionDAO.GetOrganizations(userEntity.ID, common.CONSISTENCY_LOCAL)
 	if err != nil {
 		service.app.Logger.Errorf("Database error: %s", err)
@@ -195,12 +211,14 @@ func (service *GoogleAuthService) Register(userCredentials *UserCredentials,
 	}
 	email := userCredentials.Email
 	token := userCredentials.Password
-	userID := service.idGenerator.NewStringID(email)
-	_, err := service.userDAO.Get(userID, common.CONSISTENCY_LOCAL)
-	if err != nil && err.Error() != ErrRecordNotFound.Error() {
+	inUse, err := service.emails.inUse(email, 0)
+	if err != nil {
 		service.app.Logger.Errorf("%s", err.Error())
 		return nil, fmt.Errorf("Unexpected error: %s", err.Error())
 	}
+	if inUse {
+		return nil, ErrUserAlreadyExists
+	}
 
 	passwordHasher := util.CreatePasswordHasher(service.app.PasswordHasherParams)
 	encrypted, err := passwordHasher.Encrypt(token)
@@ -1,3 +1,4 @@
+//go:build cloud
 // +build cloud
 
 package service
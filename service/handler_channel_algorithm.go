@@ -74,7 +74,11 @@ func (h *ChannelAlgorithmHandler) Handle() (bool, error) {
 		}
 		h.logger.Debugf("Autodosing using pH algorithm: diff=%.2f, dose=%d", diff, dose)
 		message := fmt.Sprintf("%s: %.2f, auto-dosing %s for %d seconds", h.metric.GetName(), h.value, h.channel.GetName(), dose)
-		_, err := h.service.TimerSwitch(h.channel.GetBoardID(), dose, message)
+		_, err := h.service.TimerSwitch(h.channel.GetBoardID(), dose, EventAttributes{
+			Source:    common.EVENT_SOURCE_ALGORITHM,
+			MetricKey: h.metric.GetKey(),
+			Value:     h.value,
+			Message:   message})
 		if err != nil {
 			return false, err
 		}
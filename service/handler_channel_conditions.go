@@ -129,13 +129,14 @@ func (h *ChannelConditionHandler) Handle() (bool, error) {
 			if h.channelConfig.GetDuration() > 0 {
 				message := fmt.Sprintf("Switching ON %s for %d seconds. %s %.2f%s",
 					h.channelConfig.GetName(), h.channelConfig.GetDuration(), conditionMetric.GetName(), value, conditionMetric.GetUnit())
-				_, err := h.deviceService.TimerSwitch(h.channelConfig.GetBoardID(), h.channelConfig.GetDuration(), message)
+				_, err := h.deviceService.TimerSwitch(h.channelConfig.GetBoardID(), h.channelConfig.GetDuration(), h.eventAttributes(conditionMetric, value, message))
 				if err != nil {
 					return false, err
 				}
 			} else {
 				message := fmt.Sprintf("Switching ON %s. %s %.2f%s", h.channelConfig.GetName(), conditionMetric.GetName(), value, conditionMetric.GetUnit())
-				_, err := h.deviceService.Switch(h.channelConfig.GetBoardID(), common.SWITCH_ON, message)
+				_, err := h.deviceService.Switch(h.channelConfig.GetBoardID(), common.SWITCH_ON,
+					h.eventAttributes(conditionMetric, value, message))
 				if err != nil {
 					return false, err
 				}
@@ -167,7 +168,8 @@ func (h *ChannelConditionHandler) Handle() (bool, error) {
 				h.channelConfig.Identifier(), h.channelConfig.GetName(), conditionMetric.GetKey(), value, debounce)
 
 			message := fmt.Sprintf("Switching OFF %s. %s %.2f%s", h.channelConfig.GetName(), conditionMetric.GetName(), value, conditionMetric.GetUnit())
-			_, err := h.deviceService.Switch(h.channelConfig.GetBoardID(), common.SWITCH_OFF, message)
+			_, err := h.deviceService.Switch(h.channelConfig.GetBoardID(), common.SWITCH_OFF,
+				h.eventAttributes(conditionMetric, value, message))
 			if err != nil {
 				return false, err
 			}
@@ -178,3 +180,13 @@ func (h *ChannelConditionHandler) Handle() (bool, error) {
 	return false, nil
 
 }
+
+func (h *ChannelConditionHandler) eventAttributes(metric config.Metric,
+	value float64, message string) EventAttributes {
+
+	return EventAttributes{
+		Source:    common.EVENT_SOURCE_CONDITION,
+		MetricKey: metric.GetKey(),
+		Value:     value,
+		Message:   message}
+}
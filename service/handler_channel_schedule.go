@@ -81,7 +81,9 @@ func (h *ChannelScheduleHandler) Handle() error {
 		if position == common.SWITCH_OFF {
 
 			message := fmt.Sprintf("Switching ON scheduled %s.", h.channelConfig.GetName())
-			_, err := h.deviceService.Switch(h.channelConfig.GetBoardID(), common.SWITCH_ON, message)
+			_, err := h.deviceService.Switch(h.channelConfig.GetBoardID(), common.SWITCH_ON, EventAttributes{
+				Source:  common.EVENT_SOURCE_SCHEDULE,
+				Message: message})
 			if err != nil {
 				return err
 			}
@@ -100,7 +102,9 @@ func (h *ChannelScheduleHandler) Handle() error {
 		h.logger.Debugf("%s scheduled OFF condition met. Current position: %d", h.channelConfig.GetName(), position)
 		if position == common.SWITCH_ON {
 			message := fmt.Sprintf("Switching OFF scheduled %s.", h.channelConfig.GetName())
-			_, err := h.deviceService.Switch(h.channelConfig.GetBoardID(), common.SWITCH_OFF, message)
+			_, err := h.deviceService.Switch(h.channelConfig.GetBoardID(), common.SWITCH_OFF, EventAttributes{
+				Source:  common.EVENT_SOURCE_SCHEDULE,
+				Message: message})
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrInboxItemNotFound = errors.New("inbox item not found")
+)
+
+type InboxService interface {
+	Deliver(notification model.Notification) error
+	GetInbox(session Session, pageQuery query.PageQuery) (dao.PageResult[*entity.InboxItem], error)
+	CountUnread(session Session) (int64, error)
+	MarkRead(session Session, itemID uint64) (*entity.InboxItem, error)
+	MarkAllRead(session Session) error
+	Replay(session Session, cursor uint64) ([]model.Notification, error)
+}
+
+type DefaultInboxService struct {
+	logger   *logging.Logger
+	inboxDAO dao.InboxDAO
+	farmDAO  dao.FarmDAO
+	mutex    *sync.Mutex
+	clock    func() time.Time
+	InboxService
+}
+
+// Creates a new inbox service that persists a copy of each notification for
+// every user of the notification's farm, so notifications are not lost when
+// the websocket buffer is full or the user is not connected.
+func NewInboxService(
+	logger *logging.Logger,
+	inboxDAO dao.InboxDAO,
+	farmDAO dao.FarmDAO) InboxService {
+
+	return &DefaultInboxService{
+		logger:   logger,
+		inboxDAO: inboxDAO,
+		farmDAO:  farmDAO,
+		mutex:    &sync.Mutex{},
+		clock:    time.Now}
+}
+
+// Saves the notification to the inbox of each of the farm's users
+func (service *DefaultInboxService) Deliver(notification model.Notification) error {
+	if notification.GetFarmID() == 0 {
+		return nil
+	}
+	farm, err := service.farmDAO.Get(notification.GetFarmID(), common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, user := range farm.GetUsers() {
+		item := &entity.InboxItem{
+			UserID:         user.ID,
+			NotificationID: notification.GetID(),
+			OrganizationID: notification.GetOrganizationID(),
+			FarmID:         notification.GetFarmID(),
+			Device:         notification.GetDevice(),
+			Priority:       notification.GetPriority(),
+			Type:           notification.GetType(),
+			Title:          notification.GetTitle(),
+			Message:        notification.GetMessage(),
+			Timestamp:      notification.GetTimestampAsObject()}
+		if err := service.inboxDAO.Save(item); err != nil {
+			service.logger.Errorf("Error saving notification %d to inbox for user %d: %s",
+				notification.GetID(), user.ID, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns a page of the session user's inbox, newest notification first
+func (service *DefaultInboxService) GetInbox(session Session,
+	pageQuery query.PageQuery) (dao.PageResult[*entity.InboxItem], error) {
+
+	return service.inboxDAO.GetByUserID(session.GetUser().Identifier(),
+		pageQuery, session.GetConsistencyLevel())
+}
+
+// Returns the number of unread notifications in the session user's inbox
+func (service *DefaultInboxService) CountUnread(session Session) (int64, error) {
+	return service.inboxDAO.CountUnread(session.GetUser().Identifier(),
+		session.GetConsistencyLevel())
+}
+
+// Marks an item in the session user's inbox as read
+func (service *DefaultInboxService) MarkRead(session Session, itemID uint64) (*entity.InboxItem, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	item, err := service.inboxDAO.Get(itemID, session.GetConsistencyLevel())
+	if err != nil || item == nil || item.GetUserID() != session.GetUser().Identifier() {
+		return nil, ErrInboxItemNotFound
+	}
+	if item.IsRead() {
+		return item, nil
+	}
+	item.Read = true
+	item.ReadAt = service.clock()
+	if err := service.inboxDAO.Save(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Marks every unread item in the session user's inbox as read
+func (service *DefaultInboxService) MarkAllRead(session Session) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	items, err := service.inboxDAO.GetSince(session.GetUser().Identifier(),
+		0, session.GetConsistencyLevel())
+	if err != nil {
+		return err
+	}
+	now := service.clock()
+	for _, item := range items {
+		if item.IsRead() {
+			continue
+		}
+		item.Read = true
+		item.ReadAt = now
+		if err := service.inboxDAO.Save(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the session user's notifications for the requested farm that were
+// added to the inbox after the cursor, oldest first. The cursor is the ID of
+// the last notification the client received.
+func (service *DefaultInboxService) Replay(session Session, cursor uint64) ([]model.Notification, error) {
+	items, err := service.inboxDAO.GetSince(session.GetUser().Identifier(),
+		cursor, session.GetConsistencyLevel())
+	if err != nil {
+		return nil, err
+	}
+	notifications := make([]model.Notification, 0, len(items))
+	for _, item := range items {
+		if item.FarmID != session.GetRequestedFarmID() {
+			continue
+		}
+		notifications = append(notifications, &model.NotificationStruct{
+			ID:             item.NotificationID,
+			OrganizationID: item.OrganizationID,
+			FarmID:         item.FarmID,
+			Device:         item.Device,
+			Priority:       item.Priority,
+			Type:           item.Type,
+			Title:          item.Title,
+			Message:        item.Message,
+			Timestamp:      item.Timestamp})
+	}
+	return notifications, nil
+}
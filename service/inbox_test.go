@@ -0,0 +1,189 @@
+package service
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeInboxDAO struct {
+	items  map[uint64]*entity.InboxItem
+	nextID uint64
+	dao.InboxDAO
+}
+
+func (inboxDAO *fakeInboxDAO) Save(item *entity.InboxItem) error {
+	if item.ID == 0 {
+		inboxDAO.nextID++
+		item.ID = inboxDAO.nextID
+	}
+	persisted := *item
+	inboxDAO.items[item.ID] = &persisted
+	return nil
+}
+
+func (inboxDAO *fakeInboxDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.InboxItem, error) {
+	if item, ok := inboxDAO.items[id]; ok {
+		persisted := *item
+		return &persisted, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (inboxDAO *fakeInboxDAO) GetSince(userID, notificationID uint64,
+	CONSISTENCY_LEVEL int) ([]*entity.InboxItem, error) {
+
+	items := make([]*entity.InboxItem, 0)
+	for _, item := range inboxDAO.items {
+		if item.UserID == userID && item.NotificationID > notificationID {
+			persisted := *item
+			items = append(items, &persisted)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].NotificationID < items[j].NotificationID
+	})
+	return items, nil
+}
+
+func (inboxDAO *fakeInboxDAO) GetByUserID(userID uint64, pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.InboxItem], error) {
+
+	items, _ := inboxDAO.GetSince(userID, 0, CONSISTENCY_LEVEL)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].NotificationID > items[j].NotificationID
+	})
+	return dao.PageResult[*entity.InboxItem]{
+		Entities: items,
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize}, nil
+}
+
+func (inboxDAO *fakeInboxDAO) CountUnread(userID uint64, CONSISTENCY_LEVEL int) (int64, error) {
+	var count int64
+	for _, item := range inboxDAO.items {
+		if item.UserID == userID && !item.Read {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type fakeFarmDAO struct {
+	farms map[uint64]*config.FarmStruct
+	dao.FarmDAO
+}
+
+func (farmDAO *fakeFarmDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.FarmStruct, error) {
+	if farm, ok := farmDAO.farms[id]; ok {
+		return farm, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (farmDAO *fakeFarmDAO) Save(farm *config.FarmStruct) error {
+	farmDAO.farms[farm.ID] = farm
+	return nil
+}
+
+func createInboxTestService() (*DefaultInboxService, *fakeInboxDAO) {
+	farm := config.NewFarm()
+	farm.ID = 2
+	farm.SetUsers([]*config.UserStruct{{ID: 1}, {ID: 3}})
+	inboxDAO := &fakeInboxDAO{items: make(map[uint64]*entity.InboxItem)}
+	farmDAO := &fakeFarmDAO{farms: map[uint64]*config.FarmStruct{2: farm}}
+	service := NewInboxService(logging.MustGetLogger("inbox_test"),
+		inboxDAO, farmDAO).(*DefaultInboxService)
+	return service, inboxDAO
+}
+
+func createInboxTestSession(userID uint64) Session {
+	return CreateSession(logging.MustGetLogger("inbox_test"), nil, nil, nil,
+		1, 2, common.CONSISTENCY_LOCAL, &model.UserStruct{ID: userID})
+}
+
+func TestInboxDeliverAndReplay(t *testing.T) {
+	service, inboxDAO := createInboxTestService()
+
+	ns := CreateNotificationService(logging.MustGetLogger("inbox_test"),
+		nil, nil, service).(*NotificationService)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, ns.Enqueue(&model.NotificationStruct{
+			FarmID:    2,
+			Type:      "Test",
+			Message:   "test notification",
+			Timestamp: time.Now()}))
+	}
+	// Each of the farm's users receives a copy
+	assert.Equal(t, 6, len(inboxDAO.items))
+
+	session := createInboxTestSession(1)
+	replayed, err := service.Replay(session, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(replayed))
+
+	// Replay resumes after the client's cursor
+	replayed, err = service.Replay(session, replayed[0].GetID())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(replayed))
+	assert.True(t, replayed[0].GetID() < replayed[1].GetID())
+
+	// Notifications for other farms are not replayed
+	otherFarm := CreateSession(logging.MustGetLogger("inbox_test"), nil, nil, nil,
+		1, 5, common.CONSISTENCY_LOCAL, &model.UserStruct{ID: 1})
+	replayed, err = service.Replay(otherFarm, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, replayed)
+}
+
+func TestInboxMarkRead(t *testing.T) {
+	service, _ := createInboxTestService()
+	for i := 1; i <= 3; i++ {
+		assert.Nil(t, service.Deliver(&model.NotificationStruct{
+			ID:      uint64(i),
+			FarmID:  2,
+			Message: "test notification"}))
+	}
+
+	session := createInboxTestSession(1)
+	unread, err := service.CountUnread(session)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), unread)
+
+	page, err := service.GetInbox(session, query.NewPageQuery())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(page.Entities))
+	assert.Equal(t, uint64(3), page.Entities[0].NotificationID)
+
+	item, err := service.MarkRead(session, page.Entities[0].ID)
+	assert.Nil(t, err)
+	assert.True(t, item.Read)
+	assert.False(t, item.ReadAt.IsZero())
+
+	unread, err = service.CountUnread(session)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), unread)
+
+	// Users can not read another user's inbox
+	_, err = service.MarkRead(createInboxTestSession(3), page.Entities[1].ID)
+	assert.Equal(t, ErrInboxItemNotFound, err)
+
+	assert.Nil(t, service.MarkAllRead(session))
+	unread, err = service.CountUnread(session)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), unread)
+
+	unread, err = service.CountUnread(createInboxTestSession(3))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), unread)
+}
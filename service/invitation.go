@@ -0,0 +1,444 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/util"
+)
+
+var (
+	ErrInvitationNotFound             = errors.New("invitation not found")
+	ErrInvalidInvitation              = errors.New("invalid or expired invitation")
+	ErrInvitationAccepted             = errors.New("invitation already accepted")
+	ErrInvitationScopeRequired        = errors.New("invitations must be sent from an organization or farm")
+	ErrInvitationPermissionEscalation = errors.New("invitation can't grant a role with permissions the user doesn't have")
+)
+
+// InvitationRequest invites an email address to the organization or farm the
+// session is scoped to. The application's default role is granted if Role is
+// empty.
+type InvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// InvitationAcceptance accepts an invitation using the token from the
+// invitation link. A password is required when the invitee doesn't have
+// an account yet.
+type InvitationAcceptance struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type InvitationService interface {
+	Invite(session Session, request InvitationRequest) (*entity.Invitation, error)
+	List(session Session) ([]*entity.Invitation, error)
+	Revoke(session Session, id uint64) (*entity.Invitation, error)
+	Accept(acceptance InvitationAcceptance) (*entity.Invitation, error)
+}
+
+type DefaultInvitationService struct {
+	app             *app.App
+	idGenerator     util.IdGenerator
+	invitationDAO   dao.InvitationDAO
+	userDAO         dao.UserDAO
+	emails          *userEmailResolver
+	roleDAO         dao.RoleDAO
+	orgDAO          dao.OrganizationDAO
+	farmDAO         dao.FarmDAO
+	permissionDAO   dao.PermissionDAO
+	mailer          common.Mailer
+	serviceRegistry ServiceRegistry
+	expiration      time.Duration
+	clock           func() time.Time
+	InvitationService
+}
+
+// Creates a new invitation service that emails users an expiring link to
+// join an organization or farm with a role. Only a hash of each invitation
+// token secret is stored.
+func NewInvitationService(
+	app *app.App,
+	invitationDAO dao.InvitationDAO,
+	userDAO dao.UserDAO,
+	userEmailDAO dao.UserEmailDAO,
+	roleDAO dao.RoleDAO,
+	orgDAO dao.OrganizationDAO,
+	farmDAO dao.FarmDAO,
+	permissionDAO dao.PermissionDAO,
+	mailer common.Mailer,
+	serviceRegistry ServiceRegistry) InvitationService {
+
+	return &DefaultInvitationService{
+		app:             app,
+		idGenerator:     util.NewIdGenerator(app.DataStoreEngine),
+		invitationDAO:   invitationDAO,
+		userDAO:         userDAO,
+		emails:          newUserEmailResolver(util.NewIdGenerator(app.DataStoreEngine), userDAO, userEmailDAO),
+		roleDAO:         roleDAO,
+		orgDAO:          orgDAO,
+		farmDAO:         farmDAO,
+		permissionDAO:   permissionDAO,
+		mailer:          mailer,
+		serviceRegistry: serviceRegistry,
+		expiration:      common.INVITATION_EXPIRATION * time.Second,
+		clock:           time.Now}
+}
+
+// Invites an email address to the organization or farm the session is
+// scoped to and emails the invitee the invitation link. Any pending
+// invitation for the same email and organization or farm is revoked.
+func (service *DefaultInvitationService) Invite(session Session,
+	request InvitationRequest) (*entity.Invitation, error) {
+
+	if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
+		return nil, ErrPermissionDenied
+	}
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+	if !emailPattern.MatchString(email) {
+		return nil, ErrInvalidEmailAddress
+	}
+	orgID, farmID, target, err := service.scope(session)
+	if err != nil {
+		return nil, err
+	}
+	roleName := request.Role
+	if roleName == "" {
+		roleName = service.app.DefaultRole
+	}
+	role, err := service.roleDAO.GetByName(roleName, common.CONSISTENCY_LOCAL)
+	if err != nil || role == nil {
+		return nil, ErrRoleNotFound
+	}
+	for _, permission := range RolePermissions(role) {
+		if !session.HasPermission(permission) {
+			return nil, fmt.Errorf("%w: %s", ErrInvitationPermissionEscalation, permission)
+		}
+	}
+
+	pending, err := service.pending(orgID, farmID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := service.clock()
+	secret := make([]byte, common.INVITATION_SECRET_LENGTH)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	encodedSecret := hex.EncodeToString(secret)
+	invitation := &entity.Invitation{
+		Email:          email,
+		OrganizationID: orgID,
+		FarmID:         farmID,
+		RoleID:         role.ID,
+		Role:           role.GetName(),
+		InvitedBy:      session.GetUser().Identifier(),
+		Hash:           hashSecret(encodedSecret),
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(service.expiration)}
+	if err := service.invitationDAO.Save(invitation); err != nil {
+		return nil, err
+	}
+	token := fmt.Sprintf("%d.%s", invitation.ID, encodedSecret)
+
+	templateData := struct {
+		AppName   string
+		Email     string
+		InvitedBy string
+		Target    string
+		Role      string
+		AcceptURL string
+		ExpiresAt time.Time
+	}{
+		AppName:   service.app.Name,
+		Email:     email,
+		InvitedBy: session.GetUser().GetEmail(),
+		Target:    target,
+		Role:      role.GetName(),
+		AcceptURL: webURL(service.app, "/invitations/accept", token),
+		ExpiresAt: invitation.ExpiresAt}
+	subject := fmt.Sprintf("You've been invited to %s on %s", target, service.app.Name)
+	if err := service.mailer.SendTemplate([]string{email}, subject,
+		common.EMAIL_TEMPLATE_INVITATION, templateData); err != nil {
+		service.app.Logger.Errorf("Error sending invitation email to %s: %s", email, err)
+		// The invitee never received the link, so don't leave it pending
+		if err := service.invitationDAO.Delete(invitation); err != nil {
+			service.app.Logger.Errorf("Error deleting unsent invitation %d: %s", invitation.ID, err)
+		}
+		return nil, err
+	}
+
+	// The new invitation replaces any pending invitation that was sent earlier
+	for _, previous := range pending {
+		if previous.Email == email {
+			previous.RevokedAt = now
+			if err := service.invitationDAO.Save(previous); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	service.app.Logger.Infof("%s invited %s to %s as %s",
+		session.GetUser().GetEmail(), email, target, role.GetName())
+	recordAudit(service.app.Logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_INVITATION_CREATE, "invitation", invitation.ID,
+		nil, invitation.Redact())
+	return invitation.Redact(), nil
+}
+
+// Returns the pending invitations to the organization or farm the
+// session is scoped to
+func (service *DefaultInvitationService) List(session Session) ([]*entity.Invitation, error) {
+	if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
+		return nil, ErrPermissionDenied
+	}
+	orgID, farmID, _, err := service.scope(session)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := service.pending(orgID, farmID)
+	if err != nil {
+		return nil, err
+	}
+	redacted := make([]*entity.Invitation, len(pending))
+	for i, invitation := range pending {
+		redacted[i] = invitation.Redact()
+	}
+	return redacted, nil
+}
+
+// Revokes a pending invitation to the organization or farm the session
+// is scoped to
+func (service *DefaultInvitationService) Revoke(session Session, id uint64) (*entity.Invitation, error) {
+	if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
+		return nil, ErrPermissionDenied
+	}
+	orgID, farmID, _, err := service.scope(session)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := service.invitationDAO.Get(id, common.CONSISTENCY_LOCAL)
+	if err != nil || invitation == nil || invitation.ID == 0 {
+		return nil, ErrInvitationNotFound
+	}
+	if !service.inScope(invitation, orgID, farmID) {
+		return nil, ErrInvitationNotFound
+	}
+	if invitation.IsAccepted() {
+		return nil, ErrInvitationAccepted
+	}
+	if invitation.IsRevoked() {
+		return invitation.Redact(), nil
+	}
+	before := invitation.Redact()
+	invitation.RevokedAt = service.clock()
+	if err := service.invitationDAO.Save(invitation); err != nil {
+		return nil, err
+	}
+	service.app.Logger.Infof("Invitation %d for %s revoked by %s",
+		invitation.ID, invitation.Email, session.GetUser().GetEmail())
+	recordAudit(service.app.Logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_INVITATION_REVOKE, "invitation", invitation.ID,
+		before, invitation.Redact())
+	return invitation.Redact(), nil
+}
+
+// Accepts an invitation, granting the invitee the invited role in the
+// organization or farm. An account is created for invitees that don't
+// have one using the provided password, which must satisfy the password
+// policy. Invitations can only be accepted once, and only while the
+// organization or farm is within its licensed user quota.
+func (service *DefaultInvitationService) Accept(acceptance InvitationAcceptance) (*entity.Invitation, error) {
+	invitation, err := service.verify(acceptance.Token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := service.emails.getUser(invitation.Email)
+	if err != nil && err.Error() != ErrRecordNotFound.Error() {
+		return nil, err
+	}
+	newAccount := user == nil
+	userID := service.idGenerator.NewStringID(invitation.Email)
+	if !newAccount {
+		userID = user.ID
+	} else if inUse, err := service.emails.inUse(invitation.Email, 0); err != nil {
+		return nil, err
+	} else if inUse {
+		// An account that has changed its email address still has the ID
+		return nil, ErrUserAlreadyExists
+	}
+	if service.serviceRegistry != nil {
+		if licenseService := service.serviceRegistry.GetLicenseService(); licenseService != nil {
+			if err := licenseService.CheckUserQuota(invitation.OrganizationID,
+				invitation.FarmID, userID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if newAccount {
+		if err := service.app.PasswordPolicy.Validate(acceptance.Password); err != nil {
+			return nil, err
+		}
+		encrypted, err := util.CreatePasswordHasher(service.app.PasswordHasherParams).Encrypt(acceptance.Password)
+		if err != nil {
+			return nil, err
+		}
+		user = &config.UserStruct{
+			ID:       userID,
+			Email:    invitation.Email,
+			Password: encrypted}
+		if err := service.userDAO.Save(user); err != nil {
+			return nil, err
+		}
+	} else if user.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+
+	permission := config.CreatePermissionStruct(invitation.OrganizationID,
+		invitation.FarmID, userID, invitation.RoleID)
+	member := false
+	if !newAccount {
+		if member, err = service.isMember(userID, invitation); err != nil {
+			return nil, err
+		}
+	}
+	if member {
+		err = service.permissionDAO.Update(permission)
+	} else {
+		err = service.permissionDAO.Save(permission)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	before := invitation.Redact()
+	invitation.AcceptedAt = service.clock()
+	invitation.AcceptedBy = userID
+	if err := service.invitationDAO.Save(invitation); err != nil {
+		return nil, err
+	}
+	service.app.Logger.Infof("Invitation %d accepted by %s", invitation.ID, invitation.Email)
+	recordAudit(service.app.Logger, service.serviceRegistry, nil,
+		common.AUDIT_ACTION_INVITATION_ACCEPT, "invitation", invitation.ID,
+		before, invitation.Redact())
+	return invitation.Redact(), nil
+}
+
+// Returns the organization and farm the session is scoped to, along with
+// the name of the farm or organization. Farm invitations carry the farm's
+// organization.
+func (service *DefaultInvitationService) scope(session Session) (uint64, uint64, string, error) {
+	if farmID := session.GetRequestedFarmID(); farmID > 0 {
+		farm, err := service.farmDAO.Get(farmID, common.CONSISTENCY_LOCAL)
+		if err != nil || farm == nil {
+			return 0, 0, "", ErrFarmNotFound
+		}
+		return farm.GetOrganizationID(), farmID, farm.GetName(), nil
+	}
+	if orgID := session.GetRequestedOrganizationID(); orgID > 0 {
+		org, err := service.orgDAO.Get(orgID, common.CONSISTENCY_LOCAL)
+		if err != nil || org == nil {
+			return 0, 0, "", ErrOrganizationNotFound
+		}
+		return orgID, 0, org.GetName(), nil
+	}
+	return 0, 0, "", ErrInvitationScopeRequired
+}
+
+// Returns the pending invitations to the farm, or to the organization
+// if farmID is zero
+func (service *DefaultInvitationService) pending(orgID, farmID uint64) ([]*entity.Invitation, error) {
+	var invitations []*entity.Invitation
+	var err error
+	if farmID > 0 {
+		invitations, err = service.invitationDAO.GetByFarmID(farmID, common.CONSISTENCY_LOCAL)
+	} else {
+		invitations, err = service.invitationDAO.GetByOrganizationID(orgID, common.CONSISTENCY_LOCAL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := service.clock()
+	pending := make([]*entity.Invitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		if invitation.IsPending(now) && service.inScope(invitation, orgID, farmID) {
+			pending = append(pending, invitation)
+		}
+	}
+	return pending, nil
+}
+
+// Returns true if the invitation is to the farm, or to the organization
+// if farmID is zero
+func (service *DefaultInvitationService) inScope(invitation *entity.Invitation, orgID, farmID uint64) bool {
+	if farmID > 0 {
+		return invitation.FarmID == farmID
+	}
+	return invitation.OrganizationID == orgID && invitation.FarmID == 0
+}
+
+// Returns true if the user already belongs to the organization or farm
+// the invitation is for
+func (service *DefaultInvitationService) isMember(userID uint64, invitation *entity.Invitation) (bool, error) {
+	if invitation.FarmID > 0 {
+		farms, err := service.farmDAO.GetByUserID(userID, common.CONSISTENCY_LOCAL)
+		if err != nil {
+			return false, err
+		}
+		for _, farm := range farms {
+			if farm.Identifier() == invitation.FarmID {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	orgs, err := service.permissionDAO.GetOrganizations(userID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return false, err
+	}
+	for _, org := range orgs {
+		if org.ID == invitation.OrganizationID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Returns the pending invitation if the token's secret matches
+func (service *DefaultInvitationService) verify(token string) (*entity.Invitation, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidInvitation
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidInvitation
+	}
+	invitation, err := service.invitationDAO.Get(id, common.CONSISTENCY_LOCAL)
+	if err != nil || invitation == nil || invitation.ID == 0 {
+		return nil, ErrInvalidInvitation
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(parts[1])), []byte(invitation.Hash)) != 1 {
+		return nil, ErrInvalidInvitation
+	}
+	if invitation.IsAccepted() {
+		return nil, ErrInvitationAccepted
+	}
+	if !invitation.IsPending(service.clock()) {
+		return nil, ErrInvalidInvitation
+	}
+	return invitation, nil
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeInvitationDAO struct {
+	invitations map[uint64]*entity.Invitation
+	nextID      uint64
+	dao.InvitationDAO
+}
+
+func (invitationDAO *fakeInvitationDAO) Save(invitation *entity.Invitation) error {
+	if invitation.ID == 0 {
+		invitationDAO.nextID++
+		invitation.ID = invitationDAO.nextID
+	}
+	invitationDAO.invitations[invitation.ID] = invitation
+	return nil
+}
+
+func (invitationDAO *fakeInvitationDAO) Delete(invitation *entity.Invitation) error {
+	delete(invitationDAO.invitations, invitation.ID)
+	return nil
+}
+
+func (invitationDAO *fakeInvitationDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.Invitation, error) {
+	if invitation, ok := invitationDAO.invitations[id]; ok {
+		return invitation, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (invitationDAO *fakeInvitationDAO) GetByOrganizationID(orgID uint64, CONSISTENCY_LEVEL int) ([]*entity.Invitation, error) {
+	invitations := make([]*entity.Invitation, 0)
+	for _, invitation := range invitationDAO.invitations {
+		if invitation.OrganizationID == orgID {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+func (invitationDAO *fakeInvitationDAO) GetByFarmID(farmID uint64, CONSISTENCY_LEVEL int) ([]*entity.Invitation, error) {
+	invitations := make([]*entity.Invitation, 0)
+	for _, invitation := range invitationDAO.invitations {
+		if invitation.FarmID == farmID {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+type fakeInvitationOrgDAO struct {
+	dao.OrganizationDAO
+}
+
+func (orgDAO *fakeInvitationOrgDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.OrganizationStruct, error) {
+	return &config.OrganizationStruct{ID: id, Name: "Acme Farms"}, nil
+}
+
+func invitationTestSession(orgID, farmID uint64, permissions ...string) Session {
+	return CreateSession(logging.MustGetLogger("invitation_test"), nil,
+		nil, nil, orgID, farmID, common.CONSISTENCY_LOCAL,
+		&model.UserStruct{
+			ID:    1,
+			Email: "admin@example.com",
+			Roles: []model.Role{&model.RoleStruct{
+				Name:        common.ROLE_ADMIN,
+				Permissions: permissions}}})
+}
+
+func createInvitationTestService(now *time.Time) (*DefaultInvitationService,
+	*fakeInvitationDAO, *fakeOIDCUserDAO, *fakeOIDCPermissionDAO, *fakeTemplateMailer) {
+
+	_app := &app.App{
+		Logger:         logging.MustGetLogger("invitation_test"),
+		Name:           "cropdroid",
+		Domain:         "cropdroid.local",
+		DefaultRole:    common.ROLE_ANALYST,
+		WebService:     config.WebService{TLSPort: 8443},
+		PasswordPolicy: util.NewPasswordPolicy(),
+		PasswordHasherParams: &util.PasswordHasherParams{
+			Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+	farm := config.NewFarm()
+	farm.ID = 7
+	farm.OrganizationID = 3
+	farm.Name = "Greenhouse"
+	invitationDAO := &fakeInvitationDAO{invitations: make(map[uint64]*entity.Invitation)}
+	userDAO := &fakeOIDCUserDAO{users: make(map[uint64]*config.UserStruct)}
+	permissionDAO := &fakeOIDCPermissionDAO{}
+	mailer := &fakeTemplateMailer{}
+	invitationService := NewInvitationService(_app, invitationDAO, userDAO, nil,
+		&fakeOIDCRoleDAO{}, &fakeInvitationOrgDAO{},
+		&fakeFarmDAO{farms: map[uint64]*config.FarmStruct{7: farm}},
+		permissionDAO, mailer, nil).(*DefaultInvitationService)
+	invitationService.clock = func() time.Time { return *now }
+	return invitationService, invitationDAO, userDAO, permissionDAO, mailer
+}
+
+// Returns the token from the accept link in the last email sent
+func invitationToken(t *testing.T, mailer *fakeTemplateMailer) string {
+	acceptURL := mailer.data.(struct {
+		AppName   string
+		Email     string
+		InvitedBy string
+		Target    string
+		Role      string
+		AcceptURL string
+		ExpiresAt time.Time
+	}).AcceptURL
+	assert.True(t, strings.HasPrefix(acceptURL, "https://cropdroid.local:8443/invitations/accept?token="))
+	parsed, err := url.Parse(acceptURL)
+	assert.Nil(t, err)
+	return parsed.Query().Get("token")
+}
+
+func TestInvitationOrganization(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	invitationService, invitationDAO, userDAO, permissionDAO, mailer := createInvitationTestService(&now)
+	session := invitationTestSession(3, 0, common.PermissionCatalog...)
+
+	invitation, err := invitationService.Invite(session, InvitationRequest{
+		Email: " Grower@Example.com ", Role: common.ROLE_CULTIVATOR})
+	assert.Nil(t, err)
+	assert.Equal(t, "grower@example.com", invitation.Email)
+	assert.Equal(t, uint64(3), invitation.GetOrganizationID())
+	assert.Equal(t, uint64(0), invitation.GetFarmID())
+	assert.Equal(t, common.ROLE_CULTIVATOR, invitation.Role)
+	assert.Empty(t, invitation.Hash)
+	assert.NotEmpty(t, invitationDAO.invitations[invitation.ID].Hash)
+	assert.Equal(t, []string{"grower@example.com"}, mailer.recipients)
+	assert.Equal(t, common.EMAIL_TEMPLATE_INVITATION, mailer.template)
+	firstToken := invitationToken(t, mailer)
+
+	// Inviting the same email again replaces the pending invitation
+	invitation, err = invitationService.Invite(session, InvitationRequest{
+		Email: "grower@example.com", Role: common.ROLE_CULTIVATOR})
+	assert.Nil(t, err)
+	token := invitationToken(t, mailer)
+	pending, err := invitationService.List(session)
+	assert.Nil(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, invitation.ID, pending[0].ID)
+	assert.Empty(t, pending[0].Hash)
+	_, err = invitationService.Accept(InvitationAcceptance{Token: firstToken, Password: "newpassword"})
+	assert.Equal(t, ErrInvalidInvitation, err)
+
+	// New accounts must satisfy the password policy
+	_, err = invitationService.Accept(InvitationAcceptance{Token: token, Password: "short"})
+	assert.ErrorIs(t, err, util.ErrWeakPassword)
+
+	now = now.Add(time.Hour)
+	accepted, err := invitationService.Accept(InvitationAcceptance{Token: token, Password: "newpassword"})
+	assert.Nil(t, err)
+	assert.Equal(t, now, accepted.AcceptedAt)
+
+	userID := util.NewIdGenerator("").NewStringID("grower@example.com")
+	assert.Equal(t, userID, accepted.AcceptedBy)
+	assert.Equal(t, "grower@example.com", userDAO.users[userID].Email)
+	assert.Len(t, permissionDAO.permissions, 1)
+	assert.Equal(t, uint64(3), permissionDAO.permissions[0].OrganizationID)
+	assert.Equal(t, util.NewIdGenerator("").NewStringID(common.ROLE_CULTIVATOR),
+		permissionDAO.permissions[0].RoleID)
+
+	// Invitations can only be accepted once
+	_, err = invitationService.Accept(InvitationAcceptance{Token: token, Password: "newpassword"})
+	assert.Equal(t, ErrInvitationAccepted, err)
+	pending, err = invitationService.List(session)
+	assert.Nil(t, err)
+	assert.Empty(t, pending)
+
+	// Accepting an invitation to an organization the user already belongs
+	// to updates the user's role instead of adding another permission
+	_, err = invitationService.Invite(session, InvitationRequest{Email: "grower@example.com"})
+	assert.Nil(t, err)
+	_, err = invitationService.Accept(InvitationAcceptance{Token: invitationToken(t, mailer)})
+	assert.Nil(t, err)
+	assert.Len(t, permissionDAO.permissions, 1)
+	assert.Equal(t, util.NewIdGenerator("").NewStringID(common.ROLE_ANALYST),
+		permissionDAO.permissions[0].RoleID)
+}
+
+func TestInvitationFarm(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	invitationService, _, _, permissionDAO, mailer := createInvitationTestService(&now)
+	farmSession := invitationTestSession(0, 7, common.PermissionCatalog...)
+
+	invitation, err := invitationService.Invite(farmSession, InvitationRequest{Email: "grower@example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), invitation.GetOrganizationID())
+	assert.Equal(t, uint64(7), invitation.GetFarmID())
+	assert.Equal(t, common.ROLE_ANALYST, invitation.Role)
+
+	// Farm invitations aren't listed with the organization's invitations
+	pending, err := invitationService.List(invitationTestSession(3, 0, common.PermissionCatalog...))
+	assert.Nil(t, err)
+	assert.Empty(t, pending)
+	_, err = invitationService.Revoke(invitationTestSession(3, 0, common.PermissionCatalog...), invitation.ID)
+	assert.Equal(t, ErrInvitationNotFound, err)
+
+	_, err = invitationService.Accept(InvitationAcceptance{
+		Token: invitationToken(t, mailer), Password: "newpassword"})
+	assert.Nil(t, err)
+	assert.Len(t, permissionDAO.permissions, 1)
+	assert.Equal(t, uint64(3), permissionDAO.permissions[0].OrganizationID)
+	assert.Equal(t, uint64(7), permissionDAO.permissions[0].FarmID)
+}
+
+func TestInvitationRevokeAndExpire(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	invitationService, _, _, _, mailer := createInvitationTestService(&now)
+	session := invitationTestSession(3, 0, common.PermissionCatalog...)
+
+	invitation, err := invitationService.Invite(session, InvitationRequest{Email: "grower@example.com"})
+	assert.Nil(t, err)
+	token := invitationToken(t, mailer)
+	revoked, err := invitationService.Revoke(session, invitation.ID)
+	assert.Nil(t, err)
+	assert.True(t, revoked.IsRevoked())
+	_, err = invitationService.Accept(InvitationAcceptance{Token: token, Password: "newpassword"})
+	assert.Equal(t, ErrInvalidInvitation, err)
+
+	_, err = invitationService.Invite(session, InvitationRequest{Email: "grower@example.com"})
+	assert.Nil(t, err)
+	token = invitationToken(t, mailer)
+	now = now.Add(common.INVITATION_EXPIRATION*time.Second + time.Second)
+	_, err = invitationService.Accept(InvitationAcceptance{Token: token, Password: "newpassword"})
+	assert.Equal(t, ErrInvalidInvitation, err)
+	pending, err := invitationService.List(session)
+	assert.Nil(t, err)
+	assert.Empty(t, pending)
+
+	_, err = invitationService.Accept(InvitationAcceptance{Token: "1.deadbeef"})
+	assert.Equal(t, ErrInvalidInvitation, err)
+	_, err = invitationService.Accept(InvitationAcceptance{Token: "garbage"})
+	assert.Equal(t, ErrInvalidInvitation, err)
+}
+
+func TestInvitationSendFailure(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	invitationService, invitationDAO, _, _, mailer := createInvitationTestService(&now)
+	session := invitationTestSession(3, 0, common.PermissionCatalog...)
+
+	invitation, err := invitationService.Invite(session, InvitationRequest{Email: "grower@example.com"})
+	assert.Nil(t, err)
+
+	// A failed send doesn't leave an unusable invitation behind or revoke the one already sent
+	mailer.err = errors.New("smtp unavailable")
+	_, err = invitationService.Invite(session, InvitationRequest{Email: "grower@example.com"})
+	assert.Equal(t, mailer.err, err)
+	assert.Len(t, invitationDAO.invitations, 1)
+	assert.False(t, invitationDAO.invitations[invitation.ID].IsRevoked())
+	pending, err := invitationService.List(session)
+	assert.Nil(t, err)
+	assert.Len(t, pending, 1)
+}
+
+func TestInvitationPermissions(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	invitationService, _, _, _, _ := createInvitationTestService(&now)
+
+	_, err := invitationService.Invite(invitationTestSession(3, 0, common.PERMISSION_FARM_READ),
+		InvitationRequest{Email: "grower@example.com"})
+	assert.Equal(t, ErrPermissionDenied, err)
+
+	// Users can't invite others to a role with more permissions than their own
+	manager := invitationTestSession(3, 0, common.PERMISSION_USER_MANAGE,
+		common.PERMISSION_FARM_READ, common.PERMISSION_PROFILE_MANAGE)
+	_, err = invitationService.Invite(manager, InvitationRequest{
+		Email: "grower@example.com", Role: common.ROLE_ADMIN})
+	assert.ErrorIs(t, err, ErrInvitationPermissionEscalation)
+	_, err = invitationService.Invite(manager, InvitationRequest{
+		Email: "grower@example.com", Role: common.ROLE_ANALYST})
+	assert.Nil(t, err)
+
+	// Invitations must be scoped to an organization or farm
+	_, err = invitationService.Invite(invitationTestSession(0, 0, common.PermissionCatalog...),
+		InvitationRequest{Email: "grower@example.com"})
+	assert.Equal(t, ErrInvitationScopeRequired, err)
+
+	_, err = invitationService.Invite(manager, InvitationRequest{Email: "not an email"})
+	assert.Equal(t, ErrInvalidEmailAddress, err)
+}
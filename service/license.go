@@ -0,0 +1,519 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrQuotaExceeded           = errors.New("license quota exceeded")
+	ErrLicenseSignatureInvalid = errors.New("invalid license signature")
+	ErrLicenseSignerRequired   = errors.New("licenses can't be issued without a certificate authority")
+	ErrInvalidQuota            = errors.New("license quotas can't be negative")
+	ErrLicenseRequired         = errors.New("a valid server license is required")
+	ErrLicenseReplayed         = errors.New("license is older than the current license")
+	ErrLicenseExists           = errors.New("server license has already been issued")
+)
+
+// QuotaUsage is the number of licensed resources in use. A zero quota is
+// unlimited.
+type QuotaUsage struct {
+	Used  int `json:"used"`
+	Quota int `json:"quota"`
+}
+
+// LicenseUsage is the consumption of the server, organization or farm
+// license quotas. Only the quotas the license carries are populated.
+type LicenseUsage struct {
+	Organizations *QuotaUsage `json:"organizations,omitempty"`
+	Farms         *QuotaUsage `json:"farms,omitempty"`
+	Users         *QuotaUsage `json:"users,omitempty"`
+	Devices       *QuotaUsage `json:"devices,omitempty"`
+}
+
+type LicenseService interface {
+	Initialize() (*entity.License, error)
+	IssueServerLicense(session Session, license *config.ServerLicenseStruct) (*entity.License, error)
+	IssueOrganizationLicense(session Session, license *config.OrganizationLicenseStruct) (*entity.License, error)
+	IssueFarmLicense(session Session, license *config.FarmLicenseStruct) (*entity.License, error)
+	GetServerLicense() (*config.ServerLicenseStruct, error)
+	GetOrganizationLicense(orgID uint64) (*config.OrganizationLicenseStruct, error)
+	GetFarmLicense(farmID uint64) (*config.FarmLicenseStruct, error)
+	CheckOrganizationQuota() error
+	CheckFarmQuota(orgID uint64) error
+	CheckUserQuota(orgID, farmID, userID uint64) error
+	CheckDeviceQuota(farmID uint64, devices int) error
+	Usage(session Session) (*LicenseUsage, error)
+}
+
+type DefaultLicenseService struct {
+	logger          *logging.Logger
+	idGenerator     util.IdGenerator
+	licenseDAO      dao.LicenseDAO
+	orgDAO          dao.OrganizationDAO
+	farmDAO         dao.FarmDAO
+	signer          AuditSigner
+	serviceRegistry ServiceRegistry
+	clock           func() time.Time
+	serials         map[uint64]uint64
+	mutex           sync.Mutex
+	LicenseService
+}
+
+// Creates a new license service that enforces the server, organization
+// and farm license quotas. Licenses are signed by the signer when they're
+// issued and verified every time they're read, so a license that's been
+// edited, deleted or replaced with an older license in the database is
+// rejected rather than enforced.
+func NewLicenseService(
+	logger *logging.Logger,
+	idGenerator util.IdGenerator,
+	licenseDAO dao.LicenseDAO,
+	orgDAO dao.OrganizationDAO,
+	farmDAO dao.FarmDAO,
+	signer AuditSigner,
+	serviceRegistry ServiceRegistry) LicenseService {
+
+	return &DefaultLicenseService{
+		logger:          logger,
+		idGenerator:     idGenerator,
+		licenseDAO:      licenseDAO,
+		orgDAO:          orgDAO,
+		farmDAO:         farmDAO,
+		signer:          signer,
+		serviceRegistry: serviceRegistry,
+		clock:           time.Now,
+		serials:         make(map[uint64]uint64)}
+}
+
+// Issues the initial, unlimited server license when a new database is
+// initialized. Returns ErrLicenseExists if the server is already licensed.
+func (service *DefaultLicenseService) Initialize() (*entity.License, error) {
+	if service.signer == nil {
+		return nil, ErrLicenseSignerRequired
+	}
+	id := service.licenseID(common.LICENSE_TYPE_SERVER, 0)
+	if current, err := service.licenseDAO.Get(id, common.CONSISTENCY_LOCAL); err == nil && current != nil && current.ID != 0 {
+		return nil, ErrLicenseExists
+	}
+	license := &entity.License{Type: common.LICENSE_TYPE_SERVER}
+	if err := service.sign(license, 1); err != nil {
+		return nil, err
+	}
+	if err := service.licenseDAO.Save(license); err != nil {
+		return nil, err
+	}
+	service.witness(license)
+	service.logger.Info("Issued initial server license")
+	return license, nil
+}
+
+// Issues the server license, replacing the current license. The current
+// server license must be valid.
+func (service *DefaultLicenseService) IssueServerLicense(session Session,
+	license *config.ServerLicenseStruct) (*entity.License, error) {
+
+	return service.issue(session, &entity.License{
+		Type:              common.LICENSE_TYPE_SERVER,
+		OrganizationQuota: license.GetOrganizationQuota()})
+}
+
+// Issues an organization license, replacing the organization's current license
+func (service *DefaultLicenseService) IssueOrganizationLicense(session Session,
+	license *config.OrganizationLicenseStruct) (*entity.License, error) {
+
+	if _, err := service.orgDAO.Get(license.GetOrganizationID(), common.CONSISTENCY_LOCAL); err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return service.issue(session, &entity.License{
+		Type:      common.LICENSE_TYPE_ORGANIZATION,
+		SubjectID: license.GetOrganizationID(),
+		FarmQuota: license.GetFarmQuota(),
+		UserQuota: license.GetUserQuota()})
+}
+
+// Issues a farm license, replacing the farm's current license
+func (service *DefaultLicenseService) IssueFarmLicense(session Session,
+	license *config.FarmLicenseStruct) (*entity.License, error) {
+
+	if _, err := service.farmDAO.Get(license.GetFarmID(), common.CONSISTENCY_LOCAL); err != nil {
+		return nil, ErrFarmNotFound
+	}
+	return service.issue(session, &entity.License{
+		Type:        common.LICENSE_TYPE_FARM,
+		SubjectID:   license.GetFarmID(),
+		DeviceQuota: license.GetDeviceQuota(),
+		UserQuota:   license.GetUserQuota()})
+}
+
+// Returns the server license. Returns ErrLicenseRequired if the server
+// hasn't been licensed.
+func (service *DefaultLicenseService) GetServerLicense() (*config.ServerLicenseStruct, error) {
+	license, err := service.get(common.LICENSE_TYPE_SERVER, 0)
+	if err != nil {
+		return nil, err
+	}
+	return license.ServerLicense(), nil
+}
+
+// Returns the organization's license. An organization that's never been
+// licensed has unlimited quotas within a valid server license.
+func (service *DefaultLicenseService) GetOrganizationLicense(orgID uint64) (*config.OrganizationLicenseStruct, error) {
+	license, err := service.get(common.LICENSE_TYPE_ORGANIZATION, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return license.OrganizationLicense(), nil
+}
+
+// Returns the farm's license. A farm that's never been licensed has
+// unlimited quotas within a valid server license.
+func (service *DefaultLicenseService) GetFarmLicense(farmID uint64) (*config.FarmLicenseStruct, error) {
+	license, err := service.get(common.LICENSE_TYPE_FARM, farmID)
+	if err != nil {
+		return nil, err
+	}
+	return license.FarmLicense(), nil
+}
+
+// Returns ErrQuotaExceeded if the server license doesn't allow another
+// organization
+func (service *DefaultLicenseService) CheckOrganizationQuota() error {
+	license, err := service.GetServerLicense()
+	if err != nil {
+		return err
+	}
+	if license.GetOrganizationQuota() == 0 {
+		return nil
+	}
+	count, err := service.orgDAO.Count(common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return err
+	}
+	if int(count) >= license.GetOrganizationQuota() {
+		return fmt.Errorf("%w: the server is licensed for %d organizations",
+			ErrQuotaExceeded, license.GetOrganizationQuota())
+	}
+	return nil
+}
+
+// Returns ErrQuotaExceeded if the organization's license doesn't allow
+// another farm. Farms that don't belong to an organization aren't limited.
+func (service *DefaultLicenseService) CheckFarmQuota(orgID uint64) error {
+	if orgID == 0 {
+		return nil
+	}
+	license, err := service.GetOrganizationLicense(orgID)
+	if err != nil {
+		return err
+	}
+	if license.GetFarmQuota() == 0 {
+		return nil
+	}
+	org, err := service.orgDAO.Get(orgID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return err
+	}
+	if len(org.GetFarms()) >= license.GetFarmQuota() {
+		return fmt.Errorf("%w: organization %s is licensed for %d farms",
+			ErrQuotaExceeded, org.GetName(), license.GetFarmQuota())
+	}
+	return nil
+}
+
+// Returns ErrQuotaExceeded if adding the user to the organization and/or
+// farm would exceed either license's user quota. Users that already belong
+// to the organization or farm don't count against the quota again.
+func (service *DefaultLicenseService) CheckUserQuota(orgID, farmID, userID uint64) error {
+	if orgID > 0 {
+		license, err := service.GetOrganizationLicense(orgID)
+		if err != nil {
+			return err
+		}
+		if license.GetUserQuota() > 0 {
+			users, err := service.orgDAO.GetUsers(orgID)
+			if err != nil && !errors.Is(err, datastore.ErrRecordNotFound) {
+				return err
+			}
+			if !service.hasUser(users, userID) && len(users) >= license.GetUserQuota() {
+				return fmt.Errorf("%w: organization %d is licensed for %d users",
+					ErrQuotaExceeded, orgID, license.GetUserQuota())
+			}
+		}
+	}
+	if farmID > 0 {
+		license, err := service.GetFarmLicense(farmID)
+		if err != nil {
+			return err
+		}
+		if license.GetUserQuota() > 0 {
+			farm, err := service.farmDAO.Get(farmID, common.CONSISTENCY_LOCAL)
+			if err != nil {
+				return err
+			}
+			users := farm.GetUsers()
+			if !service.hasUser(users, userID) && len(users) >= license.GetUserQuota() {
+				return fmt.Errorf("%w: farm %s is licensed for %d users",
+					ErrQuotaExceeded, farm.GetName(), license.GetUserQuota())
+			}
+		}
+	}
+	return nil
+}
+
+// Returns ErrQuotaExceeded if the farm isn't licensed for the number of
+// devices
+func (service *DefaultLicenseService) CheckDeviceQuota(farmID uint64, devices int) error {
+	license, err := service.GetFarmLicense(farmID)
+	if err != nil {
+		return err
+	}
+	if license.GetDeviceQuota() > 0 && devices > license.GetDeviceQuota() {
+		return fmt.Errorf("%w: farm %d is licensed for %d devices",
+			ErrQuotaExceeded, farmID, license.GetDeviceQuota())
+	}
+	return nil
+}
+
+// Returns the license consumption of the farm or organization the session
+// is scoped to, or the server's consumption for unscoped system admin
+// sessions.
+func (service *DefaultLicenseService) Usage(session Session) (*LicenseUsage, error) {
+	if farmID := session.GetRequestedFarmID(); farmID > 0 {
+		if !session.HasPermission(common.PERMISSION_FARM_READ) {
+			return nil, ErrPermissionDenied
+		}
+		license, err := service.GetFarmLicense(farmID)
+		if err != nil {
+			return nil, err
+		}
+		farm, err := NewTenantFarmDAO(session, service.farmDAO, service.orgDAO).Get(farmID, common.CONSISTENCY_LOCAL)
+		if errors.Is(err, ErrTenantAccessDenied) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, ErrFarmNotFound
+		}
+		return &LicenseUsage{
+			Users:   &QuotaUsage{Used: len(farm.GetUsers()), Quota: license.GetUserQuota()},
+			Devices: &QuotaUsage{Used: countDevices(farm), Quota: license.GetDeviceQuota()}}, nil
+	}
+	if orgID := session.GetRequestedOrganizationID(); orgID > 0 {
+		if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
+			return nil, ErrPermissionDenied
+		}
+		license, err := service.GetOrganizationLicense(orgID)
+		if err != nil {
+			return nil, err
+		}
+		orgDAO := NewTenantOrganizationDAO(session, service.orgDAO)
+		org, err := orgDAO.Get(orgID, common.CONSISTENCY_LOCAL)
+		if errors.Is(err, ErrTenantAccessDenied) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, ErrOrganizationNotFound
+		}
+		users, err := orgDAO.GetUsers(orgID)
+		if err != nil && !errors.Is(err, datastore.ErrRecordNotFound) {
+			return nil, err
+		}
+		return &LicenseUsage{
+			Farms: &QuotaUsage{Used: len(org.GetFarms()), Quota: license.GetFarmQuota()},
+			Users: &QuotaUsage{Used: len(users), Quota: license.GetUserQuota()}}, nil
+	}
+	if !session.HasPermission(common.PERMISSION_SYSTEM_ADMIN) {
+		return nil, ErrPermissionDenied
+	}
+	license, err := service.GetServerLicense()
+	if err != nil {
+		return nil, err
+	}
+	count, err := service.orgDAO.Count(common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, err
+	}
+	return &LicenseUsage{
+		Organizations: &QuotaUsage{Used: int(count), Quota: license.GetOrganizationQuota()}}, nil
+}
+
+// Signs and saves the license with the next serial number. Licenses can
+// only be issued by system administrators, not service accounts, and only
+// while the server license is valid.
+func (service *DefaultLicenseService) issue(session Session, license *entity.License) (*entity.License, error) {
+	if !session.HasPermission(common.PERMISSION_SYSTEM_ADMIN) ||
+		session.HasRole(common.ROLE_SERVICE_ACCOUNT) {
+		return nil, ErrPermissionDenied
+	}
+	if license.OrganizationQuota < 0 || license.FarmQuota < 0 ||
+		license.UserQuota < 0 || license.DeviceQuota < 0 {
+		return nil, ErrInvalidQuota
+	}
+	if service.signer == nil {
+		return nil, ErrLicenseSignerRequired
+	}
+	if _, err := service.get(common.LICENSE_TYPE_SERVER, 0); err != nil {
+		return nil, err
+	}
+	license.ID = service.licenseID(license.Type, license.SubjectID)
+	var before *entity.License
+	current, err := service.licenseDAO.Get(license.ID, common.CONSISTENCY_LOCAL)
+	if err != nil && !errors.Is(err, datastore.ErrRecordNotFound) {
+		return nil, err
+	}
+	service.mutex.Lock()
+	serial := service.serials[license.ID]
+	service.mutex.Unlock()
+	if current != nil && current.ID != 0 {
+		before = current
+		if current.Serial > serial {
+			serial = current.Serial
+		}
+	}
+	if err := service.sign(license, serial+1); err != nil {
+		return nil, err
+	}
+	if err := service.save(license); err != nil {
+		return nil, err
+	}
+	service.logger.Infof("%s license %d issued for %d by %s: organizations=%d, farms=%d, users=%d, devices=%d",
+		license.Type, license.Serial, license.SubjectID, session.GetUser().GetEmail(),
+		license.OrganizationQuota, license.FarmQuota, license.UserQuota, license.DeviceQuota)
+	recordAudit(service.logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_LICENSE_ISSUE, "license", license.ID, before, license)
+	return license, nil
+}
+
+// Sets the license ID, serial and issue time and signs the license
+func (service *DefaultLicenseService) sign(license *entity.License, serial uint64) error {
+	license.ID = service.licenseID(license.Type, license.SubjectID)
+	license.Serial = serial
+	license.IssuedAt = service.clock().UTC().Truncate(time.Second)
+	signature, err := service.signer.Sign(license.SigningBytes())
+	if err != nil {
+		return err
+	}
+	license.Signature = base64.StdEncoding.EncodeToString(signature)
+	return nil
+}
+
+// Saves the license unless a license with the same or a newer serial has
+// been stored since it was signed
+func (service *DefaultLicenseService) save(license *entity.License) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	current, err := service.licenseDAO.Get(license.ID, common.CONSISTENCY_LOCAL)
+	if err != nil && !errors.Is(err, datastore.ErrRecordNotFound) {
+		return err
+	}
+	if (current != nil && current.Serial >= license.Serial) || service.serials[license.ID] >= license.Serial {
+		return ErrLicenseReplayed
+	}
+	if err := service.licenseDAO.Save(license); err != nil {
+		return err
+	}
+	service.serials[license.ID] = license.Serial
+	return nil
+}
+
+// Records the license serial as the newest serial seen for the license
+func (service *DefaultLicenseService) witness(license *entity.License) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if license.Serial > service.serials[license.ID] {
+		service.serials[license.ID] = license.Serial
+	}
+}
+
+// Returns the verified license. Organizations and farms that have never been
+// licensed get an unlimited license as long as the server license is valid;
+// a missing server license, or a license that's gone missing or been replaced
+// with an older one since it was read, returns an error so the quotas fail
+// closed.
+func (service *DefaultLicenseService) get(licenseType string, subjectID uint64) (*entity.License, error) {
+	id := service.licenseID(licenseType, subjectID)
+	license, err := service.licenseDAO.Get(id, common.CONSISTENCY_LOCAL)
+	if err != nil && !errors.Is(err, datastore.ErrRecordNotFound) {
+		return nil, err
+	}
+	service.mutex.Lock()
+	seen := service.serials[id]
+	service.mutex.Unlock()
+	if license == nil || license.ID == 0 {
+		if licenseType == common.LICENSE_TYPE_SERVER || seen > 0 {
+			service.logger.Errorf("Missing %s license for %d", licenseType, subjectID)
+			return nil, ErrLicenseRequired
+		}
+		if _, err := service.get(common.LICENSE_TYPE_SERVER, 0); err != nil {
+			return nil, err
+		}
+		return &entity.License{ID: id, Type: licenseType, SubjectID: subjectID}, nil
+	}
+	if err := service.verify(license); err != nil {
+		service.logger.Errorf("Rejecting %s license for %d: %s", licenseType, subjectID, err)
+		if licenseType == common.LICENSE_TYPE_SERVER {
+			return nil, ErrLicenseRequired
+		}
+		return nil, err
+	}
+	if license.Serial < seen {
+		service.logger.Errorf("Rejecting %s license for %d: serial %d is older than %d",
+			licenseType, subjectID, license.Serial, seen)
+		return nil, ErrLicenseReplayed
+	}
+	service.witness(license)
+	return license, nil
+}
+
+// Verifies the license was signed by the signer and hasn't been modified
+func (service *DefaultLicenseService) verify(license *entity.License) error {
+	if service.signer == nil || license.Signature == "" {
+		return ErrLicenseSignatureInvalid
+	}
+	signature, err := base64.StdEncoding.DecodeString(license.Signature)
+	if err != nil {
+		return ErrLicenseSignatureInvalid
+	}
+	if license.ID != service.licenseID(license.Type, license.SubjectID) {
+		return ErrLicenseSignatureInvalid
+	}
+	if err := service.signer.VerifySignature(license.SigningBytes(), signature); err != nil {
+		return ErrLicenseSignatureInvalid
+	}
+	return nil
+}
+
+func (service *DefaultLicenseService) licenseID(licenseType string, subjectID uint64) uint64 {
+	return service.idGenerator.NewStringID(fmt.Sprintf("license-%s-%d", licenseType, subjectID))
+}
+
+func (service *DefaultLicenseService) hasUser(users []*config.UserStruct, userID uint64) bool {
+	for _, user := range users {
+		if user.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the number of devices in the farm, excluding the server
+func countDevices(farm *config.FarmStruct) int {
+	devices := 0
+	for _, device := range farm.GetDevices() {
+		if device.GetType() != common.CONTROLLER_TYPE_SERVER {
+			devices++
+		}
+	}
+	return devices
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLicenseDAO struct {
+	licenses map[uint64]*entity.License
+	dao.LicenseDAO
+}
+
+func (licenseDAO *fakeLicenseDAO) Save(license *entity.License) error {
+	stored := *license
+	licenseDAO.licenses[license.ID] = &stored
+	return nil
+}
+
+func (licenseDAO *fakeLicenseDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.License, error) {
+	license, ok := licenseDAO.licenses[id]
+	if !ok {
+		return nil, datastore.ErrRecordNotFound
+	}
+	persisted := *license
+	return &persisted, nil
+}
+
+type fakeLicenseOrgDAO struct {
+	orgs map[uint64]*config.OrganizationStruct
+	dao.OrganizationDAO
+}
+
+func (orgDAO *fakeLicenseOrgDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.OrganizationStruct, error) {
+	if org, ok := orgDAO.orgs[id]; ok {
+		return org, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (orgDAO *fakeLicenseOrgDAO) GetUsers(id uint64) ([]*config.UserStruct, error) {
+	if org, ok := orgDAO.orgs[id]; ok {
+		return org.Users, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (orgDAO *fakeLicenseOrgDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	return int64(len(orgDAO.orgs)), nil
+}
+
+func licenseTestSession(orgID, farmID uint64, permissions ...string) Session {
+	var orgClaims []OrganizationClaim
+	if orgID > 0 {
+		orgClaims = []OrganizationClaim{{ID: orgID}}
+	}
+	var farmClaims []FarmClaim
+	if farmID > 0 {
+		farmClaims = []FarmClaim{{ID: farmID}}
+	}
+	return CreateSession(logging.MustGetLogger("license_test"), orgClaims,
+		farmClaims, nil, orgID, farmID, common.CONSISTENCY_LOCAL,
+		&model.UserStruct{
+			ID:    1,
+			Email: "admin@example.com",
+			Roles: []model.Role{&model.RoleStruct{
+				Name:        common.ROLE_ADMIN,
+				Permissions: permissions}}})
+}
+
+func createLicenseTestService(t *testing.T) (*DefaultLicenseService, *fakeLicenseDAO) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	farm := config.NewFarm()
+	farm.ID = 7
+	farm.OrganizationID = 3
+	farm.Name = "Greenhouse"
+	farm.SetUsers([]*config.UserStruct{{ID: 1}, {ID: 2}})
+	farm.SetDevices([]*config.DeviceStruct{
+		{ID: 1, Type: common.CONTROLLER_TYPE_SERVER},
+		{ID: 2, Type: "room"},
+		{ID: 3, Type: "reservoir"}})
+	orgDAO := &fakeLicenseOrgDAO{orgs: map[uint64]*config.OrganizationStruct{
+		3: {ID: 3, Name: "Acme Farms",
+			Farms: []*config.FarmStruct{farm},
+			Users: []*config.UserStruct{{ID: 1}, {ID: 2}, {ID: 4}}}}}
+	licenseDAO := &fakeLicenseDAO{licenses: make(map[uint64]*entity.License)}
+	licenseService := NewLicenseService(logging.MustGetLogger("license_test"),
+		util.NewIdGenerator(""), licenseDAO, orgDAO,
+		&fakeFarmDAO{farms: map[uint64]*config.FarmStruct{7: farm}},
+		&fakeAuditSigner{key: key}, nil).(*DefaultLicenseService)
+	licenseService.clock = func() time.Time {
+		return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	}
+	_, err = licenseService.Initialize()
+	assert.Nil(t, err)
+	return licenseService, licenseDAO
+}
+
+func TestLicenseUnlicensed(t *testing.T) {
+	service, licenseDAO := createLicenseTestService(t)
+
+	// The initial server license doesn't limit organizations, farms or users
+	assert.Nil(t, service.CheckOrganizationQuota())
+	assert.Nil(t, service.CheckFarmQuota(3))
+	assert.Nil(t, service.CheckUserQuota(3, 7, 99))
+	assert.Nil(t, service.CheckDeviceQuota(7, 100))
+	_, err := service.Initialize()
+	assert.Equal(t, ErrLicenseExists, err)
+
+	// Without a server license every quota check fails closed
+	serverLicenseID := service.licenseID(common.LICENSE_TYPE_SERVER, 0)
+	delete(licenseDAO.licenses, serverLicenseID)
+	service.serials = make(map[uint64]uint64)
+	assert.Equal(t, ErrLicenseRequired, service.CheckOrganizationQuota())
+	assert.Equal(t, ErrLicenseRequired, service.CheckFarmQuota(3))
+	assert.Equal(t, ErrLicenseRequired, service.CheckUserQuota(3, 7, 99))
+	assert.Equal(t, ErrLicenseRequired, service.CheckDeviceQuota(7, 100))
+	_, err = service.IssueFarmLicense(licenseTestSession(0, 0, common.PERMISSION_SYSTEM_ADMIN),
+		&config.FarmLicenseStruct{FarmID: 7})
+	assert.Equal(t, ErrLicenseRequired, err)
+}
+
+func TestLicenseIssue(t *testing.T) {
+	service, licenseDAO := createLicenseTestService(t)
+
+	// Only system admins can issue licenses
+	_, err := service.IssueServerLicense(licenseTestSession(0, 0, common.PERMISSION_USER_MANAGE),
+		&config.ServerLicenseStruct{OrganizationQuota: 1})
+	assert.Equal(t, ErrPermissionDenied, err)
+	_, err = service.IssueServerLicense(CreateSession(logging.MustGetLogger("license_test"),
+		nil, nil, nil, 0, 0, common.CONSISTENCY_LOCAL, &model.UserStruct{
+			ID:    2,
+			Email: "grafana@service.local",
+			Roles: []model.Role{&model.RoleStruct{
+				Name:        common.ROLE_SERVICE_ACCOUNT,
+				Permissions: []string{common.PERMISSION_SYSTEM_ADMIN}}}}),
+		&config.ServerLicenseStruct{OrganizationQuota: 1})
+	assert.Equal(t, ErrPermissionDenied, err)
+
+	session := licenseTestSession(0, 0, common.PERMISSION_SYSTEM_ADMIN)
+	_, err = service.IssueFarmLicense(session, &config.FarmLicenseStruct{FarmID: 7, DeviceQuota: -1})
+	assert.Equal(t, ErrInvalidQuota, err)
+	_, err = service.IssueFarmLicense(session, &config.FarmLicenseStruct{FarmID: 8})
+	assert.Equal(t, ErrFarmNotFound, err)
+	_, err = service.IssueOrganizationLicense(session, &config.OrganizationLicenseStruct{OrganizationID: 4})
+	assert.Equal(t, ErrOrganizationNotFound, err)
+
+	license, err := service.IssueOrganizationLicense(session,
+		&config.OrganizationLicenseStruct{OrganizationID: 3, FarmQuota: 2, UserQuota: 5})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, license.Signature)
+	assert.Equal(t, common.LICENSE_TYPE_ORGANIZATION, license.Type)
+
+	orgLicense, err := service.GetOrganizationLicense(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, orgLicense.GetFarmQuota())
+	assert.Equal(t, 5, orgLicense.GetUserQuota())
+
+	assert.Equal(t, uint64(1), license.Serial)
+
+	// Reissuing replaces the license with the next serial
+	license, err = service.IssueOrganizationLicense(session,
+		&config.OrganizationLicenseStruct{OrganizationID: 3, FarmQuota: 4})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), license.Serial)
+	assert.Equal(t, 2, len(licenseDAO.licenses))
+	orgLicense, err = service.GetOrganizationLicense(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, orgLicense.GetFarmQuota())
+	assert.Equal(t, 0, orgLicense.GetUserQuota())
+}
+
+func TestLicenseTampered(t *testing.T) {
+	service, licenseDAO := createLicenseTestService(t)
+	session := licenseTestSession(0, 0, common.PERMISSION_SYSTEM_ADMIN)
+
+	license, err := service.IssueFarmLicense(session, &config.FarmLicenseStruct{FarmID: 7, DeviceQuota: 2})
+	assert.Nil(t, err)
+
+	// Raising the quota in the datastore invalidates the signature
+	licenseDAO.licenses[license.ID].DeviceQuota = 50
+	_, err = service.GetFarmLicense(7)
+	assert.Equal(t, ErrLicenseSignatureInvalid, err)
+	assert.ErrorIs(t, service.CheckDeviceQuota(7, 3), ErrLicenseSignatureInvalid)
+
+	// So does moving the license to another farm
+	licenseDAO.licenses[license.ID].DeviceQuota = 2
+	licenseDAO.licenses[license.ID].SubjectID = 8
+	_, err = service.GetFarmLicense(7)
+	assert.Equal(t, ErrLicenseSignatureInvalid, err)
+
+	// A tampered server license fails every quota check closed
+	serverLicense := licenseDAO.licenses[service.licenseID(common.LICENSE_TYPE_SERVER, 0)]
+	serverLicense.OrganizationQuota = 1
+	assert.Equal(t, ErrLicenseRequired, service.CheckFarmQuota(3))
+	serverLicense.OrganizationQuota = 0
+
+	// Licenses can't be issued or verified without a signer
+	licenseDAO.licenses[license.ID].SubjectID = 7
+	service.signer = nil
+	_, err = service.GetFarmLicense(7)
+	assert.Equal(t, ErrLicenseSignatureInvalid, err)
+	_, err = service.IssueServerLicense(session, &config.ServerLicenseStruct{})
+	assert.Equal(t, ErrLicenseSignerRequired, err)
+}
+
+func TestLicenseReplay(t *testing.T) {
+	service, licenseDAO := createLicenseTestService(t)
+	session := licenseTestSession(0, 0, common.PERMISSION_SYSTEM_ADMIN)
+
+	license, err := service.IssueFarmLicense(session, &config.FarmLicenseStruct{FarmID: 7, DeviceQuota: 50})
+	assert.Nil(t, err)
+	replayed := *licenseDAO.licenses[license.ID]
+	_, err = service.IssueFarmLicense(session, &config.FarmLicenseStruct{FarmID: 7, DeviceQuota: 2})
+	assert.Nil(t, err)
+
+	// Restoring the older, validly signed license is rejected
+	licenseDAO.licenses[license.ID] = &replayed
+	_, err = service.GetFarmLicense(7)
+	assert.Equal(t, ErrLicenseReplayed, err)
+	assert.ErrorIs(t, service.CheckDeviceQuota(7, 3), ErrLicenseReplayed)
+
+	// Deleting a license doesn't lift its quotas
+	delete(licenseDAO.licenses, license.ID)
+	assert.Equal(t, ErrLicenseRequired, service.CheckDeviceQuota(7, 3))
+
+	// Reissuing restores the license with a newer serial
+	license, err = service.IssueFarmLicense(session, &config.FarmLicenseStruct{FarmID: 7, DeviceQuota: 5})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), license.Serial)
+	assert.Nil(t, service.CheckDeviceQuota(7, 3))
+
+	// A license can't be saved over a license with the same or a newer serial
+	assert.Equal(t, ErrLicenseReplayed, service.save(&replayed))
+}
+
+func TestLicenseQuotas(t *testing.T) {
+	service, _ := createLicenseTestService(t)
+	session := licenseTestSession(0, 0, common.PERMISSION_SYSTEM_ADMIN)
+
+	_, err := service.IssueServerLicense(session, &config.ServerLicenseStruct{OrganizationQuota: 1})
+	assert.Nil(t, err)
+	assert.ErrorIs(t, service.CheckOrganizationQuota(), ErrQuotaExceeded)
+
+	_, err = service.IssueOrganizationLicense(session,
+		&config.OrganizationLicenseStruct{OrganizationID: 3, FarmQuota: 1, UserQuota: 3})
+	assert.Nil(t, err)
+	assert.ErrorIs(t, service.CheckFarmQuota(3), ErrQuotaExceeded)
+	// Farms without an organization aren't limited
+	assert.Nil(t, service.CheckFarmQuota(0))
+	// Existing members don't count against the quota again
+	assert.Nil(t, service.CheckUserQuota(3, 0, 4))
+	assert.ErrorIs(t, service.CheckUserQuota(3, 0, 5), ErrQuotaExceeded)
+
+	_, err = service.IssueFarmLicense(session,
+		&config.FarmLicenseStruct{FarmID: 7, DeviceQuota: 2, UserQuota: 2})
+	assert.Nil(t, err)
+	assert.Nil(t, service.CheckDeviceQuota(7, 2))
+	assert.ErrorIs(t, service.CheckDeviceQuota(7, 3), ErrQuotaExceeded)
+	assert.Nil(t, service.CheckUserQuota(0, 7, 2))
+	assert.ErrorIs(t, service.CheckUserQuota(0, 7, 4), ErrQuotaExceeded)
+}
+
+func TestLicenseUsage(t *testing.T) {
+	service, _ := createLicenseTestService(t)
+	session := licenseTestSession(0, 0, common.PERMISSION_SYSTEM_ADMIN)
+	_, err := service.IssueFarmLicense(session, &config.FarmLicenseStruct{FarmID: 7, DeviceQuota: 5})
+	assert.Nil(t, err)
+
+	usage, err := service.Usage(session)
+	assert.Nil(t, err)
+	assert.Equal(t, &QuotaUsage{Used: 1, Quota: 0}, usage.Organizations)
+	assert.Nil(t, usage.Farms)
+
+	usage, err = service.Usage(licenseTestSession(3, 0, common.PERMISSION_USER_MANAGE))
+	assert.Nil(t, err)
+	assert.Equal(t, &QuotaUsage{Used: 1, Quota: 0}, usage.Farms)
+	assert.Equal(t, &QuotaUsage{Used: 3, Quota: 0}, usage.Users)
+
+	// The server isn't counted as a licensed device
+	usage, err = service.Usage(licenseTestSession(3, 7, common.PERMISSION_FARM_READ))
+	assert.Nil(t, err)
+	assert.Equal(t, &QuotaUsage{Used: 2, Quota: 5}, usage.Devices)
+	assert.Equal(t, &QuotaUsage{Used: 2, Quota: 0}, usage.Users)
+
+	_, err = service.Usage(licenseTestSession(0, 0, common.PERMISSION_FARM_READ))
+	assert.Equal(t, ErrPermissionDenied, err)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"html/template"
 	"regexp"
+	"time"
 
 	"github.com/jeremyhahn/go-cropdroid/app"
 	"github.com/jeremyhahn/go-cropdroid/common"
@@ -26,6 +27,10 @@ var (
 	ErrUserAlreadyExists          = errors.New("user already exists")
 	ErrOrgRegistrationUnsupported = errors.New("organization registration unsupported")
 	ErrInvalidEmailAddress        = errors.New("invalid email address")
+	ErrAccountDisabled            = errors.New("account disabled")
+	ErrAccountLocked              = errors.New("account locked after too many failed logins, try again later")
+
+	emailPattern = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 )
 
 type LocalAuthService struct {
@@ -36,8 +41,12 @@ type LocalAuthService struct {
 	orgDAO        dao.OrganizationDAO
 	farmDAO       dao.FarmDAO
 	userDAO       dao.UserDAO
+	emails        *userEmailResolver
 	roleDAO       dao.RoleDAO
 	mapper        mapper.UserMapper
+	mfaService    MFAService
+	lockout       *AccountLockout
+	clock         func() time.Time
 	AuthServicer
 }
 
@@ -50,8 +59,10 @@ func NewLocalAuthService(
 	orgDAO dao.OrganizationDAO,
 	farmDAO dao.FarmDAO,
 	userDAO dao.UserDAO,
+	userEmailDAO dao.UserEmailDAO,
 	roleDAO dao.RoleDAO,
-	userMapper mapper.UserMapper) AuthServicer {
+	userMapper mapper.UserMapper,
+	mfaService MFAService) AuthServicer {
 
 	return &LocalAuthService{
 		app:           app,
@@ -61,8 +72,12 @@ func NewLocalAuthService(
 		orgDAO:        orgDAO,
 		farmDAO:       farmDAO,
 		userDAO:       userDAO,
+		emails:        newUserEmailResolver(util.NewIdGenerator(app.DataStoreEngine), userDAO, userEmailDAO),
 		roleDAO:       roleDAO,
-		mapper:        userMapper}
+		mapper:        userMapper,
+		mfaService:    mfaService,
+		lockout:       NewAccountLockout(app, userDAO),
+		clock:         time.Now}
 }
 
 // Looks up the specified user from the data store by email address
@@ -79,13 +94,16 @@ func NewLocalAuthService(
 // }
 
 // ResetPassword looks the user up from the database, encrypts the UserCredentials.Password
-// and updates the database with the encrypted value.
+// and updates the database with the encrypted value. The password must satisfy the
+// password policy.
 func (service *LocalAuthService) ResetPassword(userCredentials *UserCredentials) error {
-	userID := service.idGenerator.NewStringID(userCredentials.Email)
-	userEntity, err := service.userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	userEntity, err := service.emails.getUser(userCredentials.Email)
 	if err != nil {
 		return err
 	}
+	if err := service.app.PasswordPolicy.Validate(userCredentials.Password); err != nil {
+		return err
+	}
 	encrypted, err := service.encryptPassword(userCredentials.Password)
 	if err != nil {
 		return err
@@ -96,14 +114,17 @@ func (service *LocalAuthService) ResetPassword(userCredentials *UserCredentials)
 
 // Login takes a set of credentials and returns a list of organizations with the farms
 // the user has permission to access, minimally populated. No device or workflow data
-// will be contained with the farm(s).
+// will be contained with the farm(s). Users enrolled in MFA, or who belong to an
+// organization that requires MFA, are returned an *MFAChallengeError instead; the
+// login is completed by VerifyMFA. Accounts are locked for an exponentially
+// increasing delay once MaxFailedLogins consecutive password or second factor
+// attempts have failed.
 func (service *LocalAuthService) Login(userCredentials *UserCredentials) (model.User,
 	[]config.Organization, []config.Farm, error) {
 
 	service.app.Logger.Debugf("Authenticating user: %s", userCredentials.Email)
 
-	userID := service.idGenerator.NewStringID(userCredentials.Email)
-	userEntity, err := service.userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	userEntity, err := service.emails.getUser(userCredentials.Email)
 	if err != nil && err.Error() != ErrRecordNotFound.Error() {
 		return nil, nil, nil, ErrInvalidCredentials
 	}
@@ -111,13 +132,68 @@ func (service *LocalAuthService) Login(userCredentials *UserCredentials) (model.
 		return nil, nil, nil, err
 	}
 
+	now := service.clock()
+	if userEntity.IsLocked(now) {
+		service.app.Logger.Warningf("[UNAUTHORIZED] Login attempt for locked account: %s", userEntity.Email)
+		return nil, nil, nil, ErrAccountLocked
+	}
+
 	match, err := service.comparePassword(userCredentials.Password, userEntity.GetPassword())
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	if match == false {
+		service.lockout.Fail(userEntity, now)
 		return nil, nil, nil, ErrInvalidCredentials
 	}
+	if userEntity.IsDisabled() {
+		return nil, nil, nil, ErrAccountDisabled
+	}
+
+	organizations, err := service.permissionDAO.GetOrganizations(userEntity.ID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		service.app.Logger.Errorf("Error looking up organization user: %s", err)
+		return nil, nil, nil, err
+	}
+
+	// The failed attempts are only cleared once the login is complete so
+	// failed second factor attempts keep counting towards the lockout
+	if service.mfaService != nil {
+		mfaRequired := false
+		for _, org := range organizations {
+			if org.IsMFARequired() {
+				mfaRequired = true
+				break
+			}
+		}
+		if err := service.mfaService.Challenge(userEntity.ID, userEntity.Email, mfaRequired); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	service.lockout.Reset(userEntity)
+	userEntity.RedactPassword()
+
+	return service.loadUser(userEntity, organizations)
+}
+
+// VerifyMFA completes a login that was challenged for a second factor
+func (service *LocalAuthService) VerifyMFA(token, code string) (model.User,
+	[]config.Organization, []config.Farm, error) {
+
+	if service.mfaService == nil {
+		return nil, nil, nil, ErrMFAUnsupported
+	}
+	userID, err := service.mfaService.Verify(token, code)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	userEntity, err := service.userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if userEntity.IsDisabled() {
+		return nil, nil, nil, ErrAccountDisabled
+	}
 	userEntity.RedactPassword()
 
 	organizations, err := service.permissionDAO.GetOrganizations(userEntity.ID, common.CONSISTENCY_LOCAL)
@@ -125,6 +201,12 @@ func (service *LocalAuthService) Login(userCredentials *UserCredentials) (model.
 		service.app.Logger.Errorf("Error looking up organization user: %s", err)
 		return nil, nil, nil, err
 	}
+	return service.loadUser(userEntity, organizations)
+}
+
+// Returns the authenticated user along with their organizations and farms
+func (service *LocalAuthService) loadUser(userEntity *config.UserStruct,
+	organizations []*config.OrganizationStruct) (model.User, []config.Organization, []config.Farm, error) {
 
 	farms, err := service.farmDAO.GetByUserID(userEntity.ID, common.CONSISTENCY_LOCAL)
 	if err != nil {
@@ -152,18 +234,19 @@ func (service *LocalAuthService) Register(userCredentials *UserCredentials,
 		return nil, ErrRegistrationDisabled
 	}
 
-	pattern := regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
-	if !pattern.MatchString(userCredentials.Email) {
+	if !emailPattern.MatchString(userCredentials.Email) {
 		return nil, ErrInvalidEmailAddress
 	}
+	if err := service.app.PasswordPolicy.Validate(userCredentials.Password); err != nil {
+		return nil, err
+	}
 
-	userID := service.idGenerator.NewStringID(userCredentials.Email)
-	persistedUser, err := service.userDAO.Get(userID, common.CONSISTENCY_LOCAL)
-	if err != nil && err.Error() != ErrRecordNotFound.Error() {
+	inUse, err := service.emails.inUse(userCredentials.Email, 0)
+	if err != nil {
 		service.app.Logger.Errorf("%s", err.Error())
 		return nil, fmt.Errorf("Unexpected error: %s", err.Error())
 	}
-	if persistedUser != nil {
+	if inUse {
 		return nil, ErrUserAlreadyExists
 	}
 	encrypted, err := service.encryptPassword(userCredentials.Password)
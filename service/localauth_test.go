@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/mapper"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalAuthLockout(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_app := &app.App{
+		Logger:          logging.MustGetLogger("localauth_test"),
+		MaxFailedLogins: 3,
+		LockoutDelay:    30,
+		PasswordHasherParams: &util.PasswordHasherParams{
+			Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+	password, err := util.CreatePasswordHasher(_app.PasswordHasherParams).Encrypt("password")
+	assert.Nil(t, err)
+	user := &config.UserStruct{
+		ID:       util.NewIdGenerator("").NewStringID("grower@example.com"),
+		Email:    "grower@example.com",
+		Password: password}
+	userDAO := &fakeOIDCUserDAO{users: map[uint64]*config.UserStruct{user.ID: user}}
+	authService := NewLocalAuthService(_app, &fakeOIDCPermissionDAO{}, nil, nil,
+		&fakeAPIKeyFarmDAO{}, userDAO, nil, &fakeOIDCRoleDAO{}, mapper.NewUserMapper(), nil).(*LocalAuthService)
+	authService.clock = func() time.Time { return now }
+
+	badCredentials := &UserCredentials{Email: "grower@example.com", Password: "wrong"}
+	for i := 0; i < 2; i++ {
+		_, _, _, err = authService.Login(badCredentials)
+		assert.Equal(t, ErrInvalidCredentials, err)
+	}
+	assert.False(t, user.IsLocked(now))
+
+	_, _, _, err = authService.Login(badCredentials)
+	assert.Equal(t, ErrInvalidCredentials, err)
+	assert.Equal(t, now.Add(30*time.Second), user.LockedUntil)
+
+	// The correct password is refused while the account is locked
+	_, _, _, err = authService.Login(&UserCredentials{Email: "grower@example.com", Password: "password"})
+	assert.Equal(t, ErrAccountLocked, err)
+
+	// Each failure past the limit doubles the delay
+	now = now.Add(31 * time.Second)
+	_, _, _, err = authService.Login(badCredentials)
+	assert.Equal(t, ErrInvalidCredentials, err)
+	assert.Equal(t, 4, user.FailedLogins)
+	assert.Equal(t, now.Add(60*time.Second), user.LockedUntil)
+}
+
+type fakeChallengeMFAService struct {
+	MFAService
+}
+
+func (mfaService *fakeChallengeMFAService) Challenge(userID uint64, account string, required bool) error {
+	return &MFAChallengeError{Token: "challenge"}
+}
+
+func TestLocalAuthLockoutWithMFA(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_app := &app.App{
+		Logger:          logging.MustGetLogger("localauth_test"),
+		MaxFailedLogins: 3,
+		LockoutDelay:    30,
+		PasswordHasherParams: &util.PasswordHasherParams{
+			Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+	password, err := util.CreatePasswordHasher(_app.PasswordHasherParams).Encrypt("password")
+	assert.Nil(t, err)
+	user := &config.UserStruct{
+		ID:           util.NewIdGenerator("").NewStringID("grower@example.com"),
+		Email:        "grower@example.com",
+		Password:     password,
+		FailedLogins: 2}
+	userDAO := &fakeOIDCUserDAO{users: map[uint64]*config.UserStruct{user.ID: user}}
+	authService := NewLocalAuthService(_app, &fakeOIDCPermissionDAO{}, nil, nil,
+		&fakeAPIKeyFarmDAO{}, userDAO, nil, &fakeOIDCRoleDAO{}, mapper.NewUserMapper(),
+		&fakeChallengeMFAService{}).(*LocalAuthService)
+	authService.clock = func() time.Time { return now }
+
+	// A correct password doesn't clear the failed attempts until
+	// the second factor has been verified
+	_, _, _, err = authService.Login(&UserCredentials{Email: "grower@example.com", Password: "password"})
+	assert.ErrorIs(t, err, ErrMFARequired)
+	assert.Equal(t, 2, user.FailedLogins)
+	assert.NotEmpty(t, user.Password)
+}
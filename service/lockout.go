@@ -0,0 +1,76 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+)
+
+// AccountLockout counts the consecutive failed password and second factor
+// attempts for an account, locking the account once MaxFailedLogins attempts
+// have failed. The lockout starts at LockoutDelay seconds and doubles with
+// each additional failure, up to LOCKOUT_MAX_DELAY.
+type AccountLockout struct {
+	app     *app.App
+	userDAO dao.UserDAO
+}
+
+func NewAccountLockout(app *app.App, userDAO dao.UserDAO) *AccountLockout {
+	return &AccountLockout{
+		app:     app,
+		userDAO: userDAO}
+}
+
+// Returns the user's account, or ErrAccountLocked if the account is locked
+func (lockout *AccountLockout) Check(userID uint64, now time.Time) (*config.UserStruct, error) {
+	userEntity, err := lockout.userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, err
+	}
+	if userEntity.IsLocked(now) {
+		lockout.app.Logger.Warningf("[UNAUTHORIZED] Authentication attempt for locked account: %s", userEntity.Email)
+		return nil, ErrAccountLocked
+	}
+	return userEntity, nil
+}
+
+// Counts a failed attempt, locking the account once too many
+// consecutive attempts have failed
+func (lockout *AccountLockout) Fail(userEntity *config.UserStruct, now time.Time) {
+	if lockout == nil || userEntity == nil {
+		return
+	}
+	userEntity.FailedLogins++
+	maxFailedLogins := lockout.app.MaxFailedLogins
+	if maxFailedLogins > 0 && userEntity.FailedLogins >= maxFailedLogins {
+		delay := time.Duration(lockout.app.LockoutDelay) * time.Second
+		maxDelay := common.LOCKOUT_MAX_DELAY * time.Second
+		for i := maxFailedLogins; i < userEntity.FailedLogins && delay < maxDelay; i++ {
+			delay *= 2
+		}
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+		userEntity.LockedUntil = now.Add(delay)
+		lockout.app.Logger.Warningf("[UNAUTHORIZED] Account %s locked until %s after %d failed attempts",
+			userEntity.Email, userEntity.LockedUntil, userEntity.FailedLogins)
+	}
+	if err := lockout.userDAO.Save(userEntity); err != nil {
+		lockout.app.Logger.Errorf("Error saving failed attempt for %s: %s", userEntity.Email, err)
+	}
+}
+
+// Clears the failed attempts after a successful authentication
+func (lockout *AccountLockout) Reset(userEntity *config.UserStruct) {
+	if lockout == nil || userEntity == nil || userEntity.FailedLogins == 0 {
+		return
+	}
+	userEntity.FailedLogins = 0
+	userEntity.LockedUntil = time.Time{}
+	if err := lockout.userDAO.Save(userEntity); err != nil {
+		lockout.app.Logger.Errorf("Error resetting failed attempts for %s: %s", userEntity.Email, err)
+	}
+}
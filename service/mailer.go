@@ -2,27 +2,58 @@ package service
 
 import (
 	"bytes"
-	"fmt"
+	"crypto/tls"
+	"embed"
+	"errors"
 	"html/template"
+	"mime"
+	"net"
 	"net/smtp"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/jeremyhahn/go-cropdroid/app"
 	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/config"
 )
 
+var (
+	ErrInvalidSmtpConfig    = errors.New("invalid SMTP configuration")
+	ErrStartTLSNotSupported = errors.New("SMTP server does not support STARTTLS")
+	ErrMailQueueFull        = errors.New("mail queue full")
+
+	//go:embed templates/email/*.html
+	emailTemplateFS embed.FS
+	emailTemplates  = template.Must(template.ParseFS(emailTemplateFS, "templates/email/*.html"))
+)
+
 type GMailer struct {
-	app       *app.App
-	enabled   bool
-	host      string
-	port      int
-	username  string
-	password  string
-	recipient string
+	app         *app.App
+	enabled     bool
+	host        string
+	port        int
+	username    string
+	password    string
+	recipient   string
+	encryption  string
+	queue       chan *queuedEmail
+	queueOnce   *sync.Once
+	maxAttempts int
+	backoff     time.Duration
 	common.Mailer
 }
 
+// An email waiting to be delivered to a single recipient
+type queuedEmail struct {
+	recipient string
+	subject   string
+	body      string
+	html      bool
+	attempts  int
+}
+
 func NewMailer(app *app.App) common.Mailer {
 	return CreateMailer(app, app.Smtp)
 }
@@ -36,13 +67,18 @@ func CreateMailer(app *app.App, smtpConfig *config.SmtpStruct) common.Mailer {
 		}
 	}
 	return &GMailer{
-		app:       app,
-		enabled:   smtpConfig.IsEnabled(),
-		host:      smtpConfig.GetHost(),
-		port:      smtpConfig.GetPort(),
-		username:  smtpConfig.GetUsername(),
-		password:  smtpConfig.GetPassword(),
-		recipient: smtpConfig.GetRecipient()}
+		app:         app,
+		enabled:     smtpConfig.IsEnabled(),
+		host:        smtpConfig.GetHost(),
+		port:        smtpConfig.GetPort(),
+		username:    smtpConfig.GetUsername(),
+		password:    smtpConfig.GetPassword(),
+		recipient:   smtpConfig.GetRecipient(),
+		encryption:  strings.ToLower(smtpConfig.GetEncryption()),
+		queue:       make(chan *queuedEmail, common.BUFFERED_CHANNEL_SIZE),
+		queueOnce:   &sync.Once{},
+		maxAttempts: common.MAILER_MAX_ATTEMPTS,
+		backoff:     common.MAILER_RETRY_BACKOFF * time.Second}
 }
 
 func (mailer *GMailer) SetRecipient(recipient string) {
@@ -50,38 +86,46 @@ func (mailer *GMailer) SetRecipient(recipient string) {
 }
 
 func (mailer *GMailer) Send(subject, message string) error {
+	return mailer.SendTo(mailer.recipient, subject, message)
+}
+
+// Queues a plain text message to the recipient instead of the configured recipient
+func (mailer *GMailer) SendTo(recipient, subject, message string) error {
 	if !mailer.enabled {
 		mailer.app.Logger.Warningf("Disabled!")
 		return nil
 	}
+	mailer.app.Logger.Debugf("subject=[%s], message=%s", subject, message)
+	return mailer.enqueue(&queuedEmail{
+		recipient: recipient,
+		subject:   subject,
+		body:      message})
+}
 
-	mailer.app.Logger.Debugf("subject=[%s] %s, message=%s", subject, message)
-
-	if mailer.host == "" || mailer.port <= 0 || mailer.username == "" || mailer.password == "" || mailer.recipient == "" {
-		err := fmt.Errorf("invalid SMTP configuration")
-		mailer.app.Logger.Error(err)
-		return err
+// Renders the named embedded email template and queues a copy of the
+// HTML message for each of the recipients
+func (mailer *GMailer) SendTemplate(recipients []string, subject, templateName string, data interface{}) error {
+	if !mailer.enabled {
+		mailer.app.Logger.Warningf("Disabled!")
+		return nil
 	}
-
-	msg := "From: " + mailer.username + "\n" +
-		"To: " + mailer.recipient + "\n" +
-		"Subject: " + subject + "\n\n" + message
-
-	err := smtp.SendMail(mailer.host+":"+strconv.Itoa(mailer.port),
-		smtp.PlainAuth("", mailer.username, mailer.password, mailer.host),
-		mailer.username, []string{mailer.recipient}, []byte(msg))
-
-	if err != nil {
-		mailer.app.Logger.Errorf("smtp error: %s", err)
+	buf := new(bytes.Buffer)
+	if err := emailTemplates.ExecuteTemplate(buf, templateName, data); err != nil {
 		return err
 	}
-
-	return nil
+	var errs []error
+	for _, recipient := range recipients {
+		errs = append(errs, mailer.enqueue(&queuedEmail{
+			recipient: recipient,
+			subject:   subject,
+			body:      buf.String(),
+			html:      true}))
+	}
+	return errors.Join(errs...)
 }
 
+// Sends an HTML message rendered from a template file to the configured recipient
 func (mailer *GMailer) SendHtml(template, subject string, data interface{}) (bool, error) {
-	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
-
 	body, err := mailer.parseTemplate(template, data)
 	if err != nil {
 		return false, err
@@ -90,17 +134,17 @@ func (mailer *GMailer) SendHtml(template, subject string, data interface{}) (boo
 	// mailer.app.Logger.Debugf("body=%s", body)
 	// mailer.app.Logger.Debugf("data=%+v", data)
 
-	msg := "From: " + mailer.username + "\n" +
-		"To: " + mailer.recipient + "\n" +
-		"Subject: " + subject + "\n" + mime + "\n" + body
-
-	if err := smtp.SendMail(mailer.host+":"+strconv.Itoa(mailer.port),
-		smtp.PlainAuth("", mailer.username, mailer.password, mailer.host),
-		mailer.username, []string{mailer.recipient}, []byte(msg)); err != nil {
-
+	if err := mailer.validate(mailer.recipient); err != nil {
 		return false, err
 	}
+	if err := mailer.deliver(&queuedEmail{
+		recipient: mailer.recipient,
+		subject:   subject,
+		body:      body,
+		html:      true}); err != nil {
 
+		return false, err
+	}
 	return true, nil
 }
 
@@ -115,3 +159,145 @@ func (mailer *GMailer) parseTemplate(templateFileName string, data interface{})
 	}
 	return buf.String(), nil
 }
+
+func (mailer *GMailer) validate(recipient string) error {
+	if mailer.host == "" || mailer.port <= 0 || mailer.username == "" || recipient == "" {
+		mailer.app.Logger.Error(ErrInvalidSmtpConfig)
+		return ErrInvalidSmtpConfig
+	}
+	if strings.ContainsAny(recipient, "\r\n") {
+		mailer.app.Logger.Errorf("%s: %q", ErrInvalidEmailAddress, recipient)
+		return ErrInvalidEmailAddress
+	}
+	return nil
+}
+
+// Adds the email to the send queue, starting the queue worker
+// the first time an email is sent
+func (mailer *GMailer) enqueue(email *queuedEmail) error {
+	if err := mailer.validate(email.recipient); err != nil {
+		return err
+	}
+	mailer.queueOnce.Do(func() {
+		go mailer.run()
+	})
+	select {
+	case mailer.queue <- email:
+		return nil
+	default:
+		mailer.app.Logger.Errorf("%s, discarding email to %s: %s",
+			ErrMailQueueFull, email.recipient, email.subject)
+		return ErrMailQueueFull
+	}
+}
+
+// Delivers queued emails, retrying failed deliveries with exponential
+// backoff until the maximum number of attempts is reached
+func (mailer *GMailer) run() {
+	for email := range mailer.queue {
+		err := mailer.deliver(email)
+		if err == nil {
+			continue
+		}
+		email.attempts++
+		if email.attempts >= mailer.maxAttempts {
+			mailer.app.Logger.Errorf("Giving up on email to %s after %d attempts: %s",
+				email.recipient, email.attempts, err)
+			continue
+		}
+		backoff := mailer.backoff * time.Duration(1<<(email.attempts-1))
+		mailer.app.Logger.Warningf("Error sending email to %s, retrying in %s: %s",
+			email.recipient, backoff, err)
+		retry := email
+		time.AfterFunc(backoff, func() {
+			select {
+			case mailer.queue <- retry:
+			default:
+				mailer.app.Logger.Errorf("%s, discarding email to %s: %s",
+					ErrMailQueueFull, retry.recipient, retry.subject)
+			}
+		})
+	}
+}
+
+// Connects to the SMTP server using the configured encryption and sends the email
+func (mailer *GMailer) deliver(email *queuedEmail) error {
+	address := net.JoinHostPort(mailer.host, strconv.Itoa(mailer.port))
+	dialer := &net.Dialer{Timeout: common.SMTP_TIMEOUT * time.Second}
+	tlsConfig := &tls.Config{ServerName: mailer.host}
+
+	var conn net.Conn
+	var err error
+	if mailer.encryption == common.SMTP_ENCRYPTION_TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(common.SMTP_TIMEOUT * time.Second))
+
+	client, err := smtp.NewClient(conn, mailer.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if mailer.encryption != common.SMTP_ENCRYPTION_TLS && mailer.encryption != common.SMTP_ENCRYPTION_NONE {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if mailer.encryption == common.SMTP_ENCRYPTION_STARTTLS {
+			return ErrStartTLSNotSupported
+		}
+	}
+	if mailer.password != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			auth := smtp.PlainAuth("", mailer.username, mailer.password, mailer.host)
+			if err := client.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err := client.Mail(mailer.username); err != nil {
+		return err
+	}
+	if err := client.Rcpt(email.recipient); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(mailer.message(email)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (mailer *GMailer) message(email *queuedEmail) []byte {
+	var msg strings.Builder
+	msg.WriteString("From: " + headerValue(mailer.username) + "\r\n")
+	msg.WriteString("To: " + headerValue(email.recipient) + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", headerValue(email.subject)) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	if email.html {
+		msg.WriteString("MIME-Version: 1.0\r\n")
+		msg.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n")
+	}
+	msg.WriteString("\r\n")
+	msg.WriteString(email.body)
+	return []byte(msg.String())
+}
+
+// Replaces the CR and LF characters in a header value with spaces so
+// user supplied values can't inject additional headers or a body
+func headerValue(value string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+}
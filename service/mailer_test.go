@@ -0,0 +1,224 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// A minimal local SMTP server that records the messages it receives. The
+// first "failures" MAIL commands are rejected with a transient error.
+type smtpStandIn struct {
+	listener net.Listener
+	failures int32
+	mutex    sync.Mutex
+	messages []receivedMail
+}
+
+func startSmtpStandIn(t *testing.T, failures int32) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	server := &smtpStandIn{listener: listener, failures: failures}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.handle(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+func (server *smtpStandIn) port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *smtpStandIn) received() []receivedMail {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]receivedMail{}, server.messages...)
+}
+
+func (server *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		fmt.Fprintf(conn, "%s\r\n", line)
+	}
+	reply("220 localhost ESMTP")
+	var mail receivedMail
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+		switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			if atomic.AddInt32(&server.failures, -1) >= 0 {
+				reply("451 try again later")
+				continue
+			}
+			mail = receivedMail{from: command[len("MAIL FROM:"):]}
+			reply("250 OK")
+		case "RCPT":
+			mail.to = append(mail.to, command[len("RCPT TO:"):])
+			reply("250 OK")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			mail.data = data.String()
+			server.mutex.Lock()
+			server.messages = append(server.messages, mail)
+			server.mutex.Unlock()
+			reply("250 OK")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+func createTestMailer(port int, encryption string) *GMailer {
+	mailer := CreateMailer(&app.App{Logger: logging.MustGetLogger("mailer_test")},
+		&config.SmtpStruct{
+			Enable:     true,
+			Host:       "127.0.0.1",
+			Port:       port,
+			Username:   "cropdroid@localhost",
+			Recipient:  "grower@localhost",
+			Encryption: encryption}).(*GMailer)
+	mailer.backoff = 10 * time.Millisecond
+	return mailer
+}
+
+func TestMailerSendTemplate(t *testing.T) {
+	server := startSmtpStandIn(t, 0)
+	mailer := createTestMailer(server.port(), common.SMTP_ENCRYPTION_NONE)
+
+	err := mailer.SendTemplate([]string{"grower@localhost", "oncall@localhost"},
+		"Room alarm", common.EMAIL_TEMPLATE_ALARM, alarmEmail{
+			AppName:  "cropdroid",
+			FarmName: "Room",
+			Alarm: &entity.Alarm{
+				MetricKey:  "tempF0",
+				MetricName: "Air Temperature",
+				Condition:  common.ALARM_CONDITION_HIGH,
+				State:      common.ALARM_STATE_ACTIVE,
+				Value:      91.5,
+				Message:    "Air Temperature HIGH: 91.50"}})
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	recipients := make([]string, 0)
+	for _, mail := range server.received() {
+		assert.Equal(t, "<cropdroid@localhost>", mail.from)
+		assert.Equal(t, 1, len(mail.to))
+		recipients = append(recipients, mail.to[0])
+		assert.Contains(t, mail.data, "Subject: Room alarm")
+		assert.Contains(t, mail.data, "Content-Type: text/html")
+		assert.Contains(t, mail.data, "Air Temperature HIGH: 91.50")
+	}
+	assert.ElementsMatch(t, []string{"<grower@localhost>", "<oncall@localhost>"}, recipients)
+}
+
+func TestMailerRetriesWithBackoff(t *testing.T) {
+	server := startSmtpStandIn(t, 2)
+	mailer := createTestMailer(server.port(), "")
+
+	assert.Nil(t, mailer.Send("Test", "retried message"))
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mail := server.received()[0]
+	assert.Equal(t, []string{"<grower@localhost>"}, mail.to)
+	assert.Contains(t, mail.data, "retried message")
+	assert.NotContains(t, mail.data, "Content-Type: text/html")
+}
+
+func TestMailerRequiresStartTLS(t *testing.T) {
+	server := startSmtpStandIn(t, 0)
+	mailer := createTestMailer(server.port(), common.SMTP_ENCRYPTION_STARTTLS)
+
+	err := mailer.deliver(&queuedEmail{
+		recipient: "grower@localhost",
+		subject:   "Test",
+		body:      "not sent in the clear"})
+	assert.Equal(t, ErrStartTLSNotSupported, err)
+	assert.Empty(t, server.received())
+}
+
+func TestMailerInvalidConfig(t *testing.T) {
+	mailer := createTestMailer(0, common.SMTP_ENCRYPTION_NONE)
+	assert.Equal(t, ErrInvalidSmtpConfig, mailer.SendTo("grower@localhost", "Test", "message"))
+}
+
+func TestMailerHeaderInjection(t *testing.T) {
+	mailer := createTestMailer(25, common.SMTP_ENCRYPTION_NONE)
+	mailer.username = "cropdroid@localhost\r\nBcc: attacker@example.com"
+
+	message := string(mailer.message(&queuedEmail{
+		recipient: "grower@localhost\nCc: attacker@example.com",
+		subject:   "Room alarm\r\nBcc: attacker@example.com\r\n\r\nforged body",
+		body:      "message"}))
+
+	headers := strings.SplitN(message, "\r\n\r\n", 2)[0]
+	for _, header := range strings.Split(headers, "\r\n") {
+		assert.False(t, strings.HasPrefix(header, "Bcc:"), header)
+		assert.False(t, strings.HasPrefix(header, "Cc:"), header)
+	}
+	assert.Contains(t, headers, "Subject: Room alarm Bcc: attacker@example.com  forged body")
+	assert.Equal(t, "message", strings.SplitN(message, "\r\n\r\n", 2)[1])
+
+	// Non-ASCII subjects are MIME encoded
+	message = string(mailer.message(&queuedEmail{
+		recipient: "grower@localhost",
+		subject:   "Température élevée"}))
+	assert.Contains(t, message, "Subject: =?UTF-8?q?")
+
+	// Recipients with line breaks are rejected
+	assert.Equal(t, ErrInvalidEmailAddress,
+		mailer.SendTo("grower@localhost\r\nBcc: attacker@example.com", "Test", "message"))
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
 	"github.com/jeremyhahn/go-cropdroid/mapper"
 	"github.com/jeremyhahn/go-cropdroid/model"
+	"github.com/jeremyhahn/go-cropdroid/util"
 )
 
 type MetricService interface {
@@ -61,6 +62,15 @@ func (service *DefaultMetricService) Update(session Session, metric model.Metric
 	if err != nil {
 		return err
 	}
+	if entity.IsComputed() {
+		device, err := session.GetFarmService().GetConfig().GetDeviceById(persisted.GetDeviceID())
+		if err != nil {
+			return err
+		}
+		if _, err := util.ParseExpression(entity.GetExpression(), len(device.GetChannels())); err != nil {
+			return err
+		}
+	}
 	entity.SetDeviceID(persisted.GetDeviceID())
 	if err = service.dao.Save(farmID, entity); err != nil {
 		return err
@@ -72,7 +82,7 @@ func (service *DefaultMetricService) Update(session Session, metric model.Metric
 		if device.ID == metric.GetDeviceID() {
 			metricConfig := service.mapper.MapModelToConfig(metric)
 			device.SetMetric(metricConfig)
-			return farmService.SetDeviceConfig(device)
+			return farmService.SetDeviceConfig(session, device)
 		}
 	}
 	return ErrMetricNotFound
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrMFARequired         = errors.New("multi-factor authentication required")
+	ErrMFANotEnrolled      = errors.New("multi-factor authentication not enrolled")
+	ErrMFAAlreadyEnabled   = errors.New("multi-factor authentication already enabled")
+	ErrMFAUnsupported      = errors.New("multi-factor authentication unsupported by auth store")
+	ErrInvalidMFACode      = errors.New("invalid multi-factor authentication code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired multi-factor authentication challenge")
+)
+
+// MFAChallengeError is returned by Login when the password is correct but the
+// user must complete a second factor before a JWT is issued. The token is
+// exchanged, along with a TOTP or recovery code, to complete the login. Enroll
+// is true when the user's organization requires MFA and the user hasn't
+// enrolled yet; the token may then be used to enroll.
+type MFAChallengeError struct {
+	Token  string
+	Enroll bool
+}
+
+func (err *MFAChallengeError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (err *MFAChallengeError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// TOTPEnrollment is a new TOTP secret. The URI provisions an authenticator app,
+// typically by rendering it as a QR code. The secret and recovery codes are only
+// returned once, at enrollment.
+type TOTPEnrollment struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFAService interface {
+	Enroll(session Session) (*TOTPEnrollment, error)
+	Confirm(session Session, code string) error
+	Disable(session Session, code string) error
+	Reset(session Session, userID uint64) error
+	IsEnabled(userID uint64) (bool, error)
+	Challenge(userID uint64, account string, required bool) error
+	EnrollChallenge(token string) (*TOTPEnrollment, error)
+	Verify(token, code string) (uint64, error)
+}
+
+type DefaultMFAService struct {
+	logger          *logging.Logger
+	totpDAO         dao.TOTPDAO
+	lockout         *AccountLockout
+	totp            *util.TOTP
+	issuer          string
+	serviceRegistry ServiceRegistry
+	mutex           *sync.Mutex
+	clock           func() time.Time
+	MFAService
+}
+
+// Creates a new MFA service that enrolls users in TOTP (RFC 6238) two-factor
+// authentication and issues and verifies the MFA challenges that complete a
+// password login. The issuer is displayed in the user's authenticator app.
+// Invalid codes count towards the account lockout.
+func NewMFAService(
+	logger *logging.Logger,
+	totpDAO dao.TOTPDAO,
+	lockout *AccountLockout,
+	issuer string,
+	serviceRegistry ServiceRegistry) MFAService {
+
+	return &DefaultMFAService{
+		logger:          logger,
+		totpDAO:         totpDAO,
+		lockout:         lockout,
+		totp:            util.NewTOTP(common.TOTP_PERIOD, common.TOTP_DIGITS, common.TOTP_SKEW),
+		issuer:          issuer,
+		serviceRegistry: serviceRegistry,
+		mutex:           &sync.Mutex{},
+		clock:           time.Now}
+}
+
+// Generates a new TOTP secret and recovery codes for the session user. The
+// enrollment isn't enabled until it's confirmed with a code from the user's
+// authenticator app. Enrolling again before confirming replaces the secret.
+func (service *DefaultMFAService) Enroll(session Session) (*TOTPEnrollment, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	user := session.GetUser()
+	totp, err := service.get(user.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil {
+		totp = &entity.TOTP{
+			ID:        user.Identifier(),
+			CreatedAt: service.clock()}
+	}
+	if totp.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	totp.Account = user.GetEmail()
+	enrollment, err := service.enroll(totp)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.totpDAO.Save(totp); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+// Enables the session user's pending enrollment once the user proves their
+// authenticator app is generating valid codes
+func (service *DefaultMFAService) Confirm(session Session, code string) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	totp, err := service.get(session.GetUser().Identifier())
+	if err != nil {
+		return err
+	}
+	if totp == nil || totp.Secret == "" {
+		return ErrMFANotEnrolled
+	}
+	if totp.Enabled {
+		return ErrMFAAlreadyEnabled
+	}
+	if !service.verifyTOTP(totp, code) {
+		return ErrInvalidMFACode
+	}
+	totp.Enabled = true
+	totp.EnabledAt = service.clock()
+	if err := service.totpDAO.Save(totp); err != nil {
+		return err
+	}
+	service.logger.Infof("MFA enabled for %s", totp.Account)
+	recordAudit(service.logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_MFA_ENABLE, "user", totp.ID, nil, nil)
+	return nil
+}
+
+// Removes the session user's enrollment. A TOTP or recovery code is required
+// so a stolen session can't be used to disable the second factor. Invalid
+// codes count towards the account lockout.
+func (service *DefaultMFAService) Disable(session Session, code string) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	totp, err := service.get(session.GetUser().Identifier())
+	if err != nil {
+		return err
+	}
+	if totp == nil || !totp.Enabled {
+		return ErrMFANotEnrolled
+	}
+	user, err := service.account(totp.ID)
+	if err != nil {
+		return err
+	}
+	if !service.verifyCode(totp, code) {
+		service.lockout.Fail(user, service.clock())
+		return ErrInvalidMFACode
+	}
+	if err := service.totpDAO.Delete(totp); err != nil {
+		return err
+	}
+	service.lockout.Reset(user)
+	service.logger.Infof("MFA disabled for %s", totp.Account)
+	recordAudit(service.logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_MFA_DISABLE, "user", totp.ID, nil, nil)
+	return nil
+}
+
+// Removes a user's enrollment so a user who lost their authenticator app and
+// recovery codes can log in and enroll again
+func (service *DefaultMFAService) Reset(session Session, userID uint64) error {
+	if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
+		return ErrPermissionDenied
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	totp, err := service.get(userID)
+	if err != nil {
+		return err
+	}
+	if totp == nil {
+		return ErrMFANotEnrolled
+	}
+	if err := service.totpDAO.Delete(totp); err != nil {
+		return err
+	}
+	service.logger.Infof("MFA reset for %s by %s", totp.Account, session.GetUser().GetEmail())
+	recordAudit(service.logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_MFA_RESET, "user", userID, nil, nil)
+	return nil
+}
+
+// Returns true if the user has a confirmed TOTP enrollment
+func (service *DefaultMFAService) IsEnabled(userID uint64) (bool, error) {
+	totp, err := service.get(userID)
+	if err != nil {
+		return false, err
+	}
+	return totp != nil && totp.Enabled, nil
+}
+
+// Called after the user's password has been verified. Returns an
+// *MFAChallengeError if the user must complete a second factor to log in,
+// or nil if the password alone is sufficient. Users who haven't enrolled
+// are challenged to enroll when required is true.
+func (service *DefaultMFAService) Challenge(userID uint64, account string, required bool) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	totp, err := service.get(userID)
+	if err != nil {
+		return err
+	}
+	enabled := totp != nil && totp.Enabled
+	if !enabled && !required {
+		return nil
+	}
+	if totp == nil {
+		totp = &entity.TOTP{
+			ID:        userID,
+			Account:   account,
+			CreatedAt: service.clock()}
+	}
+	secret := make([]byte, common.MFA_CHALLENGE_SECRET_LENGTH)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	encodedSecret := hex.EncodeToString(secret)
+	totp.ChallengeHash = hashSecret(encodedSecret)
+	totp.ChallengeExpiresAt = service.clock().Add(common.MFA_CHALLENGE_EXPIRATION * time.Second)
+	if err := service.totpDAO.Save(totp); err != nil {
+		return err
+	}
+	return &MFAChallengeError{
+		Token:  fmt.Sprintf("%d.%s", userID, encodedSecret),
+		Enroll: !enabled}
+}
+
+// Generates a TOTP secret for a user that was challenged to enroll during
+// login. The enrollment is enabled when the challenge is verified.
+func (service *DefaultMFAService) EnrollChallenge(token string) (*TOTPEnrollment, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	totp, err := service.challenge(token)
+	if err != nil {
+		return nil, err
+	}
+	if totp.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	enrollment, err := service.enroll(totp)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.totpDAO.Save(totp); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+// Verifies the code for an MFA challenge, returning the ID of the user
+// whose login the challenge completes. Each challenge may only be completed
+// once. Invalid codes count towards the account lockout and are counted per
+// user across challenges; the challenge is discarded once the user has entered
+// too many invalid codes since their last successful verification.
+func (service *DefaultMFAService) Verify(token, code string) (uint64, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	totp, err := service.challenge(token)
+	if err != nil {
+		return 0, err
+	}
+	if totp.Secret == "" {
+		return 0, ErrMFANotEnrolled
+	}
+	user, err := service.account(totp.ID)
+	if err != nil {
+		return 0, err
+	}
+	var verified bool
+	if totp.Enabled {
+		verified = service.verifyCode(totp, code)
+	} else if verified = service.verifyTOTP(totp, code); verified {
+		totp.Enabled = true
+		totp.EnabledAt = service.clock()
+		service.logger.Infof("MFA enabled for %s", totp.Account)
+	}
+	if !verified {
+		totp.ChallengeAttempts++
+		if totp.ChallengeAttempts >= common.MFA_CHALLENGE_MAX_ATTEMPTS {
+			service.logger.Warningf("[UNAUTHORIZED] Too many invalid MFA codes, discarding challenge: user=%s",
+				totp.Account)
+			service.clearChallenge(totp)
+		}
+		if err := service.totpDAO.Save(totp); err != nil {
+			return 0, err
+		}
+		service.lockout.Fail(user, service.clock())
+		return 0, ErrInvalidMFACode
+	}
+	service.clearChallenge(totp)
+	totp.ChallengeAttempts = 0
+	if err := service.totpDAO.Save(totp); err != nil {
+		return 0, err
+	}
+	service.lockout.Reset(user)
+	return totp.ID, nil
+}
+
+// Returns the user's enrollment, or nil if the user hasn't enrolled
+func (service *DefaultMFAService) get(userID uint64) (*entity.TOTP, error) {
+	totp, err := service.totpDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		if err.Error() == datastore.ErrRecordNotFound.Error() {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if totp == nil || totp.ID == 0 {
+		return nil, nil
+	}
+	return totp, nil
+}
+
+// Returns the enrollment the challenge token was issued for, as long as
+// the challenge hasn't expired
+func (service *DefaultMFAService) challenge(token string) (*entity.TOTP, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidMFAChallenge
+	}
+	userID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidMFAChallenge
+	}
+	totp, err := service.get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil || !totp.HasChallenge() {
+		return nil, ErrInvalidMFAChallenge
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(parts[1])), []byte(totp.ChallengeHash)) != 1 {
+		return nil, ErrInvalidMFAChallenge
+	}
+	if totp.IsChallengeExpired(service.clock()) {
+		return nil, ErrInvalidMFAChallenge
+	}
+	return totp, nil
+}
+
+func (service *DefaultMFAService) clearChallenge(totp *entity.TOTP) {
+	totp.ChallengeHash = ""
+	totp.ChallengeExpiresAt = time.Time{}
+}
+
+// Returns the user's account for the lockout, or ErrAccountLocked if the
+// account is locked after too many failed password or MFA attempts
+func (service *DefaultMFAService) account(userID uint64) (*config.UserStruct, error) {
+	if service.lockout == nil {
+		return nil, nil
+	}
+	return service.lockout.Check(userID, service.clock())
+}
+
+// Generates a new secret and recovery codes for the enrollment
+func (service *DefaultMFAService) enroll(totp *entity.TOTP) (*TOTPEnrollment, error) {
+	secret, err := service.totp.GenerateSecret(common.TOTP_SECRET_LENGTH)
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes := make([]string, common.MFA_RECOVERY_CODES)
+	hashes := make([]string, common.MFA_RECOVERY_CODES)
+	for i := range recoveryCodes {
+		code := make([]byte, 5)
+		if _, err := rand.Read(code); err != nil {
+			return nil, err
+		}
+		encoded := hex.EncodeToString(code)
+		recoveryCodes[i] = fmt.Sprintf("%s-%s", encoded[:5], encoded[5:])
+		hashes[i] = hashSecret(encoded)
+	}
+	totp.Secret = secret
+	totp.RecoveryCodes = strings.Join(hashes, ",")
+	totp.LastUsedStep = 0
+	return &TOTPEnrollment{
+		Secret:        secret,
+		URI:           service.totp.ProvisioningURI(service.issuer, totp.Account, secret),
+		RecoveryCodes: recoveryCodes}, nil
+}
+
+// Returns true if the code is a valid TOTP code that hasn't been used yet
+func (service *DefaultMFAService) verifyTOTP(totp *entity.TOTP, code string) bool {
+	step, ok := service.totp.Validate(totp.Secret, code, service.clock())
+	if !ok || step <= totp.LastUsedStep {
+		return false
+	}
+	totp.LastUsedStep = step
+	return true
+}
+
+// Returns true if the code is a valid TOTP code or an unused recovery
+// code. Recovery codes are removed once used.
+func (service *DefaultMFAService) verifyCode(totp *entity.TOTP, code string) bool {
+	if service.verifyTOTP(totp, code) {
+		return true
+	}
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hash := hashSecret(normalized)
+	recoveryCodes := totp.GetRecoveryCodeList()
+	for i, recoveryCode := range recoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(recoveryCode)) == 1 {
+			remaining := append(recoveryCodes[:i], recoveryCodes[i+1:]...)
+			totp.RecoveryCodes = strings.Join(remaining, ",")
+			service.logger.Warningf("Recovery code used by %s, %d remaining", totp.Account, len(remaining))
+			return true
+		}
+	}
+	return false
+}
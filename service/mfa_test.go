@@ -0,0 +1,254 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTOTPDAO struct {
+	enrollments map[uint64]*entity.TOTP
+	dao.TOTPDAO
+}
+
+func (totpDAO *fakeTOTPDAO) Save(totp *entity.TOTP) error {
+	stored := *totp
+	totpDAO.enrollments[totp.ID] = &stored
+	return nil
+}
+
+func (totpDAO *fakeTOTPDAO) Get(userID uint64, CONSISTENCY_LEVEL int) (*entity.TOTP, error) {
+	totp, ok := totpDAO.enrollments[userID]
+	if !ok {
+		return nil, datastore.ErrRecordNotFound
+	}
+	persisted := *totp
+	return &persisted, nil
+}
+
+func (totpDAO *fakeTOTPDAO) Delete(totp *entity.TOTP) error {
+	delete(totpDAO.enrollments, totp.ID)
+	return nil
+}
+
+func newTestMFAService(now *time.Time) (MFAService, *fakeTOTPDAO) {
+	totpDAO := &fakeTOTPDAO{enrollments: make(map[uint64]*entity.TOTP)}
+	mfaService := NewMFAService(logging.MustGetLogger("mfa_test"), totpDAO, nil, "cropdroid", nil)
+	mfaService.(*DefaultMFAService).clock = func() time.Time { return *now }
+	return mfaService, totpDAO
+}
+
+func totpCode(t *testing.T, secret string, now time.Time) string {
+	totp := util.NewTOTP(common.TOTP_PERIOD, common.TOTP_DIGITS, common.TOTP_SKEW)
+	code, err := totp.Code(secret, totp.Step(now))
+	assert.Nil(t, err)
+	return code
+}
+
+func challengeToken(t *testing.T, err error) *MFAChallengeError {
+	var challenge *MFAChallengeError
+	assert.True(t, errors.As(err, &challenge))
+	assert.ErrorIs(t, err, ErrMFARequired)
+	return challenge
+}
+
+func TestMFAEnrollment(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mfaService, totpDAO := newTestMFAService(&now)
+	session := apiKeyTestSession(5, common.PERMISSION_PROFILE_MANAGE)
+
+	// Users that haven't enrolled aren't challenged unless required
+	assert.Nil(t, mfaService.Challenge(5, "user5@localhost", false))
+
+	enrollment, err := mfaService.Enroll(session)
+	assert.Nil(t, err)
+	assert.Equal(t, common.MFA_RECOVERY_CODES, len(enrollment.RecoveryCodes))
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/cropdroid:user5@localhost?"))
+	for _, code := range enrollment.RecoveryCodes {
+		assert.NotContains(t, totpDAO.enrollments[5].RecoveryCodes, strings.Replace(code, "-", "", 1))
+	}
+
+	// Not enabled until confirmed
+	enabled, err := mfaService.IsEnabled(5)
+	assert.Nil(t, err)
+	assert.False(t, enabled)
+	assert.Nil(t, mfaService.Challenge(5, "user5@localhost", false))
+
+	assert.ErrorIs(t, mfaService.Confirm(session, "000000"), ErrInvalidMFACode)
+	assert.Nil(t, mfaService.Confirm(session, totpCode(t, enrollment.Secret, now)))
+	enabled, _ = mfaService.IsEnabled(5)
+	assert.True(t, enabled)
+
+	_, err = mfaService.Enroll(session)
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+
+	// Login is challenged and completed with a TOTP code
+	challenge := challengeToken(t, mfaService.Challenge(5, "user5@localhost", false))
+	assert.False(t, challenge.Enroll)
+
+	// The code used to confirm the enrollment can't be replayed
+	_, err = mfaService.Verify(challenge.Token, totpCode(t, enrollment.Secret, now))
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	now = now.Add(common.TOTP_PERIOD * time.Second)
+	userID, err := mfaService.Verify(challenge.Token, totpCode(t, enrollment.Secret, now))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5), userID)
+
+	// Challenges can only be completed once
+	_, err = mfaService.Verify(challenge.Token, totpCode(t, enrollment.Secret, now))
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+
+	// Recovery codes are single use
+	challenge = challengeToken(t, mfaService.Challenge(5, "user5@localhost", false))
+	userID, err = mfaService.Verify(challenge.Token, strings.ToUpper(enrollment.RecoveryCodes[0]))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5), userID)
+	challenge = challengeToken(t, mfaService.Challenge(5, "user5@localhost", false))
+	_, err = mfaService.Verify(challenge.Token, enrollment.RecoveryCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	assert.Equal(t, common.MFA_RECOVERY_CODES-1, len(totpDAO.enrollments[5].GetRecoveryCodeList()))
+
+	// Disabling requires a code
+	assert.ErrorIs(t, mfaService.Disable(session, "000000"), ErrInvalidMFACode)
+	assert.Nil(t, mfaService.Disable(session, enrollment.RecoveryCodes[1]))
+	enabled, _ = mfaService.IsEnabled(5)
+	assert.False(t, enabled)
+}
+
+func TestMFAChallenge(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mfaService, _ := newTestMFAService(&now)
+
+	// Organization policy requires a user that hasn't enrolled to enroll
+	challenge := challengeToken(t, mfaService.Challenge(7, "user7@localhost", true))
+	assert.True(t, challenge.Enroll)
+
+	_, err := mfaService.Verify(challenge.Token, "123456")
+	assert.ErrorIs(t, err, ErrMFANotEnrolled)
+	_, err = mfaService.EnrollChallenge("7.invalid")
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+
+	enrollment, err := mfaService.EnrollChallenge(challenge.Token)
+	assert.Nil(t, err)
+	userID, err := mfaService.Verify(challenge.Token, totpCode(t, enrollment.Secret, now))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(7), userID)
+	enabled, _ := mfaService.IsEnabled(7)
+	assert.True(t, enabled)
+
+	// Challenges expire
+	challenge = challengeToken(t, mfaService.Challenge(7, "user7@localhost", false))
+	now = now.Add((common.MFA_CHALLENGE_EXPIRATION + 1) * time.Second)
+	_, err = mfaService.Verify(challenge.Token, totpCode(t, enrollment.Secret, now))
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+
+	// Challenges are discarded after too many invalid codes
+	challenge = challengeToken(t, mfaService.Challenge(7, "user7@localhost", false))
+	for i := 0; i < common.MFA_CHALLENGE_MAX_ATTEMPTS; i++ {
+		_, err = mfaService.Verify(challenge.Token, "000000")
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+	_, err = mfaService.Verify(challenge.Token, totpCode(t, enrollment.Secret, now))
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
+
+func TestMFAReset(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mfaService, _ := newTestMFAService(&now)
+
+	user := apiKeyTestSession(5, common.PERMISSION_PROFILE_MANAGE)
+	enrollment, err := mfaService.Enroll(user)
+	assert.Nil(t, err)
+	assert.Nil(t, mfaService.Confirm(user, totpCode(t, enrollment.Secret, now)))
+
+	assert.ErrorIs(t, mfaService.Reset(user, 5), ErrPermissionDenied)
+
+	admin := apiKeyTestSession(1, common.PERMISSION_USER_MANAGE)
+	assert.Nil(t, mfaService.Reset(admin, 5))
+	enabled, _ := mfaService.IsEnabled(5)
+	assert.False(t, enabled)
+	assert.ErrorIs(t, mfaService.Reset(admin, 5), ErrMFANotEnrolled)
+}
+
+func TestMFALockout(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	user := &config.UserStruct{ID: 5, Email: "user5@localhost"}
+	lockout := NewAccountLockout(&app.App{
+		Logger:          logging.MustGetLogger("mfa_test"),
+		MaxFailedLogins: 3,
+		LockoutDelay:    30}, &fakeOIDCUserDAO{users: map[uint64]*config.UserStruct{5: user}})
+	mfaService, _ := newTestMFAService(&now)
+	mfaService.(*DefaultMFAService).lockout = lockout
+
+	session := apiKeyTestSession(5, common.PERMISSION_PROFILE_MANAGE)
+	enrollment, err := mfaService.Enroll(session)
+	assert.Nil(t, err)
+	assert.Nil(t, mfaService.Confirm(session, totpCode(t, enrollment.Secret, now)))
+	now = now.Add(common.TOTP_PERIOD * time.Second)
+
+	// Invalid codes are counted per user across challenges
+	challenge := challengeToken(t, mfaService.Challenge(5, "user5@localhost", false))
+	for i := 0; i < 2; i++ {
+		_, err = mfaService.Verify(challenge.Token, "000000")
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+	challenge = challengeToken(t, mfaService.Challenge(5, "user5@localhost", false))
+	_, err = mfaService.Verify(challenge.Token, "000000")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	assert.Equal(t, 3, user.FailedLogins)
+	assert.Equal(t, now.Add(30*time.Second), user.LockedUntil)
+
+	// A valid code is refused while the account is locked
+	_, err = mfaService.Verify(challenge.Token, totpCode(t, enrollment.Secret, now))
+	assert.ErrorIs(t, err, ErrAccountLocked)
+
+	// Each failure past the limit doubles the delay
+	now = now.Add(31 * time.Second)
+	_, err = mfaService.Verify(challenge.Token, "000000")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	assert.Equal(t, now.Add(60*time.Second), user.LockedUntil)
+	_, err = mfaService.Verify(challenge.Token, "000000")
+	assert.ErrorIs(t, err, ErrAccountLocked)
+
+	// The challenge is discarded once the user has entered too many invalid codes
+	now = now.Add(61 * time.Second)
+	_, err = mfaService.Verify(challenge.Token, "000000")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	_, err = mfaService.Verify(challenge.Token, totpCode(t, enrollment.Secret, now))
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+	assert.Equal(t, 5, user.FailedLogins)
+	assert.Equal(t, now.Add(120*time.Second), user.LockedUntil)
+
+	// A successful verification clears the failed attempts
+	now = now.Add(121 * time.Second)
+	challenge = challengeToken(t, mfaService.Challenge(5, "user5@localhost", false))
+	userID, err := mfaService.Verify(challenge.Token, totpCode(t, enrollment.Secret, now))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5), userID)
+	assert.Equal(t, 0, user.FailedLogins)
+	assert.True(t, user.LockedUntil.IsZero())
+
+	// Disabling MFA is limited the same way
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, mfaService.Disable(session, "000000"), ErrInvalidMFACode)
+	}
+	assert.ErrorIs(t, mfaService.Disable(session, enrollment.RecoveryCodes[0]), ErrAccountLocked)
+	enabled, _ := mfaService.IsEnabled(5)
+	assert.True(t, enabled)
+
+	now = now.Add(31 * time.Second)
+	assert.Nil(t, mfaService.Disable(session, enrollment.RecoveryCodes[0]))
+	assert.Equal(t, 0, user.FailedLogins)
+}
@@ -2,48 +2,158 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
 	"github.com/jeremyhahn/go-cropdroid/model"
 	logging "github.com/op/go-logging"
 )
 
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
 type NotificationServicer interface {
 	Enqueue(notification model.Notification) error
 	Dequeue() <-chan model.Notification
 	QueueSize() int
+	SetOrganizationNotifiers(organizationID uint64, notifiers []*config.NotifierStruct) error
+	SetFarmNotifiers(farmID uint64, notifiers []*config.NotifierStruct) error
+	SetFarmRouting(farmID uint64, routing *NotificationRouting)
+	Acknowledge(session Session, notificationID uint64) error
+	GetUnacknowledged(session Session) []model.Notification
 }
 
 type NotificationService struct {
-	logger        *logging.Logger
-	notifications chan model.Notification
-	mailer        common.Mailer
+	logger         *logging.Logger
+	notifications  chan model.Notification
+	mailer         common.Mailer
+	userDAO        dao.UserDAO
+	inboxService   InboxService
+	httpClient     *http.Client
+	orgNotifiers   map[uint64][]Notifier
+	farmNotifiers  map[uint64][]Notifier
+	notifiersMutex *sync.RWMutex
+	routes         map[uint64]*NotificationRouting
+	held           map[uint64][]heldNotification
+	escalations    map[uint64]*escalation
+	pendingMutex   *sync.Mutex
+	pendingOnce    *sync.Once
+	nextID         uint64
+	clock          func() time.Time
 	NotificationServicer
 }
 
+// A low or medium priority notification held until the end of quiet hours
+type heldNotification struct {
+	notification model.Notification
+	release      time.Time
+}
+
+// A high priority notification waiting to be acknowledged
+type escalation struct {
+	notification model.Notification
+	onCallOrder  int
+	deadline     time.Time
+}
+
 func NewNotificationService(
 	logger *logging.Logger,
 	mailer common.Mailer) NotificationServicer {
 
+	return CreateNotificationService(logger, mailer, nil, nil)
+}
+
+// Creates a notification service that looks up routing rule
+// email recipients using the user DAO and persists each
+// notification to the farm users' inboxes
+func CreateNotificationService(
+	logger *logging.Logger,
+	mailer common.Mailer,
+	userDAO dao.UserDAO,
+	inboxService InboxService) NotificationServicer {
+
 	return &NotificationService{
-		logger:        logger,
-		notifications: make(chan model.Notification, common.BUFFERED_CHANNEL_SIZE),
-		mailer:        mailer}
+		logger:         logger,
+		notifications:  make(chan model.Notification, common.BUFFERED_CHANNEL_SIZE),
+		mailer:         mailer,
+		userDAO:        userDAO,
+		inboxService:   inboxService,
+		httpClient:     &http.Client{Timeout: common.NOTIFIER_TIMEOUT * time.Second},
+		orgNotifiers:   make(map[uint64][]Notifier, 0),
+		farmNotifiers:  make(map[uint64][]Notifier, 0),
+		notifiersMutex: &sync.RWMutex{},
+		routes:         make(map[uint64]*NotificationRouting, 0),
+		held:           make(map[uint64][]heldNotification, 0),
+		escalations:    make(map[uint64]*escalation, 0),
+		pendingMutex:   &sync.Mutex{},
+		pendingOnce:    &sync.Once{},
+		nextID:         uint64(time.Now().UnixNano()),
+		clock:          time.Now}
 }
 
 func (ns *NotificationService) QueueSize() int {
 	return len(ns.notifications)
 }
 
+// Replaces the organization wide notification channels. Invalid
+// channels are skipped and their errors returned.
+func (ns *NotificationService) SetOrganizationNotifiers(organizationID uint64,
+	notifiers []*config.NotifierStruct) error {
+
+	built, err := ns.buildNotifiers(notifiers)
+	ns.notifiersMutex.Lock()
+	ns.orgNotifiers[organizationID] = built
+	ns.notifiersMutex.Unlock()
+	return err
+}
+
+// Replaces the farm specific notification channels. Invalid
+// channels are skipped and their errors returned.
+func (ns *NotificationService) SetFarmNotifiers(farmID uint64,
+	notifiers []*config.NotifierStruct) error {
+
+	built, err := ns.buildNotifiers(notifiers)
+	ns.notifiersMutex.Lock()
+	ns.farmNotifiers[farmID] = built
+	ns.notifiersMutex.Unlock()
+	return err
+}
+
+// Replaces the notification routing policy for the farm
+func (ns *NotificationService) SetFarmRouting(farmID uint64, routing *NotificationRouting) {
+	ns.notifiersMutex.Lock()
+	ns.routes[farmID] = routing
+	ns.notifiersMutex.Unlock()
+}
+
+// Assigns the notification an ID, saves it to the farm users' inboxes, delivers
+// it to the farm's routing rules or notifiers and queues it for the notification
+// websocket. Farms without routing rules send the notification to the configured
+// mail recipient and every notifier whose priority filter accepts it.
 func (ns *NotificationService) Enqueue(notification model.Notification) error {
-	if ns.mailer != nil {
-		ns.mailer.Send(notification.GetType(), notification.GetMessage())
+	if notification.GetID() == 0 {
+		notification.SetID(atomic.AddUint64(&ns.nextID, 1))
 	}
 	ns.logger.Debugf("Enqueuing notification %v+", notification)
-	if notification.GetPriority() == common.NOTIFICATION_PRIORITY_HIGH {
+	if ns.inboxService != nil {
+		if err := ns.inboxService.Deliver(notification); err != nil {
+			ns.logger.Errorf("Error saving notification %d to inbox: %s", notification.GetID(), err)
+		}
+	}
+	if routing := ns.routing(notification.GetFarmID()); routing != nil && routing.HasRules() {
+		go ns.route(routing, notification, ns.clock())
+	} else {
 		if ns.mailer != nil {
 			ns.mailer.Send(notification.GetType(), notification.GetMessage())
 		}
+		go ns.dispatch(notification)
 	}
 	select {
 	case ns.notifications <- notification:
@@ -59,3 +169,252 @@ func (ns *NotificationService) Enqueue(notification model.Notification) error {
 func (ns *NotificationService) Dequeue() <-chan model.Notification {
 	return ns.notifications
 }
+
+// Acknowledges a high priority notification for the session farm,
+// stopping any further escalation
+func (ns *NotificationService) Acknowledge(session Session, notificationID uint64) error {
+	ns.pendingMutex.Lock()
+	defer ns.pendingMutex.Unlock()
+	pending, ok := ns.escalations[notificationID]
+	if !ok || pending.notification.GetFarmID() != session.GetRequestedFarmID() {
+		return ErrNotificationNotFound
+	}
+	delete(ns.escalations, notificationID)
+	ns.logger.Infof("Notification %d acknowledged by user %d",
+		notificationID, session.GetUser().Identifier())
+	return nil
+}
+
+// Returns the high priority notifications for the session farm
+// that are waiting to be acknowledged
+func (ns *NotificationService) GetUnacknowledged(session Session) []model.Notification {
+	ns.pendingMutex.Lock()
+	defer ns.pendingMutex.Unlock()
+	notifications := make([]model.Notification, 0)
+	for _, pending := range ns.escalations {
+		if pending.notification.GetFarmID() == session.GetRequestedFarmID() {
+			notifications = append(notifications, pending.notification)
+		}
+	}
+	return notifications
+}
+
+// Delivers the notification to each of the organization and farm
+// notifiers whose priority filter accepts it
+func (ns *NotificationService) dispatch(notification model.Notification) error {
+	ns.notifiersMutex.RLock()
+	notifiers := make([]Notifier, 0)
+	notifiers = append(notifiers, ns.orgNotifiers[notification.GetOrganizationID()]...)
+	notifiers = append(notifiers, ns.farmNotifiers[notification.GetFarmID()]...)
+	ns.notifiersMutex.RUnlock()
+	var errs []error
+	for _, notifier := range notifiers {
+		if !notifier.Accepts(notification) {
+			continue
+		}
+		if err := notifier.Notify(notification); err != nil {
+			ns.logger.Errorf("%s notifier %s error: %s",
+				notifier.GetType(), notifier.GetName(), err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Routes the notification using the farm's routing policy. High priority
+// notifications are delivered immediately to the subscribers and the first
+// on-call user, then escalate until acknowledged. Low and medium priority
+// notifications are held during quiet hours.
+func (ns *NotificationService) route(routing *NotificationRouting,
+	notification model.Notification, now time.Time) error {
+
+	if notification.GetPriority() >= common.NOTIFICATION_PRIORITY_HIGH {
+		rules := routing.Recipients(notification, 0)
+		if onCallOrder := routing.NextOnCallOrder(notification, 0); onCallOrder != -1 {
+			rules = append(rules, routing.Recipients(notification, onCallOrder)...)
+			ns.pendingMutex.Lock()
+			ns.escalations[notification.GetID()] = &escalation{
+				notification: notification,
+				onCallOrder:  onCallOrder,
+				deadline:     now.Add(routing.escalationTimeout)}
+			ns.pendingMutex.Unlock()
+			ns.startPending()
+		}
+		return ns.deliver(routing, notification, rules)
+	}
+	if routing.IsQuietHours(now) {
+		farmID := notification.GetFarmID()
+		ns.pendingMutex.Lock()
+		ns.held[farmID] = append(ns.held[farmID], heldNotification{
+			notification: notification,
+			release:      routing.QuietHoursEnd(now)})
+		ns.pendingMutex.Unlock()
+		ns.startPending()
+		ns.logger.Debugf("Holding notification %d until the end of quiet hours", notification.GetID())
+		return nil
+	}
+	return ns.deliver(routing, notification, routing.Recipients(notification, 0))
+}
+
+// Releases notifications held past the end of quiet hours and escalates
+// unacknowledged notifications past their deadline to the next on-call user
+func (ns *NotificationService) processPending(now time.Time) {
+	type delivery struct {
+		notification model.Notification
+		onCallOrder  int
+	}
+	deliveries := make([]delivery, 0)
+
+	ns.pendingMutex.Lock()
+	for farmID, held := range ns.held {
+		remaining := held[:0]
+		for _, h := range held {
+			if now.Before(h.release) {
+				remaining = append(remaining, h)
+				continue
+			}
+			deliveries = append(deliveries, delivery{notification: h.notification})
+		}
+		if len(remaining) == 0 {
+			delete(ns.held, farmID)
+		} else {
+			ns.held[farmID] = remaining
+		}
+	}
+	for id, pending := range ns.escalations {
+		if now.Before(pending.deadline) {
+			continue
+		}
+		routing := ns.routing(pending.notification.GetFarmID())
+		if routing == nil {
+			delete(ns.escalations, id)
+			continue
+		}
+		next := routing.NextOnCallOrder(pending.notification, pending.onCallOrder)
+		if next == -1 {
+			ns.logger.Warningf("Notification %d was not acknowledged by any on-call user", id)
+			delete(ns.escalations, id)
+			continue
+		}
+		pending.onCallOrder = next
+		pending.deadline = now.Add(routing.escalationTimeout)
+		deliveries = append(deliveries, delivery{
+			notification: pending.notification,
+			onCallOrder:  next})
+	}
+	ns.pendingMutex.Unlock()
+
+	for _, d := range deliveries {
+		routing := ns.routing(d.notification.GetFarmID())
+		if routing == nil {
+			continue
+		}
+		ns.deliver(routing, d.notification, routing.Recipients(d.notification, d.onCallOrder))
+	}
+}
+
+// Delivers the notification through the channels of each rule. Each
+// email address and notifier receives the notification once.
+func (ns *NotificationService) deliver(routing *NotificationRouting,
+	notification model.Notification, rules []*config.NotificationRuleStruct) error {
+
+	var errs []error
+	emails := make(map[string]bool, 0)
+	channels := make(map[string]bool, 0)
+	for _, rule := range rules {
+		ruleChannels := rule.GetChannelList()
+		if len(ruleChannels) == 0 {
+			ruleChannels = []string{common.NOTIFICATION_CHANNEL_EMAIL}
+		}
+		for _, channel := range ruleChannels {
+			if channel != common.NOTIFICATION_CHANNEL_EMAIL {
+				channels[channel] = true
+				continue
+			}
+			if ns.userDAO == nil {
+				continue
+			}
+			user, err := ns.userDAO.Get(rule.GetUserID(), common.CONSISTENCY_LOCAL)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("notification rule user %d: %w", rule.GetUserID(), err))
+				continue
+			}
+			emails[user.GetEmail()] = true
+		}
+	}
+	if routing.mailer != nil {
+		subject := fmt.Sprintf("%s %s", notificationTitle(notification), notification.GetType())
+		for email := range emails {
+			if err := routing.mailer.SendTo(email, subject, notification.GetMessage()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for name := range channels {
+		notifier := ns.notifier(notification, name)
+		if notifier == nil {
+			ns.logger.Warningf("Notification rule references unknown notifier %s", name)
+			continue
+		}
+		if !notifier.Accepts(notification) {
+			continue
+		}
+		if err := notifier.Notify(notification); err != nil {
+			ns.logger.Errorf("%s notifier %s error: %s",
+				notifier.GetType(), notifier.GetName(), err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the farm or organization notifier with the given name. Farm
+// notifiers take precedence over organization notifiers.
+func (ns *NotificationService) notifier(notification model.Notification, name string) Notifier {
+	ns.notifiersMutex.RLock()
+	defer ns.notifiersMutex.RUnlock()
+	for _, notifier := range ns.farmNotifiers[notification.GetFarmID()] {
+		if notifier.GetName() == name {
+			return notifier
+		}
+	}
+	for _, notifier := range ns.orgNotifiers[notification.GetOrganizationID()] {
+		if notifier.GetName() == name {
+			return notifier
+		}
+	}
+	return nil
+}
+
+func (ns *NotificationService) routing(farmID uint64) *NotificationRouting {
+	ns.notifiersMutex.RLock()
+	defer ns.notifiersMutex.RUnlock()
+	return ns.routes[farmID]
+}
+
+// Starts the goroutine that processes held and escalating notifications
+func (ns *NotificationService) startPending() {
+	ns.pendingOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(common.NOTIFICATION_PENDING_INTERVAL * time.Second)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				ns.processPending(now)
+			}
+		}()
+	})
+}
+
+func (ns *NotificationService) buildNotifiers(configs []*config.NotifierStruct) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0, len(configs))
+	var errs []error
+	for _, notifierConfig := range configs {
+		notifier, err := NewNotifier(ns.httpClient, notifierConfig)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("notifier %s: %w", notifierConfig.GetName(), err))
+			continue
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers, errors.Join(errs...)
+}
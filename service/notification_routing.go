@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/model"
+)
+
+var (
+	ErrInvalidQuietHours = errors.New("invalid quiet hours, expected HH:MM")
+)
+
+// NotificationRouting is the notification routing policy for a farm. Rules
+// with an on-call order of 0 are subscriptions that receive every matching
+// notification. Rules with an on-call order greater than 0 form the escalation
+// chain for high priority notifications.
+type NotificationRouting struct {
+	rules             []*config.NotificationRuleStruct
+	quietHours        bool
+	quietStart        int // minutes past midnight
+	quietEnd          int // minutes past midnight
+	location          *time.Location
+	escalationTimeout time.Duration
+	mailer            common.Mailer
+}
+
+// Creates the notification routing policy for the farm. Email is delivered
+// using the provided mailer.
+func NewNotificationRouting(farmConfig config.Farm, mailer common.Mailer) (*NotificationRouting, error) {
+	location := time.Local
+	if timezone := farmConfig.GetTimezone(); timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, err
+		}
+		location = loc
+	}
+	escalationTimeout := farmConfig.GetEscalationTimeout()
+	if escalationTimeout <= 0 {
+		escalationTimeout = common.DEFAULT_ESCALATION_TIMEOUT
+	}
+	routing := &NotificationRouting{
+		rules:             farmConfig.GetNotificationRules(),
+		location:          location,
+		escalationTimeout: time.Duration(escalationTimeout) * time.Minute,
+		mailer:            mailer}
+	start, end := farmConfig.GetQuietHours()
+	if start == "" && end == "" {
+		return routing, nil
+	}
+	quietStart, err := parseTimeOfDay(start)
+	if err != nil {
+		return nil, err
+	}
+	quietEnd, err := parseTimeOfDay(end)
+	if err != nil {
+		return nil, err
+	}
+	routing.quietHours = quietStart != quietEnd
+	routing.quietStart = quietStart
+	routing.quietEnd = quietEnd
+	return routing, nil
+}
+
+// Returns true if the farm has routing rules
+func (routing *NotificationRouting) HasRules() bool {
+	return len(routing.rules) > 0
+}
+
+// Returns true if the time falls within the farm's quiet hours
+func (routing *NotificationRouting) IsQuietHours(now time.Time) bool {
+	if !routing.quietHours {
+		return false
+	}
+	local := now.In(routing.location)
+	minutes := local.Hour()*60 + local.Minute()
+	if routing.quietStart < routing.quietEnd {
+		return minutes >= routing.quietStart && minutes < routing.quietEnd
+	}
+	// Quiet hours span midnight
+	return minutes >= routing.quietStart || minutes < routing.quietEnd
+}
+
+// Returns the next time the farm's quiet hours end
+func (routing *NotificationRouting) QuietHoursEnd(now time.Time) time.Time {
+	local := now.In(routing.location)
+	end := time.Date(local.Year(), local.Month(), local.Day(),
+		routing.quietEnd/60, routing.quietEnd%60, 0, 0, routing.location)
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// Returns the rules with the on-call order that route the notification
+func (routing *NotificationRouting) Recipients(notification model.Notification,
+	onCallOrder int) []*config.NotificationRuleStruct {
+
+	rules := make([]*config.NotificationRuleStruct, 0)
+	for _, rule := range routing.rules {
+		if rule.GetOnCallOrder() == onCallOrder &&
+			rule.Matches(notification.GetType(), notification.GetPriority()) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Returns the next on-call order in the escalation chain for the
+// notification after the current order, or -1 if the chain is exhausted
+func (routing *NotificationRouting) NextOnCallOrder(notification model.Notification,
+	onCallOrder int) int {
+
+	next := -1
+	for _, rule := range routing.rules {
+		order := rule.GetOnCallOrder()
+		if order <= onCallOrder || (next != -1 && order >= next) {
+			continue
+		}
+		if rule.Matches(notification.GetType(), notification.GetPriority()) {
+			next = order
+		}
+	}
+	return next
+}
+
+// Parses a HH:MM time of day into minutes past midnight
+func parseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, ErrInvalidQuietHours
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type sentMail struct {
+	recipient string
+	subject   string
+}
+
+type fakeMailer struct {
+	mutex sync.Mutex
+	sent  []sentMail
+	common.Mailer
+}
+
+func (mailer *fakeMailer) Send(subject, message string) error {
+	return mailer.SendTo("", subject, message)
+}
+
+func (mailer *fakeMailer) SendTo(recipient, subject, message string) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+	mailer.sent = append(mailer.sent, sentMail{recipient: recipient, subject: subject})
+	return nil
+}
+
+func (mailer *fakeMailer) recipients() []string {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+	recipients := make([]string, len(mailer.sent))
+	for i, mail := range mailer.sent {
+		recipients[i] = mail.recipient
+	}
+	return recipients
+}
+
+type fakeUserDAO struct {
+	users map[uint64]*config.UserStruct
+	dao.UserDAO
+}
+
+func (userDAO *fakeUserDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.UserStruct, error) {
+	return userDAO.users[id], nil
+}
+
+func createRoutingTestService(t *testing.T, farmConfig *config.FarmStruct) (*NotificationService, *fakeMailer) {
+	userDAO := &fakeUserDAO{users: map[uint64]*config.UserStruct{
+		1: {ID: 1, Email: "grower@example.com"},
+		2: {ID: 2, Email: "oncall1@example.com"},
+		3: {ID: 3, Email: "oncall2@example.com"}}}
+	mailer := &fakeMailer{}
+	ns := CreateNotificationService(logging.MustGetLogger("notification_test"),
+		nil, userDAO, nil).(*NotificationService)
+	routing, err := NewNotificationRouting(farmConfig, mailer)
+	assert.Nil(t, err)
+	ns.SetFarmRouting(farmConfig.ID, routing)
+	return ns, mailer
+}
+
+func createRoutingTestFarm() *config.FarmStruct {
+	farm := config.NewFarm()
+	farm.ID = 2
+	farm.Timezone = "UTC"
+	farm.SetQuietHours("22:00", "07:00")
+	farm.SetEscalationTimeout(10)
+	farm.SetNotificationRules([]*config.NotificationRuleStruct{
+		{UserID: 1, EventTypes: "ALARM, ANOMALY"},
+		{UserID: 2, MinPriority: common.NOTIFICATION_PRIORITY_HIGH, OnCallOrder: 1},
+		{UserID: 3, MinPriority: common.NOTIFICATION_PRIORITY_HIGH, OnCallOrder: 2}})
+	return farm
+}
+
+func TestNotificationRoutingQuietHours(t *testing.T) {
+	routing, err := NewNotificationRouting(createRoutingTestFarm(), nil)
+	assert.Nil(t, err)
+
+	night := time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)
+	morning := time.Date(2024, 1, 2, 6, 59, 0, 0, time.UTC)
+	day := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	assert.True(t, routing.IsQuietHours(night))
+	assert.True(t, routing.IsQuietHours(morning))
+	assert.False(t, routing.IsQuietHours(day))
+	assert.Equal(t, time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC), routing.QuietHoursEnd(night))
+	assert.Equal(t, time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC), routing.QuietHoursEnd(morning))
+
+	farm := createRoutingTestFarm()
+	farm.SetQuietHours("10pm", "07:00")
+	_, err = NewNotificationRouting(farm, nil)
+	assert.Equal(t, ErrInvalidQuietHours, err)
+}
+
+func TestNotificationRoutingRules(t *testing.T) {
+	ns, mailer := createRoutingTestService(t, createRoutingTestFarm())
+	routing := ns.routing(2)
+	day := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	// Event types that don't match any rule are not delivered
+	notification := createTestNotification(common.NOTIFICATION_PRIORITY_LOW)
+	notification.Type = common.EVENT_TYPE_CALIBRATION
+	assert.Nil(t, ns.route(routing, notification, day))
+	assert.Empty(t, mailer.recipients())
+
+	notification = createTestNotification(common.NOTIFICATION_PRIORITY_MED)
+	assert.Nil(t, ns.route(routing, notification, day))
+	assert.Equal(t, []string{"grower@example.com"}, mailer.recipients())
+}
+
+func TestNotificationRoutingHoldsDuringQuietHours(t *testing.T) {
+	ns, mailer := createRoutingTestService(t, createRoutingTestFarm())
+	routing := ns.routing(2)
+	night := time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)
+
+	assert.Nil(t, ns.route(routing, createTestNotification(common.NOTIFICATION_PRIORITY_LOW), night))
+	assert.Empty(t, mailer.recipients())
+	assert.Equal(t, 1, len(ns.held[2]))
+
+	ns.processPending(night.Add(time.Hour))
+	assert.Empty(t, mailer.recipients())
+
+	ns.processPending(time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC))
+	assert.Equal(t, []string{"grower@example.com"}, mailer.recipients())
+	assert.Equal(t, 0, len(ns.held))
+}
+
+func TestNotificationEscalation(t *testing.T) {
+	ns, mailer := createRoutingTestService(t, createRoutingTestFarm())
+	routing := ns.routing(2)
+	night := time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)
+
+	// High priority notifications ignore quiet hours and go to the
+	// subscribers and the first on-call user
+	notification := createTestNotification(common.NOTIFICATION_PRIORITY_HIGH)
+	notification.ID = 100
+	assert.Nil(t, ns.route(routing, notification, night))
+	assert.ElementsMatch(t, []string{"grower@example.com", "oncall1@example.com"}, mailer.recipients())
+
+	ns.processPending(night.Add(5 * time.Minute))
+	assert.Equal(t, 2, len(mailer.recipients()))
+
+	// Not acknowledged within the escalation timeout
+	ns.processPending(night.Add(10 * time.Minute))
+	assert.Equal(t, "oncall2@example.com", mailer.recipients()[2])
+
+	session := CreateSession(logging.MustGetLogger("notification_test"), nil, nil, nil,
+		1, 2, common.CONSISTENCY_LOCAL, &model.UserStruct{ID: 3})
+	assert.Equal(t, 1, len(ns.GetUnacknowledged(session)))
+	assert.Nil(t, ns.Acknowledge(session, 100))
+	assert.Equal(t, ErrNotificationNotFound, ns.Acknowledge(session, 100))
+	assert.Empty(t, ns.GetUnacknowledged(session))
+
+	ns.processPending(night.Add(30 * time.Minute))
+	assert.Equal(t, 3, len(mailer.recipients()))
+
+	// The chain ends after the last on-call user
+	notification = createTestNotification(common.NOTIFICATION_PRIORITY_HIGH)
+	notification.ID = 101
+	assert.Nil(t, ns.route(routing, notification, night))
+	ns.processPending(night.Add(10 * time.Minute))
+	ns.processPending(night.Add(20 * time.Minute))
+	assert.Equal(t, 0, len(ns.escalations))
+
+	// Notifications can only be acknowledged from their own farm
+	notification = createTestNotification(common.NOTIFICATION_PRIORITY_HIGH)
+	notification.ID = 102
+	assert.Nil(t, ns.route(routing, notification, night))
+	otherFarm := CreateSession(logging.MustGetLogger("notification_test"), nil, nil, nil,
+		1, 3, common.CONSISTENCY_LOCAL, &model.UserStruct{ID: 3})
+	assert.Equal(t, ErrNotificationNotFound, ns.Acknowledge(otherFarm, 102))
+}
+
+func TestNotificationAlarmEscalatesDuringQuietHours(t *testing.T) {
+	ns, mailer := createRoutingTestService(t, createRoutingTestFarm())
+	night := time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)
+	ns.clock = func() time.Time { return night }
+
+	assert.Equal(t, common.NOTIFICATION_PRIORITY_CRITICAL, notificationPriority(common.EVENT_TYPE_ALARM))
+	assert.Equal(t, common.NOTIFICATION_PRIORITY_HIGH, notificationPriority(common.EVENT_TYPE_ANOMALY))
+	assert.Equal(t, common.NOTIFICATION_PRIORITY_MED, notificationPriority(common.EVENT_TYPE_CALIBRATION))
+	assert.Equal(t, common.NOTIFICATION_PRIORITY_LOW, notificationPriority(common.EVENT_TYPE_SWITCH))
+
+	// Alarms are delivered during quiet hours to the subscribers and the first on-call user
+	alarm := createTestNotification(notificationPriority(common.EVENT_TYPE_ALARM))
+	assert.Nil(t, ns.Enqueue(alarm))
+	assert.Eventually(t, func() bool {
+		return len(mailer.recipients()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"grower@example.com", "oncall1@example.com"}, mailer.recipients())
+	assert.Empty(t, ns.held)
+
+	// Anomalies are routed the same way
+	anomaly := createTestNotification(notificationPriority(common.EVENT_TYPE_ANOMALY))
+	anomaly.Type = common.EVENT_TYPE_ANOMALY
+	assert.Nil(t, ns.Enqueue(anomaly))
+	assert.Eventually(t, func() bool {
+		return len(mailer.recipients()) == 4
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, ns.held)
+
+	// Both escalate to the next on-call user while quiet hours are in effect
+	ns.processPending(night.Add(10 * time.Minute))
+	assert.Equal(t, []string{"oncall2@example.com", "oncall2@example.com"}, mailer.recipients()[4:])
+
+	// Low priority notifications are still held until the end of quiet hours
+	assert.Nil(t, ns.Enqueue(createTestNotification(common.NOTIFICATION_PRIORITY_LOW)))
+	assert.Eventually(t, func() bool {
+		ns.pendingMutex.Lock()
+		defer ns.pendingMutex.Unlock()
+		return len(ns.held[2]) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 6, len(mailer.recipients()))
+}
+
+func TestNotificationRoutingNotifierChannels(t *testing.T) {
+	server, requests := createNotifierServer(t, http.StatusOK)
+	farm := createRoutingTestFarm()
+	farm.SetNotificationRules([]*config.NotificationRuleStruct{
+		{UserID: 1, Channels: "email, slack"},
+		{UserID: 2, Channels: "slack"}})
+	ns, mailer := createRoutingTestService(t, farm)
+	assert.Nil(t, ns.SetFarmNotifiers(2, []*config.NotifierStruct{{
+		Name:   "slack",
+		Type:   common.NOTIFIER_TYPE_SLACK,
+		Enable: true,
+		URL:    server.URL}}))
+
+	// The notifier only receives the notification once
+	day := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	assert.Nil(t, ns.route(ns.routing(2), createTestNotification(common.NOTIFICATION_PRIORITY_LOW), day))
+	assert.Equal(t, []string{"grower@example.com"}, mailer.recipients())
+	assert.Equal(t, 1, len(requests))
+}
+
+func TestNotificationEnqueueSendsMailOnce(t *testing.T) {
+	mailer := &fakeMailer{}
+	ns := NewNotificationService(logging.MustGetLogger("notification_test"), mailer)
+
+	notification := createTestNotification(common.NOTIFICATION_PRIORITY_HIGH)
+	assert.Nil(t, ns.Enqueue(notification))
+	assert.NotZero(t, notification.GetID())
+	assert.Equal(t, 1, len(mailer.recipients()))
+	assert.Equal(t, notification, <-ns.Dequeue())
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/model"
+)
+
+var (
+	ErrUnsupportedNotifier       = errors.New("unsupported notifier type")
+	ErrNotifierURLRequired       = errors.New("notifier url required")
+	ErrNotifierTopicRequired     = errors.New("notifier topic required")
+	ErrNotifierTokenRequired     = errors.New("notifier token required")
+	ErrNotifierRecipientRequired = errors.New("notifier recipient required")
+	ErrNotifierRequestFailed     = errors.New("notifier request failed")
+)
+
+// Notifier delivers notifications to an external channel
+type Notifier interface {
+	GetName() string
+	GetType() string
+	// Returns true if the notifier is enabled and the notification
+	// passes the notifier's priority filter
+	Accepts(notification model.Notification) bool
+	Notify(notification model.Notification) error
+}
+
+// Creates a new notifier for the notification channel config
+func NewNotifier(client *http.Client, notifierConfig config.Notifier) (Notifier, error) {
+	if client == nil {
+		client = &http.Client{Timeout: common.NOTIFIER_TIMEOUT * time.Second}
+	}
+	if notifierConfig.GetURL() == "" {
+		return nil, ErrNotifierURLRequired
+	}
+	base := httpNotifier{client: client, config: notifierConfig}
+	switch notifierConfig.GetType() {
+	case common.NOTIFIER_TYPE_WEBHOOK:
+		return &WebhookNotifier{httpNotifier: base}, nil
+	case common.NOTIFIER_TYPE_SLACK:
+		return &SlackNotifier{httpNotifier: base}, nil
+	case common.NOTIFIER_TYPE_NTFY:
+		if notifierConfig.GetTopic() == "" {
+			return nil, ErrNotifierTopicRequired
+		}
+		return &NtfyNotifier{httpNotifier: base}, nil
+	case common.NOTIFIER_TYPE_GOTIFY:
+		if notifierConfig.GetToken() == "" {
+			return nil, ErrNotifierTokenRequired
+		}
+		return &GotifyNotifier{httpNotifier: base}, nil
+	case common.NOTIFIER_TYPE_SMS:
+		if notifierConfig.GetRecipient() == "" {
+			return nil, ErrNotifierRecipientRequired
+		}
+		return &SmsGatewayNotifier{httpNotifier: base}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedNotifier, notifierConfig.GetType())
+}
+
+// httpNotifier provides the priority filter and HTTP transport
+// shared by all of the notifier implementations
+type httpNotifier struct {
+	client *http.Client
+	config config.Notifier
+}
+
+func (notifier *httpNotifier) GetName() string {
+	return notifier.config.GetName()
+}
+
+func (notifier *httpNotifier) GetType() string {
+	return notifier.config.GetType()
+}
+
+func (notifier *httpNotifier) Accepts(notification model.Notification) bool {
+	return notifier.config.IsEnabled() &&
+		notification.GetPriority() >= notifier.config.GetMinPriority()
+}
+
+// Sends the payload as a JSON encoded POST request
+func (notifier *httpNotifier) postJSON(url string, payload interface{}, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if headers == nil {
+		headers = make(map[string]string, 1)
+	}
+	headers["Content-Type"] = "application/json"
+	return notifier.post(url, body, headers)
+}
+
+func (notifier *httpNotifier) post(url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := notifier.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %s returned %s", ErrNotifierRequestFailed,
+			notifier.config.GetName(), resp.Status)
+	}
+	return nil
+}
+
+// Returns a bearer token authorization header if the notifier has a token
+func (notifier *httpNotifier) authorization() map[string]string {
+	headers := make(map[string]string, 1)
+	if token := notifier.config.GetToken(); token != "" {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", token)
+	}
+	return headers
+}
+
+// Returns the notification title, falling back to the device name
+func notificationTitle(notification model.Notification) string {
+	if title := notification.GetTitle(); title != "" {
+		return title
+	}
+	return notification.GetDevice()
+}
+
+// Returns the notification formatted as a single line of text
+func notificationText(notification model.Notification) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s: %s", notificationTitle(notification),
+		notification.GetType(), notification.GetMessage()))
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/model"
+)
+
+// NtfyNotifier publishes the notification to an ntfy topic
+type NtfyNotifier struct {
+	httpNotifier
+}
+
+func (notifier *NtfyNotifier) Notify(notification model.Notification) error {
+	url := fmt.Sprintf("%s/%s", strings.TrimSuffix(notifier.config.GetURL(), "/"),
+		notifier.config.GetTopic())
+	headers := notifier.authorization()
+	headers["Title"] = notificationTitle(notification)
+	headers["Priority"] = strconv.Itoa(ntfyPriority(notification.GetPriority()))
+	if notification.GetType() != "" {
+		headers["Tags"] = strings.ToLower(notification.GetType())
+	}
+	return notifier.post(url, []byte(notification.GetMessage()), headers)
+}
+
+// Maps notification priorities to the ntfy 1 (min) - 5 (max) scale
+func ntfyPriority(priority int) int {
+	switch priority {
+	case common.NOTIFICATION_PRIORITY_CRITICAL, common.NOTIFICATION_PRIORITY_HIGH:
+		return 5
+	case common.NOTIFICATION_PRIORITY_MED:
+		return 4
+	}
+	return 3
+}
+
+// GotifyNotifier pushes the notification to a Gotify server
+// using an application token
+type GotifyNotifier struct {
+	httpNotifier
+}
+
+type gotifyPayload struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+func (notifier *GotifyNotifier) Notify(notification model.Notification) error {
+	url := fmt.Sprintf("%s/message", strings.TrimSuffix(notifier.config.GetURL(), "/"))
+	return notifier.postJSON(url, gotifyPayload{
+		Title:    notificationTitle(notification),
+		Message:  notification.GetMessage(),
+		Priority: gotifyPriority(notification.GetPriority())},
+		map[string]string{"X-Gotify-Key": notifier.config.GetToken()})
+}
+
+// Maps notification priorities to the Gotify 0 - 10 scale
+func gotifyPriority(priority int) int {
+	switch priority {
+	case common.NOTIFICATION_PRIORITY_CRITICAL:
+		return 10
+	case common.NOTIFICATION_PRIORITY_HIGH:
+		return 8
+	case common.NOTIFICATION_PRIORITY_MED:
+		return 5
+	}
+	return 2
+}
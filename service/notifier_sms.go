@@ -0,0 +1,34 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/jeremyhahn/go-cropdroid/model"
+)
+
+// SmsGatewayNotifier sends the notification as a text message using
+// an SMS gateway HTTP API. The recipient is a comma separated list of
+// phone numbers.
+type SmsGatewayNotifier struct {
+	httpNotifier
+}
+
+type smsPayload struct {
+	To      []string `json:"to"`
+	Message string   `json:"message"`
+}
+
+func (notifier *SmsGatewayNotifier) Notify(notification model.Notification) error {
+	recipients := make([]string, 0)
+	for _, recipient := range strings.Split(notifier.config.GetRecipient(), ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	if len(recipients) == 0 {
+		return ErrNotifierRecipientRequired
+	}
+	return notifier.postJSON(notifier.config.GetURL(), smsPayload{
+		To:      recipients,
+		Message: notificationText(notification)}, notifier.authorization())
+}
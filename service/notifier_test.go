@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type capturedRequest struct {
+	path    string
+	headers http.Header
+	body    []byte
+}
+
+// Starts an httptest server that records each request it receives
+func createNotifierServer(t *testing.T, status int) (*httptest.Server, chan capturedRequest) {
+	requests := make(chan capturedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- capturedRequest{path: r.URL.Path, headers: r.Header, body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func createTestNotification(priority int) *model.NotificationStruct {
+	return &model.NotificationStruct{
+		OrganizationID: 1,
+		FarmID:         2,
+		Device:         "room",
+		Priority:       priority,
+		Type:           common.EVENT_TYPE_ALARM,
+		Title:          "Room",
+		Message:        "tempF0 is too high",
+		Timestamp:      time.Now()}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	server, requests := createNotifierServer(t, http.StatusOK)
+	notifier, err := NewNotifier(server.Client(), &config.NotifierStruct{
+		Name:   "hook",
+		Type:   common.NOTIFIER_TYPE_WEBHOOK,
+		Enable: true,
+		URL:    server.URL + "/hook",
+		Token:  "secret"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, notifier.Notify(createTestNotification(common.NOTIFICATION_PRIORITY_HIGH)))
+	req := <-requests
+	assert.Equal(t, "/hook", req.path)
+	assert.Equal(t, "Bearer secret", req.headers.Get("Authorization"))
+	assert.Equal(t, "application/json", req.headers.Get("Content-Type"))
+
+	var payload webhookPayload
+	assert.Nil(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, uint64(1), payload.OrganizationID)
+	assert.Equal(t, uint64(2), payload.FarmID)
+	assert.Equal(t, common.NOTIFICATION_PRIORITY_HIGH, payload.Priority)
+	assert.Equal(t, "tempF0 is too high", payload.Message)
+}
+
+func TestSlackNotifier(t *testing.T) {
+	server, requests := createNotifierServer(t, http.StatusOK)
+	notifier, err := NewNotifier(server.Client(), &config.NotifierStruct{
+		Type:   common.NOTIFIER_TYPE_SLACK,
+		Enable: true,
+		URL:    server.URL})
+	assert.Nil(t, err)
+
+	assert.Nil(t, notifier.Notify(createTestNotification(common.NOTIFICATION_PRIORITY_LOW)))
+	req := <-requests
+	var payload slackPayload
+	assert.Nil(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, "*Room* ALARM\ntempF0 is too high", payload.Text)
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	server, requests := createNotifierServer(t, http.StatusOK)
+	notifier, err := NewNotifier(server.Client(), &config.NotifierStruct{
+		Type:   common.NOTIFIER_TYPE_NTFY,
+		Enable: true,
+		URL:    server.URL + "/",
+		Topic:  "greenhouse"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, notifier.Notify(createTestNotification(common.NOTIFICATION_PRIORITY_HIGH)))
+	req := <-requests
+	assert.Equal(t, "/greenhouse", req.path)
+	assert.Equal(t, "Room", req.headers.Get("Title"))
+	assert.Equal(t, "5", req.headers.Get("Priority"))
+	assert.Equal(t, "alarm", req.headers.Get("Tags"))
+	assert.Empty(t, req.headers.Get("Authorization"))
+	assert.Equal(t, "tempF0 is too high", string(req.body))
+
+	_, err = NewNotifier(server.Client(), &config.NotifierStruct{
+		Type: common.NOTIFIER_TYPE_NTFY,
+		URL:  server.URL})
+	assert.Equal(t, ErrNotifierTopicRequired, err)
+}
+
+func TestGotifyNotifier(t *testing.T) {
+	server, requests := createNotifierServer(t, http.StatusOK)
+	notifier, err := NewNotifier(server.Client(), &config.NotifierStruct{
+		Type:   common.NOTIFIER_TYPE_GOTIFY,
+		Enable: true,
+		URL:    server.URL,
+		Token:  "app-token"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, notifier.Notify(createTestNotification(common.NOTIFICATION_PRIORITY_MED)))
+	req := <-requests
+	assert.Equal(t, "/message", req.path)
+	assert.Equal(t, "app-token", req.headers.Get("X-Gotify-Key"))
+	var payload gotifyPayload
+	assert.Nil(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, 5, payload.Priority)
+	assert.Equal(t, "Room", payload.Title)
+
+	_, err = NewNotifier(server.Client(), &config.NotifierStruct{
+		Type: common.NOTIFIER_TYPE_GOTIFY,
+		URL:  server.URL})
+	assert.Equal(t, ErrNotifierTokenRequired, err)
+}
+
+func TestSmsGatewayNotifier(t *testing.T) {
+	server, requests := createNotifierServer(t, http.StatusAccepted)
+	notifier, err := NewNotifier(server.Client(), &config.NotifierStruct{
+		Type:      common.NOTIFIER_TYPE_SMS,
+		Enable:    true,
+		URL:       server.URL + "/send",
+		Token:     "sms-token",
+		Recipient: "+15550100, +15550101"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, notifier.Notify(createTestNotification(common.NOTIFICATION_PRIORITY_HIGH)))
+	req := <-requests
+	assert.Equal(t, "Bearer sms-token", req.headers.Get("Authorization"))
+	var payload smsPayload
+	assert.Nil(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, []string{"+15550100", "+15550101"}, payload.To)
+	assert.Equal(t, "Room ALARM: tempF0 is too high", payload.Message)
+}
+
+func TestNotifierErrors(t *testing.T) {
+	server, _ := createNotifierServer(t, http.StatusInternalServerError)
+
+	_, err := NewNotifier(server.Client(), &config.NotifierStruct{Type: "pager", URL: server.URL})
+	assert.True(t, errors.Is(err, ErrUnsupportedNotifier))
+
+	_, err = NewNotifier(server.Client(), &config.NotifierStruct{Type: common.NOTIFIER_TYPE_WEBHOOK})
+	assert.Equal(t, ErrNotifierURLRequired, err)
+
+	notifier, err := NewNotifier(server.Client(), &config.NotifierStruct{
+		Type: common.NOTIFIER_TYPE_WEBHOOK,
+		URL:  server.URL})
+	assert.Nil(t, err)
+	err = notifier.Notify(createTestNotification(common.NOTIFICATION_PRIORITY_LOW))
+	assert.True(t, errors.Is(err, ErrNotifierRequestFailed))
+}
+
+func TestNotificationServiceDispatch(t *testing.T) {
+	orgServer, orgRequests := createNotifierServer(t, http.StatusOK)
+	farmServer, farmRequests := createNotifierServer(t, http.StatusOK)
+
+	ns := NewNotificationService(logging.MustGetLogger("notifier_test"), nil).(*NotificationService)
+
+	// Organization wide channel only receives high priority notifications
+	assert.Nil(t, ns.SetOrganizationNotifiers(1, []*config.NotifierStruct{{
+		Name:        "org",
+		Type:        common.NOTIFIER_TYPE_WEBHOOK,
+		Enable:      true,
+		URL:         orgServer.URL,
+		MinPriority: common.NOTIFICATION_PRIORITY_HIGH}}))
+
+	// Invalid and disabled farm channels are skipped
+	err := ns.SetFarmNotifiers(2, []*config.NotifierStruct{
+		{Name: "farm", Type: common.NOTIFIER_TYPE_SLACK, Enable: true, URL: farmServer.URL},
+		{Name: "disabled", Type: common.NOTIFIER_TYPE_SLACK, Enable: false, URL: farmServer.URL},
+		{Name: "invalid", Type: common.NOTIFIER_TYPE_SMS, Enable: true, URL: farmServer.URL}})
+	assert.True(t, errors.Is(err, ErrNotifierRecipientRequired))
+	assert.Equal(t, 2, len(ns.farmNotifiers[2]))
+
+	assert.Nil(t, ns.dispatch(createTestNotification(common.NOTIFICATION_PRIORITY_LOW)))
+	assert.Equal(t, 0, len(orgRequests))
+	assert.Equal(t, 1, len(farmRequests))
+	<-farmRequests
+
+	assert.Nil(t, ns.dispatch(createTestNotification(common.NOTIFICATION_PRIORITY_HIGH)))
+	assert.Equal(t, 1, len(orgRequests))
+	assert.Equal(t, 1, len(farmRequests))
+
+	// Notifications for other farms and organizations are not delivered
+	other := createTestNotification(common.NOTIFICATION_PRIORITY_HIGH)
+	other.OrganizationID = 3
+	other.FarmID = 4
+	assert.Nil(t, ns.dispatch(other))
+	assert.Equal(t, 1, len(orgRequests))
+	assert.Equal(t, 1, len(farmRequests))
+}
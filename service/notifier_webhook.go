@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/model"
+)
+
+// WebhookNotifier posts the notification as JSON to a generic webhook
+type WebhookNotifier struct {
+	httpNotifier
+}
+
+type webhookPayload struct {
+	ID             uint64    `json:"id"`
+	OrganizationID uint64    `json:"orgId"`
+	FarmID         uint64    `json:"farmId"`
+	Device         string    `json:"device"`
+	Priority       int       `json:"priority"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Message        string    `json:"message"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+func (notifier *WebhookNotifier) Notify(notification model.Notification) error {
+	return notifier.postJSON(notifier.config.GetURL(), webhookPayload{
+		ID:             notification.GetID(),
+		OrganizationID: notification.GetOrganizationID(),
+		FarmID:         notification.GetFarmID(),
+		Device:         notification.GetDevice(),
+		Priority:       notification.GetPriority(),
+		Type:           notification.GetType(),
+		Title:          notification.GetTitle(),
+		Message:        notification.GetMessage(),
+		Timestamp:      notification.GetTimestampAsObject()}, notifier.authorization())
+}
+
+// SlackNotifier posts the notification to a Slack compatible
+// incoming webhook (Slack, Mattermost, Rocket.Chat)
+type SlackNotifier struct {
+	httpNotifier
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func (notifier *SlackNotifier) Notify(notification model.Notification) error {
+	return notifier.postJSON(notifier.config.GetURL(), slackPayload{
+		Text: fmt.Sprintf("*%s* %s\n%s", notificationTitle(notification),
+			notification.GetType(), notification.GetMessage())}, nil)
+}
@@ -0,0 +1,711 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/mapper"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrOIDCDiscovery               = errors.New("oidc discovery failed")
+	ErrOIDCInvalidState            = errors.New("invalid or expired oidc state")
+	ErrOIDCInvalidIDToken          = errors.New("invalid oidc id token")
+	ErrOIDCEmailRequired           = errors.New("oidc id token is missing the email claim")
+	ErrOIDCEmailUnverified         = errors.New("oidc provider hasn't verified the email address")
+	ErrOIDCSubjectRequired         = errors.New("oidc id token is missing the subject claim")
+	ErrOIDCAccountExists           = errors.New("an account with this email already exists; sign in and link it to the provider first")
+	ErrOIDCRegistrationUnsupported = errors.New("oidc accounts are provisioned at login")
+)
+
+type OIDCAuthServicer interface {
+	AuthCodeURL(linkUserID uint64) (string, string, error)
+	AuthServicer
+}
+
+// OIDCDiscovery is the subset of the provider's discovery document
+// needed to complete an authorization code login
+type OIDCDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcJSONWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type OIDCAuthService struct {
+	app           *app.App
+	config        *config.OIDC
+	idGenerator   util.IdGenerator
+	permissionDAO dao.PermissionDAO
+	userDAO       dao.UserDAO
+	emails        *userEmailResolver
+	roleDAO       dao.RoleDAO
+	farmDAO       dao.FarmDAO
+	stateDAO      dao.OIDCStateDAO
+	mapper        mapper.UserMapper
+	httpClient    *http.Client
+	discovery     *OIDCDiscovery
+	keys          map[string]interface{}
+	purgedAt      time.Time
+	mutex         *sync.Mutex
+	clock         func() time.Time
+	OIDCAuthServicer
+}
+
+// Creates a new OpenID Connect auth service that logs users in through any
+// provider that publishes a discovery document, using the authorization code
+// flow with PKCE. Accounts are keyed on the provider's issuer and subject and
+// are provisioned, with the role and organizations mapped from the ID token
+// claims, the first time a user logs in. Logins in progress are stored in the
+// cluster datastore so the provider can redirect back to any node. The
+// discovery document is loaded on first use.
+func NewOIDCAuthService(
+	app *app.App,
+	oidcConfig *config.OIDC,
+	permissionDAO dao.PermissionDAO,
+	userDAO dao.UserDAO,
+	userEmailDAO dao.UserEmailDAO,
+	roleDAO dao.RoleDAO,
+	farmDAO dao.FarmDAO,
+	stateDAO dao.OIDCStateDAO,
+	userMapper mapper.UserMapper) OIDCAuthServicer {
+
+	return &OIDCAuthService{
+		app:           app,
+		config:        oidcConfig,
+		idGenerator:   util.NewIdGenerator(app.DataStoreEngine),
+		permissionDAO: permissionDAO,
+		userDAO:       userDAO,
+		emails:        newUserEmailResolver(util.NewIdGenerator(app.DataStoreEngine), userDAO, userEmailDAO),
+		roleDAO:       roleDAO,
+		farmDAO:       farmDAO,
+		stateDAO:      stateDAO,
+		mapper:        userMapper,
+		httpClient:    &http.Client{Timeout: common.OIDC_HTTP_TIMEOUT * time.Second},
+		keys:          make(map[string]interface{}, 0),
+		mutex:         &sync.Mutex{},
+		clock:         time.Now}
+}
+
+// Returns the provider URL the user is redirected to in order to log in and
+// the binding value the browser must present, in the OIDC_BINDING_COOKIE
+// cookie, when the provider redirects back. The state, nonce and PKCE verifier
+// are stored until the login completes or expires. A non-zero linkUserID links
+// the signed in user's account to their provider identity instead of logging
+// in.
+func (service *OIDCAuthService) AuthCodeURL(linkUserID uint64) (string, string, error) {
+	oauthConfig, err := service.oauthConfig()
+	if err != nil {
+		return "", "", err
+	}
+	state, err := service.randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := service.randomString()
+	if err != nil {
+		return "", "", err
+	}
+	binding, err := service.randomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	now := service.clock()
+	service.purgeExpiredStates(now)
+	loginState := &entity.OIDCState{
+		ID:          service.idGenerator.NewStringID(state),
+		StateHash:   hashSecret(state),
+		BindingHash: hashSecret(binding),
+		Verifier:    verifier,
+		Nonce:       nonce,
+		LinkUserID:  linkUserID,
+		ExpiresAt:   now.Add(common.OIDC_STATE_EXPIRATION * time.Second)}
+	if err := service.stateDAO.Save(loginState); err != nil {
+		return "", "", err
+	}
+
+	return oauthConfig.AuthCodeURL(state,
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("nonce", nonce)), binding, nil
+}
+
+// Completes the login by exchanging the authorization code returned by the
+// provider for an ID token. The user is provisioned on first login, or their
+// account is linked to the provider identity if the login was started to
+// link it.
+func (service *OIDCAuthService) Login(userCredentials *UserCredentials) (model.User,
+	[]config.Organization, []config.Farm, error) {
+
+	loginState, err := service.consumeState(userCredentials.State, userCredentials.Binding)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	oauthConfig, err := service.oauthConfig()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, service.httpClient)
+	token, err := oauthConfig.Exchange(ctx, userCredentials.Code, oauth2.VerifierOption(loginState.Verifier))
+	if err != nil {
+		service.app.Logger.Errorf("[UNAUTHORIZED] OIDC code exchange failed: %s", err)
+		return nil, nil, nil, ErrInvalidCredentials
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, nil, nil, ErrOIDCInvalidIDToken
+	}
+	claims, err := service.verifyIDToken(rawIDToken, loginState.Nonce)
+	if err != nil {
+		service.app.Logger.Errorf("[UNAUTHORIZED] OIDC ID token rejected: %s", err)
+		return nil, nil, nil, err
+	}
+
+	emailClaim := service.config.EmailClaim
+	if emailClaim == "" {
+		emailClaim = "email"
+	}
+	emails := service.claimValues(claims, emailClaim)
+	if len(emails) == 0 || emails[0] == "" {
+		return nil, nil, nil, ErrOIDCEmailRequired
+	}
+	email := strings.ToLower(emails[0])
+	if !service.isEmailVerified(claims) {
+		service.app.Logger.Errorf("[UNAUTHORIZED] OIDC email address not verified: %s", email)
+		return nil, nil, nil, ErrOIDCEmailUnverified
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, nil, nil, ErrOIDCSubjectRequired
+	}
+	iss, _ := claims["iss"].(string)
+	subject := fmt.Sprintf("%s|%s", strings.TrimSuffix(iss, "/"), sub)
+
+	var userEntity *config.UserStruct
+	if loginState.GetLinkUserID() > 0 {
+		userEntity, err = service.link(loginState.GetLinkUserID(), subject)
+	} else {
+		userEntity, err = service.provision(subject, email, claims)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	organizations, err := service.permissionDAO.GetOrganizations(userEntity.ID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		service.app.Logger.Errorf("Database error: %s", err)
+		return nil, nil, nil, ErrInternalDatabase
+	}
+	farms, err := service.farmDAO.GetByUserID(userEntity.ID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Convert from Structs to interface types
+	orgs := make([]config.Organization, len(organizations))
+	for i, org := range organizations {
+		orgs[i] = org
+	}
+	_farms := make([]config.Farm, len(farms))
+	for i, farm := range farms {
+		_farms[i] = farm
+	}
+	return service.mapper.MapUserConfigToModel(userEntity), orgs, _farms, nil
+}
+
+// OIDC accounts are provisioned the first time the user logs in
+func (service *OIDCAuthService) Register(userCredentials *UserCredentials,
+	baseURI string) (model.User, error) {
+
+	return nil, ErrOIDCRegistrationUnsupported
+}
+
+func (service *OIDCAuthService) Activate(registrationID uint64) (model.User, error) {
+	return nil, ErrOIDCRegistrationUnsupported
+}
+
+// Passwords are managed by the OIDC provider
+func (service *OIDCAuthService) ResetPassword(userCredentials *UserCredentials) error {
+	return ErrResetPasswordUnsupported
+}
+
+// OIDC providers enforce their own second factor
+func (service *OIDCAuthService) VerifyMFA(token, code string) (model.User,
+	[]config.Organization, []config.Farm, error) {
+
+	return nil, nil, nil, ErrMFAUnsupported
+}
+
+// Returns the account for the provider identity, creating it with the mapped
+// role if this is the user's first login. An existing account with the same
+// email address is only used if it's been explicitly linked to the identity.
+// Roles are only assigned when the account is created; organizations from
+// the ID token claims the user doesn't belong to yet are added with the
+// mapped role.
+func (service *OIDCAuthService) provision(subject, email string,
+	claims jwt.MapClaims) (*config.UserStruct, error) {
+
+	role, err := service.mapRole(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	userEntity, err := service.getUser(service.idGenerator.NewStringID(subject))
+	if err != nil {
+		return nil, err
+	}
+	if userEntity == nil {
+		userEntity, err = service.emails.getUser(email)
+		if err != nil && err.Error() != ErrRecordNotFound.Error() {
+			return nil, err
+		}
+		if userEntity != nil && userEntity.OIDCSubject != subject {
+			service.app.Logger.Errorf("[UNAUTHORIZED] OIDC login for %s refused, account isn't linked to %s",
+				email, subject)
+			return nil, ErrOIDCAccountExists
+		}
+	}
+	if userEntity == nil {
+		service.app.Logger.Infof("Provisioning new OIDC account: %s (%s)", email, subject)
+		// Local logins are never possible with the random password
+		password, err := service.randomString()
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := util.CreatePasswordHasher(service.app.PasswordHasherParams).Encrypt(password)
+		if err != nil {
+			return nil, err
+		}
+		userEntity = &config.UserStruct{
+			ID:          service.idGenerator.NewStringID(subject),
+			Email:       email,
+			Password:    encrypted,
+			OIDCSubject: subject,
+			Roles:       []*config.RoleStruct{role}}
+		if err := service.userDAO.Save(userEntity); err != nil {
+			return nil, err
+		}
+	} else if userEntity.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+
+	orgIDs := service.mapOrganizations(claims)
+	if len(orgIDs) == 0 {
+		return userEntity, nil
+	}
+	memberships, err := service.permissionDAO.GetOrganizations(userEntity.ID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, err
+	}
+	for _, orgID := range orgIDs {
+		if service.isMember(memberships, orgID) {
+			continue
+		}
+		permission := config.CreatePermissionStruct(orgID, 0, userEntity.ID, role.ID)
+		if err := service.permissionDAO.Save(permission); err != nil {
+			return nil, err
+		}
+	}
+	return userEntity, nil
+}
+
+// Links the signed in user's account to the provider identity so they can
+// log in through the provider. The identity can only be linked to one
+// account.
+func (service *OIDCAuthService) link(userID uint64, subject string) (*config.UserStruct, error) {
+	provisioned, err := service.getUser(service.idGenerator.NewStringID(subject))
+	if err != nil {
+		return nil, err
+	}
+	if provisioned != nil && provisioned.ID != userID {
+		return nil, ErrOIDCAccountExists
+	}
+	userEntity, err := service.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if userEntity == nil {
+		return nil, ErrUserNotFound
+	}
+	if userEntity.IsDisabled() {
+		return nil, ErrAccountDisabled
+	}
+	if userEntity.OIDCSubject != subject {
+		userEntity.OIDCSubject = subject
+		if err := service.userDAO.Save(userEntity); err != nil {
+			return nil, err
+		}
+		service.app.Logger.Infof("Linked account %s to OIDC identity %s", userEntity.GetEmail(), subject)
+	}
+	return userEntity, nil
+}
+
+// Returns the user or nil if the user doesn't exist
+func (service *OIDCAuthService) getUser(userID uint64) (*config.UserStruct, error) {
+	userEntity, err := service.userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	if err != nil && err.Error() != ErrRecordNotFound.Error() {
+		return nil, err
+	}
+	if userEntity == nil || userEntity.ID == 0 {
+		return nil, nil
+	}
+	return userEntity, nil
+}
+
+// Returns true if the provider has verified the user's email address.
+// Some providers send the email_verified claim as a string.
+func (service *OIDCAuthService) isEmailVerified(claims jwt.MapClaims) bool {
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		return verified
+	case string:
+		return verified == "true"
+	}
+	return false
+}
+
+// Returns the role mapped to the first role claim value with a mapping,
+// falling back to the configured default role
+func (service *OIDCAuthService) mapRole(claims jwt.MapClaims) (*config.RoleStruct, error) {
+	roleName := ""
+	if service.config.RoleClaim != "" {
+		for _, value := range service.claimValues(claims, service.config.RoleClaim) {
+			if mapped, ok := service.config.RoleMapping[value]; ok {
+				roleName = mapped
+				break
+			}
+		}
+	}
+	if roleName == "" {
+		roleName = service.config.DefaultRole
+	}
+	if roleName == "" {
+		roleName = service.app.DefaultRole
+	}
+	return service.roleDAO.GetByName(roleName, common.CONSISTENCY_LOCAL)
+}
+
+// Returns the IDs of the organizations mapped to the organization claim values
+func (service *OIDCAuthService) mapOrganizations(claims jwt.MapClaims) []uint64 {
+	orgIDs := make([]uint64, 0)
+	if service.config.OrganizationClaim == "" {
+		return orgIDs
+	}
+	seen := make(map[uint64]bool, 0)
+	for _, value := range service.claimValues(claims, service.config.OrganizationClaim) {
+		if orgID, ok := service.config.OrganizationMapping[value]; ok && !seen[orgID] {
+			seen[orgID] = true
+			orgIDs = append(orgIDs, orgID)
+		}
+	}
+	return orgIDs
+}
+
+func (service *OIDCAuthService) isMember(organizations []*config.OrganizationStruct, orgID uint64) bool {
+	for _, org := range organizations {
+		if org.ID == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the string values of a claim. Nested claims such as Keycloak's
+// realm_access.roles are addressed using a dotted path.
+func (service *OIDCAuthService) claimValues(claims jwt.MapClaims, path string) []string {
+	var value interface{} = map[string]interface{}(claims)
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// Returns the stored login for the state and deletes it so the state can't
+// be used again. The login must have been started by the same browser.
+func (service *OIDCAuthService) consumeState(state, binding string) (*entity.OIDCState, error) {
+	loginState, err := service.stateDAO.Get(service.idGenerator.NewStringID(state), common.CONSISTENCY_LOCAL)
+	if err != nil || loginState == nil || loginState.ID == 0 {
+		return nil, ErrOIDCInvalidState
+	}
+	if err := service.stateDAO.Delete(loginState); err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(state)), []byte(loginState.StateHash)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(hashSecret(binding)), []byte(loginState.BindingHash)) != 1 {
+		service.app.Logger.Errorf("[UNAUTHORIZED] OIDC callback from a browser that didn't start the login")
+		return nil, ErrOIDCInvalidState
+	}
+	if loginState.IsExpired(service.clock()) {
+		return nil, ErrOIDCInvalidState
+	}
+	return loginState, nil
+}
+
+// Deletes the logins that were never completed, at most once per state
+// expiration period
+func (service *OIDCAuthService) purgeExpiredStates(now time.Time) {
+	service.mutex.Lock()
+	if now.Sub(service.purgedAt) < common.OIDC_STATE_EXPIRATION*time.Second {
+		service.mutex.Unlock()
+		return
+	}
+	service.purgedAt = now
+	service.mutex.Unlock()
+	if err := service.stateDAO.DeleteExpired(now); err != nil {
+		service.app.Logger.Errorf("Error deleting expired OIDC logins: %s", err)
+	}
+}
+
+// Verifies the ID token signature against the provider's published keys and
+// validates the issuer, audience, expiration and nonce
+func (service *OIDCAuthService) verifyIDToken(rawIDToken, nonce string) (jwt.MapClaims, error) {
+	discovery, err := service.loadDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("%w: unexpected signing method %s", ErrOIDCInvalidIDToken, token.Method.Alg())
+		}
+		kid, _ := token.Header["kid"].(string)
+		return service.signingKey(discovery, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrOIDCInvalidIDToken, err)
+	}
+	if !claims.VerifyIssuer(discovery.Issuer, true) {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrOIDCInvalidIDToken)
+	}
+	audience := service.claimValues(claims, "aud")
+	if !service.contains(audience, service.config.ClientID) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrOIDCInvalidIDToken)
+	}
+	if azp, ok := claims["azp"].(string); len(audience) > 1 && (!ok || azp != service.config.ClientID) {
+		return nil, fmt.Errorf("%w: unexpected authorized party", ErrOIDCInvalidIDToken)
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("%w: missing expiration", ErrOIDCInvalidIDToken)
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCInvalidIDToken)
+	}
+	return claims, nil
+}
+
+func (service *OIDCAuthService) contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the provider's public key for the key ID. The key set is reloaded
+// when the key isn't known to pick up provider key rotations.
+func (service *OIDCAuthService) signingKey(discovery *OIDCDiscovery, kid string) (interface{}, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if key, ok := service.keys[kid]; ok {
+		return key, nil
+	}
+	keys, err := service.loadKeys(discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	service.keys = keys
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	// Providers with a single key may not set a key ID
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %s", ErrOIDCInvalidIDToken, kid)
+}
+
+// Downloads and parses the provider's JSON web key set
+func (service *OIDCAuthService) loadKeys(jwksURI string) (map[string]interface{}, error) {
+	var jwks struct {
+		Keys []oidcJSONWebKey `json:"keys"`
+	}
+	if err := service.getJSON(jwksURI, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := service.parseKey(jwk)
+		if err != nil {
+			service.app.Logger.Warningf("Skipping OIDC signing key %s: %s", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (service *OIDCAuthService) parseKey(jwk oidcJSONWebKey) (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+}
+
+// Returns the OAuth2 client configuration using the
+// endpoints from the discovery document
+func (service *OIDCAuthService) oauthConfig() (*oauth2.Config, error) {
+	discovery, err := service.loadDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	scopes := service.config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	return &oauth2.Config{
+		ClientID:     service.config.ClientID,
+		ClientSecret: service.config.ClientSecret,
+		RedirectURL:  service.config.RedirectURL,
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint}}, nil
+}
+
+// Loads the discovery document the first time it's needed. The issuer in
+// the document must match the configured issuer.
+func (service *OIDCAuthService) loadDiscovery() (*OIDCDiscovery, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if service.discovery != nil {
+		return service.discovery, nil
+	}
+	issuer := strings.TrimSuffix(service.config.Issuer, "/")
+	var discovery OIDCDiscovery
+	if err := service.getJSON(issuer+common.OIDC_DISCOVERY_PATH, &discovery); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch %s", ErrOIDCDiscovery, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrOIDCDiscovery)
+	}
+	service.discovery = &discovery
+	return service.discovery, nil
+}
+
+func (service *OIDCAuthService) getJSON(url string, v interface{}) error {
+	response, err := service.httpClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrOIDCDiscovery, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %d", ErrOIDCDiscovery, url, response.StatusCode)
+	}
+	if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s", ErrOIDCDiscovery, err)
+	}
+	return nil
+}
+
+func (service *OIDCAuthService) randomString() (string, error) {
+	b := make([]byte, common.OIDC_STATE_LENGTH)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
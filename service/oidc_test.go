@@ -0,0 +1,360 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/mapper"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockOIDCIssuer is a minimal OpenID Connect provider that issues an ID
+// token with the configured claims for a single authorization code
+type mockOIDCIssuer struct {
+	t         *testing.T
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockOIDCIssuer(t *testing.T) *mockOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	issuer := &mockOIDCIssuer{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc(common.OIDC_DISCOVERY_PATH, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OIDCDiscovery{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string][]oidcJSONWebKey{
+			"keys": {{
+				Kid: "test",
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes())}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		digest := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "authcode" ||
+			base64.RawURLEncoding.EncodeToString(digest[:]) != issuer.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   300,
+			"id_token":     issuer.idToken(issuer.key)})
+	})
+	issuer.server = httptest.NewServer(mux)
+	return issuer
+}
+
+func (issuer *mockOIDCIssuer) idToken(key *rsa.PrivateKey) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, issuer.claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(key)
+	assert.Nil(issuer.t, err)
+	return signed
+}
+
+// Starts a login and returns the state and nonce sent to the provider
+// and the browser binding cookie value
+func (issuer *mockOIDCIssuer) authorize(oidcService OIDCAuthServicer) (string, string, string) {
+	return issuer.authorizeLink(oidcService, 0)
+}
+
+// Starts a login that links the user's account to the provider identity
+func (issuer *mockOIDCIssuer) authorizeLink(oidcService OIDCAuthServicer, userID uint64) (string, string, string) {
+	authURL, binding, err := oidcService.AuthCodeURL(userID)
+	assert.Nil(issuer.t, err)
+	assert.NotEmpty(issuer.t, binding)
+	parsed, err := url.Parse(authURL)
+	assert.Nil(issuer.t, err)
+	query := parsed.Query()
+	assert.Equal(issuer.t, issuer.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(issuer.t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(issuer.t, "cropdroid", query.Get("client_id"))
+	issuer.challenge = query.Get("code_challenge")
+	return query.Get("state"), query.Get("nonce"), binding
+}
+
+func (issuer *mockOIDCIssuer) setClaims(nonce string, roles []interface{}, groups []interface{}) {
+	issuer.claims = jwt.MapClaims{
+		"iss":            issuer.server.URL,
+		"sub":            "f3a1",
+		"aud":            "cropdroid",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "Grower@Example.com",
+		"email_verified": true,
+		"realm_access":   map[string]interface{}{"roles": roles},
+		"groups":         groups}
+}
+
+type fakeOIDCUserDAO struct {
+	users map[uint64]*config.UserStruct
+	dao.UserDAO
+}
+
+func (userDAO *fakeOIDCUserDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.UserStruct, error) {
+	if user, ok := userDAO.users[id]; ok {
+		return user, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (userDAO *fakeOIDCUserDAO) Save(user *config.UserStruct) error {
+	userDAO.users[user.ID] = user
+	return nil
+}
+
+type fakeOIDCStateDAO struct {
+	states map[uint64]*entity.OIDCState
+	dao.OIDCStateDAO
+}
+
+func (stateDAO *fakeOIDCStateDAO) Save(state *entity.OIDCState) error {
+	stateDAO.states[state.ID] = state
+	return nil
+}
+
+func (stateDAO *fakeOIDCStateDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.OIDCState, error) {
+	if state, ok := stateDAO.states[id]; ok {
+		return state, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (stateDAO *fakeOIDCStateDAO) Delete(state *entity.OIDCState) error {
+	delete(stateDAO.states, state.ID)
+	return nil
+}
+
+func (stateDAO *fakeOIDCStateDAO) DeleteExpired(now time.Time) error {
+	for id, state := range stateDAO.states {
+		if state.IsExpired(now) {
+			delete(stateDAO.states, id)
+		}
+	}
+	return nil
+}
+
+type fakeOIDCRoleDAO struct {
+	dao.RoleDAO
+}
+
+func (roleDAO *fakeOIDCRoleDAO) GetByName(name string, CONSISTENCY_LEVEL int) (*config.RoleStruct, error) {
+	return &config.RoleStruct{ID: util.NewIdGenerator("").NewStringID(name), Name: name}, nil
+}
+
+type fakeOIDCPermissionDAO struct {
+	permissions []*config.PermissionStruct
+	dao.PermissionDAO
+}
+
+func (permissionDAO *fakeOIDCPermissionDAO) GetOrganizations(userID uint64, CONSISTENCY_LEVEL int) ([]*config.OrganizationStruct, error) {
+	orgs := make([]*config.OrganizationStruct, 0)
+	for _, permission := range permissionDAO.permissions {
+		if permission.UserID == userID {
+			orgs = append(orgs, &config.OrganizationStruct{ID: permission.OrganizationID})
+		}
+	}
+	return orgs, nil
+}
+
+func (permissionDAO *fakeOIDCPermissionDAO) Save(permission *config.PermissionStruct) error {
+	permissionDAO.permissions = append(permissionDAO.permissions, permission)
+	return nil
+}
+
+func (permissionDAO *fakeOIDCPermissionDAO) Update(permission *config.PermissionStruct) error {
+	for _, p := range permissionDAO.permissions {
+		if p.OrganizationID == permission.OrganizationID && p.UserID == permission.UserID {
+			p.RoleID = permission.RoleID
+		}
+	}
+	return nil
+}
+
+func createOIDCTestService(issuer *mockOIDCIssuer) (OIDCAuthServicer, *fakeOIDCUserDAO, *fakeOIDCPermissionDAO) {
+	_app := &app.App{
+		Logger:      logging.MustGetLogger("oidc_test"),
+		DefaultRole: common.ROLE_CULTIVATOR,
+		PasswordHasherParams: &util.PasswordHasherParams{
+			Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+	userDAO := &fakeOIDCUserDAO{users: make(map[uint64]*config.UserStruct)}
+	permissionDAO := &fakeOIDCPermissionDAO{}
+	oidcService := NewOIDCAuthService(_app, &config.OIDC{
+		Issuer:              issuer.server.URL + "/",
+		ClientID:            "cropdroid",
+		RedirectURL:         "https://cropdroid.local/api/v1/oidc/callback",
+		RoleClaim:           "realm_access.roles",
+		RoleMapping:         map[string]string{"farm-admins": common.ROLE_ADMIN},
+		OrganizationClaim:   "groups",
+		OrganizationMapping: map[string]uint64{"/growers": 10, "/analysts": 20}},
+		permissionDAO, userDAO, nil, &fakeOIDCRoleDAO{}, &fakeAPIKeyFarmDAO{},
+		&fakeOIDCStateDAO{states: make(map[uint64]*entity.OIDCState)}, mapper.NewUserMapper())
+	return oidcService, userDAO, permissionDAO
+}
+
+func TestOIDCLogin(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	defer issuer.server.Close()
+	oidcService, userDAO, permissionDAO := createOIDCTestService(issuer)
+
+	// New users are provisioned with the mapped role and organizations and
+	// are keyed on the provider's issuer and subject
+	state, nonce, binding := issuer.authorize(oidcService)
+	issuer.setClaims(nonce, []interface{}{"offline_access", "farm-admins"}, []interface{}{"/growers", "/unmapped"})
+	user, orgs, farms, err := oidcService.Login(&UserCredentials{Code: "authcode", State: state, Binding: binding})
+	assert.Nil(t, err)
+	assert.Equal(t, "grower@example.com", user.GetEmail())
+	assert.Equal(t, util.NewIdGenerator("").NewStringID(issuer.server.URL+"|f3a1"), user.Identifier())
+	assert.True(t, user.HasRole(common.ROLE_ADMIN))
+	assert.Equal(t, 1, len(orgs))
+	assert.Equal(t, uint64(10), orgs[0].Identifier())
+	assert.Equal(t, 0, len(farms))
+	assert.Equal(t, 1, len(userDAO.users))
+	assert.NotEmpty(t, userDAO.users[user.Identifier()].Password)
+
+	// The state can only be used once
+	_, _, _, err = oidcService.Login(&UserCredentials{Code: "authcode", State: state, Binding: binding})
+	assert.ErrorIs(t, err, ErrOIDCInvalidState)
+
+	// Roles are only assigned when the account is created; organizations
+	// the user doesn't belong to yet are added with the mapped role
+	state, nonce, binding = issuer.authorize(oidcService)
+	issuer.setClaims(nonce, []interface{}{"offline_access"}, []interface{}{"/growers", "/analysts"})
+	user, orgs, _, err = oidcService.Login(&UserCredentials{Code: "authcode", State: state, Binding: binding})
+	assert.Nil(t, err)
+	assert.True(t, user.HasRole(common.ROLE_ADMIN))
+	assert.False(t, user.HasRole(common.ROLE_CULTIVATOR))
+	assert.Equal(t, 2, len(orgs))
+	assert.Equal(t, 2, len(permissionDAO.permissions))
+	idGenerator := util.NewIdGenerator("")
+	for _, permission := range permissionDAO.permissions {
+		if permission.OrganizationID == 10 {
+			assert.Equal(t, idGenerator.NewStringID(common.ROLE_ADMIN), permission.RoleID)
+		} else {
+			assert.Equal(t, idGenerator.NewStringID(common.ROLE_CULTIVATOR), permission.RoleID)
+		}
+	}
+}
+
+func TestOIDCAccountLinking(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	defer issuer.server.Close()
+	oidcService, userDAO, _ := createOIDCTestService(issuer)
+
+	localUserID := util.NewIdGenerator("").NewStringID("grower@example.com")
+	userDAO.users[localUserID] = &config.UserStruct{
+		ID:       localUserID,
+		Email:    "grower@example.com",
+		Password: "local",
+		Roles:    []*config.RoleStruct{{Name: common.ROLE_ANALYST}}}
+
+	// A provider identity with the same email can't take over the account
+	state, nonce, binding := issuer.authorize(oidcService)
+	issuer.setClaims(nonce, []interface{}{"farm-admins"}, nil)
+	_, _, _, err := oidcService.Login(&UserCredentials{Code: "authcode", State: state, Binding: binding})
+	assert.ErrorIs(t, err, ErrOIDCAccountExists)
+	assert.Equal(t, 1, len(userDAO.users))
+
+	// Until the signed in user links it
+	state, nonce, binding = issuer.authorizeLink(oidcService, localUserID)
+	issuer.setClaims(nonce, []interface{}{"farm-admins"}, nil)
+	user, _, _, err := oidcService.Login(&UserCredentials{Code: "authcode", State: state, Binding: binding})
+	assert.Nil(t, err)
+	assert.Equal(t, localUserID, user.Identifier())
+	assert.Equal(t, issuer.server.URL+"|f3a1", userDAO.users[localUserID].OIDCSubject)
+
+	state, nonce, binding = issuer.authorize(oidcService)
+	issuer.setClaims(nonce, []interface{}{"farm-admins"}, nil)
+	user, _, _, err = oidcService.Login(&UserCredentials{Code: "authcode", State: state, Binding: binding})
+	assert.Nil(t, err)
+	assert.Equal(t, localUserID, user.Identifier())
+	assert.True(t, user.HasRole(common.ROLE_ANALYST))
+	assert.False(t, user.HasRole(common.ROLE_ADMIN))
+	assert.Equal(t, "local", userDAO.users[localUserID].Password)
+	assert.Equal(t, 1, len(userDAO.users))
+}
+
+func TestOIDCInvalidIDToken(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	defer issuer.server.Close()
+	oidcService, userDAO, _ := createOIDCTestService(issuer)
+
+	login := func(tamper func(claims jwt.MapClaims)) error {
+		state, nonce, binding := issuer.authorize(oidcService)
+		issuer.setClaims(nonce, nil, nil)
+		tamper(issuer.claims)
+		_, _, _, err := oidcService.Login(&UserCredentials{Code: "authcode", State: state, Binding: binding})
+		return err
+	}
+
+	assert.ErrorIs(t, login(func(claims jwt.MapClaims) { claims["nonce"] = "replayed" }), ErrOIDCInvalidIDToken)
+	assert.ErrorIs(t, login(func(claims jwt.MapClaims) { claims["aud"] = "another-client" }), ErrOIDCInvalidIDToken)
+	assert.ErrorIs(t, login(func(claims jwt.MapClaims) {
+		claims["aud"] = []interface{}{"cropdroid", "another-client"}
+		claims["azp"] = "another-client"
+	}), ErrOIDCInvalidIDToken)
+	assert.ErrorIs(t, login(func(claims jwt.MapClaims) { claims["iss"] = "https://attacker.local" }), ErrOIDCInvalidIDToken)
+	assert.ErrorIs(t, login(func(claims jwt.MapClaims) {
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	}), ErrOIDCInvalidIDToken)
+	assert.ErrorIs(t, login(func(claims jwt.MapClaims) { delete(claims, "email") }), ErrOIDCEmailRequired)
+	assert.ErrorIs(t, login(func(claims jwt.MapClaims) { delete(claims, "email_verified") }), ErrOIDCEmailUnverified)
+	assert.ErrorIs(t, login(func(claims jwt.MapClaims) { claims["email_verified"] = false }), ErrOIDCEmailUnverified)
+	assert.ErrorIs(t, login(func(claims jwt.MapClaims) { delete(claims, "sub") }), ErrOIDCSubjectRequired)
+
+	// The callback must come from the browser that started the login
+	state, nonce, _ := issuer.authorize(oidcService)
+	issuer.setClaims(nonce, nil, nil)
+	_, _, _, err := oidcService.Login(&UserCredentials{Code: "authcode", State: state, Binding: "another-browser"})
+	assert.ErrorIs(t, err, ErrOIDCInvalidState)
+
+	// The authorization code is bound to the PKCE verifier of the login
+	state, _, binding := issuer.authorize(oidcService)
+	issuer.challenge = "tampered"
+	_, _, _, err = oidcService.Login(&UserCredentials{Code: "authcode", State: state, Binding: binding})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// ID tokens must be signed by the provider
+	forged, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	_, nonce, _ = issuer.authorize(oidcService)
+	issuer.setClaims(nonce, nil, nil)
+	_, err = oidcService.(*OIDCAuthService).verifyIDToken(issuer.idToken(forged), nonce)
+	assert.ErrorIs(t, err, ErrOIDCInvalidIDToken)
+
+	assert.Equal(t, 0, len(userDAO.users))
+}
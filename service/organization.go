@@ -21,14 +21,16 @@ type OrganizationService interface {
 	Create(organization config.Organization) error
 	Page(session Session, pageQuery query.PageQuery) (dao.PageResult[*config.OrganizationStruct], error)
 	GetUsers(session Session) ([]model.User, error)
+	SetMFARequired(session Session, required bool) error
 	Delete(session Session) error
 }
 
 type Organization struct {
-	logger      *logging.Logger
-	idGenerator util.IdGenerator
-	orgDAO      dao.OrganizationDAO
-	userMapper  mapper.UserMapper
+	logger          *logging.Logger
+	idGenerator     util.IdGenerator
+	orgDAO          dao.OrganizationDAO
+	userMapper      mapper.UserMapper
+	serviceRegistry ServiceRegistry
 	OrganizationService
 }
 
@@ -36,29 +38,37 @@ func NewOrganizationService(
 	logger *logging.Logger,
 	idGenerator util.IdGenerator,
 	orgDAO dao.OrganizationDAO,
-	userMapper mapper.UserMapper) OrganizationService {
+	userMapper mapper.UserMapper,
+	serviceRegistry ServiceRegistry) OrganizationService {
 
 	return &Organization{
-		logger:      logger,
-		idGenerator: idGenerator,
-		orgDAO:      orgDAO,
-		userMapper:  userMapper}
+		logger:          logger,
+		idGenerator:     idGenerator,
+		orgDAO:          orgDAO,
+		userMapper:      userMapper,
+		serviceRegistry: serviceRegistry}
 }
 
-// Creates a new organization
+// Creates a new organization if the server license allows another one
 func (service *Organization) Create(organization config.Organization) error {
+	if licenseService := service.serviceRegistry.GetLicenseService(); licenseService != nil {
+		if err := licenseService.CheckOrganizationQuota(); err != nil {
+			return err
+		}
+	}
 	organization.SetID(service.idGenerator.NewStringID(organization.GetName()))
 	return service.orgDAO.Save(organization.(*config.OrganizationStruct))
 }
 
-// Returns a list of User entities that belong to the organization
+// Returns a page of the organizations the session belongs to. System
+// administrators page through every organization.
 func (service *Organization) Page(session Session,
 	pageQuery query.PageQuery) (dao.PageResult[*config.OrganizationStruct], error) {
 
 	if !session.GetUser().HasRole(common.ROLE_ADMIN) {
 		return dao.PageResult[*config.OrganizationStruct]{}, ErrPermissionDenied
 	}
-	return service.orgDAO.GetPage(pageQuery, common.CONSISTENCY_LOCAL)
+	return NewTenantOrganizationDAO(session, service.orgDAO).GetPage(pageQuery, common.CONSISTENCY_LOCAL)
 }
 
 // Returns a list of User entities that belong to the organization
@@ -66,7 +76,8 @@ func (service *Organization) GetUsers(session Session) ([]model.User, error) {
 	if !session.HasRole(common.ROLE_ADMIN) {
 		return nil, ErrPermissionDenied
 	}
-	userStructs, err := service.orgDAO.GetUsers(session.GetRequestedOrganizationID())
+	orgDAO := NewTenantOrganizationDAO(session, service.orgDAO)
+	userStructs, err := orgDAO.GetUsers(session.GetRequestedOrganizationID())
 	if err != nil {
 		service.logger.Error(err)
 		return nil, err
@@ -78,12 +89,41 @@ func (service *Organization) GetUsers(session Session) ([]model.User, error) {
 	return userModels, nil
 }
 
+// Sets whether members of the requested organization must log in with a
+// second factor. Members who haven't enrolled are required to enroll at
+// their next login.
+func (service *Organization) SetMFARequired(session Session, required bool) error {
+	if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
+		return ErrPermissionDenied
+	}
+	orgID := session.GetRequestedOrganizationID()
+	orgDAO := NewTenantOrganizationDAO(session, service.orgDAO)
+	org, err := orgDAO.Get(orgID, common.CONSISTENCY_LOCAL)
+	if errors.Is(err, ErrTenantAccessDenied) {
+		return err
+	}
+	if err != nil || org == nil || org.ID == 0 {
+		return ErrOrganizationNotFound
+	}
+	before := map[string]bool{"mfa_required": org.IsMFARequired()}
+	org.SetMFARequired(required)
+	if err := orgDAO.Save(org); err != nil {
+		service.logger.Error(err)
+		return err
+	}
+	service.logger.Infof("Organization %s MFA required=%t, set by %s",
+		org.GetName(), required, session.GetUser().GetEmail())
+	recordAudit(service.logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_MFA_POLICY, "organization", orgID, before, map[string]bool{"mfa_required": required})
+	return nil
+}
+
 // Deletes an existing organization and all associated entites from the database
 func (service *Organization) Delete(session Session) error {
 	if !session.GetUser().HasRole(common.ROLE_ADMIN) {
 		return ErrPermissionDenied
 	}
-	return service.orgDAO.Delete(
+	return NewTenantOrganizationDAO(session, service.orgDAO).Delete(
 		&config.OrganizationStruct{
 			ID: session.GetRequestedOrganizationID()})
 }
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/util"
+)
+
+const passwordResetAudience = "password-reset"
+
+var (
+	ErrInvalidPasswordResetToken = errors.New("invalid or expired password reset token")
+	ErrPasswordResetUnavailable  = errors.New("password reset unavailable")
+)
+
+type PasswordResetService interface {
+	RequestReset(email string) error
+	Reset(token, password string) error
+}
+
+type DefaultPasswordResetService struct {
+	app             *app.App
+	idGenerator     util.IdGenerator
+	userDAO         dao.UserDAO
+	emails          *userEmailResolver
+	mailer          common.Mailer
+	serviceRegistry ServiceRegistry
+	expiration      time.Duration
+	privateKey      func() (*rsa.PrivateKey, error)
+	clock           func() time.Time
+	PasswordResetService
+}
+
+// The claims in a password reset token. The fingerprint is derived from the
+// user's current password hash, so the token stops working as soon as the
+// password changes.
+type passwordResetClaims struct {
+	UserID      uint64 `json:"uid"`
+	Fingerprint string `json:"fp"`
+	jwt.StandardClaims
+}
+
+// Creates a new password reset service that emails local account users a
+// signed, single use link to choose a new password. Tokens are signed with
+// the web server's private key.
+func NewPasswordResetService(
+	app *app.App,
+	userDAO dao.UserDAO,
+	userEmailDAO dao.UserEmailDAO,
+	mailer common.Mailer,
+	serviceRegistry ServiceRegistry) PasswordResetService {
+
+	return &DefaultPasswordResetService{
+		app:             app,
+		idGenerator:     util.NewIdGenerator(app.DataStoreEngine),
+		userDAO:         userDAO,
+		emails:          newUserEmailResolver(util.NewIdGenerator(app.DataStoreEngine), userDAO, userEmailDAO),
+		mailer:          mailer,
+		serviceRegistry: serviceRegistry,
+		expiration:      common.PASSWORD_RESET_EXPIRATION * time.Second,
+		privateKey: func() (*rsa.PrivateKey, error) {
+			if app.CA == nil {
+				return nil, ErrPasswordResetUnavailable
+			}
+			return app.CA.CertStore().PrivKey(app.Domain)
+		},
+		clock: time.Now}
+}
+
+// Emails the user a password reset link. Nothing is sent for unknown or
+// disabled accounts; the caller isn't told whether the account exists.
+func (service *DefaultPasswordResetService) RequestReset(email string) error {
+	user, err := service.emails.getUser(email)
+	if err != nil || user == nil {
+		service.app.Logger.Warningf("Password reset requested for unknown account: %s", email)
+		return nil
+	}
+	if user.IsDisabled() {
+		service.app.Logger.Warningf("Password reset requested for disabled account: %s", email)
+		return nil
+	}
+	now := service.clock()
+	expiresAt := now.Add(service.expiration)
+	token, err := service.sign(user, now, expiresAt)
+	if err != nil {
+		return err
+	}
+	templateData := struct {
+		AppName   string
+		Email     string
+		ResetURL  string
+		ExpiresAt time.Time
+	}{
+		AppName:   service.app.Name,
+		Email:     user.Email,
+		ResetURL:  webURL(service.app, "/reset-password", token),
+		ExpiresAt: expiresAt}
+	subject := fmt.Sprintf("%s password reset", service.app.Name)
+	if err := service.mailer.SendTemplate([]string{user.Email}, subject,
+		common.EMAIL_TEMPLATE_PASSWORD_RESET, templateData); err != nil {
+		service.app.Logger.Errorf("Error sending password reset email to %s: %s", user.Email, err)
+	}
+	return nil
+}
+
+// Sets a new password using a password reset token. The password must
+// satisfy the password policy. Resetting the password unlocks the account
+// and signs the user out of every device.
+func (service *DefaultPasswordResetService) Reset(token, password string) error {
+	claims, err := service.verify(token)
+	if err != nil {
+		return err
+	}
+	user, err := service.userDAO.Get(claims.UserID, common.CONSISTENCY_LOCAL)
+	if err != nil || user == nil {
+		return ErrInvalidPasswordResetToken
+	}
+	if subtle.ConstantTimeCompare([]byte(service.fingerprint(user)), []byte(claims.Fingerprint)) != 1 {
+		return ErrInvalidPasswordResetToken
+	}
+	if user.IsDisabled() {
+		return ErrAccountDisabled
+	}
+	if err := service.app.PasswordPolicy.Validate(password); err != nil {
+		return err
+	}
+	encrypted, err := util.CreatePasswordHasher(service.app.PasswordHasherParams).Encrypt(password)
+	if err != nil {
+		return err
+	}
+	user.SetPassword(encrypted)
+	user.FailedLogins = 0
+	user.LockedUntil = time.Time{}
+	if err := service.userDAO.Save(user); err != nil {
+		return err
+	}
+	service.app.Logger.Infof("Password reset for user %s", user.Email)
+
+	if service.serviceRegistry == nil {
+		return nil
+	}
+	if refreshTokenService := service.serviceRegistry.GetRefreshTokenService(); refreshTokenService != nil {
+		if _, err := refreshTokenService.RevokeUser(nil, user.ID); err != nil {
+			service.app.Logger.Errorf("Error revoking sessions for user %d: %s", user.ID, err)
+		}
+	}
+	recordAudit(service.app.Logger, service.serviceRegistry, nil,
+		common.AUDIT_ACTION_PASSWORD_RESET, "user", user.ID, nil, nil)
+	return nil
+}
+
+func (service *DefaultPasswordResetService) sign(user *config.UserStruct,
+	issuedAt, expiresAt time.Time) (string, error) {
+
+	privateKey, err := service.privateKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, passwordResetClaims{
+		UserID:      user.ID,
+		Fingerprint: service.fingerprint(user),
+		StandardClaims: jwt.StandardClaims{
+			Audience:  passwordResetAudience,
+			Issuer:    common.APPNAME,
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: expiresAt.Unix()}})
+	return token.SignedString(privateKey)
+}
+
+// Returns the token claims if the token was signed by this server
+// for a password reset and hasn't expired
+func (service *DefaultPasswordResetService) verify(token string) (*passwordResetClaims, error) {
+	privateKey, err := service.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	claims := &passwordResetClaims{}
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	_, err = parser.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, ErrInvalidPasswordResetToken
+		}
+		return &privateKey.PublicKey, nil
+	})
+	if err != nil {
+		return nil, ErrInvalidPasswordResetToken
+	}
+	if claims.Audience != passwordResetAudience ||
+		service.clock().Unix() > claims.ExpiresAt {
+		return nil, ErrInvalidPasswordResetToken
+	}
+	return claims, nil
+}
+
+func (service *DefaultPasswordResetService) fingerprint(user *config.UserStruct) string {
+	digest := sha256.Sum256([]byte(user.Password))
+	return hex.EncodeToString(digest[:])
+}
+
+// Returns a link to a page of the web UI carrying the token. Links are
+// built from the configured domain rather than the request so they can't
+// be pointed at another host.
+func webURL(app *app.App, path, token string) string {
+	host := app.Domain
+	if port := app.WebService.TLSPort; port != 0 && port != 443 {
+		host = fmt.Sprintf("%s:%d", host, port)
+	}
+	return fmt.Sprintf("https://%s%s?token=%s", host, path, url.QueryEscape(token))
+}
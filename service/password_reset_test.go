@@ -0,0 +1,128 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTemplateMailer struct {
+	recipients []string
+	template   string
+	data       interface{}
+	err        error
+	common.Mailer
+}
+
+func (mailer *fakeTemplateMailer) SendTemplate(recipients []string, subject, templateName string, data interface{}) error {
+	mailer.recipients = recipients
+	mailer.template = templateName
+	mailer.data = data
+	return mailer.err
+}
+
+// Returns the token from the reset link in the last email sent
+func (mailer *fakeTemplateMailer) token(t *testing.T) string {
+	resetURL := mailer.data.(struct {
+		AppName   string
+		Email     string
+		ResetURL  string
+		ExpiresAt time.Time
+	}).ResetURL
+	assert.True(t, strings.HasPrefix(resetURL, "https://cropdroid.local:8443/reset-password?token="))
+	parsed, err := url.Parse(resetURL)
+	assert.Nil(t, err)
+	return parsed.Query().Get("token")
+}
+
+func createPasswordResetTestService(t *testing.T, now *time.Time) (
+	*DefaultPasswordResetService, *fakeOIDCUserDAO, *fakeTemplateMailer, *config.UserStruct) {
+
+	_app := &app.App{
+		Logger:         logging.MustGetLogger("password_reset_test"),
+		Name:           "cropdroid",
+		Domain:         "cropdroid.local",
+		WebService:     config.WebService{TLSPort: 8443},
+		PasswordPolicy: util.NewPasswordPolicy(),
+		PasswordHasherParams: &util.PasswordHasherParams{
+			Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	password, err := util.CreatePasswordHasher(_app.PasswordHasherParams).Encrypt("oldpassword")
+	assert.Nil(t, err)
+	user := &config.UserStruct{
+		ID:           util.NewIdGenerator("").NewStringID("grower@example.com"),
+		Email:        "grower@example.com",
+		Password:     password,
+		FailedLogins: 7,
+		LockedUntil:  now.Add(time.Hour)}
+	userDAO := &fakeOIDCUserDAO{users: map[uint64]*config.UserStruct{user.ID: user}}
+	mailer := &fakeTemplateMailer{}
+
+	resetService := NewPasswordResetService(_app, userDAO, nil, mailer, nil).(*DefaultPasswordResetService)
+	resetService.privateKey = func() (*rsa.PrivateKey, error) { return privateKey, nil }
+	resetService.clock = func() time.Time { return *now }
+	return resetService, userDAO, mailer, user
+}
+
+func TestPasswordReset(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	resetService, _, mailer, user := createPasswordResetTestService(t, &now)
+
+	assert.Nil(t, resetService.RequestReset("grower@example.com"))
+	assert.Equal(t, []string{"grower@example.com"}, mailer.recipients)
+	assert.Equal(t, common.EMAIL_TEMPLATE_PASSWORD_RESET, mailer.template)
+	token := mailer.token(t)
+
+	// Password policy is enforced
+	err := resetService.Reset(token, "short")
+	assert.ErrorIs(t, err, util.ErrWeakPassword)
+
+	oldPassword := user.Password
+	assert.Nil(t, resetService.Reset(token, "newpassword"))
+	assert.NotEqual(t, oldPassword, user.Password)
+	assert.Equal(t, 0, user.FailedLogins)
+	assert.True(t, user.LockedUntil.IsZero())
+
+	match, err := util.CreatePasswordHasher(resetService.app.PasswordHasherParams).Compare("newpassword", user.Password)
+	assert.Nil(t, err)
+	assert.True(t, match)
+
+	// Tokens are single use
+	assert.Equal(t, ErrInvalidPasswordResetToken, resetService.Reset(token, "anotherpassword"))
+}
+
+func TestPasswordResetInvalidToken(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	resetService, _, mailer, user := createPasswordResetTestService(t, &now)
+
+	// Unknown accounts don't get an email and aren't reported to the caller
+	assert.Nil(t, resetService.RequestReset("nobody@example.com"))
+	assert.Nil(t, mailer.recipients)
+
+	assert.Nil(t, resetService.RequestReset("grower@example.com"))
+	token := mailer.token(t)
+
+	assert.Equal(t, ErrInvalidPasswordResetToken, resetService.Reset("garbage", "newpassword"))
+	assert.Equal(t, ErrInvalidPasswordResetToken, resetService.Reset(token[:len(token)-2], "newpassword"))
+
+	// Disabled accounts can't be reset
+	user.SetDisabled(true)
+	assert.Equal(t, ErrAccountDisabled, resetService.Reset(token, "newpassword"))
+	user.SetDisabled(false)
+
+	// Expired tokens are rejected
+	now = now.Add(common.PASSWORD_RESET_EXPIRATION*time.Second + time.Second)
+	assert.Equal(t, ErrInvalidPasswordResetToken, resetService.Reset(token, "newpassword"))
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, all tokens issued from the login have been revoked")
+)
+
+type RefreshTokenService interface {
+	Issue(userID uint64, remoteAddress string) (string, *entity.RefreshToken, error)
+	Rotate(token, remoteAddress string, authorize func(userID uint64) error) (string, *entity.RefreshToken, error)
+	Revoke(token string) error
+	RevokeUser(session Session, userID uint64) (int, error)
+}
+
+type DefaultRefreshTokenService struct {
+	logger          *logging.Logger
+	refreshTokenDAO dao.RefreshTokenDAO
+	serviceRegistry ServiceRegistry
+	expiration      time.Duration
+	mutex           *sync.Mutex
+	clock           func() time.Time
+	RefreshTokenService
+}
+
+// Creates a new refresh token service that issues opaque, single use refresh
+// tokens that expire after the given number of minutes. Access tokens are
+// short-lived; revoking a refresh token ends the session once the current
+// access token expires.
+func NewRefreshTokenService(
+	logger *logging.Logger,
+	refreshTokenDAO dao.RefreshTokenDAO,
+	expiration int,
+	serviceRegistry ServiceRegistry) RefreshTokenService {
+
+	return &DefaultRefreshTokenService{
+		logger:          logger,
+		refreshTokenDAO: refreshTokenDAO,
+		serviceRegistry: serviceRegistry,
+		expiration:      time.Duration(expiration) * time.Minute,
+		mutex:           &sync.Mutex{},
+		clock:           time.Now}
+}
+
+// Issues a refresh token for a new login, starting a new token family.
+// The user's expired refresh tokens are deleted.
+func (service *DefaultRefreshTokenService) Issue(userID uint64,
+	remoteAddress string) (string, *entity.RefreshToken, error) {
+
+	service.purgeExpired(userID)
+	familyBytes := make([]byte, 8)
+	if _, err := rand.Read(familyBytes); err != nil {
+		return "", nil, err
+	}
+	// SQL drivers reject unsigned integers with the high bit set
+	family := binary.BigEndian.Uint64(familyBytes) >> 1
+	return service.create(userID, family, remoteAddress)
+}
+
+// Exchanges a refresh token for a new refresh token in the same family. A
+// refresh token can only be exchanged once; presenting a token that has
+// already been rotated is treated as theft and revokes the entire family.
+// The optional authorize function is called with the token's user before the
+// token is used up; the token can be presented again if it returns an error.
+func (service *DefaultRefreshTokenService) Rotate(token, remoteAddress string,
+	authorize func(userID uint64) error) (string, *entity.RefreshToken, error) {
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	refreshToken, err := service.verify(token)
+	if err != nil {
+		return "", nil, err
+	}
+	if refreshToken.IsRotated() {
+		service.logger.Warningf("[UNAUTHORIZED] Refresh token reuse detected: user=%d, family=%d, address=%s",
+			refreshToken.UserID, refreshToken.Family, remoteAddress)
+		if _, err := service.revokeFamily(refreshToken.Family); err != nil {
+			return "", nil, err
+		}
+		return "", nil, ErrRefreshTokenReused
+	}
+	now := service.clock()
+	if refreshToken.IsExpired(now) {
+		return "", nil, ErrRefreshTokenExpired
+	}
+	if authorize != nil {
+		if err := authorize(refreshToken.UserID); err != nil {
+			return "", nil, err
+		}
+	}
+	refreshToken.RotatedAt = now
+	if err := service.refreshTokenDAO.Save(refreshToken); err != nil {
+		return "", nil, err
+	}
+	return service.create(refreshToken.UserID, refreshToken.Family, remoteAddress)
+}
+
+// Revokes every token in the refresh token's family, ending the login
+func (service *DefaultRefreshTokenService) Revoke(token string) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	refreshToken, err := service.verify(token)
+	if err != nil {
+		return err
+	}
+	_, err = service.revokeFamily(refreshToken.Family)
+	return err
+}
+
+// Revokes all of the user's refresh tokens, ending every login the
+// user has on every device. Returns the number of tokens revoked.
+func (service *DefaultRefreshTokenService) RevokeUser(session Session, userID uint64) (int, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	tokens, err := service.refreshTokenDAO.GetByUserID(userID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return 0, err
+	}
+	revoked, err := service.revoke(tokens)
+	if err != nil {
+		return revoked, err
+	}
+	service.logger.Infof("Revoked %d refresh tokens for user %d", revoked, userID)
+	recordAudit(service.logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_SESSION_REVOKE, "user", userID, nil, map[string]int{"revoked": revoked})
+	return revoked, nil
+}
+
+func (service *DefaultRefreshTokenService) create(userID, family uint64,
+	remoteAddress string) (string, *entity.RefreshToken, error) {
+
+	secret := make([]byte, common.REFRESH_TOKEN_SECRET_LENGTH)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	encodedSecret := hex.EncodeToString(secret)
+	now := service.clock()
+	refreshToken := &entity.RefreshToken{
+		UserID:        userID,
+		Family:        family,
+		Hash:          hashSecret(encodedSecret),
+		RemoteAddress: remoteAddress,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(service.expiration)}
+	if err := service.refreshTokenDAO.Save(refreshToken); err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%d.%s", refreshToken.ID, encodedSecret), refreshToken, nil
+}
+
+// Returns the stored refresh token if the token's secret matches and
+// the token hasn't been revoked
+func (service *DefaultRefreshTokenService) verify(token string) (*entity.RefreshToken, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidRefreshToken
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	refreshToken, err := service.refreshTokenDAO.Get(id, common.CONSISTENCY_LOCAL)
+	if err != nil || refreshToken == nil || refreshToken.ID == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(parts[1])), []byte(refreshToken.Hash)) != 1 {
+		return nil, ErrInvalidRefreshToken
+	}
+	if refreshToken.IsRevoked() {
+		return nil, ErrRefreshTokenRevoked
+	}
+	return refreshToken, nil
+}
+
+func (service *DefaultRefreshTokenService) revokeFamily(family uint64) (int, error) {
+	tokens, err := service.refreshTokenDAO.GetByFamily(family, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return 0, err
+	}
+	return service.revoke(tokens)
+}
+
+func (service *DefaultRefreshTokenService) revoke(tokens []*entity.RefreshToken) (int, error) {
+	now := service.clock()
+	revoked := 0
+	for _, token := range tokens {
+		if token.IsRevoked() {
+			continue
+		}
+		token.RevokedAt = now
+		if err := service.refreshTokenDAO.Save(token); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// Deletes the user's refresh tokens that have expired
+func (service *DefaultRefreshTokenService) purgeExpired(userID uint64) {
+	tokens, err := service.refreshTokenDAO.GetByUserID(userID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		service.logger.Errorf("Error loading refresh tokens for user %d: %s", userID, err)
+		return
+	}
+	now := service.clock()
+	for _, token := range tokens {
+		if token.IsExpired(now) {
+			if err := service.refreshTokenDAO.Delete(token); err != nil {
+				service.logger.Errorf("Error deleting expired refresh token %d: %s", token.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRefreshTokenDAO struct {
+	tokens map[uint64]*entity.RefreshToken
+	nextID uint64
+	dao.RefreshTokenDAO
+}
+
+func (refreshTokenDAO *fakeRefreshTokenDAO) Save(token *entity.RefreshToken) error {
+	if token.ID == 0 {
+		refreshTokenDAO.nextID++
+		token.ID = refreshTokenDAO.nextID
+	}
+	stored := *token
+	refreshTokenDAO.tokens[token.ID] = &stored
+	return nil
+}
+
+func (refreshTokenDAO *fakeRefreshTokenDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.RefreshToken, error) {
+	token, ok := refreshTokenDAO.tokens[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	persisted := *token
+	return &persisted, nil
+}
+
+func (refreshTokenDAO *fakeRefreshTokenDAO) GetByUserID(userID uint64, CONSISTENCY_LEVEL int) ([]*entity.RefreshToken, error) {
+	tokens := make([]*entity.RefreshToken, 0)
+	for _, token := range refreshTokenDAO.tokens {
+		if token.UserID == userID {
+			persisted := *token
+			tokens = append(tokens, &persisted)
+		}
+	}
+	return tokens, nil
+}
+
+func (refreshTokenDAO *fakeRefreshTokenDAO) GetByFamily(family uint64, CONSISTENCY_LEVEL int) ([]*entity.RefreshToken, error) {
+	tokens := make([]*entity.RefreshToken, 0)
+	for _, token := range refreshTokenDAO.tokens {
+		if token.Family == family {
+			persisted := *token
+			tokens = append(tokens, &persisted)
+		}
+	}
+	return tokens, nil
+}
+
+func (refreshTokenDAO *fakeRefreshTokenDAO) Delete(token *entity.RefreshToken) error {
+	delete(refreshTokenDAO.tokens, token.ID)
+	return nil
+}
+
+func newTestRefreshTokenService(now *time.Time) (RefreshTokenService, *fakeRefreshTokenDAO) {
+	refreshTokenDAO := &fakeRefreshTokenDAO{tokens: make(map[uint64]*entity.RefreshToken)}
+	refreshTokenService := NewRefreshTokenService(logging.MustGetLogger("refresh_token_test"),
+		refreshTokenDAO, 60, nil)
+	refreshTokenService.(*DefaultRefreshTokenService).clock = func() time.Time { return *now }
+	return refreshTokenService, refreshTokenDAO
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	refreshTokenService, refreshTokenDAO := newTestRefreshTokenService(&now)
+
+	token, issued, err := refreshTokenService.Issue(5, "127.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5), issued.UserID)
+	assert.Equal(t, now.Add(time.Hour), issued.ExpiresAt)
+	assert.NotContains(t, refreshTokenDAO.tokens[issued.ID].Hash, token)
+
+	_, _, err = refreshTokenService.Rotate(token+"0", "127.0.0.1", nil)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, _, err = refreshTokenService.Rotate("garbage", "127.0.0.1", nil)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	// The token isn't used up when the account can't be refreshed
+	_, _, err = refreshTokenService.Rotate(token, "127.0.0.1", func(userID uint64) error {
+		assert.Equal(t, uint64(5), userID)
+		return ErrAccountDisabled
+	})
+	assert.Equal(t, ErrAccountDisabled, err)
+	assert.False(t, refreshTokenDAO.tokens[issued.ID].IsRotated())
+
+	rotated, next, err := refreshTokenService.Rotate(token, "127.0.0.1",
+		func(userID uint64) error { return nil })
+	assert.Nil(t, err)
+	assert.NotEqual(t, token, rotated)
+	assert.Equal(t, issued.Family, next.Family)
+	assert.Equal(t, uint64(5), next.UserID)
+	assert.True(t, refreshTokenDAO.tokens[issued.ID].IsRotated())
+
+	// Reusing a rotated token revokes the entire family, including the
+	// token that replaced it
+	_, _, err = refreshTokenService.Rotate(token, "10.0.0.1", nil)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, _, err = refreshTokenService.Rotate(rotated, "127.0.0.1", nil)
+	assert.ErrorIs(t, err, ErrRefreshTokenRevoked)
+
+	// Other logins are unaffected
+	other, _, err := refreshTokenService.Issue(5, "127.0.0.1")
+	assert.Nil(t, err)
+	_, _, err = refreshTokenService.Rotate(other, "127.0.0.1", nil)
+	assert.Nil(t, err)
+}
+
+func TestRefreshTokenExpiration(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	refreshTokenService, refreshTokenDAO := newTestRefreshTokenService(&now)
+
+	token, expired, err := refreshTokenService.Issue(5, "127.0.0.1")
+	assert.Nil(t, err)
+
+	now = now.Add(61 * time.Minute)
+	_, _, err = refreshTokenService.Rotate(token, "127.0.0.1", nil)
+	assert.ErrorIs(t, err, ErrRefreshTokenExpired)
+
+	// Expired tokens are purged at the next login
+	_, _, err = refreshTokenService.Issue(5, "127.0.0.1")
+	assert.Nil(t, err)
+	_, ok := refreshTokenDAO.tokens[expired.ID]
+	assert.False(t, ok)
+	assert.Equal(t, 1, len(refreshTokenDAO.tokens))
+}
+
+func TestRefreshTokenRevocation(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	refreshTokenService, _ := newTestRefreshTokenService(&now)
+
+	phone, _, err := refreshTokenService.Issue(5, "127.0.0.1")
+	assert.Nil(t, err)
+	laptop, _, err := refreshTokenService.Issue(5, "127.0.0.2")
+	assert.Nil(t, err)
+	otherUser, _, err := refreshTokenService.Issue(6, "127.0.0.3")
+	assert.Nil(t, err)
+
+	// Logout ends the phone's login only
+	assert.Nil(t, refreshTokenService.Revoke(phone))
+	_, _, err = refreshTokenService.Rotate(phone, "127.0.0.1", nil)
+	assert.ErrorIs(t, err, ErrRefreshTokenRevoked)
+	laptop, _, err = refreshTokenService.Rotate(laptop, "127.0.0.2", nil)
+	assert.Nil(t, err)
+
+	session := apiKeyTestSession(1, common.PERMISSION_USER_MANAGE)
+	revoked, err := refreshTokenService.RevokeUser(session, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, revoked)
+	_, _, err = refreshTokenService.Rotate(laptop, "127.0.0.2", nil)
+	assert.ErrorIs(t, err, ErrRefreshTokenRevoked)
+
+	_, _, err = refreshTokenService.Rotate(otherUser, "127.0.0.3", nil)
+	assert.Nil(t, err)
+}
@@ -13,10 +13,22 @@ import (
 )
 
 type ServiceRegistry interface {
+	SetAlarmService(AlarmService)
+	GetAlarmService() AlarmService
 	SetAlgorithmService(AlgorithmServicer)
 	GetAlgorithmService() AlgorithmServicer
+	SetAPIKeyService(APIKeyService)
+	GetAPIKeyService() APIKeyService
+	SetAuditService(AuditService)
+	GetAuditService() AuditService
+	SetRefreshTokenService(RefreshTokenService)
+	GetRefreshTokenService() RefreshTokenService
+	SetMFAService(MFAService)
+	GetMFAService() MFAService
 	SetAuthService(AuthServicer)
 	GetAuthService() AuthServicer
+	SetCalibrationService(CalibrationService)
+	GetCalibrationService() CalibrationService
 	SetChannelService(ChannelServicer)
 	GetChannelService() ChannelServicer
 	SetConditionService(ConditionServicer)
@@ -28,6 +40,8 @@ type ServiceRegistry interface {
 	GetDeviceService(farmID uint64, deviceType string) (DeviceServicer, error)
 	GetDeviceServiceByID(farmID uint64, deviceID uint64) (DeviceServicer, error)
 	SetDeviceService(farmID uint64, deviceService DeviceServicer)
+	SetEmailService(EmailService)
+	GetEmailService() EmailService
 	AddEventLogService(eventLogService EventLogServicer) error
 	SetEventLogService(eventLogServices map[uint64]EventLogServicer)
 	GetEventLogServices() map[uint64]EventLogServicer
@@ -44,6 +58,18 @@ type ServiceRegistry interface {
 	GetFarmProvisioner() provisioner.FarmProvisioner
 	SetGoogleAuthService(googleAuthService AuthServicer)
 	GetGoogleAuthService() AuthServicer
+	SetOIDCAuthService(oidcAuthService OIDCAuthServicer)
+	GetOIDCAuthService() OIDCAuthServicer
+	SetPasswordResetService(passwordResetService PasswordResetService)
+	GetPasswordResetService() PasswordResetService
+	SetEmailChangeService(emailChangeService EmailChangeService)
+	GetEmailChangeService() EmailChangeService
+	SetInvitationService(invitationService InvitationService)
+	GetInvitationService() InvitationService
+	SetLicenseService(licenseService LicenseService)
+	GetLicenseService() LicenseService
+	SetInboxService(InboxService)
+	GetInboxService() InboxService
 	SetMetricService(MetricService)
 	GetMetricService() MetricService
 	SetNotificationService(NotificationServicer)
@@ -54,6 +80,8 @@ type ServiceRegistry interface {
 	GetShoppingCartService() shoppingcart.ShoppingCartService
 	SetOrganizationService(organizationService OrganizationService)
 	GetOrganizationService() OrganizationService
+	SetReportService(ReportService)
+	GetReportService() ReportService
 	SetRoleService(roleService RoleServicer)
 	GetRoleService() RoleServicer
 	SetUserService(UserServicer)
@@ -66,13 +94,20 @@ type ServiceRegistry interface {
 
 type DefaultServiceRegistry struct {
 	app                   *app.App
+	alarmService          AlarmService
 	algorithmService      AlgorithmServicer
+	apiKeyService         APIKeyService
+	auditService          AuditService
+	refreshTokenService   RefreshTokenService
+	mfaService            MFAService
 	authService           AuthServicer
+	calibrationService    CalibrationService
 	channelService        ChannelServicer
 	conditionService      ConditionServicer
 	deviceFactory         DeviceFactory
 	deviceServices        map[uint64][]DeviceServicer
 	deviceServicesMutex   *sync.RWMutex
+	emailService          EmailService
 	eventLogServices      map[uint64]EventLogServicer
 	eventLogServicesMutex *sync.RWMutex
 	farmFactory           FarmFactory
@@ -80,9 +115,16 @@ type DefaultServiceRegistry struct {
 	farmServicesMutex     *sync.RWMutex
 	farmProvisioner       provisioner.FarmProvisioner
 	googleAuthService     AuthServicer
+	oidcAuthService       OIDCAuthServicer
+	passwordResetService  PasswordResetService
+	emailChangeService    EmailChangeService
+	invitationService     InvitationService
+	licenseService        LicenseService
+	inboxService          InboxService
 	metricService         MetricService
 	notificationService   NotificationServicer
 	organizationService   OrganizationService
+	reportService         ReportService
 	roleService           RoleServicer
 	scheduleService       ScheduleService
 	userService           UserServicer
@@ -123,28 +165,16 @@ func CreateServiceRegistry(_app *app.App, daos dao.Registry,
 	workflowService := NewWorkflowService(_app, daos.GetWorkflowDAO(), mappers.GetWorkflowMapper())
 	workflowStepService := NewWorkflowStepService(_app, daos.GetWorkflowStepDAO())
 
-	notificationService := NewNotificationService(_app.Logger, nil) // Mailer
+	inboxService := NewInboxService(_app.Logger, daos.GetInboxDAO(), daos.GetFarmDAO())
+	notificationService := CreateNotificationService(_app.Logger, nil, daos.GetUserDAO(), inboxService) // Mailer
 
 	roleService := NewRoleService(_app.Logger, daos.GetRoleDAO())
 
-	authServices := make(map[int]AuthServicer, 2)
-	authService := NewLocalAuthService(_app, daos.GetPermissionDAO(),
-		daos.GetRegistrationDAO(), daos.GetOrganizationDAO(),
-		daos.GetFarmDAO(), daos.GetUserDAO(), daos.GetRoleDAO(),
-		mappers.GetUserMapper())
-	gas := NewGoogleAuthService(_app, daos.GetPermissionDAO(),
-		daos.GetUserDAO(), daos.GetRoleDAO(), daos.GetFarmDAO(),
-		mappers.GetUserMapper())
-	authServices[common.AUTH_TYPE_LOCAL] = authService
-	authServices[common.AUTH_TYPE_GOOGLE] = gas
-
 	shoppingCartService := shoppingcart.NewStripeService(_app, daos.GetCustomerDAO())
 
 	registry := &DefaultServiceRegistry{
 		app:                   _app,
 		algorithmService:      algorithmService,
-		authService:           authService,
-		googleAuthService:     gas,
 		channelService:        channelService,
 		conditionService:      conditionService,
 		farmServicesMutex:     &sync.RWMutex{},
@@ -153,6 +183,7 @@ func CreateServiceRegistry(_app *app.App, daos dao.Registry,
 		deviceServices:        make(map[uint64][]DeviceServicer, 0),
 		eventLogServicesMutex: &sync.RWMutex{},
 		eventLogServices:      make(map[uint64]EventLogServicer, 0),
+		inboxService:          inboxService,
 		metricService:         metricService,
 		notificationService:   notificationService,
 		scheduleService:       scheduleService,
@@ -161,13 +192,64 @@ func CreateServiceRegistry(_app *app.App, daos dao.Registry,
 		workflowService:       workflowService,
 		workflowStepService:   workflowStepService}
 
+	registry.SetAuditService(NewAuditService(_app.Logger, daos.GetAuditDAO(), _app.CA))
+	registry.SetAPIKeyService(NewAPIKeyService(_app.Logger, daos.GetAPIKeyDAO(),
+		daos.GetUserDAO(), daos.GetFarmDAO(), registry))
+	registry.SetRefreshTokenService(NewRefreshTokenService(_app.Logger, daos.GetRefreshTokenDAO(),
+		_app.WebService.JWTRefreshExpiration, registry))
+	registry.SetMFAService(NewMFAService(_app.Logger, daos.GetTOTPDAO(),
+		NewAccountLockout(_app, daos.GetUserDAO()), _app.Name, registry))
+	registry.SetPasswordResetService(NewPasswordResetService(_app, daos.GetUserDAO(),
+		daos.GetUserEmailDAO(), NewMailer(_app), registry))
+	registry.SetEmailChangeService(NewEmailChangeService(_app, daos.GetUserDAO(),
+		daos.GetUserEmailDAO(), NewMailer(_app), registry))
+	registry.SetLicenseService(NewLicenseService(_app.Logger, _app.IdGenerator,
+		daos.GetLicenseDAO(), daos.GetOrganizationDAO(), daos.GetFarmDAO(), _app.CA, registry))
+	registry.SetInvitationService(NewInvitationService(_app, daos.GetInvitationDAO(),
+		daos.GetUserDAO(), daos.GetUserEmailDAO(), daos.GetRoleDAO(), daos.GetOrganizationDAO(),
+		daos.GetFarmDAO(), daos.GetPermissionDAO(), NewMailer(_app), registry))
+
+	authServices := make(map[int]AuthServicer, 3)
+	authService := NewLocalAuthService(_app, daos.GetPermissionDAO(),
+		daos.GetRegistrationDAO(), daos.GetOrganizationDAO(),
+		daos.GetFarmDAO(), daos.GetUserDAO(), daos.GetUserEmailDAO(), daos.GetRoleDAO(),
+		mappers.GetUserMapper(), registry.GetMFAService())
+	gas := NewGoogleAuthService(_app, daos.GetPermissionDAO(),
+		daos.GetUserDAO(), daos.GetUserEmailDAO(), daos.GetRoleDAO(), daos.GetFarmDAO(),
+		mappers.GetUserMapper())
+	authServices[common.AUTH_TYPE_LOCAL] = authService
+	authServices[common.AUTH_TYPE_GOOGLE] = gas
+	registry.SetAuthService(authService)
+	registry.SetGoogleAuthService(gas)
+	if _app.OIDC.IsEnabled() {
+		oidcAuthService := NewOIDCAuthService(_app, _app.OIDC, daos.GetPermissionDAO(),
+			daos.GetUserDAO(), daos.GetUserEmailDAO(), daos.GetRoleDAO(), daos.GetFarmDAO(),
+			daos.GetOIDCStateDAO(),
+			mappers.GetUserMapper())
+		authServices[common.AUTH_TYPE_OIDC] = oidcAuthService
+		registry.SetOIDCAuthService(oidcAuthService)
+	}
+
 	registry.SetUserService(NewUserService(_app, daos.GetUserDAO(), daos.GetOrganizationDAO(),
 		daos.GetRoleDAO(), daos.GetPermissionDAO(), daos.GetFarmDAO(),
 		mappers.GetUserMapper(), authServices, registry))
+	registry.SetCalibrationService(NewCalibrationService(_app.Logger, metricService, registry))
+	registry.SetEmailService(NewEmailService(_app.Logger, _app.Name, NewMailer(_app),
+		daos.GetFarmDAO(), daos.GetUserDAO()))
+	registry.SetAlarmService(NewAlarmService(_app.Logger, daos.GetAlarmDAO(), registry))
+	registry.SetReportService(NewReportService(_app.Logger, daos.GetAlarmDAO(), registry))
 
 	return registry
 }
 
+func (registry *DefaultServiceRegistry) SetAlarmService(alarmService AlarmService) {
+	registry.alarmService = alarmService
+}
+
+func (registry *DefaultServiceRegistry) GetAlarmService() AlarmService {
+	return registry.alarmService
+}
+
 func (registry *DefaultServiceRegistry) SetAlgorithmService(algoService AlgorithmServicer) {
 	registry.algorithmService = algoService
 }
@@ -176,6 +258,38 @@ func (registry *DefaultServiceRegistry) GetAlgorithmService() AlgorithmServicer
 	return registry.algorithmService
 }
 
+func (registry *DefaultServiceRegistry) SetAPIKeyService(apiKeyService APIKeyService) {
+	registry.apiKeyService = apiKeyService
+}
+
+func (registry *DefaultServiceRegistry) GetAPIKeyService() APIKeyService {
+	return registry.apiKeyService
+}
+
+func (registry *DefaultServiceRegistry) SetAuditService(auditService AuditService) {
+	registry.auditService = auditService
+}
+
+func (registry *DefaultServiceRegistry) GetAuditService() AuditService {
+	return registry.auditService
+}
+
+func (registry *DefaultServiceRegistry) SetRefreshTokenService(refreshTokenService RefreshTokenService) {
+	registry.refreshTokenService = refreshTokenService
+}
+
+func (registry *DefaultServiceRegistry) GetRefreshTokenService() RefreshTokenService {
+	return registry.refreshTokenService
+}
+
+func (registry *DefaultServiceRegistry) SetMFAService(mfaService MFAService) {
+	registry.mfaService = mfaService
+}
+
+func (registry *DefaultServiceRegistry) GetMFAService() MFAService {
+	return registry.mfaService
+}
+
 func (registry *DefaultServiceRegistry) SetAuthService(authService AuthServicer) {
 	registry.authService = authService
 }
@@ -184,6 +298,14 @@ func (registry *DefaultServiceRegistry) GetAuthService() AuthServicer {
 	return registry.authService
 }
 
+func (registry *DefaultServiceRegistry) SetCalibrationService(calibrationService CalibrationService) {
+	registry.calibrationService = calibrationService
+}
+
+func (registry *DefaultServiceRegistry) GetCalibrationService() CalibrationService {
+	return registry.calibrationService
+}
+
 func (registry *DefaultServiceRegistry) SetChannelService(channelService ChannelServicer) {
 	registry.channelService = channelService
 }
@@ -259,6 +381,14 @@ func (registry *DefaultServiceRegistry) SetDeviceService(farmID uint64, deviceSe
 	registry.deviceServices[farmID] = append(registry.deviceServices[farmID], deviceService)
 }
 
+func (registry *DefaultServiceRegistry) SetEmailService(emailService EmailService) {
+	registry.emailService = emailService
+}
+
+func (registry *DefaultServiceRegistry) GetEmailService() EmailService {
+	return registry.emailService
+}
+
 func (registry *DefaultServiceRegistry) AddEventLogService(eventLogService EventLogServicer) error {
 	registry.eventLogServicesMutex.Lock()
 	defer registry.eventLogServicesMutex.Unlock()
@@ -351,6 +481,56 @@ func (registry *DefaultServiceRegistry) GetGoogleAuthService() AuthServicer {
 	return registry.googleAuthService
 }
 
+func (registry *DefaultServiceRegistry) SetOIDCAuthService(oidcAuthService OIDCAuthServicer) {
+	registry.oidcAuthService = oidcAuthService
+}
+
+// Returns the OpenID Connect auth service, or nil if an
+// OIDC provider hasn't been configured
+func (registry *DefaultServiceRegistry) GetOIDCAuthService() OIDCAuthServicer {
+	return registry.oidcAuthService
+}
+
+func (registry *DefaultServiceRegistry) SetPasswordResetService(passwordResetService PasswordResetService) {
+	registry.passwordResetService = passwordResetService
+}
+
+func (registry *DefaultServiceRegistry) GetPasswordResetService() PasswordResetService {
+	return registry.passwordResetService
+}
+
+func (registry *DefaultServiceRegistry) SetEmailChangeService(emailChangeService EmailChangeService) {
+	registry.emailChangeService = emailChangeService
+}
+
+func (registry *DefaultServiceRegistry) GetEmailChangeService() EmailChangeService {
+	return registry.emailChangeService
+}
+
+func (registry *DefaultServiceRegistry) SetInvitationService(invitationService InvitationService) {
+	registry.invitationService = invitationService
+}
+
+func (registry *DefaultServiceRegistry) GetInvitationService() InvitationService {
+	return registry.invitationService
+}
+
+func (registry *DefaultServiceRegistry) SetLicenseService(licenseService LicenseService) {
+	registry.licenseService = licenseService
+}
+
+func (registry *DefaultServiceRegistry) GetLicenseService() LicenseService {
+	return registry.licenseService
+}
+
+func (registry *DefaultServiceRegistry) SetInboxService(inboxService InboxService) {
+	registry.inboxService = inboxService
+}
+
+func (registry *DefaultServiceRegistry) GetInboxService() InboxService {
+	return registry.inboxService
+}
+
 func (registry *DefaultServiceRegistry) SetMetricService(metricService MetricService) {
 	registry.metricService = metricService
 }
@@ -391,6 +571,14 @@ func (registry *DefaultServiceRegistry) GetOrganizationService() OrganizationSer
 	return registry.organizationService
 }
 
+func (registry *DefaultServiceRegistry) SetReportService(reportService ReportService) {
+	registry.reportService = reportService
+}
+
+func (registry *DefaultServiceRegistry) GetReportService() ReportService {
+	return registry.reportService
+}
+
 func (registry *DefaultServiceRegistry) SetRoleService(roleService RoleServicer) {
 	registry.roleService = roleService
 }
@@ -0,0 +1,498 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	ErrInvalidReportPeriod    = errors.New("invalid report period, expected daily or weekly")
+	ErrInvalidReportSchedule  = errors.New("invalid report schedule")
+	ErrReportUnsupportedStore = errors.New("unsupported datastore, farm reports require a datastore that supports ranged queries")
+)
+
+type ReportService interface {
+	Generate(farmID uint64, period string, end time.Time) (*FarmReport, error)
+	Deliver(report *FarmReport) error
+	GetReport(session Session, period string) (*FarmReport, error)
+	SendReport(session Session, period string) (*FarmReport, error)
+}
+
+// FarmReport summarizes the farm's metrics, channels, alarms,
+// workflows and devices over the report period
+type FarmReport struct {
+	FarmID         uint64           `json:"farm_id"`
+	OrganizationID uint64           `json:"org_id"`
+	FarmName       string           `json:"farm"`
+	Period         string           `json:"period"`
+	Start          time.Time        `json:"start"`
+	End            time.Time        `json:"end"`
+	Metrics        []MetricReport   `json:"metrics"`
+	Channels       []ChannelReport  `json:"channels"`
+	Alarms         []AlarmReport    `json:"alarms"`
+	Workflows      []WorkflowReport `json:"workflows"`
+	Devices        []DeviceReport   `json:"devices"`
+}
+
+type MetricReport struct {
+	Device  string  `json:"device"`
+	Key     string  `json:"key"`
+	Name    string  `json:"name"`
+	Unit    string  `json:"unit"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Avg     float64 `json:"avg"`
+	Samples int     `json:"samples"`
+}
+
+// ChannelReport is the total number of seconds the channel was on and the
+// number of times it was switched on. Switching on a channel controlled by
+// an algorithm is counted as a dose.
+type ChannelReport struct {
+	Device   string `json:"device"`
+	Name     string `json:"name"`
+	OnTime   int    `json:"on_time"`
+	Switches int    `json:"switches"`
+	Doses    int    `json:"doses"`
+}
+
+type AlarmReport struct {
+	Device    string    `json:"device"`
+	Metric    string    `json:"metric"`
+	Condition string    `json:"condition"`
+	State     string    `json:"state"`
+	Value     float64   `json:"value"`
+	Message   string    `json:"message"`
+	RaisedAt  time.Time `json:"raised_at"`
+}
+
+type WorkflowReport struct {
+	Name     string `json:"name"`
+	Runs     int    `json:"runs"`
+	Failures int    `json:"failures"`
+}
+
+// DeviceReport estimates the device uptime as the percentage of the
+// farm's poll intervals in the report period with a stored sample
+type DeviceReport struct {
+	Device  string  `json:"device"`
+	Samples int     `json:"samples"`
+	Uptime  float64 `json:"uptime"`
+}
+
+type DefaultReportService struct {
+	logger          *logging.Logger
+	alarmDAO        dao.AlarmDAO
+	serviceRegistry ServiceRegistry
+	ReportService
+}
+
+// Creates a new report service that assembles farm summary reports from the
+// farm's device data store history, alarms and event log
+func NewReportService(
+	logger *logging.Logger,
+	alarmDAO dao.AlarmDAO,
+	serviceRegistry ServiceRegistry) ReportService {
+
+	return &DefaultReportService{
+		logger:          logger,
+		alarmDAO:        alarmDAO,
+		serviceRegistry: serviceRegistry}
+}
+
+// Generates the daily or weekly report for the farm ending at the requested time
+func (service *DefaultReportService) Generate(farmID uint64, period string, end time.Time) (*FarmReport, error) {
+	duration, err := reportDuration(period)
+	if err != nil {
+		return nil, err
+	}
+	farmService := service.serviceRegistry.GetFarmService(farmID)
+	if farmService == nil {
+		return nil, ErrFarmNotFound
+	}
+	farmConfig := farmService.GetConfig()
+	report := &FarmReport{
+		FarmID:         farmID,
+		OrganizationID: farmConfig.GetOrganizationID(),
+		FarmName:       farmConfig.GetName(),
+		Period:         period,
+		Start:          end.Add(-duration),
+		End:            end,
+		Metrics:        make([]MetricReport, 0),
+		Channels:       make([]ChannelReport, 0),
+		Alarms:         make([]AlarmReport, 0),
+		Workflows:      make([]WorkflowReport, 0),
+		Devices:        make([]DeviceReport, 0)}
+
+	store, ok := farmService.GetDeviceDataStore().(datastore.TimeSeriesDataStore)
+	if !ok {
+		return nil, ErrReportUnsupportedStore
+	}
+	if err := service.summarizeDevices(report, farmConfig, store); err != nil {
+		return nil, err
+	}
+	if err := service.summarizeAlarms(report); err != nil {
+		return nil, err
+	}
+	service.summarizeWorkflows(report)
+	return report, nil
+}
+
+// Delivers the plain text report through the notification system and
+// emails the HTML report to the farm users subscribed to summary emails
+func (service *DefaultReportService) Deliver(report *FarmReport) error {
+	var errs []error
+	if notificationService := service.serviceRegistry.GetNotificationService(); notificationService != nil {
+		errs = append(errs, notificationService.Enqueue(&model.NotificationStruct{
+			OrganizationID: report.OrganizationID,
+			FarmID:         report.FarmID,
+			Device:         report.FarmName,
+			Priority:       common.NOTIFICATION_PRIORITY_LOW,
+			Title:          report.Title(),
+			Type:           common.EVENT_TYPE_REPORT,
+			Message:        report.Text(),
+			Timestamp:      report.End}))
+	}
+	if emailService := service.serviceRegistry.GetEmailService(); emailService != nil {
+		farmService := service.serviceRegistry.GetFarmService(report.FarmID)
+		if farmService != nil {
+			errs = append(errs, emailService.SendSummary(farmService.GetConfig(), report.EmailSummary()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Generates a report for the session's requested farm ending now
+func (service *DefaultReportService) GetReport(session Session, period string) (*FarmReport, error) {
+	return service.Generate(session.GetRequestedFarmID(), period, time.Now())
+}
+
+// Generates and delivers a report for the session's requested farm ending now
+func (service *DefaultReportService) SendReport(session Session, period string) (*FarmReport, error) {
+	report, err := service.GetReport(session, period)
+	if err != nil {
+		return nil, err
+	}
+	return report, service.Deliver(report)
+}
+
+// Adds the metric statistics, channel on-time and device uptime
+// from the device data store history to the report
+func (service *DefaultReportService) summarizeDevices(report *FarmReport,
+	farmConfig config.Farm, store datastore.TimeSeriesDataStore) error {
+
+	interval := time.Duration(farmConfig.GetInterval()) * time.Second
+	for _, device := range farmConfig.GetDevices() {
+		if device.GetType() == common.CONTROLLER_TYPE_SERVER || !device.IsEnabled() {
+			continue
+		}
+		samples := 0
+		for _, metric := range device.GetMetrics() {
+			if !metric.IsEnabled() {
+				continue
+			}
+			points, err := store.GetRange(device.ID, metric.GetKey(), report.Start, report.End)
+			if err != nil {
+				return err
+			}
+			if len(points) > samples {
+				samples = len(points)
+			}
+			if len(points) == 0 {
+				continue
+			}
+			metricReport := MetricReport{
+				Device:  device.GetType(),
+				Key:     metric.GetKey(),
+				Name:    metric.GetName(),
+				Unit:    metric.GetUnit(),
+				Min:     math.Inf(1),
+				Max:     math.Inf(-1),
+				Samples: len(points)}
+			sum := 0.0
+			for _, point := range points {
+				metricReport.Min = math.Min(metricReport.Min, point.Value)
+				metricReport.Max = math.Max(metricReport.Max, point.Value)
+				sum += point.Value
+			}
+			metricReport.Avg = sum / float64(len(points))
+			report.Metrics = append(report.Metrics, metricReport)
+		}
+		// Channel history is stored by the channel's index in the device state
+		for i, channel := range device.GetChannels() {
+			if !channel.IsEnabled() {
+				continue
+			}
+			points, err := store.GetRange(device.ID, fmt.Sprintf("c%d", i),
+				report.Start, report.End)
+			if err != nil {
+				return err
+			}
+			if len(points) > samples {
+				samples = len(points)
+			}
+			channelReport := ChannelReport{
+				Device: device.GetType(),
+				Name:   channel.GetName()}
+			channelReport.OnTime, channelReport.Switches = channelActivity(points, report.End)
+			if channel.GetAlgorithmID() > 0 {
+				channelReport.Doses = channelReport.Switches
+			}
+			report.Channels = append(report.Channels, channelReport)
+		}
+		deviceReport := DeviceReport{
+			Device:  device.GetType(),
+			Samples: samples}
+		if interval > 0 {
+			expected := float64(report.End.Sub(report.Start) / interval)
+			deviceReport.Uptime = math.Min(100, float64(samples)/expected*100)
+		}
+		report.Devices = append(report.Devices, deviceReport)
+	}
+	return nil
+}
+
+// Adds the alarms raised during the report period to the report
+func (service *DefaultReportService) summarizeAlarms(report *FarmReport) error {
+	alarms, err := service.alarmDAO.GetByFarmID(report.FarmID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return err
+	}
+	for _, alarm := range alarms {
+		if alarm.RaisedAt.Before(report.Start) || alarm.RaisedAt.After(report.End) {
+			continue
+		}
+		report.Alarms = append(report.Alarms, AlarmReport{
+			Device:    alarm.DeviceType,
+			Metric:    alarm.MetricName,
+			Condition: alarm.Condition,
+			State:     alarm.State,
+			Value:     alarm.Value,
+			Message:   alarm.Message,
+			RaisedAt:  alarm.RaisedAt})
+	}
+	return nil
+}
+
+// Adds the number of workflow runs and failures recorded in the
+// farm event log during the report period to the report
+func (service *DefaultReportService) summarizeWorkflows(report *FarmReport) {
+	eventLogService := service.serviceRegistry.GetEventLogService(report.FarmID)
+	if eventLogService == nil {
+		return
+	}
+	workflows := make(map[string]*WorkflowReport, 0)
+	names := make([]string, 0)
+	pageQuery := query.NewPageQuery()
+	pageQuery.SortOrder = query.SORT_DESCENDING
+	for {
+		page, err := eventLogService.GetPage(pageQuery, common.CONSISTENCY_LOCAL)
+		if err != nil {
+			service.logger.Errorf("Error reading farm %d event log: %s", report.FarmID, err)
+			break
+		}
+		done := !page.HasMore
+		for _, event := range page.Entities {
+			if event.Timestamp.Before(report.Start) {
+				done = true
+				continue
+			}
+			if event.Timestamp.After(report.End) {
+				continue
+			}
+			if event.EventType != common.EVENT_TYPE_WORKFLOW &&
+				event.EventType != common.EVENT_TYPE_WORKFLOW_ERROR {
+				continue
+			}
+			workflow, ok := workflows[event.DeviceName]
+			if !ok {
+				workflow = &WorkflowReport{Name: event.DeviceName}
+				workflows[event.DeviceName] = workflow
+				names = append(names, event.DeviceName)
+			}
+			workflow.Runs++
+			if event.EventType == common.EVENT_TYPE_WORKFLOW_ERROR {
+				workflow.Failures++
+			}
+		}
+		if done {
+			break
+		}
+		pageQuery.Page++
+	}
+	for _, name := range names {
+		report.Workflows = append(report.Workflows, *workflows[name])
+	}
+}
+
+// Returns the report title, ie: "daily report"
+func (report *FarmReport) Title() string {
+	return fmt.Sprintf("%s report", report.Period)
+}
+
+// Renders the report as plain text
+func (report *FarmReport) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s: %s - %s\n", report.FarmName, report.Title(),
+		report.Start.Format(time.RFC1123), report.End.Format(time.RFC1123))
+	for _, section := range report.EmailSummary().Sections {
+		fmt.Fprintf(&sb, "\n%s\n", section.Heading)
+		if len(section.Rows) == 0 {
+			sb.WriteString("  none\n")
+			continue
+		}
+		for _, row := range section.Rows {
+			sb.WriteString("  ")
+			for i, column := range section.Columns {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				fmt.Fprintf(&sb, "%s: %s", column, row[i])
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// Converts the report to an email summary rendered by the summary email template
+func (report *FarmReport) EmailSummary() *EmailSummary {
+	metrics := EmailSummarySection{
+		Heading: "Metrics",
+		Columns: []string{"Device", "Metric", "Min", "Max", "Avg"},
+		Rows:    make([][]string, 0, len(report.Metrics))}
+	for _, metric := range report.Metrics {
+		metrics.Rows = append(metrics.Rows, []string{
+			metric.Device,
+			metric.Name,
+			formatReportValue(metric.Min, metric.Unit),
+			formatReportValue(metric.Max, metric.Unit),
+			formatReportValue(metric.Avg, metric.Unit)})
+	}
+	channels := EmailSummarySection{
+		Heading: "Channels",
+		Columns: []string{"Device", "Channel", "On Time", "Switched On", "Doses"},
+		Rows:    make([][]string, 0, len(report.Channels))}
+	for _, channel := range report.Channels {
+		channels.Rows = append(channels.Rows, []string{
+			channel.Device,
+			channel.Name,
+			(time.Duration(channel.OnTime) * time.Second).String(),
+			fmt.Sprint(channel.Switches),
+			fmt.Sprint(channel.Doses)})
+	}
+	alarms := EmailSummarySection{
+		Heading: "Alarms",
+		Columns: []string{"Raised", "Device", "Alarm", "State"},
+		Rows:    make([][]string, 0, len(report.Alarms))}
+	for _, alarm := range report.Alarms {
+		alarms.Rows = append(alarms.Rows, []string{
+			alarm.RaisedAt.Format(time.RFC1123),
+			alarm.Device,
+			alarm.Message,
+			alarm.State})
+	}
+	workflows := EmailSummarySection{
+		Heading: "Workflows",
+		Columns: []string{"Workflow", "Runs", "Failures"},
+		Rows:    make([][]string, 0, len(report.Workflows))}
+	for _, workflow := range report.Workflows {
+		workflows.Rows = append(workflows.Rows, []string{
+			workflow.Name,
+			fmt.Sprint(workflow.Runs),
+			fmt.Sprint(workflow.Failures)})
+	}
+	devices := EmailSummarySection{
+		Heading: "Devices",
+		Columns: []string{"Device", "Samples", "Uptime"},
+		Rows:    make([][]string, 0, len(report.Devices))}
+	for _, device := range report.Devices {
+		devices.Rows = append(devices.Rows, []string{
+			device.Device,
+			fmt.Sprint(device.Samples),
+			fmt.Sprintf("%.1f%%", device.Uptime)})
+	}
+	return &EmailSummary{
+		Title:    report.Title(),
+		Start:    report.Start,
+		End:      report.End,
+		Sections: []EmailSummarySection{metrics, channels, alarms, workflows, devices}}
+}
+
+// Returns the next time a report is due after the requested time for the
+// farm's report schedule, or the zero time if scheduled reports are disabled.
+// Weekly reports are sent on Mondays.
+func NextReportTime(farmConfig config.Farm, location *time.Location, after time.Time) (time.Time, error) {
+	schedule, timeOfDay := farmConfig.GetReportSchedule()
+	if schedule == "" {
+		return time.Time{}, nil
+	}
+	if _, err := reportDuration(schedule); err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidReportSchedule, schedule)
+	}
+	if timeOfDay == "" {
+		timeOfDay = common.DEFAULT_REPORT_TIME
+	}
+	minutes, err := parseTimeOfDay(timeOfDay)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidReportSchedule, timeOfDay)
+	}
+	local := after.In(location)
+	next := time.Date(local.Year(), local.Month(), local.Day(),
+		minutes/60, minutes%60, 0, 0, location)
+	for !next.After(after) ||
+		(schedule == common.REPORT_PERIOD_WEEKLY && next.Weekday() != time.Monday) {
+
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
+
+// Returns the total number of seconds the channel was on and the number of times
+// it was switched on. Each sample's value is held until the next sample.
+func channelActivity(points []datastore.DataPoint, end time.Time) (int, int) {
+	var onTime time.Duration
+	switches := 0
+	for i, point := range points {
+		if point.Value != common.SWITCH_ON {
+			continue
+		}
+		if i == 0 || points[i-1].Value != common.SWITCH_ON {
+			switches++
+		}
+		next := end
+		if i+1 < len(points) {
+			next = points[i+1].Timestamp
+		}
+		onTime += next.Sub(point.Timestamp)
+	}
+	return int(onTime.Seconds()), switches
+}
+
+func reportDuration(period string) (time.Duration, error) {
+	switch period {
+	case common.REPORT_PERIOD_DAILY:
+		return 24 * time.Hour, nil
+	case common.REPORT_PERIOD_WEEKLY:
+		return 7 * 24 * time.Hour, nil
+	}
+	return 0, ErrInvalidReportPeriod
+}
+
+func formatReportValue(value float64, unit string) string {
+	if unit == "" {
+		return fmt.Sprintf("%.2f", value)
+	}
+	return fmt.Sprintf("%.2f %s", value, unit)
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTimeSeriesStore struct {
+	series map[string][]datastore.DataPoint
+	datastore.TimeSeriesDataStore
+}
+
+func (store *fakeTimeSeriesStore) GetRange(deviceID uint64, metric string,
+	start, end time.Time) ([]datastore.DataPoint, error) {
+
+	points := make([]datastore.DataPoint, 0)
+	for _, point := range store.series[metric] {
+		if !point.Timestamp.Before(start) && !point.Timestamp.After(end) {
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
+
+type fakeDeviceDataStore struct {
+	datastore.DeviceDataStore
+}
+
+type fakeReportFarmService struct {
+	farmConfig config.Farm
+	store      datastore.DeviceDataStore
+	FarmServicer
+}
+
+func (farm *fakeReportFarmService) GetConfig() config.Farm {
+	return farm.farmConfig
+}
+
+func (farm *fakeReportFarmService) GetDeviceDataStore() datastore.DeviceDataStore {
+	return farm.store
+}
+
+type fakeEventLogService struct {
+	events []*entity.EventLog
+	EventLogServicer
+}
+
+func (eventLog *fakeEventLogService) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*entity.EventLog], error) {
+
+	start := (pageQuery.Page - 1) * pageQuery.PageSize
+	end := start + pageQuery.PageSize
+	if end > len(eventLog.events) {
+		end = len(eventLog.events)
+	}
+	return dao.PageResult[*entity.EventLog]{
+		Entities: eventLog.events[start:end],
+		Page:     pageQuery.Page,
+		PageSize: pageQuery.PageSize,
+		HasMore:  end < len(eventLog.events)}, nil
+}
+
+type fakeReportRegistry struct {
+	farmService FarmServicer
+	eventLog    EventLogServicer
+	ServiceRegistry
+}
+
+func (registry *fakeReportRegistry) GetFarmService(farmID uint64) FarmServicer {
+	return registry.farmService
+}
+
+func (registry *fakeReportRegistry) GetEventLogService(farmID uint64) EventLogServicer {
+	return registry.eventLog
+}
+
+func TestReportGenerate(t *testing.T) {
+	end := time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC)
+	start := end.Add(-24 * time.Hour)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	farmConfig := &config.FarmStruct{
+		ID:       1,
+		Name:     "Room",
+		Interval: 3600,
+		Devices: []*config.DeviceStruct{
+			{ID: 10, Type: common.CONTROLLER_TYPE_SERVER, Enable: true},
+			{
+				ID:     11,
+				Type:   "reservoir",
+				Enable: true,
+				Metrics: []*config.MetricStruct{
+					{Key: "ph0", Name: "pH", Enable: true},
+					{Key: "ec0", Name: "EC", Enable: false}},
+				Channels: []*config.ChannelStruct{
+					{Name: "pH Down", BoardID: 4, Enable: true, AlgorithmID: 5},
+					{Name: "Pump", BoardID: 2, Enable: true}}}}}
+
+	store := &fakeTimeSeriesStore{series: map[string][]datastore.DataPoint{
+		"ph0": {
+			{Timestamp: at(-60), Value: 9.0},
+			{Timestamp: at(60), Value: 5.8},
+			{Timestamp: at(120), Value: 6.4},
+			{Timestamp: at(180), Value: 6.1}},
+		"c0": {
+			{Timestamp: at(60), Value: common.SWITCH_ON},
+			{Timestamp: at(61), Value: common.SWITCH_OFF},
+			{Timestamp: at(120), Value: common.SWITCH_ON},
+			{Timestamp: at(121), Value: common.SWITCH_OFF}},
+		"c1": {
+			{Timestamp: at(0), Value: common.SWITCH_OFF},
+			{Timestamp: at(1380), Value: common.SWITCH_ON}}}}
+
+	alarmDAO := &fakeAlarmDAO{alarms: map[uint64]*entity.Alarm{
+		1: {ID: 1, FarmID: 1, DeviceType: "reservoir", MetricName: "pH",
+			State: common.ALARM_STATE_CLEARED, Message: "pH LOW: 5.80", RaisedAt: at(60)},
+		2: {ID: 2, FarmID: 1, DeviceType: "reservoir", MetricName: "pH",
+			State: common.ALARM_STATE_CLEARED, Message: "pH HIGH: 9.00", RaisedAt: at(-60)}}}
+
+	eventLog := &fakeEventLogService{events: []*entity.EventLog{
+		{EventType: common.EVENT_TYPE_WORKFLOW, DeviceName: "Flush", Timestamp: at(600)},
+		{EventType: "TimerSwitch", DeviceName: "reservoir", Timestamp: at(500)},
+		{EventType: common.EVENT_TYPE_WORKFLOW_ERROR, DeviceName: "Flush", Timestamp: at(400)},
+		{EventType: common.EVENT_TYPE_WORKFLOW, DeviceName: "Feed", Timestamp: at(300)},
+		{EventType: common.EVENT_TYPE_WORKFLOW, DeviceName: "Feed", Timestamp: at(-300)}}}
+
+	registry := &fakeReportRegistry{
+		farmService: &fakeReportFarmService{farmConfig: farmConfig, store: store},
+		eventLog:    eventLog}
+	service := NewReportService(logging.MustGetLogger("report_test"), alarmDAO, registry)
+
+	_, err := service.Generate(1, "monthly", end)
+	assert.Equal(t, ErrInvalidReportPeriod, err)
+
+	report, err := service.Generate(1, common.REPORT_PERIOD_DAILY, end)
+	assert.Nil(t, err)
+	assert.Equal(t, start, report.Start)
+
+	assert.Equal(t, 1, len(report.Metrics))
+	assert.Equal(t, "pH", report.Metrics[0].Name)
+	assert.Equal(t, 5.8, report.Metrics[0].Min)
+	assert.Equal(t, 6.4, report.Metrics[0].Max)
+	assert.InDelta(t, 6.1, report.Metrics[0].Avg, 0.001)
+	assert.Equal(t, 3, report.Metrics[0].Samples)
+
+	assert.Equal(t, 2, len(report.Channels))
+	assert.Equal(t, ChannelReport{Device: "reservoir", Name: "pH Down",
+		OnTime: 120, Switches: 2, Doses: 2}, report.Channels[0])
+	assert.Equal(t, ChannelReport{Device: "reservoir", Name: "Pump",
+		OnTime: 3600, Switches: 1, Doses: 0}, report.Channels[1])
+
+	assert.Equal(t, 1, len(report.Alarms))
+	assert.Equal(t, "pH LOW: 5.80", report.Alarms[0].Message)
+
+	assert.Equal(t, []WorkflowReport{
+		{Name: "Flush", Runs: 2, Failures: 1},
+		{Name: "Feed", Runs: 1, Failures: 0}}, report.Workflows)
+
+	assert.Equal(t, 1, len(report.Devices))
+	assert.Equal(t, "reservoir", report.Devices[0].Device)
+	assert.Equal(t, 4, report.Devices[0].Samples)
+	assert.InDelta(t, 16.67, report.Devices[0].Uptime, 0.01)
+
+	text := report.Text()
+	assert.Contains(t, text, "Room daily report")
+	assert.Contains(t, text, "Metric: pH, Min: 5.80, Max: 6.40, Avg: 6.10")
+	assert.Contains(t, text, "Workflow: Flush, Runs: 2, Failures: 1")
+}
+
+func TestReportGenerateUnsupportedStore(t *testing.T) {
+	farmConfig := &config.FarmStruct{ID: 1, Name: "Room", Interval: 3600}
+	registry := &fakeReportRegistry{
+		farmService: &fakeReportFarmService{farmConfig: farmConfig, store: &fakeDeviceDataStore{}},
+		eventLog:    &fakeEventLogService{}}
+	service := NewReportService(logging.MustGetLogger("report_test"), &fakeAlarmDAO{}, registry)
+
+	_, err := service.Generate(1, common.REPORT_PERIOD_DAILY, time.Now())
+	assert.Equal(t, ErrReportUnsupportedStore, err)
+}
+
+func TestNextReportTime(t *testing.T) {
+	location := time.UTC
+	farmConfig := &config.FarmStruct{}
+
+	// Friday
+	now := time.Date(2024, 1, 5, 8, 30, 0, 0, location)
+
+	next, err := NextReportTime(farmConfig, location, now)
+	assert.Nil(t, err)
+	assert.True(t, next.IsZero())
+
+	farmConfig.SetReportSchedule(common.REPORT_PERIOD_DAILY, "")
+	next, err = NextReportTime(farmConfig, location, now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 6, 7, 0, 0, 0, location), next)
+
+	farmConfig.SetReportSchedule(common.REPORT_PERIOD_DAILY, "09:15")
+	next, err = NextReportTime(farmConfig, location, now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 5, 9, 15, 0, 0, location), next)
+
+	farmConfig.SetReportSchedule(common.REPORT_PERIOD_WEEKLY, "06:00")
+	next, err = NextReportTime(farmConfig, location, now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 8, 6, 0, 0, 0, location), next)
+	assert.Equal(t, time.Monday, next.Weekday())
+
+	farmConfig.SetReportSchedule(common.REPORT_PERIOD_WEEKLY, "6am")
+	_, err = NextReportTime(farmConfig, location, now)
+	assert.ErrorIs(t, err, ErrInvalidReportSchedule)
+
+	farmConfig.SetReportSchedule("hourly", "06:00")
+	_, err = NextReportTime(farmConfig, location, now)
+	assert.ErrorIs(t, err, ErrInvalidReportSchedule)
+}
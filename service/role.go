@@ -43,3 +43,16 @@ func (service *RoleService) GetPage(pageQuery query.PageQuery) (dao.PageResult[*
 func (service *RoleService) GetByName(name string, CONSISTENCY_LEVEL int) (config.Role, error) {
 	return service.roleDAO.GetByName(name, CONSISTENCY_LEVEL)
 }
+
+// Returns the permissions granted by the role. The admin role is granted
+// every permission in the catalog. Built-in roles stored without permissions
+// are granted their default permission set.
+func RolePermissions(role config.Role) []string {
+	if role.GetName() == common.ROLE_ADMIN {
+		return common.PermissionCatalog
+	}
+	if permissions := role.GetPermissionList(); len(permissions) > 0 {
+		return permissions
+	}
+	return common.DefaultRolePermissions[role.GetName()]
+}
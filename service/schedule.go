@@ -120,7 +120,7 @@ func (service *DefaultScheduleService) Create(session Session, schedule config.S
 			if channel.ID == schedule.GetChannelID() {
 				channel.SetScheduleItem(schedule.(*config.ScheduleStruct))
 				device.SetChannel(channel)
-				return schedule, farmService.SetDeviceConfig(device)
+				return schedule, farmService.SetDeviceConfig(session, device)
 			}
 		}
 	}
@@ -138,10 +138,10 @@ func (service *DefaultScheduleService) Update(session Session, schedule config.S
 	for _, device := range farmConfig.GetDevices() {
 		for _, channel := range device.GetChannels() {
 			for _, _schedule := range channel.GetSchedule() {
-				if channel.ID == _schedule.GetChannelID() {
+				if _schedule.ID == schedule.Identifier() {
 					channel.SetScheduleItem(schedule.(*config.ScheduleStruct))
 					device.SetChannel(channel)
-					return farmService.SetDeviceConfig(device)
+					return farmService.SetDeviceConfig(session, device)
 				}
 			}
 		}
@@ -153,10 +153,6 @@ func (service *DefaultScheduleService) Update(session Session, schedule config.S
 func (service *DefaultScheduleService) Delete(session Session, schedule config.Schedule) error {
 	// v0.0.3a: farmService.SetDeviceConfig doesnt delete the schedule :(
 	farmID := session.GetRequestedFarmID()
-	deviceID := uint64(0)
-	if err := service.dao.Delete(farmID, deviceID, schedule.(*config.ScheduleStruct)); err != nil {
-		return err
-	}
 	farmService := session.GetFarmService()
 	farmConfig := farmService.GetConfig()
 	for _, device := range farmConfig.GetDevices() {
@@ -165,9 +161,13 @@ func (service *DefaultScheduleService) Delete(session Session, schedule config.S
 			//if channel.GetChannelID() == schedule.GetChannelID() {
 			for i, _schedule := range channel.GetSchedule() {
 				if _schedule.ID == schedule.Identifier() {
+					// Delete the farm's own schedule, not the one sent by the client
+					if err := service.dao.Delete(farmID, device.ID, _schedule); err != nil {
+						return err
+					}
 					channel.Schedule = append(channel.Schedule[:i], channel.Schedule[i+1:]...)
 					device.SetChannel(channel)
-					return farmService.SetDeviceConfig(device)
+					return farmService.SetDeviceConfig(session, device)
 				}
 			}
 			//}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Returns the hex encoded SHA-256 digest of a token secret. Only the digest
+// of API key, invitation, refresh token and recovery code secrets is stored.
+func hashSecret(secret string) string {
+	digest := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(digest[:])
+}
@@ -16,12 +16,15 @@ type Session interface {
 	GetRequestedFarmID() uint64
 	GetConsistencyLevel() int
 	HasRole(string) bool
+	HasPermission(string) bool
 	IsMemberOfOrganization(orgID uint64) bool
 	IsMemberOfFarm(farmID uint64) bool
 	GetFarmService() FarmServicer
 	SetFarmService(FarmServicer)
 	GetUser() model.User
 	SetUser(user model.User)
+	GetSessionID() string
+	SetSessionID(id string)
 	Close()
 }
 
@@ -34,6 +37,7 @@ type DefaultSession struct {
 	FarmClaims       []FarmClaim
 	farmService      FarmServicer
 	user             model.User
+	sessionID        string
 	Session
 }
 
@@ -90,6 +94,10 @@ func (session *DefaultSession) HasRole(role string) bool {
 	return session.user.HasRole(role)
 }
 
+func (session *DefaultSession) HasPermission(permission string) bool {
+	return session.user.HasPermission(permission)
+}
+
 func (session *DefaultSession) IsMemberOfOrganization(organizationID uint64) bool {
 	for _, orgClaim := range session.orgClaims {
 		if orgClaim.ID == organizationID {
@@ -140,6 +148,16 @@ func (session *DefaultSession) SetUser(user model.User) {
 	session.user = user
 }
 
+// Returns an identifier for the authentication token the session was
+// created from, used to correlate audit entries made within a session
+func (session *DefaultSession) GetSessionID() string {
+	return session.sessionID
+}
+
+func (session *DefaultSession) SetSessionID(id string) {
+	session.sessionID = id
+}
+
 func (session *DefaultSession) Close() {
 	if session.logger != nil {
 		if session.user != nil {
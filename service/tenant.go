@@ -0,0 +1,456 @@
+package service
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+)
+
+var (
+	ErrTenantAccessDenied = errors.New("resource belongs to another organization or farm")
+)
+
+// Tenant scoped DAOs wrap a DAO with the organizations and farms the session
+// belongs to, rejecting reads and writes of another tenant's entities and
+// filtering counts and pages to the session's tenants. Sessions without a
+// user (internal system sessions) and system administrators aren't scoped.
+type tenantScope struct {
+	session Session
+}
+
+func (scope tenantScope) unrestricted() bool {
+	return scope.session.GetUser() == nil ||
+		scope.session.HasPermission(common.PERMISSION_SYSTEM_ADMIN)
+}
+
+func (scope tenantScope) allowsOrganization(orgID uint64) bool {
+	return scope.unrestricted() || scope.session.IsMemberOfOrganization(orgID)
+}
+
+// Farms are accessible to their members and the members of the
+// organization that owns the farm
+func (scope tenantScope) allowsFarm(farm *config.FarmStruct) bool {
+	if scope.unrestricted() || scope.session.IsMemberOfFarm(farm.Identifier()) {
+		return true
+	}
+	orgID := farm.GetOrganizationID()
+	return orgID > 0 && scope.session.IsMemberOfOrganization(orgID)
+}
+
+type TenantOrganizationDAO struct {
+	tenantScope
+	dao.OrganizationDAO
+}
+
+// Creates a new organization DAO scoped to the organizations the
+// session belongs to
+func NewTenantOrganizationDAO(session Session, orgDAO dao.OrganizationDAO) dao.OrganizationDAO {
+	return &TenantOrganizationDAO{
+		tenantScope:     tenantScope{session: session},
+		OrganizationDAO: orgDAO}
+}
+
+func (orgDAO *TenantOrganizationDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.OrganizationStruct, error) {
+	if !orgDAO.allowsOrganization(id) {
+		return nil, ErrTenantAccessDenied
+	}
+	return orgDAO.OrganizationDAO.Get(id, CONSISTENCY_LEVEL)
+}
+
+func (orgDAO *TenantOrganizationDAO) GetUsers(id uint64) ([]*config.UserStruct, error) {
+	if !orgDAO.allowsOrganization(id) {
+		return nil, ErrTenantAccessDenied
+	}
+	return orgDAO.OrganizationDAO.GetUsers(id)
+}
+
+func (orgDAO *TenantOrganizationDAO) Save(org *config.OrganizationStruct) error {
+	if !orgDAO.allowsOrganization(org.Identifier()) {
+		return ErrTenantAccessDenied
+	}
+	return orgDAO.OrganizationDAO.Save(org)
+}
+
+func (orgDAO *TenantOrganizationDAO) Delete(org *config.OrganizationStruct) error {
+	if !orgDAO.allowsOrganization(org.Identifier()) {
+		return ErrTenantAccessDenied
+	}
+	return orgDAO.OrganizationDAO.Delete(org)
+}
+
+func (orgDAO *TenantOrganizationDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	if orgDAO.unrestricted() {
+		return orgDAO.OrganizationDAO.Count(CONSISTENCY_LEVEL)
+	}
+	orgs, err := orgDAO.organizations(CONSISTENCY_LEVEL)
+	return int64(len(orgs)), err
+}
+
+func (orgDAO *TenantOrganizationDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*config.OrganizationStruct], error) {
+
+	if orgDAO.unrestricted() {
+		return orgDAO.OrganizationDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+	}
+	orgs, err := orgDAO.organizations(CONSISTENCY_LEVEL)
+	if err != nil {
+		return dao.PageResult[*config.OrganizationStruct]{}, err
+	}
+	return tenantPage(orgs, pageQuery), nil
+}
+
+func (orgDAO *TenantOrganizationDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*config.OrganizationStruct], CONSISTENCY_LEVEL int) error {
+
+	if orgDAO.unrestricted() {
+		return orgDAO.OrganizationDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+	}
+	orgs, err := orgDAO.organizations(CONSISTENCY_LEVEL)
+	if err != nil {
+		return err
+	}
+	return tenantForEachPage(orgs, pageQuery, pagerProcFunc)
+}
+
+// Returns the organizations the session belongs to, skipping
+// organizations that have since been deleted
+func (orgDAO *TenantOrganizationDAO) organizations(CONSISTENCY_LEVEL int) ([]*config.OrganizationStruct, error) {
+	orgs := make([]*config.OrganizationStruct, 0)
+	for _, orgID := range orgDAO.session.GetOrganizationMembership() {
+		org, err := orgDAO.OrganizationDAO.Get(orgID, CONSISTENCY_LEVEL)
+		if errors.Is(err, datastore.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, nil
+}
+
+type TenantFarmDAO struct {
+	tenantScope
+	orgDAO dao.OrganizationDAO
+	dao.FarmDAO
+}
+
+// Creates a new farm DAO scoped to the farms the session belongs to,
+// directly or through the organization that owns the farm
+func NewTenantFarmDAO(session Session, farmDAO dao.FarmDAO, orgDAO dao.OrganizationDAO) dao.FarmDAO {
+	return &TenantFarmDAO{
+		tenantScope: tenantScope{session: session},
+		orgDAO:      orgDAO,
+		FarmDAO:     farmDAO}
+}
+
+func (farmDAO *TenantFarmDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.FarmStruct, error) {
+	farm, err := farmDAO.FarmDAO.Get(id, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	if !farmDAO.allowsFarm(farm) {
+		return nil, ErrTenantAccessDenied
+	}
+	return farm, nil
+}
+
+func (farmDAO *TenantFarmDAO) GetByIds(farmIds []uint64, CONSISTENCY_LEVEL int) ([]*config.FarmStruct, error) {
+	farms, err := farmDAO.FarmDAO.GetByIds(farmIds, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	return farmDAO.filter(farms), nil
+}
+
+func (farmDAO *TenantFarmDAO) GetByUserID(userID uint64, CONSISTENCY_LEVEL int) ([]*config.FarmStruct, error) {
+	farms, err := farmDAO.FarmDAO.GetByUserID(userID, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	return farmDAO.filter(farms), nil
+}
+
+// Saves the farm. New farms may only be created within
+// the session's organizations.
+func (farmDAO *TenantFarmDAO) Save(farm *config.FarmStruct) error {
+	if !farmDAO.allowsFarm(farm) {
+		return ErrTenantAccessDenied
+	}
+	if farm.Identifier() > 0 {
+		if _, err := farmDAO.Get(farm.Identifier(), common.CONSISTENCY_LOCAL); errors.Is(err, ErrTenantAccessDenied) {
+			return err
+		}
+	}
+	return farmDAO.FarmDAO.Save(farm)
+}
+
+func (farmDAO *TenantFarmDAO) Delete(farm *config.FarmStruct) error {
+	if _, err := farmDAO.Get(farm.Identifier(), common.CONSISTENCY_LOCAL); err != nil {
+		return err
+	}
+	return farmDAO.FarmDAO.Delete(farm)
+}
+
+func (farmDAO *TenantFarmDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	if farmDAO.unrestricted() {
+		return farmDAO.FarmDAO.Count(CONSISTENCY_LEVEL)
+	}
+	farms, err := farmDAO.farms(CONSISTENCY_LEVEL)
+	return int64(len(farms)), err
+}
+
+func (farmDAO *TenantFarmDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*config.FarmStruct], error) {
+
+	if farmDAO.unrestricted() {
+		return farmDAO.FarmDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+	}
+	farms, err := farmDAO.farms(CONSISTENCY_LEVEL)
+	if err != nil {
+		return dao.PageResult[*config.FarmStruct]{}, err
+	}
+	return tenantPage(farms, pageQuery), nil
+}
+
+func (farmDAO *TenantFarmDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*config.FarmStruct], CONSISTENCY_LEVEL int) error {
+
+	if farmDAO.unrestricted() {
+		return farmDAO.FarmDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+	}
+	farms, err := farmDAO.farms(CONSISTENCY_LEVEL)
+	if err != nil {
+		return err
+	}
+	return tenantForEachPage(farms, pageQuery, pagerProcFunc)
+}
+
+// Returns the farms the session belongs to and the farms owned by the
+// session's organizations, sorted by ID
+func (farmDAO *TenantFarmDAO) farms(CONSISTENCY_LEVEL int) ([]*config.FarmStruct, error) {
+	farmIDs := make([]uint64, 0)
+	members := make(map[uint64]bool)
+	addFarm := func(farmID uint64) {
+		if !members[farmID] {
+			members[farmID] = true
+			farmIDs = append(farmIDs, farmID)
+		}
+	}
+	for _, farmID := range farmDAO.session.GetFarmMembership() {
+		addFarm(farmID)
+	}
+	for _, orgID := range farmDAO.session.GetOrganizationMembership() {
+		org, err := farmDAO.orgDAO.Get(orgID, CONSISTENCY_LEVEL)
+		if errors.Is(err, datastore.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, farm := range org.GetFarms() {
+			addFarm(farm.Identifier())
+		}
+	}
+	if len(farmIDs) == 0 {
+		return []*config.FarmStruct{}, nil
+	}
+	farms, err := farmDAO.FarmDAO.GetByIds(farmIDs, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	farms = farmDAO.filter(farms)
+	sort.Slice(farms, func(i, j int) bool {
+		return farms[i].Identifier() < farms[j].Identifier()
+	})
+	return farms, nil
+}
+
+func (farmDAO *TenantFarmDAO) filter(farms []*config.FarmStruct) []*config.FarmStruct {
+	allowed := make([]*config.FarmStruct, 0, len(farms))
+	for _, farm := range farms {
+		if farm != nil && farmDAO.allowsFarm(farm) {
+			allowed = append(allowed, farm)
+		}
+	}
+	return allowed
+}
+
+type TenantUserDAO struct {
+	tenantScope
+	orgDAO  dao.OrganizationDAO
+	farmDAO dao.FarmDAO
+	dao.UserDAO
+}
+
+// Creates a new user DAO scoped to the members of the organizations and
+// farms the session belongs to. The session user is always in scope.
+func NewTenantUserDAO(session Session, userDAO dao.UserDAO,
+	orgDAO dao.OrganizationDAO, farmDAO dao.FarmDAO) dao.UserDAO {
+
+	return &TenantUserDAO{
+		tenantScope: tenantScope{session: session},
+		orgDAO:      orgDAO,
+		farmDAO:     farmDAO,
+		UserDAO:     userDAO}
+}
+
+func (userDAO *TenantUserDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.UserStruct, error) {
+	user, err := userDAO.UserDAO.Get(id, CONSISTENCY_LEVEL)
+	if err != nil {
+		return nil, err
+	}
+	if err := userDAO.verify(id, CONSISTENCY_LEVEL); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (userDAO *TenantUserDAO) Save(user *config.UserStruct) error {
+	if err := userDAO.verify(user.Identifier(), common.CONSISTENCY_LOCAL); err != nil {
+		return err
+	}
+	return userDAO.UserDAO.Save(user)
+}
+
+func (userDAO *TenantUserDAO) Delete(user *config.UserStruct) error {
+	if err := userDAO.verify(user.Identifier(), common.CONSISTENCY_LOCAL); err != nil {
+		return err
+	}
+	return userDAO.UserDAO.Delete(user)
+}
+
+func (userDAO *TenantUserDAO) Count(CONSISTENCY_LEVEL int) (int64, error) {
+	if userDAO.unrestricted() {
+		return userDAO.UserDAO.Count(CONSISTENCY_LEVEL)
+	}
+	users, err := userDAO.users(CONSISTENCY_LEVEL)
+	return int64(len(users)), err
+}
+
+func (userDAO *TenantUserDAO) GetPage(pageQuery query.PageQuery,
+	CONSISTENCY_LEVEL int) (dao.PageResult[*config.UserStruct], error) {
+
+	if userDAO.unrestricted() {
+		return userDAO.UserDAO.GetPage(pageQuery, CONSISTENCY_LEVEL)
+	}
+	users, err := userDAO.users(CONSISTENCY_LEVEL)
+	if err != nil {
+		return dao.PageResult[*config.UserStruct]{}, err
+	}
+	return tenantPage(users, pageQuery), nil
+}
+
+func (userDAO *TenantUserDAO) ForEachPage(pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[*config.UserStruct], CONSISTENCY_LEVEL int) error {
+
+	if userDAO.unrestricted() {
+		return userDAO.UserDAO.ForEachPage(pageQuery, pagerProcFunc, CONSISTENCY_LEVEL)
+	}
+	users, err := userDAO.users(CONSISTENCY_LEVEL)
+	if err != nil {
+		return err
+	}
+	return tenantForEachPage(users, pageQuery, pagerProcFunc)
+}
+
+// Returns ErrTenantAccessDenied unless the user is the session user or
+// a member of one of the session's organizations or farms
+func (userDAO *TenantUserDAO) verify(userID uint64, CONSISTENCY_LEVEL int) error {
+	if userDAO.unrestricted() || userID == userDAO.session.GetUser().Identifier() {
+		return nil
+	}
+	users, err := userDAO.users(CONSISTENCY_LEVEL)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Identifier() == userID {
+			return nil
+		}
+	}
+	return ErrTenantAccessDenied
+}
+
+// Returns the members of the session's organizations and farms, sorted by ID
+func (userDAO *TenantUserDAO) users(CONSISTENCY_LEVEL int) ([]*config.UserStruct, error) {
+	members := make(map[uint64]*config.UserStruct)
+	for _, orgID := range userDAO.session.GetOrganizationMembership() {
+		users, err := userDAO.orgDAO.GetUsers(orgID)
+		if err != nil && !errors.Is(err, datastore.ErrRecordNotFound) {
+			return nil, err
+		}
+		for _, user := range users {
+			members[user.Identifier()] = user
+		}
+	}
+	for _, farmID := range userDAO.session.GetFarmMembership() {
+		farm, err := userDAO.farmDAO.Get(farmID, CONSISTENCY_LEVEL)
+		if errors.Is(err, datastore.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range farm.GetUsers() {
+			members[user.Identifier()] = user
+		}
+	}
+	users := make([]*config.UserStruct, 0, len(members))
+	for _, user := range members {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Identifier() < users[j].Identifier()
+	})
+	return users, nil
+}
+
+// Returns the requested page of the entities a tenant scoped DAO loaded
+func tenantPage[E any](entities []E, pageQuery query.PageQuery) dao.PageResult[E] {
+	page := pageQuery.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := pageQuery.PageSize
+	if pageSize < 1 {
+		pageSize = query.NewPageQuery().PageSize
+	}
+	pageResult := dao.PageResult[E]{
+		Entities: make([]E, 0),
+		Page:     page,
+		PageSize: pageSize}
+	start := (page - 1) * pageSize
+	if start >= len(entities) {
+		return pageResult
+	}
+	end := start + pageSize
+	if end > len(entities) {
+		end = len(entities)
+	}
+	pageResult.Entities = append(pageResult.Entities, entities[start:end]...)
+	pageResult.HasMore = end < len(entities)
+	return pageResult
+}
+
+// Calls the pager function with each page of the entities a tenant
+// scoped DAO loaded, starting with the requested page
+func tenantForEachPage[E any](entities []E, pageQuery query.PageQuery,
+	pagerProcFunc query.PagerProcFunc[E]) error {
+
+	for {
+		pageResult := tenantPage(entities, pageQuery)
+		if err := pagerProcFunc(pageResult.Entities); err != nil {
+			return err
+		}
+		if !pageResult.HasMore {
+			return nil
+		}
+		pageQuery.Page = pageResult.Page + 1
+	}
+}
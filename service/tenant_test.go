@@ -0,0 +1,224 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTenantOrgDAO struct {
+	orgs map[uint64]*config.OrganizationStruct
+	dao.OrganizationDAO
+}
+
+func (orgDAO *fakeTenantOrgDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.OrganizationStruct, error) {
+	if org, ok := orgDAO.orgs[id]; ok {
+		return org, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (orgDAO *fakeTenantOrgDAO) GetUsers(id uint64) ([]*config.UserStruct, error) {
+	if org, ok := orgDAO.orgs[id]; ok {
+		return org.Users, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (orgDAO *fakeTenantOrgDAO) Save(org *config.OrganizationStruct) error {
+	orgDAO.orgs[org.ID] = org
+	return nil
+}
+
+func (orgDAO *fakeTenantOrgDAO) Delete(org *config.OrganizationStruct) error {
+	delete(orgDAO.orgs, org.ID)
+	return nil
+}
+
+type fakeTenantFarmDAO struct {
+	farms map[uint64]*config.FarmStruct
+	dao.FarmDAO
+}
+
+func (farmDAO *fakeTenantFarmDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.FarmStruct, error) {
+	if farm, ok := farmDAO.farms[id]; ok {
+		return farm, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (farmDAO *fakeTenantFarmDAO) GetByIds(farmIds []uint64, CONSISTENCY_LEVEL int) ([]*config.FarmStruct, error) {
+	farms := make([]*config.FarmStruct, 0)
+	for _, id := range farmIds {
+		if farm, ok := farmDAO.farms[id]; ok {
+			farms = append(farms, farm)
+		}
+	}
+	return farms, nil
+}
+
+func (farmDAO *fakeTenantFarmDAO) Save(farm *config.FarmStruct) error {
+	farmDAO.farms[farm.ID] = farm
+	return nil
+}
+
+func (farmDAO *fakeTenantFarmDAO) Delete(farm *config.FarmStruct) error {
+	delete(farmDAO.farms, farm.ID)
+	return nil
+}
+
+// Creates two tenants: Acme Farms (org 1) owns farm 10 and Globex (org 2)
+// owns farm 20. Farm 30 doesn't belong to an organization and is shared
+// with user 3 from Acme.
+func createTenantTestDAOs() (*fakeTenantOrgDAO, *fakeTenantFarmDAO, *fakeOIDCUserDAO) {
+	users := map[uint64]*config.UserStruct{
+		1: {ID: 1, Email: "acme-admin@example.com"},
+		2: {ID: 2, Email: "globex-admin@example.com"},
+		3: {ID: 3, Email: "acme-grower@example.com"},
+		4: {ID: 4, Email: "contractor@example.com"}}
+	farm := func(id, orgID uint64, users ...*config.UserStruct) *config.FarmStruct {
+		farm := config.NewFarm()
+		farm.ID = id
+		farm.OrganizationID = orgID
+		farm.SetUsers(users)
+		return farm
+	}
+	farmDAO := &fakeTenantFarmDAO{farms: map[uint64]*config.FarmStruct{
+		10: farm(10, 1, users[1], users[3]),
+		20: farm(20, 2, users[2]),
+		30: farm(30, 0, users[3], users[4])}}
+	orgDAO := &fakeTenantOrgDAO{orgs: map[uint64]*config.OrganizationStruct{
+		1: {ID: 1, Name: "Acme Farms", Users: []*config.UserStruct{users[1], users[3]},
+			Farms: []*config.FarmStruct{farmDAO.farms[10]}},
+		2: {ID: 2, Name: "Globex", Users: []*config.UserStruct{users[2]},
+			Farms: []*config.FarmStruct{farmDAO.farms[20]}}}}
+	return orgDAO, farmDAO, &fakeOIDCUserDAO{users: users}
+}
+
+func tenantTestSession(userID uint64, orgIDs, farmIDs []uint64, permissions ...string) Session {
+	orgClaims := make([]OrganizationClaim, len(orgIDs))
+	for i, orgID := range orgIDs {
+		orgClaims[i] = OrganizationClaim{ID: orgID}
+	}
+	farmClaims := make([]FarmClaim, len(farmIDs))
+	for i, farmID := range farmIDs {
+		farmClaims[i] = FarmClaim{ID: farmID}
+	}
+	return CreateSession(logging.MustGetLogger("tenant_test"), orgClaims,
+		farmClaims, nil, 0, 0, common.CONSISTENCY_LOCAL,
+		&model.UserStruct{
+			ID: userID,
+			Roles: []model.Role{&model.RoleStruct{
+				Name:        "manager",
+				Permissions: permissions}}})
+}
+
+func TestTenantOrganizationDAO(t *testing.T) {
+	orgDAO, _, _ := createTenantTestDAOs()
+	acme := NewTenantOrganizationDAO(tenantTestSession(1, []uint64{1}, nil), orgDAO)
+
+	org, err := acme.Get(1, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Acme Farms", org.GetName())
+
+	_, err = acme.Get(2, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, ErrTenantAccessDenied, err)
+	_, err = acme.GetUsers(2)
+	assert.Equal(t, ErrTenantAccessDenied, err)
+	assert.Equal(t, ErrTenantAccessDenied, acme.Save(&config.OrganizationStruct{ID: 2, Name: "Acme"}))
+	assert.Equal(t, ErrTenantAccessDenied, acme.Delete(&config.OrganizationStruct{ID: 2}))
+	assert.Equal(t, "Globex", orgDAO.orgs[2].GetName())
+
+	// Pages only include the session's organizations
+	page, err := acme.GetPage(query.NewPageQuery(), common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.Entities))
+	assert.Equal(t, uint64(1), page.Entities[0].ID)
+	count, err := acme.Count(common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// System administrators aren't scoped to a tenant
+	system := NewTenantOrganizationDAO(tenantTestSession(5, nil, nil,
+		common.PERMISSION_SYSTEM_ADMIN), orgDAO)
+	_, err = system.Get(2, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+}
+
+func TestTenantFarmDAO(t *testing.T) {
+	orgDAO, farmDAO, _ := createTenantTestDAOs()
+
+	// Organization members can access the organization's farms
+	acme := NewTenantFarmDAO(tenantTestSession(1, []uint64{1}, nil), farmDAO, orgDAO)
+	_, err := acme.Get(10, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	_, err = acme.Get(20, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, ErrTenantAccessDenied, err)
+	_, err = acme.Get(30, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, ErrTenantAccessDenied, err)
+
+	// Moving another tenant's farm into the session's organization is denied
+	hijacked := config.NewFarm()
+	hijacked.ID = 20
+	hijacked.OrganizationID = 1
+	assert.Equal(t, ErrTenantAccessDenied, acme.Save(hijacked))
+	assert.Equal(t, ErrTenantAccessDenied, acme.Delete(&config.FarmStruct{ID: 20}))
+	assert.Equal(t, uint64(2), farmDAO.farms[20].GetOrganizationID())
+
+	farms, err := acme.GetByIds([]uint64{10, 20, 30}, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(farms))
+
+	// Farm members can access the farms they belong to, which are queried
+	// by membership without scanning every farm in the datastore
+	grower := NewTenantFarmDAO(tenantTestSession(3, []uint64{1}, []uint64{30}), farmDAO, orgDAO)
+	page, err := grower.GetPage(query.PageQuery{Page: 1, PageSize: 1}, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10), page.Entities[0].ID)
+	assert.True(t, page.HasMore)
+	page, err = grower.GetPage(query.PageQuery{Page: 2, PageSize: 1}, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(30), page.Entities[0].ID)
+	assert.False(t, page.HasMore)
+	count, err := grower.Count(common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestTenantUserDAO(t *testing.T) {
+	orgDAO, farmDAO, userDAO := createTenantTestDAOs()
+
+	acme := NewTenantUserDAO(tenantTestSession(1, []uint64{1}, nil), userDAO, orgDAO, farmDAO)
+	_, err := acme.Get(3, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	_, err = acme.Get(2, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, ErrTenantAccessDenied, err)
+	_, err = acme.Get(99, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, datastore.ErrRecordNotFound, err)
+	assert.Equal(t, ErrTenantAccessDenied, acme.Save(&config.UserStruct{ID: 2, Email: "hijacked@example.com"}))
+	assert.Equal(t, "globex-admin@example.com", userDAO.users[2].GetEmail())
+
+	// Farm members can see the other members of their farms
+	contractor := NewTenantUserDAO(tenantTestSession(4, nil, []uint64{30}), userDAO, orgDAO, farmDAO)
+	_, err = contractor.Get(3, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	_, err = contractor.Get(1, common.CONSISTENCY_LOCAL)
+	assert.Equal(t, ErrTenantAccessDenied, err)
+	page, err := contractor.GetPage(query.NewPageQuery(), common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(page.Entities))
+	assert.Equal(t, uint64(3), page.Entities[0].ID)
+	assert.Equal(t, uint64(4), page.Entities[1].ID)
+
+	// Users are always in scope of their own session
+	outsider := NewTenantUserDAO(tenantTestSession(2, nil, nil), userDAO, orgDAO, farmDAO)
+	_, err = outsider.Get(2, common.CONSISTENCY_LOCAL)
+	assert.Nil(t, err)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/model"
 	"github.com/jeremyhahn/go-cropdroid/state"
 
@@ -24,6 +25,8 @@ var (
 	ErrPermissionDenied         = errors.New("permission denied")
 	ErrDeleteAdminAccount       = errors.New("admin account can't be deleted")
 	ErrChangeAdminRole          = errors.New("admin role can't be changed")
+	ErrDisableAdminAccount      = errors.New("admin account can't be disabled")
+	ErrDisableOwnAccount        = errors.New("users can't disable their own account")
 	ErrResetPasswordUnsupported = errors.New("reset password feature unsupported by auth store")
 )
 
@@ -47,6 +50,7 @@ type FarmChannels struct {
 	FarmNotifyChan        chan common.FarmNotification
 	DeviceStateChangeChan chan common.DeviceStateChange
 	DeviceStateDeltaChan  chan map[string]state.DeviceStateDeltaMap
+	AlarmChan             chan *entity.Alarm
 }
 
 type UserCredentials struct {
@@ -55,6 +59,9 @@ type UserCredentials struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	AuthType int    `json:"authType"`
+	Code     string `json:"code,omitempty"`
+	State    string `json:"state,omitempty"`
+	Binding  string `json:"-"`
 }
 
 // Claim structs are condensed models concerned only
@@ -79,6 +86,7 @@ type OrganizationClaim struct {
 type AuthServicer interface {
 	Activate(registrationID uint64) (model.User, error)
 	Login(userCredentials *UserCredentials) (model.User, []config.Organization, []config.Farm, error)
+	VerifyMFA(token, code string) (model.User, []config.Organization, []config.Farm, error)
 	Register(userCredentials *UserCredentials, baseURI string) (model.User, error)
 	ResetPassword(userCredentials *UserCredentials) error
 }
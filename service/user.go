@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"time"
 
 	"github.com/jeremyhahn/go-cropdroid/app"
 	"github.com/jeremyhahn/go-cropdroid/common"
@@ -24,6 +25,9 @@ type UserServicer interface {
 	//Get(email string) (model.User, error)
 	Get(userID uint64) (model.User, error)
 	SetPermission(session Session, permission config.Permission) error
+	Disable(session Session, userID uint64) error
+	Enable(session Session, userID uint64) error
+	VerifyTenant(session Session, userID uint64) error
 	// probably needs to be moved to auth service; not implemented in google_auth yet
 	Refresh(userID uint64) (model.User, []config.Organization, []config.Farm, error)
 	AuthServicer
@@ -115,12 +119,31 @@ func (service *User) Login(userCredentials *UserCredentials) (model.User,
 	return nil, nil, nil, ErrUnsupportedAuthType
 }
 
+// VerifyMFA completes a login that was challenged for a second factor. Only
+// local accounts are challenged.
+func (service *User) VerifyMFA(token, code string) (model.User,
+	[]config.Organization, []config.Farm, error) {
+
+	if authService, ok := service.authServices[common.AUTH_TYPE_LOCAL]; ok {
+		return authService.VerifyMFA(token, code)
+	}
+	return nil, nil, nil, ErrUnsupportedAuthType
+}
+
 // Reloads the users organizations, farms and permissions
 func (service *User) Refresh(userID uint64) (model.User,
 	[]config.Organization, []config.Farm, error) {
 
 	service.app.Logger.Debugf("Refreshing user: %d", userID)
 
+	account, err := service.userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if account.IsDisabled() {
+		return nil, nil, nil, ErrAccountDisabled
+	}
+
 	var user *config.UserStruct
 
 	organizations, err := service.permissionDAO.GetOrganizations(userID, common.CONSISTENCY_LOCAL)
@@ -134,7 +157,7 @@ func (service *User) Refresh(userID uint64) (model.User,
 ORG_LOOP:
 	for _, org := range organizations {
 		for _, u := range org.GetUsers() {
-			if user.Identifier() == userID {
+			if u.Identifier() == userID {
 				user = u
 				user.RedactPassword()
 				break ORG_LOOP
@@ -207,34 +230,170 @@ func (service *User) UpdateUser(user model.User) error {
 
 // Deletes an existing user account
 func (service *User) Delete(session Session, userID uint64) error {
+	userDAO := service.tenantUserDAO(session)
+	before, err := userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	if errors.Is(err, ErrTenantAccessDenied) {
+		return err
+	}
+	if err != nil {
+		before = &config.UserStruct{ID: userID}
+	}
 	if err := service.DeletePermission(session, userID); err != nil {
 		return err
 	}
-	return service.userDAO.Delete(&config.UserStruct{ID: userID})
+	if err := service.revokeAPIKeys(session, userID); err != nil {
+		return err
+	}
+	if err := userDAO.Delete(&config.UserStruct{ID: userID}); err != nil {
+		return err
+	}
+	recordAudit(service.app.Logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_USER_DELETE, "user", userID,
+		map[string]any{"id": userID, "email": before.GetEmail()}, nil)
+	return nil
 }
 
 // Sets the users "permission", ie., the role that grants access
-// to an organization and/or farm.
+// to an organization and/or farm. Users can't be added to an organization
+// or farm that's reached its licensed user quota.
 func (service *User) SetPermission(session Session, permission config.Permission) error {
-	if !session.GetUser().HasRole(common.ROLE_ADMIN) {
+	if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
 		return ErrPermissionDenied
 	}
 	if permission.GetUserID() == common.DEFAULT_USER_ID_64 || permission.GetUserID() == common.DEFAULT_USER_ID_32 {
 		return ErrChangeAdminRole
 	}
-	return service.permissionDAO.Update(permission.(*config.PermissionStruct))
+	if licenseService := service.serviceRegistry.GetLicenseService(); licenseService != nil {
+		if err := licenseService.CheckUserQuota(permission.GetOrgID(),
+			permission.GetFarmID(), permission.GetUserID()); err != nil {
+			return err
+		}
+	}
+	if err := service.permissionDAO.Update(permission.(*config.PermissionStruct)); err != nil {
+		return err
+	}
+	recordAudit(service.app.Logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_PERMISSION_SET, "user",
+		permission.GetUserID(), nil, permission)
+	return nil
 }
 
 // Delete a user permission from the requested farm
 func (service *User) DeletePermission(session Session, userID uint64) error {
-	if !session.GetUser().HasRole(common.ROLE_ADMIN) {
+	if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
 		return ErrPermissionDenied
 	}
 	if userID == common.DEFAULT_USER_ID_64 || userID == common.DEFAULT_USER_ID_32 {
 		return ErrDeleteAdminAccount
 	}
-	return service.permissionDAO.Delete(&config.PermissionStruct{
+	permission := &config.PermissionStruct{
 		OrganizationID: session.GetRequestedOrganizationID(),
 		FarmID:         session.GetRequestedFarmID(),
-		UserID:         userID})
+		UserID:         userID}
+	if err := service.permissionDAO.Delete(permission); err != nil {
+		return err
+	}
+	recordAudit(service.app.Logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_PERMISSION_DELETE, "user",
+		userID, permission, nil)
+	return nil
+}
+
+// Disables a user account. Disabled users can't log in or refresh their
+// access token, and are signed out of every device once their current
+// access token expires.
+func (service *User) Disable(session Session, userID uint64) error {
+	if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
+		return ErrPermissionDenied
+	}
+	if userID == common.DEFAULT_USER_ID_64 || userID == common.DEFAULT_USER_ID_32 {
+		return ErrDisableAdminAccount
+	}
+	if userID == session.GetUser().Identifier() {
+		return ErrDisableOwnAccount
+	}
+	userDAO := service.tenantUserDAO(session)
+	user, err := userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	if errors.Is(err, ErrTenantAccessDenied) {
+		return err
+	}
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.IsDisabled() {
+		user.SetDisabled(true)
+		if err := userDAO.Save(user); err != nil {
+			return err
+		}
+		recordAudit(service.app.Logger, service.serviceRegistry, session,
+			common.AUDIT_ACTION_USER_DISABLE, "user", userID,
+			map[string]any{"disabled": false}, map[string]any{"disabled": true})
+	}
+	if refreshTokenService := service.serviceRegistry.GetRefreshTokenService(); refreshTokenService != nil {
+		if _, err := refreshTokenService.RevokeUser(session, userID); err != nil {
+			return err
+		}
+	}
+	if err := service.revokeAPIKeys(session, userID); err != nil {
+		return err
+	}
+	service.app.Logger.Infof("User %s disabled by %s", user.GetEmail(), session.GetUser().GetEmail())
+	return nil
+}
+
+// Enables a disabled user account, also unlocking the account if it
+// was locked after too many failed logins
+func (service *User) Enable(session Session, userID uint64) error {
+	if !session.HasPermission(common.PERMISSION_USER_MANAGE) {
+		return ErrPermissionDenied
+	}
+	userDAO := service.tenantUserDAO(session)
+	user, err := userDAO.Get(userID, common.CONSISTENCY_LOCAL)
+	if errors.Is(err, ErrTenantAccessDenied) {
+		return err
+	}
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.IsDisabled() && user.FailedLogins == 0 {
+		return nil
+	}
+	before := map[string]any{"disabled": user.IsDisabled(), "failed_logins": user.FailedLogins}
+	user.SetDisabled(false)
+	user.FailedLogins = 0
+	user.LockedUntil = time.Time{}
+	if err := userDAO.Save(user); err != nil {
+		return err
+	}
+	recordAudit(service.app.Logger, service.serviceRegistry, session,
+		common.AUDIT_ACTION_USER_ENABLE, "user", userID,
+		before, map[string]any{"disabled": false, "failed_logins": 0})
+	service.app.Logger.Infof("User %s enabled by %s", user.GetEmail(), session.GetUser().GetEmail())
+	return nil
+}
+
+// Returns ErrTenantAccessDenied unless the user belongs to one of the
+// organizations or farms the session belongs to
+func (service *User) VerifyTenant(session Session, userID uint64) error {
+	_, err := service.tenantUserDAO(session).Get(userID, common.CONSISTENCY_LOCAL)
+	if errors.Is(err, ErrTenantAccessDenied) {
+		return err
+	}
+	return nil
+}
+
+// Revokes the API keys created by the user so the service accounts they
+// set up lose access along with the user
+func (service *User) revokeAPIKeys(session Session, userID uint64) error {
+	apiKeyService := service.serviceRegistry.GetAPIKeyService()
+	if apiKeyService == nil {
+		return nil
+	}
+	_, err := apiKeyService.RevokeUser(session, userID)
+	return err
+}
+
+// Returns the user DAO scoped to the session's organizations and farms
+func (service *User) tenantUserDAO(session Session) dao.UserDAO {
+	return NewTenantUserDAO(session, service.userDAO, service.orgDAO, service.farmDAO)
 }
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/util"
+)
+
+// Resolves email addresses to user accounts. User IDs are derived from the
+// email address an account was created with and never change, so accounts
+// that have changed their email address are found through the user email
+// index instead.
+type userEmailResolver struct {
+	idGenerator  util.IdGenerator
+	userDAO      dao.UserDAO
+	userEmailDAO dao.UserEmailDAO
+}
+
+func newUserEmailResolver(idGenerator util.IdGenerator, userDAO dao.UserDAO,
+	userEmailDAO dao.UserEmailDAO) *userEmailResolver {
+
+	return &userEmailResolver{
+		idGenerator:  idGenerator,
+		userDAO:      userDAO,
+		userEmailDAO: userEmailDAO}
+}
+
+// Returns the user the email address currently belongs to, or
+// datastore.ErrRecordNotFound. An account isn't returned for the address
+// it was created with once its email address has been changed.
+func (resolver *userEmailResolver) getUser(email string) (*config.UserStruct, error) {
+	emailID := resolver.idGenerator.NewStringID(email)
+	user, err := resolver.userDAO.Get(emailID, common.CONSISTENCY_LOCAL)
+	if err != nil && err.Error() != datastore.ErrRecordNotFound.Error() {
+		return nil, err
+	}
+	if user != nil && user.GetEmail() == email {
+		return user, nil
+	}
+	if resolver.userEmailDAO == nil {
+		return nil, datastore.ErrRecordNotFound
+	}
+	userEmail, err := resolver.userEmailDAO.Get(emailID, common.CONSISTENCY_LOCAL)
+	if err != nil || userEmail == nil {
+		if err != nil && err.Error() != datastore.ErrRecordNotFound.Error() {
+			return nil, err
+		}
+		return nil, datastore.ErrRecordNotFound
+	}
+	user, err = resolver.userDAO.Get(userEmail.GetUserID(), common.CONSISTENCY_LOCAL)
+	if err != nil {
+		return nil, err
+	}
+	// The index entry is stale if the user has since moved on to another address
+	if user == nil || user.GetEmail() != email {
+		return nil, datastore.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+// Returns true if the email address can't be given to the user, either
+// because another account uses it or because another account was created
+// with it. Pass a zero user ID to check the address for a new account.
+func (resolver *userEmailResolver) inUse(email string, userID uint64) (bool, error) {
+	emailID := resolver.idGenerator.NewStringID(email)
+	user, err := resolver.userDAO.Get(emailID, common.CONSISTENCY_LOCAL)
+	if err != nil && err.Error() != datastore.ErrRecordNotFound.Error() {
+		return false, err
+	}
+	if user != nil && user.ID != userID {
+		return true, nil
+	}
+	user, err = resolver.getUser(email)
+	if err != nil && err.Error() != datastore.ErrRecordNotFound.Error() {
+		return false, err
+	}
+	return user != nil && user.ID != userID, nil
+}
+
+// Changes the user's email address. The new address is indexed before the
+// user is saved so the account can always be found, then the index entry
+// of the address the user moved away from is removed.
+func (resolver *userEmailResolver) changeEmail(user *config.UserStruct, email string) error {
+	oldEmail := user.GetEmail()
+	newID := resolver.idGenerator.NewStringID(email)
+	if newID != user.ID {
+		if err := resolver.userEmailDAO.Save(&entity.UserEmail{
+			ID:     newID,
+			Email:  email,
+			UserID: user.ID}); err != nil {
+			return err
+		}
+	}
+	user.SetEmail(email)
+	if err := resolver.userDAO.Save(user); err != nil {
+		user.SetEmail(oldEmail)
+		return err
+	}
+	oldID := resolver.idGenerator.NewStringID(oldEmail)
+	if oldID == user.ID {
+		return nil
+	}
+	err := resolver.userEmailDAO.Delete(&entity.UserEmail{ID: oldID})
+	if err != nil && err.Error() != datastore.ErrRecordNotFound.Error() {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/mapper"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUserRegistry struct {
+	refreshTokenService RefreshTokenService
+	apiKeyService       APIKeyService
+	ServiceRegistry
+}
+
+func (registry *fakeUserRegistry) GetAPIKeyService() APIKeyService {
+	return registry.apiKeyService
+}
+
+func (registry *fakeUserRegistry) GetRefreshTokenService() RefreshTokenService {
+	return registry.refreshTokenService
+}
+
+func (registry *fakeUserRegistry) GetAuditService() AuditService {
+	return nil
+}
+
+func (registry *fakeUserRegistry) GetLicenseService() LicenseService {
+	return nil
+}
+
+type fakeUserPermissionDAO struct {
+	organizations []*config.OrganizationStruct
+	updated       []*config.PermissionStruct
+	deleted       []*config.PermissionStruct
+	dao.PermissionDAO
+}
+
+func (permissionDAO *fakeUserPermissionDAO) Update(permission *config.PermissionStruct) error {
+	permissionDAO.updated = append(permissionDAO.updated, permission)
+	return nil
+}
+
+func (permissionDAO *fakeUserPermissionDAO) Delete(permission *config.PermissionStruct) error {
+	permissionDAO.deleted = append(permissionDAO.deleted, permission)
+	return nil
+}
+
+func (permissionDAO *fakeUserPermissionDAO) GetOrganizations(userID uint64, CONSISTENCY_LEVEL int) ([]*config.OrganizationStruct, error) {
+	return permissionDAO.organizations, nil
+}
+
+func TestUserRefreshOrganizationMember(t *testing.T) {
+	user := &config.UserStruct{ID: 7, Email: "grower@example.com", Password: "secret"}
+	userDAO := &fakeOIDCUserDAO{users: map[uint64]*config.UserStruct{user.ID: user}}
+	org := &config.OrganizationStruct{ID: 10, Name: "Acme"}
+	org.SetUsers([]*config.UserStruct{
+		{ID: 5, Email: "admin@example.com"},
+		{ID: 7, Email: "grower@example.com", Password: "secret"}})
+	userService := NewUserService(&app.App{Logger: logging.MustGetLogger("user_test")},
+		userDAO, nil, nil, &fakeUserPermissionDAO{organizations: []*config.OrganizationStruct{org}},
+		&fakeAPIKeyFarmDAO{}, mapper.NewUserMapper(), nil, &fakeUserRegistry{})
+
+	refreshed, orgs, farms, err := userService.Refresh(user.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, refreshed.Identifier())
+	assert.Equal(t, "grower@example.com", refreshed.GetEmail())
+	assert.NotEqual(t, "secret", refreshed.GetPassword())
+	assert.Equal(t, 1, len(orgs))
+	assert.Empty(t, farms)
+
+	// Disabled accounts can't refresh
+	user.SetDisabled(true)
+	_, _, _, err = userService.Refresh(user.ID)
+	assert.Equal(t, ErrAccountDisabled, err)
+}
+
+func TestUserDisableEnable(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	refreshTokenService, refreshTokenDAO := newTestRefreshTokenService(&now)
+	user := &config.UserStruct{ID: 7, Email: "grower@example.com", FailedLogins: 4, LockedUntil: now.Add(time.Minute)}
+	outsider := &config.UserStruct{ID: 8, Email: "outsider@example.com"}
+	userDAO := &fakeOIDCUserDAO{users: map[uint64]*config.UserStruct{user.ID: user, outsider.ID: outsider}}
+	farm := config.NewFarm()
+	farm.ID = 1
+	farm.SetUsers([]*config.UserStruct{{ID: 5}, user})
+	farmDAO := &fakeFarmDAO{farms: map[uint64]*config.FarmStruct{1: farm}}
+	apiKeyDAO := &fakeAPIKeyDAO{apiKeys: make(map[uint64]*entity.APIKey)}
+	apiKeyService := NewAPIKeyService(logging.MustGetLogger("user_test"),
+		apiKeyDAO, userDAO, farmDAO, nil)
+	userService := NewUserService(&app.App{Logger: logging.MustGetLogger("user_test")},
+		userDAO, nil, nil, nil, farmDAO,
+		mapper.NewUserMapper(), nil, &fakeUserRegistry{
+			refreshTokenService: refreshTokenService, apiKeyService: apiKeyService})
+
+	_, refreshToken, err := refreshTokenService.Issue(user.ID, "127.0.0.1")
+	assert.Nil(t, err)
+	apiKey, _, err := apiKeyService.Create(apiKeyTestSession(user.ID, common.PERMISSION_FARM_READ),
+		APIKeyRequest{ServiceAccount: "grafana", Permissions: []string{common.PERMISSION_FARM_READ}})
+	assert.Nil(t, err)
+
+	admin := apiKeyTestSession(5, common.PERMISSION_USER_MANAGE)
+	assert.Equal(t, ErrPermissionDenied, userService.Disable(apiKeyTestSession(5), user.ID))
+	assert.Equal(t, ErrDisableOwnAccount, userService.Disable(admin, 5))
+	assert.Equal(t, ErrDisableAdminAccount, userService.Disable(admin, common.DEFAULT_USER_ID_64))
+
+	// Users outside of the admin's farms can't be disabled or enabled
+	assert.Equal(t, ErrTenantAccessDenied, userService.Disable(admin, outsider.ID))
+	assert.Equal(t, ErrTenantAccessDenied, userService.Enable(admin, outsider.ID))
+	assert.Equal(t, ErrTenantAccessDenied, userService.VerifyTenant(admin, outsider.ID))
+	assert.Nil(t, userService.VerifyTenant(admin, user.ID))
+	assert.False(t, outsider.IsDisabled())
+
+	assert.Nil(t, userService.Disable(admin, user.ID))
+	assert.True(t, user.IsDisabled())
+	assert.True(t, refreshTokenDAO.tokens[refreshToken.ID].IsRevoked())
+	assert.True(t, apiKeyDAO.apiKeys[apiKey.ID].IsRevoked())
+
+	assert.Equal(t, ErrPermissionDenied, userService.Enable(apiKeyTestSession(5), user.ID))
+	assert.Nil(t, userService.Enable(admin, user.ID))
+	assert.False(t, user.IsDisabled())
+	assert.Equal(t, 0, user.FailedLogins)
+	assert.False(t, user.IsLocked(now))
+}
+
+func TestUserPermissionRequiresUserManage(t *testing.T) {
+	permissionDAO := &fakeUserPermissionDAO{}
+	userService := NewUserService(&app.App{Logger: logging.MustGetLogger("user_test")},
+		nil, nil, nil, permissionDAO, nil, mapper.NewUserMapper(), nil, &fakeUserRegistry{})
+	permission := &config.PermissionStruct{FarmID: 1, UserID: 7, RoleID: 2}
+
+	// The admin role name doesn't grant anything the role's permissions don't
+	admin := CreateSession(logging.MustGetLogger("user_test"), nil,
+		[]FarmClaim{{ID: 1}}, nil, 0, 1, common.CONSISTENCY_LOCAL,
+		&model.UserStruct{ID: 5, Roles: []model.Role{&model.RoleStruct{
+			Name:        common.ROLE_ADMIN,
+			Permissions: []string{common.PERMISSION_FARM_READ}}}})
+	assert.Equal(t, ErrPermissionDenied, userService.SetPermission(admin, permission))
+	assert.Equal(t, ErrPermissionDenied, userService.DeletePermission(admin, 7))
+	assert.Empty(t, permissionDAO.updated)
+	assert.Empty(t, permissionDAO.deleted)
+
+	manager := apiKeyTestSession(5, common.PERMISSION_USER_MANAGE)
+	assert.Nil(t, userService.SetPermission(manager, permission))
+	assert.Nil(t, userService.DeletePermission(manager, 7))
+	assert.Equal(t, []*config.PermissionStruct{permission}, permissionDAO.updated)
+	assert.Equal(t, 1, len(permissionDAO.deleted))
+}
@@ -118,6 +118,10 @@ func (service *DefaultWorkflowService) GetListView(session Session, farmID uint6
 func (service *DefaultWorkflowService) Update(session Session, workflow config.Workflow) error {
 	farmService := session.GetFarmService()
 	farmConfig := farmService.GetConfig()
+	if _, err := service.GetWorkflow(session, workflow.Identifier()); err != nil {
+		return err
+	}
+	workflow.SetFarmID(farmConfig.Identifier())
 	farmConfig.SetWorkflow(workflow.(*config.WorkflowStruct))
 	err := farmService.SetConfig(farmConfig)
 	if err != nil {
@@ -129,15 +133,19 @@ func (service *DefaultWorkflowService) Update(session Session, workflow config.W
 // Delete a workflow entry from the FarmConfig and datastore and publish
 // the new FarmConfig to connected clients.
 func (service *DefaultWorkflowService) Delete(session Session, workflow config.Workflow) error {
-	// GORM Save does not delete associations, delete steps
-	// directly via the DAO instead :(
-	if err := service.dao.Delete(workflow.(*config.WorkflowStruct)); err != nil {
-		return err
-	}
 	farmService := session.GetFarmService()
 	farmConfig := farmService.GetConfig()
-	farmConfig.RemoveWorkflow(workflow.(*config.WorkflowStruct))
-	err := farmService.SetConfig(farmConfig)
+	persisted, err := service.GetWorkflow(session, workflow.Identifier())
+	if err != nil {
+		return err
+	}
+	// GORM Save does not delete associations, delete the farm's
+	// own workflow and its steps directly via the DAO instead :(
+	if err := service.dao.Delete(persisted.(*config.WorkflowStruct)); err != nil {
+		return err
+	}
+	farmConfig.RemoveWorkflow(persisted.(*config.WorkflowStruct))
+	err = farmService.SetConfig(farmConfig)
 	if err != nil {
 		service.app.Logger.Errorf("sesion: %+v, error: %s", session, err)
 	}
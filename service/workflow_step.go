@@ -84,8 +84,13 @@ func (service *DefaultWorkflowStepService) Update(session Session, step config.W
 	farmConfig := farmService.GetConfig()
 	for _, workflow := range farmConfig.GetWorkflows() {
 		if workflow.ID == step.GetWorkflowID() {
-			workflow.SetStep(step.(*config.WorkflowStepStruct))
-			return farmService.SetConfig(farmConfig)
+			for _, _step := range workflow.GetSteps() {
+				if _step.ID == step.Identifier() {
+					workflow.SetStep(step.(*config.WorkflowStepStruct))
+					return farmService.SetConfig(farmConfig)
+				}
+			}
+			return ErrWorkflowStepNotFound
 		}
 	}
 	return ErrWorkflowNotFound
@@ -96,16 +101,22 @@ func (service *DefaultWorkflowStepService) Update(session Session, step config.W
 func (service *DefaultWorkflowStepService) Delete(session Session, step config.WorkflowStep) error {
 	// farmService.SetConfig doesnt delete the workflow :(
 	farmID := session.GetRequestedFarmID()
-	if err := service.dao.Delete(farmID, step.(*config.WorkflowStepStruct)); err != nil {
-		return err
-	}
 	farmService := session.GetFarmService()
 	farmConfig := farmService.GetConfig()
 	for _, workflow := range farmConfig.GetWorkflows() {
 		if workflow.ID == step.GetWorkflowID() {
-			workflow.RemoveStep(step.(*config.WorkflowStepStruct))
-			farmConfig.SetWorkflow(workflow)
-			return farmService.SetConfig(farmConfig)
+			for _, _step := range workflow.GetSteps() {
+				if _step.ID == step.Identifier() {
+					// Delete the farm's own step, not the one sent by the client
+					if err := service.dao.Delete(farmID, _step); err != nil {
+						return err
+					}
+					workflow.RemoveStep(_step)
+					farmConfig.SetWorkflow(workflow)
+					return farmService.SetConfig(farmConfig)
+				}
+			}
+			return ErrWorkflowStepNotFound
 		}
 	}
 	return ErrWorkflowNotFound
@@ -20,3 +20,9 @@ func (mailer *MockMailer) Send(subject, message string) error {
 	mailer.session.GetLogger().Debugf("MockMailer: subject=%s, message=%s", subject, message)
 	return nil
 }
+
+func (mailer *MockMailer) SendTo(recipient, subject, message string) error {
+	mailer.session.GetLogger().Debugf("MockMailer: recipient=%s, subject=%s, message=%s",
+		recipient, subject, message)
+	return nil
+}
@@ -0,0 +1,616 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrEmptyExpression     = errors.New("empty expression")
+	ErrUnexpectedToken     = errors.New("unexpected token")
+	ErrUnknownFunction     = errors.New("unknown function")
+	ErrUndefinedVariable   = errors.New("undefined variable")
+	ErrInvalidArgumentSize = errors.New("invalid number of function arguments")
+	ErrInvalidRange        = errors.New("invalid variable range")
+	ErrRangeExceedsDevice  = errors.New("variable range exceeds the device channel count")
+)
+
+// Expression is a parsed arithmetic expression that can be evaluated against a
+// set of named variables. Variables are metric keys, optionally qualified with a
+// device type (reservoir.resTemp). A numbered range of variables (tempF0..tempF2)
+// may be passed to functions that accept a variable number of arguments. Range
+// indexes are limited to the channel count of the device the expression belongs to.
+//
+// Supported operators: + - * / % ^ < <= > >= == != and parentheses
+// Supported functions: abs, avg, ceil, dewpoint, exp, floor, ftoc, ctof, heatindex,
+// if, ln, max, min, pow, round, sqrt, sum, vpd
+type Expression struct {
+	source string
+	root   expressionNode
+}
+
+type expressionNode interface {
+	evaluate(variables map[string]float64) ([]float64, error)
+}
+
+type numberNode struct {
+	value float64
+}
+
+type variableNode struct {
+	name string
+}
+
+type rangeNode struct {
+	names []string
+}
+
+type unaryNode struct {
+	operator string
+	operand  expressionNode
+}
+
+type binaryNode struct {
+	operator string
+	left     expressionNode
+	right    expressionNode
+}
+
+type functionNode struct {
+	name      string
+	arguments []expressionNode
+}
+
+type expressionToken struct {
+	kind  string
+	value string
+}
+
+const (
+	tokenNumber     = "number"
+	tokenIdentifier = "identifier"
+	tokenOperator   = "operator"
+	tokenRange      = ".."
+	tokenEOF        = "eof"
+)
+
+// Parses an expression string into an evaluatable Expression. Variable ranges
+// may only address indexes 0 through channels-1.
+func ParseExpression(source string, channels int) (*Expression, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, ErrEmptyExpression
+	}
+	parser := &expressionParser{tokens: tokens, channels: channels}
+	root, err := parser.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	if parser.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedToken, parser.peek().value)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// Returns the original expression string
+func (expression *Expression) String() string {
+	return expression.source
+}
+
+// Evaluates the expression using the provided variables
+func (expression *Expression) Evaluate(variables map[string]float64) (float64, error) {
+	values, err := expression.root.evaluate(variables)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) != 1 {
+		return 0, ErrInvalidRange
+	}
+	return values[0], nil
+}
+
+// Returns the unique variable names referenced by the expression
+func (expression *Expression) Variables() []string {
+	seen := make(map[string]bool, 0)
+	names := make([]string, 0)
+	var walk func(node expressionNode)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	walk = func(node expressionNode) {
+		switch n := node.(type) {
+		case *variableNode:
+			add(n.name)
+		case *rangeNode:
+			for _, name := range n.names {
+				add(name)
+			}
+		case *unaryNode:
+			walk(n.operand)
+		case *binaryNode:
+			walk(n.left)
+			walk(n.right)
+		case *functionNode:
+			for _, arg := range n.arguments {
+				walk(arg)
+			}
+		}
+	}
+	walk(expression.root)
+	return names
+}
+
+func tokenizeExpression(source string) ([]expressionToken, error) {
+	tokens := make([]expressionToken, 0)
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				if runes[i] == '.' && i+1 < len(runes) && runes[i+1] == '.' {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, expressionToken{kind: tokenNumber, value: string(runes[start:i])})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) ||
+				runes[i] == '_' || runes[i] == '.') {
+				// Stop at a range operator
+				if runes[i] == '.' && i+1 < len(runes) && runes[i+1] == '.' {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, expressionToken{kind: tokenIdentifier, value: string(runes[start:i])})
+		case r == '.' && i+1 < len(runes) && runes[i+1] == '.':
+			tokens = append(tokens, expressionToken{kind: tokenRange, value: tokenRange})
+			i += 2
+		case strings.ContainsRune("<>=!", r) && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, expressionToken{kind: tokenOperator, value: string(runes[i : i+2])})
+			i += 2
+		case strings.ContainsRune("+-*/%^()<>,", r):
+			tokens = append(tokens, expressionToken{kind: tokenOperator, value: string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnexpectedToken, string(r))
+		}
+	}
+	return append(tokens, expressionToken{kind: tokenEOF}), nil
+}
+
+type expressionParser struct {
+	tokens   []expressionToken
+	position int
+	channels int
+}
+
+func (parser *expressionParser) peek() expressionToken {
+	return parser.tokens[parser.position]
+}
+
+func (parser *expressionParser) next() expressionToken {
+	token := parser.tokens[parser.position]
+	if token.kind != tokenEOF {
+		parser.position++
+	}
+	return token
+}
+
+func (parser *expressionParser) accept(operators ...string) (string, bool) {
+	token := parser.peek()
+	if token.kind != tokenOperator {
+		return "", false
+	}
+	for _, operator := range operators {
+		if token.value == operator {
+			parser.position++
+			return operator, true
+		}
+	}
+	return "", false
+}
+
+func (parser *expressionParser) parseComparison() (expressionNode, error) {
+	left, err := parser.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := parser.accept("<", "<=", ">", ">=", "==", "!=")
+		if !ok {
+			return left, nil
+		}
+		right, err := parser.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (parser *expressionParser) parseAdditive() (expressionNode, error) {
+	left, err := parser.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := parser.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := parser.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (parser *expressionParser) parseMultiplicative() (expressionNode, error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := parser.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (parser *expressionParser) parseUnary() (expressionNode, error) {
+	if operator, ok := parser.accept("-", "+"); ok {
+		operand, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{operator: operator, operand: operand}, nil
+	}
+	return parser.parsePower()
+}
+
+// Exponents are right associative: 2^3^2 = 2^(3^2)
+func (parser *expressionParser) parsePower() (expressionNode, error) {
+	base, err := parser.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := parser.accept("^"); ok {
+		exponent, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{operator: "^", left: base, right: exponent}, nil
+	}
+	return base, nil
+}
+
+func (parser *expressionParser) parsePrimary() (expressionNode, error) {
+	token := parser.next()
+	switch token.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &numberNode{value: value}, nil
+	case tokenIdentifier:
+		if _, ok := parser.accept("("); ok {
+			return parser.parseFunction(strings.ToLower(token.value))
+		}
+		if parser.peek().kind == tokenRange {
+			parser.next()
+			end := parser.next()
+			if end.kind != tokenIdentifier {
+				return nil, fmt.Errorf("%w: %s", ErrUnexpectedToken, end.value)
+			}
+			names, err := expandVariableRange(token.value, end.value, parser.channels)
+			if err != nil {
+				return nil, err
+			}
+			return &rangeNode{names: names}, nil
+		}
+		return &variableNode{name: token.value}, nil
+	case tokenOperator:
+		if token.value == "(" {
+			node, err := parser.parseComparison()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := parser.accept(")"); !ok {
+				return nil, fmt.Errorf("%w: expected )", ErrUnexpectedToken)
+			}
+			return node, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("%w: unexpected end of expression", ErrUnexpectedToken)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnexpectedToken, token.value)
+}
+
+func (parser *expressionParser) parseFunction(name string) (expressionNode, error) {
+	if _, ok := expressionFunctions[name]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFunction, name)
+	}
+	arguments := make([]expressionNode, 0)
+	if _, ok := parser.accept(")"); ok {
+		return &functionNode{name: name, arguments: arguments}, nil
+	}
+	for {
+		argument, err := parser.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+		if _, ok := parser.accept(","); ok {
+			continue
+		}
+		if _, ok := parser.accept(")"); ok {
+			return &functionNode{name: name, arguments: arguments}, nil
+		}
+		return nil, fmt.Errorf("%w: expected , or )", ErrUnexpectedToken)
+	}
+}
+
+// Expands a numbered variable range such as tempF0..tempF2 into
+// tempF0, tempF1, tempF2. Ranges that extend past the last channel
+// index are rejected.
+func expandVariableRange(start, end string, channels int) ([]string, error) {
+	startPrefix, startIndex, err := splitNumericSuffix(start)
+	if err != nil {
+		return nil, err
+	}
+	endPrefix, endIndex, err := splitNumericSuffix(end)
+	if err != nil {
+		return nil, err
+	}
+	if startPrefix != endPrefix || endIndex < startIndex {
+		return nil, fmt.Errorf("%w: %s..%s", ErrInvalidRange, start, end)
+	}
+	if endIndex >= channels {
+		return nil, fmt.Errorf("%w: %s..%s, channels=%d", ErrRangeExceedsDevice, start, end, channels)
+	}
+	names := make([]string, 0, endIndex-startIndex+1)
+	for i := startIndex; i <= endIndex; i++ {
+		names = append(names, fmt.Sprintf("%s%d", startPrefix, i))
+	}
+	return names, nil
+}
+
+func splitNumericSuffix(name string) (string, int, error) {
+	i := len(name)
+	for i > 0 && unicode.IsDigit(rune(name[i-1])) {
+		i--
+	}
+	if i == len(name) {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidRange, name)
+	}
+	index, err := strconv.Atoi(name[i:])
+	if err != nil {
+		return "", 0, err
+	}
+	return name[:i], index, nil
+}
+
+func (node *numberNode) evaluate(variables map[string]float64) ([]float64, error) {
+	return []float64{node.value}, nil
+}
+
+func (node *variableNode) evaluate(variables map[string]float64) ([]float64, error) {
+	value, ok := variables[node.name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUndefinedVariable, node.name)
+	}
+	return []float64{value}, nil
+}
+
+func (node *rangeNode) evaluate(variables map[string]float64) ([]float64, error) {
+	values := make([]float64, len(node.names))
+	for i, name := range node.names {
+		value, ok := variables[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUndefinedVariable, name)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (node *unaryNode) evaluate(variables map[string]float64) ([]float64, error) {
+	value, err := evaluateScalar(node.operand, variables)
+	if err != nil {
+		return nil, err
+	}
+	if node.operator == "-" {
+		value = -value
+	}
+	return []float64{value}, nil
+}
+
+func (node *binaryNode) evaluate(variables map[string]float64) ([]float64, error) {
+	left, err := evaluateScalar(node.left, variables)
+	if err != nil {
+		return nil, err
+	}
+	right, err := evaluateScalar(node.right, variables)
+	if err != nil {
+		return nil, err
+	}
+	var result float64
+	switch node.operator {
+	case "+":
+		result = left + right
+	case "-":
+		result = left - right
+	case "*":
+		result = left * right
+	case "/":
+		result = left / right
+	case "%":
+		result = math.Mod(left, right)
+	case "^":
+		result = math.Pow(left, right)
+	case "<":
+		result = boolToFloat(left < right)
+	case "<=":
+		result = boolToFloat(left <= right)
+	case ">":
+		result = boolToFloat(left > right)
+	case ">=":
+		result = boolToFloat(left >= right)
+	case "==":
+		result = boolToFloat(left == right)
+	case "!=":
+		result = boolToFloat(left != right)
+	}
+	return []float64{result}, nil
+}
+
+func (node *functionNode) evaluate(variables map[string]float64) ([]float64, error) {
+	arguments := make([]float64, 0, len(node.arguments))
+	for _, argument := range node.arguments {
+		values, err := argument.evaluate(variables)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, values...)
+	}
+	function := expressionFunctions[node.name]
+	if function.arity > -1 && len(arguments) != function.arity {
+		return nil, fmt.Errorf("%w: %s expects %d, got %d",
+			ErrInvalidArgumentSize, node.name, function.arity, len(arguments))
+	}
+	if function.arity == -1 && len(arguments) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArgumentSize, node.name)
+	}
+	return []float64{function.call(arguments)}, nil
+}
+
+func evaluateScalar(node expressionNode, variables map[string]float64) (float64, error) {
+	values, err := node.evaluate(variables)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) != 1 {
+		return 0, ErrInvalidRange
+	}
+	return values[0], nil
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type expressionFunction struct {
+	arity int // -1 = variadic
+	call  func(args []float64) float64
+}
+
+var expressionFunctions = map[string]expressionFunction{
+	"abs":   {1, func(args []float64) float64 { return math.Abs(args[0]) }},
+	"ceil":  {1, func(args []float64) float64 { return math.Ceil(args[0]) }},
+	"floor": {1, func(args []float64) float64 { return math.Floor(args[0]) }},
+	"round": {1, func(args []float64) float64 { return math.Round(args[0]) }},
+	"sqrt":  {1, func(args []float64) float64 { return math.Sqrt(args[0]) }},
+	"exp":   {1, func(args []float64) float64 { return math.Exp(args[0]) }},
+	"ln":    {1, func(args []float64) float64 { return math.Log(args[0]) }},
+	"pow":   {2, func(args []float64) float64 { return math.Pow(args[0], args[1]) }},
+	"ftoc":  {1, func(args []float64) float64 { return (args[0] - 32) * 5 / 9 }},
+	"ctof":  {1, func(args []float64) float64 { return args[0]*9/5 + 32 }},
+	"if": {3, func(args []float64) float64 {
+		if args[0] != 0 {
+			return args[1]
+		}
+		return args[2]
+	}},
+	"min": {-1, func(args []float64) float64 {
+		min := args[0]
+		for _, arg := range args[1:] {
+			min = math.Min(min, arg)
+		}
+		return min
+	}},
+	"max": {-1, func(args []float64) float64 {
+		max := args[0]
+		for _, arg := range args[1:] {
+			max = math.Max(max, arg)
+		}
+		return max
+	}},
+	"sum": {-1, func(args []float64) float64 {
+		sum := 0.0
+		for _, arg := range args {
+			sum += arg
+		}
+		return sum
+	}},
+	"avg": {-1, func(args []float64) float64 {
+		sum := 0.0
+		for _, arg := range args {
+			sum += arg
+		}
+		return sum / float64(len(args))
+	}},
+	"vpd": {2, func(args []float64) float64 {
+		return VaporPressureDeficit(args[0], args[1])
+	}},
+	"dewpoint": {2, func(args []float64) float64 {
+		return DewPoint(args[0], args[1])
+	}},
+	"heatindex": {2, func(args []float64) float64 {
+		return HeatIndex(args[0], args[1])
+	}},
+}
+
+// Returns the vapor pressure deficit (kPa) for the given air
+// temperature (Celsius) and relative humidity (percent)
+func VaporPressureDeficit(tempC, humidity float64) float64 {
+	svp := 0.61078 * math.Exp((17.27*tempC)/(tempC+237.3))
+	return svp * (1 - humidity/100)
+}
+
+// Returns the dew point (Celsius) for the given air temperature (Celsius)
+// and relative humidity (percent) using the Magnus formula
+func DewPoint(tempC, humidity float64) float64 {
+	a, b := 17.27, 237.7
+	alpha := ((a * tempC) / (b + tempC)) + math.Log(humidity/100)
+	return (b * alpha) / (a - alpha)
+}
+
+// Returns the heat index (Fahrenheit) for the given air temperature (Fahrenheit)
+// and relative humidity (percent) using the NWS Rothfusz regression
+func HeatIndex(tempF, humidity float64) float64 {
+	simple := 0.5 * (tempF + 61 + ((tempF - 68) * 1.2) + (humidity * 0.094))
+	if (simple+tempF)/2 < 80 {
+		return simple
+	}
+	return -42.379 + 2.04901523*tempF + 10.14333127*humidity -
+		0.22475541*tempF*humidity - 0.00683783*tempF*tempF -
+		0.05481717*humidity*humidity + 0.00122874*tempF*tempF*humidity +
+		0.00085282*tempF*humidity*humidity - 0.00000199*tempF*tempF*humidity*humidity
+}
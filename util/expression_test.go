@@ -0,0 +1,96 @@
+package util
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpressionArithmetic(t *testing.T) {
+	variables := map[string]float64{"a": 2, "b": 3}
+	tests := map[string]float64{
+		"1 + 2 * 3":        7,
+		"(1 + 2) * 3":      9,
+		"2 ^ 3 ^ 2":        512,
+		"-a + b":           1,
+		"a * b / 2 - 1":    2,
+		"abs(-4.5)":        4.5,
+		"round(2.6)":       3,
+		"max(a, b, 10)":    10,
+		"b > a":            1,
+		"if(a >= b, 1, 0)": 0}
+	for source, expected := range tests {
+		expression, err := ParseExpression(source, 4)
+		assert.Nil(t, err, source)
+		value, err := expression.Evaluate(variables)
+		assert.Nil(t, err, source)
+		assert.InDelta(t, expected, value, 0.0001, source)
+	}
+}
+
+func TestExpressionRange(t *testing.T) {
+	expression, err := ParseExpression("avg(tempF0..tempF2)", 4)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tempF0", "tempF1", "tempF2"}, expression.Variables())
+
+	value, err := expression.Evaluate(map[string]float64{
+		"tempF0": 70, "tempF1": 72, "tempF2": 74})
+	assert.Nil(t, err)
+	assert.Equal(t, 72.0, value)
+}
+
+func TestExpressionRangeExceedsChannels(t *testing.T) {
+	_, err := ParseExpression("avg(tempF0..tempF3)", 4)
+	assert.Nil(t, err)
+
+	_, err = ParseExpression("avg(tempF0..tempF4)", 4)
+	assert.True(t, errors.Is(err, ErrRangeExceedsDevice))
+
+	_, err = ParseExpression("sum(tempF0..tempF4294967295)", 4)
+	assert.True(t, errors.Is(err, ErrRangeExceedsDevice))
+
+	_, err = ParseExpression("avg(tempF0..tempF1)", 0)
+	assert.True(t, errors.Is(err, ErrRangeExceedsDevice))
+}
+
+func TestExpressionQualifiedVariables(t *testing.T) {
+	expression, err := ParseExpression("vpd(ftoc(room.tempF0), room.humidity0)", 4)
+	assert.Nil(t, err)
+
+	value, err := expression.Evaluate(map[string]float64{
+		"room.tempF0": 77, "room.humidity0": 60})
+	assert.Nil(t, err)
+	assert.InDelta(t, 1.267, value, 0.001)
+}
+
+func TestExpressionPsychrometrics(t *testing.T) {
+	assert.InDelta(t, 1.267, VaporPressureDeficit(25, 60), 0.001)
+	assert.InDelta(t, 16.69, DewPoint(25, 60), 0.01)
+	assert.InDelta(t, 91.0, HeatIndex(88, 50), 0.5)
+}
+
+func TestExpressionErrors(t *testing.T) {
+	_, err := ParseExpression("", 4)
+	assert.True(t, errors.Is(err, ErrEmptyExpression))
+
+	_, err = ParseExpression("1 + * 2", 4)
+	assert.True(t, errors.Is(err, ErrUnexpectedToken))
+
+	_, err = ParseExpression("foo(1)", 4)
+	assert.True(t, errors.Is(err, ErrUnknownFunction))
+
+	// Ranges expand to multiple arguments so arity is checked during evaluation
+	expression, err := ParseExpression("pow(1)", 4)
+	assert.Nil(t, err)
+	_, err = expression.Evaluate(map[string]float64{})
+	assert.True(t, errors.Is(err, ErrInvalidArgumentSize))
+
+	_, err = ParseExpression("tempF..humidity0", 4)
+	assert.True(t, errors.Is(err, ErrInvalidRange))
+
+	expression, err = ParseExpression("tempF0 * 2", 4)
+	assert.Nil(t, err)
+	_, err = expression.Evaluate(map[string]float64{})
+	assert.True(t, errors.Is(err, ErrUndefinedVariable))
+}
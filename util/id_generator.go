@@ -35,6 +35,17 @@ type IdGenerator interface {
 	NewEventLogID(eventLog entity.EventLog) uint64
 
 	CreateEventLogClusterID(clusterID uint64) uint64
+	CreateEventLogArchiveClusterID(clusterID uint64) uint64
+	CreateAlarmClusterID(clusterID uint64) uint64
+	CreateInboxClusterID(clusterID uint64) uint64
+	CreateAuditClusterID(clusterID uint64) uint64
+	CreateAPIKeyClusterID(clusterID uint64) uint64
+	CreateRefreshTokenClusterID(clusterID uint64) uint64
+	CreateTOTPClusterID(clusterID uint64) uint64
+	CreateInvitationClusterID(clusterID uint64) uint64
+	CreateLicenseClusterID(clusterID uint64) uint64
+	CreateOIDCStateClusterID(clusterID uint64) uint64
+	CreateUserEmailClusterID(clusterID uint64) uint64
 	CreateDeviceDataClusterID(deviceID uint64) uint64
 }
 
@@ -175,6 +186,50 @@ func (hasher *Fnv1aHasher) CreateEventLogClusterID(clusterID uint64) uint64 {
 	return eventLogClusterID
 }
 
+func (hasher *Fnv1aHasher) CreateEventLogArchiveClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "eventlogarchive"))
+}
+
+func (hasher *Fnv1aHasher) CreateAlarmClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "alarm"))
+}
+
+func (hasher *Fnv1aHasher) CreateInboxClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "inbox"))
+}
+
+func (hasher *Fnv1aHasher) CreateAuditClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "audit"))
+}
+
+func (hasher *Fnv1aHasher) CreateAPIKeyClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "apikey"))
+}
+
+func (hasher *Fnv1aHasher) CreateRefreshTokenClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "refreshtoken"))
+}
+
+func (hasher *Fnv1aHasher) CreateTOTPClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "totp"))
+}
+
+func (hasher *Fnv1aHasher) CreateInvitationClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "invitation"))
+}
+
+func (hasher *Fnv1aHasher) CreateLicenseClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "license"))
+}
+
+func (hasher *Fnv1aHasher) CreateOIDCStateClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "oidcstate"))
+}
+
+func (hasher *Fnv1aHasher) CreateUserEmailClusterID(clusterID uint64) uint64 {
+	return hasher.NewStringID(fmt.Sprintf("%d-%s", clusterID, "useremail"))
+}
+
 func (hasher *Fnv1aHasher) CreateDeviceDataClusterID(deviceID uint64) uint64 {
 	deviceDataClusterID := hasher.NewStringID(fmt.Sprintf("%d-%s", deviceID, "devicedata"))
 	fmt.Println(fmt.Sprintf("Creating device data cluster ID for deviceID:%d, deviceDataClusterID=%d",
@@ -0,0 +1,76 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// PasswordPolicy describes the strength rules passwords must satisfy. A
+// MaxLength of zero doesn't limit the length of the password.
+type PasswordPolicy struct {
+	MinLength     int  `yaml:"min-length" json:"min_length" mapstructure:"min-length"`
+	MaxLength     int  `yaml:"max-length" json:"max_length" mapstructure:"max-length"`
+	RequireUpper  bool `yaml:"require-upper" json:"require_upper" mapstructure:"require-upper"`
+	RequireLower  bool `yaml:"require-lower" json:"require_lower" mapstructure:"require-lower"`
+	RequireDigit  bool `yaml:"require-digit" json:"require_digit" mapstructure:"require-digit"`
+	RequireSymbol bool `yaml:"require-symbol" json:"require_symbol" mapstructure:"require-symbol"`
+}
+
+// Returns the default password policy, which only requires
+// passwords to be at least 8 characters long
+func NewPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength: 8,
+		MaxLength: 128}
+}
+
+// Validate returns an ErrWeakPassword describing every rule the password
+// doesn't satisfy, or nil if the password satisfies the policy. A nil
+// policy validates against the default policy.
+func (policy *PasswordPolicy) Validate(password string) error {
+	if policy == nil {
+		policy = NewPasswordPolicy()
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	length := utf8.RuneCountInString(password)
+	unmet := make([]string, 0)
+	if length < policy.MinLength {
+		unmet = append(unmet, fmt.Sprintf("at least %d characters", policy.MinLength))
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		unmet = append(unmet, fmt.Sprintf("at most %d characters", policy.MaxLength))
+	}
+	if policy.RequireUpper && !upper {
+		unmet = append(unmet, "an uppercase letter")
+	}
+	if policy.RequireLower && !lower {
+		unmet = append(unmet, "a lowercase letter")
+	}
+	if policy.RequireDigit && !digit {
+		unmet = append(unmet, "a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		unmet = append(unmet, "a symbol")
+	}
+	if len(unmet) > 0 {
+		return fmt.Errorf("%w: password must contain %s", ErrWeakPassword, strings.Join(unmet, ", "))
+	}
+	return nil
+}
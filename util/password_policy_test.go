@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	var defaultPolicy *PasswordPolicy
+	assert.ErrorIs(t, defaultPolicy.Validate("short"), ErrWeakPassword)
+	assert.Nil(t, defaultPolicy.Validate("longenough"))
+
+	policy := &PasswordPolicy{
+		MinLength:     10,
+		MaxLength:     16,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true}
+
+	err := policy.Validate("password")
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	assert.Equal(t, "password does not meet the password policy: password must contain "+
+		"at least 10 characters, an uppercase letter, a digit, a symbol", err.Error())
+
+	assert.ErrorIs(t, policy.Validate("Password1234567!!"), ErrWeakPassword)
+	assert.ErrorIs(t, policy.Validate("PASSWORD123!"), ErrWeakPassword)
+	assert.Nil(t, policy.Validate("Corr3ct-Horse"))
+	assert.Nil(t, policy.Validate("Grüße-Bäume-42"))
+}
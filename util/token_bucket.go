@@ -2,14 +2,18 @@ package util
 
 import (
 	"math"
+	"sync"
 	"time"
 )
 
+// TokenBucket is a token bucket rate limiter that is safe for concurrent use
 type TokenBucket struct {
+	mutex          sync.Mutex
 	tokens         float64
 	maxTokens      float64
 	refillRate     float64
 	lastRefillTime time.Time
+	clock          func() time.Time
 }
 
 func NewTokenBucket() *TokenBucket {
@@ -17,27 +21,60 @@ func NewTokenBucket() *TokenBucket {
 }
 
 func CreateTokenBucket(maxTokens, refillRate float64) *TokenBucket {
+	return CreateTokenBucketWithClock(maxTokens, refillRate, time.Now)
+}
+
+// Creates a new token bucket that uses the provided clock to
+// calculate refills
+func CreateTokenBucketWithClock(maxTokens, refillRate float64, clock func() time.Time) *TokenBucket {
 	return &TokenBucket{
 		tokens:         maxTokens,
 		maxTokens:      maxTokens,
 		refillRate:     refillRate,
-		lastRefillTime: time.Now(),
+		lastRefillTime: clock(),
+		clock:          clock,
 	}
 }
 
 func (tb *TokenBucket) refill() {
-	now := time.Now()
+	now := tb.clock()
 	duration := now.Sub(tb.lastRefillTime)
+	if duration <= 0 {
+		return
+	}
 	tokensToAdd := tb.refillRate * duration.Seconds()
 	tb.tokens = math.Min(tb.tokens+tokensToAdd, tb.maxTokens)
 	tb.lastRefillTime = now
 }
 
 func (tb *TokenBucket) Request(tokens float64) bool {
+	allowed, _ := tb.Take(tokens)
+	return allowed
+}
+
+// Takes the requested number of tokens from the bucket. If there aren't
+// enough tokens, nothing is taken and the time until enough tokens will
+// be available is returned.
+func (tb *TokenBucket) Take(tokens float64) (bool, time.Duration) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
 	tb.refill()
 	if tokens <= tb.tokens {
 		tb.tokens -= tokens
-		return true
+		return true, 0
 	}
-	return false
+	if tb.refillRate <= 0 || tokens > tb.maxTokens {
+		return false, time.Duration(math.MaxInt64)
+	}
+	wait := (tokens - tb.tokens) / tb.refillRate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// Returns true if the bucket has refilled to capacity, meaning it holds
+// no state worth keeping.
+func (tb *TokenBucket) Full() bool {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+	tb.refill()
+	return tb.tokens >= tb.maxTokens
 }
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	bucket := CreateTokenBucketWithClock(2, 0.5, func() time.Time { return now })
+
+	assert.True(t, bucket.Request(1))
+	assert.True(t, bucket.Request(1))
+
+	allowed, retryAfter := bucket.Take(1)
+	assert.False(t, allowed)
+	assert.Equal(t, 2*time.Second, retryAfter)
+	assert.False(t, bucket.Full())
+
+	now = now.Add(2 * time.Second)
+	assert.True(t, bucket.Request(1))
+
+	now = now.Add(4 * time.Second)
+	assert.True(t, bucket.Full())
+}
+
+func TestTokenBucketConcurrency(t *testing.T) {
+	now := time.Now()
+	bucket := CreateTokenBucketWithClock(100, 1, func() time.Time { return now })
+	allowed := make(chan bool, 200)
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allowed <- bucket.Request(1)
+		}()
+	}
+	wg.Wait()
+	close(allowed)
+	granted := 0
+	for ok := range allowed {
+		if ok {
+			granted++
+		}
+	}
+	assert.Equal(t, 100, granted)
+}
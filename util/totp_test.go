@@ -0,0 +1,68 @@
+package util
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTP_RFC6238(t *testing.T) {
+
+	// RFC 6238 Appendix B SHA1 test vectors
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).
+		EncodeToString([]byte("12345678901234567890"))
+	totp := NewTOTP(30, 8, 1)
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130"}
+	for unix, expected := range vectors {
+		code, err := totp.Code(secret, totp.Step(time.Unix(unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, code)
+	}
+}
+
+func TestTOTP_Validate(t *testing.T) {
+
+	totp := NewTOTP(30, 6, 1)
+	secret, err := totp.GenerateSecret(20)
+	assert.Nil(t, err)
+	assert.Equal(t, 32, len(secret))
+
+	now := time.Unix(1700000000, 0)
+	step := totp.Step(now)
+
+	code, err := totp.Code(secret, step)
+	assert.Nil(t, err)
+	matched, ok := totp.Validate(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+
+	// Tolerates one period of clock drift
+	previous, _ := totp.Code(secret, step-1)
+	matched, ok = totp.Validate(secret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, step-1, matched)
+
+	stale, _ := totp.Code(secret, step-2)
+	_, ok = totp.Validate(secret, stale, now)
+	assert.False(t, ok)
+
+	_, ok = totp.Validate(secret, "12345", now)
+	assert.False(t, ok)
+
+	_, err = totp.Code("not base32!", step)
+	assert.Equal(t, ErrInvalidTOTPSecret, err)
+
+	uri := totp.ProvisioningURI("cropdroid", "user@example.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/cropdroid:user@example.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=cropdroid")
+}
@@ -1,6 +1,10 @@
 package viewmodel
 
 type JsonWebToken struct {
-	Value string `json:"token"`
-	Error string `json:"error"`
+	Value         string `json:"token"`
+	RefreshToken  string `json:"refresh_token,omitempty"`
+	ExpiresIn     int64  `json:"expires_in,omitempty"`
+	MFAToken      string `json:"mfa_token,omitempty"`
+	MFAEnrollment bool   `json:"mfa_enrollment,omitempty"`
+	Error         string `json:"error"`
 }
@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeremyhahn/go-cropdroid/util"
+	logging "github.com/op/go-logging"
+)
+
+// How often buckets that have refilled to capacity are dropped
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitPolicy is a token bucket limit: up to Capacity requests in a
+// burst, refilled at Rate requests per second.
+type RateLimitPolicy struct {
+	Name     string
+	Capacity float64
+	Rate     float64
+}
+
+// RateLimitRule applies a policy to requests whose method and path,
+// relative to the API base URI, match the rule. Rules without methods
+// match every method.
+type RateLimitRule struct {
+	Methods []string
+	Pattern *regexp.Regexp
+	Policy  RateLimitPolicy
+}
+
+// RateLimiter is negroni middleware that throttles API requests using a
+// token bucket per client IP, and another per user or API key when the
+// request is authenticated. The first rule matching the request selects
+// the policy. Throttled requests are rejected with 429 Too Many Requests
+// and a Retry-After header.
+type RateLimiter struct {
+	logger     *logging.Logger
+	baseURI    string
+	rules      []RateLimitRule
+	identifier RequestIdentifier
+	mutex      sync.Mutex
+	buckets    map[string]*util.TokenBucket
+	lastSweep  time.Time
+	clock      func() time.Time
+}
+
+// Returns the default rate limit rules: strict for the login, registration,
+// password, email change and invitation endpoints, tighter for device
+// switches than other writes, and generous for reads.
+func DefaultRateLimitRules() []RateLimitRule {
+	return []RateLimitRule{
+		{
+			Pattern: regexp.MustCompile(`^/(login|logout|register|password|oidc)(/|$)|^/email/(change|confirm)$|^/invitations/accept$|/google/login$`),
+			Policy:  RateLimitPolicy{Name: "auth", Capacity: 10, Rate: 10.0 / 60}},
+		{
+			Pattern: regexp.MustCompile(`^/farms/[^/]+/devices/[^/]+/(switch|timerSwitch)/`),
+			Policy:  RateLimitPolicy{Name: "switch", Capacity: 10, Rate: 1}},
+		{
+			Methods: []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+			Pattern: regexp.MustCompile(`.*`),
+			Policy:  RateLimitPolicy{Name: "write", Capacity: 60, Rate: 2}},
+		{
+			Pattern: regexp.MustCompile(`.*`),
+			Policy:  RateLimitPolicy{Name: "read", Capacity: 300, Rate: 50}}}
+}
+
+// Creates a new rate limiter for the endpoints under baseURI. The identifier
+// is optional; without it requests are only throttled by client IP.
+func NewRateLimiter(
+	logger *logging.Logger,
+	baseURI string,
+	rules []RateLimitRule,
+	identifier RequestIdentifier) *RateLimiter {
+
+	return &RateLimiter{
+		logger:     logger,
+		baseURI:    baseURI,
+		rules:      rules,
+		identifier: identifier,
+		buckets:    make(map[string]*util.TokenBucket),
+		lastSweep:  time.Now(),
+		clock:      time.Now}
+}
+
+// Takes a token from each of the request's buckets for the matching policy,
+// rejecting the request if any of them are empty.
+func (limiter *RateLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !strings.HasPrefix(r.URL.Path, limiter.baseURI) {
+		next(w, r)
+		return
+	}
+	policy, ok := limiter.policy(r.Method, strings.TrimPrefix(r.URL.Path, limiter.baseURI))
+	if !ok {
+		next(w, r)
+		return
+	}
+	keys := []string{"ip:" + clientIP(r)}
+	if limiter.identifier != nil {
+		if identity, ok := limiter.identifier.Identify(r); ok {
+			keys = append(keys, identity)
+		}
+	}
+	for _, key := range keys {
+		allowed, retryAfter := limiter.bucket(policy, key).Take(1)
+		if !allowed {
+			limiter.logger.Warningf("[RATE LIMIT] %s exceeded %s limit: method=%s, url=%s",
+				key, policy.Name, r.Method, r.URL.Path)
+			seconds := int64(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", seconds))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+	}
+	next(w, r)
+}
+
+// Returns the policy of the first rule matching the request
+func (limiter *RateLimiter) policy(method, path string) (RateLimitPolicy, bool) {
+	for _, rule := range limiter.rules {
+		if len(rule.Methods) > 0 && !containsMethod(rule.Methods, method) {
+			continue
+		}
+		if rule.Pattern.MatchString(path) {
+			return rule.Policy, true
+		}
+	}
+	return RateLimitPolicy{}, false
+}
+
+// Returns the bucket for the key under the policy, creating it if it
+// doesn't exist. Buckets that have refilled to capacity are periodically
+// dropped so idle clients don't accumulate.
+func (limiter *RateLimiter) bucket(policy RateLimitPolicy, key string) *util.TokenBucket {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	now := limiter.clock()
+	if now.Sub(limiter.lastSweep) >= rateLimitSweepInterval {
+		for bucketKey, bucket := range limiter.buckets {
+			if bucket.Full() {
+				delete(limiter.buckets, bucketKey)
+			}
+		}
+		limiter.lastSweep = now
+	}
+	bucketKey := policy.Name + "|" + key
+	bucket, ok := limiter.buckets[bucketKey]
+	if !ok {
+		bucket = util.CreateTokenBucketWithClock(policy.Capacity, policy.Rate, limiter.clock)
+		limiter.buckets[bucketKey] = bucket
+	}
+	return bucket
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the IP address of the client connection
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
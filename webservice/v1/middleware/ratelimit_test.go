@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeIdentifier struct{}
+
+func (identifier *fakeIdentifier) Identify(r *http.Request) (string, bool) {
+	if user := r.Header.Get("X-Test-User"); user != "" {
+		return "user:" + user, true
+	}
+	return "", false
+}
+
+func createTestRateLimiter(now *time.Time) *RateLimiter {
+	limiter := NewRateLimiter(logging.MustGetLogger("ratelimit_test"), "/api/v1",
+		DefaultRateLimitRules(), &fakeIdentifier{})
+	limiter.clock = func() time.Time { return *now }
+	return limiter
+}
+
+func serve(limiter *RateLimiter, method, path, remoteAddr, user string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	r.RemoteAddr = remoteAddr
+	if user != "" {
+		r.Header.Set("X-Test-User", user)
+	}
+	w := httptest.NewRecorder()
+	limiter.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return w
+}
+
+func TestRateLimiterAuthPolicy(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := createTestRateLimiter(&now)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusOK, serve(limiter, "POST", "/api/v1/login", "10.0.0.1:5000", "").Code)
+	}
+	w := serve(limiter, "POST", "/api/v1/login", "10.0.0.1:5001", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "6", w.Header().Get("Retry-After"))
+
+	// Other clients and other policies have their own buckets
+	assert.Equal(t, http.StatusOK, serve(limiter, "POST", "/api/v1/login", "10.0.0.2:5000", "").Code)
+	assert.Equal(t, http.StatusOK, serve(limiter, "GET", "/api/v1/farms/1/config", "10.0.0.1:5000", "").Code)
+
+	// Static files aren't throttled
+	assert.Equal(t, http.StatusOK, serve(limiter, "GET", "/index.html", "10.0.0.1:5000", "").Code)
+
+	now = now.Add(6 * time.Second)
+	assert.Equal(t, http.StatusOK, serve(limiter, "POST", "/api/v1/login", "10.0.0.1:5000", "").Code)
+}
+
+func TestRateLimiterIdentity(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := createTestRateLimiter(&now)
+
+	// A user's limit follows them across addresses
+	switchURL := "/api/v1/farms/1/devices/doser/switch/1/1"
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusOK, serve(limiter, "GET", switchURL, "10.0.0.1:5000", "7").Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serve(limiter, "GET", switchURL, "10.0.0.2:5000", "7").Code)
+	assert.Equal(t, http.StatusOK, serve(limiter, "GET", switchURL, "10.0.0.3:5000", "8").Code)
+}
+
+func TestRateLimiterPolicies(t *testing.T) {
+	limiter := createTestRateLimiter(&time.Time{})
+	tests := []struct {
+		method string
+		path   string
+		policy string
+	}{
+		{"POST", "/login", "auth"},
+		{"POST", "/login/mfa", "auth"},
+		{"POST", "/register", "auth"},
+		{"GET", "/register/activate/abc", "auth"},
+		{"POST", "/password/forgot", "auth"},
+		{"GET", "/oidc/callback", "auth"},
+		{"POST", "/farms/1/google/login", "auth"},
+		{"GET", "/farms/1/devices/doser/timerSwitch/1/30", "switch"},
+		{"PUT", "/farms/1/channels", "write"},
+		{"DELETE", "/apikeys/1", "write"},
+		{"GET", "/loginhistory", "read"},
+		{"GET", "/farms/1/devices/doser/view", "read"}}
+	for _, test := range tests {
+		policy, ok := limiter.policy(test.method, test.path)
+		assert.True(t, ok)
+		assert.Equal(t, test.policy, policy.Name, test.path)
+	}
+}
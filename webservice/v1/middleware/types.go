@@ -6,12 +6,26 @@ import (
 	"github.com/jeremyhahn/go-cropdroid/service"
 )
 
+// JsonWebTokenMiddleware authenticates requests using the JWT in the request. Every
+// protected REST and websocket endpoint is chained through Validate followed by
+// Authorize with the permission the endpoint requires.
 type JsonWebTokenMiddleware interface {
 	Validate(w http.ResponseWriter, r *http.Request, next http.HandlerFunc)
+	Authorize(permission string) func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc)
 	CreateSession(w http.ResponseWriter, r *http.Request) (service.Session, error)
 }
 
+// RequestIdentifier returns the rate limit key for the user or API key a
+// request is authenticated with
+type RequestIdentifier interface {
+	Identify(r *http.Request) (string, bool)
+}
+
 type AuthMiddleware interface {
 	GenerateToken(w http.ResponseWriter, req *http.Request)
 	RefreshToken(w http.ResponseWriter, req *http.Request)
+	Logout(w http.ResponseWriter, req *http.Request)
+	VerifyMFA(w http.ResponseWriter, req *http.Request)
+	EnrollMFA(w http.ResponseWriter, req *http.Request)
+	OIDCCallback(w http.ResponseWriter, req *http.Request)
 }
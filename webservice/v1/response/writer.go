@@ -155,8 +155,8 @@ func (writer *ResponseWriter) Error404(w http.ResponseWriter, r *http.Request, e
 
 func (writer *ResponseWriter) Error403(w http.ResponseWriter, r *http.Request, err error, payload interface{}) {
 	writer.logError(r, err)
-	writer.Write(w, r, http.StatusBadRequest, WebServiceResponse{
-		Code:    http.StatusBadRequest,
+	writer.Write(w, r, http.StatusForbidden, WebServiceResponse{
+		Code:    http.StatusForbidden,
 		Error:   err.Error(),
 		Success: false,
 		Payload: payload})
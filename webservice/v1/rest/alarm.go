@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type AlarmRestServicer interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Open(w http.ResponseWriter, r *http.Request)
+	Acknowledge(w http.ResponseWriter, r *http.Request)
+	Shelve(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type AlarmRestService struct {
+	alarmService service.AlarmService
+	middleware   middleware.JsonWebTokenMiddleware
+	httpWriter   response.HttpWriter
+	AlarmRestServicer
+}
+
+// AlarmShelveRequest is the number of minutes to shelve the alarm. The
+// default shelve duration is used when the request body is empty.
+type AlarmShelveRequest struct {
+	Minutes int `json:"minutes"`
+}
+
+func NewAlarmRestService(
+	alarmService service.AlarmService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) AlarmRestServicer {
+
+	return &AlarmRestService{
+		alarmService: alarmService,
+		middleware:   middleware,
+		httpWriter:   httpWriter}
+}
+
+// Returns all of the farm's alarms, including cleared alarms
+func (restService *AlarmRestService) List(w http.ResponseWriter, r *http.Request) {
+	restService.list(w, r, false)
+}
+
+// Returns the farm's alarms that have not been cleared
+func (restService *AlarmRestService) Open(w http.ResponseWriter, r *http.Request) {
+	restService.list(w, r, true)
+}
+
+func (restService *AlarmRestService) list(w http.ResponseWriter, r *http.Request, openOnly bool) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	alarms, err := restService.alarmService.GetAlarms(session, openOnly)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, alarms)
+}
+
+// Acknowledges an open alarm
+func (restService *AlarmRestService) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	alarmID, err := restService.parseAlarmID(r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	alarm, err := restService.alarmService.Acknowledge(session, alarmID)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, alarm)
+}
+
+// Shelves an open alarm, suppressing notifications for the requested number of minutes
+func (restService *AlarmRestService) Shelve(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	alarmID, err := restService.parseAlarmID(r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	var request AlarmShelveRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil && err != io.EOF {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	alarm, err := restService.alarmService.Shelve(session, alarmID, request.Minutes)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, alarm)
+}
+
+func (restService *AlarmRestService) parseAlarmID(r *http.Request) (uint64, error) {
+	params := mux.Vars(r)
+	return strconv.ParseUint(params["alarmID"], 10, 64)
+}
@@ -0,0 +1,105 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type APIKeyRestServicer interface {
+	Create(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Revoke(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type APIKeyRestService struct {
+	apiKeyService service.APIKeyService
+	middleware    middleware.JsonWebTokenMiddleware
+	httpWriter    response.HttpWriter
+	APIKeyRestServicer
+}
+
+// APIKeyResponse is a newly created API key. The token is the credential
+// used in the Authorization header and is only returned once.
+type APIKeyResponse struct {
+	Key   *entity.APIKey `json:"key"`
+	Token string         `json:"token"`
+}
+
+func NewAPIKeyRestService(
+	apiKeyService service.APIKeyService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) APIKeyRestServicer {
+
+	return &APIKeyRestService{
+		apiKeyService: apiKeyService,
+		middleware:    middleware,
+		httpWriter:    httpWriter}
+}
+
+// Creates a new service account API key
+func (restService *APIKeyRestService) Create(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var request service.APIKeyRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	apiKey, token, err := restService.apiKeyService.Create(session, request)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, APIKeyResponse{Key: apiKey, Token: token})
+}
+
+// Returns the API keys visible to the session user
+func (restService *APIKeyRestService) List(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	apiKeys, err := restService.apiKeyService.List(session)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, apiKeys)
+}
+
+// Revokes an API key
+func (restService *APIKeyRestService) Revoke(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	params := mux.Vars(r)
+	id, err := strconv.ParseUint(params["id"], 10, 64)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	apiKey, err := restService.apiKeyService.Revoke(session, id)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, apiKey)
+}
@@ -0,0 +1,164 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type CalibrationRestServicer interface {
+	Start(w http.ResponseWriter, r *http.Request)
+	Capture(w http.ResponseWriter, r *http.Request)
+	Complete(w http.ResponseWriter, r *http.Request)
+	Cancel(w http.ResponseWriter, r *http.Request)
+	Overdue(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type CalibrationRestService struct {
+	calibrationService service.CalibrationService
+	middleware         middleware.JsonWebTokenMiddleware
+	httpWriter         response.HttpWriter
+	CalibrationRestServicer
+}
+
+// CalibrationPointRequest is the reference value of the solution
+// the probe is placed in when a calibration point is captured
+type CalibrationPointRequest struct {
+	Reference float64 `json:"reference"`
+}
+
+func NewCalibrationRestService(
+	calibrationService service.CalibrationService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) CalibrationRestServicer {
+
+	return &CalibrationRestService{
+		calibrationService: calibrationService,
+		middleware:         middleware,
+		httpWriter:         httpWriter}
+}
+
+// Starts a guided calibration for the requested device metric
+func (restService *CalibrationRestService) Start(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	deviceID, metricID, err := restService.parseIDs(r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	calibration, err := restService.calibrationService.Start(session, deviceID, metricID)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, calibration)
+}
+
+// Captures the current raw sensor reading against a reference value
+func (restService *CalibrationRestService) Capture(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	deviceID, metricID, err := restService.parseIDs(r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	var request CalibrationPointRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	calibration, err := restService.calibrationService.Capture(session,
+		deviceID, metricID, request.Reference)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, calibration)
+}
+
+// Calculates and saves the calibration from the captured points
+func (restService *CalibrationRestService) Complete(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	deviceID, metricID, err := restService.parseIDs(r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	metric, err := restService.calibrationService.Complete(session, deviceID, metricID)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, metric)
+}
+
+// Discards a calibration in progress
+func (restService *CalibrationRestService) Cancel(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	deviceID, metricID, err := restService.parseIDs(r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.calibrationService.Cancel(session, deviceID, metricID); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
+
+// Returns the farm metrics that are overdue for calibration
+func (restService *CalibrationRestService) Overdue(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	metrics, err := restService.calibrationService.GetOverdue(session)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, metrics)
+}
+
+func (restService *CalibrationRestService) parseIDs(r *http.Request) (uint64, uint64, error) {
+	params := mux.Vars(r)
+	deviceID, err := strconv.ParseUint(params["deviceID"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	metricID, err := strconv.ParseUint(params["metricID"], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return deviceID, metricID, nil
+}
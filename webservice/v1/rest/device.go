@@ -7,6 +7,8 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/util"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
@@ -186,11 +188,17 @@ func (restService *DeviceRestService) Switch(w http.ResponseWriter, r *http.Requ
 	switchPosition := util.NewSwitchPosition(_position)
 	message := fmt.Sprintf("%s switching %s %s %s", session.GetUser().GetEmail(),
 		switchPosition.ToLowerString(), deviceType, channelConfig.GetName())
-	eventEntity, err := deviceService.Switch(_channel, _position, message)
+	before := restService.switchState(deviceService, channelConfig, _channel)
+	eventEntity, err := deviceService.Switch(_channel, _position, service.EventAttributes{
+		Source:  common.EVENT_SOURCE_MANUAL,
+		ActorID: session.GetUser().Identifier(),
+		Message: message})
 	if err != nil {
 		restService.httpWriter.Error400(w, r, err)
 		return
 	}
+	restService.audit(session, common.AUDIT_ACTION_SWITCH, channelConfig, before,
+		restService.switchState(deviceService, channelConfig, _channel))
 	restService.httpWriter.Success200(w, r, eventEntity)
 }
 
@@ -220,14 +228,26 @@ func (restService *DeviceRestService) TimerSwitch(w http.ResponseWriter, r *http
 		restService.httpWriter.Error400(w, r, err)
 		return
 	}
+	channelConfig, err := deviceService.ChannelConfig(_channel)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
 	deviceType := deviceService.DeviceType()
 	message := fmt.Sprintf("%s switching on %s channel %s for %s seconds",
 		session.GetUser().GetEmail(), deviceType, channel, duration)
-	eventEntity, err := deviceService.TimerSwitch(_channel, _duration, message)
+	before := restService.switchState(deviceService, channelConfig, _channel)
+	eventEntity, err := deviceService.TimerSwitch(_channel, _duration, service.EventAttributes{
+		Source:  common.EVENT_SOURCE_MANUAL,
+		ActorID: session.GetUser().Identifier(),
+		Message: message})
 	if err != nil {
 		restService.httpWriter.Error400(w, r, err)
 		return
 	}
+	after := restService.switchState(deviceService, channelConfig, _channel)
+	after["duration"] = _duration
+	restService.audit(session, common.AUDIT_ACTION_TIMER_SWITCH, channelConfig, before, after)
 
 	restService.httpWriter.Success200(w, r, eventEntity)
 }
@@ -254,3 +274,32 @@ func (restService *DeviceRestService) History(w http.ResponseWriter, r *http.Req
 	}
 	restService.httpWriter.Success200(w, r, history)
 }
+
+// Returns the current position of a device channel for the audit log
+func (restService *DeviceRestService) switchState(deviceService service.DeviceServicer,
+	channelConfig config.Channel, channelID int) map[string]any {
+
+	state := map[string]any{
+		"device":  deviceService.DeviceType(),
+		"channel": channelConfig.GetName()}
+	if deviceState, err := deviceService.State(); err == nil {
+		if channels := deviceState.GetChannels(); channelID >= 0 && channelID < len(channels) {
+			state["position"] = channels[channelID]
+		}
+	}
+	return state
+}
+
+// Records a manual switch in the audit log
+func (restService *DeviceRestService) audit(session service.Session, action string,
+	channelConfig config.Channel, before, after map[string]any) {
+
+	auditService := restService.serviceRegistry.GetAuditService()
+	if auditService == nil {
+		return
+	}
+	if err := auditService.Record(session, action, "channel",
+		channelConfig.Identifier(), before, after); err != nil {
+		session.GetLogger().Errorf("Error recording %s audit entry: %s", action, err)
+	}
+}
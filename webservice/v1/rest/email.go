@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type EmailRestServicer interface {
+	GetPreferences(w http.ResponseWriter, r *http.Request)
+	SetPreferences(w http.ResponseWriter, r *http.Request)
+	ChangeEmail(w http.ResponseWriter, r *http.Request)
+	ConfirmEmail(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type EmailRestService struct {
+	emailService       service.EmailService
+	emailChangeService service.EmailChangeService
+	middleware         middleware.JsonWebTokenMiddleware
+	httpWriter         response.HttpWriter
+	EmailRestServicer
+}
+
+// ChangeEmailRequest requests a confirmation link be sent to the new
+// email address. The user's current password is required.
+type ChangeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// ConfirmEmailRequest applies an email change using the token from the
+// confirmation link
+type ConfirmEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func NewEmailRestService(
+	emailService service.EmailService,
+	emailChangeService service.EmailChangeService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) EmailRestServicer {
+
+	return &EmailRestService{
+		emailService:       emailService,
+		emailChangeService: emailChangeService,
+		middleware:         middleware,
+		httpWriter:         httpWriter}
+}
+
+// Writes the user's notification email address and subscribed email categories
+func (restService *EmailRestService) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	preferences, err := restService.emailService.GetPreferences(session)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, preferences)
+}
+
+// Updates the user's notification email address and subscribed email categories
+func (restService *EmailRestService) SetPreferences(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var preferences service.EmailPreferences
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&preferences); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.emailService.SetPreferences(session, &preferences); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, preferences)
+}
+
+// Emails a confirmation link to the new email address of the session user
+func (restService *EmailRestService) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var request ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.emailChangeService.RequestChange(session, request.Email, request.Password); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
+
+// Changes the user's email address using the token from a confirmation link
+func (restService *EmailRestService) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	var request ConfirmEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.emailChangeService.Confirm(request.Token); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
@@ -3,10 +3,12 @@ package rest
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/jeremyhahn/go-cropdroid/app"
 	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
 	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
@@ -17,6 +19,8 @@ import (
 type EventLogRestServicer interface {
 	SystemPage(w http.ResponseWriter, r *http.Request)
 	FarmPage(w http.ResponseWriter, r *http.Request)
+	Archives(w http.ResponseWriter, r *http.Request)
+	Restore(w http.ResponseWriter, r *http.Request)
 }
 
 type EventLogRestService struct {
@@ -53,6 +57,7 @@ func (restService *EventLogRestService) FarmPage(w http.ResponseWriter, r *http.
 	if err != nil {
 		restService.logger.Error(err)
 		restService.httpWriter.Error400(w, r, err)
+		return
 	}
 
 	p, err := strconv.Atoi(page)
@@ -65,7 +70,14 @@ func (restService *EventLogRestService) FarmPage(w http.ResponseWriter, r *http.
 	pageQuery := query.NewPageQuery()
 	pageQuery.Page = p
 	pageQuery.SortOrder = query.SORT_DESCENDING
-	pageResult, err := restService.serviceRegistry.GetEventLogService(farmID).GetPage(pageQuery, common.CONSISTENCY_LOCAL)
+	filter, err := parseEventLogFilter(r)
+	if err != nil {
+		restService.logger.Error(err)
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	pageResult, err := restService.serviceRegistry.GetEventLogService(farmID).GetPageByFilter(
+		filter, pageQuery, common.CONSISTENCY_LOCAL)
 	if err != nil {
 		restService.logger.Error(err)
 		restService.httpWriter.Error400(w, r, err)
@@ -91,7 +103,14 @@ func (restService *EventLogRestService) SystemPage(w http.ResponseWriter, r *htt
 	pageQuery := query.NewPageQuery()
 	pageQuery.Page = p
 	pageQuery.SortOrder = query.SORT_DESCENDING
-	pageResult, err := restService.serviceRegistry.GetEventLogService(restService.app.ClusterID).GetPage(pageQuery, common.CONSISTENCY_LOCAL)
+	filter, err := parseEventLogFilter(r)
+	if err != nil {
+		restService.logger.Error(err)
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	pageResult, err := restService.serviceRegistry.GetEventLogService(restService.app.ClusterID).GetPageByFilter(
+		filter, pageQuery, common.CONSISTENCY_LOCAL)
 	if err != nil {
 		restService.logger.Error(err)
 		restService.httpWriter.Error400(w, r, err)
@@ -100,3 +119,91 @@ func (restService *EventLogRestService) SystemPage(w http.ResponseWriter, r *htt
 
 	restService.httpWriter.Success200(w, r, pageResult)
 }
+
+// Writes the list of farm event log archives
+func (restService *EventLogRestService) Archives(w http.ResponseWriter, r *http.Request) {
+	eventLogService, ok := restService.farmEventLogService(w, r)
+	if !ok {
+		return
+	}
+	archives, err := eventLogService.Archives()
+	if err != nil {
+		restService.logger.Error(err)
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, archives)
+}
+
+// Re-imports a farm event log archive and writes the number of restored entries
+func (restService *EventLogRestService) Restore(w http.ResponseWriter, r *http.Request) {
+	eventLogService, ok := restService.farmEventLogService(w, r)
+	if !ok {
+		return
+	}
+	restored, err := eventLogService.Restore(mux.Vars(r)["name"])
+	if err != nil {
+		restService.logger.Error(err)
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, restored)
+}
+
+// Returns the event log service for the requested farm,
+// writing an error response if the farm doesn't exist.
+func (restService *EventLogRestService) farmEventLogService(w http.ResponseWriter,
+	r *http.Request) (service.EventLogServicer, bool) {
+
+	farmID, err := strconv.ParseUint(mux.Vars(r)["farmID"], 10, 64)
+	if err != nil {
+		restService.logger.Error(err)
+		restService.httpWriter.Error400(w, r, err)
+		return nil, false
+	}
+	eventLogService := restService.serviceRegistry.GetEventLogService(farmID)
+	if eventLogService == nil {
+		restService.httpWriter.Error400(w, r, service.ErrFarmNotFound)
+		return nil, false
+	}
+	return eventLogService, true
+}
+
+// Parses the optional event log filter query parameters: start and end
+// (RFC3339), device, channel, type, actor, source and q (message search).
+func parseEventLogFilter(r *http.Request) (dao.EventLogFilter, error) {
+	var filter dao.EventLogFilter
+	values := r.URL.Query()
+	parseTime := func(key string) (time.Time, error) {
+		if value := values.Get(key); value != "" {
+			return time.Parse(time.RFC3339, value)
+		}
+		return time.Time{}, nil
+	}
+	parseID := func(key string) (uint64, error) {
+		if value := values.Get(key); value != "" {
+			return strconv.ParseUint(value, 10, 64)
+		}
+		return 0, nil
+	}
+	var err error
+	if filter.Start, err = parseTime("start"); err != nil {
+		return filter, err
+	}
+	if filter.End, err = parseTime("end"); err != nil {
+		return filter, err
+	}
+	if filter.DeviceID, err = parseID("device"); err != nil {
+		return filter, err
+	}
+	if filter.ChannelID, err = parseID("channel"); err != nil {
+		return filter, err
+	}
+	if filter.ActorID, err = parseID("actor"); err != nil {
+		return filter, err
+	}
+	filter.EventType = values.Get("type")
+	filter.Source = values.Get("source")
+	filter.Search = values.Get("q")
+	return filter, nil
+}
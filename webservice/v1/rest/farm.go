@@ -173,7 +173,7 @@ func (restService *FarmRestService) GetConfig(w http.ResponseWriter, r *http.Req
 		return
 	}
 	defer session.Close()
-	restService.httpWriter.Success200(w, r, session.GetFarmService().GetConfig())
+	restService.httpWriter.Success200(w, r, session.GetFarmService().GetConfig().Redact())
 }
 
 // Returns the current farm state from the current session
@@ -16,6 +16,7 @@ import (
 type FarmWebSocketRestServicer interface {
 	FarmTickerConnect(w http.ResponseWriter, r *http.Request)
 	PushNotificationConnect(w http.ResponseWriter, r *http.Request)
+	EventLogConnect(w http.ResponseWriter, r *http.Request)
 }
 
 type FarmWebSocketRestService struct {
@@ -24,6 +25,8 @@ type FarmWebSocketRestService struct {
 	farmHubsMutex         *sync.RWMutex
 	notificationHubs      map[uint64]*websocket.NotificationHub
 	notificationHubsMutex *sync.RWMutex
+	eventLogHubs          map[uint64]*websocket.EventLogHub
+	eventLogHubsMutex     *sync.RWMutex
 	serviceRegistry       service.ServiceRegistry
 	middleware            middleware.JsonWebTokenMiddleware
 	responseWriter        response.HttpWriter
@@ -36,6 +39,8 @@ func NewFarmWebSocketRestService(
 	farmHubsMutex *sync.RWMutex,
 	notificationHubs map[uint64]*websocket.NotificationHub,
 	notificationHubsMutex *sync.RWMutex,
+	eventLogHubs map[uint64]*websocket.EventLogHub,
+	eventLogHubsMutex *sync.RWMutex,
 	serviceRegistry service.ServiceRegistry,
 	middleware middleware.JsonWebTokenMiddleware,
 	responseWriter response.HttpWriter) FarmWebSocketRestServicer {
@@ -46,6 +51,8 @@ func NewFarmWebSocketRestService(
 		farmHubsMutex:         farmHubsMutex,
 		notificationHubs:      notificationHubs,
 		notificationHubsMutex: notificationHubsMutex,
+		eventLogHubs:          eventLogHubs,
+		eventLogHubsMutex:     eventLogHubsMutex,
 		serviceRegistry:       serviceRegistry,
 		middleware:            middleware,
 		responseWriter:        responseWriter}
@@ -93,7 +100,8 @@ func (farmHandler *FarmWebSocketRestService) FarmTickerConnect(w http.ResponseWr
 // notifications via websocket. This method creates a new NotificationService using the
 // passed HTTP GET farmID parameter, creates a new notification hub if needed, upgrades
 // the HTTP connection to a websocket, and connects the incoming request to the hub to
-// start receiving real-time push notifications.
+// start receiving real-time push notifications. Clients reconnecting with a cursor query
+// parameter first receive the inbox notifications newer than the cursor.
 func (farmHandler *FarmWebSocketRestService) PushNotificationConnect(w http.ResponseWriter, r *http.Request) {
 	notificationService := farmHandler.serviceRegistry.GetNotificationService()
 	params := mux.Vars(r)
@@ -115,6 +123,41 @@ func (farmHandler *FarmWebSocketRestService) PushNotificationConnect(w http.Resp
 		go farmHandler.notificationHubs[farmID].Run()
 	}
 	handler := websocket.NewNotificationWebSocket(farmHandler.logger,
-		farmHandler.notificationHubs[farmID], farmHandler.middleware)
+		farmHandler.notificationHubs[farmID], farmHandler.serviceRegistry.GetInboxService(),
+		farmHandler.middleware)
 	handler.OnConnect(w, r)
 }
+
+// Handles a new connection request on the standard HTTP protocol for real-time farm event
+// log entries via websocket. This method creates a new event log hub for the farm if needed,
+// upgrades the HTTP connection to a websocket, and connects the incoming request to the hub
+// to start receiving new event log entries matching the client's device and event type
+// subscription.
+func (farmHandler *FarmWebSocketRestService) EventLogConnect(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	farmID, err := strconv.ParseUint(params["farmID"], 10, 64)
+	if err != nil {
+		farmHandler.responseWriter.Error400(w, r, err)
+		return
+	}
+	farmHandler.eventLogHubsMutex.Lock()
+	eventLogHub, exists := farmHandler.eventLogHubs[farmID]
+	if !exists {
+		eventLogService := farmHandler.serviceRegistry.GetEventLogService(farmID)
+		if eventLogService == nil {
+			farmHandler.eventLogHubsMutex.Unlock()
+			farmHandler.responseWriter.Error400(w, r, response.ErrFarmNotFound)
+			return
+		}
+		farmHandler.logger.Debugf("Creating new websocket event log hub for farm %d", farmID)
+		eventLogHub = websocket.NewEventLogHub(farmHandler.logger, eventLogService)
+		farmHandler.eventLogHubs[farmID] = eventLogHub
+		go eventLogHub.Run()
+	}
+	farmHandler.eventLogHubsMutex.Unlock()
+	websocket.NewEventLogWebSocket(
+		farmHandler.logger,
+		eventLogHub,
+		farmHandler.middleware,
+		farmHandler.responseWriter).OnConnect(w, r)
+}
@@ -0,0 +1,121 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/datastore/raft/query"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type InboxRestServicer interface {
+	Page(w http.ResponseWriter, r *http.Request)
+	UnreadCount(w http.ResponseWriter, r *http.Request)
+	MarkRead(w http.ResponseWriter, r *http.Request)
+	MarkAllRead(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type InboxRestService struct {
+	inboxService service.InboxService
+	middleware   middleware.JsonWebTokenMiddleware
+	httpWriter   response.HttpWriter
+	InboxRestServicer
+}
+
+// InboxUnreadResponse is the number of unread notifications in the user's inbox
+type InboxUnreadResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+func NewInboxRestService(
+	inboxService service.InboxService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) InboxRestServicer {
+
+	return &InboxRestService{
+		inboxService: inboxService,
+		middleware:   middleware,
+		httpWriter:   httpWriter}
+}
+
+// Writes a page of the user's inbox, newest notification first
+func (restService *InboxRestService) Page(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	params := mux.Vars(r)
+	page, err := strconv.Atoi(params["page"])
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	pageQuery := query.NewPageQuery()
+	pageQuery.Page = page
+	pageQuery.SortOrder = query.SORT_DESCENDING
+	pageResult, err := restService.inboxService.GetInbox(session, pageQuery)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, pageResult)
+}
+
+// Writes the number of unread notifications in the user's inbox
+func (restService *InboxRestService) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	unread, err := restService.inboxService.CountUnread(session)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, InboxUnreadResponse{Unread: unread})
+}
+
+// Marks an inbox item as read
+func (restService *InboxRestService) MarkRead(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	params := mux.Vars(r)
+	itemID, err := strconv.ParseUint(params["itemID"], 10, 64)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	item, err := restService.inboxService.MarkRead(session, itemID)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, item)
+}
+
+// Marks every item in the user's inbox as read
+func (restService *InboxRestService) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	if err := restService.inboxService.MarkAllRead(session); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
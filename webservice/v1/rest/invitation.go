@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type InvitationRestServicer interface {
+	Invite(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Revoke(w http.ResponseWriter, r *http.Request)
+	Accept(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type InvitationRestService struct {
+	invitationService service.InvitationService
+	middleware        middleware.JsonWebTokenMiddleware
+	httpWriter        response.HttpWriter
+	InvitationRestServicer
+}
+
+func NewInvitationRestService(
+	invitationService service.InvitationService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) InvitationRestServicer {
+
+	return &InvitationRestService{
+		invitationService: invitationService,
+		middleware:        middleware,
+		httpWriter:        httpWriter}
+}
+
+// Invites an email address to the requested organization or farm
+func (restService *InvitationRestService) Invite(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var request service.InvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	invitation, err := restService.invitationService.Invite(session, request)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, invitation)
+}
+
+// Returns the pending invitations to the requested organization or farm
+func (restService *InvitationRestService) List(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	invitations, err := restService.invitationService.List(session)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, invitations)
+}
+
+// Revokes a pending invitation
+func (restService *InvitationRestService) Revoke(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	id, err := strconv.ParseUint(mux.Vars(r)["invitationID"], 10, 64)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	invitation, err := restService.invitationService.Revoke(session, id)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, invitation)
+}
+
+// Accepts an invitation using the token from the invitation link
+func (restService *InvitationRestService) Accept(w http.ResponseWriter, r *http.Request) {
+	var acceptance service.InvitationAcceptance
+	if err := json.NewDecoder(r.Body).Decode(&acceptance); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	invitation, err := restService.invitationService.Accept(acceptance)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, invitation)
+}
@@ -1,7 +1,10 @@
 package rest
 
 import (
+	"context"
 	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +19,7 @@ import (
 	"github.com/jeremyhahn/go-cropdroid/app"
 	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/mapper"
 	"github.com/jeremyhahn/go-cropdroid/model"
 	"github.com/jeremyhahn/go-cropdroid/service"
@@ -27,6 +31,9 @@ import (
 
 // https://gist.github.com/soulmachine/b368ce7292ddd7f91c15accccc02b8df
 
+// Request context key for the API key Validate authenticated the request with
+type apiKeyContextKey struct{}
+
 type JsonWebTokenServicer interface {
 	ParseToken(r *http.Request, extractor request.Extractor) (*jwt.Token, *JsonWebTokenClaims, error)
 	middleware.AuthMiddleware
@@ -104,14 +111,19 @@ func (jwtService *JWTService) privateKey() *rsa.PrivateKey {
 
 // Creates a new web service session by parsing the organization and farm from
 // the JWT Claims and creating a service.Session object that represents the
-// user and their organization and farm membership.
+// user and their organization and farm membership. Requests authenticated
+// with an API key are given a service account session instead.
 func (jwtService *JWTService) CreateSession(w http.ResponseWriter,
 	r *http.Request) (service.Session, error) {
 
 	jwtService.app.Logger.Debugf("url: %s, method: %s, remoteAddress: %s, requestUri: %s",
 		r.URL.Path, r.Method, r.RemoteAddr, r.RequestURI)
 
-	_, claims, err := jwtService.parseToken(w, r)
+	if apiKey, ok := jwtService.apiKeyToken(r); ok {
+		return jwtService.createServiceAccountSession(r, apiKey)
+	}
+
+	token, claims, err := jwtService.parseToken(w, r)
 	if err != nil {
 		return nil, err
 	}
@@ -168,31 +180,40 @@ func (jwtService *JWTService) CreateSession(w http.ResponseWriter,
 			return nil, fmt.Errorf("farm not found: %d", requestedFarmID)
 		}
 		farmConfig := farmService.GetConfig()
-		for _, user := range farmConfig.GetUsers() {
-			if user.GetEmail() == claims.Email {
-				isFarmMember = true
-				roles = user.GetRoles()
-				break
+		farmRoles, ok := jwtService.farmRoles(farmConfig, claims.Email)
+		isFarmMember = ok
+		roles = append(roles, farmRoles...)
+		consistencyLevel = farmConfig.GetConsistencyLevel()
+	} else if requestedOrgID == 0 {
+		// Sessions that aren't scoped to an organization or farm are granted
+		// the roles the user has been assigned in each of their organizations
+		// and farms. Requests for another tenant's resources are rejected by
+		// the tenant checks in Authorize and the tenant scoped DAOs.
+		for _, org := range orgClaims {
+			roles = append(roles, jwtService.claimRoles(org.Roles)...)
+			for _, farm := range org.Farms {
+				roles = append(roles, jwtService.claimRoles(farm.Roles)...)
 			}
 		}
-		consistencyLevel = farmConfig.GetConsistencyLevel()
+		for _, farm := range FarmClaims {
+			farmService := jwtService.serviceRegistry.GetFarmService(farm.ID)
+			if farmService == nil {
+				continue
+			}
+			farmRoles, _ := jwtService.farmRoles(farmService.GetConfig(), claims.Email)
+			roles = append(roles, farmRoles...)
+		}
 	} else {
 		// Get the roles the user has been assigned from the organization
 		// and all of the farms the user has been granted permissions within
 	ORGS_LOOP:
 		for _, org := range orgClaims {
 			if org.ID == requestedOrgID {
-				for _, role := range org.Roles {
-					roles = append(roles, &config.RoleStruct{Name: role})
-				}
+				roles = append(roles, jwtService.claimRoles(org.Roles)...)
 				for _, farm := range org.Farms {
 					if farm.ID == requestedFarmID {
 						isFarmMember = true
-						for _, role := range farm.Roles {
-							roles = append(roles, &config.RoleStruct{
-								ID:   jwtService.idGenerator.NewStringID(role),
-								Name: role})
-						}
+						roles = append(roles, jwtService.claimRoles(farm.Roles)...)
 						break ORGS_LOOP
 					}
 				}
@@ -200,32 +221,35 @@ func (jwtService *JWTService) CreateSession(w http.ResponseWriter,
 		}
 	}
 
-	// Assign a default role if no roles were found in orgs or farms
-	// The default user always gets admin, everyone else is assigned
-	// the system configurable default role.
-	if len(roles) == 0 {
-		if claims.Email == common.DEFAULT_USER {
-			roles = append(roles, &config.RoleStruct{
-				ID:   jwtService.idGenerator.NewStringID(common.ROLE_ADMIN),
-				Name: common.ROLE_ADMIN})
-		} else {
-			roles = append(roles, jwtService.defaultRole)
-		}
-	}
-
 	// Make sure the user is a member of the farm being requested
 	if !isFarmMember && requestedFarmID > 0 {
 		jwtService.app.Logger.Errorf("[UNAUTHORIZED] Unauthorized access attempt to farm: user=%s, farm=%d", claims.Email, requestedFarmID)
 		return nil, errors.New("not a member of the requested farm, access request has been logged")
 	}
 
+	// Permissions are only granted by the roles the user has been assigned
+	// within their organizations and farms; users without any role assignments
+	// aren't granted any permissions. The system administration permission
+	// can't be granted by an organization or farm role, only the default
+	// user administers the system.
+	systemAdmin := jwtService.isSystemAdmin(claims)
+	if systemAdmin {
+		roles = append(roles, &config.RoleStruct{Name: common.ROLE_ADMIN})
+	}
+
 	// Create the session
-	commonRoles := make([]model.Role, len(roles))
-	for i, role := range roles {
+	commonRoles := make([]model.Role, 0, len(roles))
+	assigned := make(map[string]bool, len(roles))
+	for _, role := range roles {
 		roleName := role.GetName()
-		commonRoles[i] = &model.RoleStruct{
-			ID:   jwtService.idGenerator.NewStringID(roleName),
-			Name: roleName}
+		if assigned[roleName] {
+			continue
+		}
+		assigned[roleName] = true
+		commonRoles = append(commonRoles, &model.RoleStruct{
+			ID:          jwtService.idGenerator.NewStringID(roleName),
+			Name:        roleName,
+			Permissions: jwtService.sessionPermissions(roleName, systemAdmin)})
 	}
 	user := &model.UserStruct{
 		ID:    claims.UserID,
@@ -234,8 +258,15 @@ func (jwtService *JWTService) CreateSession(w http.ResponseWriter,
 
 	farmService := jwtService.serviceRegistry.GetFarmService(requestedFarmID)
 
-	return service.CreateSession(jwtService.app.Logger, orgClaims,
-		FarmClaims, farmService, requestedOrgID, requestedFarmID, consistencyLevel, user), nil
+	session := service.CreateSession(jwtService.app.Logger, orgClaims,
+		FarmClaims, farmService, requestedOrgID, requestedFarmID, consistencyLevel, user)
+
+	// Identify the session by a digest of the token signature so audit
+	// entries can be correlated without storing the token
+	tokenDigest := sha256.Sum256([]byte(token.Signature))
+	session.SetSessionID(hex.EncodeToString(tokenDigest[:8]))
+
+	return session, nil
 }
 
 func (jwtService *JWTService) GenerateToken(w http.ResponseWriter, req *http.Request) {
@@ -254,12 +285,124 @@ func (jwtService *JWTService) GenerateToken(w http.ResponseWriter, req *http.Req
 	userService := jwtService.serviceRegistry.GetUserService()
 	userAccount, orgs, farms, err := userService.Login(&user)
 	if err != nil {
+		var challenge *service.MFAChallengeError
+		if errors.As(err, &challenge) {
+			jwtService.responseWriter.Write(w, req, http.StatusUnauthorized,
+				viewmodel.JsonWebToken{
+					Error:         err.Error(),
+					MFAToken:      challenge.Token,
+					MFAEnrollment: challenge.Enroll})
+			return
+		}
 		jwtService.app.Logger.Errorf("GenerateToken login error: %s", err)
 		jwtService.responseWriter.Write(w, req, http.StatusForbidden,
 			viewmodel.JsonWebToken{Error: "Invalid credentials"})
 		return
 	}
 
+	jwtService.writeToken(w, req, userAccount, orgs, farms)
+}
+
+// MFARequest completes a login challenged for a second factor. The code
+// is a TOTP code from the user's authenticator app or a recovery code.
+type MFARequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// Completes a login that was challenged for a second factor, returning a
+// new JWT and refresh token
+func (jwtService *JWTService) VerifyMFA(w http.ResponseWriter, req *http.Request) {
+
+	jwtService.app.Logger.Debugf("url: %s, method: %s, remoteAddress: %s, requestUri: %s",
+		req.URL.Path, req.Method, req.RemoteAddr, req.RequestURI)
+
+	var mfaRequest MFARequest
+	if err := json.NewDecoder(req.Body).Decode(&mfaRequest); err != nil || mfaRequest.MFAToken == "" {
+		jwtService.responseWriter.Write(w, req, http.StatusBadRequest,
+			viewmodel.JsonWebToken{Error: "MFA token required"})
+		return
+	}
+	userService := jwtService.serviceRegistry.GetUserService()
+	userAccount, orgs, farms, err := userService.VerifyMFA(mfaRequest.MFAToken, mfaRequest.Code)
+	if err != nil {
+		jwtService.app.Logger.Errorf("[UNAUTHORIZED] MFA verification failed: %s, address=%s", err, req.RemoteAddr)
+		jwtService.responseWriter.Write(w, req, http.StatusForbidden,
+			viewmodel.JsonWebToken{Error: err.Error()})
+		return
+	}
+	jwtService.writeToken(w, req, userAccount, orgs, farms)
+}
+
+// Enrolls a user in MFA when their organization requires MFA and the
+// login was challenged before the user enrolled. The enrollment is
+// confirmed when the challenge is verified.
+func (jwtService *JWTService) EnrollMFA(w http.ResponseWriter, req *http.Request) {
+
+	jwtService.app.Logger.Debugf("url: %s, method: %s, remoteAddress: %s, requestUri: %s",
+		req.URL.Path, req.Method, req.RemoteAddr, req.RequestURI)
+
+	var mfaRequest MFARequest
+	if err := json.NewDecoder(req.Body).Decode(&mfaRequest); err != nil || mfaRequest.MFAToken == "" {
+		jwtService.responseWriter.Write(w, req, http.StatusBadRequest,
+			viewmodel.JsonWebToken{Error: "MFA token required"})
+		return
+	}
+	mfaService := jwtService.serviceRegistry.GetMFAService()
+	enrollment, err := mfaService.EnrollChallenge(mfaRequest.MFAToken)
+	if err != nil {
+		jwtService.responseWriter.Write(w, req, http.StatusForbidden,
+			viewmodel.JsonWebToken{Error: err.Error()})
+		return
+	}
+	jwtService.responseWriter.Write(w, req, http.StatusOK, enrollment)
+}
+
+// Completes an OpenID Connect login when the provider redirects back with
+// an authorization code, returning a new JWT and refresh token
+func (jwtService *JWTService) OIDCCallback(w http.ResponseWriter, req *http.Request) {
+
+	jwtService.app.Logger.Debugf("url: %s, method: %s, remoteAddress: %s, requestUri: %s",
+		req.URL.Path, req.Method, req.RemoteAddr, req.RequestURI)
+
+	params := req.URL.Query()
+	if providerError := params.Get("error"); providerError != "" {
+		jwtService.app.Logger.Errorf("[UNAUTHORIZED] OIDC provider error: %s, address=%s",
+			providerError, req.RemoteAddr)
+		jwtService.responseWriter.Write(w, req, http.StatusForbidden,
+			viewmodel.JsonWebToken{Error: providerError})
+		return
+	}
+	if params.Get("code") == "" || params.Get("state") == "" {
+		jwtService.responseWriter.Write(w, req, http.StatusBadRequest,
+			viewmodel.JsonWebToken{Error: "Authorization code and state required"})
+		return
+	}
+	binding := ""
+	if cookie, err := req.Cookie(common.OIDC_BINDING_COOKIE); err == nil {
+		binding = cookie.Value
+	}
+	SetOIDCBindingCookie(w, "", -1)
+	userService := jwtService.serviceRegistry.GetUserService()
+	userAccount, orgs, farms, err := userService.Login(&service.UserCredentials{
+		AuthType: common.AUTH_TYPE_OIDC,
+		Code:     params.Get("code"),
+		State:    params.Get("state"),
+		Binding:  binding})
+	if err != nil {
+		jwtService.app.Logger.Errorf("OIDC login error: %s", err)
+		jwtService.responseWriter.Write(w, req, http.StatusForbidden,
+			viewmodel.JsonWebToken{Error: "Invalid credentials"})
+		return
+	}
+	jwtService.writeToken(w, req, userAccount, orgs, farms)
+}
+
+// Signs a new JWT for the authenticated user and issues a refresh token
+// for the new login
+func (jwtService *JWTService) writeToken(w http.ResponseWriter, req *http.Request,
+	userAccount model.User, orgs []config.Organization, farms []config.Farm) {
+
 	if len(userAccount.GetRoles()) == 0 {
 		// Must be a new user that hasn't been assigned to any roles yet
 		userAccount.SetRoles([]model.Role{
@@ -268,7 +411,6 @@ func (jwtService *JWTService) GenerateToken(w http.ResponseWriter, req *http.Req
 				Name: jwtService.defaultRole.Name}})
 	}
 
-	jwtService.app.Logger.Debugf("user: %+v", user)
 	jwtService.app.Logger.Debugf("userAccount: %+v", userAccount)
 	jwtService.app.Logger.Debugf("orgs: %+v", orgs)
 	jwtService.app.Logger.Debugf("org.len: %+v", len(orgs))
@@ -291,10 +433,11 @@ func (jwtService *JWTService) GenerateToken(w http.ResponseWriter, req *http.Req
 			//Roles: roleClaims}
 		}
 		orgClaims[i] = service.OrganizationClaim{
-			ID:    org.Identifier(),
-			Name:  org.GetName(),
-			Farms: FarmClaims,
-			Roles: roleClaims}
+			ID:      org.Identifier(),
+			Name:    org.GetName(),
+			Farms:   FarmClaims,
+			Roles:   roleClaims,
+			License: jwtService.organizationLicense(org.Identifier())}
 	}
 	orgClaimsJson, err := json.Marshal(orgClaims)
 	if err != nil {
@@ -336,138 +479,207 @@ func (jwtService *JWTService) GenerateToken(w http.ResponseWriter, req *http.Req
 
 	jwtService.app.Logger.Debugf("Generated JSON token: %s", tokenString)
 
-	jwtViewModel := viewmodel.JsonWebToken{Value: tokenString}
+	refreshTokenService := jwtService.serviceRegistry.GetRefreshTokenService()
+	refreshToken, _, err := refreshTokenService.Issue(userAccount.Identifier(), req.RemoteAddr)
+	if err != nil {
+		jwtService.app.Logger.Errorf("Error issuing refresh token: %s", err)
+		jwtService.responseWriter.Write(w, req,
+			http.StatusInternalServerError, viewmodel.JsonWebToken{Error: "Error issuing refresh token"})
+		return
+	}
+
+	jwtViewModel := viewmodel.JsonWebToken{
+		Value:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    jwtService.expirationSeconds()}
 	jwtService.responseWriter.Write(w, req, http.StatusOK, jwtViewModel)
 }
 
+// RefreshTokenRequest carries the refresh token issued at login or by the
+// previous refresh
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Exchanges a refresh token for a new access token and refresh token. Each
+// refresh token may only be used once; reusing a refresh token revokes every
+// token issued since the user logged in.
 func (jwtService *JWTService) RefreshToken(w http.ResponseWriter, req *http.Request) {
 
 	jwtService.app.Logger.Debugf("url: %s, method: %s, remoteAddress: %s, requestUri: %s",
 		req.URL.Path, req.Method, req.RemoteAddr, req.RequestURI)
 
-	token, claims, err := jwtService.parseToken(w, req)
-	if err == nil {
-		if token.Valid {
-			userService := jwtService.serviceRegistry.GetUserService()
-			userAccount, orgs, farms, err := userService.Refresh(claims.UserID)
-			if err != nil {
-				jwtService.app.Logger.Errorf("Error refreshing token: %s", err)
-				jwtService.responseWriter.Write(w, req, http.StatusUnauthorized,
-					viewmodel.JsonWebToken{Error: "Invalid token"})
-				return
-			}
+	var refreshRequest RefreshTokenRequest
+	if err := json.NewDecoder(req.Body).Decode(&refreshRequest); err != nil || refreshRequest.RefreshToken == "" {
+		jwtService.responseWriter.Write(w, req, http.StatusBadRequest,
+			viewmodel.JsonWebToken{Error: "Refresh token required"})
+		return
+	}
 
-			if len(userAccount.GetRoles()) == 0 {
-				// Must be a new user
-				userAccount.SetRoles([]model.Role{
-					&model.RoleStruct{
-						ID:   jwtService.defaultRole.ID,
-						Name: jwtService.defaultRole.Name}})
-			}
+	// The account is loaded before the refresh token is used up so a failed
+	// refresh can be retried with the same token
+	var userAccount model.User
+	var orgs []config.Organization
+	var farms []config.Farm
+	userService := jwtService.serviceRegistry.GetUserService()
+	refreshTokenService := jwtService.serviceRegistry.GetRefreshTokenService()
+	newRefreshToken, _, err := refreshTokenService.Rotate(
+		refreshRequest.RefreshToken, req.RemoteAddr, func(userID uint64) error {
+			var err error
+			userAccount, orgs, farms, err = userService.Refresh(userID)
+			return err
+		})
+	if err != nil {
+		jwtService.app.Logger.Errorf("Error refreshing token: %s", err)
+		jwtService.responseWriter.Write(w, req, http.StatusUnauthorized,
+			viewmodel.JsonWebToken{Error: "Invalid token"})
+		return
+	}
 
-			roleClaims := make([]string, len(userAccount.GetRoles()))
-			for j, role := range userAccount.GetRoles() {
-				roleClaims[j] = role.GetName()
-			}
+	if len(userAccount.GetRoles()) == 0 {
+		// Must be a new user
+		userAccount.SetRoles([]model.Role{
+			&model.RoleStruct{
+				ID:   jwtService.defaultRole.ID,
+				Name: jwtService.defaultRole.Name}})
+	}
+
+	roleClaims := make([]string, len(userAccount.GetRoles()))
+	for j, role := range userAccount.GetRoles() {
+		roleClaims[j] = role.GetName()
+	}
+
+	orgClaims := make([]*service.OrganizationClaim, len(orgs))
+	for i, org := range orgs {
+		FarmClaims := make([]service.FarmClaim, len(org.GetFarms()))
+		for j, farm := range org.GetFarms() {
 
-			orgClaims := make([]*service.OrganizationClaim, len(orgs))
-			for i, org := range orgs {
-				FarmClaims := make([]service.FarmClaim, len(org.GetFarms()))
-				for j, farm := range org.GetFarms() {
-
-					roles := make([]string, 0)
-					for _, user := range farm.GetUsers() {
-						if user.ID == userAccount.Identifier() {
-							for _, role := range user.GetRoles() {
-								roles = append(roles, role.GetName())
-							}
-						}
+			roles := make([]string, 0)
+			for _, user := range farm.GetUsers() {
+				if user.ID == userAccount.Identifier() {
+					for _, role := range user.GetRoles() {
+						roles = append(roles, role.GetName())
 					}
-					FarmClaims[j] = service.FarmClaim{
-						ID:    farm.Identifier(),
-						Name:  farm.GetName(),
-						Roles: roles}
 				}
-				orgClaims[i] = &service.OrganizationClaim{
-					ID:    org.Identifier(),
-					Name:  org.GetName(),
-					Farms: FarmClaims,
-					Roles: roleClaims}
-			}
-			orgClaimsJson, err := json.Marshal(orgClaims)
-			if err != nil {
-				jwtService.responseWriter.Write(w, req, http.StatusInternalServerError,
-					viewmodel.JsonWebToken{Error: "Error marshaling organization"})
-				return
 			}
+			FarmClaims[j] = service.FarmClaim{
+				ID:    farm.Identifier(),
+				Name:  farm.GetName(),
+				Roles: roles}
+		}
+		orgClaims[i] = &service.OrganizationClaim{
+			ID:      org.Identifier(),
+			Name:    org.GetName(),
+			Farms:   FarmClaims,
+			Roles:   roleClaims,
+			License: jwtService.organizationLicense(org.Identifier())}
+	}
+	orgClaimsJson, err := json.Marshal(orgClaims)
+	if err != nil {
+		jwtService.responseWriter.Write(w, req, http.StatusInternalServerError,
+			viewmodel.JsonWebToken{Error: "Error marshaling organization"})
+		return
+	}
 
-			FarmClaims := make([]service.FarmClaim, len(farms))
-			for i, farm := range farms {
-				roles := make([]string, 0)
-				for _, user := range farm.GetUsers() {
-					if user.ID == userAccount.Identifier() {
-						for _, role := range user.GetRoles() {
-							roles = append(roles, role.GetName())
-						}
-					}
+	FarmClaims := make([]service.FarmClaim, len(farms))
+	for i, farm := range farms {
+		roles := make([]string, 0)
+		for _, user := range farm.GetUsers() {
+			if user.ID == userAccount.Identifier() {
+				for _, role := range user.GetRoles() {
+					roles = append(roles, role.GetName())
 				}
-				FarmClaims[i] = service.FarmClaim{
-					ID:    farm.Identifier(),
-					Name:  farm.GetName(),
-					Roles: roles}
-			}
-			FarmClaimsJson, err := json.Marshal(FarmClaims)
-			if err != nil {
-				jwtService.responseWriter.Write(w, req, http.StatusInternalServerError,
-					viewmodel.JsonWebToken{Error: "Error marshaling farms"})
-				return
 			}
+		}
+		FarmClaims[i] = service.FarmClaim{
+			ID:    farm.Identifier(),
+			Name:  farm.GetName(),
+			Roles: roles}
+	}
+	FarmClaimsJson, err := json.Marshal(FarmClaims)
+	if err != nil {
+		jwtService.responseWriter.Write(w, req, http.StatusInternalServerError,
+			viewmodel.JsonWebToken{Error: "Error marshaling farms"})
+		return
+	}
 
-			token := jwt.NewWithClaims(jwt.SigningMethodRS256, JsonWebTokenClaims{
-				ServerID:      int(jwtService.app.NodeID),
-				UserID:        userAccount.Identifier(),
-				Email:         userAccount.GetEmail(),
-				Organizations: string(orgClaimsJson),
-				Farms:         string(FarmClaimsJson),
-				StandardClaims: jwt.StandardClaims{
-					Issuer:    common.APPNAME,
-					IssuedAt:  time.Now().Unix(),
-					ExpiresAt: time.Now().Add(time.Minute * jwtService.expiration).Unix()}})
-
-			tokenString, err := token.SignedString(jwtService.privateKey())
-			if err != nil {
-				jwtService.responseWriter.Write(w, req, http.StatusInternalServerError,
-					viewmodel.JsonWebToken{Error: "Error signing token"})
-				return
-			}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, JsonWebTokenClaims{
+		ServerID:      int(jwtService.app.NodeID),
+		UserID:        userAccount.Identifier(),
+		Email:         userAccount.GetEmail(),
+		Organizations: string(orgClaimsJson),
+		Farms:         string(FarmClaimsJson),
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    common.APPNAME,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(time.Minute * jwtService.expiration).Unix()}})
 
-			jwtService.app.Logger.Debugf("Refreshed JSON token: %s", tokenString)
+	tokenString, err := token.SignedString(jwtService.privateKey())
+	if err != nil {
+		jwtService.responseWriter.Write(w, req, http.StatusInternalServerError,
+			viewmodel.JsonWebToken{Error: "Error signing token"})
+		return
+	}
 
-			tokenDTO := viewmodel.JsonWebToken{Value: tokenString}
-			jwtService.responseWriter.Write(w, req, http.StatusOK, tokenDTO)
+	jwtService.app.Logger.Debugf("Refreshed JSON token: %s", tokenString)
 
-		} else {
-			jwtService.app.Logger.Errorf("Invalid token: %s", token.Raw)
-			jwtService.responseWriter.Write(w, req, http.StatusUnauthorized,
-				viewmodel.JsonWebToken{Error: "Invalid token"})
-		}
-	} else {
-		errmsg := err.Error()
-		if errmsg == "no token present in request" {
-			errmsg = "Authentication required"
-		}
-		jwtService.app.Logger.Errorf("Error: %s", errmsg)
-		http.Error(w, errmsg, http.StatusBadRequest)
+	tokenDTO := viewmodel.JsonWebToken{
+		Value:        tokenString,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    jwtService.expirationSeconds()}
+	jwtService.responseWriter.Write(w, req, http.StatusOK, tokenDTO)
+}
+
+// Ends the login the refresh token belongs to by revoking the refresh token
+// and every other token issued from the same login
+func (jwtService *JWTService) Logout(w http.ResponseWriter, req *http.Request) {
+
+	jwtService.app.Logger.Debugf("url: %s, method: %s, remoteAddress: %s, requestUri: %s",
+		req.URL.Path, req.Method, req.RemoteAddr, req.RequestURI)
+
+	var refreshRequest RefreshTokenRequest
+	if err := json.NewDecoder(req.Body).Decode(&refreshRequest); err != nil || refreshRequest.RefreshToken == "" {
+		jwtService.responseWriter.Write(w, req, http.StatusBadRequest,
+			viewmodel.JsonWebToken{Error: "Refresh token required"})
+		return
+	}
+	refreshTokenService := jwtService.serviceRegistry.GetRefreshTokenService()
+	if err := refreshTokenService.Revoke(refreshRequest.RefreshToken); err != nil {
+		jwtService.app.Logger.Errorf("Logout error: %s", err)
+		jwtService.responseWriter.Write(w, req, http.StatusUnauthorized,
+			viewmodel.JsonWebToken{Error: "Invalid token"})
+		return
 	}
+	jwtService.responseWriter.Write(w, req, http.StatusOK, viewmodel.JsonWebToken{})
+}
+
+// Returns the number of seconds an access token is valid
+func (jwtService *JWTService) expirationSeconds() int64 {
+	return int64((time.Minute * jwtService.expiration).Seconds())
 }
 
 // Validates the raw JWT token to ensure it's not expired or contains any invalid claims. This
 // is used by the negroni middleware to enforce authenticated access to procted resources.
+// API keys are accepted in place of a JWT as long as they are neither revoked nor expired.
+// The authenticated key is passed down the chain in the request context so the session
+// can be created without authenticating the key again.
 func (jwtService *JWTService) Validate(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 
 	jwtService.app.Logger.Debugf("url: %s, method: %s, remoteAddress: %s, requestUri: %s",
 		r.URL.Path, r.Method, r.RemoteAddr, r.RequestURI)
 
+	if token, ok := jwtService.apiKeyToken(r); ok {
+		apiKey, err := jwtService.serviceRegistry.GetAPIKeyService().Authenticate(token, r.RemoteAddr)
+		if err != nil {
+			jwtService.app.Logger.Errorf("[UNAUTHORIZED] API key authentication failed: %s, url=%s",
+				err, r.URL.Path)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, apiKey)))
+		return
+	}
+
 	token, claims, err := jwtService.parseToken(w, r)
 	if err == nil {
 		if token.Valid {
@@ -500,6 +712,224 @@ func (jwtService *JWTService) Validate(w http.ResponseWriter, r *http.Request, n
 	}
 }
 
+// Returns middleware that authorizes the session created from the request JWT
+// for the specified permission, rejecting the request with 403 Forbidden if
+// none of the user's roles grant the permission.
+func (jwtService *JWTService) Authorize(permission string) func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		session, err := jwtService.CreateSession(w, r)
+		if err != nil {
+			jwtService.responseWriter.Error403(w, r, err, nil)
+			return
+		}
+		defer session.Close()
+		if !session.HasPermission(permission) {
+			jwtService.app.Logger.Errorf("[UNAUTHORIZED] Permission %s denied: user=%s, url=%s",
+				permission, session.GetUser().GetEmail(), r.URL.Path)
+			jwtService.responseWriter.Error403(w, r, service.ErrPermissionDenied, permission)
+			return
+		}
+		if err := jwtService.verifyTenant(session, r); err != nil {
+			jwtService.app.Logger.Errorf("[UNAUTHORIZED] Cross-tenant access attempt: user=%s, url=%s",
+				session.GetUser().GetEmail(), r.URL.Path)
+			jwtService.responseWriter.Error403(w, r, err, nil)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Returns service.ErrTenantAccessDenied if the request names a user outside
+// of the organizations and farms the session belongs to. Requests scoped to
+// an organization or farm only operate on the user's membership in the
+// organization or farm CreateSession already verified.
+func (jwtService *JWTService) verifyTenant(session service.Session, r *http.Request) error {
+	if session.GetRequestedOrganizationID() > 0 || session.GetRequestedFarmID() > 0 {
+		return nil
+	}
+	userID, err := strconv.ParseUint(mux.Vars(r)["userID"], 10, 64)
+	if err != nil {
+		return nil
+	}
+	userService := jwtService.serviceRegistry.GetUserService()
+	if userService == nil {
+		return nil
+	}
+	return userService.VerifyTenant(session, userID)
+}
+
+// Creates a web service session for the service account an API key is bound to.
+// The session is limited to the farms and permissions the key is scoped to. The key
+// is only authenticated here if Validate hasn't already authenticated the request.
+func (jwtService *JWTService) createServiceAccountSession(r *http.Request,
+	token string) (service.Session, error) {
+
+	apiKey, ok := r.Context().Value(apiKeyContextKey{}).(*entity.APIKey)
+	if !ok {
+		var err error
+		apiKey, err = jwtService.serviceRegistry.GetAPIKeyService().Authenticate(token, r.RemoteAddr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	params := mux.Vars(r)
+	if params["organizationID"] != "" {
+		return nil, errors.New("api keys are not authorized for organization resources")
+	}
+
+	requestedFarmID, _ := strconv.ParseUint(params["farmID"], 10, 64)
+	isFarmMember := false
+	farmClaims := make([]service.FarmClaim, 0)
+	for _, farmID := range apiKey.GetFarmIDs() {
+		if farmID == requestedFarmID {
+			isFarmMember = true
+		}
+		farmClaims = append(farmClaims, service.FarmClaim{
+			ID:    farmID,
+			Roles: []string{common.ROLE_SERVICE_ACCOUNT}})
+	}
+
+	email := fmt.Sprintf("%s@%s", apiKey.GetServiceAccount(), common.SERVICE_ACCOUNT_DOMAIN)
+	if !isFarmMember && requestedFarmID > 0 {
+		jwtService.app.Logger.Errorf("[UNAUTHORIZED] Unauthorized access attempt to farm: service account=%s, key=%d, farm=%d",
+			email, apiKey.ID, requestedFarmID)
+		return nil, errors.New("api key is not scoped to the requested farm, access request has been logged")
+	}
+
+	var consistencyLevel = common.CONSISTENCY_LOCAL
+	farmService := jwtService.serviceRegistry.GetFarmService(requestedFarmID)
+	if requestedFarmID > 0 {
+		if farmService == nil {
+			return nil, fmt.Errorf("farm not found: %d", requestedFarmID)
+		}
+		consistencyLevel = farmService.GetConfig().GetConsistencyLevel()
+	}
+
+	user := &model.UserStruct{
+		ID:    jwtService.idGenerator.NewStringID(email),
+		Email: email,
+		Roles: []model.Role{
+			&model.RoleStruct{
+				ID:          jwtService.idGenerator.NewStringID(common.ROLE_SERVICE_ACCOUNT),
+				Name:        common.ROLE_SERVICE_ACCOUNT,
+				Permissions: apiKey.GetPermissionList()}}}
+
+	session := service.CreateSession(jwtService.app.Logger, []service.OrganizationClaim{},
+		farmClaims, farmService, 0, requestedFarmID, consistencyLevel, user)
+	session.SetSessionID(fmt.Sprintf("%s_%d", common.API_KEY_PREFIX, apiKey.ID))
+
+	return session, nil
+}
+
+// Returns the rate limit key for the request: a digest of the API key, or
+// the user ID from a valid JWT. Unsigned or expired tokens aren't trusted
+// so they can't be used to exhaust another user's limit.
+func (jwtService *JWTService) Identify(r *http.Request) (string, bool) {
+	if apiKey, ok := jwtService.apiKeyToken(r); ok {
+		digest := sha256.Sum256([]byte(apiKey))
+		return "apikey:" + hex.EncodeToString(digest[:8]), true
+	}
+	if r.Header.Get("Authorization") == "" && r.URL.Query().Get("access_token") == "" {
+		return "", false
+	}
+	token, claims, err := jwtService.parseToken(nil, r)
+	if err != nil || !token.Valid || claims.UserID == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("user:%d", claims.UserID), true
+}
+
+// Returns the API key the request is authenticated with, if any. API keys are
+// accepted as a Bearer or ApiKey Authorization header, or as the access_token
+// query parameter for websocket connections.
+func (jwtService *JWTService) apiKeyToken(r *http.Request) (string, bool) {
+	if token := r.URL.Query().Get("access_token"); token != "" {
+		return token, service.IsAPIKey(token)
+	}
+	fields := strings.Fields(r.Header.Get("Authorization"))
+	if len(fields) == 2 && (strings.EqualFold(fields[0], "Bearer") ||
+		strings.EqualFold(fields[0], "ApiKey")) {
+
+		return fields[1], service.IsAPIKey(fields[1])
+	}
+	return "", false
+}
+
+// Returns the permissions granted by the named role
+func (jwtService *JWTService) rolePermissions(roleName string) []string {
+	if roleName != common.ROLE_ADMIN {
+		role, err := jwtService.serviceRegistry.GetRoleService().GetByName(
+			roleName, common.CONSISTENCY_LOCAL)
+		if err == nil {
+			return service.RolePermissions(role)
+		}
+		jwtService.app.Logger.Warningf("Unable to load role %s: %s", roleName, err)
+	}
+	return service.RolePermissions(&config.RoleStruct{Name: roleName})
+}
+
+// Returns the permissions a session is granted by the named role. The
+// system administration permission is only granted to system administrators.
+func (jwtService *JWTService) sessionPermissions(roleName string, systemAdmin bool) []string {
+	rolePermissions := jwtService.rolePermissions(roleName)
+	if systemAdmin {
+		return rolePermissions
+	}
+	permissions := make([]string, 0, len(rolePermissions))
+	for _, permission := range rolePermissions {
+		if permission != common.PERMISSION_SYSTEM_ADMIN {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// Returns true if the token was issued to the default user, who administers
+// the system
+func (jwtService *JWTService) isSystemAdmin(claims *JsonWebTokenClaims) bool {
+	return claims.Email == common.DEFAULT_USER &&
+		claims.UserID == jwtService.idGenerator.NewStringID(common.DEFAULT_USER)
+}
+
+// Returns the roles the user has been assigned within the farm and true if
+// the user is a member of the farm
+func (jwtService *JWTService) farmRoles(farmConfig config.Farm, email string) ([]*config.RoleStruct, bool) {
+	for _, user := range farmConfig.GetUsers() {
+		if user.GetEmail() == email {
+			return user.GetRoles(), true
+		}
+	}
+	return nil, false
+}
+
+// Converts a list of role names from an organization or farm claim to roles
+func (jwtService *JWTService) claimRoles(roleNames []string) []*config.RoleStruct {
+	roles := make([]*config.RoleStruct, len(roleNames))
+	for i, roleName := range roleNames {
+		roles[i] = &config.RoleStruct{
+			ID:   jwtService.idGenerator.NewStringID(roleName),
+			Name: roleName}
+	}
+	return roles
+}
+
+// Returns the organization's license quotas to embed in the organization
+// claim. Unlicensed organizations and licenses that can't be verified are
+// returned with an empty license.
+func (jwtService *JWTService) organizationLicense(orgID uint64) config.OrganizationLicenseStruct {
+	licenseService := jwtService.serviceRegistry.GetLicenseService()
+	if licenseService == nil {
+		return config.OrganizationLicenseStruct{OrganizationID: orgID}
+	}
+	license, err := licenseService.GetOrganizationLicense(orgID)
+	if err != nil {
+		jwtService.app.Logger.Warningf("Unable to load license for organization %d: %s", orgID, err)
+		return config.OrganizationLicenseStruct{OrganizationID: orgID}
+	}
+	return *license
+}
+
 // Used to determine if the specified organization is a member of any of the specified OrganizationClaims
 func (jwtService *JWTService) isOrgMember(orgClaims []service.OrganizationClaim, orgID uint64) bool {
 	for _, org := range orgClaims {
@@ -551,6 +981,11 @@ func (jwtService *JWTService) parseClaims(r *http.Request, extractor request.Ext
 	if err != nil {
 		return nil, nil, err
 	}
+	// Tokens issued for another audience, such as password reset
+	// tokens, can't be used as access tokens
+	if claims.Audience != "" {
+		return nil, nil, fmt.Errorf("unexpected token audience: %s", claims.Audience)
+	}
 	jwtService.app.Logger.Debugf("claims: %+v", claims)
 	return token, claims, nil
 }
@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type LicenseRestServicer interface {
+	Usage(w http.ResponseWriter, r *http.Request)
+	IssueServer(w http.ResponseWriter, r *http.Request)
+	IssueOrganization(w http.ResponseWriter, r *http.Request)
+	IssueFarm(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type LicenseRestService struct {
+	licenseService service.LicenseService
+	middleware     middleware.JsonWebTokenMiddleware
+	httpWriter     response.HttpWriter
+	LicenseRestServicer
+}
+
+func NewLicenseRestService(
+	licenseService service.LicenseService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) LicenseRestServicer {
+
+	return &LicenseRestService{
+		licenseService: licenseService,
+		middleware:     middleware,
+		httpWriter:     httpWriter}
+}
+
+// Returns the license consumption of the requested farm or organization,
+// or the server when neither is requested
+func (restService *LicenseRestService) Usage(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	usage, err := restService.licenseService.Usage(session)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, usage)
+}
+
+// Issues the server license
+func (restService *LicenseRestService) IssueServer(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var license config.ServerLicenseStruct
+	if err := json.NewDecoder(r.Body).Decode(&license); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	issued, err := restService.licenseService.IssueServerLicense(session, &license)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, issued)
+}
+
+// Issues an organization license
+func (restService *LicenseRestService) IssueOrganization(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var license config.OrganizationLicenseStruct
+	if err := json.NewDecoder(r.Body).Decode(&license); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	issued, err := restService.licenseService.IssueOrganizationLicense(session, &license)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, issued)
+}
+
+// Issues a farm license
+func (restService *LicenseRestService) IssueFarm(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var license config.FarmLicenseStruct
+	if err := json.NewDecoder(r.Body).Decode(&license); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	issued, err := restService.licenseService.IssueFarmLicense(session, &license)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, issued)
+}
@@ -0,0 +1,120 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type MFARestServicer interface {
+	Enroll(w http.ResponseWriter, r *http.Request)
+	Confirm(w http.ResponseWriter, r *http.Request)
+	Disable(w http.ResponseWriter, r *http.Request)
+	Reset(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type MFARestService struct {
+	mfaService service.MFAService
+	middleware middleware.JsonWebTokenMiddleware
+	httpWriter response.HttpWriter
+	MFARestServicer
+}
+
+// MFACode is a TOTP code from the user's authenticator app, or a recovery code
+type MFACode struct {
+	Code string `json:"code"`
+}
+
+func NewMFARestService(
+	mfaService service.MFAService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) MFARestServicer {
+
+	return &MFARestService{
+		mfaService: mfaService,
+		middleware: middleware,
+		httpWriter: httpWriter}
+}
+
+// Starts TOTP enrollment for the session user
+func (restService *MFARestService) Enroll(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	enrollment, err := restService.mfaService.Enroll(session)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, enrollment)
+}
+
+// Confirms the session user's pending TOTP enrollment
+func (restService *MFARestService) Confirm(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var code MFACode
+	if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.mfaService.Confirm(session, code.Code); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
+
+// Disables MFA for the session user
+func (restService *MFARestService) Disable(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var code MFACode
+	if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.mfaService.Disable(session, code.Code); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
+
+// Resets a user's MFA enrollment
+func (restService *MFARestService) Reset(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	params := mux.Vars(r)
+	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.mfaService.Reset(session, userID); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
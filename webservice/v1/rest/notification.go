@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type NotificationRestServicer interface {
+	Acknowledge(w http.ResponseWriter, r *http.Request)
+	Unacknowledged(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type NotificationRestService struct {
+	notificationService service.NotificationServicer
+	middleware          middleware.JsonWebTokenMiddleware
+	httpWriter          response.HttpWriter
+	NotificationRestServicer
+}
+
+func NewNotificationRestService(
+	notificationService service.NotificationServicer,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) NotificationRestServicer {
+
+	return &NotificationRestService{
+		notificationService: notificationService,
+		middleware:          middleware,
+		httpWriter:          httpWriter}
+}
+
+// Acknowledges a high priority notification, stopping escalation
+func (restService *NotificationRestService) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	params := mux.Vars(r)
+	notificationID, err := strconv.ParseUint(params["notificationID"], 10, 64)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.notificationService.Acknowledge(session, notificationID); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
+
+// Returns the high priority notifications waiting to be acknowledged
+func (restService *NotificationRestService) Unacknowledged(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	restService.httpWriter.Success200(w, r,
+		restService.notificationService.GetUnacknowledged(session))
+}
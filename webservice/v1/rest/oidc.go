@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type OIDCRestServicer interface {
+	Login(w http.ResponseWriter, r *http.Request)
+	Link(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type OIDCRestService struct {
+	oidcAuthService service.OIDCAuthServicer
+	middleware      middleware.JsonWebTokenMiddleware
+	httpWriter      response.HttpWriter
+	OIDCRestServicer
+}
+
+// OIDCLinkResponse is the provider URL the signed in user's browser is sent
+// to in order to link their account to their provider identity
+type OIDCLinkResponse struct {
+	URL string `json:"url"`
+}
+
+func NewOIDCRestService(
+	oidcAuthService service.OIDCAuthServicer,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) OIDCRestServicer {
+
+	return &OIDCRestService{
+		oidcAuthService: oidcAuthService,
+		middleware:      middleware,
+		httpWriter:      httpWriter}
+}
+
+// Redirects the user to the OIDC provider to log in. The provider
+// redirects back to the callback endpoint with an authorization code.
+func (restService *OIDCRestService) Login(w http.ResponseWriter, r *http.Request) {
+	url, binding, err := restService.oidcAuthService.AuthCodeURL(0)
+	if err != nil {
+		restService.httpWriter.Error500(w, r, err)
+		return
+	}
+	SetOIDCBindingCookie(w, binding, common.OIDC_STATE_EXPIRATION)
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// Returns the OIDC provider URL that links the signed in user's account to
+// their provider identity once they log in at the provider
+func (restService *OIDCRestService) Link(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	url, binding, err := restService.oidcAuthService.AuthCodeURL(session.GetUser().Identifier())
+	if err != nil {
+		restService.httpWriter.Error500(w, r, err)
+		return
+	}
+	SetOIDCBindingCookie(w, binding, common.OIDC_STATE_EXPIRATION)
+	restService.httpWriter.Success200(w, r, OIDCLinkResponse{URL: url})
+}
+
+// Sets the cookie that binds an OIDC login to the browser that started it.
+// A negative maxAge deletes the cookie.
+func SetOIDCBindingCookie(w http.ResponseWriter, binding string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     common.OIDC_BINDING_COOKIE,
+		Value:    binding,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode})
+}
@@ -21,6 +21,7 @@ type OrganizationRestServicer interface {
 	Delete(w http.ResponseWriter, r *http.Request)
 	Page(w http.ResponseWriter, r *http.Request)
 	GetUsers(w http.ResponseWriter, r *http.Request)
+	SetMFARequired(w http.ResponseWriter, r *http.Request)
 	RestService
 }
 
@@ -67,6 +68,9 @@ func (restService *OrganizationRestService) Page(w http.ResponseWriter, r *http.
 		restService.httpWriter.Error500(w, r, err)
 		return
 	}
+	for i, org := range orgs.Entities {
+		orgs.Entities[i] = org.Redact()
+	}
 	restService.httpWriter.Success200(w, r, orgs)
 }
 
@@ -108,6 +112,30 @@ func (restService *OrganizationRestService) GetUsers(w http.ResponseWriter, r *h
 	restService.httpWriter.Success200(w, r, users)
 }
 
+// MFAPolicy sets whether organization members must log in with a second factor
+type MFAPolicy struct {
+	Required bool `json:"required"`
+}
+
+func (restService *OrganizationRestService) SetMFARequired(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	var policy MFAPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err = restService.orgService.SetMFARequired(session, policy.Required); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, policy)
+}
+
 func (restService *OrganizationRestService) Delete(w http.ResponseWriter, r *http.Request) {
 	session, err := restService.middleware.CreateSession(w, r)
 	if err != nil {
@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type PasswordRestServicer interface {
+	Forgot(w http.ResponseWriter, r *http.Request)
+	Reset(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type PasswordRestService struct {
+	passwordResetService service.PasswordResetService
+	httpWriter           response.HttpWriter
+	PasswordRestServicer
+}
+
+// ForgotPasswordRequest requests a password reset link for a local account
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest sets a new password using the token from a
+// password reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func NewPasswordRestService(
+	passwordResetService service.PasswordResetService,
+	httpWriter response.HttpWriter) PasswordRestServicer {
+
+	return &PasswordRestService{
+		passwordResetService: passwordResetService,
+		httpWriter:           httpWriter}
+}
+
+// Emails a password reset link to the user. The response is the same
+// whether or not the account exists.
+func (restService *PasswordRestService) Forgot(w http.ResponseWriter, r *http.Request) {
+	var request ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Email == "" {
+		restService.httpWriter.Error400(w, r, service.ErrInvalidEmailAddress)
+		return
+	}
+	if err := restService.passwordResetService.RequestReset(request.Email); err != nil {
+		restService.httpWriter.Error500(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
+
+// Sets a new password using a password reset token
+func (restService *PasswordRestService) Reset(w http.ResponseWriter, r *http.Request) {
+	var request ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.passwordResetService.Reset(request.Token, request.Password); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
@@ -51,7 +51,7 @@ func (restService *ProvisionerRestService) Provision(w http.ResponseWriter, r *h
 	}
 	defer session.Close()
 	params := mux.Vars(r)
-	orgID, err := strconv.ParseUint(params["orgId"], 10, 64)
+	orgID, err := strconv.ParseUint(params["organizationID"], 10, 64)
 	if err != nil {
 		restService.httpWriter.Error400(w, r, err)
 		return
@@ -77,7 +77,7 @@ func (restService *ProvisionerRestService) Provision(w http.ResponseWriter, r *h
 		restService.httpWriter.Error400(w, r, err)
 		return
 	}
-	restService.httpWriter.Success200(w, r, farmConfig)
+	restService.httpWriter.Success200(w, r, farmConfig.Redact())
 }
 
 func (restService *ProvisionerRestService) DeProvision(w http.ResponseWriter, r *http.Request) {
@@ -38,6 +38,8 @@ type RestRegistry struct {
 	farmsMutex               *sync.RWMutex
 	notificationHubs         map[uint64]*websocket.NotificationHub
 	notificationsMutex       *sync.RWMutex
+	eventLogHubs             map[uint64]*websocket.EventLogHub
+	eventLogsMutex           *sync.RWMutex
 	endpointList             *[]string
 	RestServiceRegistry
 }
@@ -57,6 +59,8 @@ func NewRestServiceRegistry(
 		farmsMutex:         &sync.RWMutex{},
 		notificationHubs:   make(map[uint64]*websocket.NotificationHub, 0),
 		notificationsMutex: &sync.RWMutex{},
+		eventLogHubs:       make(map[uint64]*websocket.EventLogHub, 0),
+		eventLogsMutex:     &sync.RWMutex{},
 		endpointList:       &endpointList}
 
 	registry.createJsonWebTokenService(roleDAO)
@@ -114,6 +118,8 @@ func NewRestServiceRegistry(
 		registry.farmsMutex,
 		registry.notificationHubs,
 		registry.notificationsMutex,
+		registry.eventLogHubs,
+		registry.eventLogsMutex,
 		serviceRegistry,
 		registry.jsonWebTokenService,
 		httpWriter)
@@ -246,7 +252,7 @@ func (registry *RestRegistry) createJsonWebTokenService(roleDAO dao.RoleDAO) {
 	}
 	jsonWebTokenService, err := CreateJsonWebTokenService(registry.app,
 		registry.app.IdGenerator, defaultRole, registry.mapperRegistry.GetDeviceMapper(),
-		registry.serviceRegistry, httpWriter, registry.app.WebService.JWTExpiration) // 1 year jwt expiration
+		registry.serviceRegistry, httpWriter, registry.app.WebService.JWTExpiration)
 	if err != nil {
 		registry.app.Logger.Fatal(err)
 	}
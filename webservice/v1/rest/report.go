@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type ReportRestServicer interface {
+	Get(w http.ResponseWriter, r *http.Request)
+	Text(w http.ResponseWriter, r *http.Request)
+	Send(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type ReportRestService struct {
+	reportService service.ReportService
+	middleware    middleware.JsonWebTokenMiddleware
+	httpWriter    response.HttpWriter
+	ReportRestServicer
+}
+
+func NewReportRestService(
+	reportService service.ReportService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) ReportRestServicer {
+
+	return &ReportRestService{
+		reportService: reportService,
+		middleware:    middleware,
+		httpWriter:    httpWriter}
+}
+
+// Generates the daily or weekly farm summary report on demand
+func (restService *ReportRestService) Get(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	report, err := restService.reportService.GetReport(session, mux.Vars(r)["period"])
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, report)
+}
+
+// Generates the daily or weekly farm summary report on demand as plain text
+func (restService *ReportRestService) Text(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	report, err := restService.reportService.GetReport(session, mux.Vars(r)["period"])
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(report.Text()))
+}
+
+// Generates the daily or weekly farm summary report and delivers it
+// to the farm's users through the notification system
+func (restService *ReportRestService) Send(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	report, err := restService.reportService.SendReport(session, mux.Vars(r)["period"])
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, report)
+}
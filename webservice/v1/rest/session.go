@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type SessionRestServicer interface {
+	RevokeUser(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type SessionRestService struct {
+	refreshTokenService service.RefreshTokenService
+	middleware          middleware.JsonWebTokenMiddleware
+	httpWriter          response.HttpWriter
+	SessionRestServicer
+}
+
+// RevokedSessions is the number of refresh tokens revoked for a user
+type RevokedSessions struct {
+	UserID  uint64 `json:"user_id"`
+	Revoked int    `json:"revoked"`
+}
+
+func NewSessionRestService(
+	refreshTokenService service.RefreshTokenService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) SessionRestServicer {
+
+	return &SessionRestService{
+		refreshTokenService: refreshTokenService,
+		middleware:          middleware,
+		httpWriter:          httpWriter}
+}
+
+// Revokes all of a user's refresh tokens, signing the user out of every
+// device once their current access tokens expire
+func (restService *SessionRestService) RevokeUser(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	params := mux.Vars(r)
+	userID, err := strconv.ParseUint(params["userID"], 10, 64)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	revoked, err := restService.refreshTokenService.RevokeUser(session, userID)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, RevokedSessions{UserID: userID, Revoked: revoked})
+}
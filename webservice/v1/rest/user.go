@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+)
+
+type UserRestServicer interface {
+	Disable(w http.ResponseWriter, r *http.Request)
+	Enable(w http.ResponseWriter, r *http.Request)
+	RestService
+}
+
+type UserRestService struct {
+	userService service.UserServicer
+	middleware  middleware.JsonWebTokenMiddleware
+	httpWriter  response.HttpWriter
+	UserRestServicer
+}
+
+func NewUserRestService(
+	userService service.UserServicer,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) UserRestServicer {
+
+	return &UserRestService{
+		userService: userService,
+		middleware:  middleware,
+		httpWriter:  httpWriter}
+}
+
+// Disables a user account
+func (restService *UserRestService) Disable(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	userID, err := strconv.ParseUint(mux.Vars(r)["userID"], 10, 64)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.userService.Disable(session, userID); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
+
+// Enables a disabled or locked user account
+func (restService *UserRestService) Enable(w http.ResponseWriter, r *http.Request) {
+	session, err := restService.middleware.CreateSession(w, r)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+	userID, err := strconv.ParseUint(mux.Vars(r)["userID"], 10, 64)
+	if err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	if err := restService.userService.Enable(session, userID); err != nil {
+		restService.httpWriter.Error400(w, r, err)
+		return
+	}
+	restService.httpWriter.Success200(w, r, nil)
+}
@@ -60,17 +60,31 @@ func (v1Router *RouterV1) RegisterRoutes(router *mux.Router, baseURI string) []s
 	endpointList = append(endpointList, v1Router.registrationRoutes()...)
 	endpointList = append(endpointList, v1Router.authenticationRoutes()...)
 	endpointList = append(endpointList, v1Router.farmRoutes()...)
+	endpointList = append(endpointList, v1Router.alarmRoutes()...)
+	endpointList = append(endpointList, v1Router.apiKeyRoutes()...)
 	endpointList = append(endpointList, v1Router.algorithmRoutes()...)
+	endpointList = append(endpointList, v1Router.calibrationRoutes()...)
 	endpointList = append(endpointList, v1Router.channelRoutes()...)
 	endpointList = append(endpointList, v1Router.conditionRoutes()...)
 	endpointList = append(endpointList, v1Router.deviceRoutes()...)
+	endpointList = append(endpointList, v1Router.emailRoutes()...)
 	endpointList = append(endpointList, v1Router.googleRoutes()...)
+	endpointList = append(endpointList, v1Router.inboxRoutes()...)
+	endpointList = append(endpointList, v1Router.invitationRoutes()...)
+	endpointList = append(endpointList, v1Router.licenseRoutes()...)
 	endpointList = append(endpointList, v1Router.metricRoutes()...)
+	endpointList = append(endpointList, v1Router.mfaRoutes()...)
+	endpointList = append(endpointList, v1Router.notificationRoutes()...)
+	endpointList = append(endpointList, v1Router.oidcRoutes()...)
 	endpointList = append(endpointList, v1Router.organizationRoutes()...)
+	endpointList = append(endpointList, v1Router.passwordRoutes()...)
 	endpointList = append(endpointList, v1Router.provisionerRoutes()...)
+	endpointList = append(endpointList, v1Router.reportRoutes()...)
 	endpointList = append(endpointList, v1Router.roleRoutes()...)
 	endpointList = append(endpointList, v1Router.scheduleRoutes()...)
+	endpointList = append(endpointList, v1Router.sessionRoutes()...)
 	endpointList = append(endpointList, v1Router.shoppingCartRoutes()...)
+	endpointList = append(endpointList, v1Router.userRoutes()...)
 	endpointList = append(endpointList, v1Router.workflowStepRoutes()...)
 	endpointList = append(endpointList, v1Router.workflowRoutes()...)
 	endpointList = append(endpointList, v1Router.eventLogRoutes()...)
@@ -89,17 +103,31 @@ func (v1Router *RouterV1) registerNonClusterRoutes(router *mux.Router, baseURI s
 	endpointList = append(endpointList, v1Router.registrationRoutes()...)
 	endpointList = append(endpointList, v1Router.authenticationRoutes()...)
 	endpointList = append(endpointList, v1Router.farmRoutes()...)
+	endpointList = append(endpointList, v1Router.alarmRoutes()...)
+	endpointList = append(endpointList, v1Router.apiKeyRoutes()...)
 	endpointList = append(endpointList, v1Router.algorithmRoutes()...)
+	endpointList = append(endpointList, v1Router.calibrationRoutes()...)
 	endpointList = append(endpointList, v1Router.channelRoutes()...)
 	endpointList = append(endpointList, v1Router.conditionRoutes()...)
 	endpointList = append(endpointList, v1Router.deviceRoutes()...)
+	endpointList = append(endpointList, v1Router.emailRoutes()...)
 	endpointList = append(endpointList, v1Router.googleRoutes()...)
+	endpointList = append(endpointList, v1Router.inboxRoutes()...)
+	endpointList = append(endpointList, v1Router.invitationRoutes()...)
+	endpointList = append(endpointList, v1Router.licenseRoutes()...)
 	endpointList = append(endpointList, v1Router.metricRoutes()...)
+	endpointList = append(endpointList, v1Router.mfaRoutes()...)
+	endpointList = append(endpointList, v1Router.notificationRoutes()...)
+	endpointList = append(endpointList, v1Router.oidcRoutes()...)
 	endpointList = append(endpointList, v1Router.organizationRoutes()...)
+	endpointList = append(endpointList, v1Router.passwordRoutes()...)
 	endpointList = append(endpointList, v1Router.provisionerRoutes()...)
+	endpointList = append(endpointList, v1Router.reportRoutes()...)
 	endpointList = append(endpointList, v1Router.roleRoutes()...)
 	endpointList = append(endpointList, v1Router.scheduleRoutes()...)
+	endpointList = append(endpointList, v1Router.sessionRoutes()...)
 	endpointList = append(endpointList, v1Router.shoppingCartRoutes()...)
+	endpointList = append(endpointList, v1Router.userRoutes()...)
 	endpointList = append(endpointList, v1Router.workflowStepRoutes()...)
 	endpointList = append(endpointList, v1Router.workflowRoutes()...)
 	endpointList = append(endpointList, v1Router.eventLogRoutes()...)
@@ -162,12 +190,37 @@ func (v1Router *RouterV1) farmRoutes() []string {
 	return farmRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
 }
 
+func (v1Router *RouterV1) alarmRoutes() []string {
+	alarmRouter := router.NewAlarmRouter(
+		v1Router.serviceRegistry.GetAlarmService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return alarmRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
+}
+
+func (v1Router *RouterV1) apiKeyRoutes() []string {
+	apiKeyRouter := router.NewAPIKeyRouter(
+		v1Router.serviceRegistry.GetAPIKeyService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return apiKeyRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
 func (v1Router *RouterV1) algorithmRoutes() []string {
 	algorithmRouter := router.NewAlgorithmRouter(
-		v1Router.restServiceRegistry.AlgorithmRestService())
+		v1Router.restServiceRegistry.AlgorithmRestService(),
+		v1Router.jsonWebTokenMiddleware)
 	return algorithmRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
 }
 
+func (v1Router *RouterV1) calibrationRoutes() []string {
+	calibrationRouter := router.NewCalibrationRouter(
+		v1Router.serviceRegistry.GetCalibrationService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return calibrationRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
+}
+
 func (v1Router *RouterV1) channelRoutes() []string {
 	channelRouter := router.NewChannelRouter(
 		v1Router.serviceRegistry.GetChannelService(),
@@ -193,6 +246,15 @@ func (v1Router *RouterV1) deviceRoutes() []string {
 	return deviceRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
 }
 
+func (v1Router *RouterV1) emailRoutes() []string {
+	emailRouter := router.NewEmailRouter(
+		v1Router.serviceRegistry.GetEmailService(),
+		v1Router.serviceRegistry.GetEmailChangeService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return emailRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
 func (v1Router *RouterV1) googleRoutes() []string {
 	deviceRouter := router.NewGoogleRouter(
 		v1Router.serviceRegistry.GetGoogleAuthService(),
@@ -201,6 +263,30 @@ func (v1Router *RouterV1) googleRoutes() []string {
 	return deviceRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
 }
 
+func (v1Router *RouterV1) inboxRoutes() []string {
+	inboxRouter := router.NewInboxRouter(
+		v1Router.serviceRegistry.GetInboxService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return inboxRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
+func (v1Router *RouterV1) invitationRoutes() []string {
+	invitationRouter := router.NewInvitationRouter(
+		v1Router.serviceRegistry.GetInvitationService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return invitationRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
+func (v1Router *RouterV1) licenseRoutes() []string {
+	licenseRouter := router.NewLicenseRouter(
+		v1Router.serviceRegistry.GetLicenseService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return licenseRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
 func (v1Router *RouterV1) metricRoutes() []string {
 	metricRouter := router.NewMetricRouter(
 		v1Router.app.Logger,
@@ -210,6 +296,36 @@ func (v1Router *RouterV1) metricRoutes() []string {
 	return metricRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
 }
 
+func (v1Router *RouterV1) mfaRoutes() []string {
+	mfaRouter := router.NewMFARouter(
+		v1Router.serviceRegistry.GetMFAService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return mfaRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
+func (v1Router *RouterV1) notificationRoutes() []string {
+	notificationRouter := router.NewNotificationRouter(
+		v1Router.serviceRegistry.GetNotificationService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return notificationRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
+}
+
+// Registers the OpenID Connect endpoints when an OIDC provider is configured
+func (v1Router *RouterV1) oidcRoutes() []string {
+	oidcAuthService := v1Router.serviceRegistry.GetOIDCAuthService()
+	if oidcAuthService == nil {
+		return []string{}
+	}
+	oidcRouter := router.NewOIDCRouter(
+		oidcAuthService,
+		v1Router.jsonWebTokenMiddleware.(middleware.AuthMiddleware),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return oidcRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
 func (v1Router *RouterV1) organizationRoutes() []string {
 	orgRouter := router.NewOrganizationRouter(
 		v1Router.serviceRegistry.GetOrganizationService(),
@@ -218,6 +334,13 @@ func (v1Router *RouterV1) organizationRoutes() []string {
 	return orgRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
 }
 
+func (v1Router *RouterV1) passwordRoutes() []string {
+	passwordRouter := router.NewPasswordRouter(
+		v1Router.serviceRegistry.GetPasswordResetService(),
+		v1Router.responseWriter)
+	return passwordRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
 func (v1Router *RouterV1) provisionerRoutes() []string {
 	orgRouter := router.NewProvisionerRouter(
 		v1Router.app,
@@ -228,6 +351,14 @@ func (v1Router *RouterV1) provisionerRoutes() []string {
 	return orgRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
 }
 
+func (v1Router *RouterV1) reportRoutes() []string {
+	reportRouter := router.NewReportRouter(
+		v1Router.serviceRegistry.GetReportService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return reportRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
+}
+
 func (v1Router *RouterV1) roleRoutes() []string {
 	roleRouter := router.NewRoleRouter(
 		v1Router.serviceRegistry.GetRoleService(),
@@ -244,6 +375,14 @@ func (v1Router *RouterV1) scheduleRoutes() []string {
 	return scheduleRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
 }
 
+func (v1Router *RouterV1) sessionRoutes() []string {
+	sessionRouter := router.NewSessionRouter(
+		v1Router.serviceRegistry.GetRefreshTokenService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return sessionRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
 func (v1Router *RouterV1) shoppingCartRoutes() []string {
 	if v1Router.app.Stripe == nil {
 		return []string{}
@@ -265,6 +404,14 @@ func (v1Router *RouterV1) workflowStepRoutes() []string {
 	return workflowStepRouter.RegisterRoutes(v1Router.router, v1Router.baseFarmURI)
 }
 
+func (v1Router *RouterV1) userRoutes() []string {
+	userRouter := router.NewUserRouter(
+		v1Router.serviceRegistry.GetUserService(),
+		v1Router.jsonWebTokenMiddleware,
+		v1Router.responseWriter)
+	return userRouter.RegisterRoutes(v1Router.router, v1Router.baseURI)
+}
+
 func (v1Router *RouterV1) workflowRoutes() []string {
 	workflowRouter := router.NewWorkflowRouter(
 		v1Router.serviceRegistry.GetWorkflowService(),
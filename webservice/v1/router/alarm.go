@@ -0,0 +1,123 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type AlarmRouter struct {
+	middleware       middleware.JsonWebTokenMiddleware
+	alarmRestService rest.AlarmRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service alarm router
+func NewAlarmRouter(
+	alarmService service.AlarmService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &AlarmRouter{
+		middleware: middleware,
+		alarmRestService: rest.NewAlarmRestService(
+			alarmService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the alarm endpoints at the root of the farm (/api/v1/farms/{farmID})
+func (alarmRouter *AlarmRouter) RegisterRoutes(router *mux.Router, baseFarmURI string) []string {
+	alarmsBaseURI := fmt.Sprintf("%s/alarms", baseFarmURI)
+	return []string{
+		alarmRouter.list(router, alarmsBaseURI),
+		alarmRouter.open(router, alarmsBaseURI),
+		alarmRouter.acknowledge(router, alarmsBaseURI),
+		alarmRouter.shelve(router, alarmsBaseURI)}
+}
+
+// @Summary List alarms
+// @Description Returns all of the farm's alarms, most recently raised first
+// @Tags Alarm
+// @Produce  json
+// @Param	farmID	path	integer	true	"string valid"
+// @Success 200 {object} []entity.Alarm
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/alarms [get]
+// @Security JWT
+func (alarmRouter *AlarmRouter) list(router *mux.Router, alarmsBaseURI string) string {
+	router.Handle(alarmsBaseURI, negroni.New(
+		negroni.HandlerFunc(alarmRouter.middleware.Validate),
+		negroni.HandlerFunc(alarmRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
+		negroni.Wrap(http.HandlerFunc(alarmRouter.alarmRestService.List)),
+	)).Methods("GET")
+	return alarmsBaseURI
+}
+
+// @Summary List open alarms
+// @Description Returns the farm's active, acknowledged and shelved alarms
+// @Tags Alarm
+// @Produce  json
+// @Param	farmID	path	integer	true	"string valid"
+// @Success 200 {object} []entity.Alarm
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/alarms/open [get]
+// @Security JWT
+func (alarmRouter *AlarmRouter) open(router *mux.Router, alarmsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/open", alarmsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(alarmRouter.middleware.Validate),
+		negroni.HandlerFunc(alarmRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
+		negroni.Wrap(http.HandlerFunc(alarmRouter.alarmRestService.Open)),
+	)).Methods("GET")
+	return endpoint
+}
+
+// @Summary Acknowledge alarm
+// @Description Acknowledges an open alarm so it is no longer re-notified
+// @Tags Alarm
+// @Produce  json
+// @Param	farmID	path	integer	true	"string valid"
+// @Param	alarmID	path	integer	true	"string valid"
+// @Success 200 {object} entity.Alarm
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/alarms/{alarmID}/ack [post]
+// @Security JWT
+func (alarmRouter *AlarmRouter) acknowledge(router *mux.Router, alarmsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{alarmID}/ack", alarmsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(alarmRouter.middleware.Validate),
+		negroni.HandlerFunc(alarmRouter.middleware.Authorize(common.PERMISSION_ALARM_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(alarmRouter.alarmRestService.Acknowledge)),
+	)).Methods("POST")
+	return endpoint
+}
+
+// @Summary Shelve alarm
+// @Description Shelves an open alarm, suppressing notifications for the requested number of minutes
+// @Tags Alarm
+// @Accept  json
+// @Produce  json
+// @Param	farmID	path	integer					true	"string valid"
+// @Param	alarmID	path	integer					true	"string valid"
+// @Param	request	body	rest.AlarmShelveRequest	false	"Shelve duration"
+// @Success 200 {object} entity.Alarm
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/alarms/{alarmID}/shelve [post]
+// @Security JWT
+func (alarmRouter *AlarmRouter) shelve(router *mux.Router, alarmsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{alarmID}/shelve", alarmsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(alarmRouter.middleware.Validate),
+		negroni.HandlerFunc(alarmRouter.middleware.Authorize(common.PERMISSION_ALARM_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(alarmRouter.alarmRestService.Shelve)),
+	)).Methods("POST")
+	return endpoint
+}
@@ -2,19 +2,29 @@ package router
 
 import (
 	"fmt"
+	"net/http"
 
+	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
 )
 
 type AlgorithmRouter struct {
+	middleware           middleware.JsonWebTokenMiddleware
 	algorithmRestService rest.AlgorithmRestServicer
 	WebServiceRouter
 }
 
 // Creates a new web service algorithm router
-func NewAlgorithmRouter(algorithmRestService rest.AlgorithmRestServicer) WebServiceRouter {
-	return &AlgorithmRouter{algorithmRestService: algorithmRestService}
+func NewAlgorithmRouter(
+	algorithmRestService rest.AlgorithmRestServicer,
+	middleware middleware.JsonWebTokenMiddleware) WebServiceRouter {
+
+	return &AlgorithmRouter{
+		middleware:           middleware,
+		algorithmRestService: algorithmRestService}
 }
 
 // Registers all of the algorithm endpoints at the root of the webservice (/api/v1)
@@ -35,10 +45,10 @@ func (algorithmRouter *AlgorithmRouter) RegisterRoutes(router *mux.Router, baseU
 // @Security JWT
 func (algorithmRouter *AlgorithmRouter) page(router *mux.Router, baseURI string) string {
 	endpoint := fmt.Sprintf("%s/algorithms/{page}", baseURI)
-	router.HandleFunc(endpoint, algorithmRouter.algorithmRestService.Page)
-	// router.Handle(endpoint, negroni.New(
-	// 	negroni.HandlerFunc(algorithmRouter.middleware.Validate),
-	// 	negroni.Wrap(http.HandlerFunc(algorithmRouter.algorithmRestService.GetPage)),
-	// ))
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(algorithmRouter.middleware.Validate),
+		negroni.HandlerFunc(algorithmRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
+		negroni.Wrap(http.HandlerFunc(algorithmRouter.algorithmRestService.Page)),
+	)).Methods(http.MethodGet)
 	return endpoint
 }
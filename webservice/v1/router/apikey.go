@@ -0,0 +1,98 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type APIKeyRouter struct {
+	middleware        middleware.JsonWebTokenMiddleware
+	apiKeyRestService rest.APIKeyRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service API key router
+func NewAPIKeyRouter(
+	apiKeyService service.APIKeyService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &APIKeyRouter{
+		middleware: middleware,
+		apiKeyRestService: rest.NewAPIKeyRestService(
+			apiKeyService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the API key endpoints at the root of the API (/api/v1)
+func (apiKeyRouter *APIKeyRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	apiKeysBaseURI := fmt.Sprintf("%s/apikeys", baseURI)
+	return []string{
+		apiKeyRouter.create(router, apiKeysBaseURI),
+		apiKeyRouter.list(router, apiKeysBaseURI),
+		apiKeyRouter.revoke(router, apiKeysBaseURI)}
+}
+
+// @Summary Create API key
+// @Description Creates a new API key for a service account, scoped to the requested farms and permissions. The key is only returned once.
+// @Tags API Keys
+// @Accept json
+// @Produce  json
+// @Param	APIKeyRequest	body	service.APIKeyRequest	true	"service.APIKeyRequest struct"
+// @Success 200 {object} rest.APIKeyResponse
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /apikeys [post]
+// @Security JWT
+func (apiKeyRouter *APIKeyRouter) create(router *mux.Router, apiKeysBaseURI string) string {
+	router.Handle(apiKeysBaseURI, negroni.New(
+		negroni.HandlerFunc(apiKeyRouter.middleware.Validate),
+		negroni.HandlerFunc(apiKeyRouter.middleware.Authorize(common.PERMISSION_APIKEY_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(apiKeyRouter.apiKeyRestService.Create)),
+	)).Methods("POST")
+	return apiKeysBaseURI
+}
+
+// @Summary List API keys
+// @Description Returns the API keys created by the user
+// @Tags API Keys
+// @Produce  json
+// @Success 200 {array} entity.APIKey
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /apikeys [get]
+// @Security JWT
+func (apiKeyRouter *APIKeyRouter) list(router *mux.Router, apiKeysBaseURI string) string {
+	router.Handle(apiKeysBaseURI, negroni.New(
+		negroni.HandlerFunc(apiKeyRouter.middleware.Validate),
+		negroni.HandlerFunc(apiKeyRouter.middleware.Authorize(common.PERMISSION_APIKEY_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(apiKeyRouter.apiKeyRestService.List)),
+	)).Methods("GET")
+	return apiKeysBaseURI
+}
+
+// @Summary Revoke API key
+// @Description Revokes an API key so it can no longer be used to authenticate
+// @Tags API Keys
+// @Produce  json
+// @Param	id	path	integer	true	"string valid"
+// @Success 200 {object} entity.APIKey
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /apikeys/{id} [delete]
+// @Security JWT
+func (apiKeyRouter *APIKeyRouter) revoke(router *mux.Router, apiKeysBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{id}", apiKeysBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(apiKeyRouter.middleware.Validate),
+		negroni.HandlerFunc(apiKeyRouter.middleware.Authorize(common.PERMISSION_APIKEY_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(apiKeyRouter.apiKeyRestService.Revoke)),
+	)).Methods("DELETE")
+	return endpoint
+}
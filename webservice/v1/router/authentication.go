@@ -26,7 +26,10 @@ func NewAuthenticationRouter(
 func (authenticationRouter *AuthenticationRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
 	return []string{
 		authenticationRouter.login(router, baseURI),
-		authenticationRouter.refreshToken(router, baseURI)}
+		authenticationRouter.refreshToken(router, baseURI),
+		authenticationRouter.logout(router, baseURI),
+		authenticationRouter.verifyMFA(router, baseURI),
+		authenticationRouter.enrollMFA(router, baseURI)}
 }
 
 // @Summary Authenticate and obtain JWT
@@ -47,18 +50,67 @@ func (authenticationRouter *AuthenticationRouter) login(router *mux.Router, base
 }
 
 // @Summary Refresh JWT
-// @Description Returns a new JWT token with a new, extended expiration date
+// @Description Exchanges a refresh token for a new JWT and refresh token. Refresh tokens may only be used once; reusing one revokes every token issued since login.
 // @Tags Authentication
+// @Param RefreshTokenRequest body rest.RefreshTokenRequest true "RefreshTokenRequest struct"
 // @Accept json
-// @Consume json
+// @Produce json
 // @Success 200 {object} viewmodel.JsonWebToken
 // @Failure 400 {object} viewmodel.JsonWebToken
 // @Failure 401 {object} viewmodel.JsonWebToken
 // @Failure 500 {object} viewmodel.JsonWebToken
-// @Router /login/refresh [get]
-// @Security JWT
+// @Router /login/refresh [post]
 func (authenticationRouter *AuthenticationRouter) refreshToken(router *mux.Router, baseURI string) string {
 	refreshToken := fmt.Sprintf("%s/login/refresh", baseURI)
 	router.HandleFunc(refreshToken, authenticationRouter.middleware.RefreshToken)
 	return refreshToken
 }
+
+// @Summary Logout
+// @Description Revokes the refresh token and every other token issued from the same login
+// @Tags Authentication
+// @Param RefreshTokenRequest body rest.RefreshTokenRequest true "RefreshTokenRequest struct"
+// @Accept json
+// @Produce json
+// @Success 200 {object} viewmodel.JsonWebToken
+// @Failure 400 {object} viewmodel.JsonWebToken
+// @Failure 401 {object} viewmodel.JsonWebToken
+// @Router /logout [post]
+func (authenticationRouter *AuthenticationRouter) logout(router *mux.Router, baseURI string) string {
+	logout := fmt.Sprintf("%s/logout", baseURI)
+	router.HandleFunc(logout, authenticationRouter.middleware.Logout).Methods("POST")
+	return logout
+}
+
+// @Summary Complete MFA login
+// @Description Completes a login that was challenged for a second factor using a TOTP code or recovery code, returning a new JWT
+// @Tags Authentication
+// @Param MFARequest body rest.MFARequest true "MFARequest struct"
+// @Accept json
+// @Produce json
+// @Success 200 {object} viewmodel.JsonWebToken
+// @Failure 400 {object} viewmodel.JsonWebToken
+// @Failure 403 {object} viewmodel.JsonWebToken
+// @Failure 500 {object} viewmodel.JsonWebToken
+// @Router /login/mfa [post]
+func (authenticationRouter *AuthenticationRouter) verifyMFA(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/login/mfa", baseURI)
+	router.HandleFunc(endpoint, authenticationRouter.middleware.VerifyMFA).Methods("POST")
+	return endpoint
+}
+
+// @Summary Enroll in MFA during login
+// @Description Returns a new TOTP secret, provisioning URI and recovery codes for a user whose organization requires MFA. The enrollment is confirmed by completing the MFA login.
+// @Tags Authentication
+// @Param MFARequest body rest.MFARequest true "MFARequest struct"
+// @Accept json
+// @Produce json
+// @Success 200 {object} service.TOTPEnrollment
+// @Failure 400 {object} viewmodel.JsonWebToken
+// @Failure 403 {object} viewmodel.JsonWebToken
+// @Router /login/mfa/enroll [post]
+func (authenticationRouter *AuthenticationRouter) enrollMFA(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/login/mfa/enroll", baseURI)
+	router.HandleFunc(endpoint, authenticationRouter.middleware.EnrollMFA).Methods("POST")
+	return endpoint
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// Users that haven't been assigned any roles aren't granted the default role,
+// even though the application ships with admin as the default role
+func TestUnassignedUserPermissions(t *testing.T) {
+	baseURI := "/api/v1"
+	jwtService, login, _ := createTenantTestJWTService(t)
+	router, request, requiredPermission := createTenantTestRouter(jwtService, baseURI)
+	token := login("newcomer@example.com")
+
+	tested := 0
+	walkTestRoutes(t, router, func(method, endpoint string) {
+		if _, protected := requiredPermission(method, endpoint); !protected {
+			return
+		}
+		assert.Equal(t, http.StatusForbidden,
+			request(method, tenantTestURL(endpoint, 0, 0, newcomerID), token),
+			"%s %s", method, endpoint)
+		tested++
+	})
+	assert.True(t, tested > 0)
+}
+
+// Only the default user is granted system administration
+func TestSystemAdminPermissions(t *testing.T) {
+	baseURI := "/api/v1"
+	jwtService, login, _ := createTenantTestJWTService(t)
+	router, request, requiredPermission := createTenantTestRouter(jwtService, baseURI)
+	token := login(common.DEFAULT_USER)
+
+	tested := 0
+	walkTestRoutes(t, router, func(method, endpoint string) {
+		permission, _ := requiredPermission(method, endpoint)
+		if permission != common.PERMISSION_SYSTEM_ADMIN || tenantTestURL(endpoint, 0, 0, 0) != endpoint {
+			return
+		}
+		assert.Equal(t, http.StatusOK, request(method, endpoint, token), "%s %s", method, endpoint)
+		tested++
+	})
+	assert.True(t, tested > 0)
+}
@@ -0,0 +1,150 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type CalibrationRouter struct {
+	middleware             middleware.JsonWebTokenMiddleware
+	calibrationRestService rest.CalibrationRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service sensor calibration router
+func NewCalibrationRouter(
+	calibrationService service.CalibrationService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &CalibrationRouter{
+		middleware: middleware,
+		calibrationRestService: rest.NewCalibrationRestService(
+			calibrationService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the calibration endpoints at the root of the farm (/api/v1/farms/{farmID})
+func (calibrationRouter *CalibrationRouter) RegisterRoutes(router *mux.Router, baseFarmURI string) []string {
+	calibrationsBaseURI := fmt.Sprintf("%s/calibrations", baseFarmURI)
+	return []string{
+		calibrationRouter.overdue(router, calibrationsBaseURI),
+		calibrationRouter.start(router, calibrationsBaseURI),
+		calibrationRouter.capture(router, calibrationsBaseURI),
+		calibrationRouter.complete(router, calibrationsBaseURI),
+		calibrationRouter.cancel(router, calibrationsBaseURI)}
+}
+
+// @Summary List overdue calibrations
+// @Description Returns the farm metrics that are overdue for calibration
+// @Tags Calibration
+// @Produce  json
+// @Param	farmID	path	integer	true	"string valid"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/calibrations/overdue [get]
+// @Security JWT
+func (calibrationRouter *CalibrationRouter) overdue(router *mux.Router, calibrationsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/overdue", calibrationsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(calibrationRouter.middleware.Validate),
+		negroni.HandlerFunc(calibrationRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
+		negroni.Wrap(http.HandlerFunc(calibrationRouter.calibrationRestService.Overdue)),
+	)).Methods("GET")
+	return endpoint
+}
+
+// @Summary Start calibration
+// @Description Starts a guided calibration for a device metric
+// @Tags Calibration
+// @Produce  json
+// @Param	farmID		path	integer	true	"string valid"
+// @Param	deviceID	path	integer	true	"string valid"
+// @Param	metricID	path	integer	true	"string valid"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/calibrations/{deviceID}/{metricID} [post]
+// @Security JWT
+func (calibrationRouter *CalibrationRouter) start(router *mux.Router, calibrationsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{deviceID}/{metricID}", calibrationsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(calibrationRouter.middleware.Validate),
+		negroni.HandlerFunc(calibrationRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
+		negroni.Wrap(http.HandlerFunc(calibrationRouter.calibrationRestService.Start)),
+	)).Methods("POST")
+	return endpoint
+}
+
+// @Summary Capture calibration point
+// @Description Captures the current raw sensor reading against a reference solution value
+// @Tags Calibration
+// @Accept  json
+// @Produce  json
+// @Param	farmID		path	integer	true	"string valid"
+// @Param	deviceID	path	integer	true	"string valid"
+// @Param	metricID	path	integer	true	"string valid"
+// @Param	point		body	rest.CalibrationPointRequest	true	"Reference value"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/calibrations/{deviceID}/{metricID}/points [post]
+// @Security JWT
+func (calibrationRouter *CalibrationRouter) capture(router *mux.Router, calibrationsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{deviceID}/{metricID}/points", calibrationsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(calibrationRouter.middleware.Validate),
+		negroni.HandlerFunc(calibrationRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
+		negroni.Wrap(http.HandlerFunc(calibrationRouter.calibrationRestService.Capture)),
+	)).Methods("POST")
+	return endpoint
+}
+
+// @Summary Complete calibration
+// @Description Calculates the calibration from the captured points and saves it to the metric
+// @Tags Calibration
+// @Produce  json
+// @Param	farmID		path	integer	true	"string valid"
+// @Param	deviceID	path	integer	true	"string valid"
+// @Param	metricID	path	integer	true	"string valid"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/calibrations/{deviceID}/{metricID} [put]
+// @Security JWT
+func (calibrationRouter *CalibrationRouter) complete(router *mux.Router, calibrationsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{deviceID}/{metricID}", calibrationsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(calibrationRouter.middleware.Validate),
+		negroni.HandlerFunc(calibrationRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
+		negroni.Wrap(http.HandlerFunc(calibrationRouter.calibrationRestService.Complete)),
+	)).Methods("PUT")
+	return endpoint
+}
+
+// @Summary Cancel calibration
+// @Description Discards a calibration in progress
+// @Tags Calibration
+// @Produce  json
+// @Param	farmID		path	integer	true	"string valid"
+// @Param	deviceID	path	integer	true	"string valid"
+// @Param	metricID	path	integer	true	"string valid"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/calibrations/{deviceID}/{metricID} [delete]
+// @Security JWT
+func (calibrationRouter *CalibrationRouter) cancel(router *mux.Router, calibrationsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{deviceID}/{metricID}", calibrationsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(calibrationRouter.middleware.Validate),
+		negroni.HandlerFunc(calibrationRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
+		negroni.Wrap(http.HandlerFunc(calibrationRouter.calibrationRestService.Cancel)),
+	)).Methods("DELETE")
+	return endpoint
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -54,6 +55,7 @@ func (channelRouter *ChannelRouter) list(router *mux.Router, baseFarmURI string)
 	endpoint := fmt.Sprintf("%s/channels", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(channelRouter.middleware.Validate),
+		negroni.HandlerFunc(channelRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(channelRouter.channelRestService.List)),
 	))
 	return endpoint
@@ -74,6 +76,7 @@ func (channelRouter *ChannelRouter) update(router *mux.Router, baseFarmURI strin
 	endpoint := fmt.Sprintf("%s/channel/{id}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(channelRouter.middleware.Validate),
+		negroni.HandlerFunc(channelRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(channelRouter.channelRestService.Update)),
 	))
 	return endpoint
@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/mapper"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
@@ -59,8 +60,9 @@ func (conditionRouter *ConditionRouter) listView(router *mux.Router, baseFarmURI
 	endpoint := fmt.Sprintf("%s/conditions/{channelID}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(conditionRouter.middleware.Validate),
+		negroni.HandlerFunc(conditionRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(conditionRouter.conditionRestService.ListView)),
-	))
+	)).Methods(http.MethodGet)
 	return endpoint
 }
 
@@ -79,6 +81,7 @@ func (conditionRouter *ConditionRouter) create(router *mux.Router, baseFarmURI s
 	endpoint := fmt.Sprintf("%s/conditions", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(conditionRouter.middleware.Validate),
+		negroni.HandlerFunc(conditionRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(conditionRouter.conditionRestService.Create)),
 	)).Methods(http.MethodPost)
 	return endpoint
@@ -99,6 +102,7 @@ func (conditionRouter *ConditionRouter) update(router *mux.Router, baseFarmURI s
 	endpoint := fmt.Sprintf("%s/conditions", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(conditionRouter.middleware.Validate),
+		negroni.HandlerFunc(conditionRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(conditionRouter.conditionRestService.Update)),
 	)).Methods(http.MethodPut)
 	return endpoint
@@ -119,6 +123,7 @@ func (conditionRouter *ConditionRouter) delete(router *mux.Router, baseFarmURI s
 	endpoint := fmt.Sprintf("%s/conditions/{id}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(conditionRouter.middleware.Validate),
+		negroni.HandlerFunc(conditionRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(conditionRouter.conditionRestService.Delete)),
 	)).Methods(http.MethodDelete)
 	return endpoint
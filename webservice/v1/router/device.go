@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -57,6 +58,7 @@ func (deviceRouter *DeviceRouter) view(router *mux.Router, baseFarmURI string) s
 	endpoint := fmt.Sprintf("%s/devices/{deviceType}/view", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(deviceRouter.middleware.Validate),
+		negroni.HandlerFunc(deviceRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(deviceRouter.deviceRestService.View)),
 	))
 	return endpoint
@@ -77,6 +79,7 @@ func (deviceRouter *DeviceRouter) state(router *mux.Router, baseFarmURI string)
 	endpoint := fmt.Sprintf("%s/devices/{deviceType}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(deviceRouter.middleware.Validate),
+		negroni.HandlerFunc(deviceRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(deviceRouter.deviceRestService.State)),
 	))
 	return endpoint
@@ -99,6 +102,7 @@ func (deviceRouter *DeviceRouter) metric(router *mux.Router, baseFarmURI string)
 	endpoint := fmt.Sprintf("%s/devices/{deviceType}/metrics/{key}/{value}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(deviceRouter.middleware.Validate),
+		negroni.HandlerFunc(deviceRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(deviceRouter.deviceRestService.Metric)),
 	))
 	return endpoint
@@ -120,6 +124,7 @@ func (deviceRouter *DeviceRouter) history(router *mux.Router, baseFarmURI string
 	endpoint := fmt.Sprintf("%s/devices/{deviceType}/history/{metric}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(deviceRouter.middleware.Validate),
+		negroni.HandlerFunc(deviceRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(deviceRouter.deviceRestService.History)),
 	))
 	return endpoint
@@ -142,6 +147,7 @@ func (deviceRouter *DeviceRouter) _switch(router *mux.Router, baseFarmURI string
 	endpoint := fmt.Sprintf("%s/devices/{deviceType}/switch/{channel}/{position}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(deviceRouter.middleware.Validate),
+		negroni.HandlerFunc(deviceRouter.middleware.Authorize(common.PERMISSION_DEVICE_SWITCH)),
 		negroni.Wrap(http.HandlerFunc(deviceRouter.deviceRestService.Switch)),
 	))
 	return endpoint
@@ -161,9 +167,10 @@ func (deviceRouter *DeviceRouter) _switch(router *mux.Router, baseFarmURI string
 // @Router /farms/{farmID}/devices/{deviceType}/timerSwitch/{channel}/{duration} [get]
 // @Security JWT
 func (deviceRouter *DeviceRouter) timerSwitch(router *mux.Router, baseFarmURI string) string {
-	endpoint := fmt.Sprintf("%s/devices/{deviceType}/timerSwitch/{channel}/{duration} ", baseFarmURI)
+	endpoint := fmt.Sprintf("%s/devices/{deviceType}/timerSwitch/{channel}/{duration}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(deviceRouter.middleware.Validate),
+		negroni.HandlerFunc(deviceRouter.middleware.Authorize(common.PERMISSION_DEVICE_SWITCH)),
 		negroni.Wrap(http.HandlerFunc(deviceRouter.deviceRestService.TimerSwitch)),
 	))
 	return endpoint
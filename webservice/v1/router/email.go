@@ -0,0 +1,117 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type EmailRouter struct {
+	middleware       middleware.JsonWebTokenMiddleware
+	emailRestService rest.EmailRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service email preferences and email change router
+func NewEmailRouter(
+	emailService service.EmailService,
+	emailChangeService service.EmailChangeService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &EmailRouter{
+		middleware: middleware,
+		emailRestService: rest.NewEmailRestService(
+			emailService,
+			emailChangeService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the email endpoints at the root of the API (/api/v1)
+func (emailRouter *EmailRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	preferencesURI := fmt.Sprintf("%s/email/preferences", baseURI)
+	return []string{
+		emailRouter.getPreferences(router, preferencesURI),
+		emailRouter.setPreferences(router, preferencesURI),
+		emailRouter.change(router, baseURI),
+		emailRouter.confirm(router, baseURI)}
+}
+
+// @Summary Get email preferences
+// @Description Returns the user's notification email address and subscribed email categories
+// @Tags Email
+// @Produce  json
+// @Success 200 {object} service.EmailPreferences
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /email/preferences [get]
+// @Security JWT
+func (emailRouter *EmailRouter) getPreferences(router *mux.Router, preferencesURI string) string {
+	router.Handle(preferencesURI, negroni.New(
+		negroni.HandlerFunc(emailRouter.middleware.Validate),
+		negroni.HandlerFunc(emailRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(emailRouter.emailRestService.GetPreferences)),
+	)).Methods("GET")
+	return preferencesURI
+}
+
+// @Summary Set email preferences
+// @Description Sets the user's notification email address and the email categories (alarm, summary, workflow) the user has opted in to
+// @Tags Email
+// @Accept  json
+// @Produce  json
+// @Param	preferences	body	service.EmailPreferences	true	"Email preferences"
+// @Success 200 {object} service.EmailPreferences
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /email/preferences [put]
+// @Security JWT
+func (emailRouter *EmailRouter) setPreferences(router *mux.Router, preferencesURI string) string {
+	router.Handle(preferencesURI, negroni.New(
+		negroni.HandlerFunc(emailRouter.middleware.Validate),
+		negroni.HandlerFunc(emailRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(emailRouter.emailRestService.SetPreferences)),
+	)).Methods("PUT")
+	return preferencesURI
+}
+
+// @Summary Change email address
+// @Description Emails a single use confirmation link to the new email address. The email address isn't changed until the link is confirmed. The user's current password is required.
+// @Tags Email
+// @Accept  json
+// @Produce  json
+// @Param ChangeEmailRequest body rest.ChangeEmailRequest true "ChangeEmailRequest struct"
+// @Success 200 {object} response.WebServiceResponse
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /email/change [post]
+// @Security JWT
+func (emailRouter *EmailRouter) change(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/email/change", baseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(emailRouter.middleware.Validate),
+		negroni.HandlerFunc(emailRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(emailRouter.emailRestService.ChangeEmail)),
+	)).Methods("POST")
+	return endpoint
+}
+
+// @Summary Confirm email address
+// @Description Changes the user's email address using the token from the confirmation link sent to the new address. Changing the email address revokes the user's refresh tokens.
+// @Tags Email
+// @Accept  json
+// @Produce  json
+// @Param ConfirmEmailRequest body rest.ConfirmEmailRequest true "ConfirmEmailRequest struct"
+// @Success 200 {object} response.WebServiceResponse
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /email/confirm [post]
+func (emailRouter *EmailRouter) confirm(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/email/confirm", baseURI)
+	router.HandleFunc(endpoint, emailRouter.emailRestService.ConfirmEmail).Methods("POST")
+	return endpoint
+}
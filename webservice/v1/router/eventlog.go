@@ -7,6 +7,7 @@ import (
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
 	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -42,15 +43,25 @@ func NewEventLogRouter(
 func (eventLogRouter *EventLogRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
 	return []string{
 		eventLogRouter.system(router, baseURI),
+		eventLogRouter.archives(router, baseURI),
+		eventLogRouter.restore(router, baseURI),
 		eventLogRouter.farm(router, baseURI)}
 }
 
 // @Summary List events
-// @Description Returns a page of event log entries
+// @Description Returns a page of event log entries matching the optional filters
 // @Tags Event Log
 // @Accept json
 // @Produce  json
 // @Param   page	path	integer	true	"string valid"
+// @Param   start	query	string	false	"RFC3339 start time"
+// @Param   end	query	string	false	"RFC3339 end time"
+// @Param   device	query	integer	false	"device ID"
+// @Param   channel	query	integer	false	"channel ID"
+// @Param   type	query	string	false	"event type"
+// @Param   actor	query	integer	false	"actor user ID"
+// @Param   source	query	string	false	"manual, schedule, condition, algorithm, workflow or system"
+// @Param   q	query	string	false	"message search"
 // @Success 200
 // @Failure 400 {object} response.WebServiceResponse
 // @Router /eventlog/{page} [get]
@@ -59,18 +70,27 @@ func (eventLogRouter *EventLogRouter) system(router *mux.Router, baseURI string)
 	endpoint := fmt.Sprintf("%s/eventlog/{page}", baseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(eventLogRouter.middleware.Validate),
+		negroni.HandlerFunc(eventLogRouter.middleware.Authorize(common.PERMISSION_SYSTEM_ADMIN)),
 		negroni.Wrap(http.HandlerFunc(eventLogRouter.eventLogRestService.SystemPage)),
 	))
 	return endpoint
 }
 
 // @Summary List event log
-// @Description Returns a page of event log entries for the requested farm
+// @Description Returns a page of event log entries for the requested farm matching the optional filters
 // @Tags Farms
 // @Accept json
 // @Produce  json
 // @Param   farmID	path	integer	true	"string valid"
 // @Param   page	path	integer	true	"string valid"
+// @Param   start	query	string	false	"RFC3339 start time"
+// @Param   end	query	string	false	"RFC3339 end time"
+// @Param   device	query	integer	false	"device ID"
+// @Param   channel	query	integer	false	"channel ID"
+// @Param   type	query	string	false	"event type"
+// @Param   actor	query	integer	false	"actor user ID"
+// @Param   source	query	string	false	"manual, schedule, condition, algorithm, workflow or system"
+// @Param   q	query	string	false	"message search"
 // @Success 200
 // @Failure 400 {object} response.WebServiceResponse
 // @Router /farms/{farmID}/events/{page} [get]
@@ -79,7 +99,49 @@ func (eventLogRouter *EventLogRouter) farm(router *mux.Router, baseURI string) s
 	endpoint := fmt.Sprintf("%s/farms/{farmID}/events/{page}", baseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(eventLogRouter.middleware.Validate),
+		negroni.HandlerFunc(eventLogRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(eventLogRouter.eventLogRestService.FarmPage)),
 	))
 	return endpoint
 }
+
+// @Summary List event log archives
+// @Description Returns the names of the requested farm's event log archives. Requires the farm:manage permission.
+// @Tags Farms
+// @Accept json
+// @Produce  json
+// @Param   farmID	path	integer	true	"string valid"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/events/archives [get]
+// @Security JWT
+func (eventLogRouter *EventLogRouter) archives(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/farms/{farmID}/events/archives", baseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(eventLogRouter.middleware.Validate),
+		negroni.HandlerFunc(eventLogRouter.middleware.Authorize(common.PERMISSION_FARM_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(eventLogRouter.eventLogRestService.Archives)),
+	)).Methods("GET")
+	return endpoint
+}
+
+// @Summary Restore event log archive
+// @Description Re-imports an event log archive into the requested farm's event log. Requires the farm:manage permission.
+// @Tags Farms
+// @Accept json
+// @Produce  json
+// @Param   farmID	path	integer	true	"string valid"
+// @Param   name	path	string	true	"archive name"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/events/archives/{name}/restore [post]
+// @Security JWT
+func (eventLogRouter *EventLogRouter) restore(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/farms/{farmID}/events/archives/{name}/restore", baseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(eventLogRouter.middleware.Validate),
+		negroni.HandlerFunc(eventLogRouter.middleware.Authorize(common.PERMISSION_FARM_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(eventLogRouter.eventLogRestService.Restore)),
+	)).Methods("POST")
+	return endpoint
+}
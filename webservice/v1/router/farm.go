@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -60,7 +61,8 @@ func (farmRouter *FarmRouter) RegisterRoutes(router *mux.Router, baseURI string)
 		farmRouter.pubkey(router, farmRouter.baseFarmURI),
 		farmRouter.setDeviceConfig(router, farmRouter.baseFarmURI),
 		farmRouter.sendNotification(router, farmRouter.baseFarmURI),
-		farmRouter.notificationTicker(router, farmRouter.baseFarmURI)}
+		farmRouter.notificationTicker(router, farmRouter.baseFarmURI),
+		farmRouter.eventLogTicker(router, farmRouter.baseFarmURI)}
 }
 
 // @Summary List farms
@@ -77,6 +79,7 @@ func (farmRouter *FarmRouter) farms(router *mux.Router, baseURI string) string {
 	endpoint := fmt.Sprintf("%s/farms", baseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmRestService.Farms)),
 	)).Methods("GET")
 	return endpoint
@@ -96,6 +99,7 @@ func (farmRouter *FarmRouter) devices(router *mux.Router, baseFarmURI string) st
 	endpoint := fmt.Sprintf("%s/devices", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmRestService.Devices)),
 	))
 	return endpoint
@@ -116,6 +120,7 @@ func (farmRouter *FarmRouter) users(router *mux.Router, baseFarmURI string) stri
 	endpoint := fmt.Sprintf("%s/users", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmRestService.FarmUsers)),
 	)).Methods("GET")
 	return endpoint
@@ -139,6 +144,7 @@ func (farmRouter *FarmRouter) resetPassword(router *mux.Router, baseFarmURI stri
 	endpoint := fmt.Sprintf("%s/users/{userID}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmRestService.ResetPassword)),
 	)).Methods("POST")
 	return endpoint
@@ -160,6 +166,7 @@ func (farmRouter *FarmRouter) deleteFarmUser(router *mux.Router, baseFarmURI str
 	endpoint := fmt.Sprintf("%s/users/{userID}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmRestService.DeleteFarmUser)),
 	)).Methods("DELETE")
 	return endpoint
@@ -180,6 +187,7 @@ func (farmRouter *FarmRouter) config(router *mux.Router, baseFarmURI string) str
 	endpoint := fmt.Sprintf("%s/config", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmRestService.Config)),
 	)).Methods("GET")
 	return endpoint
@@ -201,6 +209,7 @@ func (farmRouter *FarmRouter) state(router *mux.Router, baseFarmURI string) stri
 	endpoint := fmt.Sprintf("%s/state", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmRestService.State)),
 	)).Methods("GET")
 	return endpoint
@@ -239,6 +248,7 @@ func (farmRouter *FarmRouter) setDeviceConfig(router *mux.Router, baseFarmURI st
 	endpoint := fmt.Sprintf("%s/config/{deviceID}/{key}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmRestService.State)),
 	)).Methods("GET")
 	return endpoint
@@ -258,6 +268,7 @@ func (farmRouter *FarmRouter) sendNotification(router *mux.Router, baseFarmURI s
 	endpoint := fmt.Sprintf("%s/notification/{type}/{message}/{priority}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_REPORT_SEND)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmRestService.SendMessage)),
 	)).Methods("GET")
 	return endpoint
@@ -278,6 +289,7 @@ func (farmRouter *FarmRouter) farmTicker(router *mux.Router, baseURI string) str
 	endpoint := fmt.Sprintf("%s/farmticker/{farmID}", baseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmWebSocketRestService.FarmTickerConnect)),
 	))
 	return endpoint
@@ -298,7 +310,30 @@ func (farmRouter *FarmRouter) notificationTicker(router *mux.Router, baseFarmURI
 	endpoint := fmt.Sprintf("%s/notifications", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(farmRouter.farmWebSocketRestService.PushNotificationConnect)),
 	))
 	return endpoint
 }
+
+// @Summary Stream real-time farm event log entries via websocket
+// @Description Create a websocket and start receiving new farm event log entries as they are created. Clients may send {"devices": [...], "types": [...]} at any time to change the subscription.
+// @Tags Farms
+// @Produce  json
+// @Param farmID	path	integer		true	"string valid"
+// @Param device	query	string	false	"comma separated device IDs"
+// @Param type	query	string	false	"comma separated event types"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Failure 401 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/eventticker [get]
+// @Security JWT
+func (farmRouter *FarmRouter) eventLogTicker(router *mux.Router, baseFarmURI string) string {
+	endpoint := fmt.Sprintf("%s/eventticker", baseFarmURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(farmRouter.middleware.Validate),
+		negroni.HandlerFunc(farmRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
+		negroni.Wrap(http.HandlerFunc(farmRouter.farmWebSocketRestService.EventLogConnect)),
+	))
+	return endpoint
+}
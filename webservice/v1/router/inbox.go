@@ -0,0 +1,118 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type InboxRouter struct {
+	middleware       middleware.JsonWebTokenMiddleware
+	inboxRestService rest.InboxRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service notification inbox router
+func NewInboxRouter(
+	inboxService service.InboxService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &InboxRouter{
+		middleware: middleware,
+		inboxRestService: rest.NewInboxRestService(
+			inboxService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the inbox endpoints at the root of the API (/api/v1)
+func (inboxRouter *InboxRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	inboxBaseURI := fmt.Sprintf("%s/inbox", baseURI)
+	return []string{
+		inboxRouter.page(router, inboxBaseURI),
+		inboxRouter.unreadCount(router, inboxBaseURI),
+		inboxRouter.markRead(router, inboxBaseURI),
+		inboxRouter.markAllRead(router, inboxBaseURI)}
+}
+
+// @Summary Inbox page
+// @Description Returns a page of the user's notification inbox, newest first
+// @Tags Inbox
+// @Produce  json
+// @Param	page	path	integer	true	"string valid"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /inbox/{page} [get]
+// @Security JWT
+func (inboxRouter *InboxRouter) page(router *mux.Router, inboxBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{page}", inboxBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(inboxRouter.middleware.Validate),
+		negroni.HandlerFunc(inboxRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(inboxRouter.inboxRestService.Page)),
+	)).Methods("GET")
+	return endpoint
+}
+
+// @Summary Unread count
+// @Description Returns the number of unread notifications in the user's inbox
+// @Tags Inbox
+// @Produce  json
+// @Success 200 {object} rest.InboxUnreadResponse
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /inbox/unread/count [get]
+// @Security JWT
+func (inboxRouter *InboxRouter) unreadCount(router *mux.Router, inboxBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/unread/count", inboxBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(inboxRouter.middleware.Validate),
+		negroni.HandlerFunc(inboxRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(inboxRouter.inboxRestService.UnreadCount)),
+	)).Methods("GET")
+	return endpoint
+}
+
+// @Summary Mark read
+// @Description Marks an item in the user's inbox as read
+// @Tags Inbox
+// @Produce  json
+// @Param	itemID	path	integer	true	"string valid"
+// @Success 200 {object} entity.InboxItem
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /inbox/{itemID}/read [post]
+// @Security JWT
+func (inboxRouter *InboxRouter) markRead(router *mux.Router, inboxBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{itemID}/read", inboxBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(inboxRouter.middleware.Validate),
+		negroni.HandlerFunc(inboxRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(inboxRouter.inboxRestService.MarkRead)),
+	)).Methods("POST")
+	return endpoint
+}
+
+// @Summary Mark all read
+// @Description Marks every item in the user's inbox as read
+// @Tags Inbox
+// @Produce  json
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /inbox/read [post]
+// @Security JWT
+func (inboxRouter *InboxRouter) markAllRead(router *mux.Router, inboxBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/read", inboxBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(inboxRouter.middleware.Validate),
+		negroni.HandlerFunc(inboxRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(inboxRouter.inboxRestService.MarkAllRead)),
+	)).Methods("POST")
+	return endpoint
+}
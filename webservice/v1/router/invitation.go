@@ -0,0 +1,176 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type InvitationRouter struct {
+	middleware            middleware.JsonWebTokenMiddleware
+	invitationRestService rest.InvitationRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service organization and farm invitation router
+func NewInvitationRouter(
+	invitationService service.InvitationService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &InvitationRouter{
+		middleware: middleware,
+		invitationRestService: rest.NewInvitationRestService(
+			invitationService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers the invitation endpoints for organizations (/api/v1/organizations/{organizationID})
+// and farms (/api/v1/farms/{farmID}), and the public accept endpoint at the root of the API (/api/v1)
+func (invitationRouter *InvitationRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	orgInvitationsURI := fmt.Sprintf("%s/organizations/{organizationID}/invitations", baseURI)
+	farmInvitationsURI := fmt.Sprintf("%s/farms/{farmID}/invitations", baseURI)
+	return []string{
+		invitationRouter.inviteOrganization(router, orgInvitationsURI),
+		invitationRouter.listOrganization(router, orgInvitationsURI),
+		invitationRouter.revokeOrganization(router, orgInvitationsURI),
+		invitationRouter.inviteFarm(router, farmInvitationsURI),
+		invitationRouter.listFarm(router, farmInvitationsURI),
+		invitationRouter.revokeFarm(router, farmInvitationsURI),
+		invitationRouter.accept(router, baseURI)}
+}
+
+// @Summary Invite to organization
+// @Description Emails an expiring invitation link that grants the invitee a role in the organization. Any pending invitation for the same email is replaced. The role may not grant permissions the inviting user doesn't have.
+// @Tags Invitation
+// @Accept json
+// @Produce  json
+// @Param   organizationID	path	integer	true	"string valid"
+// @Param   InvitationRequest	body	service.InvitationRequest	true	"service.InvitationRequest struct"
+// @Success 200 {object} entity.Invitation
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /organizations/{organizationID}/invitations [post]
+// @Security JWT
+func (invitationRouter *InvitationRouter) inviteOrganization(router *mux.Router, orgInvitationsURI string) string {
+	return invitationRouter.invite(router, orgInvitationsURI)
+}
+
+// @Summary List organization invitations
+// @Description Returns the pending invitations to the organization
+// @Tags Invitation
+// @Produce  json
+// @Param   organizationID	path	integer	true	"string valid"
+// @Success 200 {array} entity.Invitation
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /organizations/{organizationID}/invitations [get]
+// @Security JWT
+func (invitationRouter *InvitationRouter) listOrganization(router *mux.Router, orgInvitationsURI string) string {
+	return invitationRouter.list(router, orgInvitationsURI)
+}
+
+// @Summary Revoke organization invitation
+// @Description Revokes a pending invitation to the organization
+// @Tags Invitation
+// @Produce  json
+// @Param   organizationID	path	integer	true	"string valid"
+// @Param   invitationID	path	integer	true	"string valid"
+// @Success 200 {object} entity.Invitation
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /organizations/{organizationID}/invitations/{invitationID} [delete]
+// @Security JWT
+func (invitationRouter *InvitationRouter) revokeOrganization(router *mux.Router, orgInvitationsURI string) string {
+	return invitationRouter.revoke(router, orgInvitationsURI)
+}
+
+// @Summary Invite to farm
+// @Description Emails an expiring invitation link that grants the invitee a role in the farm. Any pending invitation for the same email is replaced. The role may not grant permissions the inviting user doesn't have.
+// @Tags Invitation
+// @Accept json
+// @Produce  json
+// @Param   farmID	path	integer	true	"string valid"
+// @Param   InvitationRequest	body	service.InvitationRequest	true	"service.InvitationRequest struct"
+// @Success 200 {object} entity.Invitation
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/invitations [post]
+// @Security JWT
+func (invitationRouter *InvitationRouter) inviteFarm(router *mux.Router, farmInvitationsURI string) string {
+	return invitationRouter.invite(router, farmInvitationsURI)
+}
+
+// @Summary List farm invitations
+// @Description Returns the pending invitations to the farm
+// @Tags Invitation
+// @Produce  json
+// @Param   farmID	path	integer	true	"string valid"
+// @Success 200 {array} entity.Invitation
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/invitations [get]
+// @Security JWT
+func (invitationRouter *InvitationRouter) listFarm(router *mux.Router, farmInvitationsURI string) string {
+	return invitationRouter.list(router, farmInvitationsURI)
+}
+
+// @Summary Revoke farm invitation
+// @Description Revokes a pending invitation to the farm
+// @Tags Invitation
+// @Produce  json
+// @Param   farmID	path	integer	true	"string valid"
+// @Param   invitationID	path	integer	true	"string valid"
+// @Success 200 {object} entity.Invitation
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/invitations/{invitationID} [delete]
+// @Security JWT
+func (invitationRouter *InvitationRouter) revokeFarm(router *mux.Router, farmInvitationsURI string) string {
+	return invitationRouter.revoke(router, farmInvitationsURI)
+}
+
+// @Summary Accept invitation
+// @Description Accepts an invitation using the token from the invitation link. An account is created for invitees that don't have one using the provided password, which must satisfy the password policy.
+// @Tags Invitation
+// @Accept json
+// @Produce json
+// @Param InvitationAcceptance body service.InvitationAcceptance true "service.InvitationAcceptance struct"
+// @Success 200 {object} entity.Invitation
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /invitations/accept [post]
+func (invitationRouter *InvitationRouter) accept(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/invitations/accept", baseURI)
+	router.HandleFunc(endpoint, invitationRouter.invitationRestService.Accept).Methods("POST")
+	return endpoint
+}
+
+func (invitationRouter *InvitationRouter) invite(router *mux.Router, invitationsURI string) string {
+	router.Handle(invitationsURI, negroni.New(
+		negroni.HandlerFunc(invitationRouter.middleware.Validate),
+		negroni.HandlerFunc(invitationRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(invitationRouter.invitationRestService.Invite)),
+	)).Methods("POST")
+	return invitationsURI
+}
+
+func (invitationRouter *InvitationRouter) list(router *mux.Router, invitationsURI string) string {
+	router.Handle(invitationsURI, negroni.New(
+		negroni.HandlerFunc(invitationRouter.middleware.Validate),
+		negroni.HandlerFunc(invitationRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(invitationRouter.invitationRestService.List)),
+	)).Methods("GET")
+	return invitationsURI
+}
+
+func (invitationRouter *InvitationRouter) revoke(router *mux.Router, invitationsURI string) string {
+	endpoint := fmt.Sprintf("%s/{invitationID}", invitationsURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(invitationRouter.middleware.Validate),
+		negroni.HandlerFunc(invitationRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(invitationRouter.invitationRestService.Revoke)),
+	)).Methods("DELETE")
+	return endpoint
+}
@@ -0,0 +1,149 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type LicenseRouter struct {
+	middleware         middleware.JsonWebTokenMiddleware
+	licenseRestService rest.LicenseRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service license router
+func NewLicenseRouter(
+	licenseService service.LicenseService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &LicenseRouter{
+		middleware: middleware,
+		licenseRestService: rest.NewLicenseRestService(
+			licenseService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the license endpoints at the root of the API (/api/v1)
+func (licenseRouter *LicenseRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	return []string{
+		licenseRouter.serverUsage(router, baseURI),
+		licenseRouter.organizationUsage(router, baseURI),
+		licenseRouter.farmUsage(router, baseURI),
+		licenseRouter.issueServer(router, baseURI),
+		licenseRouter.issueOrganization(router, baseURI),
+		licenseRouter.issueFarm(router, baseURI)}
+}
+
+// @Summary Server license usage
+// @Description Returns the number of organizations vs. the server license organization quota. A zero quota is unlimited.
+// @Tags License
+// @Produce  json
+// @Success 200 {object} service.LicenseUsage
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /license/usage [get]
+// @Security JWT
+func (licenseRouter *LicenseRouter) serverUsage(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/license/usage", baseURI)
+	return licenseRouter.usage(router, endpoint, common.PERMISSION_SYSTEM_ADMIN)
+}
+
+// @Summary Organization license usage
+// @Description Returns the organization's farms and users vs. the organization license quotas. A zero quota is unlimited.
+// @Tags License
+// @Produce  json
+// @Param   organizationID	path	integer	true	"string valid"
+// @Success 200 {object} service.LicenseUsage
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /organizations/{organizationID}/license/usage [get]
+// @Security JWT
+func (licenseRouter *LicenseRouter) organizationUsage(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/organizations/{organizationID}/license/usage", baseURI)
+	return licenseRouter.usage(router, endpoint, common.PERMISSION_USER_MANAGE)
+}
+
+// @Summary Farm license usage
+// @Description Returns the farm's devices and users vs. the farm license quotas. A zero quota is unlimited.
+// @Tags License
+// @Produce  json
+// @Param   farmID	path	integer	true	"string valid"
+// @Success 200 {object} service.LicenseUsage
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/license/usage [get]
+// @Security JWT
+func (licenseRouter *LicenseRouter) farmUsage(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/farms/{farmID}/license/usage", baseURI)
+	return licenseRouter.usage(router, endpoint, common.PERMISSION_FARM_READ)
+}
+
+// @Summary Issue server license
+// @Description Signs and saves the server license, replacing the current license. A zero quota is unlimited.
+// @Tags License
+// @Accept json
+// @Produce  json
+// @Param   ServerLicenseStruct	body	config.ServerLicenseStruct	true	"config.ServerLicenseStruct struct"
+// @Success 200 {object} entity.License
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /license/server [put]
+// @Security JWT
+func (licenseRouter *LicenseRouter) issueServer(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/license/server", baseURI)
+	return licenseRouter.issue(router, endpoint, licenseRouter.licenseRestService.IssueServer)
+}
+
+// @Summary Issue organization license
+// @Description Signs and saves an organization license, replacing the organization's current license. A zero quota is unlimited.
+// @Tags License
+// @Accept json
+// @Produce  json
+// @Param   OrganizationLicenseStruct	body	config.OrganizationLicenseStruct	true	"config.OrganizationLicenseStruct struct"
+// @Success 200 {object} entity.License
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /license/organizations [put]
+// @Security JWT
+func (licenseRouter *LicenseRouter) issueOrganization(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/license/organizations", baseURI)
+	return licenseRouter.issue(router, endpoint, licenseRouter.licenseRestService.IssueOrganization)
+}
+
+// @Summary Issue farm license
+// @Description Signs and saves a farm license, replacing the farm's current license. A zero quota is unlimited.
+// @Tags License
+// @Accept json
+// @Produce  json
+// @Param   FarmLicenseStruct	body	config.FarmLicenseStruct	true	"config.FarmLicenseStruct struct"
+// @Success 200 {object} entity.License
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /license/farms [put]
+// @Security JWT
+func (licenseRouter *LicenseRouter) issueFarm(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/license/farms", baseURI)
+	return licenseRouter.issue(router, endpoint, licenseRouter.licenseRestService.IssueFarm)
+}
+
+func (licenseRouter *LicenseRouter) usage(router *mux.Router, endpoint, permission string) string {
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(licenseRouter.middleware.Validate),
+		negroni.HandlerFunc(licenseRouter.middleware.Authorize(permission)),
+		negroni.Wrap(http.HandlerFunc(licenseRouter.licenseRestService.Usage)),
+	)).Methods("GET")
+	return endpoint
+}
+
+func (licenseRouter *LicenseRouter) issue(router *mux.Router, endpoint string, handler http.HandlerFunc) string {
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(licenseRouter.middleware.Validate),
+		negroni.HandlerFunc(licenseRouter.middleware.Authorize(common.PERMISSION_SYSTEM_ADMIN)),
+		negroni.Wrap(handler),
+	)).Methods("PUT")
+	return endpoint
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -55,6 +56,7 @@ func (metricRouter *MetricRouter) set(router *mux.Router, baseFarmURI string) st
 	endpoint := fmt.Sprintf("%s/metrics", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(metricRouter.middleware.Validate),
+		negroni.HandlerFunc(metricRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(metricRouter.metricRestService.SetMetrics)),
 	))
 	return endpoint
@@ -73,6 +75,7 @@ func (metricRouter *MetricRouter) getByDeviceID(router *mux.Router, baseFarmURI
 	endpoint := fmt.Sprintf("%s/metrics/{id}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(metricRouter.middleware.Validate),
+		negroni.HandlerFunc(metricRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(metricRouter.metricRestService.GetMetricsByDeviceID)),
 	))
 	return endpoint
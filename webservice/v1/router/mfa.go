@@ -0,0 +1,120 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type MFARouter struct {
+	middleware     middleware.JsonWebTokenMiddleware
+	mfaRestService rest.MFARestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service multi-factor authentication router
+func NewMFARouter(
+	mfaService service.MFAService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &MFARouter{
+		middleware: middleware,
+		mfaRestService: rest.NewMFARestService(
+			mfaService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the MFA endpoints at the root of the API (/api/v1)
+func (mfaRouter *MFARouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	mfaBaseURI := fmt.Sprintf("%s/mfa", baseURI)
+	return []string{
+		mfaRouter.enroll(router, mfaBaseURI),
+		mfaRouter.confirm(router, mfaBaseURI),
+		mfaRouter.disable(router, mfaBaseURI),
+		mfaRouter.reset(router, baseURI)}
+}
+
+// @Summary Enroll in MFA
+// @Description Returns a new TOTP secret, provisioning URI and recovery codes. MFA is enabled once the enrollment is confirmed.
+// @Tags MFA
+// @Produce  json
+// @Success 200 {object} service.TOTPEnrollment
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /mfa/enroll [post]
+// @Security JWT
+func (mfaRouter *MFARouter) enroll(router *mux.Router, mfaBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/enroll", mfaBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(mfaRouter.middleware.Validate),
+		negroni.HandlerFunc(mfaRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(mfaRouter.mfaRestService.Enroll)),
+	)).Methods("POST")
+	return endpoint
+}
+
+// @Summary Confirm MFA enrollment
+// @Description Enables MFA using a code from the newly provisioned authenticator app
+// @Tags MFA
+// @Accept json
+// @Produce  json
+// @Param	MFACode	body	rest.MFACode	true	"rest.MFACode struct"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /mfa/confirm [post]
+// @Security JWT
+func (mfaRouter *MFARouter) confirm(router *mux.Router, mfaBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/confirm", mfaBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(mfaRouter.middleware.Validate),
+		negroni.HandlerFunc(mfaRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(mfaRouter.mfaRestService.Confirm)),
+	)).Methods("POST")
+	return endpoint
+}
+
+// @Summary Disable MFA
+// @Description Disables MFA for the user. Requires a TOTP code or recovery code.
+// @Tags MFA
+// @Accept json
+// @Produce  json
+// @Param	MFACode	body	rest.MFACode	true	"rest.MFACode struct"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /mfa [delete]
+// @Security JWT
+func (mfaRouter *MFARouter) disable(router *mux.Router, mfaBaseURI string) string {
+	router.Handle(mfaBaseURI, negroni.New(
+		negroni.HandlerFunc(mfaRouter.middleware.Validate),
+		negroni.HandlerFunc(mfaRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(mfaRouter.mfaRestService.Disable)),
+	)).Methods("DELETE")
+	return mfaBaseURI
+}
+
+// @Summary Reset user MFA
+// @Description Removes a user's MFA enrollment so a user who lost their authenticator app and recovery codes can enroll again
+// @Tags MFA
+// @Produce  json
+// @Param	userID	path	integer	true	"string valid"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /users/{userID}/mfa [delete]
+// @Security JWT
+func (mfaRouter *MFARouter) reset(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/users/{userID}/mfa", baseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(mfaRouter.middleware.Validate),
+		negroni.HandlerFunc(mfaRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(mfaRouter.mfaRestService.Reset)),
+	)).Methods("DELETE")
+	return endpoint
+}
@@ -0,0 +1,81 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type NotificationRouter struct {
+	middleware              middleware.JsonWebTokenMiddleware
+	notificationRestService rest.NotificationRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service notification router
+func NewNotificationRouter(
+	notificationService service.NotificationServicer,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &NotificationRouter{
+		middleware: middleware,
+		notificationRestService: rest.NewNotificationRestService(
+			notificationService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the notification endpoints at the root of the farm (/api/v1/farms/{farmID})
+func (notificationRouter *NotificationRouter) RegisterRoutes(router *mux.Router, baseFarmURI string) []string {
+	notificationsBaseURI := fmt.Sprintf("%s/notifications", baseFarmURI)
+	return []string{
+		notificationRouter.unacknowledged(router, notificationsBaseURI),
+		notificationRouter.acknowledge(router, notificationsBaseURI)}
+}
+
+// @Summary List unacknowledged notifications
+// @Description Returns the high priority notifications waiting to be acknowledged
+// @Tags Notification
+// @Produce  json
+// @Param	farmID	path	integer	true	"string valid"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/notifications/unacknowledged [get]
+// @Security JWT
+func (notificationRouter *NotificationRouter) unacknowledged(router *mux.Router, notificationsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/unacknowledged", notificationsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(notificationRouter.middleware.Validate),
+		negroni.HandlerFunc(notificationRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(notificationRouter.notificationRestService.Unacknowledged)),
+	)).Methods("GET")
+	return endpoint
+}
+
+// @Summary Acknowledge notification
+// @Description Acknowledges a high priority notification, stopping escalation to the next on-call user
+// @Tags Notification
+// @Produce  json
+// @Param	farmID			path	integer	true	"string valid"
+// @Param	notificationID	path	integer	true	"string valid"
+// @Success 200
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/notifications/{notificationID}/ack [post]
+// @Security JWT
+func (notificationRouter *NotificationRouter) acknowledge(router *mux.Router, notificationsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{notificationID}/ack", notificationsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(notificationRouter.middleware.Validate),
+		negroni.HandlerFunc(notificationRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(notificationRouter.notificationRestService.Acknowledge)),
+	)).Methods("POST")
+	return endpoint
+}
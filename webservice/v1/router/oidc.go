@@ -0,0 +1,90 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type OIDCRouter struct {
+	oidcRestService rest.OIDCRestServicer
+	authMiddleware  middleware.AuthMiddleware
+	middleware      middleware.JsonWebTokenMiddleware
+	WebServiceRouter
+}
+
+// Creates a new web service OpenID Connect router
+func NewOIDCRouter(
+	oidcAuthService service.OIDCAuthServicer,
+	authMiddleware middleware.AuthMiddleware,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &OIDCRouter{
+		oidcRestService: rest.NewOIDCRestService(oidcAuthService, middleware, httpWriter),
+		authMiddleware:  authMiddleware,
+		middleware:      middleware}
+}
+
+// Registers all of the OIDC endpoints at the root of the webservice (/api/v1)
+func (oidcRouter *OIDCRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	return []string{
+		oidcRouter.login(router, baseURI),
+		oidcRouter.link(router, baseURI),
+		oidcRouter.callback(router, baseURI)}
+}
+
+// @Summary OpenID Connect login
+// @Description Redirects the user to the configured OpenID Connect provider to log in
+// @Tags Authentication
+// @Success 302
+// @Failure 500 {object} response.WebServiceResponse
+// @Router /oidc/login [get]
+func (oidcRouter *OIDCRouter) login(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/oidc/login", baseURI)
+	router.HandleFunc(endpoint, oidcRouter.oidcRestService.Login).Methods("GET")
+	return endpoint
+}
+
+// @Summary Link OpenID Connect identity
+// @Description Returns the provider URL that links the signed in user's account to their OpenID Connect identity. Existing accounts can only log in through the provider once they're linked.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} rest.OIDCLinkResponse
+// @Failure 400 {object} response.WebServiceResponse
+// @Failure 500 {object} response.WebServiceResponse
+// @Router /oidc/link [post]
+// @Security JWT
+func (oidcRouter *OIDCRouter) link(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/oidc/link", baseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(oidcRouter.middleware.Validate),
+		negroni.HandlerFunc(oidcRouter.middleware.Authorize(common.PERMISSION_PROFILE_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(oidcRouter.oidcRestService.Link)),
+	)).Methods("POST")
+	return endpoint
+}
+
+// @Summary OpenID Connect callback
+// @Description Completes an OpenID Connect login by exchanging the authorization code returned by the provider, returning a new JWT
+// @Tags Authentication
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "Login state"
+// @Success 200 {object} viewmodel.JsonWebToken
+// @Failure 400 {object} viewmodel.JsonWebToken
+// @Failure 403 {object} viewmodel.JsonWebToken
+// @Failure 500 {object} viewmodel.JsonWebToken
+// @Router /oidc/callback [get]
+func (oidcRouter *OIDCRouter) callback(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/oidc/callback", baseURI)
+	router.HandleFunc(endpoint, oidcRouter.authMiddleware.OIDCCallback).Methods("GET")
+	return endpoint
+}
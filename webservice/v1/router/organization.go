@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -38,6 +39,7 @@ func (organizationRouter *OrganizationRouter) RegisterRoutes(router *mux.Router,
 		organizationRouter.getPage(router, baseFarmURI),
 		organizationRouter.getUsers(router, baseFarmURI),
 		organizationRouter.create(router, baseFarmURI),
+		organizationRouter.setMFARequired(router, baseFarmURI),
 		organizationRouter.delete(router, baseFarmURI)}
 }
 
@@ -54,6 +56,7 @@ func (organizationRouter *OrganizationRouter) getPage(router *mux.Router, baseFa
 	endpoint := fmt.Sprintf("%s/organizations/{page}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(organizationRouter.middleware.Validate),
+		negroni.HandlerFunc(organizationRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(organizationRouter.organizationRestService.Page)),
 	))
 	return endpoint
@@ -72,6 +75,7 @@ func (organizationRouter *OrganizationRouter) getUsers(router *mux.Router, baseF
 	endpoint := fmt.Sprintf("%s/organizations/{organizationID}/users", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(organizationRouter.middleware.Validate),
+		negroni.HandlerFunc(organizationRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(organizationRouter.organizationRestService.GetUsers)),
 	))
 	return endpoint
@@ -90,11 +94,33 @@ func (organizationRouter *OrganizationRouter) create(router *mux.Router, baseFar
 	endpoint := fmt.Sprintf("%s/organizations/{organizationID}/users", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(organizationRouter.middleware.Validate),
+		negroni.HandlerFunc(organizationRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(organizationRouter.organizationRestService.Create)),
 	))
 	return endpoint
 }
 
+// @Summary Set organization MFA policy
+// @Description Sets whether organization members must log in with a second factor. Members who haven't enrolled must enroll at their next login.
+// @Tags Organization
+// @Accept json
+// @Produce  json
+// @Param   organizationID	path	integer	true	"string valid"
+// @Param   MFAPolicy	body	rest.MFAPolicy	true	"rest.MFAPolicy struct"
+// @Success 200 {object} rest.MFAPolicy
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /organizations/{organizationID}/mfa [put]
+// @Security JWT
+func (organizationRouter *OrganizationRouter) setMFARequired(router *mux.Router, baseFarmURI string) string {
+	endpoint := fmt.Sprintf("%s/organizations/{organizationID}/mfa", baseFarmURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(organizationRouter.middleware.Validate),
+		negroni.HandlerFunc(organizationRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(organizationRouter.organizationRestService.SetMFARequired)),
+	)).Methods("PUT")
+	return endpoint
+}
+
 // @Summary Delete an organization
 // @Description Deletes the organization referenced in the user JWT (current logged in organization)
 // @Tags Organization
@@ -107,6 +133,7 @@ func (organizationRouter *OrganizationRouter) delete(router *mux.Router, baseFar
 	endpoint := fmt.Sprintf("%s/organizations/{organizationID}/users", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(organizationRouter.middleware.Validate),
+		negroni.HandlerFunc(organizationRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(organizationRouter.organizationRestService.Delete)),
 	))
 	return endpoint
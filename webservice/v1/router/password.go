@@ -0,0 +1,63 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type PasswordRouter struct {
+	passwordRestService rest.PasswordRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service password reset router
+func NewPasswordRouter(
+	passwordResetService service.PasswordResetService,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &PasswordRouter{
+		passwordRestService: rest.NewPasswordRestService(
+			passwordResetService,
+			httpWriter)}
+}
+
+// Registers all of the password reset endpoints at the root of the webservice (/api/v1)
+func (passwordRouter *PasswordRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	return []string{
+		passwordRouter.forgot(router, baseURI),
+		passwordRouter.reset(router, baseURI)}
+}
+
+// @Summary Forgot password
+// @Description Emails a single use password reset link to a local account user. The response is the same whether or not the account exists.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param ForgotPasswordRequest body rest.ForgotPasswordRequest true "ForgotPasswordRequest struct"
+// @Success 200 {object} response.WebServiceResponse
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /password/forgot [post]
+func (passwordRouter *PasswordRouter) forgot(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/password/forgot", baseURI)
+	router.HandleFunc(endpoint, passwordRouter.passwordRestService.Forgot).Methods("POST")
+	return endpoint
+}
+
+// @Summary Reset password
+// @Description Sets a new password using the token from a password reset link. The password must satisfy the password policy. Resetting the password unlocks the account and revokes the user's refresh tokens.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param ResetPasswordRequest body rest.ResetPasswordRequest true "ResetPasswordRequest struct"
+// @Success 200 {object} response.WebServiceResponse
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /password/reset [post]
+func (passwordRouter *PasswordRouter) reset(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/password/reset", baseURI)
+	router.HandleFunc(endpoint, passwordRouter.passwordRestService.Reset).Methods("POST")
+	return endpoint
+}
@@ -0,0 +1,49 @@
+//go:build cluster
+// +build cluster
+
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+)
+
+type permissionTestClusterRegistry struct {
+	service.ServiceRegistry
+}
+
+func (registry *permissionTestClusterRegistry) GetGossipNode() cluster.GossipNode {
+	return nil
+}
+
+func (registry *permissionTestClusterRegistry) GetRaftNode() cluster.RaftNode {
+	return nil
+}
+
+// Returns the system and raft routers the cluster registers in place of the
+// standalone system router
+func systemTestRouters(_app *app.App, router *mux.Router, jwtMiddleware middleware.JsonWebTokenMiddleware,
+	registry service.ServiceRegistry, baseURI, baseFarmURI string) []testRouter {
+
+	return []testRouter{
+		{NewClusterSystemRouter(_app, &permissionTestClusterRegistry{registry}, jwtMiddleware,
+			router, nil, &[]string{}), baseURI},
+		{NewRaftRouter(_app.Logger, nil, jwtMiddleware, nil), baseFarmURI}}
+}
+
+// Returns the permissions required by the cluster system and raft endpoints
+// along with the system endpoints that don't require authentication
+func systemTestRoutes(baseURI, baseFarmURI string) ([]routePermission, []string) {
+	return []routePermission{
+			{"GET", baseURI + "/status", common.PERMISSION_SYSTEM_ADMIN},
+			{"GET", baseURI + "/config", common.PERMISSION_SYSTEM_ADMIN},
+			{"GET", baseURI + "/events/{page}", common.PERMISSION_SYSTEM_ADMIN},
+			{"GET", baseFarmURI + "/raft/transfer/{clusterID}/{nodeID}", common.PERMISSION_SYSTEM_ADMIN}},
+		[]string{
+			baseURI + "/endpoints",
+			baseURI + "/pubkey"}
+}
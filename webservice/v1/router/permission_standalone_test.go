@@ -0,0 +1,32 @@
+//go:build !cluster
+// +build !cluster
+
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+)
+
+// Returns the standalone system router
+func systemTestRouters(_app *app.App, router *mux.Router, jwtMiddleware middleware.JsonWebTokenMiddleware,
+	registry service.ServiceRegistry, baseURI, baseFarmURI string) []testRouter {
+
+	return []testRouter{
+		{NewSystemRouter(_app, registry, jwtMiddleware, router, nil, &[]string{}), baseURI}}
+}
+
+// Returns the permissions required by the system endpoints along with the
+// system endpoints that don't require authentication
+func systemTestRoutes(baseURI, baseFarmURI string) ([]routePermission, []string) {
+	return []routePermission{
+			{"GET", baseURI + "/config", common.PERMISSION_SYSTEM_ADMIN},
+			{"GET", baseURI + "/events/{page}", common.PERMISSION_SYSTEM_ADMIN}},
+		[]string{
+			baseURI + "/endpoints",
+			baseURI + "/status",
+			baseURI + "/pubkey"}
+}
@@ -0,0 +1,306 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+const requiredPermissionHeader = "X-Required-Permission"
+
+// Passes every request through Validate and answers Authorize with the
+// permission the route requires instead of calling the route handler.
+type permissionRecorder struct {
+	middleware.JsonWebTokenMiddleware
+}
+
+func (recorder *permissionRecorder) Validate(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	next(w, r)
+}
+
+func (recorder *permissionRecorder) Authorize(permission string) func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		w.Header().Set(requiredPermissionHeader, permission)
+		w.WriteHeader(http.StatusForbidden)
+	}
+}
+
+type permissionTestRegistry struct {
+	service.ServiceRegistry
+}
+
+func (registry *permissionTestRegistry) GetFarmFactory() service.FarmFactory {
+	return nil
+}
+
+func (registry *permissionTestRegistry) GetUserService() service.UserServicer {
+	return nil
+}
+
+func (registry *permissionTestRegistry) GetNotificationService() service.NotificationServicer {
+	return nil
+}
+
+type testRouter struct {
+	router  WebServiceRouter
+	baseURI string
+}
+
+// testAuthMiddleware stands in for the login endpoints, which don't
+// require authentication
+type testAuthMiddleware struct {
+	middleware.AuthMiddleware
+}
+
+func (authMiddleware *testAuthMiddleware) OIDCCallback(w http.ResponseWriter, req *http.Request) {}
+
+type routePermission struct {
+	method     string
+	endpoint   string
+	permission string
+}
+
+// Registers every v1 web service router with the middleware, returning the
+// registered endpoints
+func registerTestRoutes(router *mux.Router, jwtMiddleware middleware.JsonWebTokenMiddleware,
+	registry service.ServiceRegistry, baseURI string) []string {
+
+	baseFarmURI := baseURI + "/farms/{farmID}"
+	logger := logging.MustGetLogger("permission_test")
+	_app := &app.App{Logger: logger}
+	webSocketService := rest.NewFarmWebSocketRestService(logger, nil, nil, nil, nil,
+		nil, nil, registry, jwtMiddleware, nil)
+
+	routers := []testRouter{
+		{NewAlarmRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewAlgorithmRouter(rest.NewAlgorithmRestService(nil, jwtMiddleware, nil), jwtMiddleware), baseURI},
+		{NewAPIKeyRouter(nil, jwtMiddleware, nil), baseURI},
+		{NewCalibrationRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewChannelRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewConditionRouter(nil, nil, jwtMiddleware, nil), baseFarmURI},
+		{NewDeviceRouter(registry, jwtMiddleware, nil), baseFarmURI},
+		{NewEmailRouter(nil, nil, jwtMiddleware, nil), baseURI},
+		{NewFarmRouter("", nil, baseFarmURI, registry, jwtMiddleware, webSocketService, nil), baseURI},
+		{NewInboxRouter(nil, jwtMiddleware, nil), baseURI},
+		{NewInvitationRouter(nil, jwtMiddleware, nil), baseURI},
+		{NewLicenseRouter(nil, jwtMiddleware, nil), baseURI},
+		{NewMetricRouter(logger, nil, jwtMiddleware, nil), baseFarmURI},
+		{NewMFARouter(nil, jwtMiddleware, nil), baseURI},
+		{NewNotificationRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewOIDCRouter(nil, &testAuthMiddleware{}, jwtMiddleware, nil), baseURI},
+		{NewOrganizationRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewProvisionerRouter(_app, nil, nil, jwtMiddleware, nil), baseURI},
+		{NewReportRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewRoleRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewScheduleRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewSessionRouter(nil, jwtMiddleware, nil), baseURI},
+		{NewShoppingCartRouter(logger, "", nil, jwtMiddleware, nil), baseURI},
+		{NewUserRouter(nil, jwtMiddleware, nil), baseURI},
+		{NewWorkflowRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewWorkflowStepRouter(nil, jwtMiddleware, nil), baseFarmURI},
+		{NewEventLogRouter(_app, logger, registry, jwtMiddleware, nil), baseURI}}
+	routers = append(routers, systemTestRouters(_app, router, jwtMiddleware, registry, baseURI, baseFarmURI)...)
+
+	endpoints := make([]string, 0)
+	for _, r := range routers {
+		endpoints = append(endpoints, r.router.RegisterRoutes(router, r.baseURI)...)
+	}
+	return endpoints
+}
+
+// Returns the endpoints that don't require authentication
+func publicEndpoints(baseURI string) map[string]bool {
+	public := map[string]bool{
+		baseURI + "/farms/{farmID}/pubkey": true,
+		baseURI + "/email/confirm":         true,
+		baseURI + "/invitations/accept":    true,
+		baseURI + "/oidc/login":            true,
+		baseURI + "/oidc/callback":         true,
+		baseURI + "/shoppingcart/webhook":  true}
+	_, systemEndpoints := systemTestRoutes(baseURI, baseURI+"/farms/{farmID}")
+	for _, endpoint := range systemEndpoints {
+		public[endpoint] = true
+	}
+	return public
+}
+
+func TestRoutePermissions(t *testing.T) {
+	baseURI := "/api/v1"
+	baseFarmURI := baseURI + "/farms/{farmID}"
+	router := mux.NewRouter()
+	endpoints := registerTestRoutes(router, &permissionRecorder{}, &permissionTestRegistry{}, baseURI)
+
+	public := publicEndpoints(baseURI)
+
+	routes := []routePermission{
+		{"GET", baseFarmURI + "/alarms", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/alarms/open", common.PERMISSION_FARM_READ},
+		{"POST", baseFarmURI + "/alarms/{alarmID}/ack", common.PERMISSION_ALARM_MANAGE},
+		{"POST", baseFarmURI + "/alarms/{alarmID}/shelve", common.PERMISSION_ALARM_MANAGE},
+
+		{"GET", baseURI + "/algorithms/{page}", common.PERMISSION_FARM_READ},
+
+		{"POST", baseURI + "/apikeys", common.PERMISSION_APIKEY_MANAGE},
+		{"GET", baseURI + "/apikeys", common.PERMISSION_APIKEY_MANAGE},
+		{"DELETE", baseURI + "/apikeys/{id}", common.PERMISSION_APIKEY_MANAGE},
+
+		{"GET", baseFarmURI + "/calibrations/overdue", common.PERMISSION_FARM_READ},
+		{"POST", baseFarmURI + "/calibrations/{deviceID}/{metricID}", common.PERMISSION_CONFIG_WRITE},
+		{"POST", baseFarmURI + "/calibrations/{deviceID}/{metricID}/points", common.PERMISSION_CONFIG_WRITE},
+		{"PUT", baseFarmURI + "/calibrations/{deviceID}/{metricID}", common.PERMISSION_CONFIG_WRITE},
+		{"DELETE", baseFarmURI + "/calibrations/{deviceID}/{metricID}", common.PERMISSION_CONFIG_WRITE},
+
+		{"GET", baseFarmURI + "/channels", common.PERMISSION_FARM_READ},
+		{"PUT", baseFarmURI + "/channel/{id}", common.PERMISSION_CONFIG_WRITE},
+
+		{"GET", baseFarmURI + "/conditions/{channelID}", common.PERMISSION_FARM_READ},
+		{"POST", baseFarmURI + "/conditions", common.PERMISSION_CONFIG_WRITE},
+		{"PUT", baseFarmURI + "/conditions", common.PERMISSION_CONFIG_WRITE},
+		{"DELETE", baseFarmURI + "/conditions/{id}", common.PERMISSION_CONFIG_WRITE},
+
+		{"GET", baseFarmURI + "/devices/{deviceType}/view", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/devices/{deviceType}", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/devices/{deviceType}/metrics/{key}/{value}", common.PERMISSION_CONFIG_WRITE},
+		{"GET", baseFarmURI + "/devices/{deviceType}/history/{metric}", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/devices/{deviceType}/switch/{channel}/{position}", common.PERMISSION_DEVICE_SWITCH},
+		{"GET", baseFarmURI + "/devices/{deviceType}/timerSwitch/{channel}/{duration}", common.PERMISSION_DEVICE_SWITCH},
+
+		{"GET", baseURI + "/email/preferences", common.PERMISSION_PROFILE_MANAGE},
+		{"PUT", baseURI + "/email/preferences", common.PERMISSION_PROFILE_MANAGE},
+		{"POST", baseURI + "/email/change", common.PERMISSION_PROFILE_MANAGE},
+
+		{"GET", baseURI + "/eventlog/{page}", common.PERMISSION_SYSTEM_ADMIN},
+		{"GET", baseURI + "/farms/{farmID}/events/{page}", common.PERMISSION_FARM_READ},
+		{"GET", baseURI + "/farms/{farmID}/events/archives", common.PERMISSION_FARM_MANAGE},
+		{"POST", baseURI + "/farms/{farmID}/events/archives/{name}/restore", common.PERMISSION_FARM_MANAGE},
+
+		{"GET", baseURI + "/farms", common.PERMISSION_PROFILE_MANAGE},
+		{"GET", baseFarmURI + "/devices", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/users", common.PERMISSION_USER_MANAGE},
+		{"POST", baseFarmURI + "/users/{userID}", common.PERMISSION_USER_MANAGE},
+		{"DELETE", baseFarmURI + "/users/{userID}", common.PERMISSION_USER_MANAGE},
+		{"GET", baseFarmURI + "/config", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/state", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/config/{deviceID}/{key}", common.PERMISSION_CONFIG_WRITE},
+		{"GET", baseFarmURI + "/notification/{type}/{message}/{priority}", common.PERMISSION_REPORT_SEND},
+		{"GET", baseURI + "/farmticker/{farmID}", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/notifications", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/eventticker", common.PERMISSION_FARM_READ},
+
+		{"GET", baseURI + "/inbox/{page}", common.PERMISSION_PROFILE_MANAGE},
+		{"GET", baseURI + "/inbox/unread/count", common.PERMISSION_PROFILE_MANAGE},
+		{"POST", baseURI + "/inbox/{itemID}/read", common.PERMISSION_PROFILE_MANAGE},
+		{"POST", baseURI + "/inbox/read", common.PERMISSION_PROFILE_MANAGE},
+
+		{"POST", baseURI + "/organizations/{organizationID}/invitations", common.PERMISSION_USER_MANAGE},
+		{"GET", baseURI + "/organizations/{organizationID}/invitations", common.PERMISSION_USER_MANAGE},
+		{"DELETE", baseURI + "/organizations/{organizationID}/invitations/{invitationID}", common.PERMISSION_USER_MANAGE},
+		{"POST", baseFarmURI + "/invitations", common.PERMISSION_USER_MANAGE},
+		{"GET", baseFarmURI + "/invitations", common.PERMISSION_USER_MANAGE},
+		{"DELETE", baseFarmURI + "/invitations/{invitationID}", common.PERMISSION_USER_MANAGE},
+
+		{"GET", baseURI + "/license/usage", common.PERMISSION_SYSTEM_ADMIN},
+		{"GET", baseURI + "/organizations/{organizationID}/license/usage", common.PERMISSION_USER_MANAGE},
+		{"GET", baseFarmURI + "/license/usage", common.PERMISSION_FARM_READ},
+		{"PUT", baseURI + "/license/server", common.PERMISSION_SYSTEM_ADMIN},
+		{"PUT", baseURI + "/license/organizations", common.PERMISSION_SYSTEM_ADMIN},
+		{"PUT", baseURI + "/license/farms", common.PERMISSION_SYSTEM_ADMIN},
+
+		{"POST", baseFarmURI + "/metrics", common.PERMISSION_CONFIG_WRITE},
+		{"GET", baseFarmURI + "/metrics/{id}", common.PERMISSION_FARM_READ},
+
+		{"POST", baseURI + "/mfa/enroll", common.PERMISSION_PROFILE_MANAGE},
+		{"POST", baseURI + "/mfa/confirm", common.PERMISSION_PROFILE_MANAGE},
+		{"DELETE", baseURI + "/mfa", common.PERMISSION_PROFILE_MANAGE},
+		{"POST", baseURI + "/oidc/link", common.PERMISSION_PROFILE_MANAGE},
+		{"DELETE", baseURI + "/users/{userID}/mfa", common.PERMISSION_USER_MANAGE},
+
+		{"GET", baseFarmURI + "/notifications/unacknowledged", common.PERMISSION_PROFILE_MANAGE},
+		{"POST", baseFarmURI + "/notifications/{notificationID}/ack", common.PERMISSION_PROFILE_MANAGE},
+
+		{"GET", baseFarmURI + "/organizations/{page}", common.PERMISSION_USER_MANAGE},
+		{"GET", baseFarmURI + "/organizations/{organizationID}/users", common.PERMISSION_USER_MANAGE},
+		{"PUT", baseFarmURI + "/organizations/{organizationID}/mfa", common.PERMISSION_USER_MANAGE},
+
+		{"GET", baseURI + "/provisioner/provision/{organizationID}/{farmName}", common.PERMISSION_FARM_MANAGE},
+		{"GET", baseURI + "/provisioner/deprovision/{farmID}", common.PERMISSION_FARM_MANAGE},
+
+		{"GET", baseFarmURI + "/reports/{period}", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/reports/{period}/text", common.PERMISSION_FARM_READ},
+		{"POST", baseFarmURI + "/reports/{period}/send", common.PERMISSION_REPORT_SEND},
+
+		{"GET", baseFarmURI + "/roles", common.PERMISSION_USER_MANAGE},
+
+		{"GET", baseFarmURI + "/schedule", common.PERMISSION_FARM_READ},
+		{"POST", baseFarmURI + "/schedule", common.PERMISSION_CONFIG_WRITE},
+		{"PUT", baseFarmURI + "/schedule", common.PERMISSION_CONFIG_WRITE},
+		{"DELETE", baseFarmURI + "/schedule/{id}", common.PERMISSION_CONFIG_WRITE},
+
+		{"DELETE", baseURI + "/users/{userID}/sessions", common.PERMISSION_USER_MANAGE},
+		{"POST", baseURI + "/users/{userID}/disable", common.PERMISSION_USER_MANAGE},
+		{"POST", baseURI + "/users/{userID}/enable", common.PERMISSION_USER_MANAGE},
+
+		{"GET", baseURI + "/shoppingcart/publishable-key", common.PERMISSION_BILLING_MANAGE},
+		{"GET", baseURI + "/shoppingcart/ephemeral-key/{customerID}", common.PERMISSION_BILLING_MANAGE},
+		{"GET", baseURI + "/shoppingcart/products", common.PERMISSION_BILLING_MANAGE},
+		{"GET", baseURI + "/shoppingcart/tax-rate", common.PERMISSION_BILLING_MANAGE},
+		{"GET", baseURI + "/shoppingcart/payment-methods/{processorID}", common.PERMISSION_BILLING_MANAGE},
+		{"POST", baseURI + "/shoppingcart/attach-and-set-default-payment-method", common.PERMISSION_BILLING_MANAGE},
+		{"POST", baseURI + "/shoppingcart/attach-payment-method", common.PERMISSION_BILLING_MANAGE},
+		{"POST", baseURI + "/shoppingcart/default-payment-method", common.PERMISSION_BILLING_MANAGE},
+		{"POST", baseURI + "/shoppingcart/setup-intent/secret", common.PERMISSION_BILLING_MANAGE},
+		{"POST", baseURI + "/shoppingcart/setup-intent", common.PERMISSION_BILLING_MANAGE},
+		{"GET", baseURI + "/shoppingcart/customers/{id}", common.PERMISSION_BILLING_MANAGE},
+		{"POST", baseURI + "/shoppingcart/payment-intent", common.PERMISSION_BILLING_MANAGE},
+		{"POST", baseURI + "/shoppingcart/customers", common.PERMISSION_BILLING_MANAGE},
+		{"PUT", baseURI + "/shoppingcart/customers", common.PERMISSION_BILLING_MANAGE},
+		{"POST", baseURI + "/shoppingcart/invoice", common.PERMISSION_BILLING_MANAGE},
+
+		{"GET", baseFarmURI + "/workflows/view", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/workflows", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/workflows/{id}", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/workflows/{id}/run", common.PERMISSION_WORKFLOW_RUN},
+		{"POST", baseFarmURI + "/workflows", common.PERMISSION_CONFIG_WRITE},
+		{"PUT", baseFarmURI + "/workflows/{id}", common.PERMISSION_CONFIG_WRITE},
+		{"DELETE", baseFarmURI + "/workflows/{id}", common.PERMISSION_CONFIG_WRITE},
+
+		{"GET", baseFarmURI + "/workflows/{workflowID}/steps", common.PERMISSION_FARM_READ},
+		{"GET", baseFarmURI + "/workflows/{workflowID}/steps/{stepID}", common.PERMISSION_FARM_READ},
+		{"POST", baseFarmURI + "/workflows/{workflowID}/steps", common.PERMISSION_CONFIG_WRITE},
+		{"PUT", baseFarmURI + "/workflows/{workflowID}/steps/{stepID}", common.PERMISSION_CONFIG_WRITE},
+		{"DELETE", baseFarmURI + "/workflows/{workflowID}/steps/{stepID}", common.PERMISSION_CONFIG_WRITE},
+	}
+	systemRoutes, _ := systemTestRoutes(baseURI, baseFarmURI)
+	routes = append(routes, systemRoutes...)
+
+	// Every protected endpoint must declare its required permission
+	declared := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		declared[route.endpoint] = true
+	}
+	for _, endpoint := range endpoints {
+		if !public[endpoint] {
+			assert.True(t, declared[endpoint], "missing required permission test for %s", endpoint)
+		}
+	}
+
+	variable := regexp.MustCompile(`{[^}]+}`)
+	for _, route := range routes {
+		url := variable.ReplaceAllString(route.endpoint, "1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.method, url, nil))
+		assert.Equal(t, http.StatusForbidden, w.Code, "%s %s", route.method, route.endpoint)
+		assert.Equal(t, route.permission, w.Header().Get(requiredPermissionHeader),
+			"%s %s", route.method, route.endpoint)
+	}
+}
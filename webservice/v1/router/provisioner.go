@@ -7,6 +7,7 @@ import (
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
 	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/provisioner"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
@@ -49,16 +50,17 @@ func (provisionerRouter *ProvisionerRouter) RegisterRoutes(router *mux.Router, b
 // @Description Creates a new farm using system configured defaults
 // @Tags Provisioner
 // @Produce  json
-// @Param   orgId		path	string	true	"string default"     default(0)
+// @Param   organizationID	path	string	true	"string default"     default(0)
 // @Param   farmName	path	string	true	"string valid"
 // @Success 200
 // @Failure 400 {object} response.WebServiceResponse
-// @Router /provisioner/provision/{orgId}/{farmName} [post]
+// @Router /provisioner/provision/{organizationID}/{farmName} [post]
 // @Security JWT
 func (provisionerRouter *ProvisionerRouter) provision(router *mux.Router, baseURI string) string {
-	endpoint := fmt.Sprintf("%s/provisioner/provision/{orgId}/{farmName}", baseURI)
+	endpoint := fmt.Sprintf("%s/provisioner/provision/{organizationID}/{farmName}", baseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(provisionerRouter.middleware.Validate),
+		negroni.HandlerFunc(provisionerRouter.middleware.Authorize(common.PERMISSION_FARM_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(provisionerRouter.provisionerRestService.Provision)),
 	))
 	return endpoint
@@ -77,6 +79,7 @@ func (provisionerRouter *ProvisionerRouter) deprovision(router *mux.Router, base
 	endpoint := fmt.Sprintf("%s/provisioner/deprovision/{farmID}", baseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(provisionerRouter.middleware.Validate),
+		negroni.HandlerFunc(provisionerRouter.middleware.Authorize(common.PERMISSION_FARM_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(provisionerRouter.provisionerRestService.DeProvision)),
 	))
 	return endpoint
@@ -10,6 +10,7 @@ import (
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
 	"github.com/jeremyhahn/go-cropdroid/cluster"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
@@ -57,6 +58,7 @@ func (raftRouter *RaftRouter) transferLeader(router *mux.Router, baseFarmURI str
 	endpoint := fmt.Sprintf("%s/raft/transfer/{clusterID}/{nodeID}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(raftRouter.middleware.Validate),
+		negroni.HandlerFunc(raftRouter.middleware.Authorize(common.PERMISSION_SYSTEM_ADMIN)),
 		negroni.Wrap(http.HandlerFunc(raftRouter.raftRestService.RequestLeaderTransfer)),
 	))
 	return endpoint
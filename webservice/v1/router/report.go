@@ -0,0 +1,103 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type ReportRouter struct {
+	middleware        middleware.JsonWebTokenMiddleware
+	reportRestService rest.ReportRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service farm summary report router
+func NewReportRouter(
+	reportService service.ReportService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &ReportRouter{
+		middleware: middleware,
+		reportRestService: rest.NewReportRestService(
+			reportService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the report endpoints at the root of the farm (/api/v1/farms/{farmID})
+func (reportRouter *ReportRouter) RegisterRoutes(router *mux.Router, baseFarmURI string) []string {
+	reportsBaseURI := fmt.Sprintf("%s/reports", baseFarmURI)
+	return []string{
+		reportRouter.get(router, reportsBaseURI),
+		reportRouter.text(router, reportsBaseURI),
+		reportRouter.send(router, reportsBaseURI)}
+}
+
+// @Summary Get farm report
+// @Description Generates the daily or weekly farm summary report
+// @Tags Reports
+// @Produce  json
+// @Param	farmID	path	integer	true	"string valid"
+// @Param	period	path	string	true	"daily or weekly"
+// @Success 200 {object} service.FarmReport
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/reports/{period} [get]
+// @Security JWT
+func (reportRouter *ReportRouter) get(router *mux.Router, reportsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{period}", reportsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(reportRouter.middleware.Validate),
+		negroni.HandlerFunc(reportRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
+		negroni.Wrap(http.HandlerFunc(reportRouter.reportRestService.Get)),
+	)).Methods("GET")
+	return endpoint
+}
+
+// @Summary Get farm report as text
+// @Description Generates the daily or weekly farm summary report as plain text
+// @Tags Reports
+// @Produce  plain
+// @Param	farmID	path	integer	true	"string valid"
+// @Param	period	path	string	true	"daily or weekly"
+// @Success 200 {string} string
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/reports/{period}/text [get]
+// @Security JWT
+func (reportRouter *ReportRouter) text(router *mux.Router, reportsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{period}/text", reportsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(reportRouter.middleware.Validate),
+		negroni.HandlerFunc(reportRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
+		negroni.Wrap(http.HandlerFunc(reportRouter.reportRestService.Text)),
+	)).Methods("GET")
+	return endpoint
+}
+
+// @Summary Send farm report
+// @Description Generates the daily or weekly farm summary report and delivers it to the farm users
+// @Tags Reports
+// @Produce  json
+// @Param	farmID	path	integer	true	"string valid"
+// @Param	period	path	string	true	"daily or weekly"
+// @Success 200 {object} service.FarmReport
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /farms/{farmID}/reports/{period}/send [post]
+// @Security JWT
+func (reportRouter *ReportRouter) send(router *mux.Router, reportsBaseURI string) string {
+	endpoint := fmt.Sprintf("%s/{period}/send", reportsBaseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(reportRouter.middleware.Validate),
+		negroni.HandlerFunc(reportRouter.middleware.Authorize(common.PERMISSION_REPORT_SEND)),
+		negroni.Wrap(http.HandlerFunc(reportRouter.reportRestService.Send)),
+	)).Methods("POST")
+	return endpoint
+}
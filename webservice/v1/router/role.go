@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -51,6 +52,7 @@ func (roleRouter *RoleRouter) listView(router *mux.Router, baseFarmURI string) s
 	endpoint := fmt.Sprintf("%s/roles", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(roleRouter.middleware.Validate),
+		negroni.HandlerFunc(roleRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(roleRouter.roleRestService.GetListView)),
 	))
 	return endpoint
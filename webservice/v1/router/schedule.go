@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -55,8 +56,9 @@ func (scheduleRouter *ScheduleRouter) get(router *mux.Router, baseFarmURI string
 	endpoint := fmt.Sprintf("%s/schedule", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(scheduleRouter.middleware.Validate),
+		negroni.HandlerFunc(scheduleRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(scheduleRouter.scheduleRestService.GetSchedule)),
-	))
+	)).Methods("GET")
 	return endpoint
 }
 
@@ -74,6 +76,7 @@ func (scheduleRouter *ScheduleRouter) create(router *mux.Router, baseFarmURI str
 	endpoint := fmt.Sprintf("%s/schedule", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(scheduleRouter.middleware.Validate),
+		negroni.HandlerFunc(scheduleRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(scheduleRouter.scheduleRestService.Create)),
 	)).Methods("POST")
 	return endpoint
@@ -93,6 +96,7 @@ func (scheduleRouter *ScheduleRouter) update(router *mux.Router, baseFarmURI str
 	endpoint := fmt.Sprintf("%s/schedule", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(scheduleRouter.middleware.Validate),
+		negroni.HandlerFunc(scheduleRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(scheduleRouter.scheduleRestService.Update)),
 	)).Methods("PUT")
 	return endpoint
@@ -112,6 +116,7 @@ func (scheduleRouter *ScheduleRouter) delete(router *mux.Router, baseFarmURI str
 	endpoint := fmt.Sprintf("%s/schedule/{id}", baseFarmURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(scheduleRouter.middleware.Validate),
+		negroni.HandlerFunc(scheduleRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(scheduleRouter.scheduleRestService.Delete)),
 	)).Methods("DELETE")
 	return endpoint
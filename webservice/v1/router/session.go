@@ -0,0 +1,59 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type SessionRouter struct {
+	middleware         middleware.JsonWebTokenMiddleware
+	sessionRestService rest.SessionRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service session router
+func NewSessionRouter(
+	refreshTokenService service.RefreshTokenService,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &SessionRouter{
+		middleware: middleware,
+		sessionRestService: rest.NewSessionRestService(
+			refreshTokenService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the session endpoints at the root of the API (/api/v1)
+func (sessionRouter *SessionRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	return []string{
+		sessionRouter.revokeUser(router, baseURI)}
+}
+
+// @Summary Revoke user sessions
+// @Description Revokes all of the user's refresh tokens, signing the user out of every device when their current access tokens expire
+// @Tags Authentication
+// @Produce  json
+// @Param	userID	path	integer	true	"string valid"
+// @Success 200 {object} rest.RevokedSessions
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /users/{userID}/sessions [delete]
+// @Security JWT
+func (sessionRouter *SessionRouter) revokeUser(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/users/{userID}/sessions", baseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(sessionRouter.middleware.Validate),
+		negroni.HandlerFunc(sessionRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(sessionRouter.sessionRestService.RevokeUser)),
+	)).Methods("DELETE")
+	return endpoint
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/shoppingcart"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -72,6 +73,7 @@ func (cartRouter *ShoppingCartRouter) publishableKey(router *mux.Router, baseCar
 	endpoint := fmt.Sprintf("%s/publishable-key", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.GetPublishableKey)),
 	))
 	return endpoint
@@ -90,6 +92,7 @@ func (cartRouter *ShoppingCartRouter) getOrCreateCustomerWithEphemeralKey(router
 	endpoint := fmt.Sprintf("%s/ephemeral-key/{customerID}", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.GetOrCreateCustomerWithEphemeralKey)),
 	))
 	return endpoint
@@ -107,6 +110,7 @@ func (cartRouter *ShoppingCartRouter) products(router *mux.Router, baseCartURI s
 	endpoint := fmt.Sprintf("%s/products", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.GetProducts)),
 	))
 	return endpoint
@@ -124,6 +128,7 @@ func (cartRouter *ShoppingCartRouter) taxRates(router *mux.Router, baseCartURI s
 	endpoint := fmt.Sprintf("%s/tax-rate", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.GetTaxRates)),
 	))
 	return endpoint
@@ -142,6 +147,7 @@ func (cartRouter *ShoppingCartRouter) defaultPaymentMethods(router *mux.Router,
 	endpoint := fmt.Sprintf("%s/payment-methods/{processorID}", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.GetPaymentMethods)),
 	))
 	return endpoint
@@ -160,6 +166,7 @@ func (cartRouter *ShoppingCartRouter) attachAndSetDefaultPaymentMethod(router *m
 	endpoint := fmt.Sprintf("%s/attach-and-set-default-payment-method", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.AttachAndSetDefaultPaymentMethod)),
 	)).Methods("POST")
 	return endpoint
@@ -178,6 +185,7 @@ func (cartRouter *ShoppingCartRouter) attachPaymentMethod(router *mux.Router, ba
 	endpoint := fmt.Sprintf("%s/attach-payment-method", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.AttachPaymentMethod)),
 	)).Methods("POST")
 	return endpoint
@@ -196,6 +204,7 @@ func (cartRouter *ShoppingCartRouter) setDefaultPaymentMethod(router *mux.Router
 	endpoint := fmt.Sprintf("%s/default-payment-method", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.SetDefaultPaymentMethod)),
 	)).Methods("POST")
 	return endpoint
@@ -214,6 +223,7 @@ func (cartRouter *ShoppingCartRouter) getSetupIntent(router *mux.Router, baseCar
 	endpoint := fmt.Sprintf("%s/setup-intent/secret", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.GetSetupIntent)),
 	)).Methods("POST")
 	return endpoint
@@ -231,6 +241,7 @@ func (cartRouter *ShoppingCartRouter) createSetupIntent(router *mux.Router, base
 	endpoint := fmt.Sprintf("%s/setup-intent", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.CreateSetupIntent)),
 	)).Methods("POST")
 	return endpoint
@@ -249,6 +260,7 @@ func (cartRouter *ShoppingCartRouter) customer(router *mux.Router, baseCartURI s
 	endpoint := fmt.Sprintf("%s/customers/{id}", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.GetCustomer)),
 	))
 	return endpoint
@@ -267,6 +279,7 @@ func (cartRouter *ShoppingCartRouter) createPaymentIntent(router *mux.Router, ba
 	endpoint := fmt.Sprintf("%s/payment-intent", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.CreatePaymentIntent)),
 	)).Methods("POST")
 	return endpoint
@@ -285,6 +298,7 @@ func (cartRouter *ShoppingCartRouter) createCustomer(router *mux.Router, baseCar
 	endpoint := fmt.Sprintf("%s/customers", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.CreateCustomer)),
 	)).Methods("POST")
 	return endpoint
@@ -303,6 +317,7 @@ func (cartRouter *ShoppingCartRouter) updateCustomer(router *mux.Router, baseCar
 	endpoint := fmt.Sprintf("%s/customers", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.UpdateCustomer)),
 	)).Methods("PUT")
 	return endpoint
@@ -321,6 +336,7 @@ func (cartRouter *ShoppingCartRouter) createInvoice(router *mux.Router, baseCart
 	endpoint := fmt.Sprintf("%s/invoice", baseCartURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(cartRouter.middleware.Validate),
+		negroni.HandlerFunc(cartRouter.middleware.Authorize(common.PERMISSION_BILLING_MANAGE)),
 		negroni.Wrap(http.HandlerFunc(cartRouter.shoppingCartRestService.CreateInvoice)),
 	)).Methods("POST")
 	return endpoint
@@ -7,6 +7,7 @@ import (
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
 	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -97,6 +98,7 @@ func (systemRouter *SystemRouter) config(router *mux.Router, baseURI string) str
 	config := fmt.Sprintf("%s/config", baseURI)
 	router.Handle(config, negroni.New(
 		negroni.HandlerFunc(systemRouter.middleware.Validate),
+		negroni.HandlerFunc(systemRouter.middleware.Authorize(common.PERMISSION_SYSTEM_ADMIN)),
 		negroni.Wrap(http.HandlerFunc(systemRouter.systemRestService.Config)),
 	))
 	return config
@@ -117,6 +119,7 @@ func (systemRouter *SystemRouter) eventlog(router *mux.Router, baseURI string) s
 	router.Handle(eventlog, negroni.New(
 		negroni.NewLogger(),
 		negroni.HandlerFunc(systemRouter.middleware.Validate),
+		negroni.HandlerFunc(systemRouter.middleware.Authorize(common.PERMISSION_SYSTEM_ADMIN)),
 		negroni.Wrap(http.HandlerFunc(systemRouter.systemRestService.EventsPage)),
 	))
 	return eventlog
@@ -10,6 +10,7 @@ import (
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
 	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -62,6 +63,7 @@ func (systemRouter *ClusterSystemRouter) status(router *mux.Router, baseURI stri
 	router.Handle(endpoint, negroni.New(
 		negroni.NewLogger(),
 		negroni.HandlerFunc(systemRouter.middleware.Validate),
+		negroni.HandlerFunc(systemRouter.middleware.Authorize(common.PERMISSION_SYSTEM_ADMIN)),
 		negroni.Wrap(http.HandlerFunc(systemRouter.systemRestService.Status)),
 	))
 	return endpoint
@@ -72,6 +74,7 @@ func (systemRouter *ClusterSystemRouter) eventlog(router *mux.Router, baseURI st
 	router.Handle(endpoint, negroni.New(
 		negroni.NewLogger(),
 		negroni.HandlerFunc(systemRouter.middleware.Validate),
+		negroni.HandlerFunc(systemRouter.middleware.Authorize(common.PERMISSION_SYSTEM_ADMIN)),
 		negroni.Wrap(http.HandlerFunc(systemRouter.systemRestService.EventsPage)),
 	))
 	return endpoint
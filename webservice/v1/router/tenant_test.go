@@ -0,0 +1,690 @@
+package router
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/app"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore"
+	"github.com/jeremyhahn/go-cropdroid/datastore/dao"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/mapper"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/util"
+	"github.com/jeremyhahn/go-cropdroid/viewmodel"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+	"github.com/jeremyhahn/go-trusted-platform/pki/ca"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// Acme Farms, the tenant the test user belongs to
+	acmeOrgID   = 1
+	acmeFarmID  = 10
+	acmeAdminID = 100
+	acmeUserID  = 101
+
+	// Globex, the tenant the test user tries to access
+	globexOrgID  = 2
+	globexFarmID = 20
+	globexUserID = 200
+
+	// A user that hasn't been assigned to any organizations or farms
+	newcomerID = 300
+
+	// Acme's farm child records
+	acmeDeviceID    = 11
+	acmeChannelID   = 12
+	acmeConditionID = 13
+	acmeScheduleID  = 14
+	acmeWorkflowID  = 15
+	acmeStepID      = 16
+
+	// Globex's farm child records
+	globexDeviceID    = 21
+	globexChannelID   = 22
+	globexConditionID = 23
+	globexScheduleID  = 24
+	globexWorkflowID  = 25
+	globexStepID      = 26
+)
+
+// Answers Authorize with 200 OK once the real middleware authorizes the
+// request instead of calling the route handler
+type tenantProbe struct {
+	middleware.JsonWebTokenMiddleware
+}
+
+func (probe *tenantProbe) Authorize(permission string) func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	authorize := probe.JsonWebTokenMiddleware.Authorize(permission)
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		authorize(w, r, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+}
+
+type tenantTestCA struct {
+	certStore ca.CertificateStore
+	ca.CertificateAuthority
+}
+
+func (authority *tenantTestCA) CertStore() ca.CertificateStore {
+	return authority.certStore
+}
+
+type tenantTestCertStore struct {
+	key *rsa.PrivateKey
+	ca.CertificateStore
+}
+
+func (certStore *tenantTestCertStore) PrivKey(cn string) (*rsa.PrivateKey, error) {
+	return certStore.key, nil
+}
+
+type tenantTestUserDAO struct {
+	users map[uint64]*config.UserStruct
+	dao.UserDAO
+}
+
+func (userDAO *tenantTestUserDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.UserStruct, error) {
+	if user, ok := userDAO.users[id]; ok {
+		return user, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+type tenantTestOrgDAO struct {
+	orgs map[uint64]*config.OrganizationStruct
+	dao.OrganizationDAO
+}
+
+func (orgDAO *tenantTestOrgDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.OrganizationStruct, error) {
+	if org, ok := orgDAO.orgs[id]; ok {
+		return org, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+func (orgDAO *tenantTestOrgDAO) GetUsers(id uint64) ([]*config.UserStruct, error) {
+	if org, ok := orgDAO.orgs[id]; ok {
+		return org.GetUsers(), nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+type tenantTestFarmDAO struct {
+	farms map[uint64]*config.FarmStruct
+	dao.FarmDAO
+}
+
+func (farmDAO *tenantTestFarmDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*config.FarmStruct, error) {
+	if farm, ok := farmDAO.farms[id]; ok {
+		return farm, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+type tenantTestLogin struct {
+	user  model.User
+	orgs  []config.Organization
+	farms []config.Farm
+}
+
+// Logs the test users in without credentials; everything else is handled
+// by the real user service
+type tenantTestUserService struct {
+	logins map[string]tenantTestLogin
+	service.UserServicer
+}
+
+func (userService *tenantTestUserService) Login(userCredentials *service.UserCredentials) (model.User,
+	[]config.Organization, []config.Farm, error) {
+
+	login, ok := userService.logins[userCredentials.Email]
+	if !ok {
+		return nil, nil, nil, service.ErrInvalidCredentials
+	}
+	return login.user, login.orgs, login.farms, nil
+}
+
+type tenantTestFarmService struct {
+	farm *config.FarmStruct
+	service.FarmServicer
+}
+
+func (farmService *tenantTestFarmService) GetConfig() config.Farm {
+	return farmService.farm
+}
+
+func (farmService *tenantTestFarmService) SetConfig(farmConfig config.Farm) error {
+	farmService.farm = farmConfig.(*config.FarmStruct)
+	return nil
+}
+
+func (farmService *tenantTestFarmService) SetDeviceConfig(session service.Session,
+	deviceConfig config.Device) error {
+
+	farmService.farm.SetDevice(deviceConfig.(*config.DeviceStruct))
+	return nil
+}
+
+// Records the farm child records the services delete from the datastore
+type tenantTestDeletions struct {
+	ids []uint64
+}
+
+type tenantTestConditionDAO struct {
+	*tenantTestDeletions
+	dao.ConditionDAO
+}
+
+func (conditionDAO *tenantTestConditionDAO) Delete(farmID, deviceID uint64,
+	condition *config.ConditionStruct) error {
+
+	conditionDAO.ids = append(conditionDAO.ids, condition.ID)
+	return nil
+}
+
+type tenantTestScheduleDAO struct {
+	*tenantTestDeletions
+	dao.ScheduleDAO
+}
+
+func (scheduleDAO *tenantTestScheduleDAO) Delete(farmID, deviceID uint64,
+	schedule *config.ScheduleStruct) error {
+
+	scheduleDAO.ids = append(scheduleDAO.ids, schedule.ID)
+	return nil
+}
+
+type tenantTestWorkflowDAO struct {
+	*tenantTestDeletions
+	dao.WorkflowDAO
+}
+
+func (workflowDAO *tenantTestWorkflowDAO) Delete(workflow *config.WorkflowStruct) error {
+	workflowDAO.ids = append(workflowDAO.ids, workflow.ID)
+	return nil
+}
+
+type tenantTestWorkflowStepDAO struct {
+	*tenantTestDeletions
+	dao.WorkflowStepDAO
+}
+
+func (workflowStepDAO *tenantTestWorkflowStepDAO) Delete(farmID uint64,
+	step *config.WorkflowStepStruct) error {
+
+	workflowStepDAO.ids = append(workflowStepDAO.ids, step.ID)
+	return nil
+}
+
+type tenantTestRoleService struct {
+	service.RoleServicer
+}
+
+// Returns the built-in roles with their default permission sets
+func (roleService *tenantTestRoleService) GetByName(name string, CONSISTENCY_LEVEL int) (config.Role, error) {
+	permissions, ok := common.DefaultRolePermissions[name]
+	if !ok {
+		return nil, datastore.ErrRecordNotFound
+	}
+	return &config.RoleStruct{Name: name, Permissions: strings.Join(permissions, ",")}, nil
+}
+
+type tenantTestRefreshTokenService struct {
+	service.RefreshTokenService
+}
+
+func (refreshTokenService *tenantTestRefreshTokenService) Issue(userID uint64,
+	remoteAddress string) (string, *entity.RefreshToken, error) {
+
+	return "refresh-token", &entity.RefreshToken{UserID: userID}, nil
+}
+
+type tenantTestAPIKeyDAO struct {
+	apiKeys map[uint64]*entity.APIKey
+	dao.APIKeyDAO
+}
+
+func (apiKeyDAO *tenantTestAPIKeyDAO) Save(apiKey *entity.APIKey) error {
+	if apiKey.ID == 0 {
+		apiKey.ID = uint64(len(apiKeyDAO.apiKeys) + 1)
+	}
+	stored := *apiKey
+	apiKeyDAO.apiKeys[apiKey.ID] = &stored
+	return nil
+}
+
+func (apiKeyDAO *tenantTestAPIKeyDAO) Get(id uint64, CONSISTENCY_LEVEL int) (*entity.APIKey, error) {
+	if apiKey, ok := apiKeyDAO.apiKeys[id]; ok {
+		persisted := *apiKey
+		return &persisted, nil
+	}
+	return nil, datastore.ErrRecordNotFound
+}
+
+// Counts the authentications made by the real API key service
+type tenantTestAPIKeyService struct {
+	authentications int
+	service.APIKeyService
+}
+
+func (apiKeyService *tenantTestAPIKeyService) Authenticate(token,
+	remoteAddress string) (*entity.APIKey, error) {
+
+	apiKeyService.authentications++
+	return apiKeyService.APIKeyService.Authenticate(token, remoteAddress)
+}
+
+type tenantTestRegistry struct {
+	userService   service.UserServicer
+	apiKeyService *tenantTestAPIKeyService
+	farmServices  map[uint64]service.FarmServicer
+	permissionTestRegistry
+}
+
+func (registry *tenantTestRegistry) GetUserService() service.UserServicer {
+	return registry.userService
+}
+
+func (registry *tenantTestRegistry) GetAPIKeyService() service.APIKeyService {
+	if registry.apiKeyService == nil {
+		return nil
+	}
+	return registry.apiKeyService
+}
+
+func (registry *tenantTestRegistry) GetFarmService(farmID uint64) service.FarmServicer {
+	if farmService, ok := registry.farmServices[farmID]; ok {
+		return farmService
+	}
+	return nil
+}
+
+func (registry *tenantTestRegistry) GetRoleService() service.RoleServicer {
+	return &tenantTestRoleService{}
+}
+
+func (registry *tenantTestRegistry) GetRefreshTokenService() service.RefreshTokenService {
+	return &tenantTestRefreshTokenService{}
+}
+
+func (registry *tenantTestRegistry) GetLicenseService() service.LicenseService {
+	return nil
+}
+
+func (registry *tenantTestRegistry) GetAuditService() service.AuditService {
+	return nil
+}
+
+// Creates the Acme and Globex tenants and returns the JWT service along with
+// a function that logs a test user in and returns their access token, and the
+// registry the JWT service authenticates users and API keys with. The
+// service is configured with the default role the application ships with.
+// Acme's admin is assigned the admin role within Acme, the newcomer hasn't
+// been assigned to any organizations or farms, and the default user
+// administers the system.
+func createTenantTestJWTService(t *testing.T) (rest.JsonWebTokenServicer,
+	func(email string) string, *tenantTestRegistry) {
+
+	logger := logging.MustGetLogger("tenant_test")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	_app := &app.App{
+		Logger:      logger,
+		Domain:      "cropdroid.local",
+		DefaultRole: common.DEFAULT_ROLE,
+		CA:          &tenantTestCA{certStore: &tenantTestCertStore{key: key}}}
+	idGenerator := util.NewIdGenerator("")
+
+	adminRole := &config.RoleStruct{
+		ID:   idGenerator.NewStringID(common.ROLE_ADMIN),
+		Name: common.ROLE_ADMIN}
+	user := func(id uint64, email string, roles ...*config.RoleStruct) *config.UserStruct {
+		return &config.UserStruct{ID: id, Email: email, Roles: roles}
+	}
+	acmeAdmin := user(acmeAdminID, "admin@acme.example.com", adminRole)
+	acmeUser := user(acmeUserID, "grower@acme.example.com", adminRole)
+	globexUser := user(globexUserID, "admin@globex.example.com", adminRole)
+	newcomer := user(newcomerID, "newcomer@example.com")
+	systemAdmin := user(idGenerator.NewStringID(common.DEFAULT_USER), common.DEFAULT_USER, adminRole)
+	farm := func(id, orgID uint64, name string, users ...*config.UserStruct) *config.FarmStruct {
+		farm := config.NewFarm()
+		farm.ID = id
+		farm.OrganizationID = orgID
+		farm.Name = name
+		farm.SetUsers(users)
+		return farm
+	}
+	acmeFarm := farm(acmeFarmID, acmeOrgID, "Acme Greenhouse", acmeAdmin, acmeUser)
+	globexFarm := farm(globexFarmID, globexOrgID, "Globex Greenhouse", globexUser)
+	addTenantTestRecords(acmeFarm, acmeDeviceID, acmeChannelID, acmeConditionID,
+		acmeScheduleID, acmeWorkflowID, acmeStepID)
+	addTenantTestRecords(globexFarm, globexDeviceID, globexChannelID, globexConditionID,
+		globexScheduleID, globexWorkflowID, globexStepID)
+	acme := &config.OrganizationStruct{ID: acmeOrgID, Name: "Acme Farms",
+		Farms: []*config.FarmStruct{acmeFarm}, Users: []*config.UserStruct{acmeAdmin, acmeUser}}
+	globex := &config.OrganizationStruct{ID: globexOrgID, Name: "Globex",
+		Farms: []*config.FarmStruct{globexFarm}, Users: []*config.UserStruct{globexUser}}
+
+	userDAO := &tenantTestUserDAO{users: map[uint64]*config.UserStruct{
+		acmeAdminID: acmeAdmin, acmeUserID: acmeUser, globexUserID: globexUser,
+		newcomerID: newcomer, systemAdmin.ID: systemAdmin}}
+	farmDAO := &tenantTestFarmDAO{farms: map[uint64]*config.FarmStruct{
+		acmeFarmID: acmeFarm, globexFarmID: globexFarm}}
+
+	registry := &tenantTestRegistry{
+		farmServices: map[uint64]service.FarmServicer{
+			acmeFarmID:   &tenantTestFarmService{farm: acmeFarm},
+			globexFarmID: &tenantTestFarmService{farm: globexFarm}}}
+	registry.apiKeyService = &tenantTestAPIKeyService{
+		APIKeyService: service.NewAPIKeyService(logger,
+			&tenantTestAPIKeyDAO{apiKeys: make(map[uint64]*entity.APIKey)},
+			userDAO, farmDAO, registry)}
+	userMapper := mapper.NewUserMapper()
+	registry.userService = &tenantTestUserService{
+		logins: map[string]tenantTestLogin{
+			acmeAdmin.GetEmail(): {
+				user:  userMapper.MapUserConfigToModel(acmeAdmin),
+				orgs:  []config.Organization{acme},
+				farms: []config.Farm{acmeFarm}},
+			newcomer.GetEmail(): {
+				user: userMapper.MapUserConfigToModel(newcomer)},
+			systemAdmin.GetEmail(): {
+				user: userMapper.MapUserConfigToModel(systemAdmin)}},
+		UserServicer: service.NewUserService(_app, userDAO,
+			&tenantTestOrgDAO{orgs: map[uint64]*config.OrganizationStruct{
+				acmeOrgID: acme, globexOrgID: globex}},
+			nil, nil, farmDAO, userMapper, nil, registry)}
+
+	defaultRole := &config.RoleStruct{
+		ID:   idGenerator.NewStringID(_app.DefaultRole),
+		Name: _app.DefaultRole}
+	jwtService, err := rest.CreateJsonWebTokenService(_app, idGenerator, defaultRole, nil, registry,
+		response.NewResponseWriter(logger, nil), 60)
+	assert.Nil(t, err)
+
+	login := func(email string) string {
+		credentials, _ := json.Marshal(service.UserCredentials{Email: email})
+		w := httptest.NewRecorder()
+		jwtService.GenerateToken(w, httptest.NewRequest("POST", "/api/v1/login", bytes.NewReader(credentials)))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var token viewmodel.JsonWebToken
+		assert.Nil(t, json.NewDecoder(w.Body).Decode(&token))
+		assert.NotEmpty(t, token.Value)
+		return token.Value
+	}
+	return jwtService, login, registry
+}
+
+// Gives the farm a device with a channel that has a condition and a
+// schedule, and a workflow with a single step
+func addTenantTestRecords(farm *config.FarmStruct, deviceID, channelID, conditionID,
+	scheduleID, workflowID, stepID uint64) {
+
+	channel := config.NewChannel()
+	channel.SetID(channelID)
+	channel.SetDeviceID(deviceID)
+	channel.SetConditions([]*config.ConditionStruct{
+		{ID: conditionID, ChannelID: channelID, Comparator: ">", Threshold: 80}})
+	channel.SetSchedule([]*config.ScheduleStruct{
+		{ID: scheduleID, ChannelID: channelID}})
+	device := config.NewDevice()
+	device.SetID(deviceID)
+	device.SetFarmID(farm.ID)
+	device.SetChannels([]*config.ChannelStruct{channel})
+	farm.SetDevices([]*config.DeviceStruct{device})
+
+	workflow := config.NewWorkflow()
+	workflow.SetID(workflowID)
+	workflow.SetFarmID(farm.ID)
+	workflow.SetSteps([]*config.WorkflowStepStruct{
+		{ID: stepID, WorkflowID: workflowID, DeviceID: deviceID, ChannelID: channelID}})
+	farm.SetWorkflows([]*config.WorkflowStruct{workflow})
+}
+
+// Registers the farm child record routes with the real JWT middleware and
+// services that record what they delete from the datastore instead of
+// deleting it, and returns a function that requests a route with an
+// access token and a JSON body
+func createTenantTestChildRouter(jwtService rest.JsonWebTokenServicer, deletions *tenantTestDeletions,
+	baseURI string) func(method, url, token string, body interface{}) int {
+
+	logger := logging.MustGetLogger("tenant_test")
+	_app := &app.App{Logger: logger}
+	httpWriter := response.NewResponseWriter(logger, nil)
+	baseFarmURI := baseURI + "/farms/{farmID}"
+
+	router := mux.NewRouter()
+	NewConditionRouter(mapper.NewConditionMapper(),
+		service.NewConditionService(logger, &tenantTestConditionDAO{tenantTestDeletions: deletions},
+			mapper.NewConditionMapper()),
+		jwtService, httpWriter).RegisterRoutes(router, baseFarmURI)
+	NewScheduleRouter(service.NewScheduleService(_app,
+		&tenantTestScheduleDAO{tenantTestDeletions: deletions}),
+		jwtService, httpWriter).RegisterRoutes(router, baseFarmURI)
+	NewWorkflowRouter(service.NewWorkflowService(_app,
+		&tenantTestWorkflowDAO{tenantTestDeletions: deletions}, mapper.NewWorkflowMapper()),
+		jwtService, httpWriter).RegisterRoutes(router, baseFarmURI)
+	NewWorkflowStepRouter(service.NewWorkflowStepService(_app,
+		&tenantTestWorkflowStepDAO{tenantTestDeletions: deletions}),
+		jwtService, httpWriter).RegisterRoutes(router, baseFarmURI)
+
+	return func(method, url, token string, body interface{}) int {
+		var payload bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&payload).Encode(body)
+		}
+		r := httptest.NewRequest(method, url, &payload)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+}
+
+// Registers every route with the real JWT middleware and returns a function
+// that requests a route with an access token, along with a function that
+// returns the permission a protected route requires
+func createTenantTestRouter(jwtService rest.JsonWebTokenServicer, baseURI string) (
+	*mux.Router, func(method, url, token string) int, func(method, endpoint string) (string, bool)) {
+
+	registry := &tenantTestRegistry{}
+	router := mux.NewRouter()
+	registerTestRoutes(router, &tenantProbe{jwtService}, registry, baseURI)
+	recorder := mux.NewRouter()
+	registerTestRoutes(recorder, &permissionRecorder{}, registry, baseURI)
+	public := publicEndpoints(baseURI)
+
+	request := func(method, url, token string) int {
+		r := httptest.NewRequest(method, url, nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+	requiredPermission := func(method, endpoint string) (string, bool) {
+		if public[endpoint] {
+			return "", false
+		}
+		w := httptest.NewRecorder()
+		recorder.ServeHTTP(w, httptest.NewRequest(method, tenantTestURL(endpoint, 1, 1, 1), nil))
+		return w.Header().Get(requiredPermissionHeader), true
+	}
+	return router, request, requiredPermission
+}
+
+// Calls fn with the method and path template of every registered route
+func walkTestRoutes(t *testing.T, router *mux.Router, fn func(method, endpoint string)) {
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		endpoint, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"GET"}
+		}
+		for _, method := range methods {
+			fn(method, endpoint)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+// Replaces the tenant path variables with the tenant's IDs and every
+// other path variable with 1
+func tenantTestURL(endpoint string, orgID, farmID, userID uint64) string {
+	url := strings.NewReplacer(
+		"{organizationID}", fmt.Sprint(orgID),
+		"{farmID}", fmt.Sprint(farmID),
+		"{userID}", fmt.Sprint(userID)).Replace(endpoint)
+	return regexp.MustCompile(`{[^}]+}`).ReplaceAllString(url, "1")
+}
+
+func TestCrossTenantAccess(t *testing.T) {
+	baseURI := "/api/v1"
+	jwtService, login, _ := createTenantTestJWTService(t)
+	router, request, requiredPermission := createTenantTestRouter(jwtService, baseURI)
+	token := login("admin@acme.example.com")
+
+	tenantVariable := regexp.MustCompile(`{(organizationID|farmID|userID)}`)
+	tested := 0
+	walkTestRoutes(t, router, func(method, endpoint string) {
+		permission, protected := requiredPermission(method, endpoint)
+		if !protected {
+			return
+		}
+		// Organization roles don't grant system administration
+		if permission == common.PERMISSION_SYSTEM_ADMIN {
+			assert.Equal(t, http.StatusForbidden,
+				request(method, tenantTestURL(endpoint, acmeOrgID, acmeFarmID, acmeUserID), token),
+				"%s %s", method, endpoint)
+			return
+		}
+		// Endpoints that don't name an organization, farm or user operate
+		// on the session user's own resources
+		if !tenantVariable.MatchString(endpoint) {
+			assert.Equal(t, http.StatusOK, request(method, tenantTestURL(endpoint, 0, 0, 0), token),
+				"%s %s", method, endpoint)
+			return
+		}
+		// The Acme admin can reach Acme's resources...
+		assert.Equal(t, http.StatusOK,
+			request(method, tenantTestURL(endpoint, acmeOrgID, acmeFarmID, acmeUserID), token),
+			"%s %s", method, endpoint)
+		// ...but not Globex's
+		assert.Equal(t, http.StatusForbidden,
+			request(method, tenantTestURL(endpoint, globexOrgID, globexFarmID, globexUserID), token),
+			"cross-tenant %s %s", method, endpoint)
+		tested++
+	})
+	assert.True(t, tested > 0)
+}
+
+func TestCrossTenantChildResources(t *testing.T) {
+	baseURI := "/api/v1"
+	jwtService, login, _ := createTenantTestJWTService(t)
+	deletions := &tenantTestDeletions{}
+	request := createTenantTestChildRouter(jwtService, deletions, baseURI)
+	token := login("admin@acme.example.com")
+	acmeFarmURI := fmt.Sprintf("%s/farms/%d", baseURI, acmeFarmID)
+
+	// Globex's records can't be deleted through Acme's farm...
+	for _, url := range []string{
+		fmt.Sprintf("%s/conditions/%d", acmeFarmURI, globexConditionID),
+		fmt.Sprintf("%s/schedule/%d", acmeFarmURI, globexScheduleID),
+		fmt.Sprintf("%s/workflows/%d", acmeFarmURI, globexWorkflowID),
+		fmt.Sprintf("%s/workflows/%d/steps/%d", acmeFarmURI, globexWorkflowID, globexStepID),
+		fmt.Sprintf("%s/workflows/%d/steps/%d", acmeFarmURI, acmeWorkflowID, globexStepID)} {
+
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodDelete, url, token, nil),
+			"cross-tenant DELETE %s", url)
+	}
+	// ...or overwritten through one of Acme's records
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPut, acmeFarmURI+"/conditions", token,
+		&config.ConditionStruct{ID: globexConditionID, ChannelID: acmeChannelID, Comparator: "<"}))
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPut,
+		fmt.Sprintf("%s/workflows/%d", acmeFarmURI, globexWorkflowID), token,
+		&config.WorkflowStruct{ID: globexWorkflowID, FarmID: globexFarmID, Name: "Acme"}))
+	assert.Empty(t, deletions.ids)
+
+	// Acme's own records can
+	assert.Equal(t, http.StatusOK, request(http.MethodPut, acmeFarmURI+"/conditions", token,
+		&config.ConditionStruct{ID: acmeConditionID, ChannelID: acmeChannelID, Comparator: "<"}))
+	for _, url := range []string{
+		fmt.Sprintf("%s/conditions/%d", acmeFarmURI, acmeConditionID),
+		fmt.Sprintf("%s/schedule/%d", acmeFarmURI, acmeScheduleID),
+		fmt.Sprintf("%s/workflows/%d/steps/%d", acmeFarmURI, acmeWorkflowID, acmeStepID),
+		fmt.Sprintf("%s/workflows/%d", acmeFarmURI, acmeWorkflowID)} {
+
+		assert.Equal(t, http.StatusOK, request(http.MethodDelete, url, token, nil), "DELETE %s", url)
+	}
+	assert.Equal(t, []uint64{acmeConditionID, acmeScheduleID, acmeStepID, acmeWorkflowID},
+		deletions.ids)
+}
+
+func TestAPIKeyAuthenticatedOnce(t *testing.T) {
+	baseURI := "/api/v1"
+	jwtService, _, registry := createTenantTestJWTService(t)
+
+	acmeAdmin := service.CreateSession(logging.MustGetLogger("tenant_test"), nil,
+		[]service.FarmClaim{{ID: acmeFarmID}}, nil, 0, 0, common.CONSISTENCY_LOCAL,
+		&model.UserStruct{ID: acmeAdminID, Email: "admin@acme.example.com",
+			Roles: []model.Role{&model.RoleStruct{Name: common.ROLE_ADMIN,
+				Permissions: []string{common.PERMISSION_FARM_READ}}}})
+	_, token, err := registry.apiKeyService.Create(acmeAdmin, service.APIKeyRequest{
+		ServiceAccount: "grafana",
+		Farms:          []uint64{acmeFarmID},
+		Permissions:    []string{common.PERMISSION_FARM_READ}})
+	assert.Nil(t, err)
+
+	// Validate authenticates the key; Authorize and the route handler
+	// create their sessions from the key Validate authenticated
+	router := mux.NewRouter()
+	router.Handle(baseURI+"/farms/{farmID}/probe", negroni.New(
+		negroni.HandlerFunc(jwtService.Validate),
+		negroni.HandlerFunc(jwtService.Authorize(common.PERMISSION_FARM_READ)),
+		negroni.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, err := jwtService.CreateSession(w, r)
+			assert.Nil(t, err)
+			assert.Equal(t, "grafana@"+common.SERVICE_ACCOUNT_DOMAIN, session.GetUser().GetEmail())
+			w.WriteHeader(http.StatusOK)
+		}))))
+	request := func(farmID uint64, token string) int {
+		r := httptest.NewRequest(http.MethodGet,
+			fmt.Sprintf("%s/farms/%d/probe", baseURI, farmID), nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(acmeFarmID, token))
+	assert.Equal(t, 1, registry.apiKeyService.authentications)
+
+	assert.Equal(t, http.StatusForbidden, request(globexFarmID, token))
+	assert.Equal(t, 2, registry.apiKeyService.authentications)
+
+	assert.Equal(t, http.StatusUnauthorized, request(acmeFarmID, token+"0"))
+	assert.Equal(t, 3, registry.apiKeyService.authentications)
+}
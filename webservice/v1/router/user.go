@@ -0,0 +1,79 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/rest"
+)
+
+type UserRouter struct {
+	middleware      middleware.JsonWebTokenMiddleware
+	userRestService rest.UserRestServicer
+	WebServiceRouter
+}
+
+// Creates a new web service user account router
+func NewUserRouter(
+	userService service.UserServicer,
+	middleware middleware.JsonWebTokenMiddleware,
+	httpWriter response.HttpWriter) WebServiceRouter {
+
+	return &UserRouter{
+		middleware: middleware,
+		userRestService: rest.NewUserRestService(
+			userService,
+			middleware,
+			httpWriter)}
+}
+
+// Registers all of the user account endpoints at the root of the API (/api/v1)
+func (userRouter *UserRouter) RegisterRoutes(router *mux.Router, baseURI string) []string {
+	return []string{
+		userRouter.disable(router, baseURI),
+		userRouter.enable(router, baseURI)}
+}
+
+// @Summary Disable user
+// @Description Disables a user account and revokes the user's refresh tokens
+// @Tags User
+// @Produce  json
+// @Param	userID	path	integer	true	"string valid"
+// @Success 200 {object} response.WebServiceResponse
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /users/{userID}/disable [post]
+// @Security JWT
+func (userRouter *UserRouter) disable(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/users/{userID}/disable", baseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(userRouter.middleware.Validate),
+		negroni.HandlerFunc(userRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(userRouter.userRestService.Disable)),
+	)).Methods("POST")
+	return endpoint
+}
+
+// @Summary Enable user
+// @Description Enables a disabled user account and unlocks the account if it was locked after too many failed logins
+// @Tags User
+// @Produce  json
+// @Param	userID	path	integer	true	"string valid"
+// @Success 200 {object} response.WebServiceResponse
+// @Failure 400 {object} response.WebServiceResponse
+// @Router /users/{userID}/enable [post]
+// @Security JWT
+func (userRouter *UserRouter) enable(router *mux.Router, baseURI string) string {
+	endpoint := fmt.Sprintf("%s/users/{userID}/enable", baseURI)
+	router.Handle(endpoint, negroni.New(
+		negroni.HandlerFunc(userRouter.middleware.Validate),
+		negroni.HandlerFunc(userRouter.middleware.Authorize(common.PERMISSION_USER_MANAGE)),
+		negroni.Wrap(http.HandlerFunc(userRouter.userRestService.Enable)),
+	)).Methods("POST")
+	return endpoint
+}
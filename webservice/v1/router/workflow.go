@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -58,8 +59,9 @@ func (workflowRouter *WorkflowRouter) view(router *mux.Router, workflowsBaseURI
 	endpoint := fmt.Sprintf("%s/view", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(workflowRouter.workflowRestService.View)),
-	))
+	)).Methods("GET")
 	return endpoint
 }
 
@@ -75,8 +77,9 @@ func (workflowRouter *WorkflowRouter) view(router *mux.Router, workflowsBaseURI
 func (workflowRouter *WorkflowRouter) workflows(router *mux.Router, workflowsBaseURI string) string {
 	router.Handle(workflowsBaseURI, negroni.New(
 		negroni.HandlerFunc(workflowRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(workflowRouter.workflowRestService.GetWorkflows)),
-	))
+	)).Methods("GET")
 	return workflowsBaseURI
 }
 
@@ -94,8 +97,9 @@ func (workflowRouter *WorkflowRouter) workflow(router *mux.Router, workflowsBase
 	endpoint := fmt.Sprintf("%s/{id}", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(workflowRouter.workflowRestService.GetWorkflow)),
-	))
+	)).Methods("GET")
 	return endpoint
 }
 
@@ -113,6 +117,7 @@ func (workflowRouter *WorkflowRouter) run(router *mux.Router, workflowsBaseURI s
 	endpoint := fmt.Sprintf("%s/{id}/run", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowRouter.middleware.Authorize(common.PERMISSION_WORKFLOW_RUN)),
 		negroni.Wrap(http.HandlerFunc(workflowRouter.workflowRestService.RunWorkflow)),
 	))
 	return endpoint
@@ -130,6 +135,7 @@ func (workflowRouter *WorkflowRouter) run(router *mux.Router, workflowsBaseURI s
 func (workflowRouter *WorkflowRouter) create(router *mux.Router, workflowsBaseURI string) string {
 	router.Handle(workflowsBaseURI, negroni.New(
 		negroni.HandlerFunc(workflowRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(workflowRouter.workflowRestService.Create)),
 	)).Methods("POST")
 	return workflowsBaseURI
@@ -149,6 +155,7 @@ func (workflowRouter *WorkflowRouter) update(router *mux.Router, workflowsBaseUR
 	endpoint := fmt.Sprintf("%s/{id}", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(workflowRouter.workflowRestService.Update)),
 	)).Methods("PUT")
 	return endpoint
@@ -168,6 +175,7 @@ func (workflowRouter *WorkflowRouter) delete(router *mux.Router, workflowsBaseUR
 	endpoint := fmt.Sprintf("%s/{id}", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(workflowRouter.workflowRestService.Delete)),
 	)).Methods("DELETE")
 	return endpoint
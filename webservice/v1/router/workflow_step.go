@@ -6,6 +6,7 @@ import (
 
 	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
+	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -57,8 +58,9 @@ func (workflowStepRouter *WorkflowStepRouter) steps(router *mux.Router, workflow
 	endpoint := fmt.Sprintf("%s/{workflowID}/steps", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowStepRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowStepRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(workflowStepRouter.workflowStepRestService.GetSteps)),
-	))
+	)).Methods("GET")
 	return endpoint
 }
 
@@ -77,8 +79,9 @@ func (workflowStepRouter *WorkflowStepRouter) step(router *mux.Router, workflows
 	endpoint := fmt.Sprintf("%s/{workflowID}/steps/{stepID}", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowStepRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowStepRouter.middleware.Authorize(common.PERMISSION_FARM_READ)),
 		negroni.Wrap(http.HandlerFunc(workflowStepRouter.workflowStepRestService.GetStep)),
-	))
+	)).Methods("GET")
 	return endpoint
 }
 
@@ -97,6 +100,7 @@ func (workflowStepRouter *WorkflowStepRouter) create(router *mux.Router, workflo
 	endpoint := fmt.Sprintf("%s/{workflowID}/steps", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowStepRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowStepRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(workflowStepRouter.workflowStepRestService.Create)),
 	)).Methods("POST")
 	return endpoint
@@ -118,6 +122,7 @@ func (workflowStepRouter *WorkflowStepRouter) update(router *mux.Router, workflo
 	endpoint := fmt.Sprintf("%s/{workflowID}/steps/{stepID}", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowStepRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowStepRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(workflowStepRouter.workflowStepRestService.Update)),
 	)).Methods("PUT")
 	return endpoint
@@ -139,6 +144,7 @@ func (workflowStepRouter *WorkflowStepRouter) delete(router *mux.Router, workflo
 	endpoint := fmt.Sprintf("%s/{workflowID}/steps/{stepID}", workflowsBaseURI)
 	router.Handle(endpoint, negroni.New(
 		negroni.HandlerFunc(workflowStepRouter.middleware.Validate),
+		negroni.HandlerFunc(workflowStepRouter.middleware.Authorize(common.PERMISSION_CONFIG_WRITE)),
 		negroni.Wrap(http.HandlerFunc(workflowStepRouter.workflowStepRestService.Delete)),
 	)).Methods("DELETE")
 	return endpoint
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	logging "github.com/op/go-logging"
+)
+
+// EventLogSubscription is sent by event log clients to choose which
+// entries they receive. Empty lists match every device or event type.
+type EventLogSubscription struct {
+	DeviceIDs  []uint64 `json:"devices"`
+	EventTypes []string `json:"types"`
+}
+
+type EventLogClient struct {
+	logger       *logging.Logger
+	hub          *EventLogHub
+	conn         *websocket.Conn
+	send         chan *entity.EventLog
+	user         model.User
+	mutex        sync.RWMutex
+	subscription EventLogSubscription
+}
+
+func (c *EventLogClient) disconnect() {
+	c.conn.Close()
+}
+
+// Replaces the client's event log subscription
+func (c *EventLogClient) subscribe(subscription EventLogSubscription) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.subscription = subscription
+}
+
+// Returns true if the event matches the client's subscription
+func (c *EventLogClient) subscribed(event *entity.EventLog) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if len(c.subscription.DeviceIDs) > 0 {
+		matched := false
+		for _, deviceID := range c.subscription.DeviceIDs {
+			if deviceID == event.DeviceID {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(c.subscription.EventTypes) > 0 {
+		for _, eventType := range c.subscription.EventTypes {
+			if eventType == event.EventType {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Reads subscription changes sent by the client
+func (c *EventLogClient) readPump() {
+	defer func() {
+		c.hub.unregister <- c
+		c.conn.Close()
+	}()
+	c.conn.SetReadLimit(maxMessageSize)
+	for {
+		var subscription EventLogSubscription
+		err := c.conn.ReadJSON(&subscription)
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway) {
+				c.logger.Errorf("[EventLogClient.readPump] Error: %s", err.Error())
+			}
+			break
+		}
+		c.logger.Debugf("[EventLogClient.readPump] Subscription: %+v", subscription)
+		c.subscribe(subscription)
+	}
+}
+
+func (c *EventLogClient) writePump() {
+	defer func() {
+		c.conn.Close()
+	}()
+	for {
+		message, ok := <-c.send
+		if !ok {
+			// The hub closed the channel.
+			c.logger.Warning("[EventLogClient.writePump] hub closed the channel")
+			return
+		}
+		if err := c.conn.WriteJSON(message); err != nil {
+			c.logger.Errorf("[EventLogClient.writePump] Error: %s", err.Error())
+			return
+		}
+	}
+}
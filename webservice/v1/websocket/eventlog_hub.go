@@ -0,0 +1,80 @@
+package websocket
+
+import (
+	"github.com/jeremyhahn/go-cropdroid/service"
+	logging "github.com/op/go-logging"
+)
+
+// EventLogHub streams a farm's new event log entries to the
+// connected clients whose subscription matches the entry.
+type EventLogHub struct {
+	logger          *logging.Logger
+	clients         map[*EventLogClient]bool
+	register        chan *EventLogClient
+	unregister      chan *EventLogClient
+	eventLogService service.EventLogServicer
+}
+
+func NewEventLogHub(
+	logger *logging.Logger,
+	eventLogService service.EventLogServicer) *EventLogHub {
+
+	return &EventLogHub{
+		logger:          logger,
+		register:        make(chan *EventLogClient),
+		unregister:      make(chan *EventLogClient),
+		clients:         make(map[*EventLogClient]bool),
+		eventLogService: eventLogService}
+}
+
+func (h *EventLogHub) Run() {
+
+	// Discard the entries buffered before the hub started; clients
+	// page through the event log for history
+	for drained := false; !drained; {
+		select {
+		case <-h.eventLogService.Watch():
+		default:
+			drained = true
+		}
+	}
+
+	for {
+		select {
+		case client := <-h.register:
+			h.clients[client] = true
+			h.logger.Debugf("[EventLogHub.Run] Registering new client: address=%s, user=%s. %d clients connected to event log hub %d",
+				client.conn.RemoteAddr(), client.user.GetEmail(), len(h.clients), h.eventLogService.GetFarmID())
+
+		case client := <-h.unregister:
+			if _, ok := h.clients[client]; ok {
+				h.logger.Debugf("[EventLogHub.Run] Unregistering client address=%s, user=%s. %d clients connected to the event log hub.",
+					client.conn.RemoteAddr(), client.user.GetEmail(), len(h.clients))
+				client.disconnect()
+				delete(h.clients, client)
+				close(client.send)
+			}
+
+		case event := <-h.eventLogService.Watch():
+			if event.FarmID != h.eventLogService.GetFarmID() {
+				h.logger.Warningf("[EventLogHub.Run] Discarding event for farm.id=%d on event log hub %d",
+					event.FarmID, h.eventLogService.GetFarmID())
+				continue
+			}
+			for client := range h.clients {
+				if !client.subscribed(event) {
+					continue
+				}
+				select {
+				case client.send <- event:
+					h.logger.Debugf("[EventLogHub.Run] Broadcasting event for farm.id=%d, type=%s, device.id=%d",
+						h.eventLogService.GetFarmID(), event.EventType, event.DeviceID)
+				default:
+					h.logger.Errorf("[EventLogHub.Run] Unable to send event to client: %s", client.conn.RemoteAddr())
+					close(client.send)
+					delete(h.clients, client)
+				}
+			}
+		}
+	}
+}
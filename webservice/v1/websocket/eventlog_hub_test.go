@@ -0,0 +1,146 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/model"
+	"github.com/jeremyhahn/go-cropdroid/service"
+	logging "github.com/op/go-logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEventLogService struct {
+	farmID uint64
+	events chan *entity.EventLog
+	service.EventLogServicer
+}
+
+func (eventLog *fakeEventLogService) GetFarmID() uint64 {
+	return eventLog.farmID
+}
+
+func (eventLog *fakeEventLogService) Watch() <-chan *entity.EventLog {
+	return eventLog.events
+}
+
+// Returns the server side of a live websocket connection
+func createTestConn(t *testing.T) *websocket.Conn {
+	conns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		assert.Nil(t, err)
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+	client, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err)
+	t.Cleanup(func() { client.Close() })
+	return <-conns
+}
+
+func createTestEventLogClient(t *testing.T, hub *EventLogHub,
+	subscription EventLogSubscription) *EventLogClient {
+
+	user := model.NewUser()
+	user.SetEmail("user@test.com")
+	return &EventLogClient{
+		logger:       hub.logger,
+		hub:          hub,
+		conn:         createTestConn(t),
+		send:         make(chan *entity.EventLog, common.BUFFERED_CHANNEL_SIZE),
+		user:         user,
+		subscription: subscription}
+}
+
+func receiveEvent(client *EventLogClient) *entity.EventLog {
+	select {
+	case event := <-client.send:
+		return event
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+func TestEventLogHubDelivery(t *testing.T) {
+	eventLogService := &fakeEventLogService{
+		farmID: 1,
+		events: make(chan *entity.EventLog, common.BUFFERED_CHANNEL_SIZE)}
+
+	// Entries published before the hub starts aren't replayed
+	eventLogService.events <- &entity.EventLog{FarmID: 1, DeviceID: 10, Message: "stale"}
+
+	hub := NewEventLogHub(logging.MustGetLogger("eventlog_hub_test"), eventLogService)
+	go hub.Run()
+
+	all := createTestEventLogClient(t, hub, EventLogSubscription{})
+	device := createTestEventLogClient(t, hub, EventLogSubscription{
+		DeviceIDs: []uint64{11}})
+	eventType := createTestEventLogClient(t, hub, EventLogSubscription{
+		EventTypes: []string{common.EVENT_TYPE_SWITCH}})
+	hub.register <- all
+	hub.register <- device
+	hub.register <- eventType
+
+	eventLogService.events <- &entity.EventLog{FarmID: 1, DeviceID: 10,
+		EventType: common.EVENT_TYPE_POLL, Message: "timeout"}
+	eventLogService.events <- &entity.EventLog{FarmID: 1, DeviceID: 11,
+		EventType: common.EVENT_TYPE_SWITCH, Message: "switching on"}
+
+	event := receiveEvent(all)
+	assert.NotNil(t, event)
+	assert.Equal(t, "timeout", event.Message)
+	event = receiveEvent(all)
+	assert.NotNil(t, event)
+	assert.Equal(t, "switching on", event.Message)
+
+	event = receiveEvent(device)
+	assert.NotNil(t, event)
+	assert.Equal(t, uint64(11), event.DeviceID)
+
+	event = receiveEvent(eventType)
+	assert.NotNil(t, event)
+	assert.Equal(t, common.EVENT_TYPE_SWITCH, event.EventType)
+
+	assert.Empty(t, all.send)
+	assert.Empty(t, device.send)
+	assert.Empty(t, eventType.send)
+
+	// Unregistered clients stop receiving entries
+	hub.unregister <- all
+	eventLogService.events <- &entity.EventLog{FarmID: 1, DeviceID: 11,
+		EventType: common.EVENT_TYPE_SWITCH, Message: "switching off"}
+	event = receiveEvent(device)
+	assert.NotNil(t, event)
+	assert.Equal(t, "switching off", event.Message)
+	_, open := <-all.send
+	assert.False(t, open)
+}
+
+func TestEventLogHubFarmFilter(t *testing.T) {
+	eventLogService := &fakeEventLogService{
+		farmID: 1,
+		events: make(chan *entity.EventLog, common.BUFFERED_CHANNEL_SIZE)}
+
+	hub := NewEventLogHub(logging.MustGetLogger("eventlog_hub_test"), eventLogService)
+	go hub.Run()
+
+	client := createTestEventLogClient(t, hub, EventLogSubscription{})
+	hub.register <- client
+
+	eventLogService.events <- &entity.EventLog{FarmID: 2, DeviceID: 10, Message: "other farm"}
+	eventLogService.events <- &entity.EventLog{FarmID: 1, DeviceID: 10, Message: "this farm"}
+
+	event := receiveEvent(client)
+	assert.NotNil(t, event)
+	assert.Equal(t, uint64(1), event.FarmID)
+	assert.Equal(t, "this farm", event.Message)
+	assert.Empty(t, client.send)
+}
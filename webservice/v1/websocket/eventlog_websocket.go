@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/jeremyhahn/go-cropdroid/common"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
+	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
+	logging "github.com/op/go-logging"
+)
+
+type EventLogWebSocket struct {
+	logger         *logging.Logger
+	hub            *EventLogHub
+	middleware     middleware.JsonWebTokenMiddleware
+	responseWriter response.HttpWriter
+	WebSocket
+}
+
+func NewEventLogWebSocket(
+	logger *logging.Logger,
+	hub *EventLogHub,
+	middleware middleware.JsonWebTokenMiddleware,
+	responseWriter response.HttpWriter) *EventLogWebSocket {
+
+	return &EventLogWebSocket{
+		logger:         logger,
+		hub:            hub,
+		middleware:     middleware,
+		responseWriter: responseWriter}
+}
+
+// Upgrades the HTTP connection to a websocket and starts streaming new farm event
+// log entries. The initial subscription is parsed from the optional comma separated
+// "device" and "type" query parameters; clients may replace it at any time by sending
+// an EventLogSubscription message.
+func (eventLogHandler *EventLogWebSocket) OnConnect(w http.ResponseWriter, r *http.Request) {
+	subscription, err := parseEventLogSubscription(r)
+	if err != nil {
+		eventLogHandler.responseWriter.Error400(w, r, err)
+		return
+	}
+
+	session, err := eventLogHandler.middleware.CreateSession(w, r)
+	if err != nil {
+		eventLogHandler.logger.Errorf("[EventLogWebSocket.OnConnect] Error: Unable to create JsonWebTokenService session: %s", err)
+		eventLogHandler.responseWriter.Error400(w, r, err)
+		return
+	}
+	defer session.Close()
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		}}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		eventLogHandler.logger.Error(err.Error())
+		return
+	}
+
+	eventLogHandler.logger.Debug("[EventLogWebSocket.OnConnect] Accepting connection from ", conn.RemoteAddr())
+
+	client := &EventLogClient{
+		logger:       eventLogHandler.logger,
+		hub:          eventLogHandler.hub,
+		conn:         conn,
+		send:         make(chan *entity.EventLog, common.BUFFERED_CHANNEL_SIZE),
+		user:         session.GetUser(),
+		subscription: subscription}
+
+	client.hub.register <- client
+	go client.writePump()
+	go client.readPump()
+}
+
+func parseEventLogSubscription(r *http.Request) (EventLogSubscription, error) {
+	var subscription EventLogSubscription
+	values := r.URL.Query()
+	if devices := values.Get("device"); devices != "" {
+		for _, device := range strings.Split(devices, ",") {
+			deviceID, err := strconv.ParseUint(strings.TrimSpace(device), 10, 64)
+			if err != nil {
+				return subscription, err
+			}
+			subscription.DeviceIDs = append(subscription.DeviceIDs, deviceID)
+		}
+	}
+	if types := values.Get("type"); types != "" {
+		for _, eventType := range strings.Split(types, ",") {
+			subscription.EventTypes = append(subscription.EventTypes, strings.TrimSpace(eventType))
+		}
+	}
+	return subscription, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/model"
 	"github.com/jeremyhahn/go-cropdroid/state"
 	logging "github.com/op/go-logging"
@@ -18,6 +19,7 @@ type FarmClient struct {
 	state            chan state.FarmStateMap
 	deviceState      chan map[string]state.DeviceStateMap
 	deviceStateDelta chan map[string]state.DeviceStateDeltaMap
+	alarm            chan *entity.Alarm
 	user             model.User
 }
 
@@ -144,6 +146,24 @@ func (c *FarmClient) writePump() {
 			for i := 0; i < n; i++ {
 				c.conn.WriteJSON(<-c.deviceStateDelta)
 			}
+
+		case message, ok := <-c.alarm:
+			if !ok {
+				// The hub closed the channel.
+				c.logger.Warning("[FarmClient.writePump] hub closed the channel")
+				c.hub.unregister <- c
+				return
+			}
+			err := c.conn.WriteJSON(message)
+			if err != nil {
+				c.logger.Errorf("[FarmClient.writePump] Error: %s", err.Error())
+				return
+			}
+			// Add queued messages
+			n := len(c.alarm)
+			for i := 0; i < n; i++ {
+				c.conn.WriteJSON(<-c.alarm)
+			}
 		}
 
 	}
@@ -36,7 +36,7 @@ func (h *FarmHub) Run() {
 			h.logger.Debugf("[FarmHub.Run] Registering new client: address=%s, user=%s. %d clients connected to farm hub %d",
 				client.conn.RemoteAddr(), client.getUser().GetEmail(), len(h.clients), h.farmService.GetFarmID())
 			//h.logger.Debugf("Sending config: %s", client.session.GetFarmService().GetConfig())
-			client.send <- h.farmService.GetConfig()
+			client.send <- h.farmService.GetConfig().Redact()
 
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
@@ -50,7 +50,7 @@ func (h *FarmHub) Run() {
 		case farmConfig := <-h.farmService.WatchConfig():
 			for client := range h.clients {
 				select {
-				case client.send <- farmConfig:
+				case client.send <- farmConfig.Redact():
 					h.logger.Errorf("[FarmHub.Run] Broadcasting configuration update for farm.id=%d, farm.name=%s\n",
 						farmConfig.Identifier(), farmConfig.GetName())
 				default:
@@ -103,6 +103,19 @@ func (h *FarmHub) Run() {
 					delete(h.clients, client)
 				}
 			}
+
+		case alarm := <-h.farmService.WatchAlarms():
+			for client := range h.clients {
+				select {
+				case client.alarm <- alarm:
+					h.logger.Debugf("[FarmHub.Run] Broadcasting alarm update for farm.id=%d, alarm.id=%d, state=%s",
+						h.farmService.GetFarmID(), alarm.ID, alarm.State)
+				default:
+					h.logger.Errorf("[FarmHub.Run] Unable to send alarm update to client: %s", client.conn.RemoteAddr())
+					close(client.send)
+					delete(h.clients, client)
+				}
+			}
 		}
 
 	}
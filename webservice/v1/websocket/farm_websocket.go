@@ -6,6 +6,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/config"
+	"github.com/jeremyhahn/go-cropdroid/datastore/entity"
 	"github.com/jeremyhahn/go-cropdroid/state"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/response"
@@ -86,6 +87,7 @@ func (farmHandler *FarmWebSocket) OnConnect(w http.ResponseWriter, r *http.Reque
 		state:            make(chan state.FarmStateMap, common.BUFFERED_CHANNEL_SIZE),
 		deviceState:      make(chan map[string]state.DeviceStateMap, common.BUFFERED_CHANNEL_SIZE),
 		deviceStateDelta: make(chan map[string]state.DeviceStateDeltaMap, common.BUFFERED_CHANNEL_SIZE),
+		alarm:            make(chan *entity.Alarm, common.BUFFERED_CHANNEL_SIZE),
 		user:             session.GetUser()}
 
 	client.hub.register <- client
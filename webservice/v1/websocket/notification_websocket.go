@@ -2,10 +2,12 @@ package websocket
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/websocket"
 	"github.com/jeremyhahn/go-cropdroid/common"
 	"github.com/jeremyhahn/go-cropdroid/model"
+	"github.com/jeremyhahn/go-cropdroid/service"
 	"github.com/jeremyhahn/go-cropdroid/webservice/v1/middleware"
 	logging "github.com/op/go-logging"
 )
@@ -13,6 +15,7 @@ import (
 type NotificationWebSocket struct {
 	logger            *logging.Logger
 	hub               *NotificationHub
+	inboxService      service.InboxService
 	middlewareService middleware.JsonWebTokenMiddleware
 	WebSocket
 }
@@ -20,11 +23,13 @@ type NotificationWebSocket struct {
 func NewNotificationWebSocket(
 	logger *logging.Logger,
 	hub *NotificationHub,
+	inboxService service.InboxService,
 	middlewareService middleware.JsonWebTokenMiddleware) *NotificationWebSocket {
 
 	return &NotificationWebSocket{
 		logger:            logger,
 		hub:               hub,
+		inboxService:      inboxService,
 		middlewareService: middlewareService}
 }
 
@@ -73,6 +78,29 @@ func (ph *NotificationWebSocket) OnConnect(w http.ResponseWriter, r *http.Reques
 		notificationService := service.NewNotificationService(session)
 	*/
 
+	// Replay the notifications persisted to the user's inbox since the
+	// last notification the client received, before any new notifications
+	// are sent by the write pump
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" && ph.inboxService != nil {
+		notificationID, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			ph.logger.Errorf("[NotificationHandler.OnConnect] Invalid cursor %s: %s", cursor, err)
+			conn.Close()
+			return
+		}
+		notifications, err := ph.inboxService.Replay(session, notificationID)
+		if err != nil {
+			ph.logger.Errorf("[NotificationHandler.OnConnect] Inbox replay error: %s", err)
+		}
+		for _, notification := range notifications {
+			if err := conn.WriteJSON(notification); err != nil {
+				ph.logger.Errorf("[NotificationHandler.OnConnect] Inbox replay error: %s", err)
+				conn.Close()
+				return
+			}
+		}
+	}
+
 	client := &NotificationClient{
 		hub:     ph.hub,
 		conn:    conn,
@@ -97,6 +97,6 @@ func (server ClusterWebServerV1) buildRoutes() {
 	server.WebServerV1.routerMutex.Lock()
 	server.WebServerV1.router = muxRouter
 	server.WebServerV1.endpointList = endpointList
-	server.WebServerV1.httpServer.Handler = server.WebServerV1.router
+	server.WebServerV1.httpServer.Handler = server.WebServerV1.handler()
 	server.WebServerV1.routerMutex.Unlock()
 }
@@ -33,6 +33,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/codegangsta/negroni"
 	"github.com/gorilla/mux"
 	"github.com/jeremyhahn/go-cropdroid/app"
 	"github.com/jeremyhahn/go-cropdroid/common"
@@ -65,6 +66,7 @@ type WebServerV1 struct {
 	serviceRegistry           service.ServiceRegistry
 	restServiceRegistry       rest.RestServiceRegistry
 	middleware                middleware.JsonWebTokenMiddleware
+	rateLimiter               *middleware.RateLimiter
 	systemEventLogService     service.EventLogServicer
 	notificationService       service.NotificationServicer
 	farmWebSocketHandler      rest.FarmWebSocketRestServicer
@@ -72,6 +74,8 @@ type WebServerV1 struct {
 	farmHubs                  map[uint64]*websocket.FarmHub
 	notificationHubs          map[uint64]*websocket.NotificationHub
 	notificationHubMutex      *sync.RWMutex
+	eventLogHubs              map[uint64]*websocket.EventLogHub
+	eventLogHubMutex          *sync.RWMutex
 	farmTickerProvisionerChan chan uint64
 	closeChan                 chan bool
 }
@@ -86,7 +90,7 @@ func NewWebServerV1(
 	webserver := &WebServerV1{
 		app:                       app,
 		baseURI:                   "/api/v1",
-		eventType:                 "WebServer",
+		eventType:                 common.EVENT_TYPE_WEBSERVER,
 		endpointList:              make([]string, 0),
 		routerMutex:               &sync.Mutex{},
 		router:                    mux.NewRouter().StrictSlash(true),
@@ -100,14 +104,22 @@ func NewWebServerV1(
 		farmHubs:                  make(map[uint64]*websocket.FarmHub),
 		notificationHubs:          make(map[uint64]*websocket.NotificationHub),
 		notificationHubMutex:      &sync.RWMutex{},
+		eventLogHubs:              make(map[uint64]*websocket.EventLogHub),
+		eventLogHubMutex:          &sync.RWMutex{},
 		farmTickerProvisionerChan: farmTickerProvisionerChan,
 		closeChan:                 make(chan bool, 1)}
 
+	if app.RateLimit {
+		identifier, _ := webserver.middleware.(middleware.RequestIdentifier)
+		webserver.rateLimiter = middleware.NewRateLimiter(app.Logger,
+			webserver.baseURI, middleware.DefaultRateLimitRules(), identifier)
+	}
+
 	webserver.httpServer = &http.Server{
 		ReadTimeout:  common.HTTP_SERVER_READ_TIMEOUT,
 		WriteTimeout: common.HTTP_SERVER_WRITE_TIMEOUT,
 		IdleTimeout:  common.HTTP_SERVER_IDLE_TIMEOUT,
-		Handler:      webserver.router}
+		Handler:      webserver.handler()}
 
 	// Manages all farm websocket connection pools / hubs
 	webserver.farmWebSocketHandler = rest.NewFarmWebSocketRestService(
@@ -116,6 +128,8 @@ func NewWebServerV1(
 		webserver.farmHubMutex,
 		webserver.notificationHubs,
 		webserver.notificationHubMutex,
+		webserver.eventLogHubs,
+		webserver.eventLogHubMutex,
 		webserver.serviceRegistry,
 		webserver.middleware,
 		response.NewResponseWriter(app.Logger, nil))
@@ -143,8 +157,7 @@ func (server *WebServerV1) Run() {
 }
 
 func (server *WebServerV1) RunProvisionerConsumer() {
-	for {
-		farmID := <-server.farmTickerProvisionerChan
+	for farmID := range server.farmTickerProvisionerChan {
 		server.app.Logger.Warningf("Web services rebuilding routes for farm: %d", farmID)
 		server.buildRoutes()
 	}
@@ -284,10 +297,18 @@ func (server *WebServerV1) buildRoutes() {
 	server.routerMutex.Lock()
 	server.router = muxRouter
 	copy(server.endpointList, endpointList)
-	server.httpServer.Handler = server.router
+	server.httpServer.Handler = server.handler()
 	server.routerMutex.Unlock()
 }
 
+// Returns the router, throttled by the rate limiter when rate limiting is enabled
+func (server *WebServerV1) handler() http.Handler {
+	if server.rateLimiter == nil {
+		return server.router
+	}
+	return negroni.New(server.rateLimiter, negroni.Wrap(server.router))
+}
+
 // func (server *WebServer) walkRoutes() {
 // 	err := server.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
 // 		pathTemplate, err := route.GetPathTemplate()